@@ -3,6 +3,8 @@ module github.com/onlyboxes/onlyboxes/api
 go 1.24.0
 
 require (
+	github.com/HugoSmits86/nativewebp v1.2.0
+	golang.org/x/image v0.34.0
 	google.golang.org/grpc v1.79.1
 	google.golang.org/protobuf v1.36.10
 )
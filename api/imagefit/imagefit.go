@@ -0,0 +1,199 @@
+// Package imagefit scales and re-encodes images so they fit the size limits
+// a caller asks for. Both workers use it for image results.
+package imagefit
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+)
+
+// Output formats accepted in Options.Format.
+const (
+	FormatPNG  = "png"
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+)
+
+const (
+	jpegQuality   = 80
+	maxAttempts   = 8
+	shrinkPercent = 75
+	// maxPixels bounds the decoded size (about 256 MiB as RGBA) so a small
+	// file declaring huge dimensions cannot exhaust worker memory.
+	maxPixels = 64 * 1024 * 1024
+)
+
+var (
+	// ErrTooLarge is returned when the image cannot be made to fit
+	// MaxBytes, or is too large to decode.
+	ErrTooLarge = errors.New("image exceeds size limit")
+	// ErrUndecodable is returned when a Format is requested for data that
+	// cannot be decoded as an image.
+	ErrUndecodable = errors.New("image format cannot be decoded")
+)
+
+// Options are the limits for Fit; zero values are not enforced.
+type Options struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxBytes  int
+	// Format forces the output encoding (png, jpeg or webp); empty keeps the
+	// source encoding when possible.
+	Format string
+}
+
+// Result is the fitted image and its dimensions before and after.
+type Result struct {
+	Blob           []byte
+	MIMEType       string
+	Width          int
+	Height         int
+	OriginalWidth  int
+	OriginalHeight int
+	// Reencoded is false when Blob is the untouched input.
+	Reencoded bool
+}
+
+// NormalizeFormat maps a requested output format to its canonical
+// name. The empty string is valid and means "keep".
+func NormalizeFormat(raw string) (string, bool) {
+	switch strings.TrimSpace(strings.ToLower(raw)) {
+	case "":
+		return "", true
+	case FormatPNG:
+		return FormatPNG, true
+	case FormatJPEG, "jpg":
+		return FormatJPEG, true
+	case FormatWebP:
+		return FormatWebP, true
+	default:
+		return "", false
+	}
+}
+
+// Fit scales blob down to fit within MaxWidth x MaxHeight, keeping the
+// aspect ratio, and re-encodes it until it is at most MaxBytes. Zero limits
+// are not enforced. An image that already fits in the requested format is
+// returned unchanged; without a format PNG is tried before JPEG, and the
+// image keeps shrinking while nothing fits.
+func Fit(blob []byte, opts Options) (Result, error) {
+	fitsBytes := func(size int) bool {
+		return opts.MaxBytes <= 0 || size <= opts.MaxBytes
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(blob))
+	if err != nil {
+		if opts.Format != "" {
+			return Result{}, ErrUndecodable
+		}
+		// Not a format we can decode: pass it through untouched when small
+		// enough and let the caller judge the MIME type.
+		if !fitsBytes(len(blob)) {
+			return Result{}, ErrTooLarge
+		}
+		return Result{Blob: blob, MIMEType: detectMIME(blob)}, nil
+	}
+
+	result := Result{
+		Blob:           blob,
+		MIMEType:       "image/" + format,
+		Width:          config.Width,
+		Height:         config.Height,
+		OriginalWidth:  config.Width,
+		OriginalHeight: config.Height,
+	}
+	width, height := scaleToFit(config.Width, config.Height, opts.MaxWidth, opts.MaxHeight)
+	sameFormat := opts.Format == "" || opts.Format == format
+	if width == config.Width && height == config.Height && sameFormat && fitsBytes(len(blob)) {
+		return result, nil
+	}
+	if config.Width > 0 && config.Height > maxPixels/config.Width {
+		return Result{}, ErrTooLarge
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(blob))
+	if err != nil {
+		return Result{}, ErrUndecodable
+	}
+	formats := []string{FormatPNG, FormatJPEG}
+	if opts.Format != "" {
+		formats = []string{opts.Format}
+	}
+	for attempt := 0; attempt < maxAttempts && width > 0 && height > 0; attempt++ {
+		scaled := source
+		if width != config.Width || height != config.Height {
+			target := image.NewRGBA(image.Rect(0, 0, width, height))
+			draw.CatmullRom.Scale(target, target.Bounds(), source, source.Bounds(), draw.Src, nil)
+			scaled = target
+		}
+
+		for _, candidate := range formats {
+			encoded, err := encode(scaled, candidate)
+			if err != nil {
+				return Result{}, err
+			}
+			if fitsBytes(len(encoded)) {
+				result.Blob, result.MIMEType, result.Width, result.Height = encoded, "image/"+candidate, width, height
+				result.Reencoded = true
+				return result, nil
+			}
+		}
+
+		width = width * shrinkPercent / 100
+		height = height * shrinkPercent / 100
+	}
+	return Result{}, ErrTooLarge
+}
+
+func encode(img image.Image, format string) ([]byte, error) {
+	var encoded bytes.Buffer
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&encoded, img, &jpeg.Options{Quality: jpegQuality})
+	case FormatWebP:
+		err = nativewebp.Encode(&encoded, img, nil)
+	default:
+		err = png.Encode(&encoded, img)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// scaleToFit returns the largest size within maxWidth x maxHeight with the
+// aspect ratio of width x height. It never scales up.
+func scaleToFit(width int, height int, maxWidth int, maxHeight int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return width, height
+	}
+	scaledWidth, scaledHeight := width, height
+	if maxWidth > 0 && scaledWidth > maxWidth {
+		scaledHeight = max(scaledHeight*maxWidth/scaledWidth, 1)
+		scaledWidth = maxWidth
+	}
+	if maxHeight > 0 && scaledHeight > maxHeight {
+		scaledWidth = max(scaledWidth*maxHeight/scaledHeight, 1)
+		scaledHeight = maxHeight
+	}
+	return scaledWidth, scaledHeight
+}
+
+func detectMIME(blob []byte) string {
+	if len(blob) == 0 {
+		return "application/octet-stream"
+	}
+	detected, _, _ := strings.Cut(http.DetectContentType(blob), ";")
+	return strings.TrimSpace(detected)
+}
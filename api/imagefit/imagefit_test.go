@@ -0,0 +1,111 @@
+package imagefit
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func encodeTestPNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode test png failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestNormalizeFormat(t *testing.T) {
+	for raw, want := range map[string]string{"": "", " PNG ": FormatPNG, "jpg": FormatJPEG, "jpeg": FormatJPEG, "WebP": FormatWebP} {
+		if got, ok := NormalizeFormat(raw); !ok || got != want {
+			t.Fatalf("NormalizeFormat(%q) = %q, %v; want %q", raw, got, ok, want)
+		}
+	}
+	if _, ok := NormalizeFormat("tiff"); ok {
+		t.Fatalf("expected tiff to be rejected")
+	}
+}
+
+func TestFitScalesAndConverts(t *testing.T) {
+	source := encodeTestPNG(t, 400, 200)
+	for _, tc := range []struct {
+		format   string
+		wantMIME string
+	}{
+		{format: "", wantMIME: "image/png"},
+		{format: FormatJPEG, wantMIME: "image/jpeg"},
+		{format: FormatWebP, wantMIME: "image/webp"},
+	} {
+		result, err := Fit(source, Options{MaxWidth: 100, MaxHeight: 100, Format: tc.format})
+		if err != nil {
+			t.Fatalf("format %q: fit failed: %v", tc.format, err)
+		}
+		if result.MIMEType != tc.wantMIME || result.Width != 100 || result.Height != 50 || !result.Reencoded {
+			t.Fatalf("format %q: unexpected result mime=%s size=%dx%d", tc.format, result.MIMEType, result.Width, result.Height)
+		}
+		if result.OriginalWidth != 400 || result.OriginalHeight != 200 {
+			t.Fatalf("format %q: unexpected original size %dx%d", tc.format, result.OriginalWidth, result.OriginalHeight)
+		}
+		decoded, format, err := image.DecodeConfig(bytes.NewReader(result.Blob))
+		if err != nil || decoded.Width != 100 || "image/"+format != tc.wantMIME {
+			t.Fatalf("format %q: unexpected blob config %#v format=%s err=%v", tc.format, decoded, format, err)
+		}
+	}
+}
+
+func TestFitKeepsImageThatAlreadyFits(t *testing.T) {
+	source := encodeTestPNG(t, 40, 30)
+
+	result, err := Fit(source, Options{MaxWidth: 100, Format: FormatPNG})
+	if err != nil {
+		t.Fatalf("fit failed: %v", err)
+	}
+	if !bytes.Equal(result.Blob, source) || result.Reencoded || result.Width != 40 || result.Height != 30 {
+		t.Fatalf("expected unchanged image with dimensions, got %#v", result)
+	}
+}
+
+func TestFitShrinksToMaxBytes(t *testing.T) {
+	source := encodeTestPNG(t, 256, 256)
+
+	limit := len(source) / 2
+
+	result, err := Fit(source, Options{MaxBytes: limit})
+	if err != nil {
+		t.Fatalf("fit failed: %v", err)
+	}
+	if len(result.Blob) > limit || !result.Reencoded {
+		t.Fatalf("expected a re-encoded image within %d bytes, got %d bytes as %s", limit, len(result.Blob), result.MIMEType)
+	}
+
+	if _, err := Fit(source, Options{MaxBytes: 10}); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestFitHandlesDataThatIsNotAnImage(t *testing.T) {
+	text := []byte("plain text, not an image")
+
+	result, err := Fit(text, Options{MaxWidth: 10})
+	if err != nil {
+		t.Fatalf("fit failed: %v", err)
+	}
+	if !bytes.Equal(result.Blob, text) || result.MIMEType != "text/plain" {
+		t.Fatalf("expected pass-through, got mime=%s", result.MIMEType)
+	}
+	if _, err := Fit(text, Options{MaxBytes: 4}); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+	if _, err := Fit(text, Options{Format: FormatPNG}); !errors.Is(err, ErrUndecodable) {
+		t.Fatalf("expected ErrUndecodable, got %v", err)
+	}
+}
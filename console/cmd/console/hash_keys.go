@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+)
+
+// runHashKeysCommand prints how many tokens and worker credentials are
+// stored under each hash key. It is safe to run next to a live console: the
+// database is opened without startup recovery.
+func runHashKeysCommand(opts persistence.Options, out io.Writer) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts.SkipStartupRecovery = true
+	db, err := persistence.Open(ctx, opts)
+	if err != nil {
+		slog.Error("failed to open database", "error", err)
+		return 1
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	usage, err := db.HashKeyUsage(ctx)
+	if err != nil {
+		slog.Error("failed to count hash key usage", "error", err)
+		return 1
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "KEY ID\tSTATUS\tTOKENS\tMANAGEMENT TOKENS\tWORKER CREDENTIALS")
+	pending := int64(0)
+	for _, item := range usage {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%d\t%d\t%d\n", item.KeyID, item.Status, item.TrustedTokens, item.ManagementTokens, item.WorkerCredentials)
+		if item.Status != persistence.HashKeyStatusPrimary {
+			pending += item.TrustedTokens + item.ManagementTokens + item.WorkerCredentials
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		slog.Error("failed to write hash key usage", "error", err)
+		return 1
+	}
+	if pending > 0 {
+		_, _ = fmt.Fprintf(out, "\n%d hashes are not yet on the primary key; they are re-keyed on next use.\n", pending)
+	}
+	return 0
+}
@@ -14,23 +14,45 @@ import (
 	"github.com/onlyboxes/onlyboxes/console/internal/config"
 	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
 	"github.com/onlyboxes/onlyboxes/console/internal/httpapi"
+	"github.com/onlyboxes/onlyboxes/console/internal/oidc"
 	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
 	"github.com/onlyboxes/onlyboxes/console/internal/registry"
 	"google.golang.org/grpc"
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("invalid console configuration", "error", err)
+	}
 	slog.SetDefault(newLogger(cfg))
 
-	dbCtx, dbCancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer dbCancel()
-	db, err := persistence.Open(dbCtx, persistence.Options{
+	retiredHashKeys, err := persistence.ParseHashKeys(cfg.RetiredHashKeys)
+	if err != nil {
+		fatal("invalid CONSOLE_HASH_KEYS_RETIRED", "error", err)
+	}
+	dbOptions := persistence.Options{
 		Path:             cfg.DBPath,
 		BusyTimeoutMS:    cfg.DBBusyTimeoutMS,
 		HashKey:          cfg.HashKey,
+		HashKeyID:        cfg.HashKeyID,
+		RetiredHashKeys:  retiredHashKeys,
 		TaskRetentionDay: cfg.TaskRetentionDays,
-	})
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "hash-keys":
+			os.Exit(runHashKeysCommand(dbOptions, os.Stdout))
+		default:
+			fatal("unknown command", "command", os.Args[1])
+		}
+	}
+
+	dbCtx, dbCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer dbCancel()
+	db, err := persistence.Open(dbCtx, dbOptions)
 	if err != nil {
 		fatal("failed to initialize persistence", "error", err)
 	}
@@ -40,11 +62,26 @@ func main() {
 		}
 	}()
 
+	passwordPolicy := httpapi.PasswordPolicy{
+		HashAlgo:    cfg.PasswordHashAlgo,
+		MinLength:   cfg.PasswordMinLength,
+		HistorySize: cfg.PasswordHistory,
+	}
+	if cfg.PasswordBlocklist != "" {
+		blocklist, err := httpapi.LoadPasswordBlocklist(cfg.PasswordBlocklist)
+		if err != nil {
+			fatal("failed to load password blocklist", "path", cfg.PasswordBlocklist, "error", err)
+		}
+		passwordPolicy.Blocklist = blocklist
+		slog.Info("console password blocklist loaded", "entries", len(blocklist))
+	}
+
 	adminAccount, err := httpapi.InitializeAdminAccount(
 		context.Background(),
 		db.Queries,
 		cfg.DashboardUsername,
 		cfg.DashboardPassword,
+		passwordPolicy,
 	)
 	if err != nil {
 		fatal("failed to initialize admin account", "error", err)
@@ -83,6 +120,14 @@ func main() {
 	)
 	registryService.SetHasher(db.Hasher)
 	registryService.SetTaskRetention(time.Duration(cfg.TaskRetentionDays) * 24 * time.Hour)
+	policyEngine, err := policy.NewEngine(db.Queries, cfg.PolicyDefaultAction)
+	if err != nil {
+		fatal("failed to initialize command policy", "error", err)
+	}
+	registryService.SetCommandPolicy(policyEngine)
+	registryService.SetApprovalTimeout(cfg.ApprovalTimeout)
+	registryService.SetTaskQueueWait(cfg.TaskQueueWait)
+	registryService.SetTaskQueueStarvationThreshold(cfg.TaskQueueStarvation)
 	grpcSrv := grpcserver.NewServer(registryService)
 	httpHandler := httpapi.NewWorkerHandler(
 		store,
@@ -92,10 +137,52 @@ func main() {
 		registryService,
 		cfg.GRPCAddr,
 	)
+	httpHandler.SetCommandPolicy(policyEngine)
+	httpHandler.SetApprovals(registryService)
+	httpHandler.SetUsage(registryService)
+	httpHandler.SetScheduling(registryService)
 	consoleAuth, err := httpapi.NewConsoleAuth(db.Queries, cfg.EnableRegistration)
 	if err != nil {
 		fatal("failed to initialize console auth", "error", err)
 	}
+	if err := consoleAuth.SetPasswordPolicy(passwordPolicy); err != nil {
+		fatal("failed to configure password policy", "error", err)
+	}
+	if err := consoleAuth.SetAllowedOrigins(cfg.AllowedOrigins); err != nil {
+		fatal("failed to configure allowed origins", "error", err)
+	}
+	if err := consoleAuth.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal("failed to configure trusted proxies", "error", err)
+	}
+	if err := consoleAuth.SetTOTPEncryptionKey(cfg.TOTPEncryptionKey); err != nil {
+		fatal("failed to configure totp encryption", "error", err)
+	}
+	if cfg.TOTPEncryptionKey == "" {
+		slog.Warn("CONSOLE_TOTP_ENCRYPTION_KEY is not set, two-factor secrets are stored unencrypted")
+	}
+	consoleAuth.SetAuditLog(db)
+	if cfg.OIDCIssuer != "" {
+		oidcProvider, err := oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		})
+		if err != nil {
+			fatal("failed to initialize oidc provider", "error", err)
+		}
+		if err := consoleAuth.SetOIDC(httpapi.OIDCOptions{
+			Provider:      oidcProvider,
+			UsernameClaim: cfg.OIDCUsernameClaim,
+			GroupsClaim:   cfg.OIDCGroupsClaim,
+			AdminGroup:    cfg.OIDCAdminGroup,
+			AutoCreate:    cfg.OIDCAutoCreate,
+		}); err != nil {
+			fatal("failed to configure oidc login", "error", err)
+		}
+		slog.Info("console oidc login enabled", "issuer", oidcProvider.Issuer())
+	}
 	mcpAuth, err := httpapi.NewMCPAuthWithPersistence(db)
 	if err != nil {
 		fatal("failed to initialize mcp auth", "error", err)
@@ -111,7 +198,7 @@ func main() {
 	runCtx, cancelRun := context.WithCancel(context.Background())
 	defer cancelRun()
 	go startOfflinePruner(runCtx, store, cfg.OfflineTTL)
-	go startTaskPruner(runCtx, registryService)
+	go startTaskPruner(runCtx, registryService, store)
 
 	grpcListener, err := net.Listen("tcp", cfg.GRPCAddr)
 	if err != nil {
@@ -199,7 +286,7 @@ func stopGRPCWithTimeout(grpcSrv *grpc.Server, timeout time.Duration) {
 	}
 }
 
-func startTaskPruner(ctx context.Context, service *grpcserver.RegistryService) {
+func startTaskPruner(ctx context.Context, service *grpcserver.RegistryService, store *registry.Store) {
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
@@ -212,6 +299,15 @@ func startTaskPruner(ctx context.Context, service *grpcserver.RegistryService) {
 			if removed > 0 {
 				slog.Info("pruned expired tasks", "removed", removed)
 			}
+			if removed := store.PruneExpiredWorkerSysGrants(now); removed > 0 {
+				slog.Info("pruned expired worker grants", "removed", removed)
+			}
+			if removed := store.PruneExecUsage(now); removed > 0 {
+				slog.Info("pruned execution usage", "removed", removed)
+			}
+			if removed := store.PruneUsageRecords(now); removed > 0 {
+				slog.Info("pruned usage records", "removed", removed)
+			}
 		}
 	}
 }
@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE console_sessions (
+    session_id TEXT PRIMARY KEY,
+    token_hash TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    last_seen_at_unix_ms INTEGER NOT NULL,
+    expires_at_unix_ms INTEGER NOT NULL,
+    UNIQUE (token_hash),
+    FOREIGN KEY (account_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_console_sessions_account_created
+    ON console_sessions(account_id, created_at_unix_ms);
+
+CREATE INDEX idx_console_sessions_expires
+    ON console_sessions(expires_at_unix_ms);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_console_sessions_expires;
+DROP INDEX IF EXISTS idx_console_sessions_account_created;
+DROP TABLE IF EXISTS console_sessions;
@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE login_failures (
+    failure_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope TEXT NOT NULL CHECK (scope IN ('ip', 'username')),
+    subject_key TEXT NOT NULL,
+    failed_at_unix_ms INTEGER NOT NULL
+);
+
+CREATE INDEX idx_login_failures_subject_failed
+    ON login_failures(scope, subject_key, failed_at_unix_ms);
+
+CREATE INDEX idx_login_failures_failed
+    ON login_failures(failed_at_unix_ms);
+
+CREATE TABLE login_lockouts (
+    scope TEXT NOT NULL CHECK (scope IN ('ip', 'username')),
+    subject_key TEXT NOT NULL,
+    failure_count INTEGER NOT NULL,
+    locked_at_unix_ms INTEGER NOT NULL,
+    locked_until_unix_ms INTEGER NOT NULL,
+    PRIMARY KEY (scope, subject_key)
+);
+
+CREATE INDEX idx_login_lockouts_until
+    ON login_lockouts(locked_until_unix_ms);
+
+CREATE TABLE login_lockout_events (
+    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type TEXT NOT NULL CHECK (event_type IN ('locked', 'unlocked')),
+    scope TEXT NOT NULL CHECK (scope IN ('ip', 'username')),
+    subject_key TEXT NOT NULL,
+    failure_count INTEGER NOT NULL,
+    ip_address TEXT NOT NULL,
+    actor_account_id TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL
+);
+
+CREATE INDEX idx_login_lockout_events_created
+    ON login_lockout_events(created_at_unix_ms);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_login_lockout_events_created;
+DROP TABLE IF EXISTS login_lockout_events;
+DROP INDEX IF EXISTS idx_login_lockouts_until;
+DROP TABLE IF EXISTS login_lockouts;
+DROP INDEX IF EXISTS idx_login_failures_failed;
+DROP INDEX IF EXISTS idx_login_failures_subject_failed;
+DROP TABLE IF EXISTS login_failures;
@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE account_totp (
+    account_id TEXT PRIMARY KEY,
+    secret TEXT NOT NULL,
+    enabled INTEGER NOT NULL CHECK (enabled IN (0, 1)),
+    last_used_step INTEGER NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    updated_at_unix_ms INTEGER NOT NULL,
+    FOREIGN KEY (account_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+CREATE TABLE account_recovery_codes (
+    account_id TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at_unix_ms INTEGER NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    PRIMARY KEY (account_id, code_hash),
+    FOREIGN KEY (account_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+CREATE TABLE login_challenges (
+    challenge_hash TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    expires_at_unix_ms INTEGER NOT NULL,
+    FOREIGN KEY (account_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_login_challenges_expires
+    ON login_challenges(expires_at_unix_ms);
+
+CREATE TABLE console_settings (
+    setting_key TEXT PRIMARY KEY,
+    setting_value TEXT NOT NULL,
+    updated_at_unix_ms INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS console_settings;
+DROP INDEX IF EXISTS idx_login_challenges_expires;
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS account_recovery_codes;
+DROP TABLE IF EXISTS account_totp;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE account_identities (
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    last_login_at_unix_ms INTEGER NOT NULL,
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (account_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_account_identities_account
+    ON account_identities(account_id);
+
+CREATE TABLE oidc_login_states (
+    state_hash TEXT PRIMARY KEY,
+    nonce TEXT NOT NULL,
+    code_verifier TEXT NOT NULL,
+    redirect_path TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    expires_at_unix_ms INTEGER NOT NULL
+);
+
+CREATE INDEX idx_oidc_login_states_expires
+    ON oidc_login_states(expires_at_unix_ms);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_oidc_login_states_expires;
+DROP TABLE IF EXISTS oidc_login_states;
+DROP INDEX IF EXISTS idx_account_identities_account;
+DROP TABLE IF EXISTS account_identities;
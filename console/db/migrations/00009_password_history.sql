@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE account_password_history (
+    history_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    hash_algo TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    FOREIGN KEY (account_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_account_password_history_account
+    ON account_password_history(account_id, history_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_account_password_history_account;
+DROP TABLE IF EXISTS account_password_history;
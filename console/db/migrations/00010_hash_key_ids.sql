@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE trusted_tokens ADD COLUMN hash_key_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE worker_credentials ADD COLUMN hash_key_id TEXT NOT NULL DEFAULT 'default';
+
+-- +goose Down
+ALTER TABLE worker_credentials DROP COLUMN hash_key_id;
+ALTER TABLE trusted_tokens DROP COLUMN hash_key_id;
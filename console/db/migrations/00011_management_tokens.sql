@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE management_tokens (
+    token_id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    name_key TEXT NOT NULL,
+    token_hash TEXT NOT NULL,
+    hash_key_id TEXT NOT NULL,
+    token_masked TEXT NOT NULL,
+    permissions_json TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    last_used_at_unix_ms INTEGER NOT NULL,
+    UNIQUE (account_id, name_key),
+    UNIQUE (token_hash),
+    FOREIGN KEY (account_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+-- Usage events outlive the token so the trail survives token deletion.
+CREATE TABLE management_token_events (
+    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_id TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,
+    permission TEXT NOT NULL,
+    status_code INTEGER NOT NULL,
+    ip_address TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL
+);
+
+CREATE INDEX idx_management_token_events_token_created
+    ON management_token_events(token_id, created_at_unix_ms);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_management_token_events_token_created;
+DROP TABLE IF EXISTS management_token_events;
+DROP TABLE IF EXISTS management_tokens;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- Append-only, hash-chained audit trail: event_hash covers the event fields
+-- and prev_hash, so editing or removing a row breaks every later link.
+CREATE TABLE audit_events (
+    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at_unix_ms INTEGER NOT NULL,
+    actor_type TEXT NOT NULL,
+    actor_id TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    details_json TEXT NOT NULL,
+    prev_hash TEXT NOT NULL,
+    event_hash TEXT NOT NULL,
+    UNIQUE (event_hash)
+);
+
+CREATE INDEX idx_audit_events_account ON audit_events(account_id, event_id);
+CREATE INDEX idx_audit_events_action ON audit_events(action, event_id);
+CREATE INDEX idx_audit_events_created ON audit_events(created_at_unix_ms);
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER IF EXISTS audit_events_no_delete;
+DROP TRIGGER IF EXISTS audit_events_no_update;
+DROP INDEX IF EXISTS idx_audit_events_created;
+DROP INDEX IF EXISTS idx_audit_events_action;
+DROP INDEX IF EXISTS idx_audit_events_account;
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Empty scope columns match any account, token, capability, or worker.
+CREATE TABLE command_policies (
+    policy_id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    action TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    token_id TEXT NOT NULL,
+    capability TEXT NOT NULL,
+    worker_labels_json TEXT NOT NULL,
+    match_field TEXT NOT NULL,
+    pattern TEXT NOT NULL,
+    enabled INTEGER NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    updated_at_unix_ms INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS command_policies;
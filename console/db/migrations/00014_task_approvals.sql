@@ -0,0 +1,139 @@
+-- +goose Up
+-- SQLite cannot alter a CHECK constraint, so tasks is rebuilt to allow the
+-- pending_approval status.
+CREATE TABLE tasks_new (
+    task_id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    capability TEXT NOT NULL,
+    input_json TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (
+        status IN ('pending_approval', 'queued', 'dispatched', 'running', 'succeeded', 'failed', 'timeout', 'canceled')
+    ),
+    command_id TEXT NOT NULL DEFAULT '',
+    result_json TEXT NOT NULL DEFAULT '',
+    error_code TEXT NOT NULL DEFAULT '',
+    error_message TEXT NOT NULL DEFAULT '',
+    created_at_unix_ms INTEGER NOT NULL,
+    updated_at_unix_ms INTEGER NOT NULL,
+    deadline_at_unix_ms INTEGER NOT NULL,
+    completed_at_unix_ms INTEGER NOT NULL DEFAULT 0,
+    expires_at_unix_ms INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO tasks_new SELECT * FROM tasks;
+
+DROP INDEX IF EXISTS idx_tasks_status;
+DROP INDEX IF EXISTS idx_tasks_expires;
+DROP INDEX IF EXISTS idx_tasks_owner_created;
+DROP INDEX IF EXISTS idx_tasks_owner_request_unique;
+DROP TABLE tasks;
+
+ALTER TABLE tasks_new RENAME TO tasks;
+
+CREATE UNIQUE INDEX idx_tasks_owner_request_unique
+    ON tasks(owner_id, request_id)
+    WHERE request_id <> '';
+
+CREATE INDEX idx_tasks_owner_created
+    ON tasks(owner_id, created_at_unix_ms DESC);
+
+CREATE INDEX idx_tasks_expires
+    ON tasks(expires_at_unix_ms);
+
+CREATE INDEX idx_tasks_status
+    ON tasks(status);
+
+-- One approval per task; rows go away with their task when it is pruned.
+CREATE TABLE task_approvals (
+    approval_id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL,
+    owner_id TEXT NOT NULL,
+    token_id TEXT NOT NULL,
+    capability TEXT NOT NULL,
+    input_json TEXT NOT NULL,
+    policy_id TEXT NOT NULL,
+    policy_name TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (
+        status IN ('pending', 'approved', 'rejected', 'expired', 'canceled')
+    ),
+    task_timeout_ms INTEGER NOT NULL,
+    decided_by TEXT NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    created_at_unix_ms INTEGER NOT NULL,
+    expires_at_unix_ms INTEGER NOT NULL,
+    decided_at_unix_ms INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (task_id),
+    FOREIGN KEY (task_id) REFERENCES tasks(task_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_task_approvals_owner_created
+    ON task_approvals(owner_id, created_at_unix_ms DESC);
+
+CREATE INDEX idx_task_approvals_status
+    ON task_approvals(status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_task_approvals_status;
+DROP INDEX IF EXISTS idx_task_approvals_owner_created;
+DROP TABLE IF EXISTS task_approvals;
+
+CREATE TABLE tasks_old (
+    task_id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    capability TEXT NOT NULL,
+    input_json TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (
+        status IN ('queued', 'dispatched', 'running', 'succeeded', 'failed', 'timeout', 'canceled')
+    ),
+    command_id TEXT NOT NULL DEFAULT '',
+    result_json TEXT NOT NULL DEFAULT '',
+    error_code TEXT NOT NULL DEFAULT '',
+    error_message TEXT NOT NULL DEFAULT '',
+    created_at_unix_ms INTEGER NOT NULL,
+    updated_at_unix_ms INTEGER NOT NULL,
+    deadline_at_unix_ms INTEGER NOT NULL,
+    completed_at_unix_ms INTEGER NOT NULL DEFAULT 0,
+    expires_at_unix_ms INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO tasks_old
+SELECT
+    task_id,
+    owner_id,
+    request_id,
+    capability,
+    input_json,
+    CASE WHEN status = 'pending_approval' THEN 'canceled' ELSE status END,
+    command_id,
+    result_json,
+    error_code,
+    error_message,
+    created_at_unix_ms,
+    updated_at_unix_ms,
+    deadline_at_unix_ms,
+    completed_at_unix_ms,
+    expires_at_unix_ms
+FROM tasks;
+
+DROP INDEX IF EXISTS idx_tasks_status;
+DROP INDEX IF EXISTS idx_tasks_expires;
+DROP INDEX IF EXISTS idx_tasks_owner_created;
+DROP INDEX IF EXISTS idx_tasks_owner_request_unique;
+DROP TABLE tasks;
+
+ALTER TABLE tasks_old RENAME TO tasks;
+
+CREATE UNIQUE INDEX idx_tasks_owner_request_unique
+    ON tasks(owner_id, request_id)
+    WHERE request_id <> '';
+
+CREATE INDEX idx_tasks_owner_created
+    ON tasks(owner_id, created_at_unix_ms DESC);
+
+CREATE INDEX idx_tasks_expires
+    ON tasks(expires_at_unix_ms);
+
+CREATE INDEX idx_tasks_status
+    ON tasks(status);
@@ -0,0 +1,25 @@
+-- +goose Up
+-- One row per granted capability. Only the worker-sys owner writes rows;
+-- routing reads the unexpired ones for the grantee.
+CREATE TABLE worker_sys_grants (
+    node_id TEXT NOT NULL,
+    grantee_id TEXT NOT NULL,
+    capability TEXT NOT NULL,
+    granted_by TEXT NOT NULL,
+    granted_at_unix_ms INTEGER NOT NULL,
+    expires_at_unix_ms INTEGER NOT NULL,
+    PRIMARY KEY (node_id, grantee_id, capability),
+    FOREIGN KEY (node_id) REFERENCES worker_nodes(node_id) ON DELETE CASCADE,
+    FOREIGN KEY (grantee_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_worker_sys_grants_grantee
+    ON worker_sys_grants(grantee_id, capability);
+
+CREATE INDEX idx_worker_sys_grants_expires
+    ON worker_sys_grants(expires_at_unix_ms);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_worker_sys_grants_expires;
+DROP INDEX IF EXISTS idx_worker_sys_grants_grantee;
+DROP TABLE IF EXISTS worker_sys_grants;
@@ -0,0 +1,45 @@
+-- +goose Up
+-- An organization is an owner scope of its own: org_id is used as owner_id
+-- for its tasks, sessions and worker-sys pool.
+CREATE TABLE organizations (
+    org_id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    name_key TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at_unix_ms INTEGER NOT NULL,
+    updated_at_unix_ms INTEGER NOT NULL,
+    UNIQUE (name_key)
+);
+
+CREATE TABLE organization_members (
+    org_id TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    created_at_unix_ms INTEGER NOT NULL,
+    updated_at_unix_ms INTEGER NOT NULL,
+    PRIMARY KEY (org_id, account_id),
+    FOREIGN KEY (org_id) REFERENCES organizations(org_id) ON DELETE CASCADE,
+    FOREIGN KEY (account_id) REFERENCES accounts(account_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_organization_members_account
+    ON organization_members(account_id);
+
+-- Org tokens are ordinary trusted tokens created by a member; this row
+-- makes requests authenticated with them act for the organization.
+CREATE TABLE organization_tokens (
+    token_id TEXT PRIMARY KEY,
+    org_id TEXT NOT NULL,
+    FOREIGN KEY (token_id) REFERENCES trusted_tokens(token_id) ON DELETE CASCADE,
+    FOREIGN KEY (org_id) REFERENCES organizations(org_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_organization_tokens_org
+    ON organization_tokens(org_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_organization_tokens_org;
+DROP TABLE IF EXISTS organization_tokens;
+DROP INDEX IF EXISTS idx_organization_members_account;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
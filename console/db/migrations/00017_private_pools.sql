@@ -0,0 +1,11 @@
+-- +goose Up
+-- Routing preference for an owner's private worker pool. Owners without a
+-- row fall back to the shared pool when their private workers are busy.
+CREATE TABLE private_pool_settings (
+    owner_id TEXT PRIMARY KEY,
+    fallback_to_shared INTEGER NOT NULL CHECK (fallback_to_shared IN (0, 1)),
+    updated_at_unix_ms INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS private_pool_settings;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Execution limits per owner scope (account or organization). Zero means
+-- unlimited; owners without a row have no limits.
+CREATE TABLE account_quotas (
+    owner_id TEXT PRIMARY KEY,
+    max_concurrent_per_capability INTEGER NOT NULL DEFAULT 0 CHECK (max_concurrent_per_capability >= 0),
+    max_tasks_per_minute INTEGER NOT NULL DEFAULT 0 CHECK (max_tasks_per_minute >= 0),
+    max_exec_seconds_per_day INTEGER NOT NULL DEFAULT 0 CHECK (max_exec_seconds_per_day >= 0),
+    max_terminal_sessions INTEGER NOT NULL DEFAULT 0 CHECK (max_terminal_sessions >= 0),
+    updated_at_unix_ms INTEGER NOT NULL
+);
+
+-- Command execution time per owner and UTC day (days since the Unix epoch).
+CREATE TABLE account_usage_daily (
+    owner_id TEXT NOT NULL,
+    usage_day INTEGER NOT NULL,
+    exec_ms INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (owner_id, usage_day)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS account_usage_daily;
+DROP TABLE IF EXISTS account_quotas;
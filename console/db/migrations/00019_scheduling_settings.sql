@@ -0,0 +1,12 @@
+-- +goose Up
+-- Fair-share scheduling settings per owner scope (account or organization).
+-- Owners without a row have weight 1 and the interactive class.
+CREATE TABLE scheduling_settings (
+    owner_id TEXT PRIMARY KEY,
+    weight INTEGER NOT NULL DEFAULT 1 CHECK (weight BETWEEN 1 AND 100),
+    priority_class TEXT NOT NULL DEFAULT 'interactive' CHECK (priority_class IN ('interactive', 'batch')),
+    updated_at_unix_ms INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS scheduling_settings;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- Resource usage per command dispatched for an owner scope, kept for
+-- chargeback reports after the task itself is pruned. cpu_seconds and
+-- peak_memory_bytes are 0 when the worker did not measure them.
+CREATE TABLE task_usage (
+    command_id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL,
+    capability TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    queue_wait_ms INTEGER NOT NULL DEFAULT 0 CHECK (queue_wait_ms >= 0),
+    exec_ms INTEGER NOT NULL DEFAULT 0 CHECK (exec_ms >= 0),
+    output_bytes INTEGER NOT NULL DEFAULT 0 CHECK (output_bytes >= 0),
+    cpu_seconds REAL NOT NULL DEFAULT 0 CHECK (cpu_seconds >= 0),
+    peak_memory_bytes INTEGER NOT NULL DEFAULT 0 CHECK (peak_memory_bytes >= 0),
+    completed_at_unix_ms INTEGER NOT NULL
+);
+
+CREATE INDEX idx_task_usage_completed_at ON task_usage (completed_at_unix_ms);
+CREATE INDEX idx_task_usage_owner_completed_at ON task_usage (owner_id, completed_at_unix_ms);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_task_usage_owner_completed_at;
+DROP INDEX IF EXISTS idx_task_usage_completed_at;
+DROP TABLE IF EXISTS task_usage;
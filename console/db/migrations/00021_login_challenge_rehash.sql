@@ -0,0 +1,9 @@
+-- +goose Up
+-- Password hashes computed at the password step of a two-step login. They are
+-- only stored on the account once the second factor is verified.
+ALTER TABLE login_challenges ADD COLUMN rehash_password_hash TEXT NOT NULL DEFAULT '';
+ALTER TABLE login_challenges ADD COLUMN rehash_hash_algo TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE login_challenges DROP COLUMN rehash_hash_algo;
+ALTER TABLE login_challenges DROP COLUMN rehash_password_hash;
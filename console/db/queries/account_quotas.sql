@@ -0,0 +1,46 @@
+-- name: GetAccountQuota :one
+SELECT owner_id,
+       max_concurrent_per_capability,
+       max_tasks_per_minute,
+       max_exec_seconds_per_day,
+       max_terminal_sessions,
+       updated_at_unix_ms
+FROM account_quotas
+WHERE owner_id = ?
+LIMIT 1;
+
+-- name: UpsertAccountQuota :exec
+INSERT INTO account_quotas (
+    owner_id,
+    max_concurrent_per_capability,
+    max_tasks_per_minute,
+    max_exec_seconds_per_day,
+    max_terminal_sessions,
+    updated_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(owner_id) DO UPDATE SET
+    max_concurrent_per_capability = excluded.max_concurrent_per_capability,
+    max_tasks_per_minute = excluded.max_tasks_per_minute,
+    max_exec_seconds_per_day = excluded.max_exec_seconds_per_day,
+    max_terminal_sessions = excluded.max_terminal_sessions,
+    updated_at_unix_ms = excluded.updated_at_unix_ms;
+
+-- name: AddAccountUsageExecMs :exec
+INSERT INTO account_usage_daily (
+    owner_id,
+    usage_day,
+    exec_ms
+) VALUES (?, ?, ?)
+ON CONFLICT(owner_id, usage_day) DO UPDATE SET
+    exec_ms = account_usage_daily.exec_ms + excluded.exec_ms;
+
+-- name: GetAccountUsageExecMs :one
+SELECT exec_ms
+FROM account_usage_daily
+WHERE owner_id = ?
+  AND usage_day = ?
+LIMIT 1;
+
+-- name: DeleteAccountUsageBefore :execrows
+DELETE FROM account_usage_daily
+WHERE usage_day < ?;
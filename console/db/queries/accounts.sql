@@ -80,6 +80,19 @@ SET password_hash = ?,
     updated_at_unix_ms = ?
 WHERE account_id = ?;
 
+-- name: RehashAccountPassword :execrows
+UPDATE accounts
+SET password_hash = ?,
+    hash_algo = ?,
+    updated_at_unix_ms = ?
+WHERE account_id = ? AND password_hash = ?;
+
+-- name: UpdateAccountIsAdminByID :execrows
+UPDATE accounts
+SET is_admin = ?,
+    updated_at_unix_ms = ?
+WHERE account_id = ?;
+
 -- name: DeleteAccountByID :execrows
 DELETE FROM accounts
 WHERE account_id = ?;
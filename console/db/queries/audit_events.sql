@@ -0,0 +1,88 @@
+-- name: GetLatestAuditEvent :one
+SELECT
+    event_id,
+    created_at_unix_ms,
+    actor_type,
+    actor_id,
+    account_id,
+    action,
+    target_type,
+    target_id,
+    outcome,
+    ip_address,
+    details_json,
+    prev_hash,
+    event_hash
+FROM audit_events
+ORDER BY event_id DESC
+LIMIT 1;
+
+-- name: InsertAuditEvent :exec
+INSERT INTO audit_events (
+    created_at_unix_ms,
+    actor_type,
+    actor_id,
+    account_id,
+    action,
+    target_type,
+    target_id,
+    outcome,
+    ip_address,
+    details_json,
+    prev_hash,
+    event_hash
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListAuditEvents :many
+SELECT
+    event_id,
+    created_at_unix_ms,
+    actor_type,
+    actor_id,
+    account_id,
+    action,
+    target_type,
+    target_id,
+    outcome,
+    ip_address,
+    details_json,
+    prev_hash,
+    event_hash
+FROM audit_events
+WHERE event_id < sqlc.arg(before_event_id)
+  AND (sqlc.arg(account_id) = '' OR account_id = sqlc.arg(account_id))
+  AND (sqlc.arg(action) = '' OR action = sqlc.arg(action))
+  AND (sqlc.arg(target_type) = '' OR target_type = sqlc.arg(target_type))
+  AND (sqlc.arg(target_id) = '' OR target_id = sqlc.arg(target_id))
+  AND (sqlc.arg(outcome) = '' OR outcome = sqlc.arg(outcome))
+  AND created_at_unix_ms >= sqlc.arg(since_unix_ms)
+  AND created_at_unix_ms < sqlc.arg(until_unix_ms)
+ORDER BY event_id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: ListAuditEventsAfter :many
+SELECT
+    event_id,
+    created_at_unix_ms,
+    actor_type,
+    actor_id,
+    account_id,
+    action,
+    target_type,
+    target_id,
+    outcome,
+    ip_address,
+    details_json,
+    prev_hash,
+    event_hash
+FROM audit_events
+WHERE event_id > sqlc.arg(after_event_id)
+  AND (sqlc.arg(account_id) = '' OR account_id = sqlc.arg(account_id))
+  AND (sqlc.arg(action) = '' OR action = sqlc.arg(action))
+  AND (sqlc.arg(target_type) = '' OR target_type = sqlc.arg(target_type))
+  AND (sqlc.arg(target_id) = '' OR target_id = sqlc.arg(target_id))
+  AND (sqlc.arg(outcome) = '' OR outcome = sqlc.arg(outcome))
+  AND created_at_unix_ms >= sqlc.arg(since_unix_ms)
+  AND created_at_unix_ms < sqlc.arg(until_unix_ms)
+ORDER BY event_id ASC
+LIMIT sqlc.arg(limit);
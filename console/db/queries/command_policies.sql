@@ -0,0 +1,75 @@
+-- name: ListCommandPolicies :many
+SELECT
+    policy_id,
+    name,
+    description,
+    action,
+    account_id,
+    token_id,
+    capability,
+    worker_labels_json,
+    match_field,
+    pattern,
+    enabled,
+    created_by,
+    created_at_unix_ms,
+    updated_at_unix_ms
+FROM command_policies
+ORDER BY created_at_unix_ms ASC, policy_id ASC;
+
+-- name: GetCommandPolicyByID :one
+SELECT
+    policy_id,
+    name,
+    description,
+    action,
+    account_id,
+    token_id,
+    capability,
+    worker_labels_json,
+    match_field,
+    pattern,
+    enabled,
+    created_by,
+    created_at_unix_ms,
+    updated_at_unix_ms
+FROM command_policies
+WHERE policy_id = ?
+LIMIT 1;
+
+-- name: InsertCommandPolicy :exec
+INSERT INTO command_policies (
+    policy_id,
+    name,
+    description,
+    action,
+    account_id,
+    token_id,
+    capability,
+    worker_labels_json,
+    match_field,
+    pattern,
+    enabled,
+    created_by,
+    created_at_unix_ms,
+    updated_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: UpdateCommandPolicy :execrows
+UPDATE command_policies
+SET name = ?,
+    description = ?,
+    action = ?,
+    account_id = ?,
+    token_id = ?,
+    capability = ?,
+    worker_labels_json = ?,
+    match_field = ?,
+    pattern = ?,
+    enabled = ?,
+    updated_at_unix_ms = ?
+WHERE policy_id = ?;
+
+-- name: DeleteCommandPolicy :execrows
+DELETE FROM command_policies
+WHERE policy_id = ?;
@@ -0,0 +1,80 @@
+-- name: InsertLoginFailure :exec
+INSERT INTO login_failures (
+    scope,
+    subject_key,
+    failed_at_unix_ms
+) VALUES (?, ?, ?);
+
+-- name: CountLoginFailuresSince :one
+SELECT COUNT(*)
+FROM login_failures
+WHERE scope = ? AND subject_key = ? AND failed_at_unix_ms > ?;
+
+-- name: GetLatestLoginFailureAt :one
+SELECT failed_at_unix_ms
+FROM login_failures
+WHERE scope = ? AND subject_key = ?
+ORDER BY failed_at_unix_ms DESC
+LIMIT 1;
+
+-- name: DeleteLoginFailuresBySubject :execrows
+DELETE FROM login_failures
+WHERE scope = ? AND subject_key = ?;
+
+-- name: DeleteLoginFailuresBefore :execrows
+DELETE FROM login_failures
+WHERE failed_at_unix_ms <= ?;
+
+-- name: UpsertLoginLockout :exec
+INSERT INTO login_lockouts (
+    scope,
+    subject_key,
+    failure_count,
+    locked_at_unix_ms,
+    locked_until_unix_ms
+) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(scope, subject_key) DO UPDATE SET
+    failure_count = excluded.failure_count,
+    locked_at_unix_ms = excluded.locked_at_unix_ms,
+    locked_until_unix_ms = excluded.locked_until_unix_ms;
+
+-- name: GetLoginLockout :one
+SELECT
+    scope,
+    subject_key,
+    failure_count,
+    locked_at_unix_ms,
+    locked_until_unix_ms
+FROM login_lockouts
+WHERE scope = ? AND subject_key = ?
+LIMIT 1;
+
+-- name: ListActiveLoginLockouts :many
+SELECT
+    scope,
+    subject_key,
+    failure_count,
+    locked_at_unix_ms,
+    locked_until_unix_ms
+FROM login_lockouts
+WHERE locked_until_unix_ms > ?
+ORDER BY locked_at_unix_ms DESC, scope ASC, subject_key ASC;
+
+-- name: DeleteLoginLockout :execrows
+DELETE FROM login_lockouts
+WHERE scope = ? AND subject_key = ?;
+
+-- name: DeleteExpiredLoginLockouts :execrows
+DELETE FROM login_lockouts
+WHERE locked_until_unix_ms <= ?;
+
+-- name: InsertLoginLockoutEvent :exec
+INSERT INTO login_lockout_events (
+    event_type,
+    scope,
+    subject_key,
+    failure_count,
+    ip_address,
+    actor_account_id,
+    created_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?);
@@ -6,4 +6,10 @@ SET status = 'failed',
     updated_at_unix_ms = ?,
     completed_at_unix_ms = ?,
     expires_at_unix_ms = ?
-WHERE status IN ('queued', 'dispatched', 'running');
+WHERE status IN ('pending_approval', 'queued', 'dispatched', 'running');
+
+-- name: ExpirePendingTaskApprovalsOnStartup :execrows
+UPDATE task_approvals
+SET status = 'expired',
+    decided_at_unix_ms = ?
+WHERE status = 'pending';
@@ -0,0 +1,128 @@
+-- name: ListManagementTokensByAccount :many
+SELECT
+    token_id,
+    account_id,
+    name,
+    name_key,
+    token_hash,
+    hash_key_id,
+    token_masked,
+    permissions_json,
+    created_at_unix_ms,
+    last_used_at_unix_ms
+FROM management_tokens
+WHERE account_id = ?
+ORDER BY created_at_unix_ms ASC, token_id ASC;
+
+-- name: GetManagementTokenByID :one
+SELECT
+    token_id,
+    account_id,
+    name,
+    name_key,
+    token_hash,
+    hash_key_id,
+    token_masked,
+    permissions_json,
+    created_at_unix_ms,
+    last_used_at_unix_ms
+FROM management_tokens
+WHERE token_id = ?
+LIMIT 1;
+
+-- name: GetManagementTokenByHash :one
+SELECT
+    token_id,
+    account_id,
+    name,
+    name_key,
+    token_hash,
+    hash_key_id,
+    token_masked,
+    permissions_json,
+    created_at_unix_ms,
+    last_used_at_unix_ms
+FROM management_tokens
+WHERE token_hash = ?
+LIMIT 1;
+
+-- name: GetManagementTokenByAccountAndNameKey :one
+SELECT
+    token_id,
+    account_id,
+    name,
+    name_key,
+    token_hash,
+    hash_key_id,
+    token_masked,
+    permissions_json,
+    created_at_unix_ms,
+    last_used_at_unix_ms
+FROM management_tokens
+WHERE account_id = ? AND name_key = ?
+LIMIT 1;
+
+-- name: InsertManagementToken :exec
+INSERT INTO management_tokens (
+    token_id,
+    account_id,
+    name,
+    name_key,
+    token_hash,
+    hash_key_id,
+    token_masked,
+    permissions_json,
+    created_at_unix_ms,
+    last_used_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: DeleteManagementTokenByIDAndAccount :execrows
+DELETE FROM management_tokens
+WHERE token_id = ? AND account_id = ?;
+
+-- name: TouchManagementToken :exec
+UPDATE management_tokens
+SET last_used_at_unix_ms = ?
+WHERE token_id = ?;
+
+-- name: UpdateManagementTokenHash :execrows
+UPDATE management_tokens
+SET token_hash = ?,
+    hash_key_id = ?
+WHERE token_id = ? AND token_hash = ?;
+
+-- name: CountManagementTokensByHashKey :many
+SELECT
+    hash_key_id,
+    COUNT(*) AS total
+FROM management_tokens
+GROUP BY hash_key_id
+ORDER BY hash_key_id ASC;
+
+-- name: InsertManagementTokenEvent :exec
+INSERT INTO management_token_events (
+    token_id,
+    account_id,
+    method,
+    route,
+    permission,
+    status_code,
+    ip_address,
+    created_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListManagementTokenEvents :many
+SELECT
+    event_id,
+    token_id,
+    account_id,
+    method,
+    route,
+    permission,
+    status_code,
+    ip_address,
+    created_at_unix_ms
+FROM management_token_events
+WHERE token_id = ? AND account_id = ?
+ORDER BY event_id DESC
+LIMIT ?;
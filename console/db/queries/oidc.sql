@@ -0,0 +1,54 @@
+-- name: InsertOIDCLoginState :exec
+INSERT INTO oidc_login_states (
+    state_hash,
+    nonce,
+    code_verifier,
+    redirect_path,
+    created_at_unix_ms,
+    expires_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetOIDCLoginStateByHash :one
+SELECT
+    state_hash,
+    nonce,
+    code_verifier,
+    redirect_path,
+    created_at_unix_ms,
+    expires_at_unix_ms
+FROM oidc_login_states
+WHERE state_hash = ?
+LIMIT 1;
+
+-- name: DeleteOIDCLoginStateByHash :execrows
+DELETE FROM oidc_login_states
+WHERE state_hash = ?;
+
+-- name: DeleteExpiredOIDCLoginStates :execrows
+DELETE FROM oidc_login_states
+WHERE expires_at_unix_ms <= ?;
+
+-- name: GetAccountIdentity :one
+SELECT
+    issuer,
+    subject,
+    account_id,
+    created_at_unix_ms,
+    last_login_at_unix_ms
+FROM account_identities
+WHERE issuer = ? AND subject = ?
+LIMIT 1;
+
+-- name: InsertAccountIdentity :exec
+INSERT INTO account_identities (
+    issuer,
+    subject,
+    account_id,
+    created_at_unix_ms,
+    last_login_at_unix_ms
+) VALUES (?, ?, ?, ?, ?);
+
+-- name: TouchAccountIdentity :execrows
+UPDATE account_identities
+SET last_login_at_unix_ms = ?
+WHERE issuer = ? AND subject = ?;
@@ -0,0 +1,137 @@
+-- name: InsertOrganization :exec
+INSERT INTO organizations (
+    org_id,
+    name,
+    name_key,
+    created_by,
+    created_at_unix_ms,
+    updated_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetOrganizationByID :one
+SELECT
+    org_id,
+    name,
+    name_key,
+    created_by,
+    created_at_unix_ms,
+    updated_at_unix_ms
+FROM organizations
+WHERE org_id = ?
+LIMIT 1;
+
+-- name: DeleteOrganizationByID :execrows
+DELETE FROM organizations
+WHERE org_id = ?;
+
+-- name: ListOrganizationsByAccount :many
+SELECT
+    o.org_id,
+    o.name,
+    m.role,
+    o.created_at_unix_ms
+FROM organization_members m
+JOIN organizations o
+  ON o.org_id = m.org_id
+WHERE m.account_id = ?
+ORDER BY o.name_key ASC;
+
+-- name: CountOrganizationsOwnedByAccount :one
+SELECT COUNT(1)
+FROM organization_members
+WHERE account_id = ?
+  AND role = 'owner';
+
+-- name: GetOrganizationMember :one
+SELECT
+    org_id,
+    account_id,
+    role,
+    created_at_unix_ms,
+    updated_at_unix_ms
+FROM organization_members
+WHERE org_id = ?
+  AND account_id = ?
+LIMIT 1;
+
+-- name: ListOrganizationMembers :many
+SELECT
+    m.account_id,
+    a.username,
+    m.role,
+    m.created_at_unix_ms,
+    m.updated_at_unix_ms
+FROM organization_members m
+JOIN accounts a
+  ON a.account_id = m.account_id
+WHERE m.org_id = ?
+ORDER BY m.created_at_unix_ms ASC, m.account_id ASC;
+
+-- name: UpsertOrganizationMember :exec
+INSERT INTO organization_members (
+    org_id,
+    account_id,
+    role,
+    created_at_unix_ms,
+    updated_at_unix_ms
+) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT(org_id, account_id) DO UPDATE SET
+    role = excluded.role,
+    updated_at_unix_ms = excluded.updated_at_unix_ms;
+
+-- name: DeleteOrganizationMember :execrows
+DELETE FROM organization_members
+WHERE org_id = ?
+  AND account_id = ?;
+
+-- name: InsertOrganizationToken :exec
+INSERT INTO organization_tokens (
+    token_id,
+    org_id
+) VALUES (?, ?);
+
+-- name: GetOrganizationIDByToken :one
+SELECT org_id
+FROM organization_tokens
+WHERE token_id = ?
+LIMIT 1;
+
+-- name: ListOrganizationTokens :many
+SELECT
+    t.token_id,
+    t.account_id,
+    t.name,
+    t.token_masked,
+    t.created_at_unix_ms,
+    t.updated_at_unix_ms
+FROM organization_tokens ot
+JOIN trusted_tokens t
+  ON t.token_id = ot.token_id
+WHERE ot.org_id = ?
+ORDER BY t.created_at_unix_ms ASC, t.token_id ASC;
+
+-- name: DeleteOrganizationTrustedToken :execrows
+DELETE FROM trusted_tokens
+WHERE trusted_tokens.token_id = ?
+  AND trusted_tokens.token_id IN (
+    SELECT ot.token_id
+    FROM organization_tokens ot
+    WHERE ot.org_id = ?
+  );
+
+-- name: DeleteOrganizationTrustedTokensByAccount :execrows
+DELETE FROM trusted_tokens
+WHERE trusted_tokens.account_id = ?
+  AND trusted_tokens.token_id IN (
+    SELECT ot.token_id
+    FROM organization_tokens ot
+    WHERE ot.org_id = ?
+  );
+
+-- name: DeleteOrganizationTrustedTokens :execrows
+DELETE FROM trusted_tokens
+WHERE trusted_tokens.token_id IN (
+    SELECT ot.token_id
+    FROM organization_tokens ot
+    WHERE ot.org_id = ?
+  );
@@ -0,0 +1,27 @@
+-- name: InsertAccountPasswordHistory :exec
+INSERT INTO account_password_history (
+    account_id,
+    password_hash,
+    hash_algo,
+    created_at_unix_ms
+) VALUES (?, ?, ?, ?);
+
+-- name: ListRecentAccountPasswordHistory :many
+SELECT
+    password_hash,
+    hash_algo
+FROM account_password_history
+WHERE account_id = ?
+ORDER BY history_id DESC
+LIMIT ?;
+
+-- name: PruneAccountPasswordHistory :execrows
+DELETE FROM account_password_history
+WHERE account_id = ?
+  AND history_id NOT IN (
+    SELECT history_id
+    FROM account_password_history
+    WHERE account_id = ?
+    ORDER BY history_id DESC
+    LIMIT ?
+  );
@@ -0,0 +1,15 @@
+-- name: GetPrivatePoolFallback :one
+SELECT fallback_to_shared
+FROM private_pool_settings
+WHERE owner_id = ?
+LIMIT 1;
+
+-- name: UpsertPrivatePoolSetting :exec
+INSERT INTO private_pool_settings (
+    owner_id,
+    fallback_to_shared,
+    updated_at_unix_ms
+) VALUES (?, ?, ?)
+ON CONFLICT(owner_id) DO UPDATE SET
+    fallback_to_shared = excluded.fallback_to_shared,
+    updated_at_unix_ms = excluded.updated_at_unix_ms;
@@ -0,0 +1,20 @@
+-- name: GetSchedulingSetting :one
+SELECT owner_id,
+       weight,
+       priority_class,
+       updated_at_unix_ms
+FROM scheduling_settings
+WHERE owner_id = ?
+LIMIT 1;
+
+-- name: UpsertSchedulingSetting :exec
+INSERT INTO scheduling_settings (
+    owner_id,
+    weight,
+    priority_class,
+    updated_at_unix_ms
+) VALUES (?, ?, ?, ?)
+ON CONFLICT(owner_id) DO UPDATE SET
+    weight = excluded.weight,
+    priority_class = excluded.priority_class,
+    updated_at_unix_ms = excluded.updated_at_unix_ms;
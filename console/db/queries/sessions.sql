@@ -0,0 +1,58 @@
+-- name: InsertConsoleSession :exec
+INSERT INTO console_sessions (
+    session_id,
+    token_hash,
+    account_id,
+    user_agent,
+    ip_address,
+    created_at_unix_ms,
+    last_seen_at_unix_ms,
+    expires_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetConsoleSessionByTokenHash :one
+SELECT
+    cs.session_id,
+    cs.account_id,
+    cs.last_seen_at_unix_ms,
+    cs.expires_at_unix_ms,
+    a.username,
+    a.is_admin
+FROM console_sessions cs
+JOIN accounts a ON a.account_id = cs.account_id
+WHERE cs.token_hash = ?
+LIMIT 1;
+
+-- name: ListConsoleSessionsByAccount :many
+SELECT
+    session_id,
+    user_agent,
+    ip_address,
+    created_at_unix_ms,
+    last_seen_at_unix_ms,
+    expires_at_unix_ms
+FROM console_sessions
+WHERE account_id = ? AND expires_at_unix_ms > ?
+ORDER BY last_seen_at_unix_ms DESC, session_id ASC;
+
+-- name: TouchConsoleSession :execrows
+UPDATE console_sessions
+SET last_seen_at_unix_ms = ?,
+    ip_address = ?
+WHERE session_id = ?;
+
+-- name: DeleteConsoleSessionByTokenHash :execrows
+DELETE FROM console_sessions
+WHERE token_hash = ?;
+
+-- name: DeleteConsoleSessionByIDAndAccount :execrows
+DELETE FROM console_sessions
+WHERE session_id = ? AND account_id = ?;
+
+-- name: DeleteConsoleSessionsByAccount :execrows
+DELETE FROM console_sessions
+WHERE account_id = ?;
+
+-- name: DeleteExpiredConsoleSessions :execrows
+DELETE FROM console_sessions
+WHERE expires_at_unix_ms <= ?;
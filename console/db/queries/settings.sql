@@ -0,0 +1,15 @@
+-- name: GetConsoleSetting :one
+SELECT setting_value
+FROM console_settings
+WHERE setting_key = ?
+LIMIT 1;
+
+-- name: UpsertConsoleSetting :exec
+INSERT INTO console_settings (
+    setting_key,
+    setting_value,
+    updated_at_unix_ms
+) VALUES (?, ?, ?)
+ON CONFLICT(setting_key) DO UPDATE SET
+    setting_value = excluded.setting_value,
+    updated_at_unix_ms = excluded.updated_at_unix_ms;
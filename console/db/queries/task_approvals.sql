@@ -0,0 +1,78 @@
+-- name: InsertTaskApproval :exec
+INSERT INTO task_approvals (
+    approval_id,
+    task_id,
+    owner_id,
+    token_id,
+    capability,
+    input_json,
+    policy_id,
+    policy_name,
+    status,
+    task_timeout_ms,
+    decided_by,
+    comment,
+    created_at_unix_ms,
+    expires_at_unix_ms,
+    decided_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'pending', ?, '', '', ?, ?, 0);
+
+-- name: GetTaskApprovalByID :one
+SELECT
+    approval_id,
+    task_id,
+    owner_id,
+    token_id,
+    capability,
+    input_json,
+    policy_id,
+    policy_name,
+    status,
+    task_timeout_ms,
+    decided_by,
+    comment,
+    created_at_unix_ms,
+    expires_at_unix_ms,
+    decided_at_unix_ms
+FROM task_approvals
+WHERE approval_id = ?
+LIMIT 1;
+
+-- name: ListTaskApprovals :many
+SELECT
+    approval_id,
+    task_id,
+    owner_id,
+    token_id,
+    capability,
+    input_json,
+    policy_id,
+    policy_name,
+    status,
+    task_timeout_ms,
+    decided_by,
+    comment,
+    created_at_unix_ms,
+    expires_at_unix_ms,
+    decided_at_unix_ms
+FROM task_approvals
+WHERE (sqlc.arg(owner_id) = '' OR owner_id = sqlc.arg(owner_id))
+  AND (sqlc.arg(status) = '' OR status = sqlc.arg(status))
+ORDER BY created_at_unix_ms DESC, approval_id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: DecideTaskApproval :execrows
+UPDATE task_approvals
+SET status = ?,
+    decided_by = ?,
+    comment = ?,
+    decided_at_unix_ms = ?
+WHERE approval_id = ?
+  AND status = 'pending';
+
+-- name: CloseTaskApprovalForTask :execrows
+UPDATE task_approvals
+SET status = ?,
+    decided_at_unix_ms = ?
+WHERE task_id = ?
+  AND status = 'pending';
@@ -0,0 +1,36 @@
+-- name: InsertTaskUsage :exec
+INSERT INTO task_usage (
+    command_id,
+    owner_id,
+    capability,
+    node_id,
+    status,
+    queue_wait_ms,
+    exec_ms,
+    output_bytes,
+    cpu_seconds,
+    peak_memory_bytes,
+    completed_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: SummarizeTaskUsage :many
+SELECT owner_id,
+       capability,
+       node_id,
+       COUNT(*) AS tasks,
+       CAST(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) AS INTEGER) AS failed_tasks,
+       CAST(SUM(queue_wait_ms) AS INTEGER) AS queue_wait_ms,
+       CAST(SUM(exec_ms) AS INTEGER) AS exec_ms,
+       CAST(SUM(output_bytes) AS INTEGER) AS output_bytes,
+       CAST(SUM(cpu_seconds) AS REAL) AS cpu_seconds,
+       CAST(MAX(peak_memory_bytes) AS INTEGER) AS peak_memory_bytes
+FROM task_usage
+WHERE (sqlc.arg(owner_id) = '' OR owner_id = sqlc.arg(owner_id))
+  AND completed_at_unix_ms >= sqlc.arg(from_unix_ms)
+  AND completed_at_unix_ms < sqlc.arg(to_unix_ms)
+GROUP BY owner_id, capability, node_id
+ORDER BY owner_id, capability, node_id;
+
+-- name: DeleteTaskUsageBefore :execrows
+DELETE FROM task_usage
+WHERE completed_at_unix_ms < ?;
@@ -59,6 +59,36 @@ FROM tasks
 WHERE owner_id = ? AND request_id = ?
 LIMIT 1;
 
+-- name: ListTasksByOwner :many
+SELECT
+    task_id,
+    owner_id,
+    request_id,
+    capability,
+    input_json,
+    status,
+    command_id,
+    result_json,
+    error_code,
+    error_message,
+    created_at_unix_ms,
+    updated_at_unix_ms,
+    deadline_at_unix_ms,
+    completed_at_unix_ms,
+    expires_at_unix_ms
+FROM tasks
+WHERE owner_id = ?
+ORDER BY created_at_unix_ms DESC, task_id DESC
+LIMIT ?;
+
+-- name: MarkTaskApproved :execrows
+UPDATE tasks
+SET status = 'queued',
+    deadline_at_unix_ms = ?,
+    updated_at_unix_ms = ?
+WHERE task_id = ?
+  AND status = 'pending_approval';
+
 -- name: MarkTaskDispatched :execrows
 UPDATE tasks
 SET status = 'dispatched',
@@ -84,7 +114,7 @@ SET status = ?,
     completed_at_unix_ms = ?,
     expires_at_unix_ms = ?
 WHERE task_id = ?
-  AND status IN ('queued', 'dispatched', 'running');
+  AND status IN ('pending_approval', 'queued', 'dispatched', 'running');
 
 -- name: DeleteExpiredTerminalTasks :execrows
 DELETE FROM tasks
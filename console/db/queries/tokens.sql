@@ -8,9 +8,11 @@ SELECT
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM trusted_tokens
 WHERE account_id = ?
+  AND token_id NOT IN (SELECT token_id FROM organization_tokens)
 ORDER BY created_at_unix_ms ASC, token_id ASC;
 
 -- name: GetTrustedTokenByID :one
@@ -23,7 +25,8 @@ SELECT
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM trusted_tokens
 WHERE token_id = ?
 LIMIT 1;
@@ -38,7 +41,8 @@ SELECT
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM trusted_tokens
 WHERE account_id = ? AND name_key = ?
 LIMIT 1;
@@ -53,7 +57,8 @@ SELECT
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM trusted_tokens
 WHERE token_hash = ?
 LIMIT 1;
@@ -68,9 +73,25 @@ INSERT INTO trusted_tokens (
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+    updated_at_unix_ms,
+    hash_key_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: DeleteTrustedTokenByIDAndAccount :execrows
 DELETE FROM trusted_tokens
-WHERE token_id = ? AND account_id = ?;
+WHERE token_id = ? AND account_id = ?
+  AND token_id NOT IN (SELECT token_id FROM organization_tokens);
+
+-- name: UpdateTrustedTokenHash :execrows
+UPDATE trusted_tokens
+SET token_hash = ?,
+    hash_key_id = ?
+WHERE token_id = ? AND token_hash = ?;
+
+-- name: CountTrustedTokensByHashKey :many
+SELECT
+    hash_key_id,
+    COUNT(*) AS total
+FROM trusted_tokens
+GROUP BY hash_key_id
+ORDER BY hash_key_id ASC;
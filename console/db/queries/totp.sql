@@ -0,0 +1,107 @@
+-- name: GetAccountTOTP :one
+SELECT
+    account_id,
+    secret,
+    enabled,
+    last_used_step,
+    created_at_unix_ms,
+    updated_at_unix_ms
+FROM account_totp
+WHERE account_id = ?
+LIMIT 1;
+
+-- name: UpsertPendingAccountTOTP :execrows
+INSERT INTO account_totp (
+    account_id,
+    secret,
+    enabled,
+    last_used_step,
+    created_at_unix_ms,
+    updated_at_unix_ms
+) VALUES (?, ?, 0, 0, ?, ?)
+ON CONFLICT(account_id) DO UPDATE SET
+    secret = excluded.secret,
+    last_used_step = 0,
+    updated_at_unix_ms = excluded.updated_at_unix_ms
+WHERE account_totp.enabled = 0;
+
+-- name: EnableAccountTOTP :execrows
+UPDATE account_totp
+SET enabled = 1,
+    last_used_step = ?,
+    updated_at_unix_ms = ?
+WHERE account_id = ? AND enabled = 0;
+
+-- name: ConsumeAccountTOTPStep :execrows
+UPDATE account_totp
+SET last_used_step = ?,
+    updated_at_unix_ms = ?
+WHERE account_id = ? AND enabled = 1 AND last_used_step < ?;
+
+-- name: UpdateAccountTOTPSecret :execrows
+UPDATE account_totp
+SET secret = ?
+WHERE account_id = ? AND secret = ?;
+
+-- name: DeleteAccountTOTP :execrows
+DELETE FROM account_totp
+WHERE account_id = ?;
+
+-- name: InsertAccountRecoveryCode :exec
+INSERT INTO account_recovery_codes (
+    account_id,
+    code_hash,
+    used_at_unix_ms,
+    created_at_unix_ms
+) VALUES (?, ?, 0, ?);
+
+-- name: UseAccountRecoveryCode :execrows
+UPDATE account_recovery_codes
+SET used_at_unix_ms = ?
+WHERE account_id = ? AND code_hash = ? AND used_at_unix_ms = 0;
+
+-- name: CountUnusedAccountRecoveryCodes :one
+SELECT COUNT(*)
+FROM account_recovery_codes
+WHERE account_id = ? AND used_at_unix_ms = 0;
+
+-- name: DeleteAccountRecoveryCodes :execrows
+DELETE FROM account_recovery_codes
+WHERE account_id = ?;
+
+-- name: InsertLoginChallenge :exec
+INSERT INTO login_challenges (
+    challenge_hash,
+    account_id,
+    attempts,
+    created_at_unix_ms,
+    expires_at_unix_ms,
+    rehash_password_hash,
+    rehash_hash_algo
+) VALUES (?, ?, 0, ?, ?, ?, ?);
+
+-- name: GetLoginChallengeByHash :one
+SELECT
+    challenge_hash,
+    account_id,
+    attempts,
+    created_at_unix_ms,
+    expires_at_unix_ms,
+    rehash_password_hash,
+    rehash_hash_algo
+FROM login_challenges
+WHERE challenge_hash = ?
+LIMIT 1;
+
+-- name: IncrementLoginChallengeAttempts :execrows
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE challenge_hash = ?;
+
+-- name: DeleteLoginChallengeByHash :execrows
+DELETE FROM login_challenges
+WHERE challenge_hash = ?;
+
+-- name: DeleteExpiredLoginChallenges :execrows
+DELETE FROM login_challenges
+WHERE expires_at_unix_ms <= ?;
@@ -0,0 +1,67 @@
+-- name: InsertWorkerSysGrant :exec
+INSERT INTO worker_sys_grants (
+    node_id,
+    grantee_id,
+    capability,
+    granted_by,
+    granted_at_unix_ms,
+    expires_at_unix_ms
+) VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: DeleteWorkerSysGrantsByNodeAndGrantee :execrows
+DELETE FROM worker_sys_grants
+WHERE node_id = ?
+  AND grantee_id = ?;
+
+-- name: ListWorkerSysGrantsByNode :many
+SELECT
+    node_id,
+    grantee_id,
+    capability,
+    granted_by,
+    granted_at_unix_ms,
+    expires_at_unix_ms
+FROM worker_sys_grants
+WHERE node_id = ?
+  AND expires_at_unix_ms > ?
+ORDER BY grantee_id ASC, capability ASC;
+
+-- name: ListWorkerSysGrantsByGrantee :many
+SELECT
+    node_id,
+    grantee_id,
+    capability,
+    granted_by,
+    granted_at_unix_ms,
+    expires_at_unix_ms
+FROM worker_sys_grants
+WHERE grantee_id = ?
+  AND expires_at_unix_ms > ?
+ORDER BY node_id ASC, capability ASC;
+
+-- name: CountActiveWorkerSysGrant :one
+SELECT COUNT(1)
+FROM worker_sys_grants
+WHERE node_id = ?
+  AND grantee_id = ?
+  AND capability = ?
+  AND expires_at_unix_ms > ?;
+
+-- name: ListOnlineGrantedWorkerNodeIDsByCapability :many
+SELECT wn.node_id
+FROM worker_sys_grants g
+JOIN worker_nodes wn
+  ON wn.node_id = g.node_id
+JOIN worker_capabilities wc
+  ON wc.node_id = wn.node_id
+  AND LOWER(wc.capability_name) = g.capability
+WHERE g.grantee_id = ?
+  AND g.capability = ?
+  AND g.expires_at_unix_ms > ?
+  AND wn.last_seen_at_unix_ms >= ?
+  AND wn.session_id <> ''
+ORDER BY wn.node_id ASC;
+
+-- name: DeleteExpiredWorkerSysGrants :execrows
+DELETE FROM worker_sys_grants
+WHERE expires_at_unix_ms <= ?;
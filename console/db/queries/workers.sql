@@ -147,6 +147,13 @@ JOIN worker_capabilities wc ON wc.node_id = wn.node_id
 WHERE LOWER(wc.capability_name) = ?
   AND wn.last_seen_at_unix_ms >= ?
   AND wn.session_id <> ''
+  AND NOT EXISTS (
+    SELECT 1
+    FROM worker_labels type_label
+    WHERE type_label.node_id = wn.node_id
+      AND type_label.label_key = 'obx.worker_type'
+      AND type_label.label_value = 'private'
+  )
 ORDER BY wn.node_id ASC;
 
 -- name: ListWorkerNodeIDsByOwnerAndType :many
@@ -211,7 +218,8 @@ SELECT
     secret_hash,
     hash_algo,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM worker_credentials
 WHERE node_id = ?
 LIMIT 1;
@@ -222,7 +230,8 @@ SELECT
     secret_hash,
     hash_algo,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM worker_credentials
 ORDER BY node_id ASC;
 
@@ -232,10 +241,26 @@ INSERT INTO worker_credentials (
     secret_hash,
     hash_algo,
     created_at_unix_ms,
-    updated_at_unix_ms
-) VALUES (?, ?, ?, ?, ?)
+    updated_at_unix_ms,
+    hash_key_id
+) VALUES (?, ?, ?, ?, ?, ?)
 ON CONFLICT(node_id) DO NOTHING;
 
 -- name: DeleteWorkerCredentialByNode :execrows
 DELETE FROM worker_credentials
 WHERE node_id = ?;
+
+-- name: UpdateWorkerCredentialHash :execrows
+UPDATE worker_credentials
+SET secret_hash = ?,
+    hash_key_id = ?,
+    updated_at_unix_ms = ?
+WHERE node_id = ? AND secret_hash = ?;
+
+-- name: CountWorkerCredentialsByHashKey :many
+SELECT
+    hash_key_id,
+    COUNT(*) AS total
+FROM worker_credentials
+GROUP BY hash_key_id
+ORDER BY hash_key_id ASC;
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -19,6 +20,18 @@ const (
 	defaultLogLevel             = "info"
 	defaultLogFormat            = "json"
 	defaultLogAddSource         = false
+	defaultOIDCScopes           = "openid profile email groups"
+	defaultOIDCUsernameClaim    = "preferred_username"
+	defaultOIDCGroupsClaim      = "groups"
+	defaultOIDCAutoCreate       = true
+	defaultPasswordHashAlgo     = "bcrypt"
+	defaultPasswordMinLength    = 8
+	defaultPasswordHistory      = 5
+	defaultHashKeyID            = "default"
+	defaultPolicyDefaultAction  = "allow"
+	defaultApprovalTimeoutSec   = 600
+	defaultTaskQueueWaitSec     = 0
+	defaultTaskQueueStarveSec   = 30
 )
 
 type Config struct {
@@ -32,19 +45,55 @@ type Config struct {
 	DBPath               string
 	DBBusyTimeoutMS      int
 	HashKey              string
+	HashKeyID            string
+	RetiredHashKeys      string
+	TOTPEncryptionKey    string
 	TaskRetentionDays    int
 	EnableRegistration   bool
 	LogLevel             string
 	LogFormat            string
 	LogAddSource         bool
+	OIDCIssuer           string
+	OIDCClientID         string
+	OIDCClientSecret     string
+	OIDCRedirectURL      string
+	OIDCScopes           []string
+	OIDCUsernameClaim    string
+	OIDCGroupsClaim      string
+	OIDCAdminGroup       string
+	OIDCAutoCreate       bool
+	PasswordHashAlgo     string
+	PasswordMinLength    int
+	PasswordBlocklist    string
+	PasswordHistory      int
+	AllowedOrigins       []string
+	TrustedProxies       []string
+	PolicyDefaultAction  string
+	ApprovalTimeout      time.Duration
+	TaskQueueWait        time.Duration
+	TaskQueueStarvation  time.Duration
 }
 
-func Load() Config {
+// Load reads the console configuration from the environment. Invalid numeric
+// and log settings fall back to their defaults; settings that change security
+// behaviour fail instead.
+func Load() (Config, error) {
 	offlineTTLSec := parsePositiveIntEnv("CONSOLE_OFFLINE_TTL_SEC", defaultOfflineTTLSec)
 	replayWindowSec := parsePositiveIntEnv("CONSOLE_REPLAY_WINDOW_SEC", defaultReplayWindowSec)
 	heartbeatIntervalSec := parsePositiveIntEnv("CONSOLE_HEARTBEAT_INTERVAL_SEC", defaultHeartbeatIntervalSec)
 	dbBusyTimeoutMS := parsePositiveIntEnv("CONSOLE_DB_BUSY_TIMEOUT_MS", defaultDBBusyTimeoutMS)
 	taskRetentionDays := parsePositiveIntEnv("CONSOLE_TASK_RETENTION_DAYS", defaultTaskRetentionDays)
+	approvalTimeoutSec := parsePositiveIntEnv("CONSOLE_APPROVAL_TIMEOUT_SEC", defaultApprovalTimeoutSec)
+	taskQueueWaitSec := parseNonNegativeIntEnv("CONSOLE_TASK_QUEUE_WAIT_SEC", defaultTaskQueueWaitSec)
+	taskQueueStarveSec := parsePositiveIntEnv("CONSOLE_TASK_QUEUE_STARVATION_SEC", defaultTaskQueueStarveSec)
+	passwordHashAlgo, err := parsePasswordHashAlgoEnv("CONSOLE_PASSWORD_HASH_ALGO", defaultPasswordHashAlgo)
+	if err != nil {
+		return Config{}, err
+	}
+	policyDefaultAction, err := parsePolicyActionEnv("CONSOLE_POLICY_DEFAULT_ACTION", defaultPolicyDefaultAction)
+	if err != nil {
+		return Config{}, err
+	}
 
 	return Config{
 		HTTPAddr:             getEnv("CONSOLE_HTTP_ADDR", defaultHTTPAddr),
@@ -57,12 +106,34 @@ func Load() Config {
 		DBPath:               getEnv("CONSOLE_DB_PATH", defaultDBPath),
 		DBBusyTimeoutMS:      dbBusyTimeoutMS,
 		HashKey:              os.Getenv("CONSOLE_HASH_KEY"),
+		HashKeyID:            strings.TrimSpace(getEnv("CONSOLE_HASH_KEY_ID", defaultHashKeyID)),
+		RetiredHashKeys:      os.Getenv("CONSOLE_HASH_KEYS_RETIRED"),
+		TOTPEncryptionKey:    os.Getenv("CONSOLE_TOTP_ENCRYPTION_KEY"),
 		TaskRetentionDays:    taskRetentionDays,
 		EnableRegistration:   parseBoolEnv("CONSOLE_ENABLE_REGISTRATION", false),
 		LogLevel:             parseLogLevelEnv("CONSOLE_LOG_LEVEL", defaultLogLevel),
 		LogFormat:            parseLogFormatEnv("CONSOLE_LOG_FORMAT", defaultLogFormat),
 		LogAddSource:         parseBoolEnv("CONSOLE_LOG_ADD_SOURCE", defaultLogAddSource),
-	}
+		OIDCIssuer:           strings.TrimSpace(os.Getenv("CONSOLE_OIDC_ISSUER")),
+		OIDCClientID:         strings.TrimSpace(os.Getenv("CONSOLE_OIDC_CLIENT_ID")),
+		OIDCClientSecret:     os.Getenv("CONSOLE_OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:      strings.TrimSpace(os.Getenv("CONSOLE_OIDC_REDIRECT_URL")),
+		OIDCScopes:           parseListEnv("CONSOLE_OIDC_SCOPES", defaultOIDCScopes),
+		OIDCUsernameClaim:    strings.TrimSpace(getEnv("CONSOLE_OIDC_USERNAME_CLAIM", defaultOIDCUsernameClaim)),
+		OIDCGroupsClaim:      strings.TrimSpace(getEnv("CONSOLE_OIDC_GROUPS_CLAIM", defaultOIDCGroupsClaim)),
+		OIDCAdminGroup:       strings.TrimSpace(os.Getenv("CONSOLE_OIDC_ADMIN_GROUP")),
+		OIDCAutoCreate:       parseBoolEnv("CONSOLE_OIDC_AUTO_CREATE", defaultOIDCAutoCreate),
+		PasswordHashAlgo:     passwordHashAlgo,
+		PasswordMinLength:    parsePositiveIntEnv("CONSOLE_PASSWORD_MIN_LENGTH", defaultPasswordMinLength),
+		PasswordBlocklist:    strings.TrimSpace(os.Getenv("CONSOLE_PASSWORD_BLOCKLIST_FILE")),
+		PasswordHistory:      parseNonNegativeIntEnv("CONSOLE_PASSWORD_HISTORY", defaultPasswordHistory),
+		AllowedOrigins:       parseListEnv("CONSOLE_ALLOWED_ORIGINS", ""),
+		TrustedProxies:       parseListEnv("CONSOLE_TRUSTED_PROXIES", ""),
+		PolicyDefaultAction:  policyDefaultAction,
+		ApprovalTimeout:      time.Duration(approvalTimeoutSec) * time.Second,
+		TaskQueueWait:        time.Duration(taskQueueWaitSec) * time.Second,
+		TaskQueueStarvation:  time.Duration(taskQueueStarveSec) * time.Second,
+	}, nil
 }
 
 func getEnv(key string, defaultValue string) string {
@@ -85,6 +156,28 @@ func parsePositiveIntEnv(key string, defaultValue int) int {
 	return parsed
 }
 
+func parseNonNegativeIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return defaultValue
+	}
+	return parsed
+}
+
+func parseListEnv(key string, defaultValue string) []string {
+	value := os.Getenv(key)
+	if strings.TrimSpace(value) == "" {
+		value = defaultValue
+	}
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
 func parseBoolEnv(key string, defaultValue bool) bool {
 	value := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
 	switch value {
@@ -122,3 +215,31 @@ func parseLogFormatEnv(key string, defaultValue string) string {
 		return defaultValue
 	}
 }
+
+// parsePasswordHashAlgoEnv rejects unknown algorithms rather than silently
+// hashing new passwords with the default.
+func parsePasswordHashAlgoEnv(key string, defaultValue string) (string, error) {
+	value := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
+	if value == "" {
+		return defaultValue, nil
+	}
+	switch value {
+	case "bcrypt", "argon2id":
+		return value, nil
+	default:
+		return "", fmt.Errorf("%s must be bcrypt or argon2id, got %q", key, value)
+	}
+}
+
+func parsePolicyActionEnv(key string, defaultValue string) (string, error) {
+	value := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
+	if value == "" {
+		return defaultValue, nil
+	}
+	switch value {
+	case "allow", "deny", "require_approval":
+		return value, nil
+	default:
+		return "", fmt.Errorf("%s must be allow, deny or require_approval, got %q", key, value)
+	}
+}
@@ -1,10 +1,20 @@
 package config
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
 
+func mustLoad(t *testing.T) Config {
+	t.Helper()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	return cfg
+}
+
 func TestLoadDefaults(t *testing.T) {
 	t.Setenv("CONSOLE_HTTP_ADDR", "")
 	t.Setenv("CONSOLE_GRPC_ADDR", "")
@@ -19,7 +29,7 @@ func TestLoadDefaults(t *testing.T) {
 	t.Setenv("CONSOLE_LOG_FORMAT", "")
 	t.Setenv("CONSOLE_LOG_ADD_SOURCE", "")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.HTTPAddr != defaultHTTPAddr {
 		t.Fatalf("expected HTTPAddr=%q, got %q", defaultHTTPAddr, cfg.HTTPAddr)
 	}
@@ -67,7 +77,7 @@ func TestLoadReadsDashboardCredentialsAndDurations(t *testing.T) {
 	t.Setenv("CONSOLE_LOG_FORMAT", "text")
 	t.Setenv("CONSOLE_LOG_ADD_SOURCE", "true")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.DashboardUsername != "admin" {
 		t.Fatalf("expected username admin, got %q", cfg.DashboardUsername)
 	}
@@ -105,7 +115,7 @@ func TestLoadFallsBackForInvalidNumericEnv(t *testing.T) {
 	t.Setenv("CONSOLE_REPLAY_WINDOW_SEC", "not-a-number")
 	t.Setenv("CONSOLE_HEARTBEAT_INTERVAL_SEC", "0")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.OfflineTTL != time.Duration(defaultOfflineTTLSec)*time.Second {
 		t.Fatalf("expected default offline ttl, got %s", cfg.OfflineTTL)
 	}
@@ -119,7 +129,7 @@ func TestLoadFallsBackForInvalidNumericEnv(t *testing.T) {
 
 func TestLoadRegistrationFlagFallback(t *testing.T) {
 	t.Setenv("CONSOLE_ENABLE_REGISTRATION", "not-a-bool")
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.EnableRegistration {
 		t.Fatalf("expected invalid bool value to fallback to false")
 	}
@@ -130,7 +140,7 @@ func TestLoadLogConfigFallback(t *testing.T) {
 	t.Setenv("CONSOLE_LOG_FORMAT", "yaml")
 	t.Setenv("CONSOLE_LOG_ADD_SOURCE", "not-a-bool")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.LogLevel != defaultLogLevel {
 		t.Fatalf("expected LogLevel fallback=%q, got %q", defaultLogLevel, cfg.LogLevel)
 	}
@@ -141,3 +151,224 @@ func TestLoadLogConfigFallback(t *testing.T) {
 		t.Fatalf("expected LogAddSource fallback=%t, got %t", defaultLogAddSource, cfg.LogAddSource)
 	}
 }
+
+func TestLoadOIDCConfig(t *testing.T) {
+	t.Setenv("CONSOLE_OIDC_ISSUER", "")
+	t.Setenv("CONSOLE_OIDC_SCOPES", "")
+	t.Setenv("CONSOLE_OIDC_USERNAME_CLAIM", "")
+	t.Setenv("CONSOLE_OIDC_GROUPS_CLAIM", "")
+	t.Setenv("CONSOLE_OIDC_AUTO_CREATE", "")
+
+	cfg := mustLoad(t)
+	if cfg.OIDCIssuer != "" {
+		t.Fatalf("expected oidc disabled by default, got issuer %q", cfg.OIDCIssuer)
+	}
+	if strings.Join(cfg.OIDCScopes, " ") != defaultOIDCScopes {
+		t.Fatalf("expected default scopes, got %v", cfg.OIDCScopes)
+	}
+	if cfg.OIDCUsernameClaim != defaultOIDCUsernameClaim || cfg.OIDCGroupsClaim != defaultOIDCGroupsClaim {
+		t.Fatalf("unexpected default claims: username=%q groups=%q", cfg.OIDCUsernameClaim, cfg.OIDCGroupsClaim)
+	}
+	if !cfg.OIDCAutoCreate {
+		t.Fatalf("expected oidc auto create enabled by default")
+	}
+
+	t.Setenv("CONSOLE_OIDC_ISSUER", " https://idp.example.com ")
+	t.Setenv("CONSOLE_OIDC_CLIENT_ID", "onlyboxes")
+	t.Setenv("CONSOLE_OIDC_CLIENT_SECRET", "secret")
+	t.Setenv("CONSOLE_OIDC_REDIRECT_URL", "https://console.example.com/api/v1/console/oidc/callback")
+	t.Setenv("CONSOLE_OIDC_SCOPES", "openid, email  roles")
+	t.Setenv("CONSOLE_OIDC_USERNAME_CLAIM", "email")
+	t.Setenv("CONSOLE_OIDC_GROUPS_CLAIM", "roles")
+	t.Setenv("CONSOLE_OIDC_ADMIN_GROUP", "onlyboxes-admins")
+	t.Setenv("CONSOLE_OIDC_AUTO_CREATE", "false")
+
+	cfg = mustLoad(t)
+	if cfg.OIDCIssuer != "https://idp.example.com" || cfg.OIDCClientID != "onlyboxes" || cfg.OIDCClientSecret != "secret" {
+		t.Fatalf("unexpected oidc client config: %#v", cfg)
+	}
+	if cfg.OIDCRedirectURL != "https://console.example.com/api/v1/console/oidc/callback" {
+		t.Fatalf("unexpected redirect url %q", cfg.OIDCRedirectURL)
+	}
+	if strings.Join(cfg.OIDCScopes, ",") != "openid,email,roles" {
+		t.Fatalf("unexpected scopes %v", cfg.OIDCScopes)
+	}
+	if cfg.OIDCUsernameClaim != "email" || cfg.OIDCGroupsClaim != "roles" || cfg.OIDCAdminGroup != "onlyboxes-admins" {
+		t.Fatalf("unexpected claim mapping: %#v", cfg)
+	}
+	if cfg.OIDCAutoCreate {
+		t.Fatalf("expected oidc auto create disabled")
+	}
+}
+
+func TestLoadPasswordPolicyConfig(t *testing.T) {
+	t.Setenv("CONSOLE_PASSWORD_HASH_ALGO", "")
+	t.Setenv("CONSOLE_PASSWORD_MIN_LENGTH", "")
+	t.Setenv("CONSOLE_PASSWORD_BLOCKLIST_FILE", "")
+	t.Setenv("CONSOLE_PASSWORD_HISTORY", "")
+
+	cfg := mustLoad(t)
+	if cfg.PasswordHashAlgo != defaultPasswordHashAlgo || cfg.PasswordMinLength != defaultPasswordMinLength {
+		t.Fatalf("unexpected default password policy: algo=%q min=%d", cfg.PasswordHashAlgo, cfg.PasswordMinLength)
+	}
+	if cfg.PasswordBlocklist != "" || cfg.PasswordHistory != defaultPasswordHistory {
+		t.Fatalf("unexpected default password policy: blocklist=%q history=%d", cfg.PasswordBlocklist, cfg.PasswordHistory)
+	}
+
+	t.Setenv("CONSOLE_PASSWORD_HASH_ALGO", " Argon2id ")
+	t.Setenv("CONSOLE_PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("CONSOLE_PASSWORD_BLOCKLIST_FILE", " /etc/onlyboxes/breached.txt ")
+	t.Setenv("CONSOLE_PASSWORD_HISTORY", "0")
+
+	cfg = mustLoad(t)
+	if cfg.PasswordHashAlgo != "argon2id" || cfg.PasswordMinLength != 12 {
+		t.Fatalf("unexpected password policy: algo=%q min=%d", cfg.PasswordHashAlgo, cfg.PasswordMinLength)
+	}
+	if cfg.PasswordBlocklist != "/etc/onlyboxes/breached.txt" || cfg.PasswordHistory != 0 {
+		t.Fatalf("unexpected password policy: blocklist=%q history=%d", cfg.PasswordBlocklist, cfg.PasswordHistory)
+	}
+
+	t.Setenv("CONSOLE_PASSWORD_HASH_ALGO", "")
+	t.Setenv("CONSOLE_PASSWORD_MIN_LENGTH", "-1")
+	t.Setenv("CONSOLE_PASSWORD_HISTORY", "-3")
+
+	cfg = mustLoad(t)
+	if cfg.PasswordMinLength != defaultPasswordMinLength || cfg.PasswordHistory != defaultPasswordHistory {
+		t.Fatalf("expected invalid password policy values to fall back, got %#v", cfg)
+	}
+}
+
+func TestLoadRejectsInvalidPasswordHashAlgo(t *testing.T) {
+	t.Setenv("CONSOLE_PASSWORD_HASH_ALGO", "md5")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "CONSOLE_PASSWORD_HASH_ALGO") {
+		t.Fatalf("expected invalid hash algo to fail, got %v", err)
+	}
+}
+
+func TestLoadHashKeyConfig(t *testing.T) {
+	t.Setenv("CONSOLE_HASH_KEY_ID", "")
+	t.Setenv("CONSOLE_HASH_KEYS_RETIRED", "")
+
+	cfg := mustLoad(t)
+	if cfg.HashKeyID != defaultHashKeyID || cfg.RetiredHashKeys != "" {
+		t.Fatalf("unexpected default hash key config: id=%q retired=%q", cfg.HashKeyID, cfg.RetiredHashKeys)
+	}
+
+	t.Setenv("CONSOLE_HASH_KEY_ID", " k2026 ")
+	t.Setenv("CONSOLE_HASH_KEYS_RETIRED", "default:old-secret")
+
+	cfg = mustLoad(t)
+	if cfg.HashKeyID != "k2026" || cfg.RetiredHashKeys != "default:old-secret" {
+		t.Fatalf("unexpected hash key config: id=%q retired=%q", cfg.HashKeyID, cfg.RetiredHashKeys)
+	}
+}
+
+func TestLoadAllowedOriginsConfig(t *testing.T) {
+	t.Setenv("CONSOLE_ALLOWED_ORIGINS", "")
+	t.Setenv("CONSOLE_TRUSTED_PROXIES", "")
+
+	cfg := mustLoad(t)
+	if len(cfg.AllowedOrigins) != 0 || len(cfg.TrustedProxies) != 0 {
+		t.Fatalf("expected no allowed origins or trusted proxies by default, got %v %v", cfg.AllowedOrigins, cfg.TrustedProxies)
+	}
+
+	t.Setenv("CONSOLE_ALLOWED_ORIGINS", " https://console.example.com, https://ops.example.com ")
+	t.Setenv("CONSOLE_TRUSTED_PROXIES", "10.0.0.0/8, 127.0.0.1")
+
+	cfg = mustLoad(t)
+	if strings.Join(cfg.AllowedOrigins, ",") != "https://console.example.com,https://ops.example.com" {
+		t.Fatalf("unexpected allowed origins %v", cfg.AllowedOrigins)
+	}
+	if strings.Join(cfg.TrustedProxies, ",") != "10.0.0.0/8,127.0.0.1" {
+		t.Fatalf("unexpected trusted proxies %v", cfg.TrustedProxies)
+	}
+}
+
+func TestLoadPolicyDefaultActionConfig(t *testing.T) {
+	t.Setenv("CONSOLE_POLICY_DEFAULT_ACTION", "")
+
+	cfg := mustLoad(t)
+	if cfg.PolicyDefaultAction != defaultPolicyDefaultAction {
+		t.Fatalf("unexpected default policy action %q", cfg.PolicyDefaultAction)
+	}
+
+	t.Setenv("CONSOLE_POLICY_DEFAULT_ACTION", " Require_Approval ")
+	cfg = mustLoad(t)
+	if cfg.PolicyDefaultAction != "require_approval" {
+		t.Fatalf("unexpected policy action %q", cfg.PolicyDefaultAction)
+	}
+}
+
+func TestLoadRejectsInvalidPolicyDefaultAction(t *testing.T) {
+	t.Setenv("CONSOLE_POLICY_DEFAULT_ACTION", "denny")
+
+	_, err := Load()
+	if err == nil || !strings.Contains(err.Error(), "CONSOLE_POLICY_DEFAULT_ACTION") {
+		t.Fatalf("expected invalid policy action to fail, got %v", err)
+	}
+}
+
+func TestLoadApprovalTimeoutConfig(t *testing.T) {
+	t.Setenv("CONSOLE_APPROVAL_TIMEOUT_SEC", "")
+
+	cfg := mustLoad(t)
+	if cfg.ApprovalTimeout != defaultApprovalTimeoutSec*time.Second {
+		t.Fatalf("unexpected default approval timeout %s", cfg.ApprovalTimeout)
+	}
+
+	t.Setenv("CONSOLE_APPROVAL_TIMEOUT_SEC", "90")
+	cfg = mustLoad(t)
+	if cfg.ApprovalTimeout != 90*time.Second {
+		t.Fatalf("unexpected approval timeout %s", cfg.ApprovalTimeout)
+	}
+
+	t.Setenv("CONSOLE_APPROVAL_TIMEOUT_SEC", "0")
+	cfg = mustLoad(t)
+	if cfg.ApprovalTimeout != defaultApprovalTimeoutSec*time.Second {
+		t.Fatalf("expected invalid approval timeout to fall back, got %s", cfg.ApprovalTimeout)
+	}
+}
+
+func TestLoadTaskQueueWaitConfig(t *testing.T) {
+	t.Setenv("CONSOLE_TASK_QUEUE_WAIT_SEC", "")
+
+	cfg := mustLoad(t)
+	if cfg.TaskQueueWait != 0 {
+		t.Fatalf("expected the queue to be off by default, got %s", cfg.TaskQueueWait)
+	}
+
+	t.Setenv("CONSOLE_TASK_QUEUE_WAIT_SEC", "45")
+	cfg = mustLoad(t)
+	if cfg.TaskQueueWait != 45*time.Second {
+		t.Fatalf("unexpected task queue wait %s", cfg.TaskQueueWait)
+	}
+
+	t.Setenv("CONSOLE_TASK_QUEUE_WAIT_SEC", "-5")
+	cfg = mustLoad(t)
+	if cfg.TaskQueueWait != defaultTaskQueueWaitSec*time.Second {
+		t.Fatalf("expected invalid task queue wait to fall back, got %s", cfg.TaskQueueWait)
+	}
+}
+
+func TestLoadTaskQueueStarvationConfig(t *testing.T) {
+	t.Setenv("CONSOLE_TASK_QUEUE_STARVATION_SEC", "")
+
+	cfg := mustLoad(t)
+	if cfg.TaskQueueStarvation != defaultTaskQueueStarveSec*time.Second {
+		t.Fatalf("unexpected default task queue starvation threshold %s", cfg.TaskQueueStarvation)
+	}
+
+	t.Setenv("CONSOLE_TASK_QUEUE_STARVATION_SEC", "120")
+	cfg = mustLoad(t)
+	if cfg.TaskQueueStarvation != 120*time.Second {
+		t.Fatalf("expected configured starvation threshold, got %s", cfg.TaskQueueStarvation)
+	}
+
+	t.Setenv("CONSOLE_TASK_QUEUE_STARVATION_SEC", "0")
+	cfg = mustLoad(t)
+	if cfg.TaskQueueStarvation != defaultTaskQueueStarveSec*time.Second {
+		t.Fatalf("expected invalid starvation threshold to fall back, got %s", cfg.TaskQueueStarvation)
+	}
+}
@@ -0,0 +1,384 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+const (
+	defaultApprovalTimeout     = 10 * time.Minute
+	defaultApprovalListLimit   = 50
+	maxApprovalListLimit       = 200
+	maxApprovalCommentLength   = 1024
+	taskApprovalRejectedCode   = "approval_rejected"
+	taskApprovalExpiredCode    = "approval_expired"
+	auditActionApprovalRequest = "approval.request"
+)
+
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending  ApprovalStatus = "pending"
+	ApprovalStatusApproved ApprovalStatus = "approved"
+	ApprovalStatusRejected ApprovalStatus = "rejected"
+	ApprovalStatusExpired  ApprovalStatus = "expired"
+	ApprovalStatusCanceled ApprovalStatus = "canceled"
+)
+
+var ErrApprovalNotFound = errors.New("approval not found")
+var ErrApprovalDecided = errors.New("approval already decided")
+var ErrApprovalCommentTooLong = errors.New("comment length must be <= 1024")
+
+// ApprovalSnapshot is a task held for a human decision by a
+// require_approval policy.
+type ApprovalSnapshot struct {
+	ApprovalID  string
+	TaskID      string
+	OwnerID     string
+	TokenID     string
+	Capability  string
+	InputJSON   []byte
+	PolicyID    string
+	PolicyName  string
+	Status      ApprovalStatus
+	TaskTimeout time.Duration
+	DecidedBy   string
+	Comment     string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	DecidedAt   *time.Time
+}
+
+// pendingApproval is the in-memory side of a pending approval: the task's
+// cancelable root context and the expiry timer.
+type pendingApproval struct {
+	approvalID    string
+	taskCtx       context.Context
+	priorityClass string
+	timer         *time.Timer
+}
+
+// SetApprovalTimeout bounds how long a task waits for an approval decision.
+func (s *RegistryService) SetApprovalTimeout(timeout time.Duration) {
+	if s == nil || timeout <= 0 {
+		return
+	}
+	s.approvalsMu.Lock()
+	defer s.approvalsMu.Unlock()
+	s.approvalTimeout = timeout
+}
+
+func (s *RegistryService) currentApprovalTimeout() time.Duration {
+	s.approvalsMu.Lock()
+	defer s.approvalsMu.Unlock()
+	return s.approvalTimeout
+}
+
+// holdTaskForApproval records the approval row for a task inserted as
+// pending_approval and arms its expiry timer.
+func (s *RegistryService) holdTaskForApproval(
+	ctx context.Context,
+	taskID string,
+	taskCtx context.Context,
+	req SubmitTaskRequest,
+	capability string,
+	inputJSON []byte,
+	timeout time.Duration,
+	decision policy.Decision,
+	createdAt time.Time,
+	expiresAt time.Time,
+) error {
+	approvalID, err := generateUUIDv4()
+	if err != nil {
+		return err
+	}
+	params := sqlc.InsertTaskApprovalParams{
+		ApprovalID:      approvalID,
+		TaskID:          taskID,
+		OwnerID:         normalizeTaskOwnerID(req.OwnerID),
+		TokenID:         strings.TrimSpace(req.TokenID),
+		Capability:      capability,
+		InputJson:       string(inputJSON),
+		TaskTimeoutMs:   timeout.Milliseconds(),
+		CreatedAtUnixMs: createdAt.UnixMilli(),
+		ExpiresAtUnixMs: expiresAt.UnixMilli(),
+	}
+	if decision.Policy != nil {
+		params.PolicyID = decision.Policy.ID
+		params.PolicyName = decision.Policy.Name
+	}
+	if err := s.taskQueries().InsertTaskApproval(context.Background(), params); err != nil {
+		return err
+	}
+
+	mode, _ := ParseTaskMode(string(req.Mode))
+	pending := &pendingApproval{approvalID: approvalID, taskCtx: taskCtx, priorityClass: taskPriorityClass(mode)}
+	s.approvalsMu.Lock()
+	s.approvals[taskID] = pending
+	pending.timer = time.AfterFunc(expiresAt.Sub(createdAt), func() {
+		s.expireApproval(taskID, approvalID)
+	})
+	s.approvalsMu.Unlock()
+
+	s.recordPolicyAudit(ctx, capability, params.OwnerID, params.TokenID, inputJSON, persistence.AuditEvent{
+		Action:     auditActionApprovalRequest,
+		TargetType: "approval",
+		TargetID:   approvalID,
+		Outcome:    persistence.AuditOutcomeSuccess,
+		Details: map[string]any{
+			"task_id":   taskID,
+			"policy_id": params.PolicyID,
+		},
+	})
+	return nil
+}
+
+// takePendingApproval removes and stops the in-memory state of a task's
+// approval. It returns nil when another path already took it.
+func (s *RegistryService) takePendingApproval(taskID string) *pendingApproval {
+	s.approvalsMu.Lock()
+	defer s.approvalsMu.Unlock()
+	pending := s.approvals[taskID]
+	if pending == nil {
+		return nil
+	}
+	delete(s.approvals, taskID)
+	if pending.timer != nil {
+		pending.timer.Stop()
+	}
+	return pending
+}
+
+// withApprovalID attaches the pending approval id to a pending_approval
+// task snapshot.
+func (s *RegistryService) withApprovalID(snapshot TaskSnapshot) TaskSnapshot {
+	if snapshot.Status != TaskStatusPendingApproval {
+		return snapshot
+	}
+	s.approvalsMu.Lock()
+	defer s.approvalsMu.Unlock()
+	if pending := s.approvals[snapshot.TaskID]; pending != nil {
+		snapshot.ApprovalID = pending.approvalID
+	}
+	return snapshot
+}
+
+func (s *RegistryService) ListApprovals(ctx context.Context, ownerID string, isAdmin bool, statusFilter ApprovalStatus, limit int) ([]ApprovalSnapshot, error) {
+	queries := s.taskQueries()
+	if queries == nil {
+		return nil, errors.New("task store is unavailable")
+	}
+	if limit <= 0 {
+		limit = defaultApprovalListLimit
+	}
+	if limit > maxApprovalListLimit {
+		limit = maxApprovalListLimit
+	}
+	ownerFilter := normalizeTaskOwnerID(ownerID)
+	if isAdmin {
+		ownerFilter = ""
+	}
+	records, err := queries.ListTaskApprovals(ctx, sqlc.ListTaskApprovalsParams{
+		OwnerID: ownerFilter,
+		Status:  string(statusFilter),
+		Limit:   int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]ApprovalSnapshot, 0, len(records))
+	for _, record := range records {
+		items = append(items, convertDBApproval(record))
+	}
+	return items, nil
+}
+
+func (s *RegistryService) GetApproval(ctx context.Context, approvalID string, ownerID string, isAdmin bool) (ApprovalSnapshot, error) {
+	queries := s.taskQueries()
+	if queries == nil {
+		return ApprovalSnapshot{}, errors.New("task store is unavailable")
+	}
+	record, err := queries.GetTaskApprovalByID(ctx, strings.TrimSpace(approvalID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ApprovalSnapshot{}, ErrApprovalNotFound
+	}
+	if err != nil {
+		return ApprovalSnapshot{}, err
+	}
+	if !isAdmin && record.OwnerID != normalizeTaskOwnerID(ownerID) {
+		return ApprovalSnapshot{}, ErrApprovalNotFound
+	}
+	return convertDBApproval(record), nil
+}
+
+// DecideApproval approves or rejects a pending approval on behalf of
+// deciderID. Only the task owner or an admin may decide. An approved task is
+// queued and dispatched with its original timeout; a rejected task fails
+// with approval_rejected.
+func (s *RegistryService) DecideApproval(ctx context.Context, approvalID string, deciderID string, isAdmin bool, approve bool, comment string) (ApprovalSnapshot, error) {
+	comment = strings.TrimSpace(comment)
+	if len(comment) > maxApprovalCommentLength {
+		return ApprovalSnapshot{}, ErrApprovalCommentTooLong
+	}
+	current, err := s.GetApproval(ctx, approvalID, deciderID, isAdmin)
+	if err != nil {
+		return ApprovalSnapshot{}, err
+	}
+	if current.Status != ApprovalStatusPending {
+		return current, ErrApprovalDecided
+	}
+
+	decided := ApprovalStatusRejected
+	if approve {
+		decided = ApprovalStatusApproved
+	}
+	now := s.nowFn()
+	rows, err := s.taskQueries().DecideTaskApproval(ctx, sqlc.DecideTaskApprovalParams{
+		Status:          string(decided),
+		DecidedBy:       strings.TrimSpace(deciderID),
+		Comment:         comment,
+		DecidedAtUnixMs: now.UnixMilli(),
+		ApprovalID:      current.ApprovalID,
+	})
+	if err != nil {
+		return ApprovalSnapshot{}, err
+	}
+	if rows == 0 {
+		latest, getErr := s.GetApproval(ctx, current.ApprovalID, deciderID, isAdmin)
+		if getErr != nil {
+			return ApprovalSnapshot{}, getErr
+		}
+		return latest, ErrApprovalDecided
+	}
+
+	pending := s.takePendingApproval(current.TaskID)
+	if approve {
+		s.startApprovedTask(current, pending, now)
+	} else {
+		message := "rejected by approver"
+		if comment != "" {
+			message += ": " + comment
+		}
+		if err := s.finishTask(current.TaskID, TaskStatusFailed, nil, taskApprovalRejectedCode, message, now); err != nil &&
+			!errors.Is(err, ErrTaskTransitionNotApplied) {
+			slog.Error("failed to mark rejected task", "task_id", current.TaskID, "error", err)
+		}
+	}
+	return s.GetApproval(ctx, current.ApprovalID, deciderID, isAdmin)
+}
+
+func (s *RegistryService) startApprovedTask(approval ApprovalSnapshot, pending *pendingApproval, now time.Time) {
+	rows, err := s.taskQueries().MarkTaskApproved(context.Background(), sqlc.MarkTaskApprovedParams{
+		DeadlineAtUnixMs: now.Add(approval.TaskTimeout).UnixMilli(),
+		UpdatedAtUnixMs:  now.UnixMilli(),
+		TaskID:           approval.TaskID,
+	})
+	if err != nil {
+		slog.Error("failed to mark approved task queued", "task_id", approval.TaskID, "error", err)
+		if failErr := s.failTaskOnPersistenceError(approval.TaskID, "mark_approved", err); failErr != nil {
+			slog.Error("failed to persist fallback task failure", "task_id", approval.TaskID, "stage", "mark_approved", "error", failErr)
+		}
+		return
+	}
+	if rows == 0 {
+		// The task was canceled or expired while the decision was recorded.
+		return
+	}
+
+	taskCtx := context.Background()
+	if pending != nil && pending.taskCtx != nil {
+		taskCtx = pending.taskCtx
+	}
+	priorityClass := registry.PriorityClassInteractive
+	if pending != nil {
+		priorityClass = pending.priorityClass
+	}
+	go func() {
+		execCtx, cancel := context.WithTimeout(taskCtx, approval.TaskTimeout)
+		defer cancel()
+		s.executeTask(execCtx, approval.TaskID, approval.OwnerID, approval.Capability, approval.InputJSON, priorityClass)
+	}()
+}
+
+// expireApproval runs when nobody decided before the approval timeout.
+func (s *RegistryService) expireApproval(taskID string, approvalID string) {
+	if s.takePendingApproval(taskID) == nil {
+		return
+	}
+	now := s.nowFn()
+	rows, err := s.taskQueries().DecideTaskApproval(context.Background(), sqlc.DecideTaskApprovalParams{
+		Status:          string(ApprovalStatusExpired),
+		DecidedAtUnixMs: now.UnixMilli(),
+		ApprovalID:      approvalID,
+	})
+	if err != nil {
+		slog.Error("failed to expire approval", "approval_id", approvalID, "error", err)
+		return
+	}
+	if rows == 0 {
+		return
+	}
+	if err := s.finishTask(taskID, TaskStatusTimeout, nil, taskApprovalExpiredCode, "approval timed out", now); err != nil &&
+		!errors.Is(err, ErrTaskTransitionNotApplied) {
+		slog.Error("failed to mark expired approval task", "task_id", taskID, "error", err)
+	}
+}
+
+// closeApprovalForTask marks a still-pending approval as canceled once its
+// task has reached a terminal state some other way.
+func (s *RegistryService) closeApprovalForTask(taskID string) {
+	if s.takePendingApproval(taskID) == nil {
+		return
+	}
+	if _, err := s.taskQueries().CloseTaskApprovalForTask(context.Background(), sqlc.CloseTaskApprovalForTaskParams{
+		Status:          string(ApprovalStatusCanceled),
+		DecidedAtUnixMs: s.nowFn().UnixMilli(),
+		TaskID:          taskID,
+	}); err != nil {
+		slog.Warn("failed to close approval for task", "task_id", taskID, "error", err)
+	}
+}
+
+func ParseApprovalStatus(raw string) (ApprovalStatus, error) {
+	trimmed := ApprovalStatus(strings.TrimSpace(strings.ToLower(raw)))
+	switch trimmed {
+	case "", ApprovalStatusPending, ApprovalStatusApproved, ApprovalStatusRejected, ApprovalStatusExpired, ApprovalStatusCanceled:
+		return trimmed, nil
+	default:
+		return "", fmt.Errorf("status must be one of pending|approved|rejected|expired|canceled")
+	}
+}
+
+func convertDBApproval(record sqlc.TaskApproval) ApprovalSnapshot {
+	snapshot := ApprovalSnapshot{
+		ApprovalID:  record.ApprovalID,
+		TaskID:      record.TaskID,
+		OwnerID:     record.OwnerID,
+		TokenID:     record.TokenID,
+		Capability:  record.Capability,
+		InputJSON:   []byte(record.InputJson),
+		PolicyID:    record.PolicyID,
+		PolicyName:  record.PolicyName,
+		Status:      ApprovalStatus(record.Status),
+		TaskTimeout: time.Duration(record.TaskTimeoutMs) * time.Millisecond,
+		DecidedBy:   record.DecidedBy,
+		Comment:     record.Comment,
+		CreatedAt:   time.UnixMilli(record.CreatedAtUnixMs),
+		ExpiresAt:   time.UnixMilli(record.ExpiresAtUnixMs),
+	}
+	if record.DecidedAtUnixMs > 0 {
+		decidedAt := time.UnixMilli(record.DecidedAtUnixMs)
+		snapshot.DecidedAt = &decidedAt
+	}
+	return snapshot
+}
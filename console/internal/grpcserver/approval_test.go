@@ -0,0 +1,147 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func newApprovalTestService(t *testing.T) (*RegistryService, func(string) SubmitTaskResult) {
+	t.Helper()
+	store := registrytest.NewStore(t)
+	svc := NewRegistryService(store, map[string]string{"node-1": "secret-1"}, 5, 15, 60*time.Second)
+	engine, err := policy.NewEngine(store.Persistence().Queries, policy.ActionAllow)
+	if err != nil {
+		t.Fatalf("new policy engine: %v", err)
+	}
+	if _, err := engine.Create(context.Background(), policy.Rule{
+		Name:       "approve-shutdown",
+		Action:     policy.ActionRequireApproval,
+		AccountID:  "owner-a",
+		MatchField: policy.FieldCommand,
+		Pattern:    `^shutdown\b`,
+		Enabled:    true,
+	}); err != nil {
+		t.Fatalf("create policy: %v", err)
+	}
+	svc.SetCommandPolicy(engine)
+
+	client, cleanup := newBufClient(t, svc)
+	t.Cleanup(cleanup)
+	stream, _, err := connectWorker(client, "node-1", "secret-1", "nonce-approval", []string{"echo"})
+	if err != nil {
+		t.Fatalf("connect worker failed: %v", err)
+	}
+	go payloadEchoResponder(stream)
+
+	submit := func(requestID string) SubmitTaskResult {
+		t.Helper()
+		result, err := svc.SubmitTask(context.Background(), SubmitTaskRequest{
+			Capability:              "echo",
+			InputJSON:               []byte(`{"message":"bye","command":"shutdown now"}`),
+			Mode:                    TaskModeSync,
+			Timeout:                 2 * time.Second,
+			RequestID:               requestID,
+			OwnerID:                 "owner-a",
+			ReturnOnPendingApproval: true,
+		})
+		if err != nil {
+			t.Fatalf("submit task: %v", err)
+		}
+		if result.Task.Status != TaskStatusPendingApproval || result.Task.ApprovalID == "" {
+			t.Fatalf("expected pending approval, got %#v", result.Task)
+		}
+		return result
+	}
+	return svc, submit
+}
+
+func waitForTaskStatus(t *testing.T, svc *RegistryService, taskID string, want TaskStatus) TaskSnapshot {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		task, ok := svc.GetTask(taskID, "owner-a")
+		if ok && task.Status == want {
+			return task
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected task %s to reach %s, got %#v", taskID, want, task)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestApprovalApproveDispatchesTask(t *testing.T) {
+	svc, submit := newApprovalTestService(t)
+	held := submit("req-approve")
+
+	if _, err := svc.DecideApproval(context.Background(), held.Task.ApprovalID, "owner-b", false, true, ""); !errors.Is(err, ErrApprovalNotFound) {
+		t.Fatalf("expected other account to get not found, got %v", err)
+	}
+	approval, err := svc.DecideApproval(context.Background(), held.Task.ApprovalID, "owner-a", false, true, "looks fine")
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if approval.Status != ApprovalStatusApproved || approval.DecidedBy != "owner-a" || approval.Comment != "looks fine" || approval.PolicyName != "approve-shutdown" {
+		t.Fatalf("unexpected approval %#v", approval)
+	}
+	task := waitForTaskStatus(t, svc, held.Task.TaskID, TaskStatusSucceeded)
+	if string(task.ResultJSON) != `{"message":"bye","command":"shutdown now"}` {
+		t.Fatalf("unexpected result %s", task.ResultJSON)
+	}
+	if _, err := svc.DecideApproval(context.Background(), held.Task.ApprovalID, "admin", true, false, ""); !errors.Is(err, ErrApprovalDecided) {
+		t.Fatalf("expected second decision to fail, got %v", err)
+	}
+}
+
+func TestApprovalRejectFailsTask(t *testing.T) {
+	svc, submit := newApprovalTestService(t)
+	held := submit("req-reject")
+
+	items, err := svc.ListApprovals(context.Background(), "owner-a", false, ApprovalStatusPending, 0)
+	if err != nil || len(items) != 1 || items[0].TaskID != held.Task.TaskID {
+		t.Fatalf("expected one pending approval, got %#v err=%v", items, err)
+	}
+	if _, err := svc.DecideApproval(context.Background(), held.Task.ApprovalID, "admin", true, false, "not today"); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	task := waitForTaskStatus(t, svc, held.Task.TaskID, TaskStatusFailed)
+	if task.ErrorCode != taskApprovalRejectedCode || task.ErrorMessage != "rejected by approver: not today" {
+		t.Fatalf("unexpected rejected task %#v", task)
+	}
+}
+
+func TestApprovalExpiresAfterTimeout(t *testing.T) {
+	svc, submit := newApprovalTestService(t)
+	svc.SetApprovalTimeout(50 * time.Millisecond)
+	held := submit("req-expire")
+
+	task := waitForTaskStatus(t, svc, held.Task.TaskID, TaskStatusTimeout)
+	if task.ErrorCode != taskApprovalExpiredCode {
+		t.Fatalf("unexpected expired task %#v", task)
+	}
+	approval, err := svc.GetApproval(context.Background(), held.Task.ApprovalID, "owner-a", false)
+	if err != nil || approval.Status != ApprovalStatusExpired {
+		t.Fatalf("expected expired approval, got %#v err=%v", approval, err)
+	}
+}
+
+func TestApprovalClosedWhenTaskCanceled(t *testing.T) {
+	svc, submit := newApprovalTestService(t)
+	held := submit("req-cancel")
+
+	if _, err := svc.CancelTask(held.Task.TaskID, "owner-a"); err != nil {
+		t.Fatalf("cancel task: %v", err)
+	}
+	approval, err := svc.GetApproval(context.Background(), held.Task.ApprovalID, "owner-a", false)
+	if err != nil || approval.Status != ApprovalStatusCanceled {
+		t.Fatalf("expected canceled approval, got %#v err=%v", approval, err)
+	}
+	if _, err := svc.DecideApproval(context.Background(), held.Task.ApprovalID, "owner-a", false, true, ""); !errors.Is(err, ErrApprovalDecided) {
+		t.Fatalf("expected decision on canceled approval to fail, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	auditActionCommandDispatch = "command.dispatch"
+	maxAuditCommandBytes       = 2048
+)
+
+type commandAudit struct {
+	capability  string
+	ownerID     string
+	nodeID      string
+	commandID   string
+	payloadJSON []byte
+}
+
+// recordCommandAudit appends one command.dispatch event per dispatch attempt.
+// Payloads can carry file contents, so only the shell command (when present)
+// and a digest of the full payload are kept.
+func (s *RegistryService) recordCommandAudit(ctx context.Context, audit commandAudit, outcome commandOutcome, dispatchErr error) {
+	if s == nil || s.store == nil || s.store.Persistence() == nil {
+		return
+	}
+	digest := sha256.Sum256(audit.payloadJSON)
+	details := map[string]any{
+		"capability":     audit.capability,
+		"payload_sha256": hex.EncodeToString(digest[:]),
+	}
+	if audit.commandID != "" {
+		details["command_id"] = audit.commandID
+	}
+	if command := auditCommandFromPayload(audit.payloadJSON); command != "" {
+		details["command"] = command
+	}
+
+	result := persistence.AuditOutcomeSuccess
+	var commandErr *CommandExecutionError
+	switch {
+	case dispatchErr != nil:
+		result = persistence.AuditOutcomeFailure
+		details["error"] = auditErrorMessage(dispatchErr)
+	case errors.As(outcome.err, &commandErr):
+		result = persistence.AuditOutcomeFailure
+		details["error_code"] = commandErr.Code
+		details["error"] = commandErr.Message
+	case outcome.err != nil:
+		result = persistence.AuditOutcomeFailure
+		details["error"] = outcome.err.Error()
+	}
+
+	actorType := persistence.AuditActorAccount
+	if strings.TrimSpace(audit.ownerID) == "" {
+		actorType = persistence.AuditActorSystem
+	}
+	event := persistence.AuditEvent{
+		CreatedAt:  s.nowFn(),
+		ActorType:  actorType,
+		ActorID:    audit.ownerID,
+		AccountID:  audit.ownerID,
+		Action:     auditActionCommandDispatch,
+		TargetType: "worker",
+		TargetID:   audit.nodeID,
+		Outcome:    result,
+		Details:    details,
+	}
+	// The dispatch context is often already canceled or past its deadline
+	// here; the audit write must still happen.
+	if err := s.store.Persistence().AppendAuditEvent(context.WithoutCancel(ctx), event); err != nil {
+		slog.Warn("failed to record command audit event", "command_id", audit.commandID, "error", err)
+	}
+}
+
+func auditCommandFromPayload(payloadJSON []byte) string {
+	var decoded struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(payloadJSON, &decoded); err != nil {
+		return ""
+	}
+	command := strings.TrimSpace(decoded.Command)
+	if len(command) > maxAuditCommandBytes {
+		command = strings.ToValidUTF8(command[:maxAuditCommandBytes], "")
+	}
+	return command
+}
+
+func auditErrorMessage(err error) string {
+	if st, ok := status.FromError(err); ok {
+		return st.Message()
+	}
+	return err.Error()
+}
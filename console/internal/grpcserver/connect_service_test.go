@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
 	"github.com/onlyboxes/onlyboxes/console/internal/registry"
 	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
 	"google.golang.org/grpc"
@@ -132,6 +134,47 @@ func TestCreateProvisionedWorkerAllowsDynamicConnect(t *testing.T) {
 	_ = stream.CloseSend()
 }
 
+func TestConnectRekeysCredentialHashedWithRetiredKey(t *testing.T) {
+	store := registrytest.NewStore(t)
+	svc := NewRegistryService(store, map[string]string{}, 5, 15, 60*time.Second)
+	original, err := persistence.NewHasher("old-hash-key")
+	if err != nil {
+		t.Fatalf("new hasher: %v", err)
+	}
+	svc.SetHasher(original)
+
+	workerID, workerSecret, err := svc.CreateProvisionedWorker(time.Now(), 15*time.Second)
+	if err != nil {
+		t.Fatalf("create provisioned worker failed: %v", err)
+	}
+
+	rotated, err := persistence.NewKeyedHasher(
+		persistence.HashKey{ID: "k2", Secret: "new-hash-key"},
+		[]persistence.HashKey{{ID: persistence.DefaultHashKeyID, Secret: "old-hash-key"}},
+	)
+	if err != nil {
+		t.Fatalf("new keyed hasher: %v", err)
+	}
+	svc.SetHasher(rotated)
+
+	client, cleanup := newBufClient(t, svc)
+	defer cleanup()
+
+	stream, _, err := connectWorker(client, workerID, workerSecret, "nonce-rekey", []string{"echo"})
+	if err != nil {
+		t.Fatalf("connect with retired key failed: %v", err)
+	}
+	_ = stream.CloseSend()
+
+	wantHash := rotated.Hash(workerSecret)
+	if got, ok := svc.GetWorkerSecret(workerID); !ok || got != wantHash {
+		t.Fatalf("expected in-memory credential re-keyed to primary key")
+	}
+	if got, ok := store.GetCredentialHash(workerID); !ok || got != wantHash {
+		t.Fatalf("expected persisted credential re-keyed to primary key")
+	}
+}
+
 func TestCreateProvisionedWorkerForOwnerLimitsWorkerSysToOne(t *testing.T) {
 	store := registrytest.NewStore(t)
 	svc := NewRegistryService(store, map[string]string{}, 5, 15, 60*time.Second)
@@ -151,6 +194,21 @@ func TestCreateProvisionedWorkerForOwnerLimitsWorkerSysToOne(t *testing.T) {
 	}
 }
 
+func TestCreateProvisionedWorkerForOwnerAllowsOrganizationWorkerSysPool(t *testing.T) {
+	store := registrytest.NewStore(t)
+	svc := NewRegistryService(store, map[string]string{}, 5, 15, 60*time.Second)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := svc.CreateProvisionedWorkerForOwner("org_team", registry.WorkerTypeSys, now, 15*time.Second); err != nil {
+			t.Fatalf("create org worker-sys %d failed: %v", i, err)
+		}
+	}
+	if count := store.CountWorkersByOwnerAndType("org_team", registry.WorkerTypeSys); count != 2 {
+		t.Fatalf("expected two org worker-sys in store, got %d", count)
+	}
+}
+
 func TestCreateProvisionedWorkerForOwnerWorkerSysConcurrentSingleton(t *testing.T) {
 	store := registrytest.NewStore(t)
 	svc := NewRegistryService(store, map[string]string{}, 5, 15, 60*time.Second)
@@ -336,7 +394,7 @@ func TestConnectRejectsWorkerSysMissingReadImageCapability(t *testing.T) {
 	}
 }
 
-func TestConnectWorkerSysForcesRequiredCapabilitiesMaxInflightOne(t *testing.T) {
+func TestConnectWorkerSysDefaultsRequiredCapabilitiesMaxInflightOne(t *testing.T) {
 	store := registrytest.NewStore(t)
 	now := time.Unix(1_700_000_020, 0)
 	seeded := store.SeedProvisionedWorkers([]registry.ProvisionedWorker{
@@ -377,7 +435,7 @@ func TestConnectWorkerSysForcesRequiredCapabilitiesMaxInflightOne(t *testing.T)
 		t.Fatalf("expected computerUse capability to be present")
 	}
 	if maxInflight != 1 {
-		t.Fatalf("expected computerUse max_inflight to be forced to 1, got %d", maxInflight)
+		t.Fatalf("expected computerUse max_inflight to default to 1, got %d", maxInflight)
 	}
 	_, maxInflight, ok = session.inflightSnapshot(readImageCapabilityName)
 	if !ok {
@@ -388,6 +446,108 @@ func TestConnectWorkerSysForcesRequiredCapabilitiesMaxInflightOne(t *testing.T)
 	}
 }
 
+func TestResolveHelloWorkerSysHonorsComputerUseMaxInflight(t *testing.T) {
+	store := registrytest.NewStore(t)
+	now := time.Unix(1_700_000_020, 0)
+	store.SeedProvisionedWorkers([]registry.ProvisionedWorker{
+		{
+			NodeID: "node-sys",
+			Labels: map[string]string{
+				registry.LabelOwnerIDKey:    "owner-a",
+				registry.LabelWorkerTypeKey: registry.WorkerTypeSys,
+			},
+		},
+	}, now, 15*time.Second)
+	svc := NewRegistryService(store, map[string]string{"node-sys": "secret-sys"}, 5, 15, 60*time.Second)
+
+	for _, tc := range []struct {
+		declared int32
+		want     int32
+	}{
+		{declared: 0, want: 1},
+		{declared: 4, want: 4},
+		{declared: 99, want: maxWorkerSysComputerUseMaxInflight},
+	} {
+		resolved, err := svc.resolveHelloByWorkerType(&registryv1.ConnectHello{
+			NodeId: "node-sys",
+			Capabilities: []*registryv1.CapabilityDeclaration{
+				{Name: computerUseCapabilityDeclared, MaxInflight: tc.declared},
+				{Name: readImageCapabilityDeclared, MaxInflight: tc.declared},
+			},
+		})
+		if err != nil {
+			t.Fatalf("resolve hello with max_inflight=%d: %v", tc.declared, err)
+		}
+		for _, capability := range resolved.GetCapabilities() {
+			want := tc.want
+			if capability.GetName() == readImageCapabilityDeclared {
+				want = 1
+			}
+			if capability.GetMaxInflight() != want {
+				t.Fatalf("declared %d: expected %s max_inflight %d, got %d", tc.declared, capability.GetName(), want, capability.GetMaxInflight())
+			}
+		}
+	}
+}
+
+func TestResolveHelloWorkerSysAcceptsOptionalFileCapabilities(t *testing.T) {
+	store := registrytest.NewStore(t)
+	now := time.Unix(1_700_000_020, 0)
+	store.SeedProvisionedWorkers([]registry.ProvisionedWorker{
+		{
+			NodeID: "node-sys",
+			Labels: map[string]string{
+				registry.LabelOwnerIDKey:    "owner-a",
+				registry.LabelWorkerTypeKey: registry.WorkerTypeSys,
+			},
+		},
+	}, now, 15*time.Second)
+	svc := NewRegistryService(store, map[string]string{"node-sys": "secret-sys"}, 5, 15, 60*time.Second)
+
+	resolved, err := svc.resolveHelloByWorkerType(&registryv1.ConnectHello{
+		NodeId: "node-sys",
+		Capabilities: []*registryv1.CapabilityDeclaration{
+			{Name: computerUseCapabilityDeclared, MaxInflight: 2},
+			{Name: readImageCapabilityDeclared},
+			{Name: "READFILE", MaxInflight: 8},
+			{Name: writeFileCapabilityDeclared, MaxInflight: 8},
+			{Name: writeFileCapabilityDeclared, MaxInflight: 8},
+			{Name: listDirCapabilityDeclared},
+			{Name: screenshotCapabilityDeclared, MaxInflight: 4},
+		},
+	})
+	if err != nil {
+		t.Fatalf("resolve hello with file capabilities: %v", err)
+	}
+	got := map[string]int32{}
+	for _, capability := range resolved.GetCapabilities() {
+		got[capability.GetName()] = capability.GetMaxInflight()
+	}
+	want := map[string]int32{
+		computerUseCapabilityDeclared: 2,
+		readImageCapabilityDeclared:   1,
+		readFileCapabilityDeclared:    1,
+		writeFileCapabilityDeclared:   1,
+		listDirCapabilityDeclared:     1,
+		screenshotCapabilityDeclared:  1,
+	}
+	if len(resolved.GetCapabilities()) != len(want) || !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected capabilities %v", resolved.GetCapabilities())
+	}
+
+	_, err = svc.resolveHelloByWorkerType(&registryv1.ConnectHello{
+		NodeId: "node-sys",
+		Capabilities: []*registryv1.CapabilityDeclaration{
+			{Name: computerUseCapabilityDeclared},
+			{Name: readImageCapabilityDeclared},
+			{Name: "pythonExec"},
+		},
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for unknown capability, got %v", err)
+	}
+}
+
 func TestConnectAndHeartbeatSuccess(t *testing.T) {
 	svc := NewRegistryService(registrytest.NewStore(t), map[string]string{"node-1": "secret-1"}, 5, 15, 60*time.Second)
 	svc.newSessionIDFn = func() (string, error) {
@@ -1057,7 +1217,7 @@ func TestDispatchCommandTerminalRouteRollbackWhenEnqueueFails(t *testing.T) {
 		t.Fatalf("marshal payload failed: %v", err)
 	}
 
-	_, dispatchErr := svc.dispatchCommand(context.Background(), taskCapabilityTerminalExec, payloadJSON, 30*time.Millisecond, "owner-a", nil)
+	_, dispatchErr := svc.dispatchCommand(context.Background(), taskCapabilityTerminalExec, payloadJSON, 30*time.Millisecond, "owner-a", "", nil)
 	if !errors.Is(dispatchErr, context.DeadlineExceeded) {
 		t.Fatalf("expected context.DeadlineExceeded, got %v", dispatchErr)
 	}
@@ -1108,7 +1268,7 @@ func TestDispatchCommandTerminalSessionNotFoundClearsRoute(t *testing.T) {
 		t.Fatalf("marshal payload failed: %v", err)
 	}
 
-	outcome, dispatchErr := svc.dispatchCommand(context.Background(), taskCapabilityTerminalExec, payloadJSON, 2*time.Second, "owner-a", nil)
+	outcome, dispatchErr := svc.dispatchCommand(context.Background(), taskCapabilityTerminalExec, payloadJSON, 2*time.Second, "owner-a", "", nil)
 	if dispatchErr != nil {
 		t.Fatalf("dispatch command failed: %v", dispatchErr)
 	}
@@ -1124,6 +1284,66 @@ func TestDispatchCommandTerminalSessionNotFoundClearsRoute(t *testing.T) {
 	}
 }
 
+func TestDispatchCommandRecordsAuditEvent(t *testing.T) {
+	svc := NewRegistryService(registrytest.NewStore(t), nil, 5, 15, 60*time.Second)
+	now := time.Unix(1_700_000_300, 0)
+	svc.nowFn = func() time.Time {
+		return now
+	}
+
+	hello := &registryv1.ConnectHello{
+		NodeId:       "node-1",
+		Capabilities: []*registryv1.CapabilityDeclaration{{Name: taskCapabilityTerminalExec}},
+	}
+	if err := svc.store.Upsert(hello, "worker-session-1", now); err != nil {
+		t.Fatalf("seed store upsert failed: %v", err)
+	}
+	session := newActiveSession("node-1", "worker-session-1", hello)
+	svc.swapSession(session)
+
+	go func() {
+		response := <-session.commandOutbound
+		dispatch := response.GetCommandDispatch()
+		if dispatch == nil {
+			return
+		}
+		session.resolvePending(&registryv1.CommandResult{
+			CommandId:       dispatch.GetCommandId(),
+			PayloadJson:     []byte(`{"exit_code":0}`),
+			CompletedUnixMs: now.UnixMilli(),
+		})
+	}()
+
+	payloadJSON, err := json.Marshal(terminalExecScopedPayload{Command: "id -u"})
+	if err != nil {
+		t.Fatalf("marshal payload failed: %v", err)
+	}
+	if _, err := svc.dispatchCommand(context.Background(), taskCapabilityTerminalExec, payloadJSON, 2*time.Second, "owner-a", "", nil); err != nil {
+		t.Fatalf("dispatch command failed: %v", err)
+	}
+	if _, err := svc.dispatchCommand(context.Background(), "computerUse", []byte(`{"command":"ls"}`), time.Second, "owner-b", "", nil); !errors.Is(err, ErrNoCapabilityWorker) {
+		t.Fatalf("expected no worker error, got %v", err)
+	}
+
+	events, err := svc.store.Persistence().ListAuditEvents(context.Background(), persistence.AuditFilter{Action: auditActionCommandDispatch}, 0, 10)
+	if err != nil {
+		t.Fatalf("list audit events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 command audit events, got %#v", events)
+	}
+	failed, succeeded := events[0], events[1]
+	if succeeded.AccountID != "owner-a" || succeeded.TargetID != "node-1" || succeeded.Outcome != persistence.AuditOutcomeSuccess {
+		t.Fatalf("unexpected successful dispatch event %#v", succeeded)
+	}
+	if !strings.Contains(succeeded.DetailsJson, `"command":"id -u"`) || !strings.Contains(succeeded.DetailsJson, `"command_id"`) {
+		t.Fatalf("expected command and command_id in details, got %s", succeeded.DetailsJson)
+	}
+	if failed.AccountID != "owner-b" || failed.TargetID != "" || failed.Outcome != persistence.AuditOutcomeFailure {
+		t.Fatalf("unexpected failed dispatch event %#v", failed)
+	}
+}
+
 func TestPruneExpiredTerminalSessionRoutes(t *testing.T) {
 	svc := NewRegistryService(registrytest.NewStore(t), nil, 5, 15, 60*time.Second)
 	svc.terminalRouteTTL = 1000 * time.Millisecond
@@ -1166,6 +1386,66 @@ func TestPickSessionForNodeAndCapabilityReturnsNoWorkerCapacityWhenFull(t *testi
 	}
 }
 
+func TestPickSessionForCapabilityPrefersPrivatePool(t *testing.T) {
+	store := registrytest.NewStore(t)
+	now := time.Now()
+	svc := NewRegistryService(store, nil, 5, 15, 60*time.Second)
+	seed := func(nodeID string, labels map[string]string) *activeSession {
+		t.Helper()
+		hello := &registryv1.ConnectHello{
+			NodeId:       nodeID,
+			Labels:       labels,
+			Capabilities: []*registryv1.CapabilityDeclaration{{Name: "echo", MaxInflight: 1}},
+		}
+		if err := store.Upsert(hello, "session-"+nodeID, now); err != nil {
+			t.Fatalf("seed %s: %v", nodeID, err)
+		}
+		session := newActiveSession(nodeID, "session-"+nodeID, hello)
+		svc.swapSession(session)
+		return session
+	}
+	seed("node-shared", nil)
+	private := seed("node-private-a", map[string]string{
+		registry.LabelOwnerIDKey:    "owner-a",
+		registry.LabelWorkerTypeKey: registry.WorkerTypePrivate,
+	})
+
+	session, err := svc.pickSessionForCapability("echo", "owner-a")
+	if err != nil || session.nodeID != "node-private-a" {
+		t.Fatalf("expected owner-a to use its private worker first, got %v err=%v", session, err)
+	}
+	session, err = svc.pickSessionForCapability("echo", "owner-a")
+	if err != nil || session.nodeID != "node-shared" {
+		t.Fatalf("expected owner-a to fall back to the shared pool, got %v err=%v", session, err)
+	}
+	session.releaseCapability("echo")
+
+	for _, ownerID := range []string{"owner-b", ""} {
+		session, err := svc.pickSessionForCapability("echo", ownerID)
+		if err != nil || session.nodeID != "node-shared" {
+			t.Fatalf("expected %q to stay on the shared pool, got %v err=%v", ownerID, session, err)
+		}
+		session.releaseCapability("echo")
+	}
+	private.releaseCapability("echo")
+	if _, err := svc.pickSessionForCapability("echo", "owner-b"); err != nil {
+		t.Fatalf("expected shared worker for owner-b, got %v", err)
+	}
+	if _, err := svc.pickSessionForCapability("echo", "owner-b"); !errors.Is(err, ErrNoWorkerCapacity) {
+		t.Fatalf("expected owner-b never to reach the private worker, got %v", err)
+	}
+
+	if err := store.SetPrivatePoolFallback("owner-a", false, now); err != nil {
+		t.Fatalf("disable fallback: %v", err)
+	}
+	if _, err := svc.pickSessionForCapability("echo", "owner-a"); err != nil {
+		t.Fatalf("expected private worker for owner-a, got %v", err)
+	}
+	if _, err := svc.pickSessionForCapability("echo", "owner-a"); !errors.Is(err, ErrNoWorkerCapacity) {
+		t.Fatalf("expected no fallback once disabled, got %v", err)
+	}
+}
+
 func TestDispatchEchoCommandError(t *testing.T) {
 	svc := NewRegistryService(registrytest.NewStore(t), map[string]string{"node-1": "secret-1"}, 5, 15, 60*time.Second)
 	client, cleanup := newBufClient(t, svc)
@@ -1683,7 +1963,7 @@ func TestDispatchCommandContextCanceledAfterEnqueueCleansPending(t *testing.T) {
 
 	errCh := make(chan error, 1)
 	go func() {
-		_, dispatchErr := svc.dispatchCommand(ctx, "echo", buildEchoPayload("cleanup"), 2*time.Second, "", nil)
+		_, dispatchErr := svc.dispatchCommand(ctx, "echo", buildEchoPayload("cleanup"), 2*time.Second, "", "", nil)
 		errCh <- dispatchErr
 	}()
 
@@ -0,0 +1,519 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+const (
+	// defaultQueueStarvationThreshold is how long a task may wait before it
+	// is served ahead of every priority class and weight.
+	defaultQueueStarvationThreshold = 30 * time.Second
+	// queueRecheckInterval retries waiting tasks in case a slot was freed
+	// without a dispatch finishing, e.g. by a worker raising its limits.
+	queueRecheckInterval = time.Second
+)
+
+// QueueOwnerStats describes how one owner's tasks fared in the fair-share
+// queue since the console started.
+type QueueOwnerStats struct {
+	OwnerID            string
+	Weight             int
+	PriorityClass      string
+	WaitingInteractive int
+	WaitingBatch       int
+	OldestWaiting      time.Duration
+	Dispatched         int64
+	StarvedDispatches  int64
+	Abandoned          int64
+	TotalWait          time.Duration
+	MaxWait            time.Duration
+}
+
+// QueueStats is a snapshot of the fair-share queue.
+type QueueStats struct {
+	Enabled             bool
+	MaxWait             time.Duration
+	StarvationThreshold time.Duration
+	Owners              []QueueOwnerStats
+}
+
+// fairQueue orders tasks waiting for a worker slot. Each capability has its
+// own lane with start-time fair queuing between owners: a waiter's start
+// tag is max(lane virtual time, owner's last finish tag) and its finish tag
+// adds 1/weight, so an owner with weight 2 gets twice the slots of an owner
+// with weight 1 while both have tasks waiting.
+//
+// One recheck loop per queue runs while any lane has waiters. Scheduling
+// passes of a lane are serialized and pick workers outside mu, so store
+// lookups never hold up enqueues, abandons or other lanes.
+type fairQueue struct {
+	mu                  sync.Mutex
+	maxWait             time.Duration
+	starvationThreshold time.Duration
+	nextSeq             uint64
+	lanes               map[string]*queueLane
+	owners              map[string]*queueOwnerRecord
+	rechecking          bool
+}
+
+type queueLane struct {
+	virtualTime float64
+	lastFinish  map[string]float64
+	waiters     []*queueWaiter
+	// scheduling is set while a pass runs; rescheduled asks it to run again
+	// once done because a slot was freed in the meantime.
+	scheduling  bool
+	rescheduled bool
+}
+
+type queueWaiter struct {
+	ownerID           string
+	class             string
+	terminalSessionID string
+	start             float64
+	finish            float64
+	seq               uint64
+	enqueuedAt        time.Time
+	pick              func() (*activeSession, bool, error)
+	grant             chan queueGrant
+}
+
+type queueGrant struct {
+	session              *activeSession
+	terminalRouteCreated bool
+	err                  error
+}
+
+type queueOwnerRecord struct {
+	weight            int
+	priorityClass     string
+	dispatched        int64
+	starvedDispatches int64
+	abandoned         int64
+	totalWait         time.Duration
+	maxWait           time.Duration
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{
+		starvationThreshold: defaultQueueStarvationThreshold,
+		lanes:               make(map[string]*queueLane),
+		owners:              make(map[string]*queueOwnerRecord),
+	}
+}
+
+// SetTaskQueueWait lets tasks wait up to wait for a worker slot instead of
+// failing with no_capacity when every worker is busy. Zero turns the queue
+// off.
+func (s *RegistryService) SetTaskQueueWait(wait time.Duration) {
+	if s == nil || wait < 0 {
+		return
+	}
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+	s.queue.maxWait = wait
+}
+
+// SetTaskQueueStarvationThreshold sets how long a task may wait before it
+// is served ahead of every priority class and weight.
+func (s *RegistryService) SetTaskQueueStarvationThreshold(threshold time.Duration) {
+	if s == nil || threshold <= 0 {
+		return
+	}
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+	s.queue.starvationThreshold = threshold
+}
+
+func (s *RegistryService) taskQueueWait() time.Duration {
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+	return s.queue.maxWait
+}
+
+// QueueStats returns per-owner wait times and queue depth.
+func (s *RegistryService) QueueStats() QueueStats {
+	now := s.nowFn()
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+
+	byOwner := make(map[string]*QueueOwnerStats, len(s.queue.owners))
+	for ownerID, record := range s.queue.owners {
+		byOwner[ownerID] = &QueueOwnerStats{
+			OwnerID:           ownerID,
+			Weight:            record.weight,
+			PriorityClass:     record.priorityClass,
+			Dispatched:        record.dispatched,
+			StarvedDispatches: record.starvedDispatches,
+			Abandoned:         record.abandoned,
+			TotalWait:         record.totalWait,
+			MaxWait:           record.maxWait,
+		}
+	}
+	for _, lane := range s.queue.lanes {
+		for _, waiter := range lane.waiters {
+			stats := byOwner[waiter.ownerID]
+			if stats == nil {
+				continue
+			}
+			if waiter.class == registry.PriorityClassBatch {
+				stats.WaitingBatch++
+			} else {
+				stats.WaitingInteractive++
+			}
+			stats.OldestWaiting = max(stats.OldestWaiting, now.Sub(waiter.enqueuedAt))
+		}
+	}
+
+	owners := make([]QueueOwnerStats, 0, len(byOwner))
+	for _, stats := range byOwner {
+		owners = append(owners, *stats)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return owners[i].OwnerID < owners[j].OwnerID
+	})
+	return QueueStats{
+		Enabled:             s.queue.maxWait > 0,
+		MaxWait:             s.queue.maxWait,
+		StarvationThreshold: s.queue.starvationThreshold,
+		Owners:              owners,
+	}
+}
+
+// acquireDispatchSession takes a worker slot for a command. Task commands
+// (class set) wait in the fair-share queue while every slot is taken, up to
+// the configured queue wait; other commands and a disabled queue fail with
+// ErrNoWorkerCapacity right away.
+func (s *RegistryService) acquireDispatchSession(
+	ctx context.Context,
+	capability string,
+	ownerID string,
+	terminalSessionID string,
+	class string,
+) (*activeSession, bool, error) {
+	pick := func() (*activeSession, bool, error) {
+		return s.pickSessionForDispatch(capability, ownerID, terminalSessionID)
+	}
+	maxWait := s.taskQueueWait()
+	if class == "" || maxWait <= 0 || normalizeTaskOwnerID(ownerID) == "" {
+		return pick()
+	}
+
+	settings := s.ownerSchedulingSettings(ownerID)
+	if settings.PriorityClass == registry.PriorityClassBatch {
+		class = registry.PriorityClassBatch
+	}
+	waiter := s.queue.enqueue(capability, ownerID, class, terminalSessionID, settings, s.nowFn(), pick)
+	s.startQueueRecheck()
+	s.scheduleQueue(capability)
+
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+	select {
+	case grant := <-waiter.grant:
+		return grant.session, grant.terminalRouteCreated, grant.err
+	case <-timer.C:
+		if s.queue.abandon(capability, waiter) {
+			return nil, false, ErrNoWorkerCapacity
+		}
+		grant := <-waiter.grant
+		return grant.session, grant.terminalRouteCreated, grant.err
+	case <-ctx.Done():
+		if !s.queue.abandon(capability, waiter) {
+			// The slot was granted as the context ended; hand it back.
+			s.returnQueueGrant(capability, waiter, <-waiter.grant)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, false, context.DeadlineExceeded
+		}
+		return nil, false, context.Canceled
+	}
+}
+
+// returnQueueGrant releases a slot picked for a waiter that is no longer
+// waiting and offers it to the rest of the lane.
+func (s *RegistryService) returnQueueGrant(capability string, waiter *queueWaiter, grant queueGrant) {
+	if grant.err != nil {
+		return
+	}
+	grant.session.releaseCapability(capability)
+	if grant.terminalRouteCreated {
+		s.clearTerminalSessionRoute(waiter.terminalSessionID, grant.session.nodeID)
+	}
+	s.scheduleQueue(capability)
+}
+
+// startQueueRecheck starts the queue's recheck loop unless it is running.
+// The loop exits once no lane has waiters.
+func (s *RegistryService) startQueueRecheck() {
+	s.queue.mu.Lock()
+	defer s.queue.mu.Unlock()
+	if s.queue.rechecking {
+		return
+	}
+	s.queue.rechecking = true
+	go s.recheckQueues()
+}
+
+func (s *RegistryService) recheckQueues() {
+	ticker := time.NewTicker(queueRecheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.queue.mu.Lock()
+		if !s.queue.hasWaitersLocked() {
+			s.queue.rechecking = false
+			s.queue.mu.Unlock()
+			return
+		}
+		s.queue.mu.Unlock()
+		s.scheduleAllQueues()
+	}
+}
+
+// scheduleQueue hands free slots to the waiters of capability in order:
+// starved waiters first (oldest first), then interactive before batch, then
+// by fair-share start tag. A waiter whose workers are all busy is skipped
+// so owners with other pools, e.g. private workers, still get theirs.
+//
+// Waiters are ranked under the queue lock and their workers are picked
+// outside it. Only one pass runs per lane; a call during a pass makes that
+// pass start over instead.
+func (s *RegistryService) scheduleQueue(capability string) {
+	if !s.queue.beginPass(capability) {
+		return
+	}
+	tried := make(map[*queueWaiter]struct{})
+	for {
+		waiter := s.queue.nextCandidate(capability, tried, s.nowFn())
+		if waiter == nil {
+			return
+		}
+		session, terminalRouteCreated, err := waiter.pick()
+		if errors.Is(err, ErrNoWorkerCapacity) {
+			continue
+		}
+		grant := queueGrant{session: session, terminalRouteCreated: terminalRouteCreated, err: err}
+		if !s.queue.grant(capability, waiter, grant, s.nowFn()) {
+			// The waiter gave up while its worker was picked.
+			s.returnQueueGrant(capability, waiter, grant)
+		}
+	}
+}
+
+// scheduleAllQueues runs a scheduling pass on every lane with waiters.
+func (s *RegistryService) scheduleAllQueues() {
+	s.queue.mu.Lock()
+	capabilities := make([]string, 0, len(s.queue.lanes))
+	for capability, lane := range s.queue.lanes {
+		if len(lane.waiters) > 0 {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	s.queue.mu.Unlock()
+	for _, capability := range capabilities {
+		s.scheduleQueue(capability)
+	}
+}
+
+func (s *RegistryService) ownerSchedulingSettings(ownerID string) registry.SchedulingSettings {
+	if s.store == nil {
+		return registry.DefaultSchedulingSettings()
+	}
+	settings, err := s.store.SchedulingSettings(ownerID)
+	if err != nil {
+		slog.Warn("failed to load scheduling settings", "owner_id", ownerID, "error", err)
+	}
+	return settings
+}
+
+// beginPass starts a scheduling pass of capability. It reports false when
+// the lane is empty or a pass is already running; the running pass then
+// starts over so it sees the slot freed by the caller.
+func (q *fairQueue) beginPass(capability string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane := q.lanes[capability]
+	if lane == nil || len(lane.waiters) == 0 {
+		return false
+	}
+	if lane.scheduling {
+		lane.rescheduled = true
+		return false
+	}
+	lane.scheduling = true
+	lane.rescheduled = false
+	return true
+}
+
+// nextCandidate returns the first waiter in service order that the pass
+// has not tried yet, re-sorting the lane so waiters that arrived during
+// the pass are ranked too. It ends the pass when every waiter was tried.
+func (q *fairQueue) nextCandidate(capability string, tried map[*queueWaiter]struct{}, now time.Time) *queueWaiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane := q.lanes[capability]
+	if lane.rescheduled {
+		clear(tried)
+		lane.rescheduled = false
+	}
+	sort.SliceStable(lane.waiters, func(i, j int) bool {
+		return queueWaiterBefore(lane.waiters[i], lane.waiters[j], now, q.starvationThreshold)
+	})
+	for _, waiter := range lane.waiters {
+		if _, ok := tried[waiter]; !ok {
+			tried[waiter] = struct{}{}
+			return waiter
+		}
+	}
+	lane.scheduling = false
+	return nil
+}
+
+// grant hands grant to waiter and removes it from the lane. It reports
+// false when the waiter already left the queue.
+func (q *fairQueue) grant(capability string, waiter *queueWaiter, grant queueGrant, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane := q.lanes[capability]
+	index := slices.Index(lane.waiters, waiter)
+	if index < 0 {
+		return false
+	}
+	lane.waiters = slices.Delete(lane.waiters, index, index+1)
+	if grant.err == nil {
+		q.recordGrantLocked(lane, waiter, now)
+	}
+	waiter.grant <- grant
+	return true
+}
+
+func (q *fairQueue) hasWaitersLocked() bool {
+	for _, lane := range q.lanes {
+		if len(lane.waiters) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *fairQueue) enqueue(
+	capability string,
+	ownerID string,
+	class string,
+	terminalSessionID string,
+	settings registry.SchedulingSettings,
+	now time.Time,
+	pick func() (*activeSession, bool, error),
+) *queueWaiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane := q.lanes[capability]
+	if lane == nil {
+		lane = &queueLane{lastFinish: make(map[string]float64)}
+		q.lanes[capability] = lane
+	}
+	weight := max(settings.Weight, 1)
+	start := max(lane.virtualTime, lane.lastFinish[ownerID])
+	waiter := &queueWaiter{
+		ownerID:           ownerID,
+		class:             class,
+		terminalSessionID: terminalSessionID,
+		start:             start,
+		finish:            start + 1/float64(weight),
+		seq:               q.nextSeq,
+		enqueuedAt:        now,
+		pick:              pick,
+		grant:             make(chan queueGrant, 1),
+	}
+	q.nextSeq++
+	lane.lastFinish[ownerID] = waiter.finish
+	lane.waiters = append(lane.waiters, waiter)
+
+	record := q.ownerRecordLocked(ownerID)
+	record.weight = weight
+	record.priorityClass = settings.PriorityClass
+	return waiter
+}
+
+// abandon removes a waiter that gave up. It reports false when the waiter
+// was already granted, in which case the grant is in its channel.
+func (q *fairQueue) abandon(capability string, waiter *queueWaiter) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lane := q.lanes[capability]
+	if lane == nil {
+		return false
+	}
+	index := slices.Index(lane.waiters, waiter)
+	if index < 0 {
+		return false
+	}
+	lane.waiters = slices.Delete(lane.waiters, index, index+1)
+	// Do not charge the owner for a slot it never used.
+	if lane.lastFinish[waiter.ownerID] == waiter.finish {
+		lane.lastFinish[waiter.ownerID] = waiter.start
+	}
+	q.ownerRecordLocked(waiter.ownerID).abandoned++
+	return true
+}
+
+func (q *fairQueue) recordGrantLocked(lane *queueLane, waiter *queueWaiter, now time.Time) {
+	lane.virtualTime = max(lane.virtualTime, waiter.start)
+	for ownerID, finish := range lane.lastFinish {
+		if finish <= lane.virtualTime {
+			delete(lane.lastFinish, ownerID)
+		}
+	}
+	wait := now.Sub(waiter.enqueuedAt)
+	record := q.ownerRecordLocked(waiter.ownerID)
+	record.dispatched++
+	record.totalWait += wait
+	record.maxWait = max(record.maxWait, wait)
+	if wait >= q.starvationThreshold {
+		record.starvedDispatches++
+	}
+}
+
+func (q *fairQueue) ownerRecordLocked(ownerID string) *queueOwnerRecord {
+	record := q.owners[ownerID]
+	if record == nil {
+		record = &queueOwnerRecord{weight: registry.DefaultSchedulingWeight, priorityClass: registry.PriorityClassInteractive}
+		q.owners[ownerID] = record
+	}
+	return record
+}
+
+func queueWaiterBefore(a *queueWaiter, b *queueWaiter, now time.Time, starvationThreshold time.Duration) bool {
+	aStarved := now.Sub(a.enqueuedAt) >= starvationThreshold
+	bStarved := now.Sub(b.enqueuedAt) >= starvationThreshold
+	if aStarved != bStarved {
+		return aStarved
+	}
+	if aStarved {
+		return a.seq < b.seq
+	}
+	if a.class != b.class {
+		return a.class == registry.PriorityClassInteractive
+	}
+	if a.start != b.start {
+		return a.start < b.start
+	}
+	return a.seq < b.seq
+}
+
+// taskPriorityClass queues async tasks as batch work; sync and auto callers
+// are waiting on the answer.
+func taskPriorityClass(mode TaskMode) string {
+	if mode == TaskModeAsync {
+		return registry.PriorityClassBatch
+	}
+	return registry.PriorityClassInteractive
+}
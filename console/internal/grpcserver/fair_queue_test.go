@@ -0,0 +1,292 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func TestFairQueueOrdersWaitersAcrossOwners(t *testing.T) {
+	store := registrytest.NewStore(t)
+	svc := NewRegistryService(store, map[string]string{"node-1": "secret-1"}, 5, 15, 60*time.Second)
+	svc.SetTaskQueueWait(5 * time.Second)
+	client, cleanup := newBufClient(t, svc)
+	defer cleanup()
+	stream, _, err := connectWorker(client, "node-1", "secret-1", "nonce-fair-queue", []string{"echo"})
+	if err != nil {
+		t.Fatalf("connect worker failed: %v", err)
+	}
+	go payloadEchoResponder(stream)
+	session := svc.getSession("node-1")
+	if session == nil {
+		t.Fatalf("expected active session for node-1")
+	}
+	for i := 0; i < defaultCapabilityMaxInflight; i++ {
+		if !session.tryAcquireCapability("echo") {
+			t.Fatalf("expected capability slot %d to be acquirable", i)
+		}
+	}
+
+	type grant struct {
+		ownerID string
+		err     error
+	}
+	granted := make(chan grant, 16)
+	waiting := 0
+	enqueue := func(ownerID string, class string) {
+		t.Helper()
+		go func() {
+			_, _, err := svc.acquireDispatchSession(context.Background(), "echo", ownerID, "", class)
+			granted <- grant{ownerID: ownerID, err: err}
+		}()
+		waiting++
+		deadline := time.Now().Add(2 * time.Second)
+		// Wait for the waiter's own scheduling pass to finish so the
+		// next release is handed out by a fresh pass.
+		for queuedWaiters(svc) != waiting || laneScheduling(svc, "echo") {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d waiters, got %d", waiting, queuedWaiters(svc))
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	next := func() string {
+		t.Helper()
+		session.releaseCapability("echo")
+		svc.scheduleQueue("echo")
+		select {
+		case result := <-granted:
+			if result.err != nil {
+				t.Fatalf("expected %s to get a slot, got %v", result.ownerID, result.err)
+			}
+			waiting--
+			return result.ownerID
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected a waiter to be granted")
+			return ""
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		enqueue("owner-heavy", registry.PriorityClassBatch)
+	}
+	enqueue("owner-light", registry.PriorityClassBatch)
+	enqueue("owner-interactive", registry.PriorityClassInteractive)
+	for _, want := range []string{"owner-interactive", "owner-heavy", "owner-light", "owner-heavy", "owner-heavy"} {
+		if got := next(); got != want {
+			t.Fatalf("expected %s to be served next, got %s", want, got)
+		}
+	}
+
+	if err := store.SetSchedulingSettings("owner-batch", registry.SchedulingSettings{Weight: 1, PriorityClass: registry.PriorityClassBatch}, time.Now()); err != nil {
+		t.Fatalf("set scheduling settings: %v", err)
+	}
+	enqueue("owner-starved", registry.PriorityClassBatch)
+	enqueue("owner-batch", registry.PriorityClassInteractive)
+	enqueue("owner-interactive", registry.PriorityClassInteractive)
+	svc.queue.mu.Lock()
+	for _, waiter := range svc.queue.lanes["echo"].waiters {
+		if waiter.ownerID == "owner-starved" {
+			waiter.enqueuedAt = waiter.enqueuedAt.Add(-defaultQueueStarvationThreshold)
+		}
+	}
+	svc.queue.mu.Unlock()
+	for _, want := range []string{"owner-starved", "owner-interactive", "owner-batch"} {
+		if got := next(); got != want {
+			t.Fatalf("expected %s to be served next, got %s", want, got)
+		}
+	}
+
+	stats := svc.QueueStats()
+	if !stats.Enabled || len(stats.Owners) != 5 {
+		t.Fatalf("unexpected queue stats %#v", stats)
+	}
+	for _, owner := range stats.Owners {
+		switch owner.OwnerID {
+		case "owner-heavy":
+			if owner.Dispatched != 3 || owner.MaxWait <= 0 || owner.TotalWait < owner.MaxWait {
+				t.Fatalf("unexpected heavy owner stats %#v", owner)
+			}
+		case "owner-starved":
+			if owner.StarvedDispatches != 1 {
+				t.Fatalf("expected a starved dispatch, got %#v", owner)
+			}
+		case "owner-batch":
+			if owner.PriorityClass != registry.PriorityClassBatch {
+				t.Fatalf("expected batch class, got %#v", owner)
+			}
+		}
+	}
+
+	svc.SetTaskQueueWait(50 * time.Millisecond)
+	_, _, err = svc.acquireDispatchSession(context.Background(), "echo", "owner-late", "", registry.PriorityClassInteractive)
+	if !errors.Is(err, ErrNoWorkerCapacity) {
+		t.Fatalf("expected queue wait to expire with ErrNoWorkerCapacity, got %v", err)
+	}
+	if queuedWaiters(svc) != 0 {
+		t.Fatalf("expected expired waiter to leave the queue")
+	}
+}
+
+func TestFairQueueWeightsShareSlots(t *testing.T) {
+	queue := newFairQueue()
+	now := time.Now()
+	heavy := registry.SchedulingSettings{Weight: 2, PriorityClass: registry.PriorityClassInteractive}
+	light := registry.DefaultSchedulingSettings()
+	waiters := []*queueWaiter{}
+	for i := 0; i < 4; i++ {
+		waiters = append(waiters, queue.enqueue("echo", "owner-heavy", registry.PriorityClassInteractive, "", heavy, now, nil))
+	}
+	for i := 0; i < 2; i++ {
+		waiters = append(waiters, queue.enqueue("echo", "owner-light", registry.PriorityClassInteractive, "", light, now, nil))
+	}
+
+	served := map[string]int{}
+	for len(waiters) > 0 {
+		best := 0
+		for i := range waiters {
+			if queueWaiterBefore(waiters[i], waiters[best], now, defaultQueueStarvationThreshold) {
+				best = i
+			}
+		}
+		served[waiters[best].ownerID]++
+		if served["owner-heavy"]+served["owner-light"] == 3 && served["owner-heavy"] != 2 {
+			t.Fatalf("expected weight 2 owner to get two of the first three slots, got %v", served)
+		}
+		waiters = append(waiters[:best], waiters[best+1:]...)
+	}
+}
+
+func TestSubmitTaskWaitsForCapacityWhenQueueEnabled(t *testing.T) {
+	svc := NewRegistryService(registrytest.NewStore(t), map[string]string{"node-1": "secret-1"}, 5, 15, 60*time.Second)
+	svc.SetTaskQueueWait(5 * time.Second)
+	client, cleanup := newBufClient(t, svc)
+	defer cleanup()
+	stream, _, err := connectWorker(client, "node-1", "secret-1", "nonce-queued-submit", []string{"echo"})
+	if err != nil {
+		t.Fatalf("connect worker failed: %v", err)
+	}
+	go payloadEchoResponder(stream)
+	session := svc.getSession("node-1")
+	if session == nil {
+		t.Fatalf("expected active session for node-1")
+	}
+	for i := 0; i < defaultCapabilityMaxInflight; i++ {
+		if !session.tryAcquireCapability("echo") {
+			t.Fatalf("expected capability slot %d to be acquirable", i)
+		}
+	}
+
+	result, err := svc.SubmitTask(context.Background(), SubmitTaskRequest{
+		Capability: "echo",
+		InputJSON:  []byte(`{"message":"queued"}`),
+		Mode:       TaskModeAsync,
+		Timeout:    5 * time.Second,
+		OwnerID:    "owner-a",
+	})
+	if err != nil {
+		t.Fatalf("expected submission to queue, got %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for queuedWaiters(svc) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected task to wait in the queue")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	session.releaseCapability("echo")
+	svc.scheduleQueue("echo")
+	deadline = time.Now().Add(2 * time.Second)
+	for {
+		task, ok := svc.GetTask(result.Task.TaskID, "owner-a")
+		if ok && task.Status == TaskStatusSucceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected queued task to succeed, got %#v", task)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func laneScheduling(svc *RegistryService, capability string) bool {
+	svc.queue.mu.Lock()
+	defer svc.queue.mu.Unlock()
+	lane := svc.queue.lanes[capability]
+	return lane != nil && lane.scheduling
+}
+
+func queuedWaiters(svc *RegistryService) int {
+	svc.queue.mu.Lock()
+	defer svc.queue.mu.Unlock()
+	count := 0
+	for _, lane := range svc.queue.lanes {
+		count += len(lane.waiters)
+	}
+	return count
+}
+
+func TestScheduleQueuePicksOutsideQueueLock(t *testing.T) {
+	svc := NewRegistryService(registrytest.NewStore(t), nil, 5, 15, 60*time.Second)
+	svc.SetTaskQueueStarvationThreshold(time.Minute)
+	now := time.Now()
+	free := false
+	lockedDuringPick := false
+	pick := func() (*activeSession, bool, error) {
+		if !svc.queue.mu.TryLock() {
+			lockedDuringPick = true
+		} else {
+			svc.queue.mu.Unlock()
+		}
+		if !free {
+			return nil, false, ErrNoWorkerCapacity
+		}
+		return &activeSession{nodeID: "node-1"}, false, nil
+	}
+	busy := svc.queue.enqueue("echo", "owner-a", registry.PriorityClassInteractive, "", registry.DefaultSchedulingSettings(), now, pick)
+	svc.scheduleQueue("echo")
+	if queuedWaiters(svc) != 1 {
+		t.Fatalf("expected waiter to stay queued while no slot is free")
+	}
+
+	free = true
+	svc.scheduleQueue("echo")
+	if lockedDuringPick {
+		t.Fatalf("expected workers to be picked without holding the queue lock")
+	}
+	select {
+	case grant := <-busy.grant:
+		if grant.err != nil || grant.session.nodeID != "node-1" {
+			t.Fatalf("unexpected grant %#v", grant)
+		}
+	default:
+		t.Fatalf("expected waiter to be granted")
+	}
+	if stats := svc.QueueStats(); stats.StarvationThreshold != time.Minute {
+		t.Fatalf("expected configured starvation threshold, got %s", stats.StarvationThreshold)
+	}
+}
+
+func TestQueueRecheckLoopStopsWhenQueueEmpties(t *testing.T) {
+	svc := NewRegistryService(registrytest.NewStore(t), nil, 5, 15, 60*time.Second)
+	svc.startQueueRecheck()
+	svc.startQueueRecheck()
+	deadline := time.Now().Add(3 * queueRecheckInterval)
+	for {
+		svc.queue.mu.Lock()
+		rechecking := svc.queue.rechecking
+		svc.queue.mu.Unlock()
+		if !rechecking {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected recheck loop to stop without waiters")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,114 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const auditActionCommandPolicy = "command.policy"
+
+var ErrCommandPolicyDenied = errors.New("command denied by policy")
+
+// CommandPolicyError reports which policy stopped a task submission.
+type CommandPolicyError struct {
+	Action     string
+	PolicyID   string
+	PolicyName string
+}
+
+func (e *CommandPolicyError) Error() string {
+	if e.PolicyName != "" {
+		return ErrCommandPolicyDenied.Error() + " (" + e.PolicyName + ")"
+	}
+	return ErrCommandPolicyDenied.Error()
+}
+
+func (e *CommandPolicyError) Unwrap() error {
+	return ErrCommandPolicyDenied
+}
+
+// SetCommandPolicy enables policy checks on every task submission.
+func (s *RegistryService) SetCommandPolicy(engine *policy.Engine) {
+	if s == nil {
+		return
+	}
+	s.policy = engine
+}
+
+// checkCommandPolicy evaluates a submission against the configured policy
+// engine. A deny decision is returned as *CommandPolicyError; callers hold
+// require_approval submissions for a human decision. Evaluation failures
+// reject the submission rather than skipping the check.
+func (s *RegistryService) checkCommandPolicy(ctx context.Context, capability string, ownerID string, tokenID string, inputJSON []byte) (policy.Decision, error) {
+	if s == nil || s.policy == nil {
+		return policy.Decision{Action: policy.ActionAllow}, nil
+	}
+	nodeIDs := s.listOnlineNodeIDsForCapability(capability, ownerID)
+	workers := make([]map[string]string, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		workers = append(workers, s.store.LabelsByNodeID(nodeID))
+	}
+	decision, err := s.policy.Evaluate(ctx, policy.Request{
+		AccountID:  ownerID,
+		TokenID:    tokenID,
+		Capability: capability,
+		InputJSON:  inputJSON,
+		Workers:    workers,
+	})
+	if err != nil {
+		slog.Error("failed to evaluate command policy", "capability", capability, "error", err)
+		return policy.Decision{}, status.Error(codes.Internal, "failed to evaluate command policy")
+	}
+	if decision.Action != policy.ActionDeny {
+		return decision, nil
+	}
+
+	policyErr := &CommandPolicyError{Action: decision.Action}
+	if decision.Policy != nil {
+		policyErr.PolicyID = decision.Policy.ID
+		policyErr.PolicyName = decision.Policy.Name
+	}
+	s.recordPolicyAudit(ctx, capability, ownerID, tokenID, inputJSON, persistence.AuditEvent{
+		Action:     auditActionCommandPolicy,
+		TargetType: "policy",
+		TargetID:   policyErr.PolicyID,
+		Outcome:    persistence.AuditOutcomeDenied,
+		Details: map[string]any{
+			"decision":  policyErr.Action,
+			"policy_id": policyErr.PolicyID,
+		},
+	})
+	return decision, policyErr
+}
+
+// recordPolicyAudit fills the actor and submission details of event and
+// appends it. The actor is the access token when the submission carried one.
+func (s *RegistryService) recordPolicyAudit(ctx context.Context, capability string, ownerID string, tokenID string, inputJSON []byte, event persistence.AuditEvent) {
+	if s.store == nil || s.store.Persistence() == nil {
+		return
+	}
+	details := map[string]any{"capability": capability}
+	for key, value := range event.Details {
+		details[key] = value
+	}
+	if command := auditCommandFromPayload(inputJSON); command != "" {
+		details["command"] = command
+	}
+	event.ActorType, event.ActorID = persistence.AuditActorAccount, ownerID
+	if strings.TrimSpace(tokenID) != "" {
+		event.ActorType, event.ActorID = persistence.AuditActorAccessToken, tokenID
+	}
+	event.CreatedAt = s.nowFn()
+	event.AccountID = ownerID
+	event.Details = details
+	if err := s.store.Persistence().AppendAuditEvent(context.WithoutCancel(ctx), event); err != nil {
+		slog.Warn("failed to record command policy audit event", "action", event.Action, "error", err)
+	}
+}
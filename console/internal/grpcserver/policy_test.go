@@ -0,0 +1,76 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func TestSubmitTaskRejectedByCommandPolicy(t *testing.T) {
+	store := registrytest.NewStore(t)
+	svc := NewRegistryService(store, map[string]string{"node-1": "secret-1"}, 5, 15, 60*time.Second)
+	engine, err := policy.NewEngine(store.Persistence().Queries, policy.ActionAllow)
+	if err != nil {
+		t.Fatalf("new policy engine: %v", err)
+	}
+	if _, err := engine.Create(context.Background(), policy.Rule{
+		Name:    "deny-ci-token",
+		Action:  policy.ActionDeny,
+		TokenID: "tok-ci",
+		Enabled: true,
+	}); err != nil {
+		t.Fatalf("create policy: %v", err)
+	}
+	if _, err := engine.Create(context.Background(), policy.Rule{
+		Name:       "approve-shutdown",
+		Action:     policy.ActionRequireApproval,
+		MatchField: policy.FieldCommand,
+		Pattern:    `^shutdown\b`,
+		Enabled:    true,
+	}); err != nil {
+		t.Fatalf("create policy: %v", err)
+	}
+	svc.SetCommandPolicy(engine)
+
+	client, cleanup := newBufClient(t, svc)
+	defer cleanup()
+	stream, _, err := connectWorker(client, "node-1", "secret-1", "nonce-policy", []string{"echo"})
+	if err != nil {
+		t.Fatalf("connect worker failed: %v", err)
+	}
+	go payloadEchoResponder(stream)
+
+	submit := func(tokenID string, input string) (SubmitTaskResult, error) {
+		return svc.SubmitTask(context.Background(), SubmitTaskRequest{
+			Capability:              "echo",
+			InputJSON:               []byte(input),
+			Mode:                    TaskModeSync,
+			Wait:                    2 * time.Second,
+			Timeout:                 2 * time.Second,
+			OwnerID:                 "owner-a",
+			TokenID:                 tokenID,
+			ReturnOnPendingApproval: true,
+		})
+	}
+
+	_, err = submit("tok-ci", `{"message":"hello"}`)
+	var policyErr *CommandPolicyError
+	if !errors.As(err, &policyErr) || !errors.Is(err, ErrCommandPolicyDenied) || policyErr.PolicyName != "deny-ci-token" {
+		t.Fatalf("expected deny policy error, got %v", err)
+	}
+	held, err := submit("tok-other", `{"message":"hello","command":"shutdown now"}`)
+	if err != nil || held.Task.Status != TaskStatusPendingApproval || held.Task.ApprovalID == "" {
+		t.Fatalf("expected submission held for approval, got %#v err=%v", held, err)
+	}
+	if _, err := svc.CancelTask(held.Task.TaskID, "owner-a"); err != nil {
+		t.Fatalf("cancel held task: %v", err)
+	}
+	result, err := submit("tok-other", `{"message":"hello"}`)
+	if err != nil || result.Task.Status != TaskStatusSucceeded {
+		t.Fatalf("expected allowed submission to succeed, got %#v err=%v", result, err)
+	}
+}
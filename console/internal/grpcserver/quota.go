@@ -0,0 +1,234 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	QuotaLimitConcurrentPerCapability = "concurrent_tasks_per_capability"
+	QuotaLimitTasksPerMinute          = "tasks_per_minute"
+	QuotaLimitExecSecondsPerDay       = "exec_seconds_per_day"
+	QuotaLimitTerminalSessions        = "terminal_sessions"
+
+	taskQuotaExceededCode = "quota_exceeded"
+	quotaRateWindow       = time.Minute
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaExceededError reports which per-owner limit stopped a task. RetryAfter
+// is set when the limit frees up at a known time.
+type QuotaExceededError struct {
+	Limit      string
+	Max        int
+	Capability string
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	if e.Limit == QuotaLimitConcurrentPerCapability && e.Capability != "" {
+		return fmt.Sprintf("%s: %s limit of %d reached for %s", ErrQuotaExceeded.Error(), e.Limit, e.Max, e.Capability)
+	}
+	return fmt.Sprintf("%s: %s limit of %d reached", ErrQuotaExceeded.Error(), e.Limit, e.Max)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// UsageSnapshot is an owner's current consumption next to its limits.
+type UsageSnapshot struct {
+	OwnerID          string
+	Quota            registry.Quota
+	Inflight         map[string]int
+	TasksLastMinute  int
+	ExecToday        time.Duration
+	TerminalSessions int
+	DayStartedAt     time.Time
+}
+
+// Usage returns the quota and current consumption of ownerID.
+func (s *RegistryService) Usage(ownerID string) (UsageSnapshot, error) {
+	normalizedOwnerID := normalizeTaskOwnerID(ownerID)
+	if normalizedOwnerID == "" {
+		return UsageSnapshot{}, status.Error(codes.InvalidArgument, "owner_id is required")
+	}
+	quota, err := s.ownerQuota(normalizedOwnerID)
+	if err != nil {
+		return UsageSnapshot{}, err
+	}
+	now := s.nowFn()
+	execToday, err := s.store.ExecUsage(normalizedOwnerID, now)
+	if err != nil {
+		slog.Error("failed to load execution usage", "owner_id", normalizedOwnerID, "error", err)
+		return UsageSnapshot{}, status.Error(codes.Internal, "failed to load execution usage")
+	}
+
+	inflight := make(map[string]int)
+	prefix := normalizedOwnerID + taskRequestScopeSeparator
+	s.quotaMu.Lock()
+	for key, count := range s.quotaInflight {
+		if capability, ok := strings.CutPrefix(key, prefix); ok && count > 0 {
+			inflight[capability] = count
+		}
+	}
+	tasksLastMinute := len(pruneQuotaSubmissions(s.quotaSubmissions[normalizedOwnerID], now))
+	s.quotaMu.Unlock()
+
+	return UsageSnapshot{
+		OwnerID:          normalizedOwnerID,
+		Quota:            quota,
+		Inflight:         inflight,
+		TasksLastMinute:  tasksLastMinute,
+		ExecToday:        execToday,
+		TerminalSessions: s.countTerminalSessionRoutesByOwner(normalizedOwnerID),
+		DayStartedAt:     registry.UsageDayStart(now),
+	}, nil
+}
+
+func (s *RegistryService) ownerQuota(ownerID string) (registry.Quota, error) {
+	if s == nil || s.store == nil {
+		return registry.Quota{}, nil
+	}
+	quota, err := s.store.Quota(ownerID)
+	if err != nil {
+		slog.Error("failed to load quota", "owner_id", ownerID, "error", err)
+		return registry.Quota{}, status.Error(codes.Internal, "failed to load quota")
+	}
+	return quota, nil
+}
+
+// checkSubmitQuota rejects a submission that would exceed ownerID's limits
+// and counts it against tasks_per_minute when it passes. Concurrency, daily
+// execution time and terminal sessions are checked again at dispatch, which
+// is where they are enforced for queued and approved tasks.
+func (s *RegistryService) checkSubmitQuota(capability string, ownerID string, inputJSON []byte) error {
+	quota, err := s.ownerQuota(ownerID)
+	if err != nil || quota == (registry.Quota{}) {
+		return err
+	}
+	now := s.nowFn()
+	if err := s.checkDispatchQuota(quota, capability, ownerID, terminalSessionIDFromPayload(capability, inputJSON), now); err != nil {
+		return err
+	}
+	if quota.MaxConcurrentPerCapability > 0 && s.quotaInflightCount(ownerID, capability) >= quota.MaxConcurrentPerCapability {
+		return &QuotaExceededError{Limit: QuotaLimitConcurrentPerCapability, Max: quota.MaxConcurrentPerCapability, Capability: capability}
+	}
+	if quota.MaxTasksPerMinute <= 0 {
+		return nil
+	}
+
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	submissions := pruneQuotaSubmissions(s.quotaSubmissions[ownerID], now)
+	if len(submissions) >= quota.MaxTasksPerMinute {
+		s.quotaSubmissions[ownerID] = submissions
+		return &QuotaExceededError{
+			Limit:      QuotaLimitTasksPerMinute,
+			Max:        quota.MaxTasksPerMinute,
+			RetryAfter: submissions[0].Add(quotaRateWindow).Sub(now),
+		}
+	}
+	s.quotaSubmissions[ownerID] = append(submissions, now)
+	return nil
+}
+
+// acquireDispatchQuota takes one of ownerID's concurrency slots for
+// capability. Callers run release once the command finishes.
+func (s *RegistryService) acquireDispatchQuota(capability string, ownerID string, terminalSessionID string) (func(), error) {
+	release := func() {}
+	if normalizeTaskOwnerID(ownerID) == "" {
+		return release, nil
+	}
+	quota, err := s.ownerQuota(ownerID)
+	if err != nil || quota == (registry.Quota{}) {
+		return release, err
+	}
+	if err := s.checkDispatchQuota(quota, capability, ownerID, terminalSessionID, s.nowFn()); err != nil {
+		return release, err
+	}
+	if quota.MaxConcurrentPerCapability <= 0 {
+		return release, nil
+	}
+
+	key := quotaInflightKey(ownerID, capability)
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	if s.quotaInflight[key] >= quota.MaxConcurrentPerCapability {
+		return release, &QuotaExceededError{Limit: QuotaLimitConcurrentPerCapability, Max: quota.MaxConcurrentPerCapability, Capability: capability}
+	}
+	s.quotaInflight[key]++
+	return func() {
+		s.quotaMu.Lock()
+		defer s.quotaMu.Unlock()
+		if s.quotaInflight[key] <= 1 {
+			delete(s.quotaInflight, key)
+			return
+		}
+		s.quotaInflight[key]--
+	}, nil
+}
+
+// checkDispatchQuota covers the limits that do not change on submission:
+// the daily execution budget and the number of live terminal sessions. A
+// command for a session that already has a route never counts as new.
+func (s *RegistryService) checkDispatchQuota(quota registry.Quota, capability string, ownerID string, terminalSessionID string, now time.Time) error {
+	if quota.MaxExecSecondsPerDay > 0 {
+		used, err := s.store.ExecUsage(ownerID, now)
+		if err != nil {
+			slog.Error("failed to load execution usage", "owner_id", ownerID, "error", err)
+			return status.Error(codes.Internal, "failed to load execution usage")
+		}
+		if used >= time.Duration(quota.MaxExecSecondsPerDay)*time.Second {
+			return &QuotaExceededError{
+				Limit:      QuotaLimitExecSecondsPerDay,
+				Max:        quota.MaxExecSecondsPerDay,
+				RetryAfter: registry.UsageDayStart(now).Add(24 * time.Hour).Sub(now),
+			}
+		}
+	}
+	if quota.MaxTerminalSessions > 0 && terminalSessionID != "" && !s.hasTerminalSessionRoute(terminalSessionID) {
+		if s.countTerminalSessionRoutesByOwner(ownerID) >= quota.MaxTerminalSessions {
+			return &QuotaExceededError{Limit: QuotaLimitTerminalSessions, Max: quota.MaxTerminalSessions, Capability: capability}
+		}
+	}
+	return nil
+}
+
+// recordExecUsage charges the time since startedAt to ownerID's daily budget.
+func (s *RegistryService) recordExecUsage(ownerID string, startedAt time.Time) {
+	if normalizeTaskOwnerID(ownerID) == "" || s.store == nil {
+		return
+	}
+	now := s.nowFn()
+	if err := s.store.AddExecUsage(ownerID, now.Sub(startedAt), now); err != nil {
+		slog.Warn("failed to record execution usage", "owner_id", ownerID, "error", err)
+	}
+}
+
+func (s *RegistryService) quotaInflightCount(ownerID string, capability string) int {
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	return s.quotaInflight[quotaInflightKey(ownerID, capability)]
+}
+
+func quotaInflightKey(ownerID string, capability string) string {
+	return normalizeTaskOwnerID(ownerID) + taskRequestScopeSeparator + normalizeCapability(capability)
+}
+
+func pruneQuotaSubmissions(submissions []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-quotaRateWindow)
+	index := 0
+	for index < len(submissions) && !submissions[index].After(cutoff) {
+		index++
+	}
+	return submissions[index:]
+}
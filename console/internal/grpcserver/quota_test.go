@@ -0,0 +1,90 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func TestSubmitTaskEnforcesQuota(t *testing.T) {
+	store := registrytest.NewStore(t)
+	svc := NewRegistryService(store, map[string]string{"node-1": "secret-1"}, 5, 15, 60*time.Second)
+	client, cleanup := newBufClient(t, svc)
+	defer cleanup()
+	stream, _, err := connectWorker(client, "node-1", "secret-1", "nonce-quota", []string{"echo"})
+	if err != nil {
+		t.Fatalf("connect worker failed: %v", err)
+	}
+	go payloadEchoResponder(stream)
+
+	submit := func() (SubmitTaskResult, error) {
+		return svc.SubmitTask(context.Background(), SubmitTaskRequest{
+			Capability: "echo",
+			InputJSON:  []byte(`{"message":"hello"}`),
+			Mode:       TaskModeSync,
+			Wait:       2 * time.Second,
+			Timeout:    2 * time.Second,
+			OwnerID:    "owner-a",
+		})
+	}
+	setQuota := func(quota registry.Quota) {
+		t.Helper()
+		if err := store.SetQuota("owner-a", quota, time.Now()); err != nil {
+			t.Fatalf("set quota: %v", err)
+		}
+	}
+
+	setQuota(registry.Quota{MaxTasksPerMinute: 2})
+	for i := 0; i < 2; i++ {
+		if result, err := submit(); err != nil || result.Task.Status != TaskStatusSucceeded {
+			t.Fatalf("expected submission %d to succeed, got %#v err=%v", i, result, err)
+		}
+	}
+	_, err = submit()
+	var quotaErr *QuotaExceededError
+	if !errors.As(err, &quotaErr) || !errors.Is(err, ErrQuotaExceeded) || quotaErr.Limit != QuotaLimitTasksPerMinute || quotaErr.RetryAfter <= 0 {
+		t.Fatalf("expected tasks_per_minute quota error, got %v", err)
+	}
+	usage, err := svc.Usage("owner-a")
+	if err != nil || usage.TasksLastMinute != 2 || usage.ExecToday <= 0 {
+		t.Fatalf("expected usage to count two executed tasks, got %#v err=%v", usage, err)
+	}
+
+	setQuota(registry.Quota{MaxConcurrentPerCapability: 1})
+	release, err := svc.acquireDispatchQuota("echo", "owner-a", "")
+	if err != nil {
+		t.Fatalf("acquire first slot: %v", err)
+	}
+	if _, err := submit(); !errors.As(err, &quotaErr) || quotaErr.Limit != QuotaLimitConcurrentPerCapability {
+		t.Fatalf("expected concurrency quota error, got %v", err)
+	}
+	if _, err := svc.acquireDispatchQuota("echo", "owner-b", ""); err != nil {
+		t.Fatalf("expected other owners to keep their slots, got %v", err)
+	}
+	release()
+	if result, err := submit(); err != nil || result.Task.Status != TaskStatusSucceeded {
+		t.Fatalf("expected submission after release to succeed, got %#v err=%v", result, err)
+	}
+
+	setQuota(registry.Quota{MaxTerminalSessions: 1})
+	svc.bindTerminalSessionRoute(scopeTerminalSessionID("owner-a", "s1"), "node-1", time.Now())
+	if _, err := svc.acquireDispatchQuota(taskCapabilityTerminalExec, "owner-a", scopeTerminalSessionID("owner-a", "s1")); err != nil {
+		t.Fatalf("expected existing session to stay usable, got %v", err)
+	}
+	_, err = svc.acquireDispatchQuota(taskCapabilityTerminalExec, "owner-a", scopeTerminalSessionID("owner-a", "s2"))
+	if !errors.As(err, &quotaErr) || quotaErr.Limit != QuotaLimitTerminalSessions {
+		t.Fatalf("expected terminal session quota error, got %v", err)
+	}
+
+	setQuota(registry.Quota{MaxExecSecondsPerDay: 1})
+	if err := store.AddExecUsage("owner-a", 2*time.Second, time.Now()); err != nil {
+		t.Fatalf("add usage: %v", err)
+	}
+	if _, err := submit(); !errors.As(err, &quotaErr) || quotaErr.Limit != QuotaLimitExecSecondsPerDay || quotaErr.RetryAfter <= 0 {
+		t.Fatalf("expected daily execution quota error, got %v", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
 	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
 	"github.com/onlyboxes/onlyboxes/console/internal/registry"
 )
 
@@ -30,6 +31,17 @@ const (
 	computerUseCapabilityDeclared = "computerUse"
 	readImageCapabilityName       = "readimage"
 	readImageCapabilityDeclared   = "readImage"
+	readFileCapabilityName        = "readfile"
+	readFileCapabilityDeclared    = "readFile"
+	writeFileCapabilityName       = "writefile"
+	writeFileCapabilityDeclared   = "writeFile"
+	listDirCapabilityName         = "listdir"
+	listDirCapabilityDeclared     = "listDir"
+	screenshotCapabilityName      = "screenshot"
+	screenshotCapabilityDeclared  = "screenshot"
+	// maxWorkerSysComputerUseMaxInflight matches the worker-sys cap on
+	// WORKER_COMPUTER_USE_MAX_INFLIGHT.
+	maxWorkerSysComputerUseMaxInflight = 8
 )
 
 var ErrNoEchoWorker = errors.New("no online worker supports echo")
@@ -54,6 +66,7 @@ type RegistryService struct {
 	newTaskIDFn            func() (string, error)
 	newTerminalSessionIDFn func() (string, error)
 	taskRetention          time.Duration
+	policy                 *policy.Engine
 
 	sessionsMu sync.RWMutex
 	sessions   map[string]*activeSession
@@ -73,6 +86,26 @@ type RegistryService struct {
 	taskRequestReservations      map[string]struct{}
 	criticalPersistenceFailureFn func(error)
 	lastInlineTaskPruneUnixMs    atomic.Int64
+
+	// granteeFlights holds the node_id/owner_id pairs of grantees running a
+	// computerUse command on a shared worker-sys.
+	granteeFlightsMu sync.Mutex
+	granteeFlights   map[string]struct{}
+
+	// quotaInflight counts dispatched commands by owner_id/capability and
+	// quotaSubmissions holds each owner's task submissions of the last
+	// minute; both only track owners with the matching limit set.
+	quotaMu          sync.Mutex
+	quotaInflight    map[string]int
+	quotaSubmissions map[string][]time.Time
+
+	// queue orders tasks waiting for a worker slot between owners.
+	queue *fairQueue
+
+	approvalsMu     sync.Mutex
+	approvalTimeout time.Duration
+	// approvals holds the expiry timer of every pending approval by task_id.
+	approvals map[string]*pendingApproval
 }
 
 func NewRegistryService(
@@ -106,6 +139,12 @@ func NewRegistryService(
 		tasks:                        make(map[string]*taskRecord),
 		taskRequestReservations:      make(map[string]struct{}),
 		criticalPersistenceFailureFn: func(error) {},
+		approvalTimeout:              defaultApprovalTimeout,
+		approvals:                    make(map[string]*pendingApproval),
+		queue:                        newFairQueue(),
+		granteeFlights:               make(map[string]struct{}),
+		quotaInflight:                make(map[string]int),
+		quotaSubmissions:             make(map[string][]time.Time),
 	}
 }
 
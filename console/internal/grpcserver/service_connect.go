@@ -52,9 +52,13 @@ func (s *RegistryService) Connect(stream grpc.BidiStreamingServer[registryv1.Con
 		return s.hasher
 	}()
 	if hasher != nil {
-		if !hasher.Equal(secret, workerSecret) {
+		keyID, ok := hasher.Match("", secret, workerSecret)
+		if !ok {
 			return status.Error(codes.Unauthenticated, "invalid worker credential")
 		}
+		if !hasher.IsPrimary(keyID) {
+			s.rekeyCredential(hello.GetNodeId(), secret, workerSecret, hasher)
+		}
 	} else if subtle.ConstantTimeCompare([]byte(secret), []byte(workerSecret)) != 1 {
 		return status.Error(codes.Unauthenticated, "invalid worker credential")
 	}
@@ -83,6 +87,7 @@ func (s *RegistryService) Connect(stream grpc.BidiStreamingServer[registryv1.Con
 	if err := s.store.Upsert(hello, sessionID, now); err != nil {
 		return status.Error(codes.Internal, "failed to persist worker registration")
 	}
+	s.scheduleAllQueues()
 
 	writerErrCh := make(chan error, 1)
 	go func() {
@@ -144,17 +149,34 @@ func (s *RegistryService) resolveHelloByWorkerType(hello *registryv1.ConnectHell
 
 	hasComputerUse := false
 	hasReadImage := false
+	computerUseMaxInflight := int32(1)
+	// File and screenshot capabilities are optional so older or unconfigured
+	// worker-sys builds still register; each one is forced to a single
+	// inflight call.
+	optionalCapabilities := make([]*registryv1.CapabilityDeclaration, 0, 4)
+	declaredOptionalCapabilities := make(map[string]struct{}, 4)
 	for _, capability := range hello.GetCapabilities() {
 		if capability == nil {
 			continue
 		}
-		switch normalizeCapability(capability.GetName()) {
+		normalized := normalizeCapability(capability.GetName())
+		switch normalized {
 		case computerUseCapabilityName:
 			hasComputerUse = true
+			computerUseMaxInflight = clampWorkerSysComputerUseMaxInflight(capability.GetMaxInflight())
 		case readImageCapabilityName:
 			hasReadImage = true
+		case readFileCapabilityName, writeFileCapabilityName, listDirCapabilityName, screenshotCapabilityName:
+			if _, exists := declaredOptionalCapabilities[normalized]; exists {
+				continue
+			}
+			declaredOptionalCapabilities[normalized] = struct{}{}
+			optionalCapabilities = append(optionalCapabilities, &registryv1.CapabilityDeclaration{
+				Name:        workerSysOptionalCapabilityDeclared(normalized),
+				MaxInflight: 1,
+			})
 		default:
-			return nil, status.Error(codes.PermissionDenied, "worker-sys supports only computerUse and readImage capabilities")
+			return nil, status.Error(codes.PermissionDenied, "worker-sys supports only computerUse, readImage, readFile, writeFile, listDir and screenshot capabilities")
 		}
 	}
 	if !hasComputerUse || !hasReadImage {
@@ -169,19 +191,55 @@ func (s *RegistryService) resolveHelloByWorkerType(hello *registryv1.ConnectHell
 		Labels:       labels,
 		Version:      hello.GetVersion(),
 		WorkerSecret: hello.GetWorkerSecret(),
-		Capabilities: []*registryv1.CapabilityDeclaration{
+		Capabilities: append([]*registryv1.CapabilityDeclaration{
 			{
 				Name:        computerUseCapabilityDeclared,
-				MaxInflight: 1,
+				MaxInflight: computerUseMaxInflight,
 			},
 			{
 				Name:        readImageCapabilityDeclared,
 				MaxInflight: 1,
 			},
-		},
+		}, optionalCapabilities...),
 	}, nil
 }
 
+func workerSysOptionalCapabilityDeclared(normalized string) string {
+	switch normalized {
+	case readFileCapabilityName:
+		return readFileCapabilityDeclared
+	case writeFileCapabilityName:
+		return writeFileCapabilityDeclared
+	case screenshotCapabilityName:
+		return screenshotCapabilityDeclared
+	default:
+		return listDirCapabilityDeclared
+	}
+}
+
+// isWorkerSysCapability reports whether capability is served only by
+// account-owned worker-sys nodes.
+func isWorkerSysCapability(normalizedCapability string) bool {
+	switch normalizedCapability {
+	case computerUseCapabilityName, readImageCapabilityName, readFileCapabilityName, writeFileCapabilityName, listDirCapabilityName, screenshotCapabilityName:
+		return true
+	default:
+		return false
+	}
+}
+
+// clampWorkerSysComputerUseMaxInflight keeps worker-sys computerUse
+// concurrency opt-in: an undeclared value means one command at a time.
+func clampWorkerSysComputerUseMaxInflight(declared int32) int32 {
+	if declared < 1 {
+		return 1
+	}
+	if declared > maxWorkerSysComputerUseMaxInflight {
+		return maxWorkerSysComputerUseMaxInflight
+	}
+	return declared
+}
+
 func cloneLabels(labels map[string]string) map[string]string {
 	if len(labels) == 0 {
 		return map[string]string{}
@@ -44,6 +44,9 @@ func (s *RegistryService) CreateProvisionedWorker(now time.Time, offlineTTL time
 	return s.CreateProvisionedWorkerForOwner(defaultWorkerOwnerID, registry.WorkerTypeNormal, now, offlineTTL)
 }
 
+// CreateProvisionedWorkerForOwner seeds a worker labeled with ownerID and
+// workerType and returns its credentials. A private worker joins ownerID's
+// private pool; an account may hold at most one worker-sys.
 func (s *RegistryService) CreateProvisionedWorkerForOwner(
 	ownerID string,
 	workerType string,
@@ -58,7 +61,10 @@ func (s *RegistryService) CreateProvisionedWorkerForOwner(
 	if normalizedWorkerType == "" {
 		return "", "", ErrInvalidWorkerType
 	}
-	if normalizedWorkerType == registry.WorkerTypeSys {
+	// Organizations run a pool of worker-sys, so the one-per-owner claim
+	// only applies to accounts.
+	claimWorkerSys := normalizedWorkerType == registry.WorkerTypeSys && !registry.IsOrganizationOwnerID(normalizedOwnerID)
+	if claimWorkerSys {
 		if count := s.store.CountWorkersByOwnerAndType(normalizedOwnerID, normalizedWorkerType); count > 0 {
 			return "", "", ErrWorkerSysAlreadyExists
 		}
@@ -87,7 +93,7 @@ func (s *RegistryService) CreateProvisionedWorkerForOwner(
 		if seeded != 1 {
 			continue
 		}
-		if normalizedWorkerType == registry.WorkerTypeSys {
+		if claimWorkerSys {
 			claimed, claimErr := s.store.ClaimWorkerSysOwner(normalizedOwnerID, workerID, now)
 			if claimErr != nil {
 				s.store.Delete(workerID)
@@ -100,6 +106,7 @@ func (s *RegistryService) CreateProvisionedWorkerForOwner(
 		}
 
 		credentialValue := workerSecret
+		hashKeyID := ""
 		hasher, hashAlgo := func() (*persistence.Hasher, string) {
 			s.credentialsMu.RLock()
 			defer s.credentialsMu.RUnlock()
@@ -111,13 +118,14 @@ func (s *RegistryService) CreateProvisionedWorkerForOwner(
 		}()
 		if hasher != nil {
 			credentialValue = hasher.Hash(workerSecret)
+			hashKeyID = hasher.PrimaryKeyID()
 		}
 
 		if !s.putCredentialIfAbsent(workerID, credentialValue) {
 			s.store.Delete(workerID)
 			continue
 		}
-		if !s.store.PutCredentialHashIfAbsent(workerID, credentialValue, hashAlgo, now) {
+		if !s.store.PutCredentialHashIfAbsent(workerID, credentialValue, hashAlgo, hashKeyID, now) {
 			s.deleteCredential(workerID)
 			s.store.Delete(workerID)
 			continue
@@ -134,6 +142,8 @@ func normalizeProvisioningWorkerType(workerType string) string {
 		return registry.WorkerTypeNormal
 	case registry.WorkerTypeSys:
 		return registry.WorkerTypeSys
+	case registry.WorkerTypePrivate:
+		return registry.WorkerTypePrivate
 	default:
 		return ""
 	}
@@ -186,6 +196,26 @@ func (s *RegistryService) getCredential(nodeID string) (string, bool) {
 	return hash, true
 }
 
+// rekeyCredential moves a worker credential verified with a retired hash key
+// to the primary key. Failures are logged and retried on the next connect.
+func (s *RegistryService) rekeyCredential(nodeID string, oldHash string, workerSecret string, hasher *persistence.Hasher) {
+	trimmedNodeID := strings.TrimSpace(nodeID)
+	if trimmedNodeID == "" || hasher == nil || s.store == nil {
+		return
+	}
+	newHash := hasher.Hash(workerSecret)
+	if !s.store.RekeyCredentialHash(trimmedNodeID, oldHash, newHash, hasher.PrimaryKeyID(), s.nowFn()) {
+		slog.Warn("failed to re-key worker credential", "node_id", trimmedNodeID)
+		return
+	}
+
+	s.credentialsMu.Lock()
+	defer s.credentialsMu.Unlock()
+	if current, ok := s.credentials[trimmedNodeID]; ok && current == oldHash {
+		s.credentials[trimmedNodeID] = newHash
+	}
+}
+
 func (s *RegistryService) putCredentialIfAbsent(nodeID string, secret string) bool {
 	trimmedNodeID := strings.TrimSpace(nodeID)
 	trimmedSecret := strings.TrimSpace(secret)
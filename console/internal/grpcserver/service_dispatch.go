@@ -48,7 +48,7 @@ func (s *RegistryService) DispatchEcho(ctx context.Context, message string, time
 		timeout = defaultEchoTimeout
 	}
 
-	outcome, err := s.dispatchCommand(ctx, echoCapabilityName, buildEchoPayload(message), timeout, "", nil)
+	outcome, err := s.dispatchCommand(ctx, echoCapabilityName, buildEchoPayload(message), timeout, "", "", nil)
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrNoCapabilityWorker):
@@ -83,8 +83,9 @@ func (s *RegistryService) dispatchCommand(
 	payloadJSON []byte,
 	timeout time.Duration,
 	ownerID string,
+	priorityClass string,
 	onDispatched func(commandID string),
-) (commandOutcome, error) {
+) (result commandOutcome, err error) {
 	capability = normalizeCapability(capability)
 	if capability == "" {
 		return commandOutcome{}, status.Error(codes.InvalidArgument, "capability is required")
@@ -92,6 +93,10 @@ func (s *RegistryService) dispatchCommand(
 	if len(payloadJSON) == 0 {
 		payloadJSON = []byte("{}")
 	}
+	audit := commandAudit{capability: capability, ownerID: ownerID, payloadJSON: payloadJSON}
+	defer func() {
+		s.recordCommandAudit(ctx, audit, result, err)
+	}()
 
 	commandCtx := ctx
 	cancel := func() {}
@@ -103,10 +108,30 @@ func (s *RegistryService) dispatchCommand(
 	defer cancel()
 
 	terminalSessionID := terminalSessionIDFromPayload(capability, payloadJSON)
-	session, terminalRouteCreated, err := s.pickSessionForDispatch(capability, ownerID, terminalSessionID)
+	releaseQuota, err := s.acquireDispatchQuota(capability, ownerID, terminalSessionID)
+	if err != nil {
+		return commandOutcome{}, err
+	}
+	defer releaseQuota()
+	queuedAt := s.nowFn()
+	session, terminalRouteCreated, err := s.acquireDispatchSession(commandCtx, capability, ownerID, terminalSessionID, priorityClass)
 	if err != nil {
 		return commandOutcome{}, err
 	}
+	usage := commandUsage{capability: capability, ownerID: ownerID, nodeID: session.nodeID, queueWait: s.nowFn().Sub(queuedAt)}
+	// Runs after the slot is released below, so queued tasks can take it.
+	defer s.scheduleQueue(capability)
+	audit.nodeID = session.nodeID
+	if capability == computerUseCapabilityName && s.isWorkerSysGrantee(ownerID, session.nodeID) {
+		if !s.tryAcquireGranteeFlight(ownerID, session.nodeID) {
+			session.releaseCapability(capability)
+			if terminalRouteCreated && terminalSessionID != "" {
+				s.clearTerminalSessionRoute(terminalSessionID, session.nodeID)
+			}
+			return commandOutcome{}, ErrNoWorkerCapacity
+		}
+		defer s.releaseGranteeFlight(ownerID, session.nodeID)
+	}
 
 	commandID, err := s.newCommandIDFn()
 	if err != nil {
@@ -117,6 +142,8 @@ func (s *RegistryService) dispatchCommand(
 		return commandOutcome{}, status.Error(codes.Internal, "failed to create command_id")
 	}
 
+	audit.commandID = commandID
+
 	resultCh, err := session.registerPending(commandID, capability)
 	if err != nil {
 		session.releaseCapability(capability)
@@ -160,6 +187,13 @@ func (s *RegistryService) dispatchCommand(
 		}
 		return commandOutcome{}, status.Error(codes.Unavailable, "worker session unavailable")
 	}
+	// Execution time is charged from enqueue until the command returns.
+	defer s.recordExecUsage(ownerID, s.nowFn())
+	usage.commandID = commandID
+	usage.startedAt = s.nowFn()
+	defer func() {
+		s.recordCommandUsage(usage, result, err)
+	}()
 	if onDispatched != nil {
 		onDispatched(commandID)
 	}
@@ -197,6 +231,10 @@ func (s *RegistryService) pickSessionForDispatch(capability string, ownerID stri
 	s.maybePruneTerminalSessionRoutes(now)
 
 	nodeID, ok := s.touchTerminalSessionRoute(normalizedTerminalSessionID, now)
+	if ok && isWorkerSysCapability(capability) && !s.canUseWorkerSysNode(ownerID, nodeID, capability) {
+		s.clearTerminalSessionRoute(normalizedTerminalSessionID, nodeID)
+		ok = false
+	}
 	if !ok {
 		return s.tryReserveAndPickTerminalSession(capability, ownerID, normalizedTerminalSessionID, now)
 	}
@@ -277,8 +315,24 @@ func (s *RegistryService) pickSessionForNodeAndCapability(nodeID string, capabil
 	return session, nil
 }
 
+// pickSessionForCapability acquires a slot on the least busy node of the
+// most preferred tier that has room, so an owner's private workers fill up
+// before the shared pool is used.
 func (s *RegistryService) pickSessionForCapability(capability string, ownerID string) (*activeSession, error) {
-	nodeIDs := s.listOnlineNodeIDsForCapability(capability, ownerID)
+	err := ErrNoCapabilityWorker
+	for _, nodeIDs := range s.listOnlineNodeIDTiersForCapability(capability, ownerID) {
+		session, tierErr := s.pickSessionFromNodes(capability, nodeIDs)
+		if tierErr == nil {
+			return session, nil
+		}
+		if errors.Is(tierErr, ErrNoWorkerCapacity) {
+			err = tierErr
+		}
+	}
+	return nil, err
+}
+
+func (s *RegistryService) pickSessionFromNodes(capability string, nodeIDs []string) (*activeSession, error) {
 	if len(nodeIDs) == 0 {
 		return nil, ErrNoCapabilityWorker
 	}
@@ -337,24 +391,45 @@ func (s *RegistryService) pickSessionForCapability(capability string, ownerID st
 	return nil, ErrNoWorkerCapacity
 }
 
+// listOnlineNodeIDsForCapability lists every node ownerID may use for
+// capability, most preferred first.
 func (s *RegistryService) listOnlineNodeIDsForCapability(capability string, ownerID string) []string {
+	nodeIDs := []string{}
+	for _, tier := range s.listOnlineNodeIDTiersForCapability(capability, ownerID) {
+		nodeIDs = append(nodeIDs, tier...)
+	}
+	return nodeIDs
+}
+
+// listOnlineNodeIDTiersForCapability groups the nodes ownerID may use by
+// preference. Worker-sys capabilities use the owner's own nodes, else the
+// granted ones (see listWorkerSysNodeIDTiers). Other capabilities try
+// the owner's private workers first, then the shared pool unless the owner
+// turned the fallback off. Private workers never appear in another owner's
+// tiers.
+func (s *RegistryService) listOnlineNodeIDTiersForCapability(capability string, ownerID string) [][]string {
 	now := s.nowFn()
 	offlineTTL := time.Duration(s.offlineTTLSec) * time.Second
 	normalizedCapability := normalizeCapability(capability)
-	if normalizedCapability == computerUseCapabilityName || normalizedCapability == readImageCapabilityName {
-		normalizedOwnerID := normalizeTaskOwnerID(ownerID)
+	normalizedOwnerID := normalizeTaskOwnerID(ownerID)
+	if isWorkerSysCapability(normalizedCapability) {
 		if normalizedOwnerID == "" {
-			return []string{}
+			return [][]string{}
 		}
-		return s.store.ListOnlineNodeIDsByOwnerTypeAndCapability(
-			normalizedOwnerID,
-			registry.WorkerTypeSys,
-			normalizedCapability,
-			now,
-			offlineTTL,
-		)
-	}
-	return s.store.ListOnlineNodeIDsByCapability(normalizedCapability, now, offlineTTL)
+		return s.listWorkerSysNodeIDTiers(normalizedCapability, normalizedOwnerID, now, offlineTTL)
+	}
+	if normalizedOwnerID == "" {
+		return [][]string{s.store.ListOnlineNodeIDsByCapability(normalizedCapability, now, offlineTTL)}
+	}
+	tiers := make([][]string, 0, 2)
+	private := s.store.ListOnlineNodeIDsByOwnerTypeAndCapability(normalizedOwnerID, registry.WorkerTypePrivate, normalizedCapability, now, offlineTTL)
+	if len(private) > 0 {
+		tiers = append(tiers, private)
+	}
+	if s.store.PrivatePoolFallback(normalizedOwnerID) {
+		tiers = append(tiers, s.store.ListOnlineNodeIDsByCapability(normalizedCapability, now, offlineTTL))
+	}
+	return tiers
 }
 
 func normalizeCapability(capability string) string {
@@ -386,6 +461,18 @@ func terminalSessionIDFromPayload(capability string, payload []byte) string {
 			return ""
 		}
 		return strings.TrimSpace(decoded.SessionID)
+	case computerUseCapabilityName:
+		var decoded computerUseScopedPayload
+		if err := json.Unmarshal(payload, &decoded); err != nil {
+			return ""
+		}
+		sessionID := strings.TrimSpace(decoded.SessionID)
+		if sessionID == "" {
+			return ""
+		}
+		// computerUse sessions live on worker-sys nodes; keep their routes
+		// apart from terminalExec sessions that reuse the same id.
+		return computerUseCapabilityName + taskOwnerScopeSeparator + sessionID
 	default:
 		return ""
 	}
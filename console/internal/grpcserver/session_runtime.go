@@ -11,10 +11,12 @@ import (
 )
 
 type commandOutcome struct {
-	payloadJSON []byte
-	message     string
-	err         error
-	completedAt time.Time
+	payloadJSON     []byte
+	message         string
+	err             error
+	completedAt     time.Time
+	cpuSeconds      float64
+	peakMemoryBytes int64
 }
 
 type pendingCommand struct {
@@ -263,6 +265,8 @@ func (s *activeSession) resolvePending(result *registryv1.CommandResult) {
 			Message: "worker returned empty command result",
 		}
 	}
+	outcome.cpuSeconds = result.GetCpuSeconds()
+	outcome.peakMemoryBytes = result.GetPeakMemoryBytes()
 	if result.GetCompletedUnixMs() > 0 {
 		outcome.completedAt = time.UnixMilli(result.GetCompletedUnixMs())
 	} else {
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -45,13 +46,14 @@ const (
 type TaskStatus string
 
 const (
-	TaskStatusQueued     TaskStatus = "queued"
-	TaskStatusDispatched TaskStatus = "dispatched"
-	TaskStatusRunning    TaskStatus = "running"
-	TaskStatusSucceeded  TaskStatus = "succeeded"
-	TaskStatusFailed     TaskStatus = "failed"
-	TaskStatusTimeout    TaskStatus = "timeout"
-	TaskStatusCanceled   TaskStatus = "canceled"
+	TaskStatusPendingApproval TaskStatus = "pending_approval"
+	TaskStatusQueued          TaskStatus = "queued"
+	TaskStatusDispatched      TaskStatus = "dispatched"
+	TaskStatusRunning         TaskStatus = "running"
+	TaskStatusSucceeded       TaskStatus = "succeeded"
+	TaskStatusFailed          TaskStatus = "failed"
+	TaskStatusTimeout         TaskStatus = "timeout"
+	TaskStatusCanceled        TaskStatus = "canceled"
 )
 
 type SubmitTaskRequest struct {
@@ -62,6 +64,10 @@ type SubmitTaskRequest struct {
 	Timeout    time.Duration
 	RequestID  string
 	OwnerID    string
+	TokenID    string
+	// ReturnOnPendingApproval makes sync and auto submissions return as soon
+	// as the task is held for approval instead of waiting for the decision.
+	ReturnOnPendingApproval bool
 }
 
 type SubmitTaskResult struct {
@@ -82,6 +88,8 @@ type TaskSnapshot struct {
 	UpdatedAt    time.Time
 	DeadlineAt   time.Time
 	CompletedAt  *time.Time
+	// ApprovalID is set while the task is pending_approval.
+	ApprovalID string
 }
 
 type taskRecord struct {
@@ -185,12 +193,23 @@ func (s *RegistryService) SubmitTask(ctx context.Context, req SubmitTaskRequest)
 		}()
 		existing, found := s.getTaskByOwnerAndRequest(ownerID, requestID)
 		if found {
-			return s.resolveSubmitTaskResult(ctx, existing.taskID, s.getTaskRuntime(existing.taskID), mode, wait)
+			return s.resolveSubmitTaskResult(ctx, existing.taskID, s.getTaskRuntime(existing.taskID), mode, wait, req.ReturnOnPendingApproval)
 		}
 	}
 
+	decision, policyErr := s.checkCommandPolicy(ctx, capability, ownerID, req.TokenID, inputJSON)
+	if policyErr != nil {
+		return SubmitTaskResult{}, policyErr
+	}
+	needsApproval := decision.Action == policy.ActionRequireApproval
+	if quotaErr := s.checkSubmitQuota(capability, ownerID, inputJSON); quotaErr != nil {
+		return SubmitTaskResult{}, quotaErr
+	}
 	if availabilityErr := s.checkCapabilityAvailability(capability, ownerID); availabilityErr != nil {
-		return SubmitTaskResult{}, availabilityErr
+		// With the queue on, busy workers are waited for; missing ones are not.
+		if !errors.Is(availabilityErr, ErrNoWorkerCapacity) || s.taskQueueWait() <= 0 {
+			return SubmitTaskResult{}, availabilityErr
+		}
 	}
 
 	taskID, err := s.newTaskIDFn()
@@ -198,6 +217,16 @@ func (s *RegistryService) SubmitTask(ctx context.Context, req SubmitTaskRequest)
 		return SubmitTaskResult{}, status.Error(codes.Internal, "failed to create task_id")
 	}
 	now := s.nowFn()
+	initialStatus := TaskStatusQueued
+	deadlineAt := now.Add(timeout)
+	var approvalExpiresAt time.Time
+	if needsApproval {
+		// The deadline is an upper bound until a decision arrives; approval
+		// resets it to decision time plus timeout.
+		initialStatus = TaskStatusPendingApproval
+		approvalExpiresAt = now.Add(s.currentApprovalTimeout())
+		deadlineAt = approvalExpiresAt.Add(timeout)
+	}
 
 	insertErr := s.taskQueries().InsertTask(context.Background(), sqlc.InsertTaskParams{
 		TaskID:            taskID,
@@ -205,14 +234,14 @@ func (s *RegistryService) SubmitTask(ctx context.Context, req SubmitTaskRequest)
 		RequestID:         requestID,
 		Capability:        capability,
 		InputJson:         string(inputJSON),
-		Status:            string(TaskStatusQueued),
+		Status:            string(initialStatus),
 		CommandID:         "",
 		ResultJson:        "",
 		ErrorCode:         "",
 		ErrorMessage:      "",
 		CreatedAtUnixMs:   now.UnixMilli(),
 		UpdatedAtUnixMs:   now.UnixMilli(),
-		DeadlineAtUnixMs:  now.Add(timeout).UnixMilli(),
+		DeadlineAtUnixMs:  deadlineAt.UnixMilli(),
 		CompletedAtUnixMs: 0,
 		ExpiresAtUnixMs:   0,
 	})
@@ -220,13 +249,20 @@ func (s *RegistryService) SubmitTask(ctx context.Context, req SubmitTaskRequest)
 		if requestID != "" && isTaskOwnerRequestConflict(insertErr) {
 			existing, found := s.getTaskByOwnerAndRequest(ownerID, requestID)
 			if found {
-				return s.resolveSubmitTaskResult(ctx, existing.taskID, s.getTaskRuntime(existing.taskID), mode, wait)
+				return s.resolveSubmitTaskResult(ctx, existing.taskID, s.getTaskRuntime(existing.taskID), mode, wait, req.ReturnOnPendingApproval)
 			}
 		}
 		return SubmitTaskResult{}, status.Error(codes.Internal, "failed to create task")
 	}
 
-	taskCtx, taskCancel := context.WithTimeout(context.Background(), timeout)
+	var taskCtx context.Context
+	var taskCancel context.CancelFunc
+	if needsApproval {
+		// The execution timeout starts once the task is approved.
+		taskCtx, taskCancel = context.WithCancel(context.Background())
+	} else {
+		taskCtx, taskCancel = context.WithTimeout(context.Background(), timeout)
+	}
 	runtimeRecord := &taskRecord{
 		id:        taskID,
 		ownerID:   ownerID,
@@ -244,8 +280,18 @@ func (s *RegistryService) SubmitTask(ctx context.Context, req SubmitTaskRequest)
 		requestReserved = false
 	}
 
-	go s.executeTask(taskCtx, taskID, ownerID, capability, inputJSON)
-	return s.resolveSubmitTaskResult(ctx, taskID, runtimeRecord, mode, wait)
+	if needsApproval {
+		if err := s.holdTaskForApproval(ctx, taskID, taskCtx, req, capability, inputJSON, timeout, decision, now, approvalExpiresAt); err != nil {
+			slog.Error("failed to create task approval", "task_id", taskID, "error", err)
+			if failErr := s.failTaskOnPersistenceError(taskID, "insert_approval", err); failErr != nil {
+				slog.Error("failed to persist fallback task failure", "task_id", taskID, "stage", "insert_approval", "error", failErr)
+			}
+			return SubmitTaskResult{}, status.Error(codes.Internal, "failed to create task approval")
+		}
+	} else {
+		go s.executeTask(taskCtx, taskID, ownerID, capability, inputJSON, taskPriorityClass(mode))
+	}
+	return s.resolveSubmitTaskResult(ctx, taskID, runtimeRecord, mode, wait, req.ReturnOnPendingApproval)
 }
 
 func (s *RegistryService) GetTask(taskID string, ownerID string) (TaskSnapshot, bool) {
@@ -258,7 +304,7 @@ func (s *RegistryService) GetTask(taskID string, ownerID string) (TaskSnapshot,
 	if !found || snapshot.ownerID != normalizedOwnerID {
 		return TaskSnapshot{}, false
 	}
-	return snapshotTask(snapshot), true
+	return s.withApprovalID(snapshotTask(snapshot)), true
 }
 
 func (s *RegistryService) CancelTask(taskID string, ownerID string) (TaskSnapshot, error) {
@@ -276,6 +322,9 @@ func (s *RegistryService) CancelTask(taskID string, ownerID string) (TaskSnapsho
 	}
 
 	now := s.nowFn()
+	if current.status == TaskStatusPendingApproval {
+		defer s.closeApprovalForTask(taskID)
+	}
 	if err := s.finishTask(taskID, TaskStatusCanceled, nil, defaultTaskCanceledCode, "task canceled", now); err != nil {
 		if errors.Is(err, ErrTaskTransitionNotApplied) {
 			latest, found := s.getTaskByID(taskID)
@@ -301,6 +350,7 @@ func (s *RegistryService) resolveSubmitTaskResult(
 	runtime *taskRecord,
 	mode TaskMode,
 	wait time.Duration,
+	returnOnPendingApproval bool,
 ) (SubmitTaskResult, error) {
 	if strings.TrimSpace(taskID) == "" {
 		return SubmitTaskResult{}, ErrTaskNotFound
@@ -311,7 +361,7 @@ func (s *RegistryService) resolveSubmitTaskResult(
 		if !found {
 			return SubmitTaskResult{}, ErrTaskNotFound
 		}
-		snapshot := snapshotTask(taskState)
+		snapshot := s.withApprovalID(snapshotTask(taskState))
 		return SubmitTaskResult{Task: snapshot, Completed: isTaskTerminal(snapshot.Status)}, nil
 	}
 
@@ -322,6 +372,9 @@ func (s *RegistryService) resolveSubmitTaskResult(
 	if mode == TaskModeAsync || snap.Completed {
 		return snap, nil
 	}
+	if returnOnPendingApproval && snap.Task.Status == TaskStatusPendingApproval {
+		return snap, nil
+	}
 
 	waitDone := func(waitDuration time.Duration) error {
 		if runtime == nil {
@@ -363,7 +416,7 @@ func (s *RegistryService) resolveSubmitTaskResult(
 	}
 }
 
-func (s *RegistryService) executeTask(ctx context.Context, taskID string, ownerID string, capability string, inputJSON []byte) {
+func (s *RegistryService) executeTask(ctx context.Context, taskID string, ownerID string, capability string, inputJSON []byte, priorityClass string) {
 	if err := s.markTaskDispatched(taskID); err != nil {
 		if errors.Is(err, ErrTaskTransitionNotApplied) {
 			return
@@ -375,7 +428,7 @@ func (s *RegistryService) executeTask(ctx context.Context, taskID string, ownerI
 		return
 	}
 	var markRunningErr error
-	outcome, err := s.dispatchCommand(ctx, capability, inputJSON, 0, ownerID, func(commandID string) {
+	outcome, err := s.dispatchCommand(ctx, capability, inputJSON, 0, ownerID, priorityClass, func(commandID string) {
 		if markErr := s.markTaskRunning(taskID, commandID); markErr != nil {
 			markRunningErr = markErr
 			runtime := s.getTaskRuntime(taskID)
@@ -501,7 +554,10 @@ func (s *RegistryService) markTaskRunning(taskID string, commandID string) error
 func (s *RegistryService) finishTaskWithError(taskID string, err error) error {
 	now := s.nowFn()
 	var commandErr *CommandExecutionError
+	var quotaErr *QuotaExceededError
 	switch {
+	case errors.As(err, &quotaErr):
+		return s.finishTask(taskID, TaskStatusFailed, nil, taskQuotaExceededCode, quotaErr.Error(), now)
 	case errors.Is(err, ErrNoCapabilityWorker):
 		return s.finishTask(taskID, TaskStatusFailed, nil, defaultTaskNoWorkerCode, "no online worker supports capability", now)
 	case errors.Is(err, ErrNoWorkerCapacity):
@@ -609,6 +665,9 @@ func (s *RegistryService) checkCapabilityAvailability(capability string, ownerID
 		if session == nil || !session.hasCapability(capability) {
 			continue
 		}
+		if normalizeCapability(capability) == computerUseCapabilityName && s.granteeFlightBusy(ownerID, nodeID) {
+			continue
+		}
 		inflight, maxInflight, ok := session.inflightSnapshot(capability)
 		if !ok {
 			continue
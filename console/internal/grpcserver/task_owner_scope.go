@@ -25,12 +25,24 @@ type terminalExecScopedPayload struct {
 	LeaseTTLSec     *int   `json:"lease_ttl_sec,omitempty"`
 }
 
+// computerUseScopedPayload mirrors the worker-sys computerUse payload;
+// without session_id or create_if_missing the command runs statelessly.
+type computerUseScopedPayload struct {
+	Command         string `json:"command"`
+	SessionID       string `json:"session_id,omitempty"`
+	CreateIfMissing bool   `json:"create_if_missing,omitempty"`
+	LeaseTTLSec     *int   `json:"lease_ttl_sec,omitempty"`
+}
+
 type terminalResourceScopedPayload struct {
 	SessionID string `json:"session_id"`
 	FilePath  string `json:"file_path"`
 	Action    string `json:"action,omitempty"`
 }
 
+// normalizeTaskOwnerID trims an owner scope. The scope is an account ID, or
+// an organization ID for org tokens; both isolate tasks, request IDs and
+// sessions the same way, so members sharing org tokens share sessions.
 func normalizeTaskOwnerID(ownerID string) string {
 	return strings.TrimSpace(ownerID)
 }
@@ -108,6 +120,34 @@ func (s *RegistryService) scopeTaskInputByOwner(capability string, ownerID strin
 			return nil, status.Error(codes.Internal, "failed to encode terminalExec payload")
 		}
 		return scopedPayload, nil
+	case computerUseCapabilityName:
+		payload := computerUseScopedPayload{}
+		if err := json.Unmarshal(inputJSON, &payload); err != nil {
+			return inputJSON, nil
+		}
+		sessionID := strings.TrimSpace(payload.SessionID)
+		if sessionID == "" {
+			if !payload.CreateIfMissing {
+				return inputJSON, nil
+			}
+			if s == nil || s.newTerminalSessionIDFn == nil {
+				return nil, status.Error(codes.Internal, "failed to create session_id")
+			}
+			createdSessionID, err := s.newTerminalSessionIDFn()
+			if err != nil {
+				return nil, status.Error(codes.Internal, "failed to create session_id")
+			}
+			sessionID = strings.TrimSpace(createdSessionID)
+			if sessionID == "" {
+				return nil, status.Error(codes.Internal, "failed to create session_id")
+			}
+		}
+		payload.SessionID = scopeTerminalSessionID(normalizedOwnerID, sessionID)
+		scopedPayload, err := json.Marshal(payload)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to encode computerUse payload")
+		}
+		return scopedPayload, nil
 	case taskCapabilityTerminalResource:
 		payload := terminalResourceScopedPayload{}
 		if err := json.Unmarshal(inputJSON, &payload); err != nil {
@@ -133,7 +173,7 @@ func (s *RegistryService) restoreTaskResultOwnerScope(ownerID string, capability
 	if normalizedOwnerID == "" || len(resultJSON) == 0 {
 		return resultJSON, true
 	}
-	if capability != taskCapabilityTerminalExec && capability != taskCapabilityTerminalResource {
+	if capability != taskCapabilityTerminalExec && capability != taskCapabilityTerminalResource && capability != computerUseCapabilityName {
 		return resultJSON, true
 	}
 
@@ -35,3 +35,32 @@ func TestRestoreTaskResultOwnerScopeReturnsFalseOnOwnerMismatch(t *testing.T) {
 		t.Fatalf("expected restore to fail for mismatched owner scope")
 	}
 }
+
+func TestScopeTaskInputByOwnerScopesComputerUseSessionsOnly(t *testing.T) {
+	svc := &RegistryService{newTerminalSessionIDFn: func() (string, error) { return "generated-1", nil }}
+
+	stateless := []byte(`{"command":"pwd"}`)
+	scoped, err := svc.scopeTaskInputByOwner(computerUseCapabilityName, "owner-a", stateless)
+	if err != nil {
+		t.Fatalf("scope stateless input: %v", err)
+	}
+	if string(scoped) != string(stateless) {
+		t.Fatalf("expected stateless computerUse payload to be unchanged, got %s", scoped)
+	}
+
+	scoped, err = svc.scopeTaskInputByOwner(computerUseCapabilityName, "owner-a", []byte(`{"command":"pwd","create_if_missing":true}`))
+	if err != nil {
+		t.Fatalf("scope session input: %v", err)
+	}
+	if string(scoped) != `{"command":"pwd","session_id":"obx:owner-a:generated-1","create_if_missing":true}` {
+		t.Fatalf("unexpected scoped computerUse payload: %s", scoped)
+	}
+	if routeKey := terminalSessionIDFromPayload(computerUseCapabilityName, scoped); routeKey != "computeruse:obx:owner-a:generated-1" {
+		t.Fatalf("unexpected computerUse route key %q", routeKey)
+	}
+
+	restored, ok := svc.restoreTaskResultOwnerScope("owner-a", computerUseCapabilityName, []byte(`{"session_id":"obx:owner-a:generated-1"}`))
+	if !ok || string(restored) != `{"session_id":"generated-1"}` {
+		t.Fatalf("unexpected restored computerUse result %s (ok=%v)", restored, ok)
+	}
+}
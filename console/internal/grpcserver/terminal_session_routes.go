@@ -104,6 +104,40 @@ func (s *RegistryService) touchTerminalSessionRoute(sessionID string, now time.T
 	return route.NodeID, true
 }
 
+func (s *RegistryService) hasTerminalSessionRoute(sessionID string) bool {
+	if s == nil {
+		return false
+	}
+	s.terminalRoutesMu.RLock()
+	defer s.terminalRoutesMu.RUnlock()
+	_, ok := s.terminalSessionToNode[strings.TrimSpace(sessionID)]
+	return ok
+}
+
+// countTerminalSessionRoutesByOwner counts the terminalExec and computerUse
+// sessions of ownerID that still have a route.
+func (s *RegistryService) countTerminalSessionRoutesByOwner(ownerID string) int {
+	normalizedOwnerID := normalizeTaskOwnerID(ownerID)
+	if s == nil || normalizedOwnerID == "" {
+		return 0
+	}
+	prefix := strings.Join([]string{
+		taskOwnerScopePrefix,
+		normalizedOwnerID,
+	}, taskOwnerScopeSeparator) + taskOwnerScopeSeparator
+	computerUsePrefix := computerUseCapabilityName + taskOwnerScopeSeparator
+
+	count := 0
+	s.terminalRoutesMu.RLock()
+	defer s.terminalRoutesMu.RUnlock()
+	for sessionID := range s.terminalSessionToNode {
+		if strings.HasPrefix(strings.TrimPrefix(sessionID, computerUsePrefix), prefix) {
+			count++
+		}
+	}
+	return count
+}
+
 func (s *RegistryService) clearTerminalSessionRoute(sessionID string, expectedNodeID string) {
 	if s == nil {
 		return
@@ -0,0 +1,48 @@
+package grpcserver
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+// commandUsage collects what a dispatched command cost while it runs.
+type commandUsage struct {
+	capability string
+	ownerID    string
+	nodeID     string
+	commandID  string
+	queueWait  time.Duration
+	startedAt  time.Time
+}
+
+// recordCommandUsage stores one usage record per command that reached a
+// worker on behalf of an owner. Failed and timed out commands are recorded
+// too; they held the worker just the same.
+func (s *RegistryService) recordCommandUsage(usage commandUsage, outcome commandOutcome, dispatchErr error) {
+	if normalizeTaskOwnerID(usage.ownerID) == "" || s.store == nil {
+		return
+	}
+	now := s.nowFn()
+	status := registry.UsageStatusSucceeded
+	if dispatchErr != nil || outcome.err != nil {
+		status = registry.UsageStatusFailed
+	}
+	record := registry.UsageRecord{
+		CommandID:       usage.commandID,
+		OwnerID:         usage.ownerID,
+		Capability:      usage.capability,
+		NodeID:          usage.nodeID,
+		Status:          status,
+		QueueWait:       usage.queueWait,
+		Exec:            now.Sub(usage.startedAt),
+		OutputBytes:     int64(len(outcome.payloadJSON)),
+		CPUSeconds:      outcome.cpuSeconds,
+		PeakMemoryBytes: outcome.peakMemoryBytes,
+		CompletedAt:     now,
+	}
+	if err := s.store.RecordUsage(record); err != nil {
+		slog.Warn("failed to record command usage", "command_id", usage.commandID, "owner_id", usage.ownerID, "error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func TestDispatchRecordsCommandUsage(t *testing.T) {
+	store := registrytest.NewStore(t)
+	svc := NewRegistryService(store, map[string]string{"node-1": "secret-1"}, 5, 15, 60*time.Second)
+	client, cleanup := newBufClient(t, svc)
+	defer cleanup()
+	stream, _, err := connectWorker(client, "node-1", "secret-1", "nonce-usage", []string{"echo"})
+	if err != nil {
+		t.Fatalf("connect worker failed: %v", err)
+	}
+	go func() {
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			dispatch := resp.GetCommandDispatch()
+			if dispatch == nil {
+				continue
+			}
+			result := &registryv1.CommandResult{
+				CommandId:       dispatch.GetCommandId(),
+				PayloadJson:     dispatch.GetPayloadJson(),
+				CompletedUnixMs: time.Now().UnixMilli(),
+				CpuSeconds:      1.25,
+				PeakMemoryBytes: 4096,
+			}
+			if strings.Contains(string(dispatch.GetPayloadJson()), "fail") {
+				result.PayloadJson = nil
+				result.Error = &registryv1.CommandError{Code: "execution_failed", Message: "boom"}
+			}
+			_ = stream.Send(&registryv1.ConnectRequest{
+				Payload: &registryv1.ConnectRequest_CommandResult{CommandResult: result},
+			})
+		}
+	}()
+
+	for _, message := range []string{"hello", "fail"} {
+		if _, err := svc.SubmitTask(context.Background(), SubmitTaskRequest{
+			Capability: "echo",
+			InputJSON:  []byte(`{"message":"` + message + `"}`),
+			Mode:       TaskModeSync,
+			Timeout:    2 * time.Second,
+			OwnerID:    "owner-a",
+		}); err != nil {
+			t.Fatalf("submit %s: %v", message, err)
+		}
+	}
+	if _, err := svc.DispatchEcho(context.Background(), "unowned", time.Second); err != nil {
+		t.Fatalf("dispatch echo: %v", err)
+	}
+
+	totals, err := store.UsageTotals("", time.Now().Add(-time.Minute), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("usage totals: %v", err)
+	}
+	if len(totals) != 1 {
+		t.Fatalf("expected one owner, capability and node group, got %#v", totals)
+	}
+	total := totals[0]
+	if total.OwnerID != "owner-a" || total.Capability != "echo" || total.NodeID != "node-1" {
+		t.Fatalf("unexpected usage group %#v", total)
+	}
+	if total.Tasks != 2 || total.FailedTasks != 1 || total.CPUSeconds != 2.5 || total.PeakMemoryBytes != 4096 {
+		t.Fatalf("unexpected usage totals %#v", total)
+	}
+	if total.OutputBytes != int64(len(`{"message":"hello"}`)) || total.Exec <= 0 {
+		t.Fatalf("expected output bytes and execution time to be recorded, got %#v", total)
+	}
+
+	if removed := store.PruneUsageRecords(time.Now().Add(91 * 24 * time.Hour)); removed != 2 {
+		t.Fatalf("expected both usage records to be pruned, got %d", removed)
+	}
+}
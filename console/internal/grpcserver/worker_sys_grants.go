@@ -0,0 +1,94 @@
+package grpcserver
+
+import (
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+// listWorkerSysNodeIDTiers returns the worker-sys nodes ownerID may use for
+// a new command. The caller's own worker-sys wins; otherwise every online
+// granted node is returned and the dispatch loop picks one with capacity.
+// A grantee runs one computerUse command per node, so for computerUse the
+// nodes where it already has one form a later tier.
+func (s *RegistryService) listWorkerSysNodeIDTiers(capability string, ownerID string, now time.Time, offlineTTL time.Duration) [][]string {
+	owned := s.store.ListOnlineNodeIDsByOwnerTypeAndCapability(ownerID, registry.WorkerTypeSys, capability, now, offlineTTL)
+	if len(owned) > 0 {
+		return [][]string{owned}
+	}
+	granted := s.store.ListOnlineGrantedNodeIDsByCapability(ownerID, capability, now, offlineTTL)
+	if capability != computerUseCapabilityName {
+		return [][]string{granted}
+	}
+	free := make([]string, 0, len(granted))
+	busy := make([]string, 0, len(granted))
+	for _, nodeID := range granted {
+		if s.granteeFlightBusy(ownerID, nodeID) {
+			busy = append(busy, nodeID)
+		} else {
+			free = append(free, nodeID)
+		}
+	}
+	tiers := make([][]string, 0, 2)
+	for _, tier := range [][]string{free, busy} {
+		if len(tier) > 0 {
+			tiers = append(tiers, tier)
+		}
+	}
+	return tiers
+}
+
+// canUseWorkerSysNode reports whether ownerID still owns or holds a grant
+// for capability on nodeID. Session routes are checked with it so a revoked
+// or expired grant stops reaching sessions created under it.
+func (s *RegistryService) canUseWorkerSysNode(ownerID string, nodeID string, capability string) bool {
+	normalizedOwnerID := normalizeTaskOwnerID(ownerID)
+	if normalizedOwnerID == "" {
+		return false
+	}
+	if !s.isWorkerSysGrantee(normalizedOwnerID, nodeID) {
+		return true
+	}
+	return s.store.HasWorkerSysGrant(nodeID, normalizedOwnerID, capability, s.nowFn())
+}
+
+func (s *RegistryService) isWorkerSysGrantee(ownerID string, nodeID string) bool {
+	labels := s.store.LabelsByNodeID(nodeID)
+	return strings.TrimSpace(labels[registry.LabelOwnerIDKey]) != normalizeTaskOwnerID(ownerID)
+}
+
+// tryAcquireGranteeFlight keeps a grantee to one computerUse command at a
+// time on a shared worker-sys, so one teammate cannot take every slot the
+// owner configured with WORKER_COMPUTER_USE_MAX_INFLIGHT.
+func (s *RegistryService) tryAcquireGranteeFlight(ownerID string, nodeID string) bool {
+	key := granteeFlightKey(ownerID, nodeID)
+	s.granteeFlightsMu.Lock()
+	defer s.granteeFlightsMu.Unlock()
+	if _, busy := s.granteeFlights[key]; busy {
+		return false
+	}
+	s.granteeFlights[key] = struct{}{}
+	return true
+}
+
+// granteeFlightBusy reports whether ownerID already runs a computerUse
+// command on nodeID as a grantee. Owners never hold a flight.
+func (s *RegistryService) granteeFlightBusy(ownerID string, nodeID string) bool {
+	key := granteeFlightKey(ownerID, nodeID)
+	s.granteeFlightsMu.Lock()
+	defer s.granteeFlightsMu.Unlock()
+	_, busy := s.granteeFlights[key]
+	return busy
+}
+
+func (s *RegistryService) releaseGranteeFlight(ownerID string, nodeID string) {
+	key := granteeFlightKey(ownerID, nodeID)
+	s.granteeFlightsMu.Lock()
+	defer s.granteeFlightsMu.Unlock()
+	delete(s.granteeFlights, key)
+}
+
+func granteeFlightKey(ownerID string, nodeID string) string {
+	return strings.Join([]string{strings.TrimSpace(nodeID), normalizeTaskOwnerID(ownerID)}, taskRequestScopeSeparator)
+}
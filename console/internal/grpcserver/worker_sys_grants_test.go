@@ -0,0 +1,237 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func seedGrantAccount(t *testing.T, store *registry.Store, accountID string) {
+	t.Helper()
+	nowMS := time.Now().UnixMilli()
+	if err := store.Persistence().Queries.InsertAccount(context.Background(), sqlc.InsertAccountParams{
+		AccountID:       accountID,
+		Username:        accountID,
+		UsernameKey:     accountID,
+		PasswordHash:    "x",
+		HashAlgo:        "x",
+		CreatedAtUnixMs: nowMS,
+		UpdatedAtUnixMs: nowMS,
+	}); err != nil {
+		t.Fatalf("insert account %s failed: %v", accountID, err)
+	}
+}
+
+func TestSubmitTaskComputerUseHonorsWorkerSysGrants(t *testing.T) {
+	store := registrytest.NewStore(t)
+	now := time.Now()
+	store.SeedProvisionedWorkers([]registry.ProvisionedWorker{
+		{
+			NodeID: "node-owner-a",
+			Labels: map[string]string{
+				registry.LabelOwnerIDKey:    "owner-a",
+				registry.LabelWorkerTypeKey: registry.WorkerTypeSys,
+			},
+		},
+	}, now, 15*time.Second)
+	seedGrantAccount(t, store, "owner-b")
+
+	svc := NewRegistryService(store, map[string]string{"node-owner-a": "secret-owner-a"}, 5, 15, 60*time.Second)
+	client, cleanup := newBufClient(t, svc)
+	defer cleanup()
+	stream, _, err := connectWorker(client, "node-owner-a", "secret-owner-a", "nonce-grant", []string{computerUseCapabilityDeclared, readImageCapabilityDeclared})
+	if err != nil {
+		t.Fatalf("connect worker failed: %v", err)
+	}
+	defer stream.CloseSend()
+	go computerUseResponder(stream, "owner-a")
+
+	submit := func(ownerID string) (SubmitTaskResult, error) {
+		return svc.SubmitTask(context.Background(), SubmitTaskRequest{
+			Capability: "computerUse",
+			InputJSON:  []byte(`{"command":"echo shared"}`),
+			Mode:       TaskModeSync,
+			Timeout:    2 * time.Second,
+			OwnerID:    ownerID,
+		})
+	}
+	grant := func(capabilities []string, expiresAt time.Time) {
+		t.Helper()
+		if err := store.ReplaceWorkerSysGrant(registry.WorkerSysGrant{
+			NodeID:       "node-owner-a",
+			GranteeID:    "owner-b",
+			Capabilities: capabilities,
+			GrantedBy:    "owner-a",
+			GrantedAt:    now,
+			ExpiresAt:    expiresAt,
+		}); err != nil {
+			t.Fatalf("grant failed: %v", err)
+		}
+	}
+
+	if _, err := submit("owner-b"); !errors.Is(err, ErrNoCapabilityWorker) {
+		t.Fatalf("expected ErrNoCapabilityWorker without a grant, got %v", err)
+	}
+
+	grant([]string{registry.WorkerSysReadOnlyCapability}, now.Add(time.Hour))
+	if _, err := submit("owner-b"); !errors.Is(err, ErrNoCapabilityWorker) {
+		t.Fatalf("expected read-only grant to exclude computerUse, got %v", err)
+	}
+
+	grant([]string{computerUseCapabilityDeclared}, now.Add(-time.Second))
+	if _, err := submit("owner-b"); !errors.Is(err, ErrNoCapabilityWorker) {
+		t.Fatalf("expected expired grant to be ignored, got %v", err)
+	}
+
+	grant([]string{computerUseCapabilityDeclared}, now.Add(time.Hour))
+	result, err := submit("owner-b")
+	if err != nil {
+		t.Fatalf("submit as grantee failed: %v", err)
+	}
+	if result.Task.Status != TaskStatusSucceeded || !strings.Contains(string(result.Task.ResultJSON), "owner-a") {
+		t.Fatalf("expected grantee task to run on the shared worker, got status=%s result=%s", result.Task.Status, string(result.Task.ResultJSON))
+	}
+
+	// A grantee runs one computerUse command at a time; the owner is not
+	// affected by the grantee's flight.
+	if !svc.tryAcquireGranteeFlight("owner-b", "node-owner-a") {
+		t.Fatalf("expected to acquire grantee flight")
+	}
+	if _, err := submit("owner-b"); !errors.Is(err, ErrNoWorkerCapacity) {
+		t.Fatalf("expected ErrNoWorkerCapacity while grantee is busy, got %v", err)
+	}
+	if _, err := submit("owner-a"); err != nil {
+		t.Fatalf("submit as owner failed: %v", err)
+	}
+	svc.releaseGranteeFlight("owner-b", "node-owner-a")
+
+	if deleted, err := store.DeleteWorkerSysGrant("node-owner-a", "owner-b"); err != nil || !deleted {
+		t.Fatalf("revoke grant failed: deleted=%v err=%v", deleted, err)
+	}
+	if _, err := submit("owner-b"); !errors.Is(err, ErrNoCapabilityWorker) {
+		t.Fatalf("expected ErrNoCapabilityWorker after revoke, got %v", err)
+	}
+}
+
+func TestSubmitTaskComputerUseUsesEveryGrantedNode(t *testing.T) {
+	store := registrytest.NewStore(t)
+	now := time.Now()
+	store.SeedProvisionedWorkers([]registry.ProvisionedWorker{
+		{
+			NodeID: "node-owner-a",
+			Labels: map[string]string{
+				registry.LabelOwnerIDKey:    "owner-a",
+				registry.LabelWorkerTypeKey: registry.WorkerTypeSys,
+			},
+		},
+		{
+			NodeID: "node-owner-c",
+			Labels: map[string]string{
+				registry.LabelOwnerIDKey:    "owner-c",
+				registry.LabelWorkerTypeKey: registry.WorkerTypeSys,
+			},
+		},
+	}, now, 15*time.Second)
+	seedGrantAccount(t, store, "owner-b")
+	for _, grant := range []struct{ nodeID, ownerID string }{{"node-owner-a", "owner-a"}, {"node-owner-c", "owner-c"}} {
+		if err := store.ReplaceWorkerSysGrant(registry.WorkerSysGrant{
+			NodeID:       grant.nodeID,
+			GranteeID:    "owner-b",
+			Capabilities: []string{computerUseCapabilityDeclared},
+			GrantedBy:    grant.ownerID,
+			GrantedAt:    now,
+			ExpiresAt:    now.Add(time.Hour),
+		}); err != nil {
+			t.Fatalf("grant %s failed: %v", grant.nodeID, err)
+		}
+	}
+
+	svc := NewRegistryService(store, map[string]string{"node-owner-a": "secret-owner-a", "node-owner-c": "secret-owner-c"}, 5, 15, 60*time.Second)
+	client, cleanup := newBufClient(t, svc)
+	defer cleanup()
+	for _, worker := range []struct{ nodeID, secret, label string }{
+		{"node-owner-a", "secret-owner-a", "owner-a"},
+		{"node-owner-c", "secret-owner-c", "owner-c"},
+	} {
+		stream, _, err := connectWorker(client, worker.nodeID, worker.secret, "nonce-"+worker.nodeID, []string{computerUseCapabilityDeclared, readImageCapabilityDeclared})
+		if err != nil {
+			t.Fatalf("connect %s failed: %v", worker.nodeID, err)
+		}
+		defer stream.CloseSend()
+		go computerUseResponder(stream, worker.label)
+	}
+
+	tiers := svc.listWorkerSysNodeIDTiers(computerUseCapabilityName, "owner-b", time.Now(), 15*time.Second)
+	if len(tiers) != 1 || len(tiers[0]) != 2 {
+		t.Fatalf("expected both granted nodes in one tier, got %v", tiers)
+	}
+
+	// With the grantee busy on the first node the command goes to the
+	// other granted node instead of failing for lack of capacity.
+	if !svc.tryAcquireGranteeFlight("owner-b", "node-owner-a") {
+		t.Fatalf("expected to acquire grantee flight")
+	}
+	defer svc.releaseGranteeFlight("owner-b", "node-owner-a")
+	tiers = svc.listWorkerSysNodeIDTiers(computerUseCapabilityName, "owner-b", time.Now(), 15*time.Second)
+	if len(tiers) != 2 || len(tiers[0]) != 1 || tiers[0][0] != "node-owner-c" {
+		t.Fatalf("expected the busy node in a later tier, got %v", tiers)
+	}
+	result, err := svc.SubmitTask(context.Background(), SubmitTaskRequest{
+		Capability: "computerUse",
+		InputJSON:  []byte(`{"command":"echo shared"}`),
+		Mode:       TaskModeSync,
+		Timeout:    2 * time.Second,
+		OwnerID:    "owner-b",
+	})
+	if err != nil {
+		t.Fatalf("submit as grantee failed: %v", err)
+	}
+	if result.Task.Status != TaskStatusSucceeded || !strings.Contains(string(result.Task.ResultJSON), "owner-c") {
+		t.Fatalf("expected task to run on the second granted node, got status=%s result=%s", result.Task.Status, string(result.Task.ResultJSON))
+	}
+}
+
+func TestCanUseWorkerSysNodeChecksGrantCapability(t *testing.T) {
+	store := registrytest.NewStore(t)
+	now := time.Now()
+	store.SeedProvisionedWorkers([]registry.ProvisionedWorker{
+		{
+			NodeID: "node-owner-a",
+			Labels: map[string]string{
+				registry.LabelOwnerIDKey:    "owner-a",
+				registry.LabelWorkerTypeKey: registry.WorkerTypeSys,
+			},
+		},
+	}, now, 15*time.Second)
+	seedGrantAccount(t, store, "owner-b")
+	if err := store.ReplaceWorkerSysGrant(registry.WorkerSysGrant{
+		NodeID:       "node-owner-a",
+		GranteeID:    "owner-b",
+		Capabilities: []string{readImageCapabilityDeclared},
+		GrantedBy:    "owner-a",
+		GrantedAt:    now,
+		ExpiresAt:    now.Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("grant failed: %v", err)
+	}
+
+	svc := NewRegistryService(store, nil, 5, 15, 60*time.Second)
+	if !svc.canUseWorkerSysNode("owner-a", "node-owner-a", computerUseCapabilityName) {
+		t.Fatalf("expected owner to use own worker-sys")
+	}
+	if !svc.canUseWorkerSysNode("owner-b", "node-owner-a", readImageCapabilityName) {
+		t.Fatalf("expected grantee to use granted readImage")
+	}
+	if svc.canUseWorkerSysNode("owner-b", "node-owner-a", computerUseCapabilityName) {
+		t.Fatalf("expected grantee to be denied ungranted computerUse")
+	}
+	if svc.canUseWorkerSysNode("owner-c", "node-owner-a", readImageCapabilityName) {
+		t.Fatalf("expected other accounts to be denied")
+	}
+}
@@ -0,0 +1,193 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+)
+
+var errApprovalsUnavailable = errors.New("approvals are unavailable")
+
+// ApprovalService lists and decides tasks held by require_approval policies.
+type ApprovalService interface {
+	ListApprovals(ctx context.Context, ownerID string, isAdmin bool, status grpcserver.ApprovalStatus, limit int) ([]grpcserver.ApprovalSnapshot, error)
+	GetApproval(ctx context.Context, approvalID string, ownerID string, isAdmin bool) (grpcserver.ApprovalSnapshot, error)
+	DecideApproval(ctx context.Context, approvalID string, deciderID string, isAdmin bool, approve bool, comment string) (grpcserver.ApprovalSnapshot, error)
+}
+
+type approvalItem struct {
+	ApprovalID    string          `json:"approval_id"`
+	TaskID        string          `json:"task_id"`
+	OwnerID       string          `json:"owner_id"`
+	TokenID       string          `json:"token_id,omitempty"`
+	Capability    string          `json:"capability"`
+	Input         json.RawMessage `json:"input"`
+	PolicyID      string          `json:"policy_id"`
+	PolicyName    string          `json:"policy_name"`
+	Status        string          `json:"status"`
+	TaskTimeoutMS int64           `json:"task_timeout_ms"`
+	DecidedBy     string          `json:"decided_by,omitempty"`
+	Comment       string          `json:"comment,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+	DecidedAt     *time.Time      `json:"decided_at,omitempty"`
+}
+
+type approvalListResponse struct {
+	Items []approvalItem `json:"items"`
+	Total int            `json:"total"`
+}
+
+type approvalDecisionRequest struct {
+	Decision string `json:"decision"`
+	Comment  string `json:"comment"`
+}
+
+// SetApprovals enables the approval routes.
+func (h *WorkerHandler) SetApprovals(approvals ApprovalService) {
+	if h == nil {
+		return
+	}
+	h.approvals = approvals
+}
+
+func (h *WorkerHandler) ListApprovals(c *gin.Context) {
+	if h.approvals == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errApprovalsUnavailable.Error()})
+		return
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	statusFilter, err := grpcserver.ParseApprovalStatus(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, ok := parsePositiveIntQuery(c, "limit", 0)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+
+	approvals, err := h.approvals.ListApprovals(c.Request.Context(), account.AccountID, account.IsAdmin, statusFilter, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list approvals"})
+		return
+	}
+	items := make([]approvalItem, 0, len(approvals))
+	for _, approval := range approvals {
+		items = append(items, buildApprovalItem(approval))
+	}
+	c.JSON(http.StatusOK, approvalListResponse{Items: items, Total: len(items)})
+}
+
+func (h *WorkerHandler) GetApproval(c *gin.Context) {
+	if h.approvals == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errApprovalsUnavailable.Error()})
+		return
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	approval, err := h.approvals.GetApproval(c.Request.Context(), c.Param("approval_id"), account.AccountID, account.IsAdmin)
+	if err != nil {
+		writeApprovalError(c, err, "failed to load approval")
+		return
+	}
+	c.JSON(http.StatusOK, buildApprovalItem(approval))
+}
+
+// DecideApproval approves or rejects a held task. Only the task owner or an
+// admin sees the approval; anyone else gets 404.
+func (h *WorkerHandler) DecideApproval(c *gin.Context) {
+	if h.approvals == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errApprovalsUnavailable.Error()})
+		return
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	var req approvalDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	var approve bool
+	switch strings.TrimSpace(strings.ToLower(req.Decision)) {
+	case "approve":
+		approve = true
+	case "reject":
+		approve = false
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "decision must be one of approve|reject"})
+		return
+	}
+
+	approval, err := h.approvals.DecideApproval(c.Request.Context(), c.Param("approval_id"), account.AccountID, account.IsAdmin, approve, req.Comment)
+	if err != nil {
+		writeApprovalError(c, err, "failed to decide approval")
+		return
+	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionApprovalDecide,
+		TargetType: "approval",
+		TargetID:   approval.ApprovalID,
+		Details: map[string]any{
+			"decision":   string(approval.Status),
+			"task_id":    approval.TaskID,
+			"owner_id":   approval.OwnerID,
+			"policy_id":  approval.PolicyID,
+			"capability": approval.Capability,
+			"comment":    approval.Comment,
+		},
+	})
+	c.JSON(http.StatusOK, buildApprovalItem(approval))
+}
+
+func buildApprovalItem(approval grpcserver.ApprovalSnapshot) approvalItem {
+	input := json.RawMessage(append([]byte(nil), approval.InputJSON...))
+	if !json.Valid(input) {
+		input = json.RawMessage("null")
+	}
+	return approvalItem{
+		ApprovalID:    approval.ApprovalID,
+		TaskID:        approval.TaskID,
+		OwnerID:       approval.OwnerID,
+		TokenID:       approval.TokenID,
+		Capability:    approval.Capability,
+		Input:         input,
+		PolicyID:      approval.PolicyID,
+		PolicyName:    approval.PolicyName,
+		Status:        string(approval.Status),
+		TaskTimeoutMS: approval.TaskTimeout.Milliseconds(),
+		DecidedBy:     approval.DecidedBy,
+		Comment:       approval.Comment,
+		CreatedAt:     approval.CreatedAt,
+		ExpiresAt:     approval.ExpiresAt,
+		DecidedAt:     approval.DecidedAt,
+	}
+}
+
+func writeApprovalError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, grpcserver.ErrApprovalNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, grpcserver.ErrApprovalDecided):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, grpcserver.ErrApprovalCommentTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,135 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+type fakeApprovalService struct {
+	approval grpcserver.ApprovalSnapshot
+	deciders []string
+}
+
+func (f *fakeApprovalService) visible(ownerID string, isAdmin bool) bool {
+	return isAdmin || f.approval.OwnerID == ownerID
+}
+
+func (f *fakeApprovalService) ListApprovals(_ context.Context, ownerID string, isAdmin bool, status grpcserver.ApprovalStatus, _ int) ([]grpcserver.ApprovalSnapshot, error) {
+	if !f.visible(ownerID, isAdmin) || (status != "" && status != f.approval.Status) {
+		return nil, nil
+	}
+	return []grpcserver.ApprovalSnapshot{f.approval}, nil
+}
+
+func (f *fakeApprovalService) GetApproval(_ context.Context, approvalID string, ownerID string, isAdmin bool) (grpcserver.ApprovalSnapshot, error) {
+	if approvalID != f.approval.ApprovalID || !f.visible(ownerID, isAdmin) {
+		return grpcserver.ApprovalSnapshot{}, grpcserver.ErrApprovalNotFound
+	}
+	return f.approval, nil
+}
+
+func (f *fakeApprovalService) DecideApproval(ctx context.Context, approvalID string, deciderID string, isAdmin bool, approve bool, comment string) (grpcserver.ApprovalSnapshot, error) {
+	current, err := f.GetApproval(ctx, approvalID, deciderID, isAdmin)
+	if err != nil {
+		return grpcserver.ApprovalSnapshot{}, err
+	}
+	if current.Status != grpcserver.ApprovalStatusPending {
+		return current, grpcserver.ErrApprovalDecided
+	}
+	f.approval.Status = grpcserver.ApprovalStatusRejected
+	if approve {
+		f.approval.Status = grpcserver.ApprovalStatusApproved
+	}
+	f.approval.DecidedBy = deciderID
+	f.approval.Comment = comment
+	f.deciders = append(f.deciders, deciderID)
+	return f.approval, nil
+}
+
+func TestApprovalRoutesOwnerDecision(t *testing.T) {
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+	seedTestAccount(t, db.Queries, "acc-approval-owner", "approval-owner", "owner-password", false)
+	seedTestAccount(t, db.Queries, "acc-approval-other", "approval-other", "other-password", false)
+
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+	store := registrytest.NewStore(t)
+	approvals := &fakeApprovalService{approval: grpcserver.ApprovalSnapshot{
+		ApprovalID:  "approval-1",
+		TaskID:      "task-1",
+		OwnerID:     "acc-approval-owner",
+		Capability:  "computerUse",
+		InputJSON:   []byte(`{"command":"shutdown now"}`),
+		PolicyID:    "pol-1",
+		PolicyName:  "approve-shutdown",
+		Status:      grpcserver.ApprovalStatusPending,
+		TaskTimeout: time.Minute,
+		CreatedAt:   time.Unix(1_700_000_000, 0),
+		ExpiresAt:   time.Unix(1_700_000_600, 0),
+	}}
+	handler := NewWorkerHandler(store, 15*time.Second, nil, nil, nil, "")
+	handler.SetApprovals(approvals)
+	router := mustNewRouter(t, handler, consoleAuth, mcpAuth)
+
+	otherCookie := loginSessionCookieFor(t, router, "approval-other", "other-password")
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/approvals/approval-1", "", otherCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected other account 404, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/approvals/approval-1", `{"decision":"approve"}`, otherCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected other account decision 404, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	ownerCookie := loginSessionCookieFor(t, router, "approval-owner", "owner-password")
+	listRec := doJSON(t, router, http.MethodGet, "/api/v1/approvals?status=pending", "", ownerCookie)
+	var listed approvalListResponse
+	if err := json.Unmarshal(listRec.Body.Bytes(), &listed); err != nil || listRec.Code != http.StatusOK {
+		t.Fatalf("expected list 200, got %d body=%s", listRec.Code, listRec.Body.String())
+	}
+	if listed.Total != 1 || listed.Items[0].PolicyName != "approve-shutdown" || string(listed.Items[0].Input) != `{"command":"shutdown now"}` {
+		t.Fatalf("unexpected approval list %#v", listed)
+	}
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/approvals?status=later", "", ownerCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid status 400, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/approvals/approval-1", `{"decision":"maybe"}`, ownerCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid decision 400, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	decideRec := doJSON(t, router, http.MethodPost, "/api/v1/approvals/approval-1", `{"decision":"reject","comment":"not now"}`, ownerCookie)
+	if decideRec.Code != http.StatusOK {
+		t.Fatalf("expected decision 200, got %d body=%s", decideRec.Code, decideRec.Body.String())
+	}
+	if body := decideRec.Body.String(); !strings.Contains(body, `"status":"rejected"`) || !strings.Contains(body, `"comment":"not now"`) {
+		t.Fatalf("unexpected decision payload %s", body)
+	}
+	adminCookie := loginSessionCookie(t, router)
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/approvals/approval-1", `{"decision":"approve"}`, adminCookie); rec.Code != http.StatusConflict {
+		t.Fatalf("expected decided approval 409, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if len(approvals.deciders) != 1 || approvals.deciders[0] != "acc-approval-owner" {
+		t.Fatalf("unexpected deciders %#v", approvals.deciders)
+	}
+
+	events, err := store.Persistence().ListAuditEvents(context.Background(), persistence.AuditFilter{Action: auditActionApprovalDecide}, 0, 10)
+	if err != nil || len(events) != 1 || events[0].TargetID != "approval-1" || events[0].AccountID != "acc-approval-owner" {
+		t.Fatalf("expected one approval.decide audit event, got %#v err=%v", events, err)
+	}
+}
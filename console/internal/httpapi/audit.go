@@ -0,0 +1,310 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+const (
+	requestManagementTokenIDGinKey = "request_management_token_id"
+
+	defaultAuditListLimit = 50
+	maxAuditListLimit     = 500
+	auditExportBatchSize  = 500
+
+	auditActionLogin                    = "auth.login"
+	auditActionLogout                   = "auth.logout"
+	auditActionTokenRejected            = "auth.token_rejected"
+	auditActionLockoutUnlock            = "auth.lockout_unlock"
+	auditActionAccountCreate            = "account.create"
+	auditActionAccountDelete            = "account.delete"
+	auditActionAccountPassword          = "account.password_change"
+	auditActionAccountSessionsRevoke    = "account.sessions_revoke"
+	auditActionAccountTwoFactorReset    = "account.2fa_reset"
+	auditActionSessionRevoke            = "session.revoke"
+	auditActionTwoFactorEnable          = "2fa.enable"
+	auditActionTwoFactorDisable         = "2fa.disable"
+	auditActionRecoveryCodesRegenerate  = "2fa.recovery_codes_regenerate"
+	auditActionSecuritySettingsUpdate   = "settings.security_update"
+	auditActionTokenCreate              = "token.create"
+	auditActionTokenDelete              = "token.delete"
+	auditActionManagementTokenCreate    = "management_token.create"
+	auditActionManagementTokenDelete    = "management_token.delete"
+	auditActionWorkerCreate             = "worker.create"
+	auditActionWorkerDelete             = "worker.delete"
+	auditActionWorkerGrant              = "worker.grant"
+	auditActionWorkerGrantRevoke        = "worker.grant_revoke"
+	auditActionWorkerPoolUpdate         = "worker.pool_update"
+	auditActionTaskSubmit               = "task.submit"
+	auditActionTaskCancel               = "task.cancel"
+	auditActionPolicyCreate             = "policy.create"
+	auditActionPolicyUpdate             = "policy.update"
+	auditActionPolicyDelete             = "policy.delete"
+	auditActionApprovalDecide           = "approval.decide"
+	auditActionOrganizationCreate       = "org.create"
+	auditActionOrganizationDelete       = "org.delete"
+	auditActionOrganizationMemberPut    = "org.member_put"
+	auditActionOrganizationMemberRemove = "org.member_remove"
+	auditActionQuotaUpdate              = "quota.update"
+	auditActionSchedulingUpdate         = "scheduling.update"
+)
+
+var errAuditLogUnavailable = errors.New("audit log is unavailable")
+
+type auditEventItem struct {
+	EventID    int64           `json:"event_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ActorType  string          `json:"actor_type"`
+	ActorID    string          `json:"actor_id"`
+	AccountID  string          `json:"account_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Outcome    string          `json:"outcome"`
+	IPAddress  string          `json:"ip_address"`
+	Details    json.RawMessage `json:"details"`
+	PrevHash   string          `json:"prev_hash"`
+	EventHash  string          `json:"event_hash"`
+}
+
+type auditEventListResponse struct {
+	Items      []auditEventItem `json:"items"`
+	NextBefore int64            `json:"next_before,omitempty"`
+}
+
+// SetAuditLog enables recording of console account actions and the admin
+// audit routes.
+func (a *ConsoleAuth) SetAuditLog(db *persistence.DB) {
+	if a == nil {
+		return
+	}
+	a.auditLog = db
+}
+
+func (a *ConsoleAuth) recordAudit(c *gin.Context, event persistence.AuditEvent) {
+	if a == nil {
+		return
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = a.now()
+	}
+	appendAuditEvent(c, a.auditLog, event)
+}
+
+func (a *MCPAuth) recordAudit(c *gin.Context, event persistence.AuditEvent) {
+	if a == nil {
+		return
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = a.now()
+	}
+	appendAuditEvent(c, a.db, event)
+}
+
+func (h *WorkerHandler) recordAudit(c *gin.Context, event persistence.AuditEvent) {
+	if h == nil || h.store == nil {
+		return
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = h.nowFn()
+	}
+	appendAuditEvent(c, h.store.Persistence(), event)
+}
+
+// appendAuditEvent fills the actor and client address from the request and
+// stores the event. Audit failures are logged, never surfaced to the caller.
+func appendAuditEvent(c *gin.Context, db *persistence.DB, event persistence.AuditEvent) {
+	if db == nil || c == nil {
+		return
+	}
+	if event.ActorType == "" {
+		event.ActorType, event.ActorID, event.AccountID = requestAuditActor(c, event.AccountID)
+	}
+	if event.IPAddress == "" {
+		event.IPAddress = strings.TrimSpace(c.ClientIP())
+	}
+	ctx := context.Background()
+	if c.Request != nil {
+		ctx = context.WithoutCancel(c.Request.Context())
+	}
+	if err := db.AppendAuditEvent(ctx, event); err != nil {
+		slog.Warn("failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+// requestAuditActor identifies who made the request: a management token, a
+// dashboard session, or an access token, in that order.
+func requestAuditActor(c *gin.Context, fallbackAccountID string) (string, string, string) {
+	account, hasAccount := requestSessionAccountFromGin(c)
+	if tokenID := c.GetString(requestManagementTokenIDGinKey); tokenID != "" && hasAccount {
+		return persistence.AuditActorManagementToken, tokenID, account.AccountID
+	}
+	if hasAccount {
+		return persistence.AuditActorAccount, account.AccountID, account.AccountID
+	}
+	if tokenID := c.GetString(requestAccessTokenIDGinKey); tokenID != "" {
+		if accountID := c.GetString(requestTokenAccountIDGinKey); accountID != "" {
+			return persistence.AuditActorAccessToken, tokenID, accountID
+		}
+		return persistence.AuditActorAccessToken, tokenID, requestOwnerIDFromGin(c)
+	}
+	return persistence.AuditActorAnonymous, "", fallbackAccountID
+}
+
+func (a *ConsoleAuth) ListAuditEvents(c *gin.Context) {
+	if a == nil || a.auditLog == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errAuditLogUnavailable.Error()})
+		return
+	}
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+	limit, ok := parsePositiveIntQuery(c, "limit", defaultAuditListLimit)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	if limit > maxAuditListLimit {
+		limit = maxAuditListLimit
+	}
+	before := int64(0)
+	if raw := strings.TrimSpace(c.Query("before")); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "before must be a positive event_id"})
+			return
+		}
+		before = parsed
+	}
+
+	events, err := a.auditLog.ListAuditEvents(c.Request.Context(), filter, before, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list audit events"})
+		return
+	}
+	response := auditEventListResponse{Items: make([]auditEventItem, 0, len(events))}
+	for _, event := range events {
+		response.Items = append(response.Items, newAuditEventItem(event))
+	}
+	if len(events) == limit {
+		response.NextBefore = events[len(events)-1].EventID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportAuditEvents streams matching events oldest first as JSON Lines, one
+// stored row per line, so the chain can be re-verified outside the console.
+func (a *ConsoleAuth) ExportAuditEvents(c *gin.Context) {
+	if a == nil || a.auditLog == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errAuditLogUnavailable.Error()})
+		return
+	}
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	events, err := a.auditLog.ListAuditEventsAfter(ctx, filter, 0, auditExportBatchSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export audit events"})
+		return
+	}
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="onlyboxes-audit-`+strconv.FormatInt(a.now().Unix(), 10)+`.jsonl"`)
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	for len(events) > 0 {
+		for _, event := range events {
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+		}
+		if len(events) < auditExportBatchSize {
+			return
+		}
+		events, err = a.auditLog.ListAuditEventsAfter(ctx, filter, events[len(events)-1].EventID, auditExportBatchSize)
+		if err != nil {
+			slog.Warn("audit export stopped early", "error", err)
+			return
+		}
+	}
+}
+
+func (a *ConsoleAuth) VerifyAuditChain(c *gin.Context) {
+	if a == nil || a.auditLog == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errAuditLogUnavailable.Error()})
+		return
+	}
+	report, err := a.auditLog.VerifyAuditChain(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify audit chain"})
+		return
+	}
+	if !report.Valid {
+		slog.Error("audit chain verification failed", "event_id", report.BrokenEventID, "reason", report.BrokenReason)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func parseAuditFilter(c *gin.Context) (persistence.AuditFilter, bool) {
+	filter := persistence.AuditFilter{
+		AccountID:  strings.TrimSpace(c.Query("account_id")),
+		Action:     strings.TrimSpace(c.Query("action")),
+		TargetType: strings.TrimSpace(c.Query("target_type")),
+		TargetID:   strings.TrimSpace(c.Query("target_id")),
+		Outcome:    strings.TrimSpace(c.Query("outcome")),
+	}
+	for _, bound := range []struct {
+		key   string
+		value *time.Time
+	}{
+		{key: "since", value: &filter.Since},
+		{key: "until", value: &filter.Until},
+	} {
+		raw := strings.TrimSpace(c.Query(bound.key))
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": bound.key + " must be an RFC3339 timestamp"})
+			return persistence.AuditFilter{}, false
+		}
+		*bound.value = parsed
+	}
+	return filter, true
+}
+
+func newAuditEventItem(event sqlc.AuditEvent) auditEventItem {
+	details := json.RawMessage(event.DetailsJson)
+	if !json.Valid(details) {
+		details = json.RawMessage("{}")
+	}
+	return auditEventItem{
+		EventID:    event.EventID,
+		CreatedAt:  time.UnixMilli(event.CreatedAtUnixMs),
+		ActorType:  event.ActorType,
+		ActorID:    event.ActorID,
+		AccountID:  event.AccountID,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		Outcome:    event.Outcome,
+		IPAddress:  event.IpAddress,
+		Details:    details,
+		PrevHash:   event.PrevHash,
+		EventHash:  event.EventHash,
+	}
+}
@@ -0,0 +1,159 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func newAuditTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+	seedTestAccount(t, db.Queries, "acc-test-member", "member-test", "member-password", false)
+
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	consoleAuth.SetAuditLog(db)
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	return mustNewRouter(t, handler, consoleAuth, mcpAuth)
+}
+
+func listAuditEventsForTest(t *testing.T, router http.Handler, cookie *http.Cookie, query string) auditEventListResponse {
+	t.Helper()
+	rec := doJSON(t, router, http.MethodGet, "/api/v1/audit"+query, "", cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected audit list 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload auditEventListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode audit list: %v", err)
+	}
+	return payload
+}
+
+func TestAuditLogRecordsConsoleActions(t *testing.T) {
+	router := newAuditTestRouter(t)
+
+	if rec := postLogin(router, testDashboardUsername, "wrong-password"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected failed login 401, got %d", rec.Code)
+	}
+	cookie := loginSessionCookie(t, router)
+	createRec := doJSON(t, router, http.MethodPost, "/api/v1/console/tokens", `{"name":"ci"}`, cookie)
+	if createRec.Code != http.StatusCreated {
+		t.Fatalf("expected token create 201, got %d body=%s", createRec.Code, createRec.Body.String())
+	}
+	var created createTrustedTokenResponse
+	if err := json.Unmarshal(createRec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode token create: %v", err)
+	}
+
+	all := listAuditEventsForTest(t, router, cookie, "")
+	if len(all.Items) != 3 {
+		t.Fatalf("expected 3 audit events, got %#v", all.Items)
+	}
+	tokenEvent, loginEvent, failedEvent := all.Items[0], all.Items[1], all.Items[2]
+	if tokenEvent.Action != auditActionTokenCreate || tokenEvent.TargetID != created.ID ||
+		tokenEvent.ActorType != persistence.AuditActorAccount || tokenEvent.ActorID != testDashboardAccountID {
+		t.Fatalf("unexpected token create event %#v", tokenEvent)
+	}
+	if strings.Contains(string(tokenEvent.Details), created.Token) {
+		t.Fatalf("expected token value to stay out of the audit log, got %s", tokenEvent.Details)
+	}
+	if loginEvent.Action != auditActionLogin || loginEvent.Outcome != persistence.AuditOutcomeSuccess ||
+		loginEvent.AccountID != testDashboardAccountID || !strings.Contains(string(loginEvent.Details), `"method":"password"`) {
+		t.Fatalf("unexpected login event %#v", loginEvent)
+	}
+	if failedEvent.Action != auditActionLogin || failedEvent.Outcome != persistence.AuditOutcomeFailure ||
+		failedEvent.ActorType != persistence.AuditActorAnonymous || failedEvent.PrevHash != "" {
+		t.Fatalf("unexpected failed login event %#v", failedEvent)
+	}
+	if loginEvent.PrevHash != failedEvent.EventHash || tokenEvent.PrevHash != loginEvent.EventHash {
+		t.Fatalf("expected listed events to be chained, got %#v", all.Items)
+	}
+
+	filtered := listAuditEventsForTest(t, router, cookie, "?action=auth.login&outcome=failure")
+	if len(filtered.Items) != 1 || filtered.Items[0].EventID != failedEvent.EventID {
+		t.Fatalf("unexpected filtered audit events %#v", filtered.Items)
+	}
+	firstPage := listAuditEventsForTest(t, router, cookie, "?limit=2")
+	if len(firstPage.Items) != 2 || firstPage.NextBefore != loginEvent.EventID {
+		t.Fatalf("unexpected first audit page %#v", firstPage)
+	}
+	secondPage := listAuditEventsForTest(t, router, cookie, "?limit=2&before="+strconv.FormatInt(firstPage.NextBefore, 10))
+	if len(secondPage.Items) != 1 || secondPage.Items[0].EventID != failedEvent.EventID || secondPage.NextBefore != 0 {
+		t.Fatalf("unexpected second audit page %#v", secondPage)
+	}
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/audit?since=yesterday", "", cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid since 400, got %d", rec.Code)
+	}
+
+	exportRec := doJSON(t, router, http.MethodGet, "/api/v1/audit/export", "", cookie)
+	if exportRec.Code != http.StatusOK || !strings.HasPrefix(exportRec.Header().Get("Content-Type"), "application/x-ndjson") {
+		t.Fatalf("unexpected export response %d %q", exportRec.Code, exportRec.Header().Get("Content-Type"))
+	}
+	prevHash := ""
+	lines := 0
+	scanner := bufio.NewScanner(strings.NewReader(exportRec.Body.String()))
+	for scanner.Scan() {
+		var row sqlc.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("decode export line %q: %v", scanner.Text(), err)
+		}
+		if row.PrevHash != prevHash || persistence.AuditEventHash(row) != row.EventHash {
+			t.Fatalf("export line %d does not verify: %#v", lines, row)
+		}
+		prevHash = row.EventHash
+		lines++
+	}
+	if lines != 3 || prevHash != tokenEvent.EventHash {
+		t.Fatalf("expected 3 chained export lines ending at %q, got %d ending at %q", tokenEvent.EventHash, lines, prevHash)
+	}
+
+	verifyRec := doJSON(t, router, http.MethodGet, "/api/v1/audit/verify", "", cookie)
+	if verifyRec.Code != http.StatusOK {
+		t.Fatalf("expected verify 200, got %d body=%s", verifyRec.Code, verifyRec.Body.String())
+	}
+	var report persistence.AuditChainVerification
+	if err := json.Unmarshal(verifyRec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode verify report: %v", err)
+	}
+	if !report.Valid || report.CheckedEvents != 3 || report.LastEventHash != tokenEvent.EventHash {
+		t.Fatalf("unexpected verify report %#v", report)
+	}
+}
+
+func TestAuditRoutesRequireAdmin(t *testing.T) {
+	router := newAuditTestRouter(t)
+	memberCookie := loginSessionCookieFor(t, router, "member-test", "member-password")
+
+	for _, path := range []string{"/api/v1/audit", "/api/v1/audit/export", "/api/v1/audit/verify"} {
+		if rec := doJSON(t, router, http.MethodGet, path, "", memberCookie); rec.Code != http.StatusForbidden {
+			t.Fatalf("expected member %s to be forbidden, got %d", path, rec.Code)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected anonymous %s to be unauthorized, got %d", path, rec.Code)
+		}
+	}
+}
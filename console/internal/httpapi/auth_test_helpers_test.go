@@ -163,3 +163,10 @@ func loginSessionCookie(t *testing.T, router http.Handler) *http.Cookie {
 	t.Fatalf("expected %s cookie in login response", dashboardSessionCookieName)
 	return nil
 }
+
+// addSessionCookie attaches the dashboard session cookie and the matching
+// CSRF header, the way the dashboard does for every request.
+func addSessionCookie(req *http.Request, cookie *http.Cookie) {
+	req.AddCookie(cookie)
+	req.Header.Set(dashboardCSRFHeaderName, csrfTokenForSession(cookie.Value))
+}
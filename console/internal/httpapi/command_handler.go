@@ -30,6 +30,10 @@ const (
 	terminalTaskNoWorkerCode        = "no_worker"
 	terminalTaskNoCapacityCode      = "no_capacity"
 	terminalTaskTimeoutCode         = "timeout"
+	taskApprovalRejectedCode        = "approval_rejected"
+	taskApprovalExpiredCode         = "approval_expired"
+	computerUseSessionLimitCode     = "session_limit_reached"
+	taskQuotaExceededCode           = "quota_exceeded"
 )
 
 type EchoDispatcher interface {
@@ -73,13 +77,19 @@ type terminalExecPayload struct {
 }
 
 type computerUseCommandRequest struct {
-	Command   string `json:"command"`
-	TimeoutMS *int   `json:"timeout_ms,omitempty"`
-	RequestID string `json:"request_id,omitempty"`
+	Command         string `json:"command"`
+	SessionID       string `json:"session_id,omitempty"`
+	CreateIfMissing bool   `json:"create_if_missing,omitempty"`
+	LeaseTTLSec     *int   `json:"lease_ttl_sec,omitempty"`
+	TimeoutMS       *int   `json:"timeout_ms,omitempty"`
+	RequestID       string `json:"request_id,omitempty"`
 }
 
 type computerUsePayload struct {
-	Command string `json:"command"`
+	Command         string `json:"command"`
+	SessionID       string `json:"session_id,omitempty"`
+	CreateIfMissing bool   `json:"create_if_missing,omitempty"`
+	LeaseTTLSec     *int   `json:"lease_ttl_sec,omitempty"`
 }
 
 type terminalCommandResponse struct {
@@ -94,11 +104,15 @@ type terminalCommandResponse struct {
 }
 
 type computerUseCommandResponse struct {
-	Stdout          string `json:"stdout"`
-	Stderr          string `json:"stderr"`
-	ExitCode        int    `json:"exit_code"`
-	StdoutTruncated bool   `json:"stdout_truncated"`
-	StderrTruncated bool   `json:"stderr_truncated"`
+	SessionID          string `json:"session_id,omitempty"`
+	Created            bool   `json:"created,omitempty"`
+	SessionClosed      bool   `json:"session_closed,omitempty"`
+	Stdout             string `json:"stdout"`
+	Stderr             string `json:"stderr"`
+	ExitCode           int    `json:"exit_code"`
+	StdoutTruncated    bool   `json:"stdout_truncated"`
+	StderrTruncated    bool   `json:"stderr_truncated"`
+	LeaseExpiresUnixMS int64  `json:"lease_expires_unix_ms,omitempty"`
 }
 
 func (h *WorkerHandler) EchoCommand(c *gin.Context) {
@@ -201,6 +215,7 @@ func (h *WorkerHandler) TerminalCommand(c *gin.Context) {
 		Timeout:    time.Duration(timeoutMS) * time.Millisecond,
 		RequestID:  strings.TrimSpace(req.RequestID),
 		OwnerID:    ownerID,
+		TokenID:    requestAccessTokenIDFromContext(c.Request.Context()),
 	})
 	if err != nil {
 		h.writeTaskSubmitError(c, err)
@@ -261,7 +276,12 @@ func (h *WorkerHandler) ComputerUseCommand(c *gin.Context) {
 		return
 	}
 
-	payloadJSON, err := json.Marshal(computerUsePayload{Command: req.Command})
+	payloadJSON, err := json.Marshal(computerUsePayload{
+		Command:         req.Command,
+		SessionID:       strings.TrimSpace(req.SessionID),
+		CreateIfMissing: req.CreateIfMissing,
+		LeaseTTLSec:     req.LeaseTTLSec,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode computerUse payload"})
 		return
@@ -274,6 +294,7 @@ func (h *WorkerHandler) ComputerUseCommand(c *gin.Context) {
 		Timeout:    time.Duration(timeoutMS) * time.Millisecond,
 		RequestID:  strings.TrimSpace(req.RequestID),
 		OwnerID:    ownerID,
+		TokenID:    requestAccessTokenIDFromContext(c.Request.Context()),
 	})
 	if err != nil {
 		h.writeTaskSubmitError(c, err)
@@ -321,9 +342,13 @@ func mapTerminalTaskFailure(task grpcserver.TaskSnapshot) (int, string) {
 		return http.StatusServiceUnavailable, "no online worker supports requested capability"
 	case terminalTaskNoCapacityCode:
 		return http.StatusTooManyRequests, "no online worker capacity for requested capability"
+	case taskQuotaExceededCode:
+		return http.StatusTooManyRequests, message
 	case terminalExecInvalidPayloadCode:
 		return http.StatusBadRequest, message
-	case terminalTaskTimeoutCode, "deadline_exceeded":
+	case taskApprovalRejectedCode:
+		return http.StatusForbidden, message
+	case terminalTaskTimeoutCode, "deadline_exceeded", taskApprovalExpiredCode:
 		return http.StatusGatewayTimeout, message
 	default:
 		return http.StatusBadGateway, message
@@ -342,11 +367,17 @@ func mapComputerUseTaskFailure(task grpcserver.TaskSnapshot) (int, string) {
 		return http.StatusServiceUnavailable, "no online worker supports requested capability"
 	case terminalTaskNoCapacityCode:
 		return http.StatusTooManyRequests, "no online worker capacity for requested capability"
+	case terminalExecSessionNotFoundCode:
+		return http.StatusNotFound, message
 	case terminalExecSessionBusyCode:
 		return http.StatusConflict, message
+	case computerUseSessionLimitCode, taskQuotaExceededCode:
+		return http.StatusTooManyRequests, message
 	case terminalExecInvalidPayloadCode:
 		return http.StatusBadRequest, message
-	case terminalTaskTimeoutCode, "deadline_exceeded":
+	case taskApprovalRejectedCode:
+		return http.StatusForbidden, message
+	case terminalTaskTimeoutCode, "deadline_exceeded", taskApprovalExpiredCode:
 		return http.StatusGatewayTimeout, message
 	default:
 		return http.StatusBadGateway, message
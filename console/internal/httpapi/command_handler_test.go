@@ -342,6 +342,9 @@ func TestComputerUseCommandSuccess(t *testing.T) {
 			if payload.Command != "pwd" {
 				t.Fatalf("unexpected command payload: %#v", payload)
 			}
+			if string(req.InputJSON) != `{"command":"pwd"}` {
+				t.Fatalf("expected stateless payload without session fields, got %s", string(req.InputJSON))
+			}
 			resultJSON, _ := json.Marshal(computerUseCommandResponse{
 				Stdout:          "/workspace\n",
 				Stderr:          "",
@@ -377,22 +380,25 @@ func TestComputerUseCommandSuccess(t *testing.T) {
 	}
 }
 
-func TestComputerUseCommandIgnoresLeaseTTLField(t *testing.T) {
+func TestComputerUseCommandForwardsSessionFields(t *testing.T) {
 	store := registrytest.NewStore(t)
 	handler := NewWorkerHandler(store, 15*time.Second, &fakeEchoDispatcher{
 		dispatch: func(ctx context.Context, message string, timeout time.Duration) (string, error) {
 			return message, nil
 		},
 		submitTask: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
-			if strings.Contains(string(req.InputJSON), "lease_ttl_sec") {
-				t.Fatalf("expected lease_ttl_sec to be ignored in computerUse payload, got %s", string(req.InputJSON))
+			payload := computerUsePayload{}
+			if err := json.Unmarshal(req.InputJSON, &payload); err != nil {
+				t.Fatalf("expected valid computerUse payload, got %s", string(req.InputJSON))
+			}
+			if payload.SessionID != "build" || !payload.CreateIfMissing || payload.LeaseTTLSec == nil || *payload.LeaseTTLSec != 60 {
+				t.Fatalf("expected session fields to be forwarded, got %s", string(req.InputJSON))
 			}
 			resultJSON, _ := json.Marshal(computerUseCommandResponse{
-				Stdout:          "/workspace\n",
-				Stderr:          "",
-				ExitCode:        0,
-				StdoutTruncated: false,
-				StderrTruncated: false,
+				SessionID:          "build",
+				Created:            true,
+				Stdout:             "/workspace\n",
+				LeaseExpiresUnixMS: 1_700_000_060_000,
 			})
 			return grpcserver.SubmitTaskResult{
 				Task: grpcserver.TaskSnapshot{
@@ -407,7 +413,7 @@ func TestComputerUseCommandIgnoresLeaseTTLField(t *testing.T) {
 	}, nil, nil, "")
 	router := mustNewRouter(t, handler, newTestConsoleAuth(t), newTestMCPAuth(t))
 
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/commands/computer-use", strings.NewReader(`{"command":"pwd","lease_ttl_sec":60}`))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/commands/computer-use", strings.NewReader(`{"command":"pwd","session_id":" build ","create_if_missing":true,"lease_ttl_sec":60}`))
 	req.Header.Set("Content-Type", "application/json")
 	rec := httptest.NewRecorder()
 	setMCPTokenHeader(req)
@@ -417,6 +423,10 @@ func TestComputerUseCommandIgnoresLeaseTTLField(t *testing.T) {
 	if rec.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d body=%s", rec.Code, rec.Body.String())
 	}
+	if !strings.Contains(rec.Body.String(), `"session_id":"build","created":true`) ||
+		!strings.Contains(rec.Body.String(), `"lease_expires_unix_ms":1700000060000`) {
+		t.Fatalf("expected session fields in response, got %s", rec.Body.String())
+	}
 }
 
 func TestComputerUseCommandStatusMappings(t *testing.T) {
@@ -426,7 +436,9 @@ func TestComputerUseCommandStatusMappings(t *testing.T) {
 		statusCode int
 	}{
 		{name: "invalid_payload", errorCode: terminalExecInvalidPayloadCode, statusCode: http.StatusBadRequest},
+		{name: "session_not_found", errorCode: terminalExecSessionNotFoundCode, statusCode: http.StatusNotFound},
 		{name: "session_busy", errorCode: terminalExecSessionBusyCode, statusCode: http.StatusConflict},
+		{name: "session_limit_reached", errorCode: computerUseSessionLimitCode, statusCode: http.StatusTooManyRequests},
 		{name: "no_capacity", errorCode: terminalTaskNoCapacityCode, statusCode: http.StatusTooManyRequests},
 		{name: "no_worker", errorCode: terminalTaskNoWorkerCode, statusCode: http.StatusServiceUnavailable},
 		{name: "other_failed", errorCode: "execution_failed", statusCode: http.StatusBadGateway},
@@ -1,32 +1,41 @@
 package httpapi
 
 import (
+	"crypto/cipher"
 	"errors"
-	"sync"
+	"net/netip"
 	"time"
 
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
 	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
 )
 
 const (
-	dashboardSessionCookieName      = "onlyboxes_console_session"
-	dashboardSessionMaxAgeSec       = 12 * 60 * 60
-	dashboardUsernamePrefix         = "admin-"
-	dashboardUsernameRandomByteSize = 4
-	dashboardPasswordRandomByteSize = 24
-	dashboardPasswordHashAlgo       = "bcrypt"
-	dashboardPasswordBCryptCost     = 12
-	accountIDPrefix                 = "acc_"
-	accountIDRandomByteSize         = 16
-	maxAccountUsernameRunes         = 64
+	dashboardSessionCookieName       = "onlyboxes_console_session"
+	dashboardSessionMaxAgeSec        = 12 * 60 * 60
+	dashboardUsernamePrefix          = "admin-"
+	dashboardUsernameRandomByteSize  = 4
+	dashboardPasswordRandomByteSize  = 24
+	dashboardPasswordHashAlgo        = "bcrypt"
+	dashboardPasswordBCryptCost      = 12
+	accountIDPrefix                  = "acc_"
+	accountIDRandomByteSize          = 16
+	maxAccountUsernameRunes          = 64
+	dashboardSessionIDPrefix         = "ses_"
+	dashboardSessionIDRandomByteSize = 16
+	dashboardSessionTokenByteSize    = 32
+	dashboardSessionInsertAttempts   = 8
+	maxSessionUserAgentBytes         = 512
 
 	requestAccountIDGinKey       = "request_account_id"
 	requestAccountUsernameGinKey = "request_account_username"
 	requestAccountIsAdminGinKey  = "request_account_is_admin"
+	requestSessionIDGinKey       = "request_session_id"
 )
 
 var (
 	dashboardSessionTTL               = 12 * time.Hour
+	dashboardSessionTouchInterval     = time.Minute
 	errAccountUsernameRequired        = errors.New("username is required")
 	errAccountUsernameTooLong         = errors.New("username length must be <= 64")
 	errAccountPasswordRequired        = errors.New("password is required")
@@ -39,6 +48,9 @@ var (
 	errAccountNotFound                = errors.New("account not found")
 	errAccountDeleteSelfForbidden     = errors.New("cannot delete current account")
 	errAccountDeleteAdminForbidden    = errors.New("cannot delete admin account")
+	errAccountOwnsOrganizations       = errors.New("account owns organizations; transfer or delete them first")
+	errSessionNotFound                = errors.New("session not found")
+	errSessionCreateConflict          = errors.New("failed to allocate unique session id")
 	ErrConsoleQueriesRequired         = errors.New("console auth requires non-nil queries")
 	accountIDGenerator                = generateAccountID
 )
@@ -63,10 +75,16 @@ type SessionAccount struct {
 }
 
 type accountSessionState struct {
+	SessionID string
 	Account   SessionAccount
 	ExpiresAt time.Time
 }
 
+type sessionClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
 type accountContextKey struct{}
 
 type consoleAccountContext struct {
@@ -78,10 +96,14 @@ type consoleAccountContext struct {
 type ConsoleAuth struct {
 	queries             *sqlc.Queries
 	registrationEnabled bool
-
-	sessionMu sync.Mutex
-	sessions  map[string]accountSessionState
-	nowFn     func() time.Time
+	loginThrottle       loginThrottlePolicy
+	passwordPolicy      PasswordPolicy
+	oidc                *OIDCOptions
+	allowedOrigins      map[string]struct{}
+	trustedProxies      []netip.Prefix
+	auditLog            *persistence.DB
+	totpCipher          cipher.AEAD
+	nowFn               func() time.Time
 }
 
 type loginRequest struct {
@@ -100,11 +122,13 @@ type changePasswordRequest struct {
 }
 
 type accountSessionResponse struct {
-	Authenticated       bool           `json:"authenticated,omitempty"`
-	Account             SessionAccount `json:"account"`
-	RegistrationEnabled bool           `json:"registration_enabled"`
-	ConsoleVersion      string         `json:"console_version"`
-	ConsoleRepoURL      string         `json:"console_repo_url"`
+	Authenticated          bool           `json:"authenticated,omitempty"`
+	Account                SessionAccount `json:"account"`
+	RegistrationEnabled    bool           `json:"registration_enabled"`
+	TOTPEnrollmentRequired bool           `json:"totp_enrollment_required,omitempty"`
+	ConsoleVersion         string         `json:"console_version"`
+	ConsoleRepoURL         string         `json:"console_repo_url"`
+	CSRFToken              string         `json:"csrf_token,omitempty"`
 }
 
 type registerAccountResponse struct {
@@ -121,6 +145,21 @@ type accountListItem struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type consoleSessionItem struct {
+	SessionID  string    `json:"session_id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type consoleSessionListResponse struct {
+	Items []consoleSessionItem `json:"items"`
+	Total int                  `json:"total"`
+}
+
 type accountListResponse struct {
 	Items    []accountListItem `json:"items"`
 	Total    int               `json:"total"`
@@ -132,6 +171,8 @@ type createAccountInput struct {
 	Username string
 	Password string
 	IsAdmin  bool
+	External bool
+	Policy   PasswordPolicy
 	Now      time.Time
 }
 
@@ -158,7 +199,8 @@ func NewConsoleAuth(queries *sqlc.Queries, registrationEnabled bool) (*ConsoleAu
 	return &ConsoleAuth{
 		queries:             queries,
 		registrationEnabled: registrationEnabled,
-		sessions:            make(map[string]accountSessionState),
+		loginThrottle:       defaultLoginThrottlePolicy,
+		passwordPolicy:      defaultPasswordPolicy,
 		nowFn:               time.Now,
 	}, nil
 }
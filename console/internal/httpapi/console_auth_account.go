@@ -44,6 +44,7 @@ func InitializeAdminAccount(
 	queries *sqlc.Queries,
 	envUsername string,
 	envPassword string,
+	policy PasswordPolicy,
 ) (AdminAccountInitResult, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -51,6 +52,10 @@ func InitializeAdminAccount(
 	if queries == nil {
 		return AdminAccountInitResult{}, errors.New("account queries is required")
 	}
+	policy, err := normalizePasswordPolicy(policy)
+	if err != nil {
+		return AdminAccountInitResult{}, err
+	}
 
 	adminCount, err := queries.CountAdminAccounts(ctx)
 	if err != nil {
@@ -73,11 +78,19 @@ func InitializeAdminAccount(
 	if err != nil {
 		return AdminAccountInitResult{}, err
 	}
+	// Generated passwords are long random strings; only operator supplied ones
+	// need to be checked against the policy.
+	if envPassword != "" {
+		if err := policy.validate(envPassword); err != nil {
+			return AdminAccountInitResult{}, fmt.Errorf("invalid dashboard password: %w", err)
+		}
+	}
 
 	created, err := createAccountWithRetry(ctx, queries, createAccountInput{
 		Username: credentials.Username,
 		Password: credentials.Password,
 		IsAdmin:  true,
+		Policy:   policy,
 		Now:      time.Now(),
 	})
 	if err != nil {
@@ -121,10 +134,7 @@ func (a *ConsoleAuth) verifyPassword(account sqlc.Account, password string) bool
 	if strings.TrimSpace(password) == "" {
 		return false
 	}
-	if !strings.EqualFold(strings.TrimSpace(account.HashAlgo), dashboardPasswordHashAlgo) {
-		return false
-	}
-	return compareDashboardPassword(account.PasswordHash, password)
+	return comparePasswordHash(account.HashAlgo, account.PasswordHash, password)
 }
 
 func normalizeAccountUsername(value string) (string, string, error) {
@@ -154,13 +164,18 @@ func createAccountWithRetry(
 	if err != nil {
 		return createdAccount{}, err
 	}
-	if strings.TrimSpace(input.Password) == "" {
-		return createdAccount{}, errAccountPasswordRequired
-	}
-
-	passwordHash, err := hashDashboardPassword(input.Password)
-	if err != nil {
-		return createdAccount{}, fmt.Errorf("hash account password: %w", err)
+	// Accounts backed by an external identity have no local password and can
+	// only sign in through SSO.
+	passwordHash := ""
+	hashAlgo := oidcAccountHashAlgo
+	if !input.External {
+		if strings.TrimSpace(input.Password) == "" {
+			return createdAccount{}, errAccountPasswordRequired
+		}
+		passwordHash, hashAlgo, err = input.Policy.hash(input.Password)
+		if err != nil {
+			return createdAccount{}, fmt.Errorf("hash account password: %w", err)
+		}
 	}
 
 	now := input.Now
@@ -179,7 +194,7 @@ func createAccountWithRetry(
 			Username:        normalizedUsername,
 			UsernameKey:     usernameKey,
 			PasswordHash:    passwordHash,
-			HashAlgo:        dashboardPasswordHashAlgo,
+			HashAlgo:        hashAlgo,
 			IsAdmin:         boolToInt64(input.IsAdmin),
 			CreatedAtUnixMs: nowMS,
 			UpdatedAtUnixMs: nowMS,
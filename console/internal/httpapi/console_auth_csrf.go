@@ -0,0 +1,210 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	dashboardCSRFCookieName = "onlyboxes_console_csrf"
+	dashboardCSRFHeaderName = "X-CSRF-Token"
+	dashboardCSRFContext    = "onlyboxes-console-csrf"
+)
+
+var (
+	errCSRFTokenInvalid    = errors.New("invalid csrf token")
+	errCSRFOriginRejected  = errors.New("cross-origin request rejected")
+	errAllowedOriginFormat = errors.New("allowed origin must be scheme://host[:port]")
+	errTrustedProxyFormat  = errors.New("trusted proxy must be an IP address or CIDR prefix")
+)
+
+// csrfTokenForSession derives the synchronizer token from the session secret.
+// It is bound to one session, changes whenever the session rotates, and needs
+// no storage of its own.
+func csrfTokenForSession(sessionToken string) string {
+	mac := hmac.New(sha256.New, []byte(sessionToken))
+	_, _ = mac.Write([]byte(dashboardCSRFContext))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetAllowedOrigins lists extra origins, besides the console's own host, that
+// may send state-changing dashboard requests; for example the public URL of a
+// reverse proxy that rewrites the Host header.
+func (a *ConsoleAuth) SetAllowedOrigins(origins []string) error {
+	if a == nil {
+		return nil
+	}
+	allowed := make(map[string]struct{}, len(origins))
+	for _, origin := range origins {
+		normalized, ok := normalizeOrigin(origin)
+		if !ok {
+			return fmt.Errorf("%w: %q", errAllowedOriginFormat, origin)
+		}
+		allowed[normalized] = struct{}{}
+	}
+	a.allowedOrigins = allowed
+	return nil
+}
+
+// SetTrustedProxies lists the reverse proxies, as IP addresses or CIDR
+// prefixes, whose X-Forwarded-Host header names the public host and whose
+// X-Forwarded-For header names the client IP. The headers are ignored on
+// requests from any other peer. Call it before NewRouter.
+func (a *ConsoleAuth) SetTrustedProxies(proxies []string) error {
+	if a == nil {
+		return nil
+	}
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return fmt.Errorf("%w: %q", errTrustedProxyFormat, proxy)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	a.trustedProxies = prefixes
+	return nil
+}
+
+// trustedProxyList returns the trusted proxies in the form gin expects. It
+// is empty, so no forwarding header is trusted, without a ConsoleAuth.
+func (a *ConsoleAuth) trustedProxyList() []string {
+	if a == nil {
+		return nil
+	}
+	proxies := make([]string, 0, len(a.trustedProxies))
+	for _, prefix := range a.trustedProxies {
+		proxies = append(proxies, prefix.String())
+	}
+	return proxies
+}
+
+// RequireSameOrigin rejects cross-origin state-changing requests on routes
+// that run before a session exists, such as login.
+func (a *ConsoleAuth) RequireSameOrigin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isSafeHTTPMethod(c.Request.Method) && !a.sameOriginRequest(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": errCSRFOriginRejected.Error()})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// verifyCSRF checks a cookie-authenticated state-changing request: the
+// browser-supplied Origin (or Referer) must be trusted and the X-CSRF-Token
+// header must carry the token derived from the session cookie.
+func (a *ConsoleAuth) verifyCSRF(c *gin.Context, sessionToken string) error {
+	if isSafeHTTPMethod(c.Request.Method) {
+		return nil
+	}
+	if !a.sameOriginRequest(c) {
+		return errCSRFOriginRejected
+	}
+	provided := strings.TrimSpace(c.GetHeader(dashboardCSRFHeaderName))
+	if provided == "" || !hmac.Equal([]byte(provided), []byte(csrfTokenForSession(sessionToken))) {
+		return errCSRFTokenInvalid
+	}
+	return nil
+}
+
+// sameOriginRequest compares Origin, or Referer when Origin is absent, with
+// the request host and the configured allowed origins. Requests carrying
+// neither header come from non-browser clients and are left to the CSRF token.
+func (a *ConsoleAuth) sameOriginRequest(c *gin.Context) bool {
+	source := strings.TrimSpace(c.GetHeader("Origin"))
+	if source == "" {
+		referer := strings.TrimSpace(c.GetHeader("Referer"))
+		if referer == "" {
+			return true
+		}
+		source = referer
+	}
+	origin, ok := normalizeOrigin(source)
+	if !ok {
+		return false
+	}
+	if a != nil {
+		if _, allowed := a.allowedOrigins[origin]; allowed {
+			return true
+		}
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Host, a.requestHost(c.Request))
+}
+
+// requestHost is the host the client addressed. X-Forwarded-Host is honored
+// only from a trusted proxy; anyone else could use it to make a foreign
+// Origin look same-origin.
+func (a *ConsoleAuth) requestHost(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if forwarded := strings.TrimSpace(r.Header.Get("X-Forwarded-Host")); forwarded != "" && a.fromTrustedProxy(r) {
+		first, _, _ := strings.Cut(forwarded, ",")
+		return strings.TrimSpace(first)
+	}
+	return strings.TrimSpace(r.Host)
+}
+
+func (a *ConsoleAuth) fromTrustedProxy(r *http.Request) bool {
+	if a == nil || len(a.trustedProxies) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		host = strings.TrimSpace(r.RemoteAddr)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range a.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeOrigin(value string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || parsed.Host == "" {
+		return "", false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", false
+	}
+	return scheme + "://" + strings.ToLower(parsed.Host), true
+}
+
+func isSafeHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
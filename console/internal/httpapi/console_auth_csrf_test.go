@@ -0,0 +1,281 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+const (
+	testSameOrigin    = "http://example.com"
+	testForeignOrigin = "https://attacker.example"
+)
+
+var routeParamPattern = regexp.MustCompile(`[:*][^/]+`)
+
+func newCSRFTestRouter(t *testing.T, allowedOrigins []string, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	if err := consoleAuth.SetAllowedOrigins(allowedOrigins); err != nil {
+		t.Fatalf("set allowed origins: %v", err)
+	}
+	if err := consoleAuth.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatalf("set trusted proxies: %v", err)
+	}
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	return mustNewRouter(t, handler, consoleAuth, mcpAuth)
+}
+
+func doCSRFRequest(router http.Handler, method string, path string, cookie *http.Cookie, csrfToken string, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	if csrfToken != "" {
+		req.Header.Set(dashboardCSRFHeaderName, csrfToken)
+	}
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func isCSRFRejection(rec *httptest.ResponseRecorder) bool {
+	if rec.Code != http.StatusForbidden {
+		return false
+	}
+	body := rec.Body.String()
+	return strings.Contains(body, errCSRFTokenInvalid.Error()) || strings.Contains(body, errCSRFOriginRejected.Error())
+}
+
+func TestCSRFProtectsEveryMutatingRoute(t *testing.T) {
+	router := newCSRFTestRouter(t, nil, nil)
+	cookie := loginSessionCookie(t, router)
+	csrfToken := csrfTokenForSession(cookie.Value)
+
+	preSession := map[string]bool{
+		"/api/v1/console/login":     true,
+		"/api/v1/console/login/2fa": true,
+		"/api/v1/console/logout":    true,
+	}
+	bearerOnly := map[string]bool{
+		"/mcp":                          true,
+		"/api/v1/commands/echo":         true,
+		"/api/v1/commands/terminal":     true,
+		"/api/v1/commands/computer-use": true,
+		"/api/v1/tasks":                 true,
+		"/api/v1/tasks/:task_id/cancel": true,
+	}
+
+	checked := 0
+	for _, route := range router.Routes() {
+		if isSafeHTTPMethod(route.Method) || route.Method == http.MethodConnect {
+			continue
+		}
+		path := routeParamPattern.ReplaceAllString(route.Path, "missing")
+		name := route.Method + " " + route.Path
+		checked++
+
+		switch {
+		case preSession[route.Path]:
+			rec := doCSRFRequest(router, route.Method, path, nil, "", testForeignOrigin)
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), errCSRFOriginRejected.Error()) {
+				t.Fatalf("%s: expected cross-origin 403, got %d body=%s", name, rec.Code, rec.Body.String())
+			}
+		case bearerOnly[route.Path]:
+			// Cookies never authenticate bearer routes, so there is nothing to forge.
+			rec := doCSRFRequest(router, route.Method, path, cookie, "", testForeignOrigin)
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("%s: expected cookie-only request 401, got %d body=%s", name, rec.Code, rec.Body.String())
+			}
+		default:
+			rec := doCSRFRequest(router, route.Method, path, cookie, "", "")
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), errCSRFTokenInvalid.Error()) {
+				t.Fatalf("%s: expected missing token 403, got %d body=%s", name, rec.Code, rec.Body.String())
+			}
+			rec = doCSRFRequest(router, route.Method, path, cookie, csrfTokenForSession("other-session"), testSameOrigin)
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), errCSRFTokenInvalid.Error()) {
+				t.Fatalf("%s: expected wrong token 403, got %d body=%s", name, rec.Code, rec.Body.String())
+			}
+			rec = doCSRFRequest(router, route.Method, path, cookie, csrfToken, testForeignOrigin)
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), errCSRFOriginRejected.Error()) {
+				t.Fatalf("%s: expected foreign origin 403, got %d body=%s", name, rec.Code, rec.Body.String())
+			}
+			rec = doCSRFRequest(router, route.Method, path, cookie, csrfToken, testSameOrigin)
+			if isCSRFRejection(rec) || rec.Code == http.StatusUnauthorized {
+				t.Fatalf("%s: expected valid token to pass csrf checks, got %d body=%s", name, rec.Code, rec.Body.String())
+			}
+		}
+	}
+	if checked == 0 {
+		t.Fatal("expected mutating routes to be registered")
+	}
+}
+
+func TestCSRFExemptsManagementBearerToken(t *testing.T) {
+	router := newCSRFTestRouter(t, nil, nil)
+	cookie := loginSessionCookie(t, router)
+	created := createManagementTokenForTest(t, router, cookie, `{"name":"ci","permissions":["tokens:write"]}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/console/tokens", strings.NewReader(`{"name":"from-ci"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(trustedTokenHeader, "Bearer "+created.Token)
+	req.Header.Set("Origin", testForeignOrigin)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected bearer token create 201 without csrf token, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCSRFOriginValidation(t *testing.T) {
+	// httptest requests come from 192.0.2.1.
+	router := newCSRFTestRouter(t, []string{"https://Console.Example.org"}, []string{"192.0.2.0/24"})
+	cookie := loginSessionCookie(t, router)
+	csrfToken := csrfTokenForSession(cookie.Value)
+	path := "/api/v1/console/sessions/missing"
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		allowed    bool
+	}{
+		{name: "no origin headers", allowed: true},
+		{name: "same origin", headers: map[string]string{"Origin": testSameOrigin}, allowed: true},
+		{name: "allowed origin", headers: map[string]string{"Origin": "https://console.example.org"}, allowed: true},
+		{name: "forwarded host", headers: map[string]string{"Origin": "https://public.example.net", "X-Forwarded-Host": "public.example.net"}, allowed: true},
+		{name: "forwarded host from untrusted peer", remoteAddr: "203.0.113.7:41000", headers: map[string]string{"Origin": testForeignOrigin, "X-Forwarded-Host": strings.TrimPrefix(testForeignOrigin, "https://")}},
+		{name: "same origin referer", headers: map[string]string{"Referer": testSameOrigin + "/settings"}, allowed: true},
+		{name: "foreign referer", headers: map[string]string{"Referer": testForeignOrigin + "/page"}},
+		{name: "null origin", headers: map[string]string{"Origin": "null"}},
+		{name: "foreign origin with same referer", headers: map[string]string{"Origin": testForeignOrigin, "Referer": testSameOrigin + "/"}},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodDelete, path, nil)
+		if tc.remoteAddr != "" {
+			req.RemoteAddr = tc.remoteAddr
+		}
+		addSessionCookie(req, cookie)
+		for key, value := range tc.headers {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if tc.allowed && rec.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d body=%s", tc.name, rec.Code, rec.Body.String())
+		}
+		if !tc.allowed && (rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), errCSRFOriginRejected.Error())) {
+			t.Fatalf("%s: expected cross-origin 403, got %d body=%s", tc.name, rec.Code, rec.Body.String())
+		}
+	}
+
+	if rec := doCSRFRequest(router, http.MethodDelete, path, cookie, csrfToken, testForeignOrigin); !isCSRFRejection(rec) {
+		t.Fatalf("expected foreign origin rejection, got %d", rec.Code)
+	}
+
+	auth := &ConsoleAuth{}
+	for _, origin := range []string{"console.example.org", "ftp://console.example.org", "https://"} {
+		if err := auth.SetAllowedOrigins([]string{origin}); !errors.Is(err, errAllowedOriginFormat) {
+			t.Fatalf("expected invalid origin %q to be rejected, got %v", origin, err)
+		}
+	}
+	for _, proxy := range []string{"proxy.internal", "10.0.0.0/33", ""} {
+		if err := auth.SetTrustedProxies([]string{proxy}); !errors.Is(err, errTrustedProxyFormat) {
+			t.Fatalf("expected invalid trusted proxy %q to be rejected, got %v", proxy, err)
+		}
+	}
+}
+
+func TestCSRFTokenIssuedWithSession(t *testing.T) {
+	router := newCSRFTestRouter(t, nil, nil)
+
+	rec := postLogin(router, testDashboardUsername, testDashboardPassword)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected login 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var sessionCookie, csrfCookie *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		switch cookie.Name {
+		case dashboardSessionCookieName:
+			sessionCookie = cookie
+		case dashboardCSRFCookieName:
+			csrfCookie = cookie
+		}
+	}
+	if sessionCookie == nil || csrfCookie == nil {
+		t.Fatalf("expected session and csrf cookies, got %v", rec.Result().Cookies())
+	}
+	if !sessionCookie.HttpOnly || sessionCookie.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("expected HttpOnly SameSite=Strict session cookie, got %#v", sessionCookie)
+	}
+	if csrfCookie.HttpOnly || csrfCookie.SameSite != http.SameSiteStrictMode {
+		t.Fatalf("expected readable SameSite=Strict csrf cookie, got %#v", csrfCookie)
+	}
+	if csrfCookie.Value != csrfTokenForSession(sessionCookie.Value) || csrfCookie.Value == sessionCookie.Value {
+		t.Fatalf("expected csrf cookie derived from session")
+	}
+
+	loginResp := accountSessionResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &loginResp); err != nil {
+		t.Fatalf("decode login response: %v", err)
+	}
+	if loginResp.CSRFToken != csrfCookie.Value {
+		t.Fatalf("expected login response csrf token to match cookie")
+	}
+
+	sessionRec := doJSON(t, router, http.MethodGet, "/api/v1/console/session", "", sessionCookie)
+	sessionResp := accountSessionResponse{}
+	if err := json.Unmarshal(sessionRec.Body.Bytes(), &sessionResp); err != nil {
+		t.Fatalf("decode session response: %v", err)
+	}
+	if sessionResp.CSRFToken != csrfCookie.Value {
+		t.Fatalf("expected session response csrf token to match cookie")
+	}
+
+	// Another session's token is not accepted.
+	otherCookie := loginSessionCookie(t, router)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/console/sessions/missing", nil)
+	req.AddCookie(sessionCookie)
+	req.Header.Set(dashboardCSRFHeaderName, csrfTokenForSession(otherCookie.Value))
+	otherRec := httptest.NewRecorder()
+	router.ServeHTTP(otherRec, req)
+	if otherRec.Code != http.StatusForbidden {
+		t.Fatalf("expected token from other session 403, got %d", otherRec.Code)
+	}
+
+	logoutRec := doJSON(t, router, http.MethodPost, "/api/v1/console/logout", "", sessionCookie)
+	cleared := map[string]bool{}
+	for _, cookie := range logoutRec.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			cleared[cookie.Name] = true
+		}
+	}
+	if !cleared[dashboardSessionCookieName] || !cleared[dashboardCSRFCookieName] {
+		t.Fatalf("expected logout to clear both cookies, got %v", logoutRec.Result().Cookies())
+	}
+}
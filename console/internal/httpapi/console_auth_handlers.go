@@ -3,11 +3,13 @@ package httpapi
 import (
 	"database/sql"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
 	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
 )
 
@@ -18,30 +20,104 @@ func (a *ConsoleAuth) Login(c *gin.Context) {
 		return
 	}
 
-	account, ok := a.lookupAccount(c.Request.Context(), req.Username)
+	ctx := c.Request.Context()
+	clientIP := strings.TrimSpace(c.ClientIP())
+	now := a.now()
+	throttleSubjects := a.loginThrottle.subjects(clientIP, req.Username)
+	retryAfter, err := a.loginRetryAfter(ctx, throttleSubjects, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login throttle"})
+		return
+	}
+	if retryAfter > 0 {
+		a.recordAudit(c, persistence.AuditEvent{
+			Action:  auditActionLogin,
+			Outcome: persistence.AuditOutcomeDenied,
+			Details: map[string]any{"method": "password", "username": strings.TrimSpace(req.Username), "reason": "throttled"},
+		})
+		writeLoginThrottled(c, retryAfter)
+		return
+	}
+
+	account, ok := a.lookupAccount(ctx, req.Username)
 	if !ok || !a.verifyPassword(account, req.Password) {
+		if err := a.recordLoginFailure(ctx, throttleSubjects, clientIP, now); err != nil {
+			slog.Warn("failed to record console login failure", "error", err)
+		}
+		a.recordAudit(c, persistence.AuditEvent{
+			Action:  auditActionLogin,
+			Outcome: persistence.AuditOutcomeFailure,
+			Details: map[string]any{"method": "password", "username": strings.TrimSpace(req.Username)},
+		})
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
 		return
 	}
+	rehash := a.pendingPasswordRehash(account, req.Password)
 
+	totpEnabled, err := a.accountTOTPEnabled(ctx, account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	if totpEnabled {
+		challengeToken, challengeExpiresAt, err := a.createLoginChallenge(ctx, account.AccountID, rehash, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create login challenge"})
+			return
+		}
+		c.JSON(http.StatusOK, loginChallengeResponse{
+			Authenticated: false,
+			MFARequired:   true,
+			MFAToken:      challengeToken,
+			ExpiresAt:     challengeExpiresAt,
+		})
+		return
+	}
+
+	if _, err := a.clearLoginFailures(ctx, loginThrottleScopeUsername, strings.TrimSpace(account.UsernameKey)); err != nil {
+		slog.Warn("failed to clear console login failures", "error", err)
+	}
+	a.completeLogin(c, account, "password", rehash)
+}
+
+// completeLogin issues the dashboard session once every required factor is
+// verified, and only then stores a pending password rehash.
+func (a *ConsoleAuth) completeLogin(c *gin.Context, account sqlc.Account, method string, rehash passwordRehash) {
 	sessionAccount := SessionAccount{
 		AccountID: strings.TrimSpace(account.AccountID),
 		Username:  strings.TrimSpace(account.Username),
 		IsAdmin:   account.IsAdmin == 1,
 	}
-	sessionID, expiresAt, err := a.createSession(sessionAccount)
+	enrollmentRequired, err := a.totpEnrollmentRequired(c.Request.Context(), sessionAccount.AccountID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
 		return
 	}
-
-	a.setSessionCookie(c, sessionID, expiresAt)
+	sessionToken, expiresAt, err := a.createSession(c.Request.Context(), sessionAccount, sessionClientInfoFromRequest(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	a.applyPasswordRehash(c.Request.Context(), account, rehash)
+
+	a.setSessionCookie(c, sessionToken, expiresAt)
+	a.recordAudit(c, persistence.AuditEvent{
+		ActorType:  persistence.AuditActorAccount,
+		ActorID:    sessionAccount.AccountID,
+		AccountID:  sessionAccount.AccountID,
+		Action:     auditActionLogin,
+		TargetType: "account",
+		TargetID:   sessionAccount.AccountID,
+		Details:    map[string]any{"method": method},
+	})
 	c.JSON(http.StatusOK, accountSessionResponse{
-		Authenticated:       true,
-		Account:             sessionAccount,
-		RegistrationEnabled: a.registrationEnabled,
-		ConsoleVersion:      consoleVersion(),
-		ConsoleRepoURL:      consoleRepoURL(),
+		Authenticated:          true,
+		Account:                sessionAccount,
+		RegistrationEnabled:    a.registrationEnabled,
+		TOTPEnrollmentRequired: enrollmentRequired,
+		ConsoleVersion:         consoleVersion(),
+		ConsoleRepoURL:         consoleRepoURL(),
+		CSRFToken:              csrfTokenForSession(sessionToken),
 	})
 }
 
@@ -50,12 +126,20 @@ func (a *ConsoleAuth) Session(c *gin.Context) {
 	if !ok {
 		return
 	}
+	enrollmentRequired, err := a.totpEnrollmentRequired(c.Request.Context(), account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load session"})
+		return
+	}
+	sessionToken, _ := c.Cookie(dashboardSessionCookieName)
 	c.JSON(http.StatusOK, accountSessionResponse{
-		Authenticated:       true,
-		Account:             account,
-		RegistrationEnabled: a.registrationEnabled,
-		ConsoleVersion:      consoleVersion(),
-		ConsoleRepoURL:      consoleRepoURL(),
+		Authenticated:          true,
+		Account:                account,
+		RegistrationEnabled:    a.registrationEnabled,
+		TOTPEnrollmentRequired: enrollmentRequired,
+		ConsoleVersion:         consoleVersion(),
+		ConsoleRepoURL:         consoleRepoURL(),
+		CSRFToken:              csrfTokenForSession(sessionToken),
 	})
 }
 
@@ -79,6 +163,10 @@ func (a *ConsoleAuth) Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
+	if err := a.passwordPolicy.validate(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	now := time.Now()
 	if a.nowFn != nil {
@@ -89,6 +177,7 @@ func (a *ConsoleAuth) Register(c *gin.Context) {
 		Username: req.Username,
 		Password: req.Password,
 		IsAdmin:  false,
+		Policy:   a.passwordPolicy,
 		Now:      now,
 	})
 	if err != nil {
@@ -105,6 +194,12 @@ func (a *ConsoleAuth) Register(c *gin.Context) {
 		return
 	}
 
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionAccountCreate,
+		TargetType: "account",
+		TargetID:   created.AccountID,
+		Details:    map[string]any{"username": created.Username},
+	})
 	c.JSON(http.StatusCreated, registerAccountResponse{
 		Account: SessionAccount{
 			AccountID: created.AccountID,
@@ -142,7 +237,7 @@ func (a *ConsoleAuth) ChangePassword(c *gin.Context) {
 	accountRecord, err := a.queries.GetAccountByID(c.Request.Context(), strings.TrimSpace(sessionAccount.AccountID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			a.deleteSessionsByAccountID(sessionAccount.AccountID)
+			_ = a.deleteSessionsByAccountID(c.Request.Context(), sessionAccount.AccountID)
 			a.clearSessionCookie(c)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 			return
@@ -150,12 +245,30 @@ func (a *ConsoleAuth) ChangePassword(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
 		return
 	}
+	if isExternalAccount(accountRecord) {
+		c.JSON(http.StatusConflict, gin.H{"error": errAccountPasswordExternal.Error()})
+		return
+	}
 	if !a.verifyPassword(accountRecord, currentPassword) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": errAccountCurrentPasswordInvalid.Error()})
 		return
 	}
 
-	newPasswordHash, err := hashDashboardPassword(newPassword)
+	if err := a.passwordPolicy.validate(newPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	reused, err := a.passwordPolicy.passwordReused(c.Request.Context(), a.queries, accountRecord, newPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
+		return
+	}
+	if reused {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errAccountPasswordReused.Error()})
+		return
+	}
+
+	newPasswordHash, newHashAlgo, err := a.passwordPolicy.hash(newPassword)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
 		return
@@ -168,7 +281,7 @@ func (a *ConsoleAuth) ChangePassword(c *gin.Context) {
 
 	updatedRows, err := a.queries.UpdateAccountPasswordByID(c.Request.Context(), sqlc.UpdateAccountPasswordByIDParams{
 		PasswordHash:    newPasswordHash,
-		HashAlgo:        dashboardPasswordHashAlgo,
+		HashAlgo:        newHashAlgo,
 		UpdatedAtUnixMs: now.UnixMilli(),
 		AccountID:       strings.TrimSpace(sessionAccount.AccountID),
 	})
@@ -177,26 +290,37 @@ func (a *ConsoleAuth) ChangePassword(c *gin.Context) {
 		return
 	}
 	if updatedRows == 0 {
-		a.deleteSessionsByAccountID(sessionAccount.AccountID)
+		_ = a.deleteSessionsByAccountID(c.Request.Context(), sessionAccount.AccountID)
 		a.clearSessionCookie(c)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 		return
 	}
+	if err := a.passwordPolicy.recordPasswordHistory(c.Request.Context(), a.queries, accountRecord, now); err != nil {
+		slog.Warn("failed to record console password history", "account_id", accountRecord.AccountID, "error", err)
+	}
 
 	renewedAccount := SessionAccount{
 		AccountID: strings.TrimSpace(accountRecord.AccountID),
 		Username:  strings.TrimSpace(accountRecord.Username),
 		IsAdmin:   accountRecord.IsAdmin == 1,
 	}
-	a.deleteSessionsByAccountID(renewedAccount.AccountID)
+	if err := a.deleteSessionsByAccountID(c.Request.Context(), renewedAccount.AccountID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh session"})
+		return
+	}
 
-	sessionID, expiresAt, err := a.createSession(renewedAccount)
+	sessionToken, expiresAt, err := a.createSession(c.Request.Context(), renewedAccount, sessionClientInfoFromRequest(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh session"})
 		return
 	}
 
-	a.setSessionCookie(c, sessionID, expiresAt)
+	a.setSessionCookie(c, sessionToken, expiresAt)
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionAccountPassword,
+		TargetType: "account",
+		TargetID:   renewedAccount.AccountID,
+	})
 	c.Status(http.StatusNoContent)
 }
 
@@ -266,6 +390,16 @@ func (a *ConsoleAuth) DeleteAccount(c *gin.Context) {
 		return
 	}
 
+	ownedOrganizations, err := a.queries.CountOrganizationsOwnedByAccount(c.Request.Context(), targetAccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete account"})
+		return
+	}
+	if ownedOrganizations > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errAccountOwnsOrganizations.Error()})
+		return
+	}
+
 	deletedRows, err := a.queries.DeleteNonAdminAccountByID(c.Request.Context(), targetAccountID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete account"})
@@ -289,13 +423,144 @@ func (a *ConsoleAuth) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	a.deleteSessionsByAccountID(targetAccountID)
+	// The account row is already gone, so the deletion is audited even when
+	// its sessions could not be revoked.
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionAccountDelete,
+		TargetType: "account",
+		TargetID:   targetAccountID,
+	})
+	if err := a.deleteSessionsByAccountID(c.Request.Context(), targetAccountID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete sessions"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (a *ConsoleAuth) ListSessions(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+
+	records, err := a.queries.ListConsoleSessionsByAccount(c.Request.Context(), sqlc.ListConsoleSessionsByAccountParams{
+		AccountID:       strings.TrimSpace(account.AccountID),
+		ExpiresAtUnixMs: a.now().UnixMilli(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+
+	currentSessionID := requestSessionIDFromGin(c)
+	items := make([]consoleSessionItem, 0, len(records))
+	for _, record := range records {
+		sessionID := strings.TrimSpace(record.SessionID)
+		items = append(items, consoleSessionItem{
+			SessionID:  sessionID,
+			UserAgent:  record.UserAgent,
+			IPAddress:  record.IpAddress,
+			Current:    sessionID != "" && sessionID == currentSessionID,
+			CreatedAt:  time.UnixMilli(record.CreatedAtUnixMs),
+			LastSeenAt: time.UnixMilli(record.LastSeenAtUnixMs),
+			ExpiresAt:  time.UnixMilli(record.ExpiresAtUnixMs),
+		})
+	}
+
+	c.JSON(http.StatusOK, consoleSessionListResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+func (a *ConsoleAuth) DeleteSession(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+
+	sessionID := strings.TrimSpace(c.Param("session_id"))
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "session_id is required"})
+		return
+	}
+
+	deletedRows, err := a.queries.DeleteConsoleSessionByIDAndAccount(c.Request.Context(), sqlc.DeleteConsoleSessionByIDAndAccountParams{
+		SessionID: sessionID,
+		AccountID: strings.TrimSpace(account.AccountID),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete session"})
+		return
+	}
+	if deletedRows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errSessionNotFound.Error()})
+		return
+	}
+
+	if sessionID == requestSessionIDFromGin(c) {
+		a.clearSessionCookie(c)
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionSessionRevoke,
+		TargetType: "session",
+		TargetID:   sessionID,
+	})
+	c.Status(http.StatusNoContent)
+}
+
+func (a *ConsoleAuth) DeleteAccountSessions(c *gin.Context) {
+	currentAccount, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+
+	targetAccountID := strings.TrimSpace(c.Param("account_id"))
+	if targetAccountID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id is required"})
+		return
+	}
+
+	if _, err := a.queries.GetAccountByID(c.Request.Context(), targetAccountID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errAccountNotFound.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete sessions"})
+		return
+	}
+
+	if err := a.deleteSessionsByAccountID(c.Request.Context(), targetAccountID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete sessions"})
+		return
+	}
+
+	if targetAccountID == strings.TrimSpace(currentAccount.AccountID) {
+		a.clearSessionCookie(c)
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionAccountSessionsRevoke,
+		TargetType: "account",
+		TargetID:   targetAccountID,
+	})
 	c.Status(http.StatusNoContent)
 }
 
 func (a *ConsoleAuth) Logout(c *gin.Context) {
-	if sessionID, err := c.Cookie(dashboardSessionCookieName); err == nil {
-		a.deleteSession(sessionID)
+	if sessionToken, err := c.Cookie(dashboardSessionCookieName); err == nil {
+		state, active := a.sessionState(c.Request.Context(), sessionToken, sessionClientInfoFromRequest(c))
+		if err := a.deleteSession(c.Request.Context(), sessionToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete session"})
+			return
+		}
+		if active {
+			setRequestSessionAccount(c, state.Account)
+			a.recordAudit(c, persistence.AuditEvent{
+				Action:     auditActionLogout,
+				TargetType: "session",
+				TargetID:   state.SessionID,
+			})
+		}
 	}
 
 	a.clearSessionCookie(c)
@@ -304,14 +569,14 @@ func (a *ConsoleAuth) Logout(c *gin.Context) {
 
 func (a *ConsoleAuth) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		sessionID, err := c.Cookie(dashboardSessionCookieName)
-		if err != nil || strings.TrimSpace(sessionID) == "" {
+		sessionToken, err := c.Cookie(dashboardSessionCookieName)
+		if err != nil || strings.TrimSpace(sessionToken) == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 			c.Abort()
 			return
 		}
 
-		sessionState, ok := a.sessionState(sessionID)
+		sessionState, ok := a.sessionState(c.Request.Context(), sessionToken, sessionClientInfoFromRequest(c))
 		if !ok {
 			a.clearSessionCookie(c)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
@@ -319,7 +584,28 @@ func (a *ConsoleAuth) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		if err := a.verifyCSRF(c, sessionToken); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		setRequestSessionID(c, sessionState.SessionID)
 		setRequestSessionAccount(c, sessionState.Account)
+
+		if _, exempt := totpEnrollmentExemptRoutes[c.FullPath()]; !exempt {
+			enrollmentRequired, err := a.totpEnrollmentRequired(c.Request.Context(), sessionState.Account.AccountID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load session"})
+				c.Abort()
+				return
+			}
+			if enrollmentRequired {
+				c.JSON(http.StatusForbidden, gin.H{"error": errTOTPEnrollmentRequired.Error()})
+				c.Abort()
+				return
+			}
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,458 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/oidc"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+const (
+	oidcAccountHashAlgo      = "oidc"
+	oidcLoginStateTTL        = 10 * time.Minute
+	oidcStateByteSize        = 32
+	oidcNonceByteSize        = 16
+	oidcDefaultRedirectPath  = "/"
+	oidcDefaultUsernameClaim = "preferred_username"
+	oidcDefaultGroupsClaim   = "groups"
+	oidcLoginPath            = "/api/v1/console/oidc/login"
+	oidcCallbackPath         = "/api/v1/console/oidc/callback"
+	oidcStateCookieName      = "onlyboxes_oidc_state"
+	maxOIDCRedirectPathBytes = 512
+	oidcIdentityLinkAttempts = 2
+)
+
+var (
+	errOIDCNotConfigured        = errors.New("oidc login is not configured")
+	errOIDCProviderRequired     = errors.New("oidc provider is required")
+	errOIDCProviderUnavailable  = errors.New("identity provider unavailable")
+	errOIDCStateInvalid         = errors.New("invalid or expired login state")
+	errOIDCCodeRequired         = errors.New("authorization code is required")
+	errOIDCIdentityRejected     = errors.New("identity token rejected")
+	errOIDCUsernameClaimMissing = errors.New("identity token has no usable username claim")
+	errOIDCAccountNotLinked     = errors.New("no console account is linked to this identity")
+	errOIDCUsernameConflict     = errors.New("username is already used by a local account")
+	errAccountPasswordExternal  = errors.New("account password is managed by the identity provider")
+)
+
+// OIDCOptions configures single sign-on through an OpenID Connect provider.
+// UsernameClaim and GroupsClaim default to preferred_username and groups.
+// When AdminGroup is set, the admin flag of SSO accounts follows membership of
+// that group on every login.
+type OIDCOptions struct {
+	Provider      *oidc.Provider
+	UsernameClaim string
+	GroupsClaim   string
+	AdminGroup    string
+	AutoCreate    bool
+}
+
+type oidcConfigResponse struct {
+	Enabled  bool   `json:"enabled"`
+	LoginURL string `json:"login_url,omitempty"`
+}
+
+func (a *ConsoleAuth) SetOIDC(options OIDCOptions) error {
+	if options.Provider == nil {
+		return errOIDCProviderRequired
+	}
+	options.UsernameClaim = strings.TrimSpace(options.UsernameClaim)
+	if options.UsernameClaim == "" {
+		options.UsernameClaim = oidcDefaultUsernameClaim
+	}
+	options.GroupsClaim = strings.TrimSpace(options.GroupsClaim)
+	if options.GroupsClaim == "" {
+		options.GroupsClaim = oidcDefaultGroupsClaim
+	}
+	options.AdminGroup = strings.TrimSpace(options.AdminGroup)
+	a.oidc = &options
+	return nil
+}
+
+func (a *ConsoleAuth) OIDCConfig(c *gin.Context) {
+	if a.oidc == nil {
+		c.JSON(http.StatusOK, oidcConfigResponse{Enabled: false})
+		return
+	}
+	c.JSON(http.StatusOK, oidcConfigResponse{Enabled: true, LoginURL: oidcLoginPath})
+}
+
+func (a *ConsoleAuth) OIDCLogin(c *gin.Context) {
+	if a.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errOIDCNotConfigured.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := a.now()
+	state, err := randomHex(oidcStateByteSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sso login"})
+		return
+	}
+	nonce, err := randomHex(oidcNonceByteSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sso login"})
+		return
+	}
+	codeVerifier, err := oidc.NewPKCEVerifier()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sso login"})
+		return
+	}
+
+	authURL, err := a.oidc.Provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		slog.Warn("failed to build oidc authorization url", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": errOIDCProviderUnavailable.Error()})
+		return
+	}
+
+	if _, err := a.queries.DeleteExpiredOIDCLoginStates(ctx, now.UnixMilli()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sso login"})
+		return
+	}
+	if err := a.queries.InsertOIDCLoginState(ctx, sqlc.InsertOIDCLoginStateParams{
+		StateHash:       sha256Hex(state),
+		Nonce:           nonce,
+		CodeVerifier:    codeVerifier,
+		RedirectPath:    sanitizeOIDCRedirectPath(c.Query("redirect")),
+		CreatedAtUnixMs: now.UnixMilli(),
+		ExpiresAtUnixMs: now.Add(oidcLoginStateTTL).UnixMilli(),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sso login"})
+		return
+	}
+
+	a.setOIDCStateCookie(c, sha256Hex(state))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func (a *ConsoleAuth) OIDCCallback(c *gin.Context) {
+	if a.oidc == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errOIDCNotConfigured.Error()})
+		return
+	}
+
+	// The state must come back to the browser that started the login, so a
+	// callback URL planted by someone else cannot sign the victim in.
+	boundStateHash, _ := c.Cookie(oidcStateCookieName)
+	a.clearOIDCStateCookie(c)
+	state := strings.TrimSpace(c.Query("state"))
+	if state == "" || subtle.ConstantTimeCompare([]byte(boundStateHash), []byte(sha256Hex(state))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errOIDCStateInvalid.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := a.now()
+	loginState, ok, err := a.consumeOIDCLoginState(ctx, state, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to complete sso login"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errOIDCStateInvalid.Error()})
+		return
+	}
+	if providerError := strings.TrimSpace(c.Query("error")); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider returned error: " + providerError})
+		return
+	}
+	code := strings.TrimSpace(c.Query("code"))
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errOIDCCodeRequired.Error()})
+		return
+	}
+
+	idToken, err := a.oidc.Provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		slog.Warn("oidc code exchange failed", "error", err)
+		if errors.Is(err, oidc.ErrInvalidIDToken) || errors.Is(err, oidc.ErrNonceMismatch) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": errOIDCIdentityRejected.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": errOIDCProviderUnavailable.Error()})
+		return
+	}
+
+	account, err := a.resolveOIDCAccount(ctx, idToken, now)
+	if err != nil {
+		switch {
+		case errors.Is(err, errOIDCUsernameClaimMissing), errors.Is(err, errOIDCAccountNotLinked):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errOIDCUsernameConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			slog.Error("failed to resolve oidc account", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to complete sso login"})
+		}
+		return
+	}
+
+	sessionAccount := SessionAccount{
+		AccountID: strings.TrimSpace(account.AccountID),
+		Username:  strings.TrimSpace(account.Username),
+		IsAdmin:   account.IsAdmin == 1,
+	}
+	sessionToken, expiresAt, err := a.createSession(ctx, sessionAccount, sessionClientInfoFromRequest(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	a.setSessionCookie(c, sessionToken, expiresAt)
+	a.recordAudit(c, persistence.AuditEvent{
+		ActorType:  persistence.AuditActorAccount,
+		ActorID:    sessionAccount.AccountID,
+		AccountID:  sessionAccount.AccountID,
+		Action:     auditActionLogin,
+		TargetType: "account",
+		TargetID:   sessionAccount.AccountID,
+		Details:    map[string]any{"method": "oidc", "issuer": a.oidc.Provider.Issuer()},
+	})
+	c.Redirect(http.StatusFound, loginState.RedirectPath)
+}
+
+// setOIDCStateCookie binds a pending login to the browser with the hash of its
+// state. SameSite=Lax lets the cookie ride the top-level redirect back from
+// the identity provider.
+func (a *ConsoleAuth) setOIDCStateCookie(c *gin.Context, stateHash string) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    stateHash,
+		Path:     oidcCallbackPath,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(oidcLoginStateTTL / time.Second),
+		Secure:   requestIsTLS(c.Request),
+	})
+}
+
+func (a *ConsoleAuth) clearOIDCStateCookie(c *gin.Context) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    "",
+		Path:     oidcCallbackPath,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+		Secure:   requestIsTLS(c.Request),
+	})
+}
+
+// consumeOIDCLoginState redeems a state value exactly once.
+func (a *ConsoleAuth) consumeOIDCLoginState(ctx context.Context, state string, now time.Time) (sqlc.OidcLoginState, bool, error) {
+	state = strings.TrimSpace(state)
+	if state == "" {
+		return sqlc.OidcLoginState{}, false, nil
+	}
+	stateHash := sha256Hex(state)
+	record, err := a.queries.GetOIDCLoginStateByHash(ctx, stateHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sqlc.OidcLoginState{}, false, nil
+		}
+		return sqlc.OidcLoginState{}, false, err
+	}
+	deleted, err := a.queries.DeleteOIDCLoginStateByHash(ctx, stateHash)
+	if err != nil {
+		return sqlc.OidcLoginState{}, false, err
+	}
+	if deleted == 0 || record.ExpiresAtUnixMs <= now.UnixMilli() {
+		return sqlc.OidcLoginState{}, false, nil
+	}
+	return record, true, nil
+}
+
+// resolveOIDCAccount maps a verified identity to a console account. Identities
+// are keyed by issuer and subject; existing local accounts are never linked by
+// username, so an IdP user cannot take over a password account.
+func (a *ConsoleAuth) resolveOIDCAccount(ctx context.Context, idToken oidc.IDToken, now time.Time) (sqlc.Account, error) {
+	issuer := a.oidc.Provider.Issuer()
+	for attempt := 0; attempt < oidcIdentityLinkAttempts; attempt++ {
+		identity, err := a.queries.GetAccountIdentity(ctx, sqlc.GetAccountIdentityParams{
+			Issuer:  issuer,
+			Subject: idToken.Subject,
+		})
+		if err == nil {
+			if _, err := a.queries.TouchAccountIdentity(ctx, sqlc.TouchAccountIdentityParams{
+				LastLoginAtUnixMs: now.UnixMilli(),
+				Issuer:            issuer,
+				Subject:           idToken.Subject,
+			}); err != nil {
+				return sqlc.Account{}, err
+			}
+			account, err := a.queries.GetAccountByID(ctx, identity.AccountID)
+			if err != nil {
+				return sqlc.Account{}, err
+			}
+			return a.syncOIDCAdmin(ctx, account, idToken, now)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return sqlc.Account{}, err
+		}
+		if !a.oidc.AutoCreate {
+			return sqlc.Account{}, errOIDCAccountNotLinked
+		}
+
+		account, linked, err := a.createOIDCAccount(ctx, issuer, idToken, now)
+		if err != nil {
+			return sqlc.Account{}, err
+		}
+		if linked {
+			return account, nil
+		}
+		// A concurrent login linked the same identity first; use that account.
+	}
+	return sqlc.Account{}, errors.New("failed to link oidc identity")
+}
+
+func (a *ConsoleAuth) createOIDCAccount(ctx context.Context, issuer string, idToken oidc.IDToken, now time.Time) (sqlc.Account, bool, error) {
+	username, _ := idToken.Claims[a.oidc.UsernameClaim].(string)
+	if strings.TrimSpace(username) == "" {
+		return sqlc.Account{}, false, errOIDCUsernameClaimMissing
+	}
+
+	created, err := createAccountWithRetry(ctx, a.queries, createAccountInput{
+		Username: username,
+		IsAdmin:  a.oidc.AdminGroup != "" && oidcClaimContains(idToken.Claims[a.oidc.GroupsClaim], a.oidc.AdminGroup),
+		External: true,
+		Now:      now,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errAccountRegistrationConflict):
+			return sqlc.Account{}, false, errOIDCUsernameConflict
+		case errors.Is(err, errAccountUsernameRequired), errors.Is(err, errAccountUsernameTooLong):
+			return sqlc.Account{}, false, errOIDCUsernameClaimMissing
+		default:
+			return sqlc.Account{}, false, err
+		}
+	}
+
+	err = a.queries.InsertAccountIdentity(ctx, sqlc.InsertAccountIdentityParams{
+		Issuer:            issuer,
+		Subject:           idToken.Subject,
+		AccountID:         created.AccountID,
+		CreatedAtUnixMs:   now.UnixMilli(),
+		LastLoginAtUnixMs: now.UnixMilli(),
+	})
+	if err != nil {
+		if _, deleteErr := a.queries.DeleteAccountByID(ctx, created.AccountID); deleteErr != nil {
+			slog.Warn("failed to remove unlinked sso account", "account_id", created.AccountID, "error", deleteErr)
+		}
+		if isSQLiteConstraintError(err) {
+			return sqlc.Account{}, false, nil
+		}
+		return sqlc.Account{}, false, err
+	}
+
+	slog.Info("created console account from sso identity",
+		"account_id", created.AccountID,
+		"username", created.Username,
+		"is_admin", created.IsAdmin,
+	)
+	return sqlc.Account{
+		AccountID:       created.AccountID,
+		Username:        created.Username,
+		HashAlgo:        oidcAccountHashAlgo,
+		IsAdmin:         boolToInt64(created.IsAdmin),
+		CreatedAtUnixMs: created.CreatedAt.UnixMilli(),
+		UpdatedAtUnixMs: created.UpdatedAt.UnixMilli(),
+	}, true, nil
+}
+
+// syncOIDCAdmin applies the admin group mapping. The last admin account is
+// never demoted so the console cannot lock itself out.
+func (a *ConsoleAuth) syncOIDCAdmin(ctx context.Context, account sqlc.Account, idToken oidc.IDToken, now time.Time) (sqlc.Account, error) {
+	if a.oidc.AdminGroup == "" {
+		return account, nil
+	}
+	wantAdmin := oidcClaimContains(idToken.Claims[a.oidc.GroupsClaim], a.oidc.AdminGroup)
+	if wantAdmin == (account.IsAdmin == 1) {
+		return account, nil
+	}
+	if !wantAdmin {
+		admins, err := a.queries.CountAdminAccounts(ctx)
+		if err != nil {
+			return sqlc.Account{}, err
+		}
+		if admins <= 1 {
+			slog.Warn("keeping admin role for last admin account", "account_id", account.AccountID)
+			return account, nil
+		}
+	}
+
+	if _, err := a.queries.UpdateAccountIsAdminByID(ctx, sqlc.UpdateAccountIsAdminByIDParams{
+		IsAdmin:         boolToInt64(wantAdmin),
+		UpdatedAtUnixMs: now.UnixMilli(),
+		AccountID:       account.AccountID,
+	}); err != nil {
+		return sqlc.Account{}, err
+	}
+	// Existing sessions carry the old role, so revoke them.
+	if err := a.deleteSessionsByAccountID(ctx, account.AccountID); err != nil {
+		return sqlc.Account{}, err
+	}
+	slog.Info("updated sso account admin role from group claim", "account_id", account.AccountID, "is_admin", wantAdmin)
+	account.IsAdmin = boolToInt64(wantAdmin)
+	account.UpdatedAtUnixMs = now.UnixMilli()
+	return account, nil
+}
+
+func (a *ConsoleAuth) accountUsesExternalIdentity(ctx context.Context, accountID string) (bool, error) {
+	account, err := a.queries.GetAccountByID(ctx, strings.TrimSpace(accountID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return isExternalAccount(account), nil
+}
+
+func isExternalAccount(account sqlc.Account) bool {
+	return strings.EqualFold(strings.TrimSpace(account.HashAlgo), oidcAccountHashAlgo)
+}
+
+func oidcClaimContains(claim any, want string) bool {
+	switch value := claim.(type) {
+	case string:
+		return value == want
+	case []any:
+		for _, item := range value {
+			if text, ok := item.(string); ok && text == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sanitizeOIDCRedirectPath only allows same-origin absolute paths so the
+// callback cannot be used as an open redirect.
+func sanitizeOIDCRedirectPath(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || len(value) > maxOIDCRedirectPathBytes {
+		return oidcDefaultRedirectPath
+	}
+	if !strings.HasPrefix(value, "/") || strings.HasPrefix(value, "//") || strings.ContainsAny(value, "\\\r\n") {
+		return oidcDefaultRedirectPath
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme != "" || parsed.Host != "" {
+		return oidcDefaultRedirectPath
+	}
+	return value
+}
@@ -0,0 +1,281 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/oidc"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/oidctest"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+const (
+	testOIDCClientID    = "onlyboxes-console"
+	testOIDCRedirectURL = "https://console.example.test/api/v1/console/oidc/callback"
+	testOIDCAdminGroup  = "onlyboxes-admins"
+)
+
+func newTestOIDCRouter(t *testing.T, autoCreate bool) (*gin.Engine, *ConsoleAuth, *oidctest.Server) {
+	t.Helper()
+
+	idp := oidctest.NewServer(t, testOIDCClientID, "client-secret")
+	provider, err := oidc.NewProvider(oidc.Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     testOIDCClientID,
+		ClientSecret: "client-secret",
+		RedirectURL:  testOIDCRedirectURL,
+		Scopes:       []string{"profile", "groups"},
+	})
+	if err != nil {
+		t.Fatalf("new oidc provider: %v", err)
+	}
+	auth := newTestConsoleAuth(t)
+	if err := auth.SetOIDC(OIDCOptions{
+		Provider:   provider,
+		AdminGroup: testOIDCAdminGroup,
+		AutoCreate: autoCreate,
+	}); err != nil {
+		t.Fatalf("set oidc: %v", err)
+	}
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	return mustNewRouter(t, handler, auth, newTestMCPAuth(t)), auth, idp
+}
+
+// ssoLogin drives the browser side of the flow: start login and let the stub
+// IdP authenticate identity. It returns the callback path together with the
+// state cookie the browser would send back to the console.
+func ssoLogin(t *testing.T, router http.Handler, idp *oidctest.Server, redirect string, identity oidctest.Identity) (string, *http.Cookie) {
+	t.Helper()
+
+	loginPath := "/api/v1/console/oidc/login"
+	if redirect != "" {
+		loginPath += "?redirect=" + url.QueryEscape(redirect)
+	}
+	loginRec := doJSON(t, router, http.MethodGet, loginPath, "", nil)
+	if loginRec.Code != http.StatusFound {
+		t.Fatalf("expected 302 from oidc login, got %d body=%s", loginRec.Code, loginRec.Body.String())
+	}
+	var stateCookie *http.Cookie
+	for _, cookie := range loginRec.Result().Cookies() {
+		if cookie.Name == oidcStateCookieName && cookie.Value != "" {
+			stateCookie = cookie
+		}
+	}
+	if stateCookie == nil || !stateCookie.HttpOnly || stateCookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("expected HttpOnly SameSite=Lax state cookie, got %#v", stateCookie)
+	}
+	callbackURL, err := url.Parse(idp.Authorize(loginRec.Header().Get("Location"), identity))
+	if err != nil {
+		t.Fatalf("parse callback url: %v", err)
+	}
+	return callbackURL.RequestURI(), stateCookie
+}
+
+func decodeSessionAccount(t *testing.T, router http.Handler, cookie *http.Cookie) SessionAccount {
+	t.Helper()
+	rec := doJSON(t, router, http.MethodGet, "/api/v1/console/session", "", cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected session 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var payload accountSessionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode session payload: %v", err)
+	}
+	return payload.Account
+}
+
+func TestConsoleAuthOIDCDisabledByDefault(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	router := mustNewRouter(t, handler, newTestConsoleAuth(t), newTestMCPAuth(t))
+
+	configRec := doJSON(t, router, http.MethodGet, "/api/v1/console/oidc", "", nil)
+	if configRec.Code != http.StatusOK || configRec.Body.String() != `{"enabled":false}` {
+		t.Fatalf("unexpected oidc config response: %d body=%s", configRec.Code, configRec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/console/oidc/login", "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for disabled oidc login, got %d", rec.Code)
+	}
+}
+
+func TestConsoleAuthOIDCLoginCreatesAccountAndMapsAdminGroup(t *testing.T) {
+	router, auth, idp := newTestOIDCRouter(t, true)
+
+	configRec := doJSON(t, router, http.MethodGet, "/api/v1/console/oidc", "", nil)
+	if configRec.Code != http.StatusOK {
+		t.Fatalf("expected oidc config 200, got %d", configRec.Code)
+	}
+	var config oidcConfigResponse
+	if err := json.Unmarshal(configRec.Body.Bytes(), &config); err != nil {
+		t.Fatalf("decode oidc config: %v", err)
+	}
+	if !config.Enabled || config.LoginURL != oidcLoginPath {
+		t.Fatalf("unexpected oidc config: %#v", config)
+	}
+
+	adminIdentity := oidctest.Identity{
+		Subject: "idp-user-alice",
+		Claims: map[string]any{
+			"preferred_username": "alice",
+			"groups":             []string{"staff", testOIDCAdminGroup},
+		},
+	}
+	callbackPath, stateCookie := ssoLogin(t, router, idp, "/workers?tab=online", adminIdentity)
+	callbackRec := doJSON(t, router, http.MethodGet, callbackPath, "", stateCookie)
+	if callbackRec.Code != http.StatusFound {
+		t.Fatalf("expected 302 from callback, got %d body=%s", callbackRec.Code, callbackRec.Body.String())
+	}
+	if location := callbackRec.Header().Get("Location"); location != "/workers?tab=online" {
+		t.Fatalf("expected redirect to original path, got %q", location)
+	}
+	adminCookie := sessionCookieFromRecorder(t, callbackRec)
+	account := decodeSessionAccount(t, router, adminCookie)
+	if account.Username != "alice" || !account.IsAdmin {
+		t.Fatalf("expected jit admin account alice, got %#v", account)
+	}
+
+	// The state is single-use, so replaying the callback fails.
+	if rec := doJSON(t, router, http.MethodGet, callbackPath, "", stateCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected replayed callback to fail with 400, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// SSO accounts have no local password.
+	if rec := postLogin(router, "alice", "any-password"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected local login to fail for sso account, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/password", `{"current_password":"x","new_password":"y"}`, adminCookie); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for sso password change, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// Leaving the admin group demotes the same account and revokes old sessions.
+	memberIdentity := oidctest.Identity{
+		Subject: "idp-user-alice",
+		Claims: map[string]any{
+			"preferred_username": "alice-renamed",
+			"groups":             []string{"staff"},
+		},
+	}
+	callbackPath, stateCookie = ssoLogin(t, router, idp, "", memberIdentity)
+	callbackRec = doJSON(t, router, http.MethodGet, callbackPath, "", stateCookie)
+	if callbackRec.Code != http.StatusFound || callbackRec.Header().Get("Location") != "/" {
+		t.Fatalf("expected redirect to /, got %d location=%q", callbackRec.Code, callbackRec.Header().Get("Location"))
+	}
+	memberAccount := decodeSessionAccount(t, router, sessionCookieFromRecorder(t, callbackRec))
+	if memberAccount.AccountID != account.AccountID || memberAccount.Username != "alice" || memberAccount.IsAdmin {
+		t.Fatalf("expected same account demoted to member, got %#v (first %#v)", memberAccount, account)
+	}
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/console/session", "", adminCookie); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected pre-demotion session to be revoked, got %d", rec.Code)
+	}
+
+	identity, err := auth.queries.GetAccountIdentity(context.Background(), sqlc.GetAccountIdentityParams{Issuer: idp.Issuer(), Subject: "idp-user-alice"})
+	if err != nil || identity.AccountID != account.AccountID {
+		t.Fatalf("expected identity link for alice, got %#v err=%v", identity, err)
+	}
+}
+
+func TestConsoleAuthOIDCRejectsConflictsAndUnlinkedIdentities(t *testing.T) {
+	router, _, idp := newTestOIDCRouter(t, true)
+
+	// An IdP user must not be able to claim an existing local account by name.
+	callbackPath, stateCookie := ssoLogin(t, router, idp, "", oidctest.Identity{
+		Subject: "idp-user-impostor",
+		Claims:  map[string]any{"preferred_username": testDashboardUsername, "groups": []string{testOIDCAdminGroup}},
+	})
+	if rec := doJSON(t, router, http.MethodGet, callbackPath, "", stateCookie); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for username conflict, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	callbackPath, stateCookie = ssoLogin(t, router, idp, "", oidctest.Identity{Subject: "idp-user-nameless"})
+	if rec := doJSON(t, router, http.MethodGet, callbackPath, "", stateCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without username claim, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// Open redirects are replaced with the dashboard root.
+	callbackPath, stateCookie = ssoLogin(t, router, idp, "//evil.example.test/phish", oidctest.Identity{
+		Subject: "idp-user-bob",
+		Claims:  map[string]any{"preferred_username": "bob"},
+	})
+	rec := doJSON(t, router, http.MethodGet, callbackPath, "", stateCookie)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/" {
+		t.Fatalf("expected sanitized redirect, got %d location=%q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/console/oidc/callback?state=unknown&code=x", "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown state, got %d", rec.Code)
+	}
+
+	strictRouter, _, strictIDP := newTestOIDCRouter(t, false)
+	callbackPath, stateCookie = ssoLogin(t, strictRouter, strictIDP, "", oidctest.Identity{
+		Subject: "idp-user-carol",
+		Claims:  map[string]any{"preferred_username": "carol"},
+	})
+	if rec := doJSON(t, strictRouter, http.MethodGet, callbackPath, "", stateCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 when auto create is disabled, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestConsoleAuthOIDCCallbackRequiresStateCookie(t *testing.T) {
+	router, _, idp := newTestOIDCRouter(t, true)
+	identity := oidctest.Identity{
+		Subject: "idp-user-dave",
+		Claims:  map[string]any{"preferred_username": "dave"},
+	}
+
+	// A callback URL replayed into another browser carries no state cookie.
+	callbackPath, stateCookie := ssoLogin(t, router, idp, "", identity)
+	rec := doJSON(t, router, http.MethodGet, callbackPath, "", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without state cookie, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == dashboardSessionCookieName {
+			t.Fatalf("expected no session cookie without state cookie")
+		}
+	}
+
+	// A state cookie from a different login does not match either.
+	_, otherCookie := ssoLogin(t, router, idp, "", identity)
+	if rec := doJSON(t, router, http.MethodGet, callbackPath, "", otherCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for mismatched state cookie, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// The rejected attempts did not consume the state.
+	rec = doJSON(t, router, http.MethodGet, callbackPath, "", stateCookie)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected 302 with matching state cookie, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	sessionCookieFromRecorder(t, rec)
+	cleared := false
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == oidcStateCookieName && cookie.MaxAge < 0 {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Fatalf("expected state cookie to be cleared after the callback")
+	}
+}
+
+func TestSanitizeOIDCRedirectPath(t *testing.T) {
+	tests := map[string]string{
+		"":                      "/",
+		"/workers":              "/workers",
+		"/tokens?page=2#top":    "/tokens?page=2#top",
+		"https://evil.example":  "/",
+		"//evil.example":        "/",
+		"/\\evil.example":       "/",
+		"workers":               "/",
+		"/ok\r\nSet-Cookie: x=": "/",
+	}
+	for input, want := range tests {
+		if got := sanitizeOIDCRedirectPath(input); got != want {
+			t.Fatalf("sanitizeOIDCRedirectPath(%q)=%q, want %q", input, got, want)
+		}
+	}
+}
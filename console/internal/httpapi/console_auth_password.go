@@ -0,0 +1,351 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	argon2idPasswordHashAlgo   = "argon2id"
+	argon2idMemoryKiB          = 64 * 1024
+	argon2idIterations         = 3
+	argon2idParallelism        = 4
+	argon2idSaltByteSize       = 16
+	argon2idKeyByteSize        = 32
+	defaultPasswordMinLength   = 8
+	defaultPasswordHistorySize = 5
+	maxBCryptPasswordBytes     = 72
+	maxAccountPasswordBytes    = 1024
+)
+
+var (
+	errAccountPasswordTooShort     = errors.New("password is too short")
+	errAccountPasswordTooLong      = errors.New("password is too long")
+	errAccountPasswordBlocklisted  = errors.New("password is too common or has appeared in a data breach")
+	errAccountPasswordReused       = errors.New("password was used recently")
+	errPasswordHashAlgoUnsupported = errors.New("unsupported password hash algorithm")
+	errPasswordHashMalformed       = errors.New("malformed password hash")
+)
+
+// PasswordPolicy controls how dashboard passwords are hashed and which new
+// passwords are accepted. HistorySize is the number of previous passwords an
+// account may not reuse; zero disables the check.
+type PasswordPolicy struct {
+	HashAlgo    string
+	MinLength   int
+	HistorySize int
+	Blocklist   PasswordBlocklist
+}
+
+// PasswordBlocklist holds lower-cased passwords that are rejected regardless
+// of length, typically a list of breached or common passwords.
+type PasswordBlocklist map[string]struct{}
+
+type argon2idParams struct {
+	memoryKiB   uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+var defaultArgon2idParams = argon2idParams{
+	memoryKiB:   argon2idMemoryKiB,
+	iterations:  argon2idIterations,
+	parallelism: argon2idParallelism,
+}
+
+var defaultPasswordPolicy = PasswordPolicy{
+	HashAlgo:    dashboardPasswordHashAlgo,
+	MinLength:   defaultPasswordMinLength,
+	HistorySize: defaultPasswordHistorySize,
+}
+
+// DefaultPasswordPolicy returns the policy used when none is configured.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return defaultPasswordPolicy
+}
+
+// LoadPasswordBlocklist reads one password per line. Blank lines and lines
+// starting with # are ignored.
+func LoadPasswordBlocklist(path string) (PasswordBlocklist, error) {
+	file, err := os.Open(strings.TrimSpace(path))
+	if err != nil {
+		return nil, fmt.Errorf("open password blocklist: %w", err)
+	}
+	defer file.Close()
+
+	blocklist := make(PasswordBlocklist)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		blocklist[strings.ToLower(entry)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read password blocklist: %w", err)
+	}
+	return blocklist, nil
+}
+
+func (b PasswordBlocklist) contains(password string) bool {
+	if len(b) == 0 {
+		return false
+	}
+	_, ok := b[strings.ToLower(strings.TrimSpace(password))]
+	return ok
+}
+
+func normalizePasswordPolicy(policy PasswordPolicy) (PasswordPolicy, error) {
+	policy.HashAlgo = strings.ToLower(strings.TrimSpace(policy.HashAlgo))
+	if policy.HashAlgo == "" {
+		policy.HashAlgo = dashboardPasswordHashAlgo
+	}
+	if policy.HashAlgo != dashboardPasswordHashAlgo && policy.HashAlgo != argon2idPasswordHashAlgo {
+		return PasswordPolicy{}, fmt.Errorf("%w: %q", errPasswordHashAlgoUnsupported, policy.HashAlgo)
+	}
+	if policy.MinLength <= 0 {
+		policy.MinLength = defaultPasswordMinLength
+	}
+	if policy.HistorySize < 0 {
+		policy.HistorySize = 0
+	}
+	return policy, nil
+}
+
+func (a *ConsoleAuth) SetPasswordPolicy(policy PasswordPolicy) error {
+	normalized, err := normalizePasswordPolicy(policy)
+	if err != nil {
+		return err
+	}
+	a.passwordPolicy = normalized
+	return nil
+}
+
+// validate checks a candidate password against the policy. It does not check
+// reuse, which needs the account history.
+func (p PasswordPolicy) validate(password string) error {
+	if strings.TrimSpace(password) == "" {
+		return errAccountPasswordRequired
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: minimum length is %d", errAccountPasswordTooShort, p.MinLength)
+	}
+	maxBytes := maxAccountPasswordBytes
+	if p.HashAlgo == dashboardPasswordHashAlgo {
+		// bcrypt silently ignores everything past 72 bytes.
+		maxBytes = maxBCryptPasswordBytes
+	}
+	if len(password) > maxBytes {
+		return fmt.Errorf("%w: maximum length is %d bytes", errAccountPasswordTooLong, maxBytes)
+	}
+	if p.Blocklist.contains(password) {
+		return errAccountPasswordBlocklisted
+	}
+	return nil
+}
+
+func (p PasswordPolicy) hash(password string) (string, string, error) {
+	switch p.HashAlgo {
+	case argon2idPasswordHashAlgo:
+		hashed, err := hashArgon2idPassword(password, defaultArgon2idParams)
+		return hashed, argon2idPasswordHashAlgo, err
+	case dashboardPasswordHashAlgo, "":
+		hashed, err := hashDashboardPassword(password)
+		return hashed, dashboardPasswordHashAlgo, err
+	default:
+		return "", "", fmt.Errorf("%w: %q", errPasswordHashAlgoUnsupported, p.HashAlgo)
+	}
+}
+
+// needsRehash reports whether a hash that just verified should be replaced
+// because the configured algorithm or its cost changed.
+func (p PasswordPolicy) needsRehash(hashAlgo string, hash string) bool {
+	hashAlgo = strings.ToLower(strings.TrimSpace(hashAlgo))
+	if hashAlgo != p.HashAlgo {
+		return true
+	}
+	switch hashAlgo {
+	case dashboardPasswordHashAlgo:
+		cost, err := bcrypt.Cost([]byte(strings.TrimSpace(hash)))
+		return err != nil || cost != dashboardPasswordBCryptCost
+	case argon2idPasswordHashAlgo:
+		params, _, _, err := decodeArgon2idHash(hash)
+		return err != nil || params != defaultArgon2idParams
+	default:
+		return true
+	}
+}
+
+func comparePasswordHash(hashAlgo string, hash string, password string) bool {
+	switch strings.ToLower(strings.TrimSpace(hashAlgo)) {
+	case dashboardPasswordHashAlgo:
+		return compareDashboardPassword(hash, password)
+	case argon2idPasswordHashAlgo:
+		return compareArgon2idPassword(hash, password)
+	default:
+		return false
+	}
+}
+
+// hashArgon2idPassword encodes the hash in the PHC string format used by the
+// reference implementation, so parameters can change without a migration.
+func hashArgon2idPassword(password string, params argon2idParams) (string, error) {
+	salt := make([]byte, argon2idSaltByteSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.iterations, params.memoryKiB, params.parallelism, argon2idKeyByteSize)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		params.memoryKiB,
+		params.iterations,
+		params.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func compareArgon2idPassword(hash string, password string) bool {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.iterations, params.memoryKiB, params.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1
+}
+
+func decodeArgon2idHash(hash string) (argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimSpace(hash), "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != argon2idPasswordHashAlgo {
+		return argon2idParams{}, nil, nil, errPasswordHashMalformed
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2idParams{}, nil, nil, errPasswordHashMalformed
+	}
+	var params argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memoryKiB, &params.iterations, &params.parallelism); err != nil {
+		return argon2idParams{}, nil, nil, errPasswordHashMalformed
+	}
+	if params.memoryKiB == 0 || params.iterations == 0 || params.parallelism == 0 {
+		return argon2idParams{}, nil, nil, errPasswordHashMalformed
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return argon2idParams{}, nil, nil, errPasswordHashMalformed
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return argon2idParams{}, nil, nil, errPasswordHashMalformed
+	}
+	return params, salt, key, nil
+}
+
+// passwordReused reports whether password matches one of the last HistorySize
+// passwords of the account, counting the current one.
+func (p PasswordPolicy) passwordReused(ctx context.Context, queries *sqlc.Queries, account sqlc.Account, password string) (bool, error) {
+	if p.HistorySize <= 0 {
+		return false, nil
+	}
+	if comparePasswordHash(account.HashAlgo, account.PasswordHash, password) {
+		return true, nil
+	}
+	if p.HistorySize == 1 {
+		return false, nil
+	}
+	history, err := queries.ListRecentAccountPasswordHistory(ctx, sqlc.ListRecentAccountPasswordHistoryParams{
+		AccountID: strings.TrimSpace(account.AccountID),
+		Limit:     int64(p.HistorySize - 1),
+	})
+	if err != nil {
+		return false, fmt.Errorf("list password history: %w", err)
+	}
+	for _, entry := range history {
+		if comparePasswordHash(entry.HashAlgo, entry.PasswordHash, password) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordPasswordHistory keeps the hash being replaced so it can be checked on
+// later changes. Together with the current hash the account remembers
+// HistorySize passwords.
+func (p PasswordPolicy) recordPasswordHistory(ctx context.Context, queries *sqlc.Queries, previous sqlc.Account, now time.Time) error {
+	accountID := strings.TrimSpace(previous.AccountID)
+	keep := p.HistorySize - 1
+	if keep < 0 {
+		keep = 0
+	}
+	if keep > 0 && strings.TrimSpace(previous.PasswordHash) != "" {
+		if err := queries.InsertAccountPasswordHistory(ctx, sqlc.InsertAccountPasswordHistoryParams{
+			AccountID:       accountID,
+			PasswordHash:    previous.PasswordHash,
+			HashAlgo:        previous.HashAlgo,
+			CreatedAtUnixMs: now.UnixMilli(),
+		}); err != nil {
+			return err
+		}
+	}
+	_, err := queries.PruneAccountPasswordHistory(ctx, sqlc.PruneAccountPasswordHistoryParams{
+		AccountID:   accountID,
+		AccountID_2: accountID,
+		Limit:       int64(keep),
+	})
+	return err
+}
+
+// passwordRehash is a replacement hash in the configured algorithm, computed
+// while the plain password is at hand. Empty when the stored hash is current.
+type passwordRehash struct {
+	PasswordHash string
+	HashAlgo     string
+}
+
+// pendingPasswordRehash prepares an upgraded hash at the password step so
+// accounts migrate to the configured algorithm without a password reset. It
+// is only stored by completeLogin, once every factor is verified.
+func (a *ConsoleAuth) pendingPasswordRehash(account sqlc.Account, password string) passwordRehash {
+	if !a.passwordPolicy.needsRehash(account.HashAlgo, account.PasswordHash) {
+		return passwordRehash{}
+	}
+	passwordHash, hashAlgo, err := a.passwordPolicy.hash(password)
+	if err != nil {
+		slog.Warn("failed to rehash console password", "account_id", account.AccountID, "error", err)
+		return passwordRehash{}
+	}
+	return passwordRehash{PasswordHash: passwordHash, HashAlgo: hashAlgo}
+}
+
+// applyPasswordRehash stores rehash unless the password changed since account
+// was loaded.
+func (a *ConsoleAuth) applyPasswordRehash(ctx context.Context, account sqlc.Account, rehash passwordRehash) {
+	if rehash.PasswordHash == "" {
+		return
+	}
+	if _, err := a.queries.RehashAccountPassword(ctx, sqlc.RehashAccountPasswordParams{
+		PasswordHash:    rehash.PasswordHash,
+		HashAlgo:        rehash.HashAlgo,
+		UpdatedAtUnixMs: a.now().UnixMilli(),
+		AccountID:       strings.TrimSpace(account.AccountID),
+		PasswordHash_2:  account.PasswordHash,
+	}); err != nil {
+		slog.Warn("failed to store rehashed console password", "account_id", account.AccountID, "error", err)
+	}
+}
@@ -0,0 +1,246 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func TestArgon2idPasswordHashRoundTrip(t *testing.T) {
+	hashed, err := hashArgon2idPassword("correct horse battery", defaultArgon2idParams)
+	if err != nil {
+		t.Fatalf("hash argon2id password: %v", err)
+	}
+	if !strings.HasPrefix(hashed, "$argon2id$v=19$m=65536,t=3,p=4$") {
+		t.Fatalf("unexpected argon2id encoding %q", hashed)
+	}
+	if !comparePasswordHash(argon2idPasswordHashAlgo, hashed, "correct horse battery") {
+		t.Fatalf("expected argon2id hash to verify")
+	}
+	if comparePasswordHash(argon2idPasswordHashAlgo, hashed, "correct horse staple") {
+		t.Fatalf("expected wrong password to be rejected")
+	}
+	if comparePasswordHash(dashboardPasswordHashAlgo, hashed, "correct horse battery") {
+		t.Fatalf("expected hash_algo mismatch to be rejected")
+	}
+	if comparePasswordHash(argon2idPasswordHashAlgo, "$argon2id$v=19$m=0,t=3,p=4$AAAA$AAAA", "x") {
+		t.Fatalf("expected malformed hash to be rejected")
+	}
+
+	argon2Policy := PasswordPolicy{HashAlgo: argon2idPasswordHashAlgo}
+	if argon2Policy.needsRehash(argon2idPasswordHashAlgo, hashed) {
+		t.Fatalf("expected current argon2id hash to be kept")
+	}
+	weaker, err := hashArgon2idPassword("correct horse battery", argon2idParams{memoryKiB: 1024, iterations: 1, parallelism: 1})
+	if err != nil {
+		t.Fatalf("hash weaker argon2id password: %v", err)
+	}
+	if !comparePasswordHash(argon2idPasswordHashAlgo, weaker, "correct horse battery") {
+		t.Fatalf("expected hash with older parameters to verify")
+	}
+	if !argon2Policy.needsRehash(argon2idPasswordHashAlgo, weaker) {
+		t.Fatalf("expected hash with older parameters to be rehashed")
+	}
+	if !defaultPasswordPolicy.needsRehash(argon2idPasswordHashAlgo, hashed) {
+		t.Fatalf("expected argon2id hash to be rehashed when bcrypt is configured")
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	blocklistPath := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(blocklistPath, []byte("# common passwords\n\nPassword123\n  letmein-please  \n"), 0o600); err != nil {
+		t.Fatalf("write blocklist: %v", err)
+	}
+	blocklist, err := LoadPasswordBlocklist(blocklistPath)
+	if err != nil {
+		t.Fatalf("load blocklist: %v", err)
+	}
+	if len(blocklist) != 2 {
+		t.Fatalf("expected 2 blocklist entries, got %d", len(blocklist))
+	}
+	if _, err := LoadPasswordBlocklist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatalf("expected missing blocklist file to fail")
+	}
+
+	policy, err := normalizePasswordPolicy(PasswordPolicy{MinLength: 10, Blocklist: blocklist})
+	if err != nil {
+		t.Fatalf("normalize policy: %v", err)
+	}
+	tests := []struct {
+		password string
+		want     error
+	}{
+		{password: "   ", want: errAccountPasswordRequired},
+		{password: "short-pw", want: errAccountPasswordTooShort},
+		{password: "密码密码密码密码密码", want: nil},
+		{password: "PASSWORD123", want: errAccountPasswordBlocklisted},
+		{password: "letmein-please", want: errAccountPasswordBlocklisted},
+		{password: strings.Repeat("a", maxBCryptPasswordBytes+1), want: errAccountPasswordTooLong},
+		{password: "long-enough-password", want: nil},
+	}
+	for _, tc := range tests {
+		if err := policy.validate(tc.password); !errors.Is(err, tc.want) {
+			t.Fatalf("validate(%q): expected %v, got %v", tc.password, tc.want, err)
+		}
+	}
+
+	if _, err := normalizePasswordPolicy(PasswordPolicy{HashAlgo: "md5"}); !errors.Is(err, errPasswordHashAlgoUnsupported) {
+		t.Fatalf("expected unsupported hash algo error, got %v", err)
+	}
+}
+
+func TestInitializeAdminAccountEnforcesPasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	db := openTestAuthDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+
+	policy := PasswordPolicy{HashAlgo: argon2idPasswordHashAlgo, MinLength: 12}
+	if _, err := InitializeAdminAccount(ctx, db.Queries, "admin-short", "too-short", policy); !errors.Is(err, errAccountPasswordTooShort) {
+		t.Fatalf("expected short env password to be rejected, got %v", err)
+	}
+
+	result, err := InitializeAdminAccount(ctx, db.Queries, "admin-long", "long-enough-password", policy)
+	if err != nil {
+		t.Fatalf("initialize admin account: %v", err)
+	}
+	stored, err := db.Queries.GetAccountByID(ctx, result.AccountID)
+	if err != nil {
+		t.Fatalf("load persisted account: %v", err)
+	}
+	if stored.HashAlgo != argon2idPasswordHashAlgo || !compareArgon2idPassword(stored.PasswordHash, "long-enough-password") {
+		t.Fatalf("expected argon2id hash for admin account, got algo=%q", stored.HashAlgo)
+	}
+}
+
+func TestConsoleAuthLoginRehashesPasswordWithConfiguredAlgo(t *testing.T) {
+	auth := newTestConsoleAuth(t)
+	if err := auth.SetPasswordPolicy(PasswordPolicy{HashAlgo: argon2idPasswordHashAlgo}); err != nil {
+		t.Fatalf("set password policy: %v", err)
+	}
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+
+	if rec := postLogin(router, testDashboardUsername, "wrong-password"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected failed login 401, got %d", rec.Code)
+	}
+	account, ok := auth.lookupAccount(context.Background(), testDashboardUsername)
+	if !ok || account.HashAlgo != dashboardPasswordHashAlgo {
+		t.Fatalf("expected failed login to keep bcrypt hash, got %#v", account)
+	}
+
+	if rec := postLogin(router, testDashboardUsername, testDashboardPassword); rec.Code != http.StatusOK {
+		t.Fatalf("expected login 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	account, ok = auth.lookupAccount(context.Background(), testDashboardUsername)
+	if !ok || account.HashAlgo != argon2idPasswordHashAlgo || !strings.HasPrefix(account.PasswordHash, "$argon2id$") {
+		t.Fatalf("expected login to rehash with argon2id, got algo=%q", account.HashAlgo)
+	}
+
+	if rec := postLogin(router, testDashboardUsername, testDashboardPassword); rec.Code != http.StatusOK {
+		t.Fatalf("expected login with rehashed password 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestConsoleAuthLoginRehashWaitsForSecondFactor(t *testing.T) {
+	auth := newTestConsoleAuth(t)
+	now := time.Now()
+	auth.nowFn = func() time.Time {
+		return now
+	}
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+	secret, _ := enrollTestTOTP(t, router, loginSessionCookie(t, router), now)
+	if err := auth.SetPasswordPolicy(PasswordPolicy{HashAlgo: argon2idPasswordHashAlgo}); err != nil {
+		t.Fatalf("set password policy: %v", err)
+	}
+
+	// The password alone must not rewrite the stored hash.
+	mfaToken := startSecondFactorLogin(t, router)
+	account, ok := auth.lookupAccount(context.Background(), testDashboardUsername)
+	if !ok || account.HashAlgo != dashboardPasswordHashAlgo {
+		t.Fatalf("expected password step to keep bcrypt hash, got algo=%q", account.HashAlgo)
+	}
+
+	now = now.Add(totpPeriodSec * time.Second)
+	code, err := totpCodeAt(secret, totpStepAt(now))
+	if err != nil {
+		t.Fatalf("compute totp code: %v", err)
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/login/2fa", `{"mfa_token":"`+mfaToken+`","code":"`+code+`"}`, nil); rec.Code != http.StatusOK {
+		t.Fatalf("expected second factor login 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	account, ok = auth.lookupAccount(context.Background(), testDashboardUsername)
+	if !ok || account.HashAlgo != argon2idPasswordHashAlgo || !compareArgon2idPassword(account.PasswordHash, testDashboardPassword) {
+		t.Fatalf("expected completed login to rehash with argon2id, got algo=%q", account.HashAlgo)
+	}
+}
+
+func TestConsoleAuthPasswordPolicyOnRegisterAndChangePassword(t *testing.T) {
+	auth := newTestConsoleAuthWithRegistration(t, true)
+	if err := auth.SetPasswordPolicy(PasswordPolicy{MinLength: 12, HistorySize: 3}); err != nil {
+		t.Fatalf("set password policy: %v", err)
+	}
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+	cookie := loginSessionCookie(t, router)
+
+	rec := doJSON(t, router, http.MethodPost, "/api/v1/console/register", `{"username":"member-short","password":"member-pw"}`, cookie)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), errAccountPasswordTooShort.Error()) {
+		t.Fatalf("expected short register password 400, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/register", `{"username":"member-long","password":"member-password"}`, cookie); rec.Code != http.StatusCreated {
+		t.Fatalf("expected register 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	changePassword := func(current string, next string) *http.Cookie {
+		t.Helper()
+		body := `{"current_password":"` + current + `","new_password":"` + next + `"}`
+		rec := doJSON(t, router, http.MethodPost, "/api/v1/console/password", body, cookie)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("expected password change %q -> %q to succeed, got %d body=%s", current, next, rec.Code, rec.Body.String())
+		}
+		return sessionCookieFromRecorder(t, rec)
+	}
+	expectRejected := func(current string, next string, want error) {
+		t.Helper()
+		body := `{"current_password":"` + current + `","new_password":"` + next + `"}`
+		rec := doJSON(t, router, http.MethodPost, "/api/v1/console/password", body, cookie)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), want.Error()) {
+			t.Fatalf("expected password change %q -> %q to fail with %v, got %d body=%s", current, next, want, rec.Code, rec.Body.String())
+		}
+	}
+
+	expectRejected(testDashboardPassword, "short-pw", errAccountPasswordTooShort)
+	expectRejected(testDashboardPassword, testDashboardPassword, errAccountPasswordReused)
+
+	cookie = changePassword(testDashboardPassword, "password-second")
+	cookie = changePassword("password-second", "password-third")
+	expectRejected("password-third", testDashboardPassword, errAccountPasswordReused)
+	expectRejected("password-third", "password-second", errAccountPasswordReused)
+
+	// With a history of three, the oldest password becomes usable again after
+	// three newer ones.
+	cookie = changePassword("password-third", "password-fourth")
+	cookie = changePassword("password-fourth", testDashboardPassword)
+
+	history, err := auth.queries.ListRecentAccountPasswordHistory(context.Background(), sqlc.ListRecentAccountPasswordHistoryParams{
+		AccountID: testDashboardAccountID,
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatalf("list password history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected history pruned to 2 previous passwords, got %d", len(history))
+	}
+}
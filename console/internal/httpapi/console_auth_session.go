@@ -2,102 +2,238 @@ package httpapi
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
 )
 
-func (a *ConsoleAuth) createSession(account SessionAccount) (string, time.Time, error) {
-	sessionID, err := randomHex(32)
-	if err != nil {
-		return "", time.Time{}, err
+func (a *ConsoleAuth) createSession(ctx context.Context, account SessionAccount, client sessionClientInfo) (string, time.Time, error) {
+	if a == nil || a.queries == nil {
+		return "", time.Time{}, ErrConsoleQueriesRequired
 	}
-	now := time.Now()
-	if a != nil && a.nowFn != nil {
-		now = a.nowFn()
+	accountID := strings.TrimSpace(account.AccountID)
+	if accountID == "" {
+		return "", time.Time{}, errAccountNotFound
 	}
-	expiresAt := now.Add(dashboardSessionTTL)
 
-	a.sessionMu.Lock()
-	defer a.sessionMu.Unlock()
-	a.sessions[sessionID] = accountSessionState{
-		Account:   account,
-		ExpiresAt: expiresAt,
+	now := a.now()
+	expiresAt := now.Add(dashboardSessionTTL)
+	if _, err := a.queries.DeleteExpiredConsoleSessions(ctx, now.UnixMilli()); err != nil {
+		return "", time.Time{}, err
 	}
 
-	return sessionID, expiresAt, nil
-}
+	for attempt := 0; attempt < dashboardSessionInsertAttempts; attempt++ {
+		sessionToken, err := randomHex(dashboardSessionTokenByteSize)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		sessionIDSuffix, err := randomHex(dashboardSessionIDRandomByteSize)
+		if err != nil {
+			return "", time.Time{}, err
+		}
 
-func (a *ConsoleAuth) sessionState(sessionID string) (accountSessionState, bool) {
-	now := time.Now()
-	if a != nil && a.nowFn != nil {
-		now = a.nowFn()
+		err = a.queries.InsertConsoleSession(ctx, sqlc.InsertConsoleSessionParams{
+			SessionID:        dashboardSessionIDPrefix + sessionIDSuffix,
+			TokenHash:        hashSessionToken(sessionToken),
+			AccountID:        accountID,
+			UserAgent:        client.UserAgent,
+			IpAddress:        client.IPAddress,
+			CreatedAtUnixMs:  now.UnixMilli(),
+			LastSeenAtUnixMs: now.UnixMilli(),
+			ExpiresAtUnixMs:  expiresAt.UnixMilli(),
+		})
+		if err == nil {
+			return sessionToken, expiresAt, nil
+		}
+		if !isSQLiteConstraintError(err) {
+			return "", time.Time{}, err
+		}
 	}
+	return "", time.Time{}, errSessionCreateConflict
+}
 
-	a.sessionMu.Lock()
-	defer a.sessionMu.Unlock()
+func (a *ConsoleAuth) sessionState(ctx context.Context, sessionToken string, client sessionClientInfo) (accountSessionState, bool) {
+	if a == nil || a.queries == nil {
+		return accountSessionState{}, false
+	}
+	sessionToken = strings.TrimSpace(sessionToken)
+	if sessionToken == "" {
+		return accountSessionState{}, false
+	}
 
-	state, ok := a.sessions[sessionID]
-	if !ok {
+	tokenHash := hashSessionToken(sessionToken)
+	record, err := a.queries.GetConsoleSessionByTokenHash(ctx, tokenHash)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("failed to load console session", "error", err)
+		}
 		return accountSessionState{}, false
 	}
-	if !state.ExpiresAt.After(now) {
-		delete(a.sessions, sessionID)
+
+	now := a.now()
+	expiresAt := time.UnixMilli(record.ExpiresAtUnixMs)
+	if !expiresAt.After(now) {
+		if _, err := a.queries.DeleteConsoleSessionByTokenHash(ctx, tokenHash); err != nil {
+			slog.Warn("failed to delete expired console session", "error", err)
+		}
 		return accountSessionState{}, false
 	}
-	return state, true
+
+	// last_seen only needs coarse resolution; avoid a write on every request.
+	if now.Sub(time.UnixMilli(record.LastSeenAtUnixMs)) >= dashboardSessionTouchInterval {
+		if _, err := a.queries.TouchConsoleSession(ctx, sqlc.TouchConsoleSessionParams{
+			LastSeenAtUnixMs: now.UnixMilli(),
+			IpAddress:        client.IPAddress,
+			SessionID:        record.SessionID,
+		}); err != nil {
+			slog.Warn("failed to touch console session", "session_id", record.SessionID, "error", err)
+		}
+	}
+
+	return accountSessionState{
+		SessionID: strings.TrimSpace(record.SessionID),
+		Account: SessionAccount{
+			AccountID: strings.TrimSpace(record.AccountID),
+			Username:  strings.TrimSpace(record.Username),
+			IsAdmin:   record.IsAdmin == 1,
+		},
+		ExpiresAt: expiresAt,
+	}, true
 }
 
-func (a *ConsoleAuth) deleteSession(sessionID string) {
-	a.sessionMu.Lock()
-	defer a.sessionMu.Unlock()
-	delete(a.sessions, sessionID)
+func (a *ConsoleAuth) deleteSession(ctx context.Context, sessionToken string) error {
+	if a == nil || a.queries == nil {
+		return ErrConsoleQueriesRequired
+	}
+	sessionToken = strings.TrimSpace(sessionToken)
+	if sessionToken == "" {
+		return nil
+	}
+	_, err := a.queries.DeleteConsoleSessionByTokenHash(ctx, hashSessionToken(sessionToken))
+	return err
 }
 
-func (a *ConsoleAuth) deleteSessionsByAccountID(accountID string) {
+func (a *ConsoleAuth) deleteSessionsByAccountID(ctx context.Context, accountID string) error {
+	if a == nil || a.queries == nil {
+		return ErrConsoleQueriesRequired
+	}
 	normalizedAccountID := strings.TrimSpace(accountID)
 	if normalizedAccountID == "" {
-		return
+		return nil
 	}
+	_, err := a.queries.DeleteConsoleSessionsByAccount(ctx, normalizedAccountID)
+	return err
+}
 
-	a.sessionMu.Lock()
-	defer a.sessionMu.Unlock()
+func (a *ConsoleAuth) now() time.Time {
+	if a != nil && a.nowFn != nil {
+		return a.nowFn()
+	}
+	return time.Now()
+}
 
-	for sessionID, sessionState := range a.sessions {
-		if strings.TrimSpace(sessionState.Account.AccountID) == normalizedAccountID {
-			delete(a.sessions, sessionID)
-		}
+func hashSessionToken(sessionToken string) string {
+	return sha256Hex(sessionToken)
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func sessionClientInfoFromRequest(c *gin.Context) sessionClientInfo {
+	if c == nil || c.Request == nil {
+		return sessionClientInfo{}
+	}
+	userAgent := strings.TrimSpace(c.Request.UserAgent())
+	if len(userAgent) > maxSessionUserAgentBytes {
+		userAgent = strings.ToValidUTF8(userAgent[:maxSessionUserAgentBytes], "")
+	}
+	return sessionClientInfo{
+		UserAgent: userAgent,
+		IPAddress: strings.TrimSpace(c.ClientIP()),
 	}
 }
 
+// setSessionCookie issues the session cookie together with the readable CSRF
+// cookie the dashboard echoes back in the X-CSRF-Token header.
 func (a *ConsoleAuth) setSessionCookie(c *gin.Context, sessionID string, expiresAt time.Time) {
+	secure := requestIsTLS(c.Request)
 	http.SetCookie(c.Writer, &http.Cookie{
 		Name:     dashboardSessionCookieName,
 		Value:    sessionID,
 		Path:     "/",
 		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   dashboardSessionMaxAgeSec,
+		Expires:  expiresAt,
+		Secure:   secure,
+	})
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     dashboardCSRFCookieName,
+		Value:    csrfTokenForSession(sessionID),
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
 		MaxAge:   dashboardSessionMaxAgeSec,
 		Expires:  expiresAt,
-		Secure:   requestIsTLS(c.Request),
+		Secure:   secure,
 	})
 }
 
 func (a *ConsoleAuth) clearSessionCookie(c *gin.Context) {
+	secure := requestIsTLS(c.Request)
 	http.SetCookie(c.Writer, &http.Cookie{
 		Name:     dashboardSessionCookieName,
 		Value:    "",
 		Path:     "/",
 		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+		Secure:   secure,
+	})
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     dashboardCSRFCookieName,
+		Value:    "",
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
 		MaxAge:   -1,
 		Expires:  time.Unix(0, 0),
-		Secure:   requestIsTLS(c.Request),
+		Secure:   secure,
 	})
 }
 
+func setRequestSessionID(c *gin.Context, sessionID string) {
+	if c == nil {
+		return
+	}
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return
+	}
+	c.Set(requestSessionIDGinKey, sessionID)
+}
+
+func requestSessionIDFromGin(c *gin.Context) string {
+	if c == nil {
+		return ""
+	}
+	value, ok := c.Get(requestSessionIDGinKey)
+	if !ok {
+		return ""
+	}
+	sessionID, _ := value.(string)
+	return strings.TrimSpace(sessionID)
+}
+
 func setRequestSessionAccount(c *gin.Context, account SessionAccount) {
 	if c == nil {
 		return
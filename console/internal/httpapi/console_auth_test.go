@@ -14,6 +14,7 @@ import (
 
 	"github.com/onlyboxes/onlyboxes/console/internal/buildinfo"
 	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
 	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
 )
 
@@ -100,7 +101,7 @@ func TestInitializeAdminAccountPersistsOnFirstRun(t *testing.T) {
 		_ = db.Close()
 	}()
 
-	result, err := InitializeAdminAccount(ctx, db.Queries, "", "")
+	result, err := InitializeAdminAccount(ctx, db.Queries, "", "", DefaultPasswordPolicy())
 	if err != nil {
 		t.Fatalf("initialize admin account: %v", err)
 	}
@@ -145,7 +146,7 @@ func TestInitializeAdminAccountLoadsPersistedAndIgnoresEnv(t *testing.T) {
 		_ = db.Close()
 	}()
 
-	first, err := InitializeAdminAccount(ctx, db.Queries, "admin-first", "password-first")
+	first, err := InitializeAdminAccount(ctx, db.Queries, "admin-first", "password-first", DefaultPasswordPolicy())
 	if err != nil {
 		t.Fatalf("first initialize admin account: %v", err)
 	}
@@ -153,7 +154,7 @@ func TestInitializeAdminAccountLoadsPersistedAndIgnoresEnv(t *testing.T) {
 		t.Fatalf("expected first initialization")
 	}
 
-	second, err := InitializeAdminAccount(ctx, db.Queries, "admin-second", "password-second")
+	second, err := InitializeAdminAccount(ctx, db.Queries, "admin-second", "password-second", DefaultPasswordPolicy())
 	if err != nil {
 		t.Fatalf("second initialize admin account: %v", err)
 	}
@@ -198,7 +199,7 @@ func TestInitializeAdminAccountRetriesOnAccountIDConflict(t *testing.T) {
 		accountIDGenerator = previousGenerator
 	})
 
-	result, err := InitializeAdminAccount(ctx, db.Queries, "admin-retry", "password-retry")
+	result, err := InitializeAdminAccount(ctx, db.Queries, "admin-retry", "password-retry", DefaultPasswordPolicy())
 	if err != nil {
 		t.Fatalf("initialize admin account with account_id conflict retry: %v", err)
 	}
@@ -219,7 +220,7 @@ func TestInitializeAdminAccountReturnsConflictOnUsernameKeyCollision(t *testing.
 
 	seedTestAccount(t, db.Queries, "acc-existing", "admin-dup", "seed-pass", false)
 
-	_, err := InitializeAdminAccount(ctx, db.Queries, "ADMIN-dup", "password-new")
+	_, err := InitializeAdminAccount(ctx, db.Queries, "ADMIN-dup", "password-new", DefaultPasswordPolicy())
 	if !errors.Is(err, errAccountRegistrationConflict) {
 		t.Fatalf("expected errAccountRegistrationConflict, got %v", err)
 	}
@@ -241,7 +242,7 @@ func TestConsoleAuthLoginLogoutLifecycle(t *testing.T) {
 	sessionCookie := loginSessionCookie(t, router)
 
 	listReq := httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil)
-	listReq.AddCookie(sessionCookie)
+	addSessionCookie(listReq, sessionCookie)
 	listRec := httptest.NewRecorder()
 	router.ServeHTTP(listRec, listReq)
 	if listRec.Code != http.StatusOK {
@@ -249,7 +250,7 @@ func TestConsoleAuthLoginLogoutLifecycle(t *testing.T) {
 	}
 
 	logoutReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/logout", nil)
-	logoutReq.AddCookie(sessionCookie)
+	addSessionCookie(logoutReq, sessionCookie)
 	logoutRec := httptest.NewRecorder()
 	router.ServeHTTP(logoutRec, logoutReq)
 	if logoutRec.Code != http.StatusNoContent {
@@ -257,7 +258,7 @@ func TestConsoleAuthLoginLogoutLifecycle(t *testing.T) {
 	}
 
 	listAfterLogoutReq := httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil)
-	listAfterLogoutReq.AddCookie(sessionCookie)
+	addSessionCookie(listAfterLogoutReq, sessionCookie)
 	listAfterLogoutRec := httptest.NewRecorder()
 	router.ServeHTTP(listAfterLogoutRec, listAfterLogoutReq)
 	if listAfterLogoutRec.Code != http.StatusUnauthorized {
@@ -272,7 +273,7 @@ func TestConsoleAuthSessionEndpoint(t *testing.T) {
 	cookie := loginSessionCookie(t, router)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/console/session", nil)
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
 	if rec.Code != http.StatusOK {
@@ -313,7 +314,7 @@ func TestConsoleAuthSessionExpires(t *testing.T) {
 	now = now.Add(dashboardSessionTTL + time.Second)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil)
-	req.AddCookie(sessionCookie)
+	addSessionCookie(req, sessionCookie)
 	rec := httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
 
@@ -331,7 +332,7 @@ func TestConsoleAuthRegisterAndAdminGuard(t *testing.T) {
 	registerBody := []byte(`{"username":"member-a","password":"member-a-pass"}`)
 	registerReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/register", bytes.NewReader(registerBody))
 	registerReq.Header.Set("Content-Type", "application/json")
-	registerReq.AddCookie(adminCookie)
+	addSessionCookie(registerReq, adminCookie)
 	registerRec := httptest.NewRecorder()
 	router.ServeHTTP(registerRec, registerReq)
 	if registerRec.Code != http.StatusCreated {
@@ -341,7 +342,7 @@ func TestConsoleAuthRegisterAndAdminGuard(t *testing.T) {
 	nonAdminCookie := loginSessionCookieFor(t, router, "member-a", "member-a-pass")
 
 	workersReq := httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil)
-	workersReq.AddCookie(nonAdminCookie)
+	addSessionCookie(workersReq, nonAdminCookie)
 	workersRec := httptest.NewRecorder()
 	router.ServeHTTP(workersRec, workersReq)
 	if workersRec.Code != http.StatusOK {
@@ -350,7 +351,7 @@ func TestConsoleAuthRegisterAndAdminGuard(t *testing.T) {
 
 	nonAdminRegisterReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/register", bytes.NewReader(registerBody))
 	nonAdminRegisterReq.Header.Set("Content-Type", "application/json")
-	nonAdminRegisterReq.AddCookie(nonAdminCookie)
+	addSessionCookie(nonAdminRegisterReq, nonAdminCookie)
 	nonAdminRegisterRec := httptest.NewRecorder()
 	router.ServeHTTP(nonAdminRegisterRec, nonAdminRegisterReq)
 	if nonAdminRegisterRec.Code != http.StatusForbidden {
@@ -366,7 +367,7 @@ func TestConsoleAuthRegisterDuplicateUsernameConflict(t *testing.T) {
 
 	registerReqA := httptest.NewRequest(http.MethodPost, "/api/v1/console/register", strings.NewReader(`{"username":"member-dup","password":"member-pass"}`))
 	registerReqA.Header.Set("Content-Type", "application/json")
-	registerReqA.AddCookie(adminCookie)
+	addSessionCookie(registerReqA, adminCookie)
 	registerRecA := httptest.NewRecorder()
 	router.ServeHTTP(registerRecA, registerReqA)
 	if registerRecA.Code != http.StatusCreated {
@@ -375,7 +376,7 @@ func TestConsoleAuthRegisterDuplicateUsernameConflict(t *testing.T) {
 
 	registerReqB := httptest.NewRequest(http.MethodPost, "/api/v1/console/register", strings.NewReader(`{"username":"MEMBER-dup","password":"member-pass-2"}`))
 	registerReqB.Header.Set("Content-Type", "application/json")
-	registerReqB.AddCookie(adminCookie)
+	addSessionCookie(registerReqB, adminCookie)
 	registerRecB := httptest.NewRecorder()
 	router.ServeHTTP(registerRecB, registerReqB)
 	if registerRecB.Code != http.StatusConflict {
@@ -394,7 +395,7 @@ func TestConsoleAuthRegisterDisabled(t *testing.T) {
 
 	registerReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/register", strings.NewReader(`{"username":"member-x","password":"pass"}`))
 	registerReq.Header.Set("Content-Type", "application/json")
-	registerReq.AddCookie(adminCookie)
+	addSessionCookie(registerReq, adminCookie)
 	registerRec := httptest.NewRecorder()
 	router.ServeHTTP(registerRec, registerReq)
 	if registerRec.Code != http.StatusForbidden {
@@ -410,7 +411,7 @@ func TestConsoleAuthChangePasswordLifecycle(t *testing.T) {
 
 	changeReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/password", strings.NewReader(`{"current_password":"password-test","new_password":"password-next"}`))
 	changeReq.Header.Set("Content-Type", "application/json")
-	changeReq.AddCookie(originalCookie)
+	addSessionCookie(changeReq, originalCookie)
 	changeRec := httptest.NewRecorder()
 	router.ServeHTTP(changeRec, changeReq)
 	if changeRec.Code != http.StatusNoContent {
@@ -432,7 +433,7 @@ func TestConsoleAuthChangePasswordLifecycle(t *testing.T) {
 	}
 
 	oldSessionReq := httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil)
-	oldSessionReq.AddCookie(originalCookie)
+	addSessionCookie(oldSessionReq, originalCookie)
 	oldSessionRec := httptest.NewRecorder()
 	router.ServeHTTP(oldSessionRec, oldSessionReq)
 	if oldSessionRec.Code != http.StatusUnauthorized {
@@ -449,7 +450,7 @@ func TestConsoleAuthChangePasswordLifecycle(t *testing.T) {
 
 	newPasswordCookie := loginSessionCookieFor(t, router, "admin-test", "password-next")
 	newSessionReq := httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil)
-	newSessionReq.AddCookie(newPasswordCookie)
+	addSessionCookie(newSessionReq, newPasswordCookie)
 	newSessionRec := httptest.NewRecorder()
 	router.ServeHTTP(newSessionRec, newSessionReq)
 	if newSessionRec.Code != http.StatusOK {
@@ -465,7 +466,7 @@ func TestConsoleAuthChangePasswordValidationAndCurrentPassword(t *testing.T) {
 
 	missingCurrentReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/password", strings.NewReader(`{"new_password":"password-next"}`))
 	missingCurrentReq.Header.Set("Content-Type", "application/json")
-	missingCurrentReq.AddCookie(cookie)
+	addSessionCookie(missingCurrentReq, cookie)
 	missingCurrentRec := httptest.NewRecorder()
 	router.ServeHTTP(missingCurrentRec, missingCurrentReq)
 	if missingCurrentRec.Code != http.StatusBadRequest {
@@ -477,7 +478,7 @@ func TestConsoleAuthChangePasswordValidationAndCurrentPassword(t *testing.T) {
 
 	invalidCurrentReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/password", strings.NewReader(`{"current_password":"wrong-pass","new_password":"password-next"}`))
 	invalidCurrentReq.Header.Set("Content-Type", "application/json")
-	invalidCurrentReq.AddCookie(cookie)
+	addSessionCookie(invalidCurrentReq, cookie)
 	invalidCurrentRec := httptest.NewRecorder()
 	router.ServeHTTP(invalidCurrentRec, invalidCurrentReq)
 	if invalidCurrentRec.Code != http.StatusUnauthorized {
@@ -497,7 +498,7 @@ func TestConsoleAuthListAccountsAdminOnlyAndPagination(t *testing.T) {
 	for _, username := range []string{"member-a", "member-b"} {
 		registerReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/register", strings.NewReader(`{"username":"`+username+`","password":"member-pass"}`))
 		registerReq.Header.Set("Content-Type", "application/json")
-		registerReq.AddCookie(adminCookie)
+		addSessionCookie(registerReq, adminCookie)
 		registerRec := httptest.NewRecorder()
 		router.ServeHTTP(registerRec, registerReq)
 		if registerRec.Code != http.StatusCreated {
@@ -506,7 +507,7 @@ func TestConsoleAuthListAccountsAdminOnlyAndPagination(t *testing.T) {
 	}
 
 	listReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/accounts?page=1&page_size=2", nil)
-	listReq.AddCookie(adminCookie)
+	addSessionCookie(listReq, adminCookie)
 	listRec := httptest.NewRecorder()
 	router.ServeHTTP(listRec, listReq)
 	if listRec.Code != http.StatusOK {
@@ -529,7 +530,7 @@ func TestConsoleAuthListAccountsAdminOnlyAndPagination(t *testing.T) {
 
 	nonAdminCookie := loginSessionCookieFor(t, router, "member-a", "member-pass")
 	nonAdminListReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/accounts", nil)
-	nonAdminListReq.AddCookie(nonAdminCookie)
+	addSessionCookie(nonAdminListReq, nonAdminCookie)
 	nonAdminListRec := httptest.NewRecorder()
 	router.ServeHTTP(nonAdminListRec, nonAdminListReq)
 	if nonAdminListRec.Code != http.StatusForbidden {
@@ -560,7 +561,7 @@ func TestConsoleAuthDeleteAccountGuardsAndCascade(t *testing.T) {
 
 	registerReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/register", strings.NewReader(`{"username":"member-to-delete","password":"member-pass"}`))
 	registerReq.Header.Set("Content-Type", "application/json")
-	registerReq.AddCookie(adminCookie)
+	addSessionCookie(registerReq, adminCookie)
 	registerRec := httptest.NewRecorder()
 	router.ServeHTTP(registerRec, registerReq)
 	if registerRec.Code != http.StatusCreated {
@@ -582,7 +583,7 @@ func TestConsoleAuthDeleteAccountGuardsAndCascade(t *testing.T) {
 	memberCookie := loginSessionCookieFor(t, router, "member-to-delete", "member-pass")
 
 	deleteSelfReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/accounts/"+testDashboardAccountID, nil)
-	deleteSelfReq.AddCookie(adminCookie)
+	addSessionCookie(deleteSelfReq, adminCookie)
 	deleteSelfRec := httptest.NewRecorder()
 	router.ServeHTTP(deleteSelfRec, deleteSelfReq)
 	if deleteSelfRec.Code != http.StatusForbidden {
@@ -590,7 +591,7 @@ func TestConsoleAuthDeleteAccountGuardsAndCascade(t *testing.T) {
 	}
 
 	deleteAdminReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/accounts/acc-admin-second", nil)
-	deleteAdminReq.AddCookie(adminCookie)
+	addSessionCookie(deleteAdminReq, adminCookie)
 	deleteAdminRec := httptest.NewRecorder()
 	router.ServeHTTP(deleteAdminRec, deleteAdminReq)
 	if deleteAdminRec.Code != http.StatusForbidden {
@@ -598,7 +599,7 @@ func TestConsoleAuthDeleteAccountGuardsAndCascade(t *testing.T) {
 	}
 
 	deleteMissingReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/accounts/acc-missing", nil)
-	deleteMissingReq.AddCookie(adminCookie)
+	addSessionCookie(deleteMissingReq, adminCookie)
 	deleteMissingRec := httptest.NewRecorder()
 	router.ServeHTTP(deleteMissingRec, deleteMissingReq)
 	if deleteMissingRec.Code != http.StatusNotFound {
@@ -606,7 +607,7 @@ func TestConsoleAuthDeleteAccountGuardsAndCascade(t *testing.T) {
 	}
 
 	deleteMemberReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/accounts/"+memberID, nil)
-	deleteMemberReq.AddCookie(adminCookie)
+	addSessionCookie(deleteMemberReq, adminCookie)
 	deleteMemberRec := httptest.NewRecorder()
 	router.ServeHTTP(deleteMemberRec, deleteMemberReq)
 	if deleteMemberRec.Code != http.StatusNoContent {
@@ -614,7 +615,7 @@ func TestConsoleAuthDeleteAccountGuardsAndCascade(t *testing.T) {
 	}
 
 	memberSessionReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/tokens", nil)
-	memberSessionReq.AddCookie(memberCookie)
+	addSessionCookie(memberSessionReq, memberCookie)
 	memberSessionRec := httptest.NewRecorder()
 	router.ServeHTTP(memberSessionRec, memberSessionReq)
 	if memberSessionRec.Code != http.StatusUnauthorized {
@@ -638,6 +639,193 @@ func TestConsoleAuthDeleteAccountGuardsAndCascade(t *testing.T) {
 	}
 }
 
+func TestConsoleAuthSessionSurvivesRestart(t *testing.T) {
+	db := openTestAuthDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	firstAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	sessionCookie := loginSessionCookie(t, mustNewRouter(t, handler, firstAuth, newTestMCPAuth(t)))
+
+	secondAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth after restart: %v", err)
+	}
+	router := mustNewRouter(t, handler, secondAuth, newTestMCPAuth(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/console/session", nil)
+	addSessionCookie(req, sessionCookie)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected session to survive restart, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	records, err := db.Queries.ListConsoleSessionsByAccount(context.Background(), sqlc.ListConsoleSessionsByAccountParams{
+		AccountID:       testDashboardAccountID,
+		ExpiresAtUnixMs: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("list persisted sessions: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected 1 persisted session, got %d", len(records))
+	}
+	if records[0].SessionID == sessionCookie.Value || !strings.HasPrefix(records[0].SessionID, dashboardSessionIDPrefix) {
+		t.Fatalf("expected public session id distinct from cookie value, got %q", records[0].SessionID)
+	}
+}
+
+func TestConsoleAuthListAndRevokeSessions(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	auth := newTestConsoleAuth(t)
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+
+	laptopBody := `{"username":"` + testDashboardUsername + `","password":"` + testDashboardPassword + `"}`
+	laptopReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/login", strings.NewReader(laptopBody))
+	laptopReq.Header.Set("Content-Type", "application/json")
+	laptopReq.Header.Set("User-Agent", "laptop-browser")
+	laptopRec := httptest.NewRecorder()
+	router.ServeHTTP(laptopRec, laptopReq)
+	if laptopRec.Code != http.StatusOK {
+		t.Fatalf("expected laptop login success, got %d body=%s", laptopRec.Code, laptopRec.Body.String())
+	}
+	var laptopCookie *http.Cookie
+	for _, cookie := range laptopRec.Result().Cookies() {
+		if cookie.Name == dashboardSessionCookieName {
+			laptopCookie = cookie
+		}
+	}
+	if laptopCookie == nil {
+		t.Fatalf("expected laptop session cookie")
+	}
+	phoneCookie := loginSessionCookie(t, router)
+
+	listReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/sessions", nil)
+	addSessionCookie(listReq, phoneCookie)
+	listRec := httptest.NewRecorder()
+	router.ServeHTTP(listRec, listReq)
+	if listRec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for session list, got %d body=%s", listRec.Code, listRec.Body.String())
+	}
+	var listPayload consoleSessionListResponse
+	if err := json.Unmarshal(listRec.Body.Bytes(), &listPayload); err != nil {
+		t.Fatalf("decode session list: %v", err)
+	}
+	if listPayload.Total != 2 || len(listPayload.Items) != 2 {
+		t.Fatalf("expected 2 sessions, got %#v", listPayload)
+	}
+	laptopSessionID := ""
+	currentCount := 0
+	for _, item := range listPayload.Items {
+		if item.Current {
+			currentCount++
+		}
+		if item.UserAgent == "laptop-browser" {
+			laptopSessionID = item.SessionID
+			if item.Current {
+				t.Fatalf("expected laptop session not to be current")
+			}
+		}
+		if item.IPAddress == "" {
+			t.Fatalf("expected session ip_address to be recorded: %#v", item)
+		}
+	}
+	if currentCount != 1 || laptopSessionID == "" {
+		t.Fatalf("unexpected session list payload: %#v", listPayload.Items)
+	}
+
+	revokeReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/sessions/"+laptopSessionID, nil)
+	addSessionCookie(revokeReq, phoneCookie)
+	revokeRec := httptest.NewRecorder()
+	router.ServeHTTP(revokeRec, revokeReq)
+	if revokeRec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for session revoke, got %d body=%s", revokeRec.Code, revokeRec.Body.String())
+	}
+
+	revokeAgainReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/sessions/"+laptopSessionID, nil)
+	addSessionCookie(revokeAgainReq, phoneCookie)
+	revokeAgainRec := httptest.NewRecorder()
+	router.ServeHTTP(revokeAgainRec, revokeAgainReq)
+	if revokeAgainRec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for revoked session, got %d body=%s", revokeAgainRec.Code, revokeAgainRec.Body.String())
+	}
+
+	laptopCheckReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/session", nil)
+	addSessionCookie(laptopCheckReq, laptopCookie)
+	laptopCheckRec := httptest.NewRecorder()
+	router.ServeHTTP(laptopCheckRec, laptopCheckReq)
+	if laptopCheckRec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected revoked laptop session to be rejected, got %d body=%s", laptopCheckRec.Code, laptopCheckRec.Body.String())
+	}
+
+	phoneCheckReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/session", nil)
+	addSessionCookie(phoneCheckReq, phoneCookie)
+	phoneCheckRec := httptest.NewRecorder()
+	router.ServeHTTP(phoneCheckRec, phoneCheckReq)
+	if phoneCheckRec.Code != http.StatusOK {
+		t.Fatalf("expected current session to remain valid, got %d body=%s", phoneCheckRec.Code, phoneCheckRec.Body.String())
+	}
+}
+
+func TestConsoleAuthAdminLogsOutAccountEverywhere(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	auth := newTestConsoleAuth(t)
+	seedTestAccount(t, auth.queries, "acc-member-sessions", "member-sessions", "member-pass", false)
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+	adminCookie := loginSessionCookie(t, router)
+	memberCookieA := loginSessionCookieFor(t, router, "member-sessions", "member-pass")
+	memberCookieB := loginSessionCookieFor(t, router, "member-sessions", "member-pass")
+
+	memberRevokeReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/accounts/"+testDashboardAccountID+"/sessions", nil)
+	addSessionCookie(memberRevokeReq, memberCookieA)
+	memberRevokeRec := httptest.NewRecorder()
+	router.ServeHTTP(memberRevokeRec, memberRevokeReq)
+	if memberRevokeRec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin logout-everywhere, got %d body=%s", memberRevokeRec.Code, memberRevokeRec.Body.String())
+	}
+
+	missingReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/accounts/acc-missing/sessions", nil)
+	addSessionCookie(missingReq, adminCookie)
+	missingRec := httptest.NewRecorder()
+	router.ServeHTTP(missingRec, missingReq)
+	if missingRec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing account, got %d body=%s", missingRec.Code, missingRec.Body.String())
+	}
+
+	revokeReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/accounts/acc-member-sessions/sessions", nil)
+	addSessionCookie(revokeReq, adminCookie)
+	revokeRec := httptest.NewRecorder()
+	router.ServeHTTP(revokeRec, revokeReq)
+	if revokeRec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for logout-everywhere, got %d body=%s", revokeRec.Code, revokeRec.Body.String())
+	}
+
+	for _, cookie := range []*http.Cookie{memberCookieA, memberCookieB} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/console/session", nil)
+		addSessionCookie(req, cookie)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected member session to be revoked, got %d body=%s", rec.Code, rec.Body.String())
+		}
+	}
+
+	adminReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/session", nil)
+	addSessionCookie(adminReq, adminCookie)
+	adminRec := httptest.NewRecorder()
+	router.ServeHTTP(adminRec, adminReq)
+	if adminRec.Code != http.StatusOK {
+		t.Fatalf("expected admin session to remain valid, got %d body=%s", adminRec.Code, adminRec.Body.String())
+	}
+}
+
 func loginSessionCookieFor(t *testing.T, router http.Handler, username string, password string) *http.Cookie {
 	t.Helper()
 	body, err := json.Marshal(loginRequest{Username: username, Password: password})
@@ -0,0 +1,353 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+const (
+	loginThrottleScopeIP       = "ip"
+	loginThrottleScopeUsername = "username"
+
+	loginLockoutEventLocked   = "locked"
+	loginLockoutEventUnlocked = "unlocked"
+)
+
+var (
+	errLoginThrottled            = errors.New("too many failed login attempts, retry later")
+	errLoginLockoutScopeInvalid  = errors.New("scope must be one of: ip, username")
+	errLoginLockoutSubjectNeeded = errors.New("subject is required")
+	errLoginLockoutNotFound      = errors.New("login lockout not found")
+)
+
+// loginThrottleRule bounds failed logins for one subject (client IP or username)
+// inside the sliding window of loginThrottlePolicy.
+type loginThrottleRule struct {
+	// FreeFailures is the number of failures tolerated before backoff applies.
+	FreeFailures int64
+	// LockoutFailures is the number of failures that triggers a lockout.
+	LockoutFailures int64
+	BaseBackoff     time.Duration
+	MaxBackoff      time.Duration
+	LockoutDuration time.Duration
+}
+
+type loginThrottlePolicy struct {
+	Window   time.Duration
+	IP       loginThrottleRule
+	Username loginThrottleRule
+}
+
+var defaultLoginThrottlePolicy = loginThrottlePolicy{
+	Window: 15 * time.Minute,
+	IP: loginThrottleRule{
+		FreeFailures:    10,
+		LockoutFailures: 50,
+		BaseBackoff:     time.Second,
+		MaxBackoff:      time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	},
+	Username: loginThrottleRule{
+		FreeFailures:    3,
+		LockoutFailures: 10,
+		BaseBackoff:     time.Second,
+		MaxBackoff:      time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	},
+}
+
+type loginThrottleSubject struct {
+	Scope string
+	Key   string
+	Rule  loginThrottleRule
+}
+
+type loginLockoutItem struct {
+	Scope        string    `json:"scope"`
+	Subject      string    `json:"subject"`
+	FailureCount int64     `json:"failure_count"`
+	LockedAt     time.Time `json:"locked_at"`
+	LockedUntil  time.Time `json:"locked_until"`
+}
+
+type loginLockoutListResponse struct {
+	Items []loginLockoutItem `json:"items"`
+	Total int                `json:"total"`
+}
+
+type unlockLoginRequest struct {
+	Scope   string `json:"scope"`
+	Subject string `json:"subject"`
+}
+
+func (p loginThrottlePolicy) subjects(clientIP string, username string) []loginThrottleSubject {
+	subjects := make([]loginThrottleSubject, 0, 2)
+	if key := normalizeLoginThrottleKey(loginThrottleScopeIP, clientIP); key != "" {
+		subjects = append(subjects, loginThrottleSubject{Scope: loginThrottleScopeIP, Key: key, Rule: p.IP})
+	}
+	if key := normalizeLoginThrottleKey(loginThrottleScopeUsername, username); key != "" {
+		subjects = append(subjects, loginThrottleSubject{Scope: loginThrottleScopeUsername, Key: key, Rule: p.Username})
+	}
+	return subjects
+}
+
+func normalizeLoginThrottleKey(scope string, subject string) string {
+	trimmed := strings.TrimSpace(subject)
+	if scope == loginThrottleScopeUsername {
+		return strings.ToLower(trimmed)
+	}
+	return trimmed
+}
+
+func normalizeLoginThrottleScope(scope string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(scope)) {
+	case loginThrottleScopeIP:
+		return loginThrottleScopeIP, true
+	case loginThrottleScopeUsername:
+		return loginThrottleScopeUsername, true
+	default:
+		return "", false
+	}
+}
+
+// loginRetryAfter returns how long the caller must wait before the next login
+// attempt is evaluated. Zero means the attempt may proceed.
+func (a *ConsoleAuth) loginRetryAfter(ctx context.Context, subjects []loginThrottleSubject, now time.Time) (time.Duration, error) {
+	var retryAfter time.Duration
+	for _, subject := range subjects {
+		lockout, err := a.queries.GetLoginLockout(ctx, sqlc.GetLoginLockoutParams{
+			Scope:      subject.Scope,
+			SubjectKey: subject.Key,
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, err
+		}
+		if err == nil {
+			if wait := time.UnixMilli(lockout.LockedUntilUnixMs).Sub(now); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+
+		failures, err := a.queries.CountLoginFailuresSince(ctx, sqlc.CountLoginFailuresSinceParams{
+			Scope:          subject.Scope,
+			SubjectKey:     subject.Key,
+			FailedAtUnixMs: now.Add(-a.loginThrottle.Window).UnixMilli(),
+		})
+		if err != nil {
+			return 0, err
+		}
+		backoff := loginBackoff(subject.Rule, failures)
+		if backoff <= 0 {
+			continue
+		}
+		latestFailureMS, err := a.queries.GetLatestLoginFailureAt(ctx, sqlc.GetLatestLoginFailureAtParams{
+			Scope:      subject.Scope,
+			SubjectKey: subject.Key,
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return 0, err
+		}
+		if wait := time.UnixMilli(latestFailureMS).Add(backoff).Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	return retryAfter, nil
+}
+
+// loginBackoff doubles the enforced delay for every failure past FreeFailures.
+func loginBackoff(rule loginThrottleRule, failures int64) time.Duration {
+	excess := failures - rule.FreeFailures
+	if excess <= 0 || rule.BaseBackoff <= 0 {
+		return 0
+	}
+	exponent := float64(excess - 1)
+	backoff := time.Duration(float64(rule.BaseBackoff) * math.Pow(2, exponent))
+	if backoff <= 0 || (rule.MaxBackoff > 0 && backoff > rule.MaxBackoff) {
+		return rule.MaxBackoff
+	}
+	return backoff
+}
+
+func (a *ConsoleAuth) recordLoginFailure(ctx context.Context, subjects []loginThrottleSubject, clientIP string, now time.Time) error {
+	windowStartMS := now.Add(-a.loginThrottle.Window).UnixMilli()
+	if _, err := a.queries.DeleteLoginFailuresBefore(ctx, windowStartMS); err != nil {
+		return err
+	}
+	if _, err := a.queries.DeleteExpiredLoginLockouts(ctx, now.UnixMilli()); err != nil {
+		return err
+	}
+
+	for _, subject := range subjects {
+		if err := a.queries.InsertLoginFailure(ctx, sqlc.InsertLoginFailureParams{
+			Scope:          subject.Scope,
+			SubjectKey:     subject.Key,
+			FailedAtUnixMs: now.UnixMilli(),
+		}); err != nil {
+			return err
+		}
+		failures, err := a.queries.CountLoginFailuresSince(ctx, sqlc.CountLoginFailuresSinceParams{
+			Scope:          subject.Scope,
+			SubjectKey:     subject.Key,
+			FailedAtUnixMs: windowStartMS,
+		})
+		if err != nil {
+			return err
+		}
+		if subject.Rule.LockoutFailures <= 0 || failures < subject.Rule.LockoutFailures {
+			continue
+		}
+
+		lockedUntil := now.Add(subject.Rule.LockoutDuration)
+		if err := a.queries.UpsertLoginLockout(ctx, sqlc.UpsertLoginLockoutParams{
+			Scope:             subject.Scope,
+			SubjectKey:        subject.Key,
+			FailureCount:      failures,
+			LockedAtUnixMs:    now.UnixMilli(),
+			LockedUntilUnixMs: lockedUntil.UnixMilli(),
+		}); err != nil {
+			return err
+		}
+		if err := a.queries.InsertLoginLockoutEvent(ctx, sqlc.InsertLoginLockoutEventParams{
+			EventType:       loginLockoutEventLocked,
+			Scope:           subject.Scope,
+			SubjectKey:      subject.Key,
+			FailureCount:    failures,
+			IpAddress:       strings.TrimSpace(clientIP),
+			ActorAccountID:  "",
+			CreatedAtUnixMs: now.UnixMilli(),
+		}); err != nil {
+			return err
+		}
+		slog.Warn("console login locked out",
+			"scope", subject.Scope,
+			"subject", subject.Key,
+			"failure_count", failures,
+			"locked_until", lockedUntil,
+			"client_ip", clientIP,
+		)
+	}
+	return nil
+}
+
+func (a *ConsoleAuth) clearLoginFailures(ctx context.Context, scope string, subjectKey string) (int64, error) {
+	return a.queries.DeleteLoginFailuresBySubject(ctx, sqlc.DeleteLoginFailuresBySubjectParams{
+		Scope:      scope,
+		SubjectKey: subjectKey,
+	})
+}
+
+func writeLoginThrottled(c *gin.Context, retryAfter time.Duration) {
+	retryAfterSec := int64(math.Ceil(retryAfter.Seconds()))
+	if retryAfterSec < 1 {
+		retryAfterSec = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(retryAfterSec, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":           errLoginThrottled.Error(),
+		"retry_after_sec": retryAfterSec,
+	})
+}
+
+func (a *ConsoleAuth) ListLoginLockouts(c *gin.Context) {
+	records, err := a.queries.ListActiveLoginLockouts(c.Request.Context(), a.now().UnixMilli())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list login lockouts"})
+		return
+	}
+
+	items := make([]loginLockoutItem, 0, len(records))
+	for _, record := range records {
+		items = append(items, loginLockoutItem{
+			Scope:        record.Scope,
+			Subject:      record.SubjectKey,
+			FailureCount: record.FailureCount,
+			LockedAt:     time.UnixMilli(record.LockedAtUnixMs),
+			LockedUntil:  time.UnixMilli(record.LockedUntilUnixMs),
+		})
+	}
+	c.JSON(http.StatusOK, loginLockoutListResponse{
+		Items: items,
+		Total: len(items),
+	})
+}
+
+func (a *ConsoleAuth) UnlockLogin(c *gin.Context) {
+	currentAccount, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+
+	var req unlockLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	scope, ok := normalizeLoginThrottleScope(req.Scope)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errLoginLockoutScopeInvalid.Error()})
+		return
+	}
+	subjectKey := normalizeLoginThrottleKey(scope, req.Subject)
+	if subjectKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errLoginLockoutSubjectNeeded.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	deletedLockouts, err := a.queries.DeleteLoginLockout(ctx, sqlc.DeleteLoginLockoutParams{
+		Scope:      scope,
+		SubjectKey: subjectKey,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock login"})
+		return
+	}
+	deletedFailures, err := a.clearLoginFailures(ctx, scope, subjectKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock login"})
+		return
+	}
+	if deletedLockouts == 0 && deletedFailures == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errLoginLockoutNotFound.Error()})
+		return
+	}
+
+	if err := a.queries.InsertLoginLockoutEvent(ctx, sqlc.InsertLoginLockoutEventParams{
+		EventType:       loginLockoutEventUnlocked,
+		Scope:           scope,
+		SubjectKey:      subjectKey,
+		FailureCount:    deletedFailures,
+		IpAddress:       strings.TrimSpace(c.ClientIP()),
+		ActorAccountID:  strings.TrimSpace(currentAccount.AccountID),
+		CreatedAtUnixMs: a.now().UnixMilli(),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock login"})
+		return
+	}
+	slog.Info("console login unlocked",
+		"scope", scope,
+		"subject", subjectKey,
+		"actor_account_id", currentAccount.AccountID,
+	)
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionLockoutUnlock,
+		TargetType: "login_lockout",
+		TargetID:   scope + ":" + subjectKey,
+		Details:    map[string]any{"cleared_failures": deletedFailures},
+	})
+	c.Status(http.StatusNoContent)
+}
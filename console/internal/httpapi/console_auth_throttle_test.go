@@ -0,0 +1,238 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func testLoginThrottlePolicy() loginThrottlePolicy {
+	return loginThrottlePolicy{
+		Window: 15 * time.Minute,
+		IP: loginThrottleRule{
+			FreeFailures:    100,
+			LockoutFailures: 1000,
+			BaseBackoff:     time.Second,
+			MaxBackoff:      time.Minute,
+			LockoutDuration: 15 * time.Minute,
+		},
+		Username: loginThrottleRule{
+			FreeFailures:    2,
+			LockoutFailures: 4,
+			BaseBackoff:     time.Second,
+			MaxBackoff:      time.Minute,
+			LockoutDuration: 15 * time.Minute,
+		},
+	}
+}
+
+func postLogin(router http.Handler, username string, password string) *httptest.ResponseRecorder {
+	body := `{"username":"` + username + `","password":"` + password + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/console/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLoginBackoff(t *testing.T) {
+	rule := loginThrottleRule{FreeFailures: 3, BaseBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 3, want: 0},
+		{failures: 4, want: time.Second},
+		{failures: 5, want: 2 * time.Second},
+		{failures: 7, want: 8 * time.Second},
+		{failures: 8, want: 10 * time.Second},
+		{failures: 200, want: 10 * time.Second},
+	}
+	for _, tc := range tests {
+		if got := loginBackoff(rule, tc.failures); got != tc.want {
+			t.Fatalf("loginBackoff(%d)=%s, want %s", tc.failures, got, tc.want)
+		}
+	}
+}
+
+func TestConsoleAuthLoginBackoffAfterFreeFailures(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	auth := newTestConsoleAuth(t)
+	auth.loginThrottle = testLoginThrottlePolicy()
+	now := time.Unix(1_700_000_000, 0)
+	auth.nowFn = func() time.Time {
+		return now
+	}
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+
+	for i := 0; i < 3; i++ {
+		if rec := postLogin(router, "ghost", "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for failure %d, got %d body=%s", i+1, rec.Code, rec.Body.String())
+		}
+	}
+
+	throttledRec := postLogin(router, "GHOST", "wrong")
+	if throttledRec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 during backoff, got %d body=%s", throttledRec.Code, throttledRec.Body.String())
+	}
+	if throttledRec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected Retry-After=1, got %q", throttledRec.Header().Get("Retry-After"))
+	}
+
+	now = now.Add(time.Second)
+	if rec := postLogin(router, "ghost", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after backoff elapsed, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	now = now.Add(time.Second)
+	throttledRec = postLogin(router, "ghost", "wrong")
+	if throttledRec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected doubled backoff, got %d body=%s", throttledRec.Code, throttledRec.Body.String())
+	}
+
+	// Other usernames from the same client are not affected by a per-username backoff.
+	if rec := postLogin(router, testDashboardUsername, testDashboardPassword); rec.Code != http.StatusOK {
+		t.Fatalf("expected unrelated username login to succeed, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestConsoleAuthLockoutPersistsAndAdminUnlock(t *testing.T) {
+	ctx := context.Background()
+	db := openTestAuthDB(t)
+	defer func() {
+		_ = db.Close()
+	}()
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+	seedTestAccount(t, db.Queries, "acc-lockout-member", "lockout-member", "member-pass", false)
+
+	now := time.Unix(1_700_000_000, 0)
+	newRouter := func() http.Handler {
+		auth, err := NewConsoleAuth(db.Queries, false)
+		if err != nil {
+			t.Fatalf("new console auth: %v", err)
+		}
+		auth.loginThrottle = testLoginThrottlePolicy()
+		auth.nowFn = func() time.Time {
+			return now
+		}
+		handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+		return mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+	}
+	router := newRouter()
+
+	for i := 0; i < 4; i++ {
+		if rec := postLogin(router, "lockout-member", "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for failure %d, got %d body=%s", i+1, rec.Code, rec.Body.String())
+		}
+		now = now.Add(time.Minute)
+	}
+
+	// A restarted console keeps the lockout, and even the right password is refused.
+	router = newRouter()
+	lockedRec := postLogin(router, "lockout-member", "member-pass")
+	if lockedRec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 while locked out, got %d body=%s", lockedRec.Code, lockedRec.Body.String())
+	}
+
+	adminCookie := loginSessionCookie(t, router)
+	listReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/login-lockouts", nil)
+	addSessionCookie(listReq, adminCookie)
+	listRec := httptest.NewRecorder()
+	router.ServeHTTP(listRec, listReq)
+	if listRec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for lockout list, got %d body=%s", listRec.Code, listRec.Body.String())
+	}
+	var listPayload loginLockoutListResponse
+	if err := json.Unmarshal(listRec.Body.Bytes(), &listPayload); err != nil {
+		t.Fatalf("decode lockout list: %v", err)
+	}
+	if listPayload.Total != 1 || listPayload.Items[0].Scope != loginThrottleScopeUsername || listPayload.Items[0].Subject != "lockout-member" {
+		t.Fatalf("unexpected lockout list payload: %#v", listPayload)
+	}
+
+	invalidReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/login-lockouts/unlock", strings.NewReader(`{"scope":"account","subject":"x"}`))
+	invalidReq.Header.Set("Content-Type", "application/json")
+	addSessionCookie(invalidReq, adminCookie)
+	invalidRec := httptest.NewRecorder()
+	router.ServeHTTP(invalidRec, invalidReq)
+	if invalidRec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid scope, got %d body=%s", invalidRec.Code, invalidRec.Body.String())
+	}
+
+	unlockReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/login-lockouts/unlock", strings.NewReader(`{"scope":"username","subject":"Lockout-Member"}`))
+	unlockReq.Header.Set("Content-Type", "application/json")
+	addSessionCookie(unlockReq, adminCookie)
+	unlockRec := httptest.NewRecorder()
+	router.ServeHTTP(unlockRec, unlockReq)
+	if unlockRec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for unlock, got %d body=%s", unlockRec.Code, unlockRec.Body.String())
+	}
+
+	if rec := postLogin(router, "lockout-member", "member-pass"); rec.Code != http.StatusOK {
+		t.Fatalf("expected login after unlock, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	var eventTypes []string
+	rows, err := db.SQL.QueryContext(ctx, `SELECT event_type FROM login_lockout_events WHERE subject_key = ? ORDER BY event_id ASC`, "lockout-member")
+	if err != nil {
+		t.Fatalf("query lockout events: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var eventType string
+		if err := rows.Scan(&eventType); err != nil {
+			t.Fatalf("scan lockout event: %v", err)
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+	if strings.Join(eventTypes, ",") != "locked,unlocked" {
+		t.Fatalf("expected locked,unlocked audit events, got %v", eventTypes)
+	}
+}
+
+func TestConsoleAuthIPLockoutIgnoresForgedForwardedFor(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	auth := newTestConsoleAuth(t)
+	policy := testLoginThrottlePolicy()
+	policy.IP = loginThrottleRule{
+		FreeFailures:    2,
+		LockoutFailures: 3,
+		BaseBackoff:     time.Second,
+		MaxBackoff:      time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	}
+	auth.loginThrottle = policy
+	now := time.Unix(1_700_000_000, 0)
+	auth.nowFn = func() time.Time {
+		return now
+	}
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+
+	postForwarded := func(username string, forwardedFor string) *httptest.ResponseRecorder {
+		body := `{"username":"` + username + `","password":"wrong"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/console/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Each attempt uses a new username and a new forged client IP, so only
+	// the per-IP rule of the real peer can stop it.
+	for i := 0; i < 3; i++ {
+		if rec := postForwarded("ghost-"+string(rune('a'+i)), "203.0.113."+string(rune('1'+i))); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for failure %d, got %d body=%s", i+1, rec.Code, rec.Body.String())
+		}
+		now = now.Add(time.Minute)
+	}
+	if rec := postForwarded("ghost-z", "198.51.100.7"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected forged X-Forwarded-For to stay locked out, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
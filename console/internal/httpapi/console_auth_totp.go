@@ -0,0 +1,749 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+const (
+	totpIssuer              = "Onlyboxes"
+	totpSecretByteSize      = 20
+	totpDigits              = 6
+	totpPeriodSec           = 30
+	totpSkewSteps           = 1
+	recoveryCodeCount       = 10
+	recoveryCodeByteSize    = 5
+	loginChallengeByteSize  = 32
+	loginChallengeMaxTries  = 5
+	consoleSettingTOTPGuard = "require_totp"
+)
+
+var (
+	loginChallengeTTL = 5 * time.Minute
+
+	errTOTPAlreadyEnabled      = errors.New("two-factor authentication is already enabled")
+	errTOTPNotEnrolled         = errors.New("two-factor enrollment has not been started")
+	errTOTPNotEnabled          = errors.New("two-factor authentication is not enabled")
+	errTOTPCodeRequired        = errors.New("code is required")
+	errTOTPCodeInvalid         = errors.New("invalid two-factor code")
+	errTOTPRequiredByPolicy    = errors.New("two-factor authentication is required by policy")
+	errTOTPEnrollmentRequired  = errors.New("two-factor enrollment required")
+	errTOTPExternalAccount     = errors.New("two-factor authentication for sso accounts is managed by the identity provider")
+	errLoginChallengeInvalid   = errors.New("invalid or expired mfa_token")
+	errLoginChallengeTokenNeed = errors.New("mfa_token is required")
+
+	totpBase32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	// Routes an account may still reach while an admin policy forces it to enroll.
+	totpEnrollmentExemptRoutes = map[string]struct{}{
+		"/api/v1/console/session":              {},
+		"/api/v1/console/2fa":                  {},
+		"/api/v1/console/2fa/totp/enroll":      {},
+		"/api/v1/console/2fa/totp/verify":      {},
+		"/api/v1/console/sessions":             {},
+		"/api/v1/console/sessions/:session_id": {},
+	}
+)
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type loginSecondFactorRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type loginChallengeResponse struct {
+	Authenticated bool      `json:"authenticated"`
+	MFARequired   bool      `json:"mfa_required"`
+	MFAToken      string    `json:"mfa_token"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type twoFactorStatusResponse struct {
+	TOTPEnabled            bool  `json:"totp_enabled"`
+	TOTPPending            bool  `json:"totp_pending"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+	RequiredByPolicy       bool  `json:"required_by_policy"`
+}
+
+type totpEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type securitySettingsRequest struct {
+	RequireTOTP *bool `json:"require_totp"`
+}
+
+type securitySettingsResponse struct {
+	RequireTOTP bool `json:"require_totp"`
+}
+
+func generateTOTPSecret() (string, error) {
+	buf := make([]byte, totpSecretByteSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpBase32Encoding.EncodeToString(buf), nil
+}
+
+// totpCodeAt implements the HOTP value of RFC 4226 for the RFC 6238 time step.
+func totpCodeAt(secret string, step int64) (string, error) {
+	key, err := totpBase32Encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", fmt.Errorf("decode totp secret: %w", err)
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	_, _ = mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo), nil
+}
+
+func totpStepAt(now time.Time) int64 {
+	return now.Unix() / totpPeriodSec
+}
+
+// matchTOTPCode returns the time step matched by code, allowing totpSkewSteps of clock drift.
+func matchTOTPCode(secret string, code string, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	currentStep := totpStepAt(now)
+	for offset := -totpSkewSteps; offset <= totpSkewSteps; offset++ {
+		step := currentStep + int64(offset)
+		expected, err := totpCodeAt(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func totpProvisioningURI(secret string, username string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", totpIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(totpDigits))
+	query.Set("period", strconv.Itoa(totpPeriodSec))
+	return (&url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + totpIssuer + ":" + strings.TrimSpace(username),
+		RawQuery: query.Encode(),
+	}).String()
+}
+
+func normalizeTwoFactorCode(code string) string {
+	replacer := strings.NewReplacer(" ", "", "-", "")
+	return strings.ToLower(replacer.Replace(strings.TrimSpace(code)))
+}
+
+func isTOTPCodeFormat(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		value, err := randomHex(recoveryCodeByteSize)
+		if err != nil {
+			return nil, err
+		}
+		half := len(value) / 2
+		codes = append(codes, value[:half]+"-"+value[half:])
+	}
+	return codes, nil
+}
+
+func (a *ConsoleAuth) totpRequiredByPolicy(ctx context.Context) (bool, error) {
+	value, err := a.queries.GetConsoleSetting(ctx, consoleSettingTOTPGuard)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return strings.TrimSpace(value) == "true", nil
+}
+
+func (a *ConsoleAuth) accountTOTP(ctx context.Context, accountID string) (sqlc.AccountTotp, bool, error) {
+	record, err := a.queries.GetAccountTOTP(ctx, strings.TrimSpace(accountID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return sqlc.AccountTotp{}, false, nil
+		}
+		return sqlc.AccountTotp{}, false, err
+	}
+	a.upgradeTOTPSecret(ctx, record)
+	secret, err := a.openTOTPSecret(record.AccountID, record.Secret)
+	if err != nil {
+		return sqlc.AccountTotp{}, false, err
+	}
+	record.Secret = secret
+	return record, true, nil
+}
+
+func (a *ConsoleAuth) accountTOTPEnabled(ctx context.Context, accountID string) (bool, error) {
+	record, ok, err := a.accountTOTP(ctx, accountID)
+	if err != nil || !ok {
+		return false, err
+	}
+	return record.Enabled == 1, nil
+}
+
+func (a *ConsoleAuth) totpEnrollmentRequired(ctx context.Context, accountID string) (bool, error) {
+	required, err := a.totpRequiredByPolicy(ctx)
+	if err != nil || !required {
+		return false, err
+	}
+	// SSO accounts rely on the identity provider for multi-factor checks.
+	external, err := a.accountUsesExternalIdentity(ctx, accountID)
+	if err != nil || external {
+		return false, err
+	}
+	enabled, err := a.accountTOTPEnabled(ctx, accountID)
+	if err != nil {
+		return false, err
+	}
+	return !enabled, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery code.
+// Each TOTP step and each recovery code can be redeemed only once.
+func (a *ConsoleAuth) verifySecondFactor(ctx context.Context, accountID string, code string, now time.Time) (bool, error) {
+	normalizedCode := normalizeTwoFactorCode(code)
+	if normalizedCode == "" {
+		return false, nil
+	}
+	accountID = strings.TrimSpace(accountID)
+
+	if isTOTPCodeFormat(normalizedCode) {
+		record, ok, err := a.accountTOTP(ctx, accountID)
+		if err != nil {
+			return false, err
+		}
+		if !ok || record.Enabled != 1 {
+			return false, nil
+		}
+		step, matched := matchTOTPCode(record.Secret, normalizedCode, now)
+		if !matched {
+			return false, nil
+		}
+		consumed, err := a.queries.ConsumeAccountTOTPStep(ctx, sqlc.ConsumeAccountTOTPStepParams{
+			LastUsedStep:    step,
+			UpdatedAtUnixMs: now.UnixMilli(),
+			AccountID:       accountID,
+			LastUsedStep_2:  step,
+		})
+		if err != nil {
+			return false, err
+		}
+		return consumed == 1, nil
+	}
+
+	used, err := a.queries.UseAccountRecoveryCode(ctx, sqlc.UseAccountRecoveryCodeParams{
+		UsedAtUnixMs: now.UnixMilli(),
+		AccountID:    accountID,
+		CodeHash:     sha256Hex(normalizedCode),
+	})
+	if err != nil {
+		return false, err
+	}
+	return used == 1, nil
+}
+
+func (a *ConsoleAuth) issueRecoveryCodes(ctx context.Context, accountID string, now time.Time) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := a.queries.DeleteAccountRecoveryCodes(ctx, accountID); err != nil {
+		return nil, err
+	}
+	for _, code := range codes {
+		if err := a.queries.InsertAccountRecoveryCode(ctx, sqlc.InsertAccountRecoveryCodeParams{
+			AccountID:       accountID,
+			CodeHash:        sha256Hex(normalizeTwoFactorCode(code)),
+			CreatedAtUnixMs: now.UnixMilli(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+func (a *ConsoleAuth) resetAccountTwoFactor(ctx context.Context, accountID string) error {
+	if _, err := a.queries.DeleteAccountTOTP(ctx, accountID); err != nil {
+		return err
+	}
+	_, err := a.queries.DeleteAccountRecoveryCodes(ctx, accountID)
+	return err
+}
+
+// createLoginChallenge starts the second login step. A password rehash from
+// the first step is parked on the challenge until the second factor passes.
+func (a *ConsoleAuth) createLoginChallenge(ctx context.Context, accountID string, rehash passwordRehash, now time.Time) (string, time.Time, error) {
+	if _, err := a.queries.DeleteExpiredLoginChallenges(ctx, now.UnixMilli()); err != nil {
+		return "", time.Time{}, err
+	}
+	challengeToken, err := randomHex(loginChallengeByteSize)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := now.Add(loginChallengeTTL)
+	if err := a.queries.InsertLoginChallenge(ctx, sqlc.InsertLoginChallengeParams{
+		ChallengeHash:      sha256Hex(challengeToken),
+		AccountID:          strings.TrimSpace(accountID),
+		CreatedAtUnixMs:    now.UnixMilli(),
+		ExpiresAtUnixMs:    expiresAt.UnixMilli(),
+		RehashPasswordHash: rehash.PasswordHash,
+		RehashHashAlgo:     rehash.HashAlgo,
+	}); err != nil {
+		return "", time.Time{}, err
+	}
+	return challengeToken, expiresAt, nil
+}
+
+func (a *ConsoleAuth) LoginSecondFactor(c *gin.Context) {
+	var req loginSecondFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	challengeToken := strings.TrimSpace(req.MFAToken)
+	if challengeToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errLoginChallengeTokenNeed.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := a.now()
+	challengeHash := sha256Hex(challengeToken)
+	challenge, err := a.queries.GetLoginChallengeByHash(ctx, challengeHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": errLoginChallengeInvalid.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code"})
+		return
+	}
+	if !time.UnixMilli(challenge.ExpiresAtUnixMs).After(now) || challenge.Attempts >= loginChallengeMaxTries {
+		_, _ = a.queries.DeleteLoginChallengeByHash(ctx, challengeHash)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errLoginChallengeInvalid.Error()})
+		return
+	}
+
+	accountRecord, err := a.queries.GetAccountByID(ctx, strings.TrimSpace(challenge.AccountID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			_, _ = a.queries.DeleteLoginChallengeByHash(ctx, challengeHash)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": errLoginChallengeInvalid.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code"})
+		return
+	}
+
+	clientIP := strings.TrimSpace(c.ClientIP())
+	throttleSubjects := a.loginThrottle.subjects(clientIP, accountRecord.UsernameKey)
+	retryAfter, err := a.loginRetryAfter(ctx, throttleSubjects, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login throttle"})
+		return
+	}
+	if retryAfter > 0 {
+		writeLoginThrottled(c, retryAfter)
+		return
+	}
+
+	verified, err := a.verifySecondFactor(ctx, accountRecord.AccountID, req.Code, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code"})
+		return
+	}
+	if !verified {
+		if _, err := a.queries.IncrementLoginChallengeAttempts(ctx, challengeHash); err != nil {
+			slog.Warn("failed to count console login challenge attempt", "error", err)
+		}
+		if err := a.recordLoginFailure(ctx, throttleSubjects, clientIP, now); err != nil {
+			slog.Warn("failed to record console login failure", "error", err)
+		}
+		a.recordAudit(c, persistence.AuditEvent{
+			ActorType:  persistence.AuditActorAnonymous,
+			AccountID:  accountRecord.AccountID,
+			Action:     auditActionLogin,
+			TargetType: "account",
+			TargetID:   accountRecord.AccountID,
+			Outcome:    persistence.AuditOutcomeFailure,
+			Details:    map[string]any{"method": "totp"},
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errTOTPCodeInvalid.Error()})
+		return
+	}
+
+	if _, err := a.queries.DeleteLoginChallengeByHash(ctx, challengeHash); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	if _, err := a.clearLoginFailures(ctx, loginThrottleScopeUsername, strings.TrimSpace(accountRecord.UsernameKey)); err != nil {
+		slog.Warn("failed to clear console login failures", "error", err)
+	}
+	rehash := passwordRehash{PasswordHash: challenge.RehashPasswordHash, HashAlgo: challenge.RehashHashAlgo}
+	if accountRecord.UpdatedAtUnixMs > challenge.CreatedAtUnixMs {
+		// The account changed after the password step; the parked hash may
+		// belong to a password that is no longer current.
+		rehash = passwordRehash{}
+	}
+	a.completeLogin(c, accountRecord, "totp", rehash)
+}
+
+func (a *ConsoleAuth) TwoFactorStatus(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	record, enrolled, err := a.accountTOTP(ctx, account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load two-factor status"})
+		return
+	}
+	remaining, err := a.queries.CountUnusedAccountRecoveryCodes(ctx, account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load two-factor status"})
+		return
+	}
+	required, err := a.totpRequiredByPolicy(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load two-factor status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, twoFactorStatusResponse{
+		TOTPEnabled:            enrolled && record.Enabled == 1,
+		TOTPPending:            enrolled && record.Enabled == 0,
+		RecoveryCodesRemaining: remaining,
+		RequiredByPolicy:       required,
+	})
+}
+
+func (a *ConsoleAuth) EnrollTOTP(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	external, err := a.accountUsesExternalIdentity(c.Request.Context(), account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start two-factor enrollment"})
+		return
+	}
+	if external {
+		c.JSON(http.StatusConflict, gin.H{"error": errTOTPExternalAccount.Error()})
+		return
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start two-factor enrollment"})
+		return
+	}
+	sealedSecret, err := a.sealTOTPSecret(account.AccountID, secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start two-factor enrollment"})
+		return
+	}
+	now := a.now()
+	updatedRows, err := a.queries.UpsertPendingAccountTOTP(c.Request.Context(), sqlc.UpsertPendingAccountTOTPParams{
+		AccountID:       strings.TrimSpace(account.AccountID),
+		Secret:          sealedSecret,
+		CreatedAtUnixMs: now.UnixMilli(),
+		UpdatedAtUnixMs: now.UnixMilli(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start two-factor enrollment"})
+		return
+	}
+	if updatedRows == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errTOTPAlreadyEnabled.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, totpEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: totpProvisioningURI(secret, account.Username),
+	})
+}
+
+func (a *ConsoleAuth) VerifyTOTPEnrollment(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	code := normalizeTwoFactorCode(req.Code)
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPCodeRequired.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	record, enrolled, err := a.accountTOTP(ctx, account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code"})
+		return
+	}
+	if !enrolled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPNotEnrolled.Error()})
+		return
+	}
+	if record.Enabled == 1 {
+		c.JSON(http.StatusConflict, gin.H{"error": errTOTPAlreadyEnabled.Error()})
+		return
+	}
+
+	now := a.now()
+	step, matched := matchTOTPCode(record.Secret, code, now)
+	if !matched {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPCodeInvalid.Error()})
+		return
+	}
+	enabledRows, err := a.queries.EnableAccountTOTP(ctx, sqlc.EnableAccountTOTPParams{
+		LastUsedStep:    step,
+		UpdatedAtUnixMs: now.UnixMilli(),
+		AccountID:       strings.TrimSpace(account.AccountID),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+	if enabledRows == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": errTOTPAlreadyEnabled.Error()})
+		return
+	}
+
+	codes, err := a.issueRecoveryCodes(ctx, strings.TrimSpace(account.AccountID), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue recovery codes"})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionTwoFactorEnable,
+		TargetType: "account",
+		TargetID:   strings.TrimSpace(account.AccountID),
+	})
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (a *ConsoleAuth) DisableTOTP(c *gin.Context) {
+	account, ok := a.requireSecondFactorConfirmation(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	required, err := a.totpRequiredByPolicy(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+	if required {
+		c.JSON(http.StatusForbidden, gin.H{"error": errTOTPRequiredByPolicy.Error()})
+		return
+	}
+	if err := a.resetAccountTwoFactor(ctx, account.AccountID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionTwoFactorDisable,
+		TargetType: "account",
+		TargetID:   account.AccountID,
+	})
+	c.Status(http.StatusNoContent)
+}
+
+func (a *ConsoleAuth) RegenerateRecoveryCodes(c *gin.Context) {
+	account, ok := a.requireSecondFactorConfirmation(c)
+	if !ok {
+		return
+	}
+
+	codes, err := a.issueRecoveryCodes(c.Request.Context(), account.AccountID, a.now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue recovery codes"})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionRecoveryCodesRegenerate,
+		TargetType: "account",
+		TargetID:   account.AccountID,
+	})
+	c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// requireSecondFactorConfirmation guards sensitive 2FA changes behind a fresh code.
+func (a *ConsoleAuth) requireSecondFactorConfirmation(c *gin.Context) (SessionAccount, bool) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return SessionAccount{}, false
+	}
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return SessionAccount{}, false
+	}
+	if normalizeTwoFactorCode(req.Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPCodeRequired.Error()})
+		return SessionAccount{}, false
+	}
+
+	ctx := c.Request.Context()
+	enabled, err := a.accountTOTPEnabled(ctx, account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code"})
+		return SessionAccount{}, false
+	}
+	if !enabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPNotEnabled.Error()})
+		return SessionAccount{}, false
+	}
+	// Confirmation codes share the login throttle so a stolen session cannot
+	// be used to brute force the second factor.
+	now := a.now()
+	clientIP := strings.TrimSpace(c.ClientIP())
+	throttleSubjects := a.loginThrottle.subjects(clientIP, account.Username)
+	retryAfter, err := a.loginRetryAfter(ctx, throttleSubjects, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login throttle"})
+		return SessionAccount{}, false
+	}
+	if retryAfter > 0 {
+		writeLoginThrottled(c, retryAfter)
+		return SessionAccount{}, false
+	}
+	verified, err := a.verifySecondFactor(ctx, account.AccountID, req.Code, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify two-factor code"})
+		return SessionAccount{}, false
+	}
+	if !verified {
+		if err := a.recordLoginFailure(ctx, throttleSubjects, clientIP, now); err != nil {
+			slog.Warn("failed to record console login failure", "error", err)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": errTOTPCodeInvalid.Error()})
+		return SessionAccount{}, false
+	}
+	return account, true
+}
+
+func (a *ConsoleAuth) ResetAccountTwoFactor(c *gin.Context) {
+	targetAccountID := strings.TrimSpace(c.Param("account_id"))
+	if targetAccountID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id is required"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := a.queries.GetAccountByID(ctx, targetAccountID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errAccountNotFound.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset two-factor authentication"})
+		return
+	}
+	if err := a.resetAccountTwoFactor(ctx, targetAccountID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset two-factor authentication"})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionAccountTwoFactorReset,
+		TargetType: "account",
+		TargetID:   targetAccountID,
+	})
+	c.Status(http.StatusNoContent)
+}
+
+func (a *ConsoleAuth) GetSecuritySettings(c *gin.Context) {
+	required, err := a.totpRequiredByPolicy(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load security settings"})
+		return
+	}
+	c.JSON(http.StatusOK, securitySettingsResponse{RequireTOTP: required})
+}
+
+func (a *ConsoleAuth) UpdateSecuritySettings(c *gin.Context) {
+	var req securitySettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RequireTOTP == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := a.queries.UpsertConsoleSetting(c.Request.Context(), sqlc.UpsertConsoleSettingParams{
+		SettingKey:      consoleSettingTOTPGuard,
+		SettingValue:    strconv.FormatBool(*req.RequireTOTP),
+		UpdatedAtUnixMs: a.now().UnixMilli(),
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update security settings"})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionSecuritySettingsUpdate,
+		TargetType: "setting",
+		TargetID:   consoleSettingTOTPGuard,
+		Details:    map[string]any{"require_totp": *req.RequireTOTP},
+	})
+	c.JSON(http.StatusOK, securitySettingsResponse{RequireTOTP: *req.RequireTOTP})
+}
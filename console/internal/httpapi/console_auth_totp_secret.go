@@ -0,0 +1,102 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+// totpSecretSealPrefix marks account_totp secrets encrypted with the TOTP
+// encryption key. Rows without it predate encryption and hold the plain secret.
+const totpSecretSealPrefix = "enc:v1:"
+
+var errTOTPSecretKeyRequired = errors.New("totp secret is encrypted but CONSOLE_TOTP_ENCRYPTION_KEY is not set")
+
+// SetTOTPEncryptionKey enables AES-GCM encryption of stored TOTP secrets.
+// The AES key is the SHA-256 of key. Plain secrets written before a key was
+// configured are encrypted the next time they are read.
+func (a *ConsoleAuth) SetTOTPEncryptionKey(key string) error {
+	if a == nil {
+		return nil
+	}
+	if strings.TrimSpace(key) == "" {
+		a.totpCipher = nil
+		return nil
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return fmt.Errorf("create totp cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("create totp cipher: %w", err)
+	}
+	a.totpCipher = aead
+	return nil
+}
+
+// sealTOTPSecret encrypts secret for storage. The account ID is bound as
+// additional data so a sealed secret cannot be moved to another account.
+func (a *ConsoleAuth) sealTOTPSecret(accountID string, secret string) (string, error) {
+	if a.totpCipher == nil {
+		return secret, nil
+	}
+	nonce := make([]byte, a.totpCipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := a.totpCipher.Seal(nonce, nonce, []byte(secret), []byte(strings.TrimSpace(accountID)))
+	return totpSecretSealPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (a *ConsoleAuth) openTOTPSecret(accountID string, stored string) (string, error) {
+	encoded, sealed := strings.CutPrefix(stored, totpSecretSealPrefix)
+	if !sealed {
+		return stored, nil
+	}
+	if a.totpCipher == nil {
+		return "", errTOTPSecretKeyRequired
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode totp secret: %w", err)
+	}
+	nonceSize := a.totpCipher.NonceSize()
+	if len(raw) < nonceSize {
+		return "", errors.New("decode totp secret: sealed value is too short")
+	}
+	secret, err := a.totpCipher.Open(nil, raw[:nonceSize], raw[nonceSize:], []byte(strings.TrimSpace(accountID)))
+	if err != nil {
+		return "", fmt.Errorf("decrypt totp secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// upgradeTOTPSecret encrypts a secret stored before encryption was enabled.
+// A failure leaves the plain row in place to be retried on the next read.
+func (a *ConsoleAuth) upgradeTOTPSecret(ctx context.Context, record sqlc.AccountTotp) {
+	if a.totpCipher == nil || strings.HasPrefix(record.Secret, totpSecretSealPrefix) {
+		return
+	}
+	sealed, err := a.sealTOTPSecret(record.AccountID, record.Secret)
+	if err == nil {
+		_, err = a.queries.UpdateAccountTOTPSecret(ctx, sqlc.UpdateAccountTOTPSecretParams{
+			Secret:    sealed,
+			AccountID: record.AccountID,
+			Secret_2:  record.Secret,
+		})
+	}
+	if err != nil {
+		slog.Warn("failed to encrypt stored totp secret", "account_id", record.AccountID, "error", err)
+	}
+}
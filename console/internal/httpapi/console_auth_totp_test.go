@@ -0,0 +1,363 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func TestTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B SHA1 seed, truncated to 6 digits.
+	secret := totpBase32Encoding.EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tc := range tests {
+		got, err := totpCodeAt(secret, totpStepAt(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("totpCodeAt(%d): %v", tc.unix, err)
+		}
+		if got != tc.want {
+			t.Fatalf("totpCodeAt(%d)=%s, want %s", tc.unix, got, tc.want)
+		}
+		if _, ok := matchTOTPCode(secret, tc.want, time.Unix(tc.unix+totpPeriodSec, 0)); !ok {
+			t.Fatalf("expected code for %d to match within one step of skew", tc.unix)
+		}
+		if _, ok := matchTOTPCode(secret, tc.want, time.Unix(tc.unix+3*totpPeriodSec, 0)); ok {
+			t.Fatalf("expected code for %d to be rejected outside skew", tc.unix)
+		}
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(totpProvisioningURI("JBSWY3DPEHPK3PXP", "alice"))
+	if err != nil {
+		t.Fatalf("parse provisioning uri: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Onlyboxes:alice" {
+		t.Fatalf("unexpected provisioning uri: %s", uri.String())
+	}
+	query := uri.Query()
+	if query.Get("secret") != "JBSWY3DPEHPK3PXP" || query.Get("issuer") != totpIssuer || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Fatalf("unexpected provisioning uri query: %s", uri.RawQuery)
+	}
+}
+
+func doJSON(t *testing.T, router http.Handler, method string, path string, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if cookie != nil {
+		addSessionCookie(req, cookie)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func sessionCookieFromRecorder(t *testing.T, rec *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == dashboardSessionCookieName && cookie.Value != "" {
+			return cookie
+		}
+	}
+	t.Fatalf("expected %s cookie in response", dashboardSessionCookieName)
+	return nil
+}
+
+func enrollTestTOTP(t *testing.T, router http.Handler, cookie *http.Cookie, now time.Time) (string, []string) {
+	t.Helper()
+	enrollRec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/totp/enroll", "", cookie)
+	if enrollRec.Code != http.StatusOK {
+		t.Fatalf("expected enroll success, got %d body=%s", enrollRec.Code, enrollRec.Body.String())
+	}
+	var enrollPayload totpEnrollResponse
+	if err := json.Unmarshal(enrollRec.Body.Bytes(), &enrollPayload); err != nil {
+		t.Fatalf("decode enroll payload: %v", err)
+	}
+	if enrollPayload.Secret == "" || !strings.HasPrefix(enrollPayload.ProvisioningURI, "otpauth://totp/") {
+		t.Fatalf("unexpected enroll payload: %#v", enrollPayload)
+	}
+
+	code, err := totpCodeAt(enrollPayload.Secret, totpStepAt(now))
+	if err != nil {
+		t.Fatalf("compute totp code: %v", err)
+	}
+	verifyRec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/totp/verify", `{"code":"`+code+`"}`, cookie)
+	if verifyRec.Code != http.StatusOK {
+		t.Fatalf("expected verify success, got %d body=%s", verifyRec.Code, verifyRec.Body.String())
+	}
+	var verifyPayload recoveryCodesResponse
+	if err := json.Unmarshal(verifyRec.Body.Bytes(), &verifyPayload); err != nil {
+		t.Fatalf("decode verify payload: %v", err)
+	}
+	if len(verifyPayload.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", recoveryCodeCount, len(verifyPayload.RecoveryCodes))
+	}
+	return enrollPayload.Secret, verifyPayload.RecoveryCodes
+}
+
+func startSecondFactorLogin(t *testing.T, router http.Handler) string {
+	t.Helper()
+	rec := postLogin(router, testDashboardUsername, testDashboardPassword)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected password step success, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == dashboardSessionCookieName {
+			t.Fatalf("expected no session cookie before second factor")
+		}
+	}
+	var payload loginChallengeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode login challenge: %v", err)
+	}
+	if payload.Authenticated || !payload.MFARequired || payload.MFAToken == "" {
+		t.Fatalf("unexpected login challenge payload: %#v", payload)
+	}
+	return payload.MFAToken
+}
+
+func TestConsoleAuthTOTPEnrollmentAndTwoStepLogin(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	auth := newTestConsoleAuth(t)
+	now := time.Unix(1_700_000_000, 0)
+	auth.nowFn = func() time.Time {
+		return now
+	}
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+	cookie := loginSessionCookie(t, router)
+
+	secret, recoveryCodes := enrollTestTOTP(t, router, cookie, now)
+
+	statusRec := doJSON(t, router, http.MethodGet, "/api/v1/console/2fa", "", cookie)
+	if statusRec.Code != http.StatusOK {
+		t.Fatalf("expected 2fa status success, got %d body=%s", statusRec.Code, statusRec.Body.String())
+	}
+	var status twoFactorStatusResponse
+	if err := json.Unmarshal(statusRec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("decode 2fa status: %v", err)
+	}
+	if !status.TOTPEnabled || status.TOTPPending || status.RecoveryCodesRemaining != recoveryCodeCount {
+		t.Fatalf("unexpected 2fa status: %#v", status)
+	}
+
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/totp/enroll", "", cookie); rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when re-enrolling, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// The code used during enrollment cannot be replayed for login.
+	mfaToken := startSecondFactorLogin(t, router)
+	replayCode, err := totpCodeAt(secret, totpStepAt(now))
+	if err != nil {
+		t.Fatalf("compute totp code: %v", err)
+	}
+	replayRec := doJSON(t, router, http.MethodPost, "/api/v1/console/login/2fa", `{"mfa_token":"`+mfaToken+`","code":"`+replayCode+`"}`, nil)
+	if replayRec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected replayed code to be rejected, got %d body=%s", replayRec.Code, replayRec.Body.String())
+	}
+
+	now = now.Add(totpPeriodSec * time.Second)
+	nextCode, err := totpCodeAt(secret, totpStepAt(now))
+	if err != nil {
+		t.Fatalf("compute totp code: %v", err)
+	}
+	loginRec := doJSON(t, router, http.MethodPost, "/api/v1/console/login/2fa", `{"mfa_token":"`+mfaToken+`","code":"`+nextCode+`"}`, nil)
+	if loginRec.Code != http.StatusOK {
+		t.Fatalf("expected second factor login success, got %d body=%s", loginRec.Code, loginRec.Body.String())
+	}
+	sessionCookieFromRecorder(t, loginRec)
+
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/login/2fa", `{"mfa_token":"`+mfaToken+`","code":"`+nextCode+`"}`, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected consumed mfa_token to be rejected, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	recoveryToken := startSecondFactorLogin(t, router)
+	recoveryRec := doJSON(t, router, http.MethodPost, "/api/v1/console/login/2fa", `{"mfa_token":"`+recoveryToken+`","code":"`+strings.ToUpper(recoveryCodes[0])+`"}`, nil)
+	if recoveryRec.Code != http.StatusOK {
+		t.Fatalf("expected recovery code login success, got %d body=%s", recoveryRec.Code, recoveryRec.Body.String())
+	}
+	recoveryCookie := sessionCookieFromRecorder(t, recoveryRec)
+
+	reuseToken := startSecondFactorLogin(t, router)
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/login/2fa", `{"mfa_token":"`+reuseToken+`","code":"`+recoveryCodes[0]+`"}`, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected used recovery code to be rejected, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/totp/disable", `{"code":"000000"}`, recoveryCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected invalid code to block disable, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/totp/disable", `{"code":"`+recoveryCodes[1]+`"}`, recoveryCookie); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected disable success, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	loginSessionCookie(t, router)
+}
+
+func TestConsoleAuthRequireTOTPPolicy(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	auth := newTestConsoleAuth(t)
+	seedTestAccount(t, auth.queries, "acc-totp-member", "totp-member", "member-pass", false)
+	now := time.Unix(1_700_000_000, 0)
+	auth.nowFn = func() time.Time {
+		return now
+	}
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+	adminCookie := loginSessionCookie(t, router)
+	memberCookie := loginSessionCookieFor(t, router, "totp-member", "member-pass")
+
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/console/settings/security", `{"require_totp":true}`, memberCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-admin policy update, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/console/settings/security", `{}`, adminCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for missing require_totp, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/console/settings/security", `{"require_totp":true}`, adminCookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected policy update success, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	gatedRec := doJSON(t, router, http.MethodGet, "/api/v1/console/tokens", "", memberCookie)
+	if gatedRec.Code != http.StatusForbidden || !strings.Contains(gatedRec.Body.String(), errTOTPEnrollmentRequired.Error()) {
+		t.Fatalf("expected enrollment gate, got %d body=%s", gatedRec.Code, gatedRec.Body.String())
+	}
+	sessionRec := doJSON(t, router, http.MethodGet, "/api/v1/console/session", "", memberCookie)
+	if sessionRec.Code != http.StatusOK {
+		t.Fatalf("expected session endpoint to stay reachable, got %d body=%s", sessionRec.Code, sessionRec.Body.String())
+	}
+	var sessionPayload accountSessionResponse
+	if err := json.Unmarshal(sessionRec.Body.Bytes(), &sessionPayload); err != nil {
+		t.Fatalf("decode session payload: %v", err)
+	}
+	if !sessionPayload.TOTPEnrollmentRequired {
+		t.Fatalf("expected totp_enrollment_required=true")
+	}
+
+	_, recoveryCodes := enrollTestTOTP(t, router, memberCookie, now)
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/console/tokens", "", memberCookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected access after enrollment, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/totp/disable", `{"code":"`+recoveryCodes[0]+`"}`, memberCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected policy to block disable, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// Admin reset returns the member to the enrollment gate.
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/console/tokens", "", adminCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected unenrolled admin to be gated too, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	enrollTestTOTP(t, router, adminCookie, now)
+	if rec := doJSON(t, router, http.MethodDelete, "/api/v1/console/accounts/acc-totp-member/2fa", "", adminCookie); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected admin 2fa reset success, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/console/tokens", "", memberCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected member to be gated after reset, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestConsoleAuthSecondFactorConfirmationIsThrottled(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	auth := newTestConsoleAuth(t)
+	now := time.Unix(1_700_000_000, 0)
+	auth.nowFn = func() time.Time {
+		return now
+	}
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+	cookie := loginSessionCookie(t, router)
+	_, recoveryCodes := enrollTestTOTP(t, router, cookie, now)
+
+	for i := int64(0); i <= defaultLoginThrottlePolicy.Username.FreeFailures; i++ {
+		if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/recovery-codes", `{"code":"000000"}`, cookie); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected wrong code %d to be rejected, got %d body=%s", i, rec.Code, rec.Body.String())
+		}
+	}
+	rec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/totp/disable", `{"code":"`+recoveryCodes[0]+`"}`, cookie)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected confirmation to be throttled after repeated failures, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	now = now.Add(defaultLoginThrottlePolicy.Username.MaxBackoff)
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/2fa/totp/disable", `{"code":"`+recoveryCodes[0]+`"}`, cookie); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected disable after backoff, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestConsoleAuthTOTPSecretIsEncrypted(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	auth := newTestConsoleAuth(t)
+	seedTestAccount(t, auth.queries, "acc-totp-legacy", "totp-legacy", "legacy-pass", false)
+	if err := auth.SetTOTPEncryptionKey("test-totp-key"); err != nil {
+		t.Fatalf("set totp encryption key: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	auth.nowFn = func() time.Time {
+		return now
+	}
+	router := mustNewRouter(t, handler, auth, newTestMCPAuth(t))
+	cookie := loginSessionCookie(t, router)
+	secret, _ := enrollTestTOTP(t, router, cookie, now)
+
+	ctx := context.Background()
+	stored, err := auth.queries.GetAccountTOTP(ctx, testDashboardAccountID)
+	if err != nil {
+		t.Fatalf("load stored totp: %v", err)
+	}
+	if !strings.HasPrefix(stored.Secret, totpSecretSealPrefix) || strings.Contains(stored.Secret, secret) {
+		t.Fatalf("expected sealed secret at rest, got %q", stored.Secret)
+	}
+
+	// A secret written before the key was configured still verifies and is
+	// sealed on first use.
+	legacySecret, err := generateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generate totp secret: %v", err)
+	}
+	if _, err := auth.queries.UpsertPendingAccountTOTP(ctx, sqlc.UpsertPendingAccountTOTPParams{
+		AccountID:       "acc-totp-legacy",
+		Secret:          legacySecret,
+		CreatedAtUnixMs: now.UnixMilli(),
+		UpdatedAtUnixMs: now.UnixMilli(),
+	}); err != nil {
+		t.Fatalf("seed legacy totp: %v", err)
+	}
+	record, ok, err := auth.accountTOTP(ctx, "acc-totp-legacy")
+	if err != nil || !ok || record.Secret != legacySecret {
+		t.Fatalf("expected legacy secret to load, got %#v ok=%v err=%v", record, ok, err)
+	}
+	upgraded, err := auth.queries.GetAccountTOTP(ctx, "acc-totp-legacy")
+	if err != nil {
+		t.Fatalf("load upgraded totp: %v", err)
+	}
+	if !strings.HasPrefix(upgraded.Secret, totpSecretSealPrefix) {
+		t.Fatalf("expected legacy secret to be sealed, got %q", upgraded.Secret)
+	}
+
+	// Sealed secrets are bound to their account.
+	if _, err := auth.openTOTPSecret("acc-totp-legacy", stored.Secret); err == nil {
+		t.Fatalf("expected sealed secret to fail for another account")
+	}
+	if err := auth.SetTOTPEncryptionKey(""); err != nil {
+		t.Fatalf("clear totp encryption key: %v", err)
+	}
+	if _, _, err := auth.accountTOTP(ctx, testDashboardAccountID); err == nil {
+		t.Fatalf("expected sealed secret to require the encryption key")
+	}
+}
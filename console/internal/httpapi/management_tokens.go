@@ -0,0 +1,523 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+const (
+	managementTokenPrefix        = "obm_"
+	managementTokenIDPrefix      = "mgt_"
+	defaultManagementEventsLimit = 50
+	maxManagementEventsLimit     = 500
+)
+
+// Management tokens authenticate automation against the dashboard-only route
+// groups. Each route requires one permission; the token acts as the account
+// that created it, so admin-only routes still require an admin account.
+const (
+	managementPermissionWorkersRead   = "workers:read"
+	managementPermissionWorkersWrite  = "workers:write"
+	managementPermissionAccountsRead  = "accounts:read"
+	managementPermissionAccountsWrite = "accounts:write"
+	managementPermissionTokensRead    = "tokens:read"
+	managementPermissionTokensWrite   = "tokens:write"
+)
+
+var managementPermissions = []string{
+	managementPermissionWorkersRead,
+	managementPermissionWorkersWrite,
+	managementPermissionAccountsRead,
+	managementPermissionAccountsWrite,
+	managementPermissionTokensRead,
+	managementPermissionTokensWrite,
+}
+
+var adminManagementPermissions = map[string]struct{}{
+	managementPermissionAccountsRead:  {},
+	managementPermissionAccountsWrite: {},
+}
+
+var (
+	errManagementTokenPermissionsRequired = errors.New("permissions is required")
+	errManagementTokenPermissionUnknown   = errors.New("unknown permission")
+	errManagementTokenPermissionAdminOnly = errors.New("accounts permissions require an admin account")
+	errManagementTokenNameConflict        = errors.New("management token name already exists")
+	errManagementTokenNotFound            = errors.New("management token not found")
+)
+
+type managementTokenItem struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	TokenMasked string     `json:"token_masked"`
+	Permissions []string   `json:"permissions"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+type managementTokenListResponse struct {
+	Items []managementTokenItem `json:"items"`
+	Total int                   `json:"total"`
+}
+
+type createManagementTokenRequest struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type createManagementTokenResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Token       string    `json:"token"`
+	TokenMasked string    `json:"token_masked"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type managementTokenEventItem struct {
+	ID         int64     `json:"id"`
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	Permission string    `json:"permission"`
+	StatusCode int       `json:"status_code"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type managementTokenEventListResponse struct {
+	Items []managementTokenEventItem `json:"items"`
+	Total int                        `json:"total"`
+}
+
+// RequireManagementAccess authenticates a dashboard route with either the
+// session cookie or a management token carrying permission. Requests without
+// an Authorization header fall through to the session check; every request
+// authenticated by a management token is recorded. Tokens of accounts held at
+// the require_totp enrollment gate are rejected like their sessions.
+func (a *MCPAuth) RequireManagementAccess(consoleAuth *ConsoleAuth, permission string) gin.HandlerFunc {
+	requireSession := consoleAuth.RequireAuth()
+	return func(c *gin.Context) {
+		if strings.TrimSpace(c.GetHeader(trustedTokenHeader)) == "" {
+			requireSession(c)
+			return
+		}
+
+		token, ok := parseBearerToken(c.GetHeader(trustedTokenHeader))
+		if !ok || a == nil || a.queries == nil || a.hasher == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing token"})
+			c.Abort()
+			return
+		}
+		record, ok := a.lookupManagementToken(c.Request.Context(), token)
+		if !ok {
+			slog.Warn("management token rejected",
+				"method", c.Request.Method,
+				"route", c.FullPath(),
+				"ip", strings.TrimSpace(c.ClientIP()),
+			)
+			a.recordAudit(c, persistence.AuditEvent{
+				Action:  auditActionTokenRejected,
+				Outcome: persistence.AuditOutcomeDenied,
+				Details: map[string]any{"kind": "management", "route": c.FullPath()},
+			})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing token"})
+			c.Abort()
+			return
+		}
+		account, err := a.queries.GetAccountByID(c.Request.Context(), record.AccountID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing token"})
+			c.Abort()
+			return
+		}
+		setRequestSessionAccount(c, SessionAccount{
+			AccountID: strings.TrimSpace(account.AccountID),
+			Username:  strings.TrimSpace(account.Username),
+			IsAdmin:   account.IsAdmin == 1,
+		})
+		c.Set(requestManagementTokenIDGinKey, record.TokenID)
+
+		// A token must not let its account bypass the require_totp policy
+		// that gates the same account's dashboard session.
+		enrollmentRequired, err := consoleAuth.totpEnrollmentRequired(c.Request.Context(), account.AccountID)
+		switch {
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load token account"})
+			c.Abort()
+		case enrollmentRequired:
+			c.JSON(http.StatusForbidden, gin.H{"error": errTOTPEnrollmentRequired.Error()})
+			c.Abort()
+		case slices.Contains(decodeManagementPermissions(record.PermissionsJson), permission):
+			c.Next()
+		default:
+			c.JSON(http.StatusForbidden, gin.H{"error": "token does not grant " + permission})
+			c.Abort()
+		}
+		a.recordManagementTokenUse(c, record, permission)
+	}
+}
+
+func (a *MCPAuth) ListManagementTokens(c *gin.Context) {
+	if a == nil || a.queries == nil {
+		c.JSON(http.StatusOK, managementTokenListResponse{Items: []managementTokenItem{}, Total: 0})
+		return
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+
+	records, err := a.queries.ListManagementTokensByAccount(c.Request.Context(), account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list management tokens"})
+		return
+	}
+	items := make([]managementTokenItem, 0, len(records))
+	for _, record := range records {
+		item := managementTokenItem{
+			ID:          record.TokenID,
+			Name:        record.Name,
+			TokenMasked: record.TokenMasked,
+			Permissions: decodeManagementPermissions(record.PermissionsJson),
+			CreatedAt:   time.UnixMilli(record.CreatedAtUnixMs),
+		}
+		if record.LastUsedAtUnixMs > 0 {
+			lastUsedAt := time.UnixMilli(record.LastUsedAtUnixMs)
+			item.LastUsedAt = &lastUsedAt
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, managementTokenListResponse{Items: items, Total: len(items)})
+}
+
+func (a *MCPAuth) CreateManagementToken(c *gin.Context) {
+	if a == nil || a.queries == nil || a.hasher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token store is unavailable"})
+		return
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+
+	req := createManagementTokenRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	record, token, err := a.createManagementToken(c.Request.Context(), account, req.Name, req.Permissions)
+	if err != nil {
+		switch {
+		case errors.Is(err, errTrustedTokenNameRequired),
+			errors.Is(err, errTrustedTokenNameTooLong),
+			errors.Is(err, errManagementTokenPermissionsRequired),
+			errors.Is(err, errManagementTokenPermissionUnknown):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errManagementTokenPermissionAdminOnly):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, errManagementTokenNameConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create management token"})
+		}
+		return
+	}
+
+	slog.Info("management token created",
+		"token_id", record.TokenID,
+		"account_id", record.AccountID,
+		"permissions", record.PermissionsJson,
+	)
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionManagementTokenCreate,
+		TargetType: "management_token",
+		TargetID:   record.TokenID,
+		Details:    map[string]any{"name": record.Name, "permissions": decodeManagementPermissions(record.PermissionsJson)},
+	})
+	c.JSON(http.StatusCreated, createManagementTokenResponse{
+		ID:          record.TokenID,
+		Name:        record.Name,
+		Token:       token,
+		TokenMasked: record.TokenMasked,
+		Permissions: decodeManagementPermissions(record.PermissionsJson),
+		CreatedAt:   time.UnixMilli(record.CreatedAtUnixMs),
+	})
+}
+
+func (a *MCPAuth) DeleteManagementToken(c *gin.Context) {
+	if a == nil || a.queries == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token store is unavailable"})
+		return
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	tokenID := strings.TrimSpace(c.Param("token_id"))
+	if tokenID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token_id is required"})
+		return
+	}
+
+	rows, err := a.queries.DeleteManagementTokenByIDAndAccount(c.Request.Context(), sqlc.DeleteManagementTokenByIDAndAccountParams{
+		TokenID:   tokenID,
+		AccountID: account.AccountID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete management token"})
+		return
+	}
+	if rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errManagementTokenNotFound.Error()})
+		return
+	}
+	slog.Info("management token deleted", "token_id", tokenID, "account_id", account.AccountID)
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionManagementTokenDelete,
+		TargetType: "management_token",
+		TargetID:   tokenID,
+	})
+	c.Status(http.StatusNoContent)
+}
+
+func (a *MCPAuth) ListManagementTokenEvents(c *gin.Context) {
+	if a == nil || a.queries == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token store is unavailable"})
+		return
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	tokenID := strings.TrimSpace(c.Param("token_id"))
+	if tokenID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token_id is required"})
+		return
+	}
+	limit, ok := parsePositiveIntQuery(c, "limit", defaultManagementEventsLimit)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	if limit > maxManagementEventsLimit {
+		limit = maxManagementEventsLimit
+	}
+
+	records, err := a.queries.ListManagementTokenEvents(c.Request.Context(), sqlc.ListManagementTokenEventsParams{
+		TokenID:   tokenID,
+		AccountID: account.AccountID,
+		Limit:     int64(limit),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list management token events"})
+		return
+	}
+	items := make([]managementTokenEventItem, 0, len(records))
+	for _, record := range records {
+		items = append(items, managementTokenEventItem{
+			ID:         record.EventID,
+			Method:     record.Method,
+			Route:      record.Route,
+			Permission: record.Permission,
+			StatusCode: int(record.StatusCode),
+			IPAddress:  record.IpAddress,
+			CreatedAt:  time.UnixMilli(record.CreatedAtUnixMs),
+		})
+	}
+	c.JSON(http.StatusOK, managementTokenEventListResponse{Items: items, Total: len(items)})
+}
+
+func (a *MCPAuth) createManagementToken(
+	ctx context.Context,
+	account SessionAccount,
+	name string,
+	permissions []string,
+) (sqlc.ManagementToken, string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	normalizedName, nameKey, err := normalizeTokenName(name)
+	if err != nil {
+		return sqlc.ManagementToken{}, "", err
+	}
+	normalizedPermissions, err := normalizeManagementPermissions(permissions, account.IsAdmin)
+	if err != nil {
+		return sqlc.ManagementToken{}, "", err
+	}
+	permissionsJSON, err := json.Marshal(normalizedPermissions)
+	if err != nil {
+		return sqlc.ManagementToken{}, "", err
+	}
+
+	for i := 0; i < 8; i++ {
+		secret, genErr := randomHexString(generatedTokenByteLength)
+		if genErr != nil {
+			return sqlc.ManagementToken{}, "", errTrustedTokenGenerateFailed
+		}
+		tokenIDSuffix, idErr := randomHexString(tokenIDByteLength)
+		if idErr != nil {
+			return sqlc.ManagementToken{}, "", errTrustedTokenIDGenerateFailed
+		}
+		token := managementTokenPrefix + secret
+		record := sqlc.ManagementToken{
+			TokenID:         managementTokenIDPrefix + tokenIDSuffix,
+			AccountID:       strings.TrimSpace(account.AccountID),
+			Name:            normalizedName,
+			NameKey:         nameKey,
+			TokenHash:       a.hasher.Hash(token),
+			HashKeyID:       a.hasher.PrimaryKeyID(),
+			TokenMasked:     maskToken(token),
+			PermissionsJson: string(permissionsJSON),
+			CreatedAtUnixMs: a.now().UnixMilli(),
+		}
+		err = a.queries.InsertManagementToken(ctx, sqlc.InsertManagementTokenParams{
+			TokenID:          record.TokenID,
+			AccountID:        record.AccountID,
+			Name:             record.Name,
+			NameKey:          record.NameKey,
+			TokenHash:        record.TokenHash,
+			HashKeyID:        record.HashKeyID,
+			TokenMasked:      record.TokenMasked,
+			PermissionsJson:  record.PermissionsJson,
+			CreatedAtUnixMs:  record.CreatedAtUnixMs,
+			LastUsedAtUnixMs: 0,
+		})
+		if err == nil {
+			return record, token, nil
+		}
+		if !isSQLiteConstraintError(err) {
+			return sqlc.ManagementToken{}, "", err
+		}
+		_, lookupErr := a.queries.GetManagementTokenByAccountAndNameKey(ctx, sqlc.GetManagementTokenByAccountAndNameKeyParams{
+			AccountID: record.AccountID,
+			NameKey:   record.NameKey,
+		})
+		if lookupErr == nil {
+			return sqlc.ManagementToken{}, "", errManagementTokenNameConflict
+		}
+		if !errors.Is(lookupErr, sql.ErrNoRows) {
+			return sqlc.ManagementToken{}, "", lookupErr
+		}
+		// Token hash or id collision: retry with fresh random values.
+	}
+	return sqlc.ManagementToken{}, "", errTrustedTokenGenerateFailed
+}
+
+func (a *MCPAuth) lookupManagementToken(ctx context.Context, token string) (sqlc.ManagementToken, bool) {
+	if a == nil || a.queries == nil || a.hasher == nil {
+		return sqlc.ManagementToken{}, false
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	trimmedToken := strings.TrimSpace(token)
+	if !strings.HasPrefix(trimmedToken, managementTokenPrefix) {
+		return sqlc.ManagementToken{}, false
+	}
+	for _, candidate := range a.hasher.Candidates(trimmedToken) {
+		record, err := a.queries.GetManagementTokenByHash(ctx, candidate.Hash)
+		if err != nil {
+			continue
+		}
+		if !a.hasher.IsPrimary(candidate.KeyID) || !a.hasher.IsPrimary(record.HashKeyID) {
+			if _, err := a.queries.UpdateManagementTokenHash(ctx, sqlc.UpdateManagementTokenHashParams{
+				TokenHash:   a.hasher.Hash(trimmedToken),
+				HashKeyID:   a.hasher.PrimaryKeyID(),
+				TokenID:     record.TokenID,
+				TokenHash_2: record.TokenHash,
+			}); err != nil {
+				slog.Warn("failed to re-key management token", "token_id", record.TokenID, "error", err)
+			}
+		}
+		return record, true
+	}
+	return sqlc.ManagementToken{}, false
+}
+
+// recordManagementTokenUse runs after the handler chain so the stored status
+// code is the one returned to the caller.
+func (a *MCPAuth) recordManagementTokenUse(c *gin.Context, record sqlc.ManagementToken, permission string) {
+	ctx := context.WithoutCancel(c.Request.Context())
+	nowMS := a.now().UnixMilli()
+	event := sqlc.InsertManagementTokenEventParams{
+		TokenID:         record.TokenID,
+		AccountID:       record.AccountID,
+		Method:          c.Request.Method,
+		Route:           c.FullPath(),
+		Permission:      permission,
+		StatusCode:      int64(c.Writer.Status()),
+		IpAddress:       strings.TrimSpace(c.ClientIP()),
+		CreatedAtUnixMs: nowMS,
+	}
+	slog.Info("management token used",
+		"token_id", event.TokenID,
+		"account_id", event.AccountID,
+		"method", event.Method,
+		"route", event.Route,
+		"permission", event.Permission,
+		"status", event.StatusCode,
+		"ip", event.IpAddress,
+	)
+	if err := a.queries.InsertManagementTokenEvent(ctx, event); err != nil {
+		slog.Error("failed to record management token use", "token_id", record.TokenID, "error", err)
+	}
+	if err := a.queries.TouchManagementToken(ctx, sqlc.TouchManagementTokenParams{
+		LastUsedAtUnixMs: nowMS,
+		TokenID:          record.TokenID,
+	}); err != nil {
+		slog.Warn("failed to update management token last use", "token_id", record.TokenID, "error", err)
+	}
+}
+
+func (a *MCPAuth) now() time.Time {
+	if a != nil && a.nowFn != nil {
+		return a.nowFn()
+	}
+	return time.Now()
+}
+
+func normalizeManagementPermissions(values []string, isAdmin bool) ([]string, error) {
+	if len(values) == 0 {
+		return nil, errManagementTokenPermissionsRequired
+	}
+	granted := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		permission := strings.ToLower(strings.TrimSpace(value))
+		if !slices.Contains(managementPermissions, permission) {
+			return nil, fmt.Errorf("%w %q", errManagementTokenPermissionUnknown, value)
+		}
+		if _, adminOnly := adminManagementPermissions[permission]; adminOnly && !isAdmin {
+			return nil, errManagementTokenPermissionAdminOnly
+		}
+		granted[permission] = struct{}{}
+	}
+	// Keep the canonical order so stored permissions are stable.
+	normalized := make([]string, 0, len(granted))
+	for _, permission := range managementPermissions {
+		if _, ok := granted[permission]; ok {
+			normalized = append(normalized, permission)
+		}
+	}
+	return normalized, nil
+}
+
+func decodeManagementPermissions(value string) []string {
+	permissions := []string{}
+	if err := json.Unmarshal([]byte(value), &permissions); err != nil {
+		return []string{}
+	}
+	return permissions
+}
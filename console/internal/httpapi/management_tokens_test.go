@@ -0,0 +1,213 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func newManagementTokenTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+	seedTestAccount(t, db.Queries, "acc-test-member", "member-test", "member-password", false)
+
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	return mustNewRouter(t, handler, consoleAuth, mcpAuth)
+}
+
+func doBearerJSON(t *testing.T, router http.Handler, method string, path string, body string, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, path, nil)
+	} else {
+		req = httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(trustedTokenHeader, "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func createManagementTokenForTest(t *testing.T, router http.Handler, cookie *http.Cookie, body string) createManagementTokenResponse {
+	t.Helper()
+	rec := doJSON(t, router, http.MethodPost, "/api/v1/console/management-tokens", body, cookie)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected management token create 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	created := createManagementTokenResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode management token response: %v", err)
+	}
+	return created
+}
+
+func TestManagementTokenAuthenticatesRoutesByPermission(t *testing.T) {
+	router := newManagementTokenTestRouter(t)
+	cookie := loginSessionCookie(t, router)
+
+	created := createManagementTokenForTest(t, router, cookie, `{"name":"infra","permissions":["tokens:write","workers:read","workers:read"]}`)
+	if !strings.HasPrefix(created.Token, managementTokenPrefix) {
+		t.Fatalf("expected generated management token, got %q", created.Token)
+	}
+	if strings.Join(created.Permissions, ",") != "workers:read,tokens:write" {
+		t.Fatalf("expected canonical permissions, got %v", created.Permissions)
+	}
+
+	if rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/workers", "", created.Token); rec.Code != http.StatusOK {
+		t.Fatalf("expected workers list 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doBearerJSON(t, router, http.MethodPost, "/api/v1/console/tokens", `{"name":"ci"}`, created.Token); rec.Code != http.StatusCreated {
+		t.Fatalf("expected execution token create 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/console/tokens", "", created.Token); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected token list without tokens:read 403, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doBearerJSON(t, router, http.MethodDelete, "/api/v1/workers/node-x", "", created.Token); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected worker delete without workers:write 403, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/console/accounts", "", created.Token); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected account list without accounts:read 403, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// Management tokens never reach session-only routes, and execution tokens
+	// are not accepted as management tokens.
+	if rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/console/management-tokens", "", created.Token); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected management token list via token 401, got %d", rec.Code)
+	}
+	if rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/workers", "", "obm_not-a-real-token"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected unknown management token 401, got %d", rec.Code)
+	}
+	executionToken := "exec-token-value"
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/console/tokens", `{"name":"exec","token":"`+executionToken+`"}`, cookie); rec.Code != http.StatusCreated {
+		t.Fatalf("expected execution token create 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/workers", "", executionToken); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected execution token on management route 401, got %d", rec.Code)
+	}
+
+	// Session access keeps working for the same routes.
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/console/tokens", "", cookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected session token list 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec := doJSON(t, router, http.MethodGet, "/api/v1/console/management-tokens/"+created.ID+"/events", "", cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected events 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	events := managementTokenEventListResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &events); err != nil {
+		t.Fatalf("decode events: %v", err)
+	}
+	if events.Total != 5 {
+		t.Fatalf("expected 5 recorded uses, got %#v", events.Items)
+	}
+	latest := events.Items[0]
+	if latest.Method != http.MethodGet || latest.Route != "/api/v1/console/accounts" ||
+		latest.Permission != managementPermissionAccountsRead || latest.StatusCode != http.StatusForbidden {
+		t.Fatalf("unexpected latest event %#v", latest)
+	}
+	if first := events.Items[len(events.Items)-1]; first.Route != "/api/v1/workers" || first.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected first event %#v", first)
+	}
+
+	rec = doJSON(t, router, http.MethodGet, "/api/v1/console/management-tokens", "", cookie)
+	list := managementTokenListResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode management token list: %v", err)
+	}
+	if list.Total != 1 || list.Items[0].LastUsedAt == nil || strings.Contains(rec.Body.String(), created.Token) {
+		t.Fatalf("unexpected management token list %s", rec.Body.String())
+	}
+
+	if rec := doJSON(t, router, http.MethodDelete, "/api/v1/console/management-tokens/"+created.ID, "", cookie); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected delete 204, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/workers", "", created.Token); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected deleted management token 401, got %d", rec.Code)
+	}
+}
+
+func TestManagementTokenAccountPermissions(t *testing.T) {
+	router := newManagementTokenTestRouter(t)
+	adminCookie := loginSessionCookie(t, router)
+	memberCookie := loginSessionCookieFor(t, router, "member-test", "member-password")
+
+	rec := doJSON(t, router, http.MethodPost, "/api/v1/console/management-tokens", `{"name":"accounts","permissions":["accounts:read"]}`, memberCookie)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected member accounts permission 403, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec = doJSON(t, router, http.MethodPost, "/api/v1/console/management-tokens", `{"name":"bad","permissions":["workers:admin"]}`, adminCookie)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected unknown permission 400, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec = doJSON(t, router, http.MethodPost, "/api/v1/console/management-tokens", `{"name":"empty","permissions":[]}`, adminCookie)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected empty permissions 400, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	created := createManagementTokenForTest(t, router, adminCookie, `{"name":"accounts","permissions":["accounts:read","accounts:write"]}`)
+	rec = doJSON(t, router, http.MethodPost, "/api/v1/console/management-tokens", `{"name":"Accounts","permissions":["workers:read"]}`, adminCookie)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected duplicate name 409, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec = doBearerJSON(t, router, http.MethodGet, "/api/v1/console/accounts", "", created.Token)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "member-test") {
+		t.Fatalf("expected account list 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	// Events are scoped to the token owner.
+	rec = doJSON(t, router, http.MethodGet, "/api/v1/console/management-tokens/"+created.ID+"/events", "", memberCookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"total":0`) {
+		t.Fatalf("expected no events for other account, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodDelete, "/api/v1/console/management-tokens/"+created.ID, "", memberCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected delete of other account token 404, got %d", rec.Code)
+	}
+
+	if rec := doBearerJSON(t, router, http.MethodDelete, "/api/v1/console/accounts/acc-test-member", "", created.Token); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected account delete 204, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/console/management-tokens", "", memberCookie); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected deleted member session 401, got %d", rec.Code)
+	}
+}
+
+func TestManagementTokenRespectsTOTPEnrollmentPolicy(t *testing.T) {
+	router := newManagementTokenTestRouter(t)
+	cookie := loginSessionCookie(t, router)
+	created := createManagementTokenForTest(t, router, cookie, `{"name":"infra","permissions":["workers:read"]}`)
+
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/console/settings/security", `{"require_totp":true}`, cookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected policy update 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/workers", "", created.Token)
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), errTOTPEnrollmentRequired.Error()) {
+		t.Fatalf("expected token of unenrolled account to be gated, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	enrollTestTOTP(t, router, cookie, time.Now())
+	if rec := doBearerJSON(t, router, http.MethodGet, "/api/v1/workers", "", created.Token); rec.Code != http.StatusOK {
+		t.Fatalf("expected token access after enrollment, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
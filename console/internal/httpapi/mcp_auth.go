@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
@@ -124,15 +125,41 @@ func (a *MCPAuth) RequireToken() gin.HandlerFunc {
 		}
 		record, ok := a.lookupTrustedToken(c.Request.Context(), token)
 		if !ok {
+			a.recordAudit(c, persistence.AuditEvent{
+				Action:  auditActionTokenRejected,
+				Outcome: persistence.AuditOutcomeDenied,
+				Details: map[string]any{"kind": "access", "route": c.FullPath()},
+			})
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing token"})
 			c.Abort()
 			return
 		}
-		setRequestOwnerID(c, strings.TrimSpace(record.AccountID))
+		ownerID, err := a.resolveTokenOwnerID(c.Request.Context(), record)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve token owner"})
+			c.Abort()
+			return
+		}
+		setRequestOwnerID(c, ownerID)
+		setRequestAccessTokenID(c, record.TokenID)
+		c.Set(requestTokenAccountIDGinKey, strings.TrimSpace(record.AccountID))
 		c.Next()
 	}
 }
 
+// resolveTokenOwnerID returns the owner scope a token acts for: its
+// organization for org tokens, otherwise the account that created it.
+func (a *MCPAuth) resolveTokenOwnerID(ctx context.Context, record sqlc.TrustedToken) (string, error) {
+	orgID, err := a.queries.GetOrganizationIDByToken(ctx, record.TokenID)
+	if err == nil {
+		return strings.TrimSpace(orgID), nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return strings.TrimSpace(record.AccountID), nil
+	}
+	return "", err
+}
+
 func parseBearerToken(value string) (string, bool) {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == "" {
@@ -227,6 +254,12 @@ func (a *MCPAuth) CreateToken(c *gin.Context) {
 		return
 	}
 
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionTokenCreate,
+		TargetType: "access_token",
+		TargetID:   record.ID,
+		Details:    map[string]any{"name": record.Name, "generated": generated},
+	})
 	c.JSON(http.StatusCreated, createTrustedTokenResponse{
 		ID:          record.ID,
 		Name:        record.Name,
@@ -263,6 +296,11 @@ func (a *MCPAuth) DeleteToken(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete token"})
 		return
 	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionTokenDelete,
+		TargetType: "access_token",
+		TargetID:   tokenID,
+	})
 	c.Status(http.StatusNoContent)
 }
 
@@ -297,6 +335,11 @@ func (a *MCPAuth) createToken(ctx context.Context, accountID string, name string
 		if err != nil {
 			return trustedTokenRecord{}, false, err
 		}
+		// The unique index only covers primary-key hashes; a value stored under
+		// a retired key must also count as taken.
+		if _, exists := a.lookupTrustedToken(ctx, tokenValue); exists {
+			return trustedTokenRecord{}, false, errTrustedTokenValueConflict
+		}
 	}
 
 	for i := 0; i < 8; i++ {
@@ -341,6 +384,7 @@ func (a *MCPAuth) createToken(ctx context.Context, accountID string, name string
 			Generated:       boolToInt64(record.Generated),
 			CreatedAtUnixMs: record.CreatedAt.UnixMilli(),
 			UpdatedAtUnixMs: record.UpdatedAt.UnixMilli(),
+			HashKeyID:       a.hasher.PrimaryKeyID(),
 		})
 		if err == nil {
 			return record, generated, nil
@@ -407,15 +451,35 @@ func (a *MCPAuth) lookupTrustedToken(ctx context.Context, token string) (sqlc.Tr
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	tokenHash := a.hasher.Hash(strings.TrimSpace(token))
-	record, err := a.queries.GetTrustedTokenByHash(ctx, tokenHash)
-	if err != nil {
-		return sqlc.TrustedToken{}, false
+	// Try the primary key first, then retired keys, so tokens keep working
+	// while CONSOLE_HASH_KEY is rotated.
+	for _, candidate := range a.hasher.Candidates(strings.TrimSpace(token)) {
+		record, err := a.queries.GetTrustedTokenByHash(ctx, candidate.Hash)
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(record.AccountID) == "" {
+			return sqlc.TrustedToken{}, false
+		}
+		if !a.hasher.IsPrimary(candidate.KeyID) || !a.hasher.IsPrimary(record.HashKeyID) {
+			a.rekeyTrustedToken(ctx, record, strings.TrimSpace(token))
+		}
+		return record, true
 	}
-	if strings.TrimSpace(record.AccountID) == "" {
-		return sqlc.TrustedToken{}, false
+	return sqlc.TrustedToken{}, false
+}
+
+// rekeyTrustedToken rewrites a token hash with the primary key after the token
+// was verified with a retired one.
+func (a *MCPAuth) rekeyTrustedToken(ctx context.Context, record sqlc.TrustedToken, token string) {
+	if _, err := a.queries.UpdateTrustedTokenHash(ctx, sqlc.UpdateTrustedTokenHashParams{
+		TokenHash:   a.hasher.Hash(token),
+		HashKeyID:   a.hasher.PrimaryKeyID(),
+		TokenID:     record.TokenID,
+		TokenHash_2: record.TokenHash,
+	}); err != nil {
+		slog.Warn("failed to re-key trusted token", "token_id", record.TokenID, "error", err)
 	}
-	return record, true
 }
 
 func normalizeTokenName(value string) (string, string, error) {
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
 )
 
 func TestNewMCPAuthWithPersistenceNilReturnsError(t *testing.T) {
@@ -153,6 +154,47 @@ func TestMCPAuthRequireTokenAllowsTrustedToken(t *testing.T) {
 	}
 }
 
+func TestMCPAuthRekeysTokenHashedWithRetiredKey(t *testing.T) {
+	auth := newBareTestMCPAuth(t)
+	token := "token-a"
+	created, _, err := auth.createToken(context.Background(), testDashboardAccountID, "token-a", &token)
+	if err != nil {
+		t.Fatalf("seed token: %v", err)
+	}
+
+	rotated, err := persistence.NewKeyedHasher(
+		persistence.HashKey{ID: "k2", Secret: "rotated-hash-key"},
+		[]persistence.HashKey{{ID: persistence.DefaultHashKeyID, Secret: "test-hash-key"}},
+	)
+	if err != nil {
+		t.Fatalf("new keyed hasher: %v", err)
+	}
+	auth.hasher = rotated
+
+	if _, ok := auth.lookupTrustedToken(context.Background(), token); !ok {
+		t.Fatalf("expected token hashed with retired key to be accepted")
+	}
+	stored, err := auth.queries.GetTrustedTokenByID(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("load token: %v", err)
+	}
+	if stored.HashKeyID != "k2" || stored.TokenHash != rotated.Hash(token) {
+		t.Fatalf("expected token re-keyed to primary key, got key=%q", stored.HashKeyID)
+	}
+	if _, ok := auth.lookupTrustedToken(context.Background(), token); !ok {
+		t.Fatalf("expected re-keyed token to be accepted")
+	}
+
+	retiredOnly, err := persistence.NewKeyedHasher(persistence.HashKey{ID: "k3", Secret: "another-hash-key"}, nil)
+	if err != nil {
+		t.Fatalf("new keyed hasher: %v", err)
+	}
+	auth.hasher = retiredOnly
+	if _, ok := auth.lookupTrustedToken(context.Background(), token); ok {
+		t.Fatalf("expected token to be rejected once its key is removed")
+	}
+}
+
 func TestMCPAuthRequireTokenRejectsWhenStoreIsEmpty(t *testing.T) {
 	auth := newBareTestMCPAuth(t)
 	router := gin.New()
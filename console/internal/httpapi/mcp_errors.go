@@ -42,7 +42,13 @@ func mapMCPToolEchoError(err error) error {
 
 func mapMCPToolTaskSubmitError(err error) error {
 	var commandErr *grpcserver.CommandExecutionError
+	var policyErr *grpcserver.CommandPolicyError
+	var quotaErr *grpcserver.QuotaExceededError
 	switch {
+	case errors.As(err, &policyErr):
+		return errors.New(policyErr.Error())
+	case errors.As(err, &quotaErr):
+		return errors.New(quotaErr.Error())
 	case errors.Is(err, grpcserver.ErrTaskRequestInProgress):
 		return errors.New("task request already in progress")
 	case errors.Is(err, grpcserver.ErrNoCapabilityWorker):
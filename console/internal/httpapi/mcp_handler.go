@@ -93,6 +93,68 @@ func NewMCPHandler(dispatcher CommandDispatcher) http.Handler {
 		return handleMCPReadImageTool(ctx, dispatcher, input)
 	})
 
+	mcp.AddTool(server, &mcp.Tool{
+		Title:       mcpReadFileToolTitle,
+		Name:        "readFile",
+		Description: mcpReadFileToolDescription,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           mcpReadFileToolTitle,
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			OpenWorldHint:   boolPtr(true),
+		},
+		InputSchema:  mcpReadFileInputSchema,
+		OutputSchema: mcpReadFileOutputSchema,
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, input mcpReadFileToolInput) (*mcp.CallToolResult, mcpReadFileToolOutput, error) {
+		return handleMCPReadFileTool(ctx, dispatcher, input)
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Title:       mcpWriteFileToolTitle,
+		Name:        "writeFile",
+		Description: mcpWriteFileToolDescription,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           mcpWriteFileToolTitle,
+			DestructiveHint: boolPtr(true),
+			OpenWorldHint:   boolPtr(true),
+		},
+		InputSchema:  mcpWriteFileInputSchema,
+		OutputSchema: mcpWriteFileOutputSchema,
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, input mcpWriteFileToolInput) (*mcp.CallToolResult, mcpWriteFileToolOutput, error) {
+		return handleMCPWriteFileTool(ctx, dispatcher, input)
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Title:       mcpListDirToolTitle,
+		Name:        "listDir",
+		Description: mcpListDirToolDescription,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           mcpListDirToolTitle,
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			OpenWorldHint:   boolPtr(true),
+		},
+		InputSchema:  mcpListDirInputSchema,
+		OutputSchema: mcpListDirOutputSchema,
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, input mcpListDirToolInput) (*mcp.CallToolResult, mcpListDirToolOutput, error) {
+		return handleMCPListDirTool(ctx, dispatcher, input)
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Title:       mcpScreenshotToolTitle,
+		Name:        "screenshot",
+		Description: mcpScreenshotToolDescription,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           mcpScreenshotToolTitle,
+			ReadOnlyHint:    true,
+			DestructiveHint: boolPtr(false),
+			OpenWorldHint:   boolPtr(true),
+		},
+		InputSchema: mcpScreenshotInputSchema,
+	}, func(ctx context.Context, _ *mcp.CallToolRequest, input mcpScreenshotToolInput) (*mcp.CallToolResult, any, error) {
+		return handleMCPScreenshotTool(ctx, dispatcher, input)
+	})
+
 	return mcp.NewStreamableHTTPHandler(func(_ *http.Request) *mcp.Server {
 		return server
 	}, &mcp.StreamableHTTPOptions{
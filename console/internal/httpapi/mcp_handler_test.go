@@ -78,8 +78,8 @@ func TestMCPToolsList(t *testing.T) {
 	if !ok {
 		t.Fatalf("expected tools array, got %#v", result["tools"])
 	}
-	if len(toolsRaw) != 5 {
-		t.Fatalf("expected exactly 5 tools, got %d", len(toolsRaw))
+	if len(toolsRaw) != 9 {
+		t.Fatalf("expected exactly 9 tools, got %d", len(toolsRaw))
 	}
 
 	toolByName := map[string]map[string]any{}
@@ -105,6 +105,11 @@ func TestMCPToolsList(t *testing.T) {
 	if _, ok := toolByName["readImage"]; !ok {
 		t.Fatalf("expected tool readImage in tools/list")
 	}
+	for _, name := range []string{"readFile", "writeFile", "listDir", "screenshot"} {
+		if _, ok := toolByName[name]; !ok {
+			t.Fatalf("expected tool %s in tools/list", name)
+		}
+	}
 
 	echoTool := toolByName["echo"]
 	if got := asString(t, echoTool["title"]); got != mcpEchoToolTitle {
@@ -272,13 +277,17 @@ func TestMCPToolsList(t *testing.T) {
 	computerUseInputSchema := mustObject(t, computerUseTool["inputSchema"], "computerUse.inputSchema")
 	assertRequiredContains(t, computerUseInputSchema["required"], "command")
 	computerUseInputProperties := mustObject(t, computerUseInputSchema["properties"], "computerUse.inputSchema.properties")
-	if _, ok := computerUseInputProperties["lease_ttl_sec"]; ok {
-		t.Fatalf("did not expect computerUse.inputSchema.properties.lease_ttl_sec")
+	for _, name := range []string{"session_id", "create_if_missing", "lease_ttl_sec"} {
+		if _, ok := computerUseInputProperties[name]; !ok {
+			t.Fatalf("expected computerUse.inputSchema.properties.%s", name)
+		}
 	}
 	computerUseOutputSchema := mustObject(t, computerUseTool["outputSchema"], "computerUse.outputSchema")
 	computerUseOutputProperties := mustObject(t, computerUseOutputSchema["properties"], "computerUse.outputSchema.properties")
-	if _, ok := computerUseOutputProperties["lease_expires_unix_ms"]; ok {
-		t.Fatalf("did not expect computerUse.outputSchema.properties.lease_expires_unix_ms")
+	for _, name := range []string{"session_id", "session_closed", "lease_expires_unix_ms"} {
+		if _, ok := computerUseOutputProperties[name]; !ok {
+			t.Fatalf("expected computerUse.outputSchema.properties.%s", name)
+		}
 	}
 	computerUseExitCode := mustObject(t, computerUseOutputProperties["exit_code"], "computerUse.outputSchema.properties.exit_code")
 	if got := asString(t, computerUseExitCode["type"]); got != "integer" {
@@ -484,6 +493,90 @@ func TestMCPToolCallComputerUseSuccess(t *testing.T) {
 	}
 }
 
+func TestMCPToolCallComputerUseSessionFields(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	router := newMCPTestRouter(t, &fakeMCPDispatcher{
+		submitTask: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
+			payload := computerUsePayload{}
+			if err := json.Unmarshal(req.InputJSON, &payload); err != nil {
+				t.Fatalf("expected valid computerUse payload, got %s", string(req.InputJSON))
+			}
+			if payload.SessionID != "build" || !payload.CreateIfMissing || payload.LeaseTTLSec == nil || *payload.LeaseTTLSec != 120 {
+				t.Fatalf("expected session fields to be forwarded, got %s", string(req.InputJSON))
+			}
+			resultJSON, _ := json.Marshal(mcpComputerUseToolOutput{
+				SessionID:          "build",
+				Created:            true,
+				Stdout:             "/workspace\n",
+				LeaseExpiresUnixMS: now.Add(120 * time.Second).UnixMilli(),
+			})
+			return grpcserver.SubmitTaskResult{
+				Task: grpcserver.TaskSnapshot{
+					TaskID:     "task-cu-session",
+					Capability: computerUseCapabilityName,
+					Status:     grpcserver.TaskStatusSucceeded,
+					ResultJSON: resultJSON,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+					DeadlineAt: now.Add(60 * time.Second),
+				},
+				Completed: true,
+			}, nil
+		},
+	})
+
+	payload := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"computerUse","arguments":{"command":"pwd","session_id":"build","create_if_missing":true,"lease_ttl_sec":120}}}`)
+	result := mustMapField(t, payload, "result")
+	if asBool(result["isError"]) {
+		t.Fatalf("expected tool call success, got error payload=%s", mustJSON(t, result))
+	}
+	structured := mustMapField(t, result, "structuredContent")
+	if got := asString(t, structured["session_id"]); got != "build" {
+		t.Fatalf("expected session_id=build, got %q", got)
+	}
+	if !asBool(structured["created"]) {
+		t.Fatalf("expected created=true, got %s", mustJSON(t, structured))
+	}
+}
+
+func TestMCPToolCallComputerUseAsyncPendingApproval(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	router := newMCPTestRouter(t, &fakeMCPDispatcher{
+		submitTask: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
+			if !req.ReturnOnPendingApproval {
+				t.Fatalf("expected async approval mode to return on pending approval")
+			}
+			return grpcserver.SubmitTaskResult{
+				Task: grpcserver.TaskSnapshot{
+					TaskID:     "task-cu-held",
+					Capability: computerUseCapabilityName,
+					Status:     grpcserver.TaskStatusPendingApproval,
+					ApprovalID: "approval-1",
+					CreatedAt:  now,
+					UpdatedAt:  now,
+					DeadlineAt: now.Add(60 * time.Second),
+				},
+			}, nil
+		},
+	})
+
+	payload := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"computerUse","arguments":{"command":"reboot","request_id":"req-held","approval_mode":"async"}}}`)
+	result := mustMapField(t, payload, "result")
+	if asBool(result["isError"]) {
+		t.Fatalf("expected pending handle, got error payload=%s", mustJSON(t, result))
+	}
+	structured := mustMapField(t, result, "structuredContent")
+	if got := asString(t, structured["status"]); got != "pending_approval" {
+		t.Fatalf("expected status=pending_approval, got %q", got)
+	}
+	if asString(t, structured["task_id"]) != "task-cu-held" || asString(t, structured["approval_id"]) != "approval-1" {
+		t.Fatalf("unexpected pending handle %s", mustJSON(t, structured))
+	}
+
+	invalid := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"computerUse","arguments":{"command":"reboot","approval_mode":"later"}}}`)
+	assertMCPInvalidParamsError(t, invalid)
+}
+
 func TestMCPToolCallReadImageSuccess(t *testing.T) {
 	now := time.Unix(1_700_000_000, 0)
 	router := newMCPTestRouter(t, &fakeMCPDispatcher{
@@ -654,6 +747,68 @@ func TestMCPToolCallReadImageComputerUseSessionRoutesToReadImageCapability(t *te
 	}
 }
 
+func TestMCPToolCallReadImageForwardsResizeOptionsAndReportsDimensions(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	router := newMCPTestRouter(t, &fakeMCPDispatcher{
+		submitTask: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
+			payload := mcpTerminalResourcePayload{}
+			if err := json.Unmarshal(req.InputJSON, &payload); err != nil {
+				t.Fatalf("expected valid terminalResource payload, got %s", string(req.InputJSON))
+			}
+			result := mcpTerminalResourceResult{
+				SessionID: payload.SessionID,
+				FilePath:  payload.FilePath,
+				MIMEType:  "image/png",
+				SizeBytes: 4096,
+			}
+			if payload.Action == "read" {
+				if payload.MaxWidth != 800 || payload.MaxBytes != 200000 || payload.Format != "webp" {
+					t.Fatalf("expected resize options on read payload, got %s", string(req.InputJSON))
+				}
+				result.MIMEType = "image/webp"
+				result.SizeBytes = 4
+				result.Width, result.Height = 800, 450
+				result.OriginalWidth, result.OriginalHeight = 3840, 2160
+				result.Blob = []byte{0x52, 0x49, 0x46, 0x46}
+			}
+			resultJSON, _ := json.Marshal(result)
+			return grpcserver.SubmitTaskResult{
+				Task: grpcserver.TaskSnapshot{
+					TaskID:     "task-read-image-" + payload.Action,
+					Capability: req.Capability,
+					Status:     grpcserver.TaskStatusSucceeded,
+					ResultJSON: resultJSON,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+					DeadlineAt: now.Add(60 * time.Second),
+				},
+				Completed: true,
+			}, nil
+		},
+	})
+
+	payload := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"readImage","arguments":{"session_id":"session-1","file_path":"/workspace/shot.png","max_width":800,"max_bytes":200000,"format":"webp"}}}`)
+	result := mustMapField(t, payload, "result")
+	if asBool(result["isError"]) {
+		t.Fatalf("expected tool call success, got error payload=%s", mustJSON(t, result))
+	}
+	contentRaw, ok := result["content"].([]any)
+	if !ok || len(contentRaw) != 2 {
+		t.Fatalf("expected [text, image] content, got %s", mustJSON(t, result))
+	}
+	text := mustObject(t, contentRaw[0], "readImage.content[0]")
+	if got := asString(t, text["text"]); got != "image 800x450 (original 3840x2160)" {
+		t.Fatalf("unexpected dimensions text %q", got)
+	}
+	image := mustObject(t, contentRaw[1], "readImage.content[1]")
+	if got := asString(t, image["mimeType"]); got != "image/webp" {
+		t.Fatalf("expected image mimeType=image/webp, got %q", got)
+	}
+
+	badFormat := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"readImage","arguments":{"session_id":"session-1","file_path":"/workspace/shot.png","format":"gif"}}}`)
+	assertMCPInvalidParamsError(t, badFormat)
+}
+
 func TestMCPToolCallReadImageComputerUseSessionIsCaseSensitive(t *testing.T) {
 	now := time.Unix(1_700_000_000, 0)
 	router := newMCPTestRouter(t, &fakeMCPDispatcher{
@@ -964,6 +1119,128 @@ func TestMCPToolCallReadImageValidationErrors(t *testing.T) {
 	}
 }
 
+func TestMCPToolCallFileToolsRouteToWorkerSysCapabilities(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	capabilities := make([]string, 0, 3)
+	router := newMCPTestRouter(t, &fakeMCPDispatcher{
+		submitTask: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
+			if req.OwnerID != testDashboardAccountID {
+				t.Fatalf("expected owner_id from token, got %q", req.OwnerID)
+			}
+			capabilities = append(capabilities, req.Capability)
+			var resultJSON []byte
+			switch req.Capability {
+			case readFileCapabilityName:
+				payload := readFilePayload{}
+				if err := json.Unmarshal(req.InputJSON, &payload); err != nil || payload.SessionID != computerUseSessionID || payload.Offset != 2 {
+					t.Fatalf("unexpected readFile payload %s", string(req.InputJSON))
+				}
+				resultJSON, _ = json.Marshal(mcpReadFileToolOutput{SessionID: payload.SessionID, FilePath: payload.FilePath, SizeBytes: 7, Offset: 2, Content: "llo", Encoding: "utf-8", Truncated: true})
+			case writeFileCapabilityName:
+				payload := writeFilePayload{}
+				if err := json.Unmarshal(req.InputJSON, &payload); err != nil || payload.Content != "hi" || !payload.Append {
+					t.Fatalf("unexpected writeFile payload %s", string(req.InputJSON))
+				}
+				resultJSON, _ = json.Marshal(mcpWriteFileToolOutput{SessionID: payload.SessionID, FilePath: payload.FilePath, BytesWritten: 2, SizeBytes: 9})
+			case listDirCapabilityName:
+				resultJSON = []byte(`{"session_id":"computerUse","dir_path":"/srv","entries":null,"truncated":false}`)
+			default:
+				t.Fatalf("unexpected capability %q", req.Capability)
+			}
+			return grpcserver.SubmitTaskResult{
+				Task: grpcserver.TaskSnapshot{
+					TaskID:     "task-file-" + req.Capability,
+					Capability: req.Capability,
+					Status:     grpcserver.TaskStatusSucceeded,
+					ResultJSON: resultJSON,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+					DeadlineAt: now.Add(60 * time.Second),
+				},
+				Completed: true,
+			}, nil
+		},
+	})
+
+	readPayload := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"readFile","arguments":{"session_id":"computerUse","file_path":"/srv/a.txt","offset":2}}}`)
+	readStructured := mustObject(t, mustMapField(t, readPayload, "result")["structuredContent"], "readFile.structuredContent")
+	if asString(t, readStructured["content"]) != "llo" || !asBool(readStructured["truncated"]) {
+		t.Fatalf("unexpected readFile output: %s", mustJSON(t, readStructured))
+	}
+
+	writePayload := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"writeFile","arguments":{"session_id":"computerUse","file_path":"/srv/a.txt","content":"hi","append":true}}}`)
+	writeResult := mustMapField(t, writePayload, "result")
+	if asBool(writeResult["isError"]) {
+		t.Fatalf("expected writeFile success, got %s", mustJSON(t, writeResult))
+	}
+
+	listPayload := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"listDir","arguments":{"session_id":"computerUse","dir_path":"/srv"}}}`)
+	listStructured := mustObject(t, mustMapField(t, listPayload, "result")["structuredContent"], "listDir.structuredContent")
+	if entries, ok := listStructured["entries"].([]any); !ok || len(entries) != 0 {
+		t.Fatalf("expected empty entries array, got %s", mustJSON(t, listStructured))
+	}
+
+	if len(capabilities) != 3 || capabilities[0] != readFileCapabilityName || capabilities[1] != writeFileCapabilityName || capabilities[2] != listDirCapabilityName {
+		t.Fatalf("unexpected capabilities %#v", capabilities)
+	}
+}
+
+func TestMCPToolCallScreenshotRoutesToWorkerSysCapability(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	router := newMCPTestRouter(t, &fakeMCPDispatcher{
+		submitTask: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
+			if req.Capability != screenshotCapabilityName {
+				t.Fatalf("expected screenshot capability, got %q", req.Capability)
+			}
+			if req.OwnerID != testDashboardAccountID {
+				t.Fatalf("expected owner_id from token, got %q", req.OwnerID)
+			}
+			if req.Timeout != 5*time.Second {
+				t.Fatalf("expected timeout 5s, got %s", req.Timeout)
+			}
+			resultJSON, _ := json.Marshal(screenshotResult{
+				MIMEType:       "image/png",
+				SizeBytes:      4,
+				Width:          640,
+				Height:         360,
+				OriginalWidth:  1920,
+				OriginalHeight: 1080,
+				Blob:           []byte{0x89, 0x50, 0x4e, 0x47},
+			})
+			return grpcserver.SubmitTaskResult{
+				Task: grpcserver.TaskSnapshot{
+					TaskID:     "task-screenshot",
+					Capability: req.Capability,
+					Status:     grpcserver.TaskStatusSucceeded,
+					ResultJSON: resultJSON,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+					DeadlineAt: now.Add(60 * time.Second),
+				},
+				Completed: true,
+			}, nil
+		},
+	})
+
+	payload := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"screenshot","arguments":{"timeout_ms":5000}}}`)
+	result := mustMapField(t, payload, "result")
+	if asBool(result["isError"]) {
+		t.Fatalf("expected tool call success, got error payload=%s", mustJSON(t, result))
+	}
+	contentRaw, ok := result["content"].([]any)
+	if !ok || len(contentRaw) != 2 {
+		t.Fatalf("expected [text, image] content, got %s", mustJSON(t, result))
+	}
+	text := mustObject(t, contentRaw[0], "screenshot.content[0]")
+	if got := asString(t, text["text"]); got != "screenshot 640x360 (original 1920x1080)" {
+		t.Fatalf("unexpected screenshot text %q", got)
+	}
+	image := mustObject(t, contentRaw[1], "screenshot.content[1]")
+	if asString(t, image["type"]) != "image" || asString(t, image["mimeType"]) != "image/png" {
+		t.Fatalf("unexpected screenshot image content %s", mustJSON(t, image))
+	}
+}
+
 func TestMCPToolCallInvalidParams(t *testing.T) {
 	router := newMCPTestRouter(t, &fakeMCPDispatcher{})
 
@@ -991,14 +1268,26 @@ func TestMCPToolCallInvalidParams(t *testing.T) {
 	computerUseUnknownField := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":8,"method":"tools/call","params":{"name":"computerUse","arguments":{"command":"pwd","unknown":"x"}}}`)
 	assertMCPInvalidParamsError(t, computerUseUnknownField)
 
-	computerUseLeaseTTLField := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":11,"method":"tools/call","params":{"name":"computerUse","arguments":{"command":"pwd","lease_ttl_sec":60}}}`)
-	assertMCPInvalidParamsError(t, computerUseLeaseTTLField)
+	computerUseLeaseTTLZero := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":11,"method":"tools/call","params":{"name":"computerUse","arguments":{"command":"pwd","session_id":"build","lease_ttl_sec":0}}}`)
+	assertMCPInvalidParamsError(t, computerUseLeaseTTLZero)
 
 	registerBlankSession := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":9,"method":"tools/call","params":{"name":"readImage","arguments":{"session_id":"  ","file_path":"/workspace/a.txt"}}}`)
 	assertMCPInvalidParamsError(t, registerBlankSession)
 
 	registerUnknownField := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":10,"method":"tools/call","params":{"name":"readImage","arguments":{"session_id":"session-1","file_path":"/workspace/a.txt","unknown":"x"}}}`)
 	assertMCPInvalidParamsError(t, registerUnknownField)
+
+	readFileTerminalSession := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":12,"method":"tools/call","params":{"name":"readFile","arguments":{"session_id":"session-1","file_path":"/workspace/a.txt"}}}`)
+	assertMCPInvalidParamsError(t, readFileTerminalSession)
+
+	writeFileBadEncoding := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":13,"method":"tools/call","params":{"name":"writeFile","arguments":{"session_id":"computerUse","file_path":"/workspace/a.txt","content":"x","encoding":"hex"}}}`)
+	assertMCPInvalidParamsError(t, writeFileBadEncoding)
+
+	listDirBlankPath := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":14,"method":"tools/call","params":{"name":"listDir","arguments":{"session_id":"computerUse","dir_path":"  "}}}`)
+	assertMCPInvalidParamsError(t, listDirBlankPath)
+
+	screenshotBadTimeout := mcpPostJSON(t, router, `{"jsonrpc":"2.0","id":15,"method":"tools/call","params":{"name":"screenshot","arguments":{"timeout_ms":0}}}`)
+	assertMCPInvalidParamsError(t, screenshotBadTimeout)
 }
 
 func TestMCPToolCallBackendErrorsAsToolErrors(t *testing.T) {
@@ -15,6 +15,10 @@ type mcpTerminalResourcePayload struct {
 	SessionID string `json:"session_id"`
 	FilePath  string `json:"file_path"`
 	Action    string `json:"action,omitempty"`
+	MaxWidth  int    `json:"max_width,omitempty"`
+	MaxHeight int    `json:"max_height,omitempty"`
+	MaxBytes  int    `json:"max_bytes,omitempty"`
+	Format    string `json:"format,omitempty"`
 }
 
 type mcpTerminalResourceResult struct {
@@ -22,7 +26,13 @@ type mcpTerminalResourceResult struct {
 	FilePath  string `json:"file_path"`
 	MIMEType  string `json:"mime_type"`
 	SizeBytes int64  `json:"size_bytes"`
-	Blob      []byte `json:"blob,omitempty"`
+	// Width and Height describe the returned image and Original* the file,
+	// when the worker could decode it.
+	Width          int    `json:"width,omitempty"`
+	Height         int    `json:"height,omitempty"`
+	OriginalWidth  int    `json:"original_width,omitempty"`
+	OriginalHeight int    `json:"original_height,omitempty"`
+	Blob           []byte `json:"blob,omitempty"`
 }
 
 func callTerminalResource(
@@ -69,6 +79,7 @@ func callResourceCapability(
 		Mode:       grpcserver.TaskModeSync,
 		Timeout:    timeoutValue,
 		OwnerID:    ownerID,
+		TokenID:    requestAccessTokenIDFromContext(ctx),
 	})
 	if err != nil {
 		return mcpTerminalResourceResult{}, mapMCPToolTaskSubmitError(err)
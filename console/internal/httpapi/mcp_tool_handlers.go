@@ -66,6 +66,7 @@ func handleMCPPythonExecTool(ctx context.Context, dispatcher CommandDispatcher,
 		Mode:       grpcserver.TaskModeSync,
 		Timeout:    time.Duration(timeoutMS) * time.Millisecond,
 		OwnerID:    ownerID,
+		TokenID:    requestAccessTokenIDFromContext(ctx),
 	})
 	if err != nil {
 		return nil, mcpPythonExecToolOutput{}, mapMCPToolTaskSubmitError(err)
@@ -132,6 +133,7 @@ func handleMCPTerminalExecTool(ctx context.Context, dispatcher CommandDispatcher
 		Mode:       grpcserver.TaskModeSync,
 		Timeout:    time.Duration(timeoutMS) * time.Millisecond,
 		OwnerID:    ownerID,
+		TokenID:    requestAccessTokenIDFromContext(ctx),
 	})
 	if err != nil {
 		return nil, mcpTerminalExecToolOutput{}, mapMCPToolTaskSubmitError(err)
@@ -163,6 +165,9 @@ func handleMCPComputerUseTool(ctx context.Context, dispatcher CommandDispatcher,
 	if strings.TrimSpace(input.Command) == "" {
 		return nil, mcpComputerUseToolOutput{}, invalidParamsError("command is required")
 	}
+	if input.LeaseTTLSec != nil && *input.LeaseTTLSec < minMCPTerminalLeaseSec {
+		return nil, mcpComputerUseToolOutput{}, invalidParamsError("lease_ttl_sec must be positive")
+	}
 
 	timeoutMS := defaultMCPTaskTimeoutMS
 	if input.TimeoutMS != nil {
@@ -171,6 +176,14 @@ func handleMCPComputerUseTool(ctx context.Context, dispatcher CommandDispatcher,
 	if timeoutMS < minMCPTaskTimeoutMS || timeoutMS > maxMCPTaskTimeoutMS {
 		return nil, mcpComputerUseToolOutput{}, invalidParamsError("timeout_ms must be between 1 and 600000")
 	}
+	approvalMode := strings.TrimSpace(strings.ToLower(input.ApprovalMode))
+	switch approvalMode {
+	case "":
+		approvalMode = mcpApprovalModeWait
+	case mcpApprovalModeWait, mcpApprovalModeAsync:
+	default:
+		return nil, mcpComputerUseToolOutput{}, invalidParamsError("approval_mode must be one of wait|async")
+	}
 	if dispatcher == nil {
 		return nil, mcpComputerUseToolOutput{}, errors.New("task dispatcher is unavailable")
 	}
@@ -179,22 +192,36 @@ func handleMCPComputerUseTool(ctx context.Context, dispatcher CommandDispatcher,
 		return nil, mcpComputerUseToolOutput{}, errors.New("request owner is required")
 	}
 
-	payloadJSON, err := json.Marshal(computerUsePayload{Command: input.Command})
+	payloadJSON, err := json.Marshal(computerUsePayload{
+		Command:         input.Command,
+		SessionID:       strings.TrimSpace(input.SessionID),
+		CreateIfMissing: input.CreateIfMissing,
+		LeaseTTLSec:     input.LeaseTTLSec,
+	})
 	if err != nil {
 		return nil, mcpComputerUseToolOutput{}, errors.New("failed to encode computerUse payload")
 	}
 
 	result, err := dispatcher.SubmitTask(ctx, grpcserver.SubmitTaskRequest{
-		Capability: computerUseCapabilityName,
-		InputJSON:  payloadJSON,
-		Mode:       grpcserver.TaskModeSync,
-		Timeout:    time.Duration(timeoutMS) * time.Millisecond,
-		RequestID:  strings.TrimSpace(input.RequestID),
-		OwnerID:    ownerID,
+		Capability:              computerUseCapabilityName,
+		InputJSON:               payloadJSON,
+		Mode:                    grpcserver.TaskModeSync,
+		Timeout:                 time.Duration(timeoutMS) * time.Millisecond,
+		RequestID:               strings.TrimSpace(input.RequestID),
+		OwnerID:                 ownerID,
+		TokenID:                 requestAccessTokenIDFromContext(ctx),
+		ReturnOnPendingApproval: approvalMode == mcpApprovalModeAsync,
 	})
 	if err != nil {
 		return nil, mcpComputerUseToolOutput{}, mapMCPToolTaskSubmitError(err)
 	}
+	if result.Task.Status == grpcserver.TaskStatusPendingApproval {
+		return nil, mcpComputerUseToolOutput{
+			Status:     string(grpcserver.TaskStatusPendingApproval),
+			TaskID:     result.Task.TaskID,
+			ApprovalID: result.Task.ApprovalID,
+		}, nil
+	}
 	if !result.Completed {
 		return nil, mcpComputerUseToolOutput{}, errors.New("computerUse task did not complete")
 	}
@@ -227,6 +254,16 @@ func handleMCPReadImageTool(ctx context.Context, dispatcher CommandDispatcher, i
 	if filePath == "" {
 		return nil, nil, invalidParamsError("file_path is required")
 	}
+	if input.MaxWidth < 0 || input.MaxHeight < 0 || input.MaxBytes < 0 {
+		return nil, nil, invalidParamsError("max_width, max_height and max_bytes must be positive")
+	}
+	format := strings.ToLower(strings.TrimSpace(input.Format))
+	switch format {
+	case "", "png", "jpeg", "webp":
+	default:
+		return nil, nil, invalidParamsError("format must be png, jpeg or webp")
+	}
+	resized := input.MaxWidth > 0 || input.MaxHeight > 0 || input.MaxBytes > 0 || format != ""
 
 	timeoutMS := defaultMCPTaskTimeoutMS
 	if input.TimeoutMS != nil {
@@ -269,6 +306,10 @@ func handleMCPReadImageTool(ctx context.Context, dispatcher CommandDispatcher, i
 		SessionID: sessionID,
 		FilePath:  filePath,
 		Action:    "read",
+		MaxWidth:  input.MaxWidth,
+		MaxHeight: input.MaxHeight,
+		MaxBytes:  input.MaxBytes,
+		Format:    format,
 	}, timeout)
 	if err != nil {
 		return nil, nil, err
@@ -289,12 +330,192 @@ func handleMCPReadImageTool(ctx context.Context, dispatcher CommandDispatcher, i
 	if blob == nil {
 		blob = []byte{}
 	}
+	content := make([]mcp.Content, 0, 2)
+	if resized && readResult.Width > 0 {
+		content = append(content, &mcp.TextContent{
+			Text: fmt.Sprintf("image %dx%d (original %dx%d)", readResult.Width, readResult.Height, readResult.OriginalWidth, readResult.OriginalHeight),
+		})
+	}
+	content = append(content, &mcp.ImageContent{
+		MIMEType: readMIMEType,
+		Data:     blob,
+	})
+	return &mcp.CallToolResult{Content: content}, nil, nil
+}
+
+func handleMCPReadFileTool(ctx context.Context, dispatcher CommandDispatcher, input mcpReadFileToolInput) (*mcp.CallToolResult, mcpReadFileToolOutput, error) {
+	if err := validateMCPFileToolSession(input.SessionID); err != nil {
+		return nil, mcpReadFileToolOutput{}, err
+	}
+	filePath := strings.TrimSpace(input.FilePath)
+	if filePath == "" {
+		return nil, mcpReadFileToolOutput{}, invalidParamsError("file_path is required")
+	}
+	if input.Offset < 0 || input.Limit < 0 {
+		return nil, mcpReadFileToolOutput{}, invalidParamsError("offset and limit must not be negative")
+	}
+
+	output := mcpReadFileToolOutput{}
+	if err := callWorkerSysCapability(ctx, dispatcher, readFileCapabilityName, readFilePayload{
+		SessionID: computerUseSessionID,
+		FilePath:  filePath,
+		Offset:    input.Offset,
+		Limit:     input.Limit,
+	}, input.TimeoutMS, &output); err != nil {
+		return nil, mcpReadFileToolOutput{}, err
+	}
+	return nil, output, nil
+}
+
+func handleMCPWriteFileTool(ctx context.Context, dispatcher CommandDispatcher, input mcpWriteFileToolInput) (*mcp.CallToolResult, mcpWriteFileToolOutput, error) {
+	if err := validateMCPFileToolSession(input.SessionID); err != nil {
+		return nil, mcpWriteFileToolOutput{}, err
+	}
+	filePath := strings.TrimSpace(input.FilePath)
+	if filePath == "" {
+		return nil, mcpWriteFileToolOutput{}, invalidParamsError("file_path is required")
+	}
+	encoding := strings.TrimSpace(strings.ToLower(input.Encoding))
+	switch encoding {
+	case "", "utf-8", "base64":
+	default:
+		return nil, mcpWriteFileToolOutput{}, invalidParamsError("encoding must be one of utf-8|base64")
+	}
+
+	output := mcpWriteFileToolOutput{}
+	if err := callWorkerSysCapability(ctx, dispatcher, writeFileCapabilityName, writeFilePayload{
+		SessionID: computerUseSessionID,
+		FilePath:  filePath,
+		Content:   input.Content,
+		Encoding:  encoding,
+		Append:    input.Append,
+	}, input.TimeoutMS, &output); err != nil {
+		return nil, mcpWriteFileToolOutput{}, err
+	}
+	return nil, output, nil
+}
+
+func handleMCPListDirTool(ctx context.Context, dispatcher CommandDispatcher, input mcpListDirToolInput) (*mcp.CallToolResult, mcpListDirToolOutput, error) {
+	if err := validateMCPFileToolSession(input.SessionID); err != nil {
+		return nil, mcpListDirToolOutput{}, err
+	}
+	dirPath := strings.TrimSpace(input.DirPath)
+	if dirPath == "" {
+		return nil, mcpListDirToolOutput{}, invalidParamsError("dir_path is required")
+	}
+
+	output := mcpListDirToolOutput{}
+	if err := callWorkerSysCapability(ctx, dispatcher, listDirCapabilityName, listDirPayload{
+		SessionID: computerUseSessionID,
+		DirPath:   dirPath,
+	}, input.TimeoutMS, &output); err != nil {
+		return nil, mcpListDirToolOutput{}, err
+	}
+	if output.Entries == nil {
+		output.Entries = []mcpListDirEntry{}
+	}
+	return nil, output, nil
+}
+
+func handleMCPScreenshotTool(ctx context.Context, dispatcher CommandDispatcher, input mcpScreenshotToolInput) (*mcp.CallToolResult, any, error) {
+	result := screenshotResult{}
+	if err := callWorkerSysCapability(ctx, dispatcher, screenshotCapabilityName, screenshotPayload{}, input.TimeoutMS, &result); err != nil {
+		return nil, nil, err
+	}
+
+	mimeType := normalizeMIME(result.MIMEType)
+	if !isImageMIME(mimeType) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Text: fmt.Sprintf("unsupported mime type: %s; expected image/*", mimeType),
+				},
+			},
+		}, nil, nil
+	}
+
+	blob := append([]byte(nil), result.Blob...)
+	if blob == nil {
+		blob = []byte{}
+	}
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("screenshot %dx%d (original %dx%d)", result.Width, result.Height, result.OriginalWidth, result.OriginalHeight),
+			},
 			&mcp.ImageContent{
-				MIMEType: readMIMEType,
+				MIMEType: mimeType,
 				Data:     blob,
 			},
 		},
 	}, nil, nil
 }
+
+// validateMCPFileToolSession applies the readImage routing convention to the
+// file tools: only session_id "computerUse" has a backend, worker-sys.
+func validateMCPFileToolSession(sessionID string) error {
+	switch strings.TrimSpace(sessionID) {
+	case "":
+		return invalidParamsError("session_id is required")
+	case computerUseSessionID:
+		return nil
+	default:
+		return invalidParamsError(`session_id must be "computerUse"`)
+	}
+}
+
+// callWorkerSysCapability submits payload to the caller-owned worker-sys
+// and decodes a successful result into out.
+func callWorkerSysCapability(ctx context.Context, dispatcher CommandDispatcher, capability string, payload any, timeoutMSInput *int, out any) error {
+	timeoutMS := defaultMCPTaskTimeoutMS
+	if timeoutMSInput != nil {
+		timeoutMS = *timeoutMSInput
+	}
+	if timeoutMS < minMCPTaskTimeoutMS || timeoutMS > maxMCPTaskTimeoutMS {
+		return invalidParamsError("timeout_ms must be between 1 and 600000")
+	}
+	if dispatcher == nil {
+		return errors.New("task dispatcher is unavailable")
+	}
+	ownerID := requestOwnerIDFromContext(ctx)
+	if ownerID == "" {
+		return errors.New("request owner is required")
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload", capability)
+	}
+
+	result, err := dispatcher.SubmitTask(ctx, grpcserver.SubmitTaskRequest{
+		Capability: capability,
+		InputJSON:  payloadJSON,
+		Mode:       grpcserver.TaskModeSync,
+		Timeout:    time.Duration(timeoutMS) * time.Millisecond,
+		OwnerID:    ownerID,
+		TokenID:    requestAccessTokenIDFromContext(ctx),
+	})
+	if err != nil {
+		return mapMCPToolTaskSubmitError(err)
+	}
+	if !result.Completed {
+		return fmt.Errorf("%s task did not complete", capability)
+	}
+
+	task := result.Task
+	switch task.Status {
+	case grpcserver.TaskStatusSucceeded:
+		if err := json.Unmarshal(task.ResultJSON, out); err != nil {
+			return fmt.Errorf("invalid %s result payload", capability)
+		}
+		return nil
+	case grpcserver.TaskStatusTimeout:
+		return errors.New("task timed out")
+	case grpcserver.TaskStatusCanceled:
+		return errors.New("task canceled")
+	case grpcserver.TaskStatusFailed:
+		return formatTaskFailureError(task)
+	default:
+		return fmt.Errorf("unexpected task status: %s", task.Status)
+	}
+}
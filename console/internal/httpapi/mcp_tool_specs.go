@@ -7,7 +7,13 @@ const (
 	terminalResourceCapabilityName = "terminalResource"
 	computerUseCapabilityName      = "computerUse"
 	readImageCapabilityName        = "readImage"
+	readFileCapabilityName         = "readFile"
+	writeFileCapabilityName        = "writeFile"
+	listDirCapabilityName          = "listDir"
+	screenshotCapabilityName       = "screenshot"
 	computerUseSessionID           = "computerUse"
+	mcpApprovalModeWait            = "wait"
+	mcpApprovalModeAsync           = "async"
 	defaultMCPEchoTimeoutMS        = defaultEchoTimeoutMS
 	minMCPTaskTimeoutMS            = 1
 	defaultMCPTaskTimeoutMS        = defaultTaskTimeoutMS
@@ -19,6 +25,10 @@ const (
 	mcpTerminalExecToolTitle       = "Terminal Execute"
 	mcpComputerUseToolTitle        = "Computer Use"
 	mcpReadImageToolTitle          = "Read Image"
+	mcpReadFileToolTitle           = "Read File"
+	mcpWriteFileToolTitle          = "Write File"
+	mcpListDirToolTitle            = "List Directory"
+	mcpScreenshotToolTitle         = "Screenshot"
 )
 
 var mcpServerVersion = consoleVersion()
@@ -63,25 +73,131 @@ type mcpTerminalExecToolOutput struct {
 }
 
 type mcpComputerUseToolInput struct {
-	Command   string `json:"command"`
-	TimeoutMS *int   `json:"timeout_ms,omitempty"`
-	RequestID string `json:"request_id,omitempty"`
+	Command         string `json:"command"`
+	SessionID       string `json:"session_id,omitempty"`
+	CreateIfMissing bool   `json:"create_if_missing,omitempty"`
+	LeaseTTLSec     *int   `json:"lease_ttl_sec,omitempty"`
+	TimeoutMS       *int   `json:"timeout_ms,omitempty"`
+	RequestID       string `json:"request_id,omitempty"`
+	ApprovalMode    string `json:"approval_mode,omitempty"`
 }
 
 type mcpComputerUseToolOutput struct {
-	Stdout          string `json:"stdout"`
-	Stderr          string `json:"stderr"`
-	ExitCode        int    `json:"exit_code"`
-	StdoutTruncated bool   `json:"stdout_truncated"`
-	StderrTruncated bool   `json:"stderr_truncated"`
+	SessionID          string `json:"session_id,omitempty"`
+	Created            bool   `json:"created,omitempty"`
+	SessionClosed      bool   `json:"session_closed,omitempty"`
+	Stdout             string `json:"stdout"`
+	Stderr             string `json:"stderr"`
+	ExitCode           int    `json:"exit_code"`
+	StdoutTruncated    bool   `json:"stdout_truncated"`
+	StderrTruncated    bool   `json:"stderr_truncated"`
+	LeaseExpiresUnixMS int64  `json:"lease_expires_unix_ms,omitempty"`
+	Status             string `json:"status,omitempty"`
+	TaskID             string `json:"task_id,omitempty"`
+	ApprovalID         string `json:"approval_id,omitempty"`
 }
 
 type mcpReadImageToolInput struct {
 	SessionID string `json:"session_id"`
 	FilePath  string `json:"file_path"`
+	MaxWidth  int    `json:"max_width,omitempty"`
+	MaxHeight int    `json:"max_height,omitempty"`
+	MaxBytes  int    `json:"max_bytes,omitempty"`
+	Format    string `json:"format,omitempty"`
 	TimeoutMS *int   `json:"timeout_ms,omitempty"`
 }
 
+type mcpReadFileToolInput struct {
+	SessionID string `json:"session_id"`
+	FilePath  string `json:"file_path"`
+	Offset    int64  `json:"offset,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	TimeoutMS *int   `json:"timeout_ms,omitempty"`
+}
+
+type mcpReadFileToolOutput struct {
+	SessionID string `json:"session_id"`
+	FilePath  string `json:"file_path"`
+	SizeBytes int64  `json:"size_bytes"`
+	Offset    int64  `json:"offset"`
+	Content   string `json:"content"`
+	Encoding  string `json:"encoding"`
+	Truncated bool   `json:"truncated"`
+}
+
+type mcpWriteFileToolInput struct {
+	SessionID string `json:"session_id"`
+	FilePath  string `json:"file_path"`
+	Content   string `json:"content"`
+	Encoding  string `json:"encoding,omitempty"`
+	Append    bool   `json:"append,omitempty"`
+	TimeoutMS *int   `json:"timeout_ms,omitempty"`
+}
+
+type mcpWriteFileToolOutput struct {
+	SessionID    string `json:"session_id"`
+	FilePath     string `json:"file_path"`
+	BytesWritten int64  `json:"bytes_written"`
+	SizeBytes    int64  `json:"size_bytes"`
+	Created      bool   `json:"created"`
+}
+
+type mcpListDirToolInput struct {
+	SessionID string `json:"session_id"`
+	DirPath   string `json:"dir_path"`
+	TimeoutMS *int   `json:"timeout_ms,omitempty"`
+}
+
+type mcpListDirEntry struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	SizeBytes     int64  `json:"size_bytes"`
+	ModTimeUnixMS int64  `json:"mod_time_unix_ms"`
+}
+
+type mcpListDirToolOutput struct {
+	SessionID string            `json:"session_id"`
+	DirPath   string            `json:"dir_path"`
+	Entries   []mcpListDirEntry `json:"entries"`
+	Truncated bool              `json:"truncated"`
+}
+
+type readFilePayload struct {
+	SessionID string `json:"session_id"`
+	FilePath  string `json:"file_path"`
+	Offset    int64  `json:"offset,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+type writeFilePayload struct {
+	SessionID string `json:"session_id"`
+	FilePath  string `json:"file_path"`
+	Content   string `json:"content"`
+	Encoding  string `json:"encoding,omitempty"`
+	Append    bool   `json:"append,omitempty"`
+}
+
+type listDirPayload struct {
+	SessionID string `json:"session_id"`
+	DirPath   string `json:"dir_path"`
+}
+
+type mcpScreenshotToolInput struct {
+	TimeoutMS *int `json:"timeout_ms,omitempty"`
+}
+
+type screenshotPayload struct{}
+
+type screenshotResult struct {
+	MIMEType       string `json:"mime_type"`
+	SizeBytes      int64  `json:"size_bytes"`
+	Width          int    `json:"width,omitempty"`
+	Height         int    `json:"height,omitempty"`
+	OriginalWidth  int    `json:"original_width,omitempty"`
+	OriginalHeight int    `json:"original_height,omitempty"`
+	Blob           []byte `json:"blob,omitempty"`
+}
+
 type pythonExecPayload struct {
 	Code string `json:"code"`
 }
@@ -92,9 +208,17 @@ var mcpPythonExecToolDescription = "Executes Python code in the worker sandbox v
 
 var mcpTerminalExecToolDescription = "Executes shell commands in a persistent Docker-backed terminal session via the terminalExec capability. Sessions run on onlyboxes default-work-image (ubuntu:24.04), commands are executed with sh -lc, and common tools are preinstalled (python3/pip/venv, git, curl/wget, jq, ripgrep, fd-find, tree, file, zip/unzip, sqlite3). Reuse session_id to preserve filesystem state across calls. create_if_missing controls missing-session behavior. lease_ttl_sec extends session lease within configured bounds. timeout_ms is a synchronous execution timeout in milliseconds (1-600000, default 60000)."
 
-var mcpComputerUseToolDescription = "Executes shell commands directly on the caller-owned worker-sys host OS via /bin/sh -lc. Unlike terminalExec, this tool runs on the bare host without container isolation and is stateless — each invocation is independent with no session persistence. Only one command runs at a time (single concurrency). This tool is account-scoped and requires a user-created worker-sys. timeout_ms is a synchronous execution timeout in milliseconds (1-600000, default 60000). request_id provides idempotency for retries."
+var mcpComputerUseToolDescription = "Executes shell commands directly on the caller-owned worker-sys host OS via /bin/sh -lc. Unlike terminalExec, this tool runs on the bare host without container isolation. Calls are stateless by default; pass session_id (or create_if_missing without session_id) to run in a persistent shell on the worker that keeps working directory, exported variables and shell functions between calls, with session_closed reported once the shell exits. Sessions must be enabled on the worker-sys and renew their lease like terminalExec sessions. How many commands run at once follows the worker-sys max_inflight setting (default 1). This tool is account-scoped and requires a user-created worker-sys. timeout_ms is a synchronous execution timeout in milliseconds (1-600000, default 60000). request_id provides idempotency for retries. When a command policy requires human approval, the call blocks until an approver decides (approval_mode \"wait\", default); with approval_mode \"async\" it returns immediately with status \"pending_approval\", task_id and approval_id, and calling again with the same request_id waits for the result."
+
+var mcpReadImageToolDescription = "Reads a file and returns it as inline image content when mime type is image/*. For unsupported mime types, returns a text explanation. When session_id is exactly \"computerUse\", routing uses the caller-owned worker-sys readImage capability; otherwise routing uses terminalResource for terminal sessions. Optional max_width, max_height and max_bytes make the worker downscale the image (aspect ratio kept, never upscaled) and re-encode it until it fits; format converts it to png, jpeg or webp. When any of them is set, a text item with the returned and original dimensions precedes the image."
+
+var mcpReadFileToolDescription = "Reads a file on the caller-owned worker-sys host via the readFile capability. session_id must be exactly \"computerUse\". Only paths under the worker's WORKER_FILE_READ_ALLOWED_PATHS are readable, after resolving symlinks and \"..\". Returns at most the worker read limit per call; use offset and limit to page through larger files, truncated reports that bytes remain. UTF-8 content is returned as text, anything else base64 encoded with encoding \"base64\"."
+
+var mcpWriteFileToolDescription = "Writes a file on the caller-owned worker-sys host via the writeFile capability. session_id must be exactly \"computerUse\". Only paths under the worker's WORKER_FILE_WRITE_ALLOWED_PATHS are writable, which are configured separately from read paths; the parent directory must exist and symlinks are never followed at the target. content is UTF-8 text, or base64 when encoding is \"base64\", and is limited by the worker write limit per call. The file is replaced atomically unless append is true."
 
-var mcpReadImageToolDescription = "Reads a file and returns it as inline image content when mime type is image/*. For unsupported mime types, returns a text explanation. When session_id is exactly \"computerUse\", routing uses the caller-owned worker-sys readImage capability; otherwise routing uses terminalResource for terminal sessions."
+var mcpListDirToolDescription = "Lists a directory on the caller-owned worker-sys host via the listDir capability. session_id must be exactly \"computerUse\". Only directories under the worker's WORKER_FILE_READ_ALLOWED_PATHS can be listed, after resolving symlinks and \"..\". Entries are sorted by name and report type (file, dir, symlink, other), size and modification time; truncated is set when the worker entry limit was reached."
+
+var mcpScreenshotToolDescription = "Captures the screen of the caller-owned worker-sys host via the screenshot capability and returns it as inline image content, preceded by a text line with the returned and original dimensions. The capture command, size limits and scaling are configured on the worker (WORKER_SCREENSHOT_*); the tool is only available when the worker declares the screenshot capability. timeout_ms is a synchronous execution timeout in milliseconds (1-600000, default 60000)."
 
 var mcpEchoInputSchema = map[string]any{
 	"type":                 "object",
@@ -240,6 +364,21 @@ var mcpComputerUseInputSchema = map[string]any{
 			"type":        "string",
 			"description": "Shell command to run on worker-sys host via /bin/sh -lc. Empty or whitespace-only values are rejected.",
 		},
+		"session_id": map[string]any{
+			"type":        "string",
+			"description": "Optional persistent shell session identifier. Omit it (and create_if_missing) for a stateless call.",
+		},
+		"create_if_missing": map[string]any{
+			"type":        "boolean",
+			"description": "When true and session_id is missing on worker, create the session instead of returning session_not_found. Without session_id a new session is created.",
+			"default":     false,
+		},
+		"lease_ttl_sec": map[string]any{
+			"type":        "integer",
+			"description": "Optional lease duration in seconds for session expiry extension.",
+			"minimum":     minMCPTerminalLeaseSec,
+			"maximum":     maxMCPTerminalLeaseSec,
+		},
 		"timeout_ms": map[string]any{
 			"type":        "integer",
 			"description": "Optional synchronous execution timeout in milliseconds for this tool call.",
@@ -251,6 +390,12 @@ var mcpComputerUseInputSchema = map[string]any{
 			"type":        "string",
 			"description": "Optional idempotency key scoped to the caller account.",
 		},
+		"approval_mode": map[string]any{
+			"type":        "string",
+			"description": "What to do when a command policy holds the call for human approval: wait for the decision, or return a pending_approval handle at once.",
+			"enum":        []string{mcpApprovalModeWait, mcpApprovalModeAsync},
+			"default":     mcpApprovalModeWait,
+		},
 	},
 }
 
@@ -265,6 +410,15 @@ var mcpComputerUseOutputSchema = map[string]any{
 		"stderr_truncated",
 	},
 	"properties": map[string]any{
+		"session_id": map[string]any{
+			"type":        "string",
+			"description": "Session the command ran in; absent for stateless calls.",
+		},
+		"created": map[string]any{"type": "boolean"},
+		"session_closed": map[string]any{
+			"type":        "boolean",
+			"description": "True when the command ended the session shell, e.g. with exit.",
+		},
 		"stdout": map[string]any{"type": "string"},
 		"stderr": map[string]any{"type": "string"},
 		"exit_code": map[string]any{
@@ -276,6 +430,21 @@ var mcpComputerUseOutputSchema = map[string]any{
 		"stderr_truncated": map[string]any{
 			"type": "boolean",
 		},
+		"lease_expires_unix_ms": map[string]any{
+			"type": "integer",
+		},
+		"status": map[string]any{
+			"type":        "string",
+			"description": "Set to pending_approval when approval_mode is async and the command awaits a human decision.",
+		},
+		"task_id": map[string]any{
+			"type":        "string",
+			"description": "Task held for approval; present with status pending_approval.",
+		},
+		"approval_id": map[string]any{
+			"type":        "string",
+			"description": "Approval to decide via /api/v1/approvals; present with status pending_approval.",
+		},
 	},
 }
 
@@ -292,6 +461,26 @@ var mcpReadImageInputSchema = map[string]any{
 			"type":        "string",
 			"description": "Path to the file in the terminal session filesystem.",
 		},
+		"max_width": map[string]any{
+			"type":        "integer",
+			"description": "Optional maximum width in pixels of the returned image.",
+			"minimum":     1,
+		},
+		"max_height": map[string]any{
+			"type":        "integer",
+			"description": "Optional maximum height in pixels of the returned image.",
+			"minimum":     1,
+		},
+		"max_bytes": map[string]any{
+			"type":        "integer",
+			"description": "Optional maximum size in bytes of the returned image; the image is re-encoded and shrunk until it fits.",
+			"minimum":     1,
+		},
+		"format": map[string]any{
+			"type":        "string",
+			"description": "Optional output encoding. Omit to keep the source format when it already fits.",
+			"enum":        []string{"png", "jpeg", "webp"},
+		},
 		"timeout_ms": map[string]any{
 			"type":        "integer",
 			"description": "Optional synchronous execution timeout in milliseconds for this tool call.",
@@ -301,3 +490,163 @@ var mcpReadImageInputSchema = map[string]any{
 		},
 	},
 }
+
+var mcpFileSessionIDSchema = map[string]any{
+	"type":        "string",
+	"description": "Must be exactly \"computerUse\" to route to the caller-owned worker-sys.",
+	"enum":        []string{computerUseSessionID},
+}
+
+var mcpFileTimeoutSchema = map[string]any{
+	"type":        "integer",
+	"description": "Optional synchronous execution timeout in milliseconds for this tool call.",
+	"minimum":     minMCPTaskTimeoutMS,
+	"maximum":     maxMCPTaskTimeoutMS,
+	"default":     defaultMCPTaskTimeoutMS,
+}
+
+var mcpReadFileInputSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"session_id", "file_path"},
+	"properties": map[string]any{
+		"session_id": mcpFileSessionIDSchema,
+		"file_path": map[string]any{
+			"type":        "string",
+			"description": "Path to the file on the worker-sys host.",
+		},
+		"offset": map[string]any{
+			"type":        "integer",
+			"description": "Optional byte offset to start reading from.",
+			"minimum":     0,
+			"default":     0,
+		},
+		"limit": map[string]any{
+			"type":        "integer",
+			"description": "Optional maximum number of bytes to read; capped by the worker read limit.",
+			"minimum":     0,
+		},
+		"timeout_ms": mcpFileTimeoutSchema,
+	},
+}
+
+var mcpReadFileOutputSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"session_id", "file_path", "size_bytes", "offset", "content", "encoding", "truncated"},
+	"properties": map[string]any{
+		"session_id": map[string]any{"type": "string"},
+		"file_path":  map[string]any{"type": "string"},
+		"size_bytes": map[string]any{
+			"type":        "integer",
+			"description": "Total size of the file.",
+		},
+		"offset":  map[string]any{"type": "integer"},
+		"content": map[string]any{"type": "string"},
+		"encoding": map[string]any{
+			"type": "string",
+			"enum": []string{"utf-8", "base64"},
+		},
+		"truncated": map[string]any{
+			"type":        "boolean",
+			"description": "True when bytes remain after the returned content.",
+		},
+	},
+}
+
+var mcpWriteFileInputSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"session_id", "file_path", "content"},
+	"properties": map[string]any{
+		"session_id": mcpFileSessionIDSchema,
+		"file_path": map[string]any{
+			"type":        "string",
+			"description": "Path to the file on the worker-sys host. The parent directory must exist.",
+		},
+		"content": map[string]any{
+			"type":        "string",
+			"description": "Content to write; may be empty.",
+		},
+		"encoding": map[string]any{
+			"type":        "string",
+			"description": "How content is encoded.",
+			"enum":        []string{"utf-8", "base64"},
+			"default":     "utf-8",
+		},
+		"append": map[string]any{
+			"type":        "boolean",
+			"description": "Append to an existing file instead of replacing it.",
+			"default":     false,
+		},
+		"timeout_ms": mcpFileTimeoutSchema,
+	},
+}
+
+var mcpWriteFileOutputSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"session_id", "file_path", "bytes_written", "size_bytes", "created"},
+	"properties": map[string]any{
+		"session_id":    map[string]any{"type": "string"},
+		"file_path":     map[string]any{"type": "string"},
+		"bytes_written": map[string]any{"type": "integer"},
+		"size_bytes": map[string]any{
+			"type":        "integer",
+			"description": "Size of the file after the write.",
+		},
+		"created": map[string]any{"type": "boolean"},
+	},
+}
+
+var mcpListDirInputSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"session_id", "dir_path"},
+	"properties": map[string]any{
+		"session_id": mcpFileSessionIDSchema,
+		"dir_path": map[string]any{
+			"type":        "string",
+			"description": "Path to the directory on the worker-sys host.",
+		},
+		"timeout_ms": mcpFileTimeoutSchema,
+	},
+}
+
+var mcpListDirOutputSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"session_id", "dir_path", "entries", "truncated"},
+	"properties": map[string]any{
+		"session_id": map[string]any{"type": "string"},
+		"dir_path":   map[string]any{"type": "string"},
+		"entries": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type":     "object",
+				"required": []string{"name", "type", "size_bytes", "mod_time_unix_ms"},
+				"properties": map[string]any{
+					"name": map[string]any{"type": "string"},
+					"type": map[string]any{
+						"type": "string",
+						"enum": []string{"file", "dir", "symlink", "other"},
+					},
+					"size_bytes":       map[string]any{"type": "integer"},
+					"mod_time_unix_ms": map[string]any{"type": "integer"},
+				},
+			},
+		},
+		"truncated": map[string]any{
+			"type":        "boolean",
+			"description": "True when the worker entry limit cut the listing short.",
+		},
+	},
+}
+
+var mcpScreenshotInputSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"properties": map[string]any{
+		"timeout_ms": mcpFileTimeoutSchema,
+	},
+}
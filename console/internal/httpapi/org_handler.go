@@ -0,0 +1,592 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+const (
+	organizationRoleOwner  = "owner"
+	organizationRoleAdmin  = "admin"
+	organizationRoleMember = "member"
+
+	maxOrganizationNameRunes = 64
+	organizationIDByteLength = 16
+)
+
+var (
+	errOrganizationNotFound     = errors.New("organization not found")
+	errOrganizationRoleTooLow   = errors.New("insufficient organization role")
+	errOrganizationNameRequired = errors.New("name is required")
+	errOrganizationNameTooLong  = errors.New("name length must be <= 64")
+	errOrganizationNameConflict = errors.New("organization name already exists")
+)
+
+type organizationItem struct {
+	OrgID     string    `json:"org_id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type organizationListResponse struct {
+	Items []organizationItem `json:"items"`
+	Total int                `json:"total"`
+}
+
+type organizationMemberItem struct {
+	AccountID string    `json:"account_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type organizationDetailResponse struct {
+	OrgID     string                   `json:"org_id"`
+	Name      string                   `json:"name"`
+	Role      string                   `json:"role"`
+	CreatedBy string                   `json:"created_by"`
+	CreatedAt time.Time                `json:"created_at"`
+	Members   []organizationMemberItem `json:"members"`
+}
+
+type createOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type putOrganizationMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type organizationTokenItem struct {
+	ID          string    `json:"id"`
+	AccountID   string    `json:"account_id"`
+	Name        string    `json:"name"`
+	TokenMasked string    `json:"token_masked"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type organizationTokenListResponse struct {
+	Items []organizationTokenItem `json:"items"`
+	Total int                     `json:"total"`
+}
+
+// organizationRoleRank orders roles so checks can ask for "at least admin".
+func organizationRoleRank(role string) int {
+	switch role {
+	case organizationRoleOwner:
+		return 3
+	case organizationRoleAdmin:
+		return 2
+	case organizationRoleMember:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// lookupOrganizationRole returns the account's role in an organization.
+// Non-members get errOrganizationNotFound so they cannot probe org IDs.
+func lookupOrganizationRole(ctx context.Context, queries *sqlc.Queries, orgID string, accountID string) (string, error) {
+	if queries == nil {
+		return "", errOrganizationNotFound
+	}
+	trimmedOrgID := strings.TrimSpace(orgID)
+	if !registry.IsOrganizationOwnerID(trimmedOrgID) {
+		return "", errOrganizationNotFound
+	}
+	member, err := queries.GetOrganizationMember(ctx, sqlc.GetOrganizationMemberParams{
+		OrgID:     trimmedOrgID,
+		AccountID: strings.TrimSpace(accountID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errOrganizationNotFound
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// requireOrganizationRole resolves :org_id for the session account and
+// checks it holds at least minRole. It writes the error response itself.
+func requireOrganizationRole(c *gin.Context, queries *sqlc.Queries, minRole string) (string, SessionAccount, string, bool) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return "", SessionAccount{}, "", false
+	}
+	orgID := strings.TrimSpace(c.Param("org_id"))
+	role, err := lookupOrganizationRole(c.Request.Context(), queries, orgID, account.AccountID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return "", SessionAccount{}, "", false
+	}
+	if organizationRoleRank(role) < organizationRoleRank(minRole) {
+		writeOrganizationError(c, errOrganizationRoleTooLow)
+		return "", SessionAccount{}, "", false
+	}
+	return orgID, account, role, true
+}
+
+func writeOrganizationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errOrganizationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, errOrganizationRoleTooLow):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve organization"})
+	}
+}
+
+// ListOrganizations lists the organizations the current account belongs to.
+func (a *MCPAuth) ListOrganizations(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	rows, err := a.queries.ListOrganizationsByAccount(c.Request.Context(), account.AccountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list organizations"})
+		return
+	}
+	items := make([]organizationItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, organizationItem{
+			OrgID:     row.OrgID,
+			Name:      row.Name,
+			Role:      row.Role,
+			CreatedAt: time.UnixMilli(row.CreatedAtUnixMs),
+		})
+	}
+	c.JSON(http.StatusOK, organizationListResponse{Items: items, Total: len(items)})
+}
+
+// CreateOrganization creates an organization owned by the current account.
+func (a *MCPAuth) CreateOrganization(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	var req createOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errOrganizationNameRequired.Error()})
+		return
+	}
+	if utf8.RuneCountInString(name) > maxOrganizationNameRunes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errOrganizationNameTooLong.Error()})
+		return
+	}
+	suffix, err := randomHexString(organizationIDByteLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create organization"})
+		return
+	}
+	orgID := registry.OrganizationIDPrefix + suffix
+	nowMS := a.now().UnixMilli()
+
+	err = a.db.WithTx(c.Request.Context(), func(q *sqlc.Queries) error {
+		if err := q.InsertOrganization(c.Request.Context(), sqlc.InsertOrganizationParams{
+			OrgID:           orgID,
+			Name:            name,
+			NameKey:         strings.ToLower(name),
+			CreatedBy:       account.AccountID,
+			CreatedAtUnixMs: nowMS,
+			UpdatedAtUnixMs: nowMS,
+		}); err != nil {
+			if isSQLiteConstraintError(err) {
+				return errOrganizationNameConflict
+			}
+			return err
+		}
+		return q.UpsertOrganizationMember(c.Request.Context(), sqlc.UpsertOrganizationMemberParams{
+			OrgID:           orgID,
+			AccountID:       account.AccountID,
+			Role:            organizationRoleOwner,
+			CreatedAtUnixMs: nowMS,
+			UpdatedAtUnixMs: nowMS,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, errOrganizationNameConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create organization"})
+		return
+	}
+
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionOrganizationCreate,
+		TargetType: "organization",
+		TargetID:   orgID,
+		Details:    map[string]any{"name": name},
+	})
+	c.JSON(http.StatusCreated, organizationItem{
+		OrgID:     orgID,
+		Name:      name,
+		Role:      organizationRoleOwner,
+		CreatedAt: time.UnixMilli(nowMS),
+	})
+}
+
+// GetOrganization returns an organization and its members to any member.
+func (a *MCPAuth) GetOrganization(c *gin.Context) {
+	orgID, _, role, ok := requireOrganizationRole(c, a.queries, organizationRoleMember)
+	if !ok {
+		return
+	}
+	org, err := a.queries.GetOrganizationByID(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load organization"})
+		return
+	}
+	rows, err := a.queries.ListOrganizationMembers(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load organization"})
+		return
+	}
+	members := make([]organizationMemberItem, 0, len(rows))
+	for _, row := range rows {
+		members = append(members, organizationMemberItem{
+			AccountID: row.AccountID,
+			Username:  row.Username,
+			Role:      row.Role,
+			CreatedAt: time.UnixMilli(row.CreatedAtUnixMs),
+			UpdatedAt: time.UnixMilli(row.UpdatedAtUnixMs),
+		})
+	}
+	c.JSON(http.StatusOK, organizationDetailResponse{
+		OrgID:     org.OrgID,
+		Name:      org.Name,
+		Role:      role,
+		CreatedBy: org.CreatedBy,
+		CreatedAt: time.UnixMilli(org.CreatedAtUnixMs),
+		Members:   members,
+	})
+}
+
+// DeleteOrganization deletes an organization and its tokens. Only the owner
+// may do it, and only after the organization's workers are deleted.
+func (a *MCPAuth) DeleteOrganization(c *gin.Context) {
+	orgID, _, _, ok := requireOrganizationRole(c, a.queries, organizationRoleOwner)
+	if !ok {
+		return
+	}
+	for _, workerType := range ownedWorkerTypes {
+		workers, err := a.queries.CountWorkerNodesByOwnerAndType(c.Request.Context(), sqlc.CountWorkerNodesByOwnerAndTypeParams{
+			LabelValue:   orgID,
+			LabelValue_2: workerType,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete organization"})
+			return
+		}
+		if workers > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "organization still owns workers"})
+			return
+		}
+	}
+	err := a.db.WithTx(c.Request.Context(), func(q *sqlc.Queries) error {
+		if _, err := q.DeleteOrganizationTrustedTokens(c.Request.Context(), orgID); err != nil {
+			return err
+		}
+		_, err := q.DeleteOrganizationByID(c.Request.Context(), orgID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete organization"})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionOrganizationDelete,
+		TargetType: "organization",
+		TargetID:   orgID,
+	})
+	c.Status(http.StatusNoContent)
+}
+
+// PutOrganizationMember adds an account or changes its role. Admins manage
+// members; only the owner manages admins, and setting role owner transfers
+// ownership, leaving the previous owner an admin.
+func (a *MCPAuth) PutOrganizationMember(c *gin.Context) {
+	orgID, account, actorRole, ok := requireOrganizationRole(c, a.queries, organizationRoleAdmin)
+	if !ok {
+		return
+	}
+	targetID := strings.TrimSpace(c.Param("account_id"))
+	if targetID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id is required"})
+		return
+	}
+	if targetID == strings.TrimSpace(account.AccountID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot change your own organization role"})
+		return
+	}
+	var req putOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	role := strings.TrimSpace(strings.ToLower(req.Role))
+	if organizationRoleRank(role) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of owner|admin|member"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := a.queries.GetAccountByID(ctx, targetID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errAccountNotFound.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update organization member"})
+		return
+	}
+	currentRole, err := lookupOrganizationRole(ctx, a.queries, orgID, targetID)
+	if err != nil && !errors.Is(err, errOrganizationNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update organization member"})
+		return
+	}
+	if currentRole == organizationRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": errOrganizationRoleTooLow.Error()})
+		return
+	}
+	if actorRole != organizationRoleOwner && (role != organizationRoleMember || currentRole == organizationRoleAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errOrganizationRoleTooLow.Error()})
+		return
+	}
+
+	nowMS := a.now().UnixMilli()
+	err = a.db.WithTx(ctx, func(q *sqlc.Queries) error {
+		if err := q.UpsertOrganizationMember(ctx, sqlc.UpsertOrganizationMemberParams{
+			OrgID:           orgID,
+			AccountID:       targetID,
+			Role:            role,
+			CreatedAtUnixMs: nowMS,
+			UpdatedAtUnixMs: nowMS,
+		}); err != nil {
+			return err
+		}
+		if role != organizationRoleOwner {
+			return nil
+		}
+		return q.UpsertOrganizationMember(ctx, sqlc.UpsertOrganizationMemberParams{
+			OrgID:           orgID,
+			AccountID:       account.AccountID,
+			Role:            organizationRoleAdmin,
+			CreatedAtUnixMs: nowMS,
+			UpdatedAtUnixMs: nowMS,
+		})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update organization member"})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionOrganizationMemberPut,
+		TargetType: "organization",
+		TargetID:   orgID,
+		Details:    map[string]any{"account_id": targetID, "role": role, "previous_role": currentRole},
+	})
+	c.JSON(http.StatusOK, gin.H{"org_id": orgID, "account_id": targetID, "role": role})
+}
+
+// DeleteOrganizationMember removes an account from an organization together
+// with the org tokens it created. Members may remove themselves; the owner
+// must transfer ownership first.
+func (a *MCPAuth) DeleteOrganizationMember(c *gin.Context) {
+	orgID, account, actorRole, ok := requireOrganizationRole(c, a.queries, organizationRoleMember)
+	if !ok {
+		return
+	}
+	targetID := strings.TrimSpace(c.Param("account_id"))
+	ctx := c.Request.Context()
+	targetRole, err := lookupOrganizationRole(ctx, a.queries, orgID, targetID)
+	if err != nil {
+		if errors.Is(err, errOrganizationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "organization member not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove organization member"})
+		return
+	}
+	if targetRole == organizationRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "organization owner must transfer ownership before leaving"})
+		return
+	}
+	if targetID != strings.TrimSpace(account.AccountID) && organizationRoleRank(actorRole) <= organizationRoleRank(targetRole) {
+		c.JSON(http.StatusForbidden, gin.H{"error": errOrganizationRoleTooLow.Error()})
+		return
+	}
+
+	err = a.db.WithTx(ctx, func(q *sqlc.Queries) error {
+		if _, err := q.DeleteOrganizationTrustedTokensByAccount(ctx, sqlc.DeleteOrganizationTrustedTokensByAccountParams{
+			AccountID: targetID,
+			OrgID:     orgID,
+		}); err != nil {
+			return err
+		}
+		_, err := q.DeleteOrganizationMember(ctx, sqlc.DeleteOrganizationMemberParams{
+			OrgID:     orgID,
+			AccountID: targetID,
+		})
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove organization member"})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionOrganizationMemberRemove,
+		TargetType: "organization",
+		TargetID:   orgID,
+		Details:    map[string]any{"account_id": targetID},
+	})
+	c.Status(http.StatusNoContent)
+}
+
+// ListOrganizationTokens lists every access token bound to an organization.
+func (a *MCPAuth) ListOrganizationTokens(c *gin.Context) {
+	orgID, _, _, ok := requireOrganizationRole(c, a.queries, organizationRoleMember)
+	if !ok {
+		return
+	}
+	rows, err := a.queries.ListOrganizationTokens(c.Request.Context(), orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tokens"})
+		return
+	}
+	items := make([]organizationTokenItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, organizationTokenItem{
+			ID:          row.TokenID,
+			AccountID:   row.AccountID,
+			Name:        row.Name,
+			TokenMasked: row.TokenMasked,
+			CreatedAt:   time.UnixMilli(row.CreatedAtUnixMs),
+			UpdatedAt:   time.UnixMilli(row.UpdatedAtUnixMs),
+		})
+	}
+	c.JSON(http.StatusOK, organizationTokenListResponse{Items: items, Total: len(items)})
+}
+
+// CreateOrganizationToken creates an access token that acts for the
+// organization. Any member may create one; it is revoked when the member
+// leaves.
+func (a *MCPAuth) CreateOrganizationToken(c *gin.Context) {
+	if a == nil || a.queries == nil || a.hasher == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "token store is unavailable"})
+		return
+	}
+	orgID, account, _, ok := requireOrganizationRole(c, a.queries, organizationRoleMember)
+	if !ok {
+		return
+	}
+	req := createTrustedTokenRequest{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	record, generated, err := a.createToken(ctx, account.AccountID, req.Name, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, errTrustedTokenNameRequired),
+			errors.Is(err, errTrustedTokenNameTooLong),
+			errors.Is(err, errTrustedTokenValueRequired),
+			errors.Is(err, errTrustedTokenValueTooLong),
+			errors.Is(err, errTrustedTokenValueWhitespace):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errTrustedTokenNameConflict),
+			errors.Is(err, errTrustedTokenValueConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+		}
+		return
+	}
+	if err := a.queries.InsertOrganizationToken(ctx, sqlc.InsertOrganizationTokenParams{
+		TokenID: record.ID,
+		OrgID:   orgID,
+	}); err != nil {
+		_ = a.deleteToken(ctx, record.ID, account.AccountID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+		return
+	}
+
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionTokenCreate,
+		TargetType: "access_token",
+		TargetID:   record.ID,
+		Details:    map[string]any{"name": record.Name, "generated": generated, "org_id": orgID},
+	})
+	c.JSON(http.StatusCreated, createTrustedTokenResponse{
+		ID:          record.ID,
+		Name:        record.Name,
+		Token:       record.Token,
+		TokenMasked: record.TokenMasked,
+		Generated:   generated,
+		CreatedAt:   record.CreatedAt,
+		UpdatedAt:   record.UpdatedAt,
+	})
+}
+
+// DeleteOrganizationToken revokes an org token. Its creator and org admins
+// may delete it.
+func (a *MCPAuth) DeleteOrganizationToken(c *gin.Context) {
+	orgID, account, role, ok := requireOrganizationRole(c, a.queries, organizationRoleMember)
+	if !ok {
+		return
+	}
+	tokenID := strings.TrimSpace(c.Param("token_id"))
+	ctx := c.Request.Context()
+	if organizationRoleRank(role) < organizationRoleRank(organizationRoleAdmin) {
+		record, err := a.queries.GetTrustedTokenByID(ctx, tokenID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete token"})
+			return
+		}
+		if err == nil && record.AccountID != strings.TrimSpace(account.AccountID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": errOrganizationRoleTooLow.Error()})
+			return
+		}
+	}
+	rows, err := a.queries.DeleteOrganizationTrustedToken(ctx, sqlc.DeleteOrganizationTrustedTokenParams{
+		TokenID: tokenID,
+		OrgID:   orgID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete token"})
+		return
+	}
+	if rows == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errTrustedTokenNotFound.Error()})
+		return
+	}
+	a.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionTokenDelete,
+		TargetType: "access_token",
+		TargetID:   tokenID,
+		Details:    map[string]any{"org_id": orgID},
+	})
+	c.Status(http.StatusNoContent)
+}
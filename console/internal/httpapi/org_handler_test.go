@@ -0,0 +1,166 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
+	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+func TestOrganizationLifecycle(t *testing.T) {
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, "acc-org-owner", "org-owner", "owner-password", false)
+	seedTestAccount(t, db.Queries, "acc-org-member", "org-member", "member-password", false)
+	seedTestAccount(t, db.Queries, "acc-org-outsider", "org-outsider", "outsider-password", false)
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+
+	store, err := registry.NewStoreWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	submittedOwnerID := ""
+	dispatcher := &fakeTaskDispatcher{
+		submit: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
+			submittedOwnerID = req.OwnerID
+			return grpcserver.SubmitTaskResult{
+				Task: grpcserver.TaskSnapshot{
+					TaskID:     "task-org-1",
+					Capability: "echo",
+					Status:     grpcserver.TaskStatusRunning,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+					DeadlineAt: now.Add(60 * time.Second),
+				},
+			}, nil
+		},
+		get: func(taskID string, ownerID string) (grpcserver.TaskSnapshot, bool) {
+			return grpcserver.TaskSnapshot{}, false
+		},
+		cancel: func(taskID string, ownerID string) (grpcserver.TaskSnapshot, error) {
+			return grpcserver.TaskSnapshot{}, nil
+		},
+	}
+	provisioning := &fakeWorkerProvisioning{
+		secrets:      map[string]string{},
+		createNodeID: "node-org-sys",
+		createSecret: "secret-org-sys",
+	}
+	handler := NewWorkerHandler(store, 15*time.Second, dispatcher, provisioning, nil, ":50051")
+	router := mustNewRouter(t, handler, consoleAuth, mcpAuth)
+	ownerCookie := loginSessionCookieFor(t, router, "org-owner", "owner-password")
+	memberCookie := loginSessionCookieFor(t, router, "org-member", "member-password")
+	outsiderCookie := loginSessionCookieFor(t, router, "org-outsider", "outsider-password")
+
+	rec := doJSON(t, router, http.MethodPost, "/api/v1/orgs", `{"name":"Platform"}`, ownerCookie)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected org create 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	created := organizationItem{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode org: %v", err)
+	}
+	if !registry.IsOrganizationOwnerID(created.OrgID) || created.Role != organizationRoleOwner {
+		t.Fatalf("unexpected org %#v", created)
+	}
+	orgPath := "/api/v1/orgs/" + created.OrgID
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/orgs", `{"name":"platform"}`, outsiderCookie); rec.Code != http.StatusConflict {
+		t.Fatalf("expected duplicate org name 409, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodGet, orgPath, "", outsiderCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected outsider 404, got %d", rec.Code)
+	}
+
+	if rec := doJSON(t, router, http.MethodPut, orgPath+"/members/acc-org-member", `{"role":"member"}`, ownerCookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected member add 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPut, orgPath+"/members/acc-org-outsider", `{"role":"admin"}`, memberCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected member to be denied member management, got %d", rec.Code)
+	}
+
+	rec = doJSON(t, router, http.MethodPost, orgPath+"/tokens", `{"name":"org-ci"}`, memberCookie)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected org token create 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	orgToken := createTrustedTokenResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &orgToken); err != nil {
+		t.Fatalf("decode org token: %v", err)
+	}
+	rec = doJSON(t, router, http.MethodGet, "/api/v1/console/tokens", "", memberCookie)
+	if strings.Contains(rec.Body.String(), orgToken.ID) {
+		t.Fatalf("expected org token to stay out of the personal token list, got %s", rec.Body.String())
+	}
+
+	submit := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", strings.NewReader(`{"capability":"echo","input":{"message":"hi"},"mode":"async"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(trustedTokenHeader, "Bearer "+orgToken.Token)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	if rec := submit(); rec.Code != http.StatusAccepted || submittedOwnerID != created.OrgID {
+		t.Fatalf("expected org token task to run as %q, got code=%d owner=%q", created.OrgID, rec.Code, submittedOwnerID)
+	}
+
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/workers", `{"type":"worker-sys","org_id":"`+created.OrgID+`"}`, memberCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected member worker create 403, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/workers", `{"type":"worker-sys","org_id":"`+created.OrgID+`"}`, ownerCookie); rec.Code != http.StatusCreated {
+		t.Fatalf("expected owner worker create 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if provisioning.lastOwnerID != created.OrgID || provisioning.lastType != registry.WorkerTypeSys {
+		t.Fatalf("expected org worker-sys provisioning, got owner=%q type=%q", provisioning.lastOwnerID, provisioning.lastType)
+	}
+	if err := store.Upsert(&registryv1.ConnectHello{
+		NodeId: "node-org-sys",
+		Labels: map[string]string{
+			registry.LabelOwnerIDKey:    created.OrgID,
+			registry.LabelWorkerTypeKey: registry.WorkerTypeSys,
+		},
+	}, "session-org-sys", time.Now()); err != nil {
+		t.Fatalf("seed org worker: %v", err)
+	}
+	rec = doJSON(t, router, http.MethodGet, "/api/v1/workers?org_id="+created.OrgID, "", memberCookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "node-org-sys") {
+		t.Fatalf("expected member to list org workers, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/workers?org_id="+created.OrgID, "", outsiderCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected outsider org worker list 404, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodGet, orgPath+"/tasks", "", memberCookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected org task list 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	if rec := doJSON(t, router, http.MethodDelete, orgPath+"/members/acc-org-member", "", ownerCookie); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected member remove 204, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := submit(); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected removed member's org token to be revoked, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodDelete, "/api/v1/console/accounts/acc-org-owner", "", loginSessionCookie(t, router)); rec.Code != http.StatusConflict {
+		t.Fatalf("expected org owner account delete 409, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodDelete, orgPath, "", ownerCookie); rec.Code != http.StatusConflict {
+		t.Fatalf("expected org delete with workers 409, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,239 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
+)
+
+var errPolicyEngineUnavailable = errors.New("command policy is unavailable")
+
+type policyRequest struct {
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Action       string            `json:"action"`
+	AccountID    string            `json:"account_id"`
+	TokenID      string            `json:"token_id"`
+	Capability   string            `json:"capability"`
+	WorkerLabels map[string]string `json:"worker_labels"`
+	MatchField   string            `json:"match_field"`
+	Pattern      string            `json:"pattern"`
+	Enabled      *bool             `json:"enabled,omitempty"`
+}
+
+type policyListResponse struct {
+	Items         []policy.Rule `json:"items"`
+	Total         int           `json:"total"`
+	DefaultAction string        `json:"default_action"`
+}
+
+type policyEvaluateRequest struct {
+	AccountID    string            `json:"account_id"`
+	TokenID      string            `json:"token_id"`
+	Capability   string            `json:"capability"`
+	Input        json.RawMessage   `json:"input"`
+	WorkerLabels map[string]string `json:"worker_labels,omitempty"`
+}
+
+type policyEvaluateResponse struct {
+	policy.Decision
+	DefaultAction string `json:"default_action"`
+}
+
+// SetCommandPolicy enables the admin policy routes.
+func (h *WorkerHandler) SetCommandPolicy(engine *policy.Engine) {
+	if h == nil {
+		return
+	}
+	h.policy = engine
+}
+
+func (h *WorkerHandler) ListPolicies(c *gin.Context) {
+	if h.policy == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errPolicyEngineUnavailable.Error()})
+		return
+	}
+	rules, err := h.policy.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list policies"})
+		return
+	}
+	c.JSON(http.StatusOK, policyListResponse{
+		Items:         rules,
+		Total:         len(rules),
+		DefaultAction: h.policy.DefaultAction(),
+	})
+}
+
+func (h *WorkerHandler) CreatePolicy(c *gin.Context) {
+	if h.policy == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errPolicyEngineUnavailable.Error()})
+		return
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	var req policyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	rule := req.rule()
+	rule.CreatedBy = account.AccountID
+
+	created, err := h.policy.Create(c.Request.Context(), rule)
+	if err != nil {
+		writePolicyError(c, err, "failed to create policy")
+		return
+	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionPolicyCreate,
+		TargetType: "policy",
+		TargetID:   created.ID,
+		Details:    policyAuditDetails(created),
+	})
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *WorkerHandler) UpdatePolicy(c *gin.Context) {
+	if h.policy == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errPolicyEngineUnavailable.Error()})
+		return
+	}
+	var req policyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	rule := req.rule()
+	rule.ID = strings.TrimSpace(c.Param("policy_id"))
+
+	updated, err := h.policy.Update(c.Request.Context(), rule)
+	if err != nil {
+		writePolicyError(c, err, "failed to update policy")
+		return
+	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionPolicyUpdate,
+		TargetType: "policy",
+		TargetID:   updated.ID,
+		Details:    policyAuditDetails(updated),
+	})
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *WorkerHandler) DeletePolicy(c *gin.Context) {
+	if h.policy == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errPolicyEngineUnavailable.Error()})
+		return
+	}
+	policyID := strings.TrimSpace(c.Param("policy_id"))
+	if err := h.policy.Delete(c.Request.Context(), policyID); err != nil {
+		writePolicyError(c, err, "failed to delete policy")
+		return
+	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionPolicyDelete,
+		TargetType: "policy",
+		TargetID:   policyID,
+	})
+	c.Status(http.StatusNoContent)
+}
+
+// EvaluatePolicy is a dry run: it reports what the stored rules would decide
+// for a submission without dispatching anything.
+func (h *WorkerHandler) EvaluatePolicy(c *gin.Context) {
+	if h.policy == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errPolicyEngineUnavailable.Error()})
+		return
+	}
+	var req policyEvaluateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if strings.TrimSpace(req.Capability) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "capability is required"})
+		return
+	}
+	input := []byte(req.Input)
+	if len(input) == 0 {
+		input = []byte("{}")
+	}
+	evaluation := policy.Request{
+		AccountID:  req.AccountID,
+		TokenID:    req.TokenID,
+		Capability: req.Capability,
+		InputJSON:  input,
+	}
+	if len(req.WorkerLabels) > 0 {
+		evaluation.Workers = []map[string]string{req.WorkerLabels}
+	}
+
+	decision, err := h.policy.Evaluate(c.Request.Context(), evaluation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to evaluate policy"})
+		return
+	}
+	c.JSON(http.StatusOK, policyEvaluateResponse{
+		Decision:      decision,
+		DefaultAction: h.policy.DefaultAction(),
+	})
+}
+
+func (req policyRequest) rule() policy.Rule {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	return policy.Rule{
+		Name:         req.Name,
+		Description:  req.Description,
+		Action:       req.Action,
+		AccountID:    req.AccountID,
+		TokenID:      req.TokenID,
+		Capability:   req.Capability,
+		WorkerLabels: req.WorkerLabels,
+		MatchField:   req.MatchField,
+		Pattern:      req.Pattern,
+		Enabled:      enabled,
+	}
+}
+
+func writePolicyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, policy.ErrPolicyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, policy.ErrNameRequired),
+		errors.Is(err, policy.ErrNameTooLong),
+		errors.Is(err, policy.ErrActionInvalid),
+		errors.Is(err, policy.ErrMatchFieldInvalid),
+		errors.Is(err, policy.ErrPatternRequired),
+		errors.Is(err, policy.ErrPatternWithoutType),
+		errors.Is(err, policy.ErrPatternTooLong),
+		errors.Is(err, policy.ErrPatternInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func policyAuditDetails(rule policy.Rule) map[string]any {
+	return map[string]any{
+		"name":          rule.Name,
+		"action":        rule.Action,
+		"account_id":    rule.AccountID,
+		"token_id":      rule.TokenID,
+		"capability":    rule.Capability,
+		"worker_labels": rule.WorkerLabels,
+		"match_field":   rule.MatchField,
+		"pattern":       rule.Pattern,
+		"enabled":       rule.Enabled,
+	}
+}
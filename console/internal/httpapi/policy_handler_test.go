@@ -0,0 +1,156 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func newPolicyTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+	seedTestAccount(t, db.Queries, "acc-test-member", "member-test", "member-password", false)
+
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+	engine, err := policy.NewEngine(db.Queries, policy.ActionAllow)
+	if err != nil {
+		t.Fatalf("new policy engine: %v", err)
+	}
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, nil, nil, nil, "")
+	handler.SetCommandPolicy(engine)
+	return mustNewRouter(t, handler, consoleAuth, mcpAuth)
+}
+
+func TestPolicyAdminLifecycle(t *testing.T) {
+	router := newPolicyTestRouter(t)
+	cookie := loginSessionCookie(t, router)
+
+	invalidRec := doJSON(t, router, http.MethodPost, "/api/v1/policies", `{"name":"bad","action":"deny","match_field":"command","pattern":"("}`, cookie)
+	if invalidRec.Code != http.StatusBadRequest || !strings.Contains(invalidRec.Body.String(), policy.ErrPatternInvalid.Error()) {
+		t.Fatalf("expected invalid pattern 400, got %d body=%s", invalidRec.Code, invalidRec.Body.String())
+	}
+
+	createRec := doJSON(t, router, http.MethodPost, "/api/v1/policies", `{"name":"no-sudo","action":"require_approval","capability":"terminalExec","match_field":"command","pattern":"\\bsudo\\b"}`, cookie)
+	if createRec.Code != http.StatusCreated {
+		t.Fatalf("expected create 201, got %d body=%s", createRec.Code, createRec.Body.String())
+	}
+	var created policy.Rule
+	if err := json.Unmarshal(createRec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decode created policy: %v", err)
+	}
+	if created.ID == "" || !created.Enabled || created.CreatedBy != testDashboardAccountID || created.Capability != "terminalexec" {
+		t.Fatalf("unexpected created policy %#v", created)
+	}
+
+	evaluate := func(body string) policyEvaluateResponse {
+		t.Helper()
+		rec := doJSON(t, router, http.MethodPost, "/api/v1/policies/evaluate", body, cookie)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected evaluate 200, got %d body=%s", rec.Code, rec.Body.String())
+		}
+		var payload policyEvaluateResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("decode evaluation: %v", err)
+		}
+		return payload
+	}
+	hit := evaluate(`{"capability":"terminalExec","input":{"command":"sudo reboot"}}`)
+	if hit.Action != policy.ActionRequireApproval || hit.Policy == nil || hit.Policy.ID != created.ID || hit.DefaultAction != policy.ActionAllow {
+		t.Fatalf("unexpected evaluation %#v", hit)
+	}
+	if miss := evaluate(`{"capability":"terminalExec","input":{"command":"ls"}}`); miss.Action != policy.ActionAllow || miss.Policy != nil {
+		t.Fatalf("expected default allow, got %#v", miss)
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/policies/evaluate", `{"input":{}}`, cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected missing capability 400, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	updateRec := doJSON(t, router, http.MethodPut, "/api/v1/policies/"+created.ID, `{"name":"no-sudo","action":"deny","capability":"terminalExec","worker_labels":{"env":"prod"},"match_field":"command","pattern":"\\bsudo\\b"}`, cookie)
+	if updateRec.Code != http.StatusOK {
+		t.Fatalf("expected update 200, got %d body=%s", updateRec.Code, updateRec.Body.String())
+	}
+	if noWorker := evaluate(`{"capability":"terminalExec","input":{"command":"sudo reboot"}}`); noWorker.Action != policy.ActionAllow {
+		t.Fatalf("expected label-scoped rule to skip without worker labels, got %#v", noWorker)
+	}
+	if prod := evaluate(`{"capability":"terminalExec","input":{"command":"sudo reboot"},"worker_labels":{"env":"prod"}}`); prod.Action != policy.ActionDeny {
+		t.Fatalf("expected deny on prod worker, got %#v", prod)
+	}
+
+	listRec := doJSON(t, router, http.MethodGet, "/api/v1/policies", "", cookie)
+	var listed policyListResponse
+	if err := json.Unmarshal(listRec.Body.Bytes(), &listed); err != nil || listRec.Code != http.StatusOK {
+		t.Fatalf("expected list 200, got %d body=%s", listRec.Code, listRec.Body.String())
+	}
+	if listed.Total != 1 || listed.Items[0].Action != policy.ActionDeny || listed.DefaultAction != policy.ActionAllow {
+		t.Fatalf("unexpected policy list %#v", listed)
+	}
+
+	if rec := doJSON(t, router, http.MethodDelete, "/api/v1/policies/"+created.ID, "", cookie); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected delete 204, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodDelete, "/api/v1/policies/"+created.ID, "", cookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected second delete 404, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPolicyRoutesRequireAdmin(t *testing.T) {
+	router := newPolicyTestRouter(t)
+	cookie := loginSessionCookieFor(t, router, "member-test", "member-password")
+
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/policies", "", cookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected member list 403, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/policies/evaluate", `{"capability":"echo"}`, cookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected member evaluate 403, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestSubmitTaskPolicyDenied(t *testing.T) {
+	handler := NewWorkerHandler(registrytest.NewStore(t), 15*time.Second, &fakeTaskDispatcher{
+		submit: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
+			if req.TokenID == "" {
+				t.Fatalf("expected token id to reach the dispatcher")
+			}
+			return grpcserver.SubmitTaskResult{}, &grpcserver.CommandPolicyError{
+				Action:     policy.ActionDeny,
+				PolicyID:   "pol_1",
+				PolicyName: "no-rm",
+			}
+		},
+	}, nil, nil, "")
+	router := mustNewRouter(t, handler, newTestConsoleAuth(t), newTestMCPAuth(t))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", strings.NewReader(`{"capability":"terminalExec","input":{"command":"rm -rf /"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	setMCPTokenHeader(req)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `"decision":"deny"`) || !strings.Contains(body, `"policy_id":"pol_1"`) {
+		t.Fatalf("expected policy decision in payload, got %s", body)
+	}
+}
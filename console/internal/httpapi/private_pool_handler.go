@@ -0,0 +1,59 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+)
+
+type privatePoolResponse struct {
+	OwnerID          string `json:"owner_id"`
+	FallbackToShared bool   `json:"fallback_to_shared"`
+}
+
+type putPrivatePoolRequest struct {
+	FallbackToShared *bool `json:"fallback_to_shared"`
+}
+
+// GetPrivatePool returns the routing preference of the caller's private
+// pool, or an organization's with org_id.
+func (h *WorkerHandler) GetPrivatePool(c *gin.Context) {
+	ownerID, _, ok := h.resolveWorkerOwnerScope(c, c.Query("org_id"), organizationRoleMember)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, privatePoolResponse{
+		OwnerID:          ownerID,
+		FallbackToShared: h.store.PrivatePoolFallback(ownerID),
+	})
+}
+
+// PutPrivatePool sets whether the owner's tasks may fall back to the shared
+// pool when its private workers are missing or busy. Organization pools need
+// an org admin.
+func (h *WorkerHandler) PutPrivatePool(c *gin.Context) {
+	ownerID, _, ok := h.resolveWorkerOwnerScope(c, c.Query("org_id"), organizationRoleAdmin)
+	if !ok {
+		return
+	}
+	var req putPrivatePoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.FallbackToShared == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fallback_to_shared is required"})
+		return
+	}
+	if err := h.store.SetPrivatePoolFallback(ownerID, *req.FallbackToShared, h.nowFn()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update private pool"})
+		return
+	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionWorkerPoolUpdate,
+		TargetType: "private_pool",
+		TargetID:   ownerID,
+		Details:    map[string]any{"fallback_to_shared": *req.FallbackToShared},
+	})
+	c.JSON(http.StatusOK, privatePoolResponse{
+		OwnerID:          ownerID,
+		FallbackToShared: *req.FallbackToShared,
+	})
+}
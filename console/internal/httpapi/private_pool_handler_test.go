@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func TestPrivateWorkerPoolForNonAdmin(t *testing.T) {
+	store := registrytest.NewStore(t)
+	now := time.Unix(1_700_000_400, 0)
+	consoleAuth := newTestConsoleAuth(t)
+	seedTestAccount(t, consoleAuth.queries, "acc-member-1", "member-test", "member-password", false)
+	provisioning := &fakeWorkerProvisioning{
+		secrets:      map[string]string{},
+		createNodeID: "node-own-private",
+		createSecret: "secret-own-private",
+	}
+	handler := NewWorkerHandler(store, 15*time.Second, nil, provisioning, nil, ":50051")
+	handler.nowFn = func() time.Time { return now }
+	router := mustNewRouter(t, handler, consoleAuth, newTestMCPAuth(t))
+	cookie := loginSessionCookieFor(t, router, "member-test", "member-password")
+
+	if rec := doJSON(t, router, http.MethodPost, "/api/v1/workers", `{"type":"private"}`, cookie); rec.Code != http.StatusCreated {
+		t.Fatalf("expected private worker create 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if provisioning.lastOwnerID != "acc-member-1" || provisioning.lastType != registry.WorkerTypePrivate {
+		t.Fatalf("expected private worker for caller, got owner=%q type=%q", provisioning.lastOwnerID, provisioning.lastType)
+	}
+	if err := store.Upsert(&registryv1.ConnectHello{
+		NodeId: "node-own-private",
+		Labels: map[string]string{
+			registry.LabelOwnerIDKey:    "acc-member-1",
+			registry.LabelWorkerTypeKey: registry.WorkerTypePrivate,
+		},
+	}, "session-own-private", now); err != nil {
+		t.Fatalf("seed private worker: %v", err)
+	}
+	rec := doJSON(t, router, http.MethodGet, "/api/v1/workers", "", cookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "node-own-private") {
+		t.Fatalf("expected own private worker in list, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	readPool := func() privatePoolResponse {
+		t.Helper()
+		rec := doJSON(t, router, http.MethodGet, "/api/v1/workers/private-pool", "", cookie)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected private pool 200, got %d body=%s", rec.Code, rec.Body.String())
+		}
+		payload := privatePoolResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+			t.Fatalf("decode private pool: %v", err)
+		}
+		return payload
+	}
+	if pool := readPool(); pool.OwnerID != "acc-member-1" || !pool.FallbackToShared {
+		t.Fatalf("expected fallback on by default, got %#v", pool)
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/workers/private-pool", `{}`, cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without fallback_to_shared, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/workers/private-pool", `{"fallback_to_shared":false}`, cookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected private pool update 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if pool := readPool(); pool.FallbackToShared {
+		t.Fatalf("expected fallback off after update, got %#v", pool)
+	}
+
+	if rec := doJSON(t, router, http.MethodDelete, "/api/v1/workers/node-own-private", "", cookie); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected own private worker delete 204, got %d body=%s", rec.Code, rec.Body.String())
+	}
+}
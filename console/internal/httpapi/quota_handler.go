@@ -0,0 +1,242 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+var errUsageUnavailable = errors.New("usage is unavailable")
+
+// UsageService reports an owner's quota consumption.
+type UsageService interface {
+	Usage(ownerID string) (grpcserver.UsageSnapshot, error)
+}
+
+type quotaLimits struct {
+	MaxConcurrentPerCapability int `json:"max_concurrent_per_capability"`
+	MaxTasksPerMinute          int `json:"max_tasks_per_minute"`
+	MaxExecSecondsPerDay       int `json:"max_exec_seconds_per_day"`
+	MaxTerminalSessions        int `json:"max_terminal_sessions"`
+}
+
+type quotaResponse struct {
+	OwnerID string `json:"owner_id"`
+	quotaLimits
+}
+
+type putQuotaRequest struct {
+	MaxConcurrentPerCapability *int `json:"max_concurrent_per_capability"`
+	MaxTasksPerMinute          *int `json:"max_tasks_per_minute"`
+	MaxExecSecondsPerDay       *int `json:"max_exec_seconds_per_day"`
+	MaxTerminalSessions        *int `json:"max_terminal_sessions"`
+}
+
+// usageRemaining leaves out limits that are not set.
+type usageRemaining struct {
+	TasksPerMinute   *int `json:"tasks_per_minute,omitempty"`
+	ExecSecondsToday *int `json:"exec_seconds_today,omitempty"`
+	TerminalSessions *int `json:"terminal_sessions,omitempty"`
+}
+
+type usageResponse struct {
+	OwnerID          string         `json:"owner_id"`
+	Limits           quotaLimits    `json:"limits"`
+	ConcurrentTasks  map[string]int `json:"concurrent_tasks"`
+	TasksLastMinute  int            `json:"tasks_last_minute"`
+	ExecSecondsToday int            `json:"exec_seconds_today"`
+	TerminalSessions int            `json:"terminal_sessions"`
+	Remaining        usageRemaining `json:"remaining"`
+	DayResetsAt      time.Time      `json:"day_resets_at"`
+}
+
+// SetUsage enables GET /api/v1/usage.
+func (h *WorkerHandler) SetUsage(usage UsageService) {
+	if h == nil {
+		return
+	}
+	h.usage = usage
+}
+
+// GetQuota returns the limits of an account or organization.
+func (h *WorkerHandler) GetQuota(c *gin.Context) {
+	ownerID, ok := h.requireQuotaOwner(c)
+	if !ok {
+		return
+	}
+	quota, err := h.store.Quota(ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load quota"})
+		return
+	}
+	c.JSON(http.StatusOK, quotaResponse{OwnerID: ownerID, quotaLimits: newQuotaLimits(quota)})
+}
+
+// PutQuota replaces the limits of an account or organization. Every limit
+// is required; 0 removes it.
+func (h *WorkerHandler) PutQuota(c *gin.Context) {
+	ownerID, ok := h.requireQuotaOwner(c)
+	if !ok {
+		return
+	}
+	var req putQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	values := []*int{req.MaxConcurrentPerCapability, req.MaxTasksPerMinute, req.MaxExecSecondsPerDay, req.MaxTerminalSessions}
+	for _, value := range values {
+		if value == nil || *value < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_concurrent_per_capability, max_tasks_per_minute, max_exec_seconds_per_day and max_terminal_sessions must be non-negative integers"})
+			return
+		}
+	}
+	quota := registry.Quota{
+		MaxConcurrentPerCapability: *req.MaxConcurrentPerCapability,
+		MaxTasksPerMinute:          *req.MaxTasksPerMinute,
+		MaxExecSecondsPerDay:       *req.MaxExecSecondsPerDay,
+		MaxTerminalSessions:        *req.MaxTerminalSessions,
+	}
+	if err := h.store.SetQuota(ownerID, quota, h.nowFn()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update quota"})
+		return
+	}
+	limits := newQuotaLimits(quota)
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionQuotaUpdate,
+		TargetType: "quota",
+		TargetID:   ownerID,
+		Details: map[string]any{
+			"max_concurrent_per_capability": limits.MaxConcurrentPerCapability,
+			"max_tasks_per_minute":          limits.MaxTasksPerMinute,
+			"max_exec_seconds_per_day":      limits.MaxExecSecondsPerDay,
+			"max_terminal_sessions":         limits.MaxTerminalSessions,
+		},
+	})
+	c.JSON(http.StatusOK, quotaResponse{OwnerID: ownerID, quotaLimits: limits})
+}
+
+// GetUsage returns the caller's quota consumption. Access tokens read their
+// owner scope; console sessions read their account, or an organization's
+// with org_id. With group_by, from, to, format or owner_id it returns the
+// usage report instead.
+func (h *WorkerHandler) GetUsage(c *gin.Context) {
+	if isUsageReportRequest(c) {
+		h.getUsageReport(c)
+		return
+	}
+	if h.usage == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errUsageUnavailable.Error()})
+		return
+	}
+	ownerID := requestOwnerIDFromGin(c)
+	if ownerID == "" {
+		scopedOwnerID, _, ok := h.resolveWorkerOwnerScope(c, c.Query("org_id"), organizationRoleMember)
+		if !ok {
+			return
+		}
+		ownerID = scopedOwnerID
+	}
+	usage, err := h.usage.Usage(ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load usage"})
+		return
+	}
+	c.JSON(http.StatusOK, buildUsageResponse(usage))
+}
+
+// requireUsageAccess authenticates with an access token when the request
+// carries one and with the console session otherwise.
+func requireUsageAccess(consoleAuth *ConsoleAuth, mcpAuth *MCPAuth) gin.HandlerFunc {
+	requireToken := mcpAuth.RequireToken()
+	requireSession := consoleAuth.RequireAuth()
+	return func(c *gin.Context) {
+		if strings.TrimSpace(c.GetHeader(trustedTokenHeader)) != "" {
+			requireToken(c)
+			return
+		}
+		requireSession(c)
+	}
+}
+
+// requireQuotaOwner resolves :owner_id to an existing account or
+// organization. It writes the error response itself.
+func (h *WorkerHandler) requireQuotaOwner(c *gin.Context) (string, bool) {
+	ownerID := strings.TrimSpace(c.Param("owner_id"))
+	if ownerID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "owner_id is required"})
+		return "", false
+	}
+	exists, err := h.quotaOwnerExists(c.Request.Context(), ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load owner"})
+		return "", false
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account or organization not found"})
+		return "", false
+	}
+	return ownerID, true
+}
+
+func (h *WorkerHandler) quotaOwnerExists(ctx context.Context, ownerID string) (bool, error) {
+	queries := h.store.Persistence().Queries
+	var err error
+	if registry.IsOrganizationOwnerID(ownerID) {
+		_, err = queries.GetOrganizationByID(ctx, ownerID)
+	} else {
+		_, err = queries.GetAccountByID(ctx, ownerID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func newQuotaLimits(quota registry.Quota) quotaLimits {
+	return quotaLimits{
+		MaxConcurrentPerCapability: quota.MaxConcurrentPerCapability,
+		MaxTasksPerMinute:          quota.MaxTasksPerMinute,
+		MaxExecSecondsPerDay:       quota.MaxExecSecondsPerDay,
+		MaxTerminalSessions:        quota.MaxTerminalSessions,
+	}
+}
+
+func buildUsageResponse(usage grpcserver.UsageSnapshot) usageResponse {
+	execSeconds := int(usage.ExecToday / time.Second)
+	response := usageResponse{
+		OwnerID:          usage.OwnerID,
+		Limits:           newQuotaLimits(usage.Quota),
+		ConcurrentTasks:  usage.Inflight,
+		TasksLastMinute:  usage.TasksLastMinute,
+		ExecSecondsToday: execSeconds,
+		TerminalSessions: usage.TerminalSessions,
+		DayResetsAt:      usage.DayStartedAt.Add(24 * time.Hour),
+	}
+	if response.ConcurrentTasks == nil {
+		response.ConcurrentTasks = map[string]int{}
+	}
+	if limit := usage.Quota.MaxTasksPerMinute; limit > 0 {
+		response.Remaining.TasksPerMinute = remainingQuota(limit, usage.TasksLastMinute)
+	}
+	if limit := usage.Quota.MaxExecSecondsPerDay; limit > 0 {
+		response.Remaining.ExecSecondsToday = remainingQuota(limit, execSeconds)
+	}
+	if limit := usage.Quota.MaxTerminalSessions; limit > 0 {
+		response.Remaining.TerminalSessions = remainingQuota(limit, usage.TerminalSessions)
+	}
+	return response
+}
+
+func remainingQuota(limit int, used int) *int {
+	remaining := max(limit-used, 0)
+	return &remaining
+}
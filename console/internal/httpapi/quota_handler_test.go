@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+func TestAccountQuotaAndUsage(t *testing.T) {
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+	seedTestAccount(t, db.Queries, "acc-quota", "quota-user", "quota-password", false)
+
+	store, err := registry.NewStoreWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+	dispatcher := &fakeTaskDispatcher{
+		submit: func(ctx context.Context, req grpcserver.SubmitTaskRequest) (grpcserver.SubmitTaskResult, error) {
+			return grpcserver.SubmitTaskResult{}, &grpcserver.QuotaExceededError{
+				Limit:      grpcserver.QuotaLimitTasksPerMinute,
+				Max:        1,
+				RetryAfter: 30 * time.Second,
+			}
+		},
+	}
+	handler := NewWorkerHandler(store, 15*time.Second, dispatcher, nil, nil, ":50051")
+	handler.SetUsage(grpcserver.NewRegistryService(store, nil, 5, 15, 60*time.Second))
+	router := mustNewRouter(t, handler, consoleAuth, mcpAuth)
+	adminCookie := loginSessionCookie(t, router)
+	userCookie := loginSessionCookieFor(t, router, "quota-user", "quota-password")
+
+	body := `{"max_concurrent_per_capability":2,"max_tasks_per_minute":1,"max_exec_seconds_per_day":600,"max_terminal_sessions":3}`
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/quotas/acc-quota", body, userCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected non-admin quota update 403, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/quotas/acc-missing", body, adminCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown owner 404, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/quotas/acc-quota", `{"max_tasks_per_minute":1}`, adminCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected partial quota 400, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/quotas/acc-quota", body, adminCookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected quota update 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if err := store.AddExecUsage("acc-quota", 100*time.Second, time.Now()); err != nil {
+		t.Fatalf("add usage: %v", err)
+	}
+
+	rec := doJSON(t, router, http.MethodGet, "/api/v1/usage", "", userCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected usage 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	usage := usageResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("decode usage: %v", err)
+	}
+	if usage.OwnerID != "acc-quota" || usage.Limits.MaxTasksPerMinute != 1 || usage.ExecSecondsToday != 100 {
+		t.Fatalf("unexpected usage %#v", usage)
+	}
+	if usage.Remaining.ExecSecondsToday == nil || *usage.Remaining.ExecSecondsToday != 500 ||
+		usage.Remaining.TasksPerMinute == nil || *usage.Remaining.TasksPerMinute != 1 {
+		t.Fatalf("unexpected remaining budget %#v", usage.Remaining)
+	}
+
+	rec = doJSON(t, router, http.MethodPost, "/api/v1/console/tokens", `{"name":"quota-agent"}`, userCookie)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected token create 201, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	token := createTrustedTokenResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &token); err != nil {
+		t.Fatalf("decode token: %v", err)
+	}
+	withToken := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(trustedTokenHeader, "Bearer "+token.Token)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+	if rec := withToken(http.MethodGet, "/api/v1/usage", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"owner_id":"acc-quota"`) {
+		t.Fatalf("expected token usage for its owner, got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec = withToken(http.MethodPost, "/api/v1/tasks", `{"capability":"echo","input":{"message":"hi"},"mode":"async"}`)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected quota 429 with Retry-After, got %d headers=%v", rec.Code, rec.Header())
+	}
+	payload := map[string]any{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode quota error: %v", err)
+	}
+	if payload["code"] != "quota_exceeded" || payload["limit"] != grpcserver.QuotaLimitTasksPerMinute {
+		t.Fatalf("unexpected quota error body %#v", payload)
+	}
+}
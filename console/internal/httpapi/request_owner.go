@@ -8,10 +8,19 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-const requestOwnerIDGinKey = "request_owner_id"
+const (
+	requestOwnerIDGinKey       = "request_owner_id"
+	requestAccessTokenIDGinKey = "request_access_token_id"
+	// requestTokenAccountIDGinKey holds the account that created the access
+	// token; it differs from the owner ID when the token acts for an
+	// organization.
+	requestTokenAccountIDGinKey = "request_token_account_id"
+)
 
 type requestOwnerIDContextKey struct{}
 
+type requestAccessTokenIDContextKey struct{}
+
 func setRequestOwnerID(c *gin.Context, ownerID string) {
 	if c == nil {
 		return
@@ -45,6 +54,8 @@ func requestOwnerIDFromGin(c *gin.Context) string {
 	return ""
 }
 
+// requestOwnerIDFromContext returns the owner scope of a token-authenticated
+// request: the organization for org tokens, otherwise the token's account.
 func requestOwnerIDFromContext(ctx context.Context) string {
 	if ctx == nil {
 		return ""
@@ -57,6 +68,31 @@ func requestOwnerIDFromContext(ctx context.Context) string {
 	return strings.TrimSpace(ownerID)
 }
 
+// setRequestAccessTokenID records which access token authenticated the
+// request so task submissions can be matched against token-scoped policies.
+func setRequestAccessTokenID(c *gin.Context, tokenID string) {
+	if c == nil {
+		return
+	}
+	trimmedTokenID := strings.TrimSpace(tokenID)
+	if trimmedTokenID == "" {
+		return
+	}
+	c.Set(requestAccessTokenIDGinKey, trimmedTokenID)
+	if c.Request != nil {
+		ctx := context.WithValue(c.Request.Context(), requestAccessTokenIDContextKey{}, trimmedTokenID)
+		c.Request = c.Request.WithContext(ctx)
+	}
+}
+
+func requestAccessTokenIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tokenID, _ := ctx.Value(requestAccessTokenIDContextKey{}).(string)
+	return strings.TrimSpace(tokenID)
+}
+
 func requireRequestOwnerID(c *gin.Context) (string, bool) {
 	ownerID := requestOwnerIDFromGin(c)
 	if ownerID == "" {
@@ -0,0 +1,156 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+var errSchedulingUnavailable = errors.New("scheduling stats are unavailable")
+
+// SchedulingService reports how tasks fared in the fair-share queue.
+type SchedulingService interface {
+	QueueStats() grpcserver.QueueStats
+}
+
+type schedulingSettingsResponse struct {
+	OwnerID       string `json:"owner_id"`
+	Weight        int    `json:"weight"`
+	PriorityClass string `json:"priority_class"`
+}
+
+type putSchedulingSettingsRequest struct {
+	Weight        *int   `json:"weight"`
+	PriorityClass string `json:"priority_class"`
+}
+
+type schedulingOwnerStats struct {
+	OwnerID            string `json:"owner_id"`
+	Weight             int    `json:"weight"`
+	PriorityClass      string `json:"priority_class"`
+	WaitingInteractive int    `json:"waiting_interactive"`
+	WaitingBatch       int    `json:"waiting_batch"`
+	OldestWaitingMS    int64  `json:"oldest_waiting_ms"`
+	Dispatched         int64  `json:"dispatched"`
+	StarvedDispatches  int64  `json:"starved_dispatches"`
+	Abandoned          int64  `json:"abandoned"`
+	AvgWaitMS          int64  `json:"avg_wait_ms"`
+	MaxWaitMS          int64  `json:"max_wait_ms"`
+}
+
+type schedulingStatsResponse struct {
+	Enabled               bool                   `json:"enabled"`
+	MaxWaitMS             int64                  `json:"max_wait_ms"`
+	StarvationThresholdMS int64                  `json:"starvation_threshold_ms"`
+	Owners                []schedulingOwnerStats `json:"owners"`
+}
+
+// SetScheduling enables GET /api/v1/scheduling/stats.
+func (h *WorkerHandler) SetScheduling(scheduling SchedulingService) {
+	if h == nil {
+		return
+	}
+	h.scheduling = scheduling
+}
+
+// GetSchedulingSettings returns the fair-share weight and priority class of
+// an account or organization.
+func (h *WorkerHandler) GetSchedulingSettings(c *gin.Context) {
+	ownerID, ok := h.requireQuotaOwner(c)
+	if !ok {
+		return
+	}
+	settings, err := h.store.SchedulingSettings(ownerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load scheduling settings"})
+		return
+	}
+	c.JSON(http.StatusOK, newSchedulingSettingsResponse(ownerID, settings))
+}
+
+// PutSchedulingSettings replaces the fair-share weight and priority class of
+// an account or organization.
+func (h *WorkerHandler) PutSchedulingSettings(c *gin.Context) {
+	ownerID, ok := h.requireQuotaOwner(c)
+	if !ok {
+		return
+	}
+	var req putSchedulingSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if req.Weight == nil || *req.Weight < 1 || *req.Weight > registry.MaxSchedulingWeight {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "weight must be an integer between 1 and 100"})
+		return
+	}
+	priorityClass := strings.TrimSpace(strings.ToLower(req.PriorityClass))
+	if priorityClass != registry.PriorityClassInteractive && priorityClass != registry.PriorityClassBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "priority_class must be interactive or batch"})
+		return
+	}
+	settings := registry.SchedulingSettings{Weight: *req.Weight, PriorityClass: priorityClass}
+	if err := h.store.SetSchedulingSettings(ownerID, settings, h.nowFn()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update scheduling settings"})
+		return
+	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionSchedulingUpdate,
+		TargetType: "scheduling",
+		TargetID:   ownerID,
+		Details: map[string]any{
+			"weight":         settings.Weight,
+			"priority_class": settings.PriorityClass,
+		},
+	})
+	c.JSON(http.StatusOK, newSchedulingSettingsResponse(ownerID, settings))
+}
+
+// GetSchedulingStats returns per-owner queue depth and wait times since the
+// console started.
+func (h *WorkerHandler) GetSchedulingStats(c *gin.Context) {
+	if h.scheduling == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errSchedulingUnavailable.Error()})
+		return
+	}
+	stats := h.scheduling.QueueStats()
+	response := schedulingStatsResponse{
+		Enabled:               stats.Enabled,
+		MaxWaitMS:             stats.MaxWait.Milliseconds(),
+		StarvationThresholdMS: stats.StarvationThreshold.Milliseconds(),
+		Owners:                make([]schedulingOwnerStats, 0, len(stats.Owners)),
+	}
+	for _, owner := range stats.Owners {
+		item := schedulingOwnerStats{
+			OwnerID:            owner.OwnerID,
+			Weight:             owner.Weight,
+			PriorityClass:      owner.PriorityClass,
+			WaitingInteractive: owner.WaitingInteractive,
+			WaitingBatch:       owner.WaitingBatch,
+			OldestWaitingMS:    owner.OldestWaiting.Milliseconds(),
+			Dispatched:         owner.Dispatched,
+			StarvedDispatches:  owner.StarvedDispatches,
+			Abandoned:          owner.Abandoned,
+			MaxWaitMS:          owner.MaxWait.Milliseconds(),
+		}
+		if owner.Dispatched > 0 {
+			item.AvgWaitMS = (owner.TotalWait / time.Duration(owner.Dispatched)).Milliseconds()
+		}
+		response.Owners = append(response.Owners, item)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func newSchedulingSettingsResponse(ownerID string, settings registry.SchedulingSettings) schedulingSettingsResponse {
+	return schedulingSettingsResponse{
+		OwnerID:       ownerID,
+		Weight:        settings.Weight,
+		PriorityClass: settings.PriorityClass,
+	}
+}
@@ -0,0 +1,109 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+type fakeSchedulingService struct {
+	stats grpcserver.QueueStats
+}
+
+func (f fakeSchedulingService) QueueStats() grpcserver.QueueStats {
+	return f.stats
+}
+
+func TestSchedulingSettingsAndStats(t *testing.T) {
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+	seedTestAccount(t, db.Queries, "acc-batch", "batch-user", "batch-password", false)
+
+	store, err := registry.NewStoreWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+	handler := NewWorkerHandler(store, 15*time.Second, &fakeTaskDispatcher{}, nil, nil, ":50051")
+	handler.SetScheduling(fakeSchedulingService{stats: grpcserver.QueueStats{
+		Enabled:             true,
+		MaxWait:             time.Minute,
+		StarvationThreshold: 30 * time.Second,
+		Owners: []grpcserver.QueueOwnerStats{{
+			OwnerID:       "acc-batch",
+			Weight:        3,
+			PriorityClass: registry.PriorityClassBatch,
+			WaitingBatch:  2,
+			Dispatched:    4,
+			TotalWait:     2 * time.Second,
+			MaxWait:       time.Second,
+		}},
+	}})
+	router := mustNewRouter(t, handler, consoleAuth, mcpAuth)
+	adminCookie := loginSessionCookie(t, router)
+	userCookie := loginSessionCookieFor(t, router, "batch-user", "batch-password")
+
+	rec := doJSON(t, router, http.MethodGet, "/api/v1/scheduling/acc-batch", "", adminCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected scheduling settings 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	settings := schedulingSettingsResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("decode settings: %v", err)
+	}
+	if settings.Weight != registry.DefaultSchedulingWeight || settings.PriorityClass != registry.PriorityClassInteractive {
+		t.Fatalf("expected default settings, got %#v", settings)
+	}
+
+	body := `{"weight":3,"priority_class":"batch"}`
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/scheduling/acc-batch", body, userCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected non-admin scheduling update 403, got %d", rec.Code)
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/scheduling/acc-missing", body, adminCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected unknown owner 404, got %d", rec.Code)
+	}
+	for _, invalid := range []string{`{"priority_class":"batch"}`, `{"weight":101,"priority_class":"batch"}`, `{"weight":1,"priority_class":"urgent"}`} {
+		if rec := doJSON(t, router, http.MethodPut, "/api/v1/scheduling/acc-batch", invalid, adminCookie); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected %s to be rejected with 400, got %d", invalid, rec.Code)
+		}
+	}
+	if rec := doJSON(t, router, http.MethodPut, "/api/v1/scheduling/acc-batch", body, adminCookie); rec.Code != http.StatusOK {
+		t.Fatalf("expected scheduling update 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	stored, err := store.SchedulingSettings("acc-batch")
+	if err != nil || stored.Weight != 3 || stored.PriorityClass != registry.PriorityClassBatch {
+		t.Fatalf("expected stored settings, got %#v err=%v", stored, err)
+	}
+
+	if rec := doJSON(t, router, http.MethodGet, "/api/v1/scheduling/stats", "", userCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected non-admin stats 403, got %d", rec.Code)
+	}
+	rec = doJSON(t, router, http.MethodGet, "/api/v1/scheduling/stats", "", adminCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected scheduling stats 200, got %d body=%s", rec.Code, rec.Body.String())
+	}
+	stats := schedulingStatsResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	if !stats.Enabled || stats.MaxWaitMS != 60000 || len(stats.Owners) != 1 {
+		t.Fatalf("unexpected stats %#v", stats)
+	}
+	if owner := stats.Owners[0]; owner.AvgWaitMS != 500 || owner.MaxWaitMS != 1000 || owner.WaitingBatch != 2 {
+		t.Fatalf("unexpected owner stats %#v", owner)
+	}
+}
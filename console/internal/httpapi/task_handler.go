@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -20,6 +24,9 @@ const (
 	defaultTaskTimeoutMS = 60000
 	maxTaskWaitMS        = 60000
 	maxTaskTimeoutMS     = 600000
+
+	defaultOrganizationTaskLimit = 50
+	maxOrganizationTaskLimit     = 200
 )
 
 type submitTaskRequest struct {
@@ -31,6 +38,11 @@ type submitTaskRequest struct {
 	RequestID  string          `json:"request_id,omitempty"`
 }
 
+type organizationTaskListResponse struct {
+	Items []taskResponse `json:"items"`
+	Total int            `json:"total"`
+}
+
 type taskErrorBody struct {
 	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
@@ -49,6 +61,7 @@ type taskResponse struct {
 	DeadlineAt  time.Time       `json:"deadline_at"`
 	CompletedAt *time.Time      `json:"completed_at,omitempty"`
 	StatusURL   string          `json:"status_url,omitempty"`
+	ApprovalID  string          `json:"approval_id,omitempty"`
 }
 
 func (h *WorkerHandler) SubmitTask(c *gin.Context) {
@@ -98,7 +111,9 @@ func (h *WorkerHandler) SubmitTask(c *gin.Context) {
 		Timeout:    time.Duration(timeoutMS) * time.Millisecond,
 		RequestID:  strings.TrimSpace(req.RequestID),
 		OwnerID:    ownerID,
+		TokenID:    requestAccessTokenIDFromContext(c.Request.Context()),
 	})
+	h.recordTaskSubmitAudit(c, req, mode, result, err)
 	if err != nil {
 		h.writeTaskSubmitError(c, err)
 		return
@@ -157,12 +172,111 @@ func (h *WorkerHandler) CancelTask(c *gin.Context) {
 		}
 		return
 	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionTaskCancel,
+		TargetType: "task",
+		TargetID:   taskID,
+		Details:    map[string]any{"capability": task.Capability},
+	})
 	c.JSON(http.StatusOK, buildTaskResponse(task))
 }
 
+// ListOrganizationTasks lists an organization's most recent tasks, newest
+// first, to any of its members. Results are left out; fetch them with an org
+// token through GET /api/v1/tasks/:task_id.
+func (h *WorkerHandler) ListOrganizationTasks(c *gin.Context) {
+	orgID, _, ok := h.resolveWorkerOwnerScope(c, c.Param("org_id"), organizationRoleMember)
+	if !ok {
+		return
+	}
+	limit, ok := parsePositiveIntQuery(c, "limit", defaultOrganizationTaskLimit)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	if limit > maxOrganizationTaskLimit {
+		limit = maxOrganizationTaskLimit
+	}
+	rows, err := h.store.Persistence().Queries.ListTasksByOwner(c.Request.Context(), sqlc.ListTasksByOwnerParams{
+		OwnerID: orgID,
+		Limit:   int64(limit),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tasks"})
+		return
+	}
+	items := make([]taskResponse, 0, len(rows))
+	for _, row := range rows {
+		item := taskResponse{
+			TaskID:     row.TaskID,
+			RequestID:  row.RequestID,
+			CommandID:  row.CommandID,
+			Capability: row.Capability,
+			Status:     row.Status,
+			CreatedAt:  time.UnixMilli(row.CreatedAtUnixMs),
+			UpdatedAt:  time.UnixMilli(row.UpdatedAtUnixMs),
+			DeadlineAt: time.UnixMilli(row.DeadlineAtUnixMs),
+			StatusURL:  taskStatusURL(row.TaskID),
+		}
+		if row.ErrorCode != "" || row.ErrorMessage != "" {
+			item.Error = &taskErrorBody{Code: row.ErrorCode, Message: row.ErrorMessage}
+		}
+		if row.CompletedAtUnixMs > 0 {
+			completedAt := time.UnixMilli(row.CompletedAtUnixMs)
+			item.CompletedAt = &completedAt
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, organizationTaskListResponse{Items: items, Total: len(items)})
+}
+
+func (h *WorkerHandler) recordTaskSubmitAudit(c *gin.Context, req submitTaskRequest, mode grpcserver.TaskMode, result grpcserver.SubmitTaskResult, submitErr error) {
+	details := map[string]any{
+		"capability": strings.TrimSpace(req.Capability),
+		"mode":       string(mode),
+	}
+	if requestID := strings.TrimSpace(req.RequestID); requestID != "" {
+		details["request_id"] = requestID
+	}
+	event := persistence.AuditEvent{
+		Action:     auditActionTaskSubmit,
+		TargetType: "task",
+		Details:    details,
+	}
+	var policyErr *grpcserver.CommandPolicyError
+	var quotaErr *grpcserver.QuotaExceededError
+	switch {
+	case errors.As(submitErr, &policyErr):
+		event.Outcome = persistence.AuditOutcomeDenied
+		details["error"] = policyErr.Error()
+		details["policy_id"] = policyErr.PolicyID
+	case errors.As(submitErr, &quotaErr):
+		event.Outcome = persistence.AuditOutcomeDenied
+		details["error"] = quotaErr.Error()
+		details["limit"] = quotaErr.Limit
+	case submitErr != nil:
+		event.Outcome = persistence.AuditOutcomeFailure
+		details["error"] = submitErr.Error()
+	default:
+		event.TargetID = result.Task.TaskID
+		details["status"] = string(result.Task.Status)
+	}
+	h.recordAudit(c, event)
+}
+
 func (h *WorkerHandler) writeTaskSubmitError(c *gin.Context, err error) {
 	var commandErr *grpcserver.CommandExecutionError
+	var policyErr *grpcserver.CommandPolicyError
+	var quotaErr *grpcserver.QuotaExceededError
 	switch {
+	case errors.As(err, &policyErr):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":     policyErr.Error(),
+			"decision":  policyErr.Action,
+			"policy_id": policyErr.PolicyID,
+		})
+	case errors.As(err, &quotaErr):
+		writeQuotaExceeded(c, quotaErr)
 	case errors.Is(err, grpcserver.ErrTaskRequestInProgress):
 		c.JSON(http.StatusConflict, gin.H{"error": "task request already in progress"})
 	case errors.Is(err, grpcserver.ErrNoCapabilityWorker):
@@ -180,6 +294,20 @@ func (h *WorkerHandler) writeTaskSubmitError(c *gin.Context, err error) {
 	}
 }
 
+// writeQuotaExceeded answers 429 with the limit that was hit and, when it
+// frees up at a known time, a Retry-After header.
+func writeQuotaExceeded(c *gin.Context, quotaErr *grpcserver.QuotaExceededError) {
+	if quotaErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(quotaErr.RetryAfter.Seconds()))))
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": quotaErr.Error(),
+		"code":  taskQuotaExceededCode,
+		"limit": quotaErr.Limit,
+		"max":   quotaErr.Max,
+	})
+}
+
 func mapTaskTerminalStatusToHTTP(task grpcserver.TaskSnapshot) int {
 	switch task.Status {
 	case grpcserver.TaskStatusSucceeded:
@@ -190,10 +318,12 @@ func mapTaskTerminalStatusToHTTP(task grpcserver.TaskSnapshot) int {
 		return http.StatusConflict
 	case grpcserver.TaskStatusFailed:
 		switch task.ErrorCode {
-		case "no_capacity":
+		case "no_capacity", taskQuotaExceededCode:
 			return http.StatusTooManyRequests
 		case "no_worker":
 			return http.StatusServiceUnavailable
+		case taskApprovalRejectedCode:
+			return http.StatusForbidden
 		default:
 			return http.StatusBadGateway
 		}
@@ -218,6 +348,7 @@ func buildTaskResponse(task grpcserver.TaskSnapshot) taskResponse {
 		UpdatedAt:   task.UpdatedAt,
 		DeadlineAt:  task.DeadlineAt,
 		CompletedAt: task.CompletedAt,
+		ApprovalID:  task.ApprovalID,
 	}
 	if strings.TrimSpace(task.ErrorCode) != "" || strings.TrimSpace(task.ErrorMessage) != "" {
 		response.Error = &taskErrorBody{
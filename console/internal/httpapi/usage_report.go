@@ -0,0 +1,242 @@
+package httpapi
+
+import (
+	"cmp"
+	"encoding/csv"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+const (
+	usageGroupAccount    = "account"
+	usageGroupCapability = "capability"
+	usageGroupNode       = "node"
+
+	defaultUsageReportWindow = 30 * 24 * time.Hour
+)
+
+var usageGroupOrder = []string{usageGroupAccount, usageGroupCapability, usageGroupNode}
+
+type usageReportItem struct {
+	OwnerID         string  `json:"owner_id,omitempty"`
+	Capability      string  `json:"capability,omitempty"`
+	NodeID          string  `json:"node_id,omitempty"`
+	Tasks           int64   `json:"tasks"`
+	FailedTasks     int64   `json:"failed_tasks"`
+	ExecMS          int64   `json:"exec_ms"`
+	QueueWaitMS     int64   `json:"queue_wait_ms"`
+	OutputBytes     int64   `json:"output_bytes"`
+	CPUSeconds      float64 `json:"cpu_seconds"`
+	PeakMemoryBytes int64   `json:"peak_memory_bytes"`
+}
+
+type usageReportResponse struct {
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	GroupBy []string          `json:"group_by"`
+	Items   []usageReportItem `json:"items"`
+	Totals  usageReportItem   `json:"totals"`
+}
+
+// isUsageReportRequest reports whether GET /api/v1/usage asks for the
+// aggregated report instead of the quota snapshot.
+func isUsageReportRequest(c *gin.Context) bool {
+	for _, key := range []string{"group_by", "from", "to", "format", "owner_id"} {
+		if _, ok := c.GetQuery(key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getUsageReport sums recorded command usage over [from, to). Access tokens
+// and console accounts read their own scope or an organization's with
+// org_id; admins read every owner, or one with owner_id.
+func (h *WorkerHandler) getUsageReport(c *gin.Context) {
+	ownerID, ok := h.resolveUsageReportOwner(c)
+	if !ok {
+		return
+	}
+	groupBy, ok := parseUsageGroupBy(c.Query("group_by"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_by must list account, capability or node"})
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "json")))
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+	now := h.nowFn()
+	to, ok := parseUsageReportTime(c, "to", now)
+	if !ok {
+		return
+	}
+	from, ok := parseUsageReportTime(c, "from", to.Add(-defaultUsageReportWindow))
+	if !ok {
+		return
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	totals, err := h.store.UsageTotals(ownerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load usage report"})
+		return
+	}
+	response := buildUsageReport(totals, groupBy)
+	response.From = from.UTC()
+	response.To = to.UTC()
+	if format == "csv" {
+		writeUsageReportCSV(c, response, now)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *WorkerHandler) resolveUsageReportOwner(c *gin.Context) (string, bool) {
+	if ownerID := requestOwnerIDFromGin(c); ownerID != "" {
+		return ownerID, true
+	}
+	ownerID, isAdmin, ok := h.resolveWorkerOwnerScope(c, c.Query("org_id"), organizationRoleMember)
+	if !ok {
+		return "", false
+	}
+	if isAdmin && strings.TrimSpace(c.Query("org_id")) == "" {
+		return strings.TrimSpace(c.Query("owner_id")), true
+	}
+	return ownerID, true
+}
+
+func parseUsageGroupBy(raw string) ([]string, bool) {
+	if strings.TrimSpace(raw) == "" {
+		return []string{usageGroupAccount}, true
+	}
+	selected := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		group := strings.ToLower(strings.TrimSpace(part))
+		if !slices.Contains(usageGroupOrder, group) {
+			return nil, false
+		}
+		selected[group] = true
+	}
+	groupBy := make([]string, 0, len(selected))
+	for _, group := range usageGroupOrder {
+		if selected[group] {
+			groupBy = append(groupBy, group)
+		}
+	}
+	return groupBy, true
+}
+
+func parseUsageReportTime(c *gin.Context, key string, fallback time.Time) (time.Time, bool) {
+	raw := strings.TrimSpace(c.Query(key))
+	if raw == "" {
+		return fallback, true
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be an RFC3339 timestamp"})
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+// buildUsageReport rolls the per owner, capability and node totals up to the
+// requested groups, ordered by owner, capability and node.
+func buildUsageReport(totals []registry.UsageTotals, groupBy []string) usageReportResponse {
+	response := usageReportResponse{GroupBy: groupBy, Items: []usageReportItem{}}
+	index := map[usageReportItem]int{}
+	for _, total := range totals {
+		key := usageReportItem{}
+		for _, group := range groupBy {
+			switch group {
+			case usageGroupAccount:
+				key.OwnerID = total.OwnerID
+			case usageGroupCapability:
+				key.Capability = total.Capability
+			case usageGroupNode:
+				key.NodeID = total.NodeID
+			}
+		}
+		position, ok := index[key]
+		if !ok {
+			position = len(response.Items)
+			index[key] = position
+			response.Items = append(response.Items, key)
+		}
+		addUsageTotals(&response.Items[position], total)
+		addUsageTotals(&response.Totals, total)
+	}
+	slices.SortFunc(response.Items, func(a usageReportItem, b usageReportItem) int {
+		return cmp.Or(
+			cmp.Compare(a.OwnerID, b.OwnerID),
+			cmp.Compare(a.Capability, b.Capability),
+			cmp.Compare(a.NodeID, b.NodeID),
+		)
+	})
+	return response
+}
+
+func addUsageTotals(item *usageReportItem, total registry.UsageTotals) {
+	item.Tasks += total.Tasks
+	item.FailedTasks += total.FailedTasks
+	item.ExecMS += total.Exec.Milliseconds()
+	item.QueueWaitMS += total.QueueWait.Milliseconds()
+	item.OutputBytes += total.OutputBytes
+	item.CPUSeconds += total.CPUSeconds
+	item.PeakMemoryBytes = max(item.PeakMemoryBytes, total.PeakMemoryBytes)
+}
+
+func writeUsageReportCSV(c *gin.Context, report usageReportResponse, now time.Time) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="onlyboxes-usage-`+strconv.FormatInt(now.Unix(), 10)+`.csv"`)
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	header := []string{}
+	for _, group := range report.GroupBy {
+		switch group {
+		case usageGroupAccount:
+			header = append(header, "owner_id")
+		case usageGroupCapability:
+			header = append(header, "capability")
+		case usageGroupNode:
+			header = append(header, "node_id")
+		}
+	}
+	header = append(header, "tasks", "failed_tasks", "exec_ms", "queue_wait_ms", "output_bytes", "cpu_seconds", "peak_memory_bytes")
+	_ = writer.Write(header)
+	for _, item := range report.Items {
+		record := []string{}
+		for _, group := range report.GroupBy {
+			switch group {
+			case usageGroupAccount:
+				record = append(record, item.OwnerID)
+			case usageGroupCapability:
+				record = append(record, item.Capability)
+			case usageGroupNode:
+				record = append(record, item.NodeID)
+			}
+		}
+		record = append(record,
+			strconv.FormatInt(item.Tasks, 10),
+			strconv.FormatInt(item.FailedTasks, 10),
+			strconv.FormatInt(item.ExecMS, 10),
+			strconv.FormatInt(item.QueueWaitMS, 10),
+			strconv.FormatInt(item.OutputBytes, 10),
+			strconv.FormatFloat(item.CPUSeconds, 'f', 3, 64),
+			strconv.FormatInt(item.PeakMemoryBytes, 10),
+		)
+		_ = writer.Write(record)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,113 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+func TestUsageReport(t *testing.T) {
+	db := openTestAuthDB(t)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	seedTestAccount(t, db.Queries, testDashboardAccountID, testDashboardUsername, testDashboardPassword, true)
+	seedTestAccount(t, db.Queries, "acc-report", "report-user", "report-password", false)
+
+	store, err := registry.NewStoreWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	completedAt := time.Now().Add(-time.Hour)
+	for i, record := range []registry.UsageRecord{
+		{OwnerID: "acc-report", Capability: "pythonExec", NodeID: "node-1", Status: registry.UsageStatusSucceeded, Exec: 2 * time.Second, QueueWait: time.Second, OutputBytes: 100, CPUSeconds: 1.5, PeakMemoryBytes: 1 << 20},
+		{OwnerID: "acc-report", Capability: "pythonExec", NodeID: "node-2", Status: registry.UsageStatusFailed, Exec: time.Second, OutputBytes: 20, CPUSeconds: 0.5, PeakMemoryBytes: 2 << 20},
+		{OwnerID: "acc-report", Capability: "terminalExec", NodeID: "node-1", Status: registry.UsageStatusSucceeded, Exec: 3 * time.Second, OutputBytes: 30},
+		{OwnerID: "acc-other", Capability: "pythonExec", NodeID: "node-1", Status: registry.UsageStatusSucceeded, Exec: 5 * time.Second, OutputBytes: 50, CPUSeconds: 4},
+	} {
+		record.CommandID = "cmd-" + string(rune('a'+i))
+		record.CompletedAt = completedAt
+		if err := store.RecordUsage(record); err != nil {
+			t.Fatalf("record usage: %v", err)
+		}
+	}
+	consoleAuth, err := NewConsoleAuth(db.Queries, false)
+	if err != nil {
+		t.Fatalf("new console auth: %v", err)
+	}
+	mcpAuth, err := NewMCPAuthWithPersistence(db)
+	if err != nil {
+		t.Fatalf("new mcp auth: %v", err)
+	}
+	handler := NewWorkerHandler(store, 15*time.Second, &fakeTaskDispatcher{}, nil, nil, ":50051")
+	router := mustNewRouter(t, handler, consoleAuth, mcpAuth)
+	adminCookie := loginSessionCookie(t, router)
+	userCookie := loginSessionCookieFor(t, router, "report-user", "report-password")
+
+	report := func(query string, cookie *http.Cookie) usageReportResponse {
+		t.Helper()
+		rec := doJSON(t, router, http.MethodGet, "/api/v1/usage?"+query, "", cookie)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected usage report 200 for %q, got %d body=%s", query, rec.Code, rec.Body.String())
+		}
+		response := usageReportResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode usage report: %v", err)
+		}
+		return response
+	}
+
+	own := report("group_by=account,capability", userCookie)
+	if len(own.Items) != 2 || own.Totals.Tasks != 3 || own.Totals.FailedTasks != 1 {
+		t.Fatalf("expected the caller's two capabilities, got %#v", own)
+	}
+	python := own.Items[0]
+	if python.OwnerID != "acc-report" || python.Capability != "pythonExec" || python.NodeID != "" {
+		t.Fatalf("unexpected report group %#v", python)
+	}
+	if python.Tasks != 2 || python.ExecMS != 3000 || python.QueueWaitMS != 1000 || python.OutputBytes != 120 || python.CPUSeconds != 2 || python.PeakMemoryBytes != 2<<20 {
+		t.Fatalf("unexpected pythonExec totals %#v", python)
+	}
+
+	all := report("group_by=capability", adminCookie)
+	if len(all.Items) != 2 || all.Items[0].Capability != "pythonExec" || all.Items[0].Tasks != 3 || all.Totals.Tasks != 4 {
+		t.Fatalf("expected admin report across owners, got %#v", all)
+	}
+	if other := report("owner_id=acc-other", adminCookie); len(other.Items) != 1 || other.Items[0].OwnerID != "acc-other" {
+		t.Fatalf("expected admin owner filter, got %#v", other)
+	}
+	window := url.Values{
+		"from": {completedAt.Add(time.Minute).Format(time.RFC3339)},
+		"to":   {time.Now().Format(time.RFC3339)},
+	}
+	if empty := report(window.Encode(), adminCookie); len(empty.Items) != 0 || empty.Totals.Tasks != 0 {
+		t.Fatalf("expected no usage after from, got %#v", empty)
+	}
+
+	rec := doJSON(t, router, http.MethodGet, "/api/v1/usage?group_by=account,node&format=csv", "", userCookie)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("expected csv report, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != "owner_id,node_id,tasks,failed_tasks,exec_ms,queue_wait_ms,output_bytes,cpu_seconds,peak_memory_bytes" {
+		t.Fatalf("unexpected csv rows %#v", rows)
+	}
+	if strings.Join(rows[1], ",") != "acc-report,node-1,2,0,5000,1000,130,1.500,1048576" {
+		t.Fatalf("unexpected csv row %#v", rows[1])
+	}
+
+	for _, query := range []string{"group_by=team", "format=xml", "from=yesterday", "from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z"} {
+		if rec := doJSON(t, router, http.MethodGet, "/api/v1/usage?"+query, "", userCookie); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected %q to be rejected with 400, got %d", query, rec.Code)
+		}
+	}
+}
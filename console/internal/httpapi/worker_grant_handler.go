@@ -0,0 +1,252 @@
+package httpapi
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+)
+
+const (
+	defaultWorkerGrantTTLSec = 7 * 24 * 60 * 60
+	minWorkerGrantTTLSec     = 60
+	maxWorkerGrantTTLSec     = 365 * 24 * 60 * 60
+)
+
+type workerGrantItem struct {
+	NodeID       string    `json:"node_id"`
+	OwnerID      string    `json:"owner_id"`
+	AccountID    string    `json:"account_id"`
+	Capabilities []string  `json:"capabilities"`
+	ReadOnly     bool      `json:"read_only"`
+	GrantedBy    string    `json:"granted_by"`
+	GrantedAt    time.Time `json:"granted_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type workerGrantListResponse struct {
+	Items []workerGrantItem `json:"items"`
+	Total int               `json:"total"`
+}
+
+type putWorkerGrantRequest struct {
+	Capabilities []string `json:"capabilities"`
+	ReadOnly     bool     `json:"read_only"`
+	TTLSec       int      `json:"ttl_sec"`
+}
+
+// ListWorkerGrants lists who may use a worker-sys. Only its owner or an
+// admin sees the grants; anyone else gets 404.
+func (h *WorkerHandler) ListWorkerGrants(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	worker, ok := h.resolveGrantableWorker(c, account)
+	if !ok {
+		return
+	}
+	grants, err := h.store.ListWorkerSysGrantsByNode(worker.NodeID, h.nowFn())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list worker grants"})
+		return
+	}
+	ownerID := strings.TrimSpace(worker.Labels[registry.LabelOwnerIDKey])
+	items := make([]workerGrantItem, 0, len(grants))
+	for _, grant := range grants {
+		items = append(items, buildWorkerGrantItem(grant, ownerID))
+	}
+	c.JSON(http.StatusOK, workerGrantListResponse{Items: items, Total: len(items)})
+}
+
+// PutWorkerGrant grants an account the listed capabilities on a worker-sys,
+// replacing an earlier grant to the same account.
+func (h *WorkerHandler) PutWorkerGrant(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	worker, ok := h.resolveGrantableWorker(c, account)
+	if !ok {
+		return
+	}
+	ownerID := strings.TrimSpace(worker.Labels[registry.LabelOwnerIDKey])
+	granteeID := strings.TrimSpace(c.Param("account_id"))
+	if granteeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id is required"})
+		return
+	}
+	if granteeID == ownerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "worker owner cannot be a grantee"})
+		return
+	}
+
+	var req putWorkerGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	capabilities, err := resolveWorkerGrantCapabilities(req.Capabilities, req.ReadOnly)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ttlSec := req.TTLSec
+	if ttlSec == 0 {
+		ttlSec = defaultWorkerGrantTTLSec
+	}
+	if ttlSec < minWorkerGrantTTLSec || ttlSec > maxWorkerGrantTTLSec {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ttl_sec must be between 60 and 31536000"})
+		return
+	}
+
+	if _, err := h.store.Persistence().Queries.GetAccountByID(c.Request.Context(), granteeID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "account not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to grant worker access"})
+		return
+	}
+
+	now := h.nowFn()
+	grant := registry.WorkerSysGrant{
+		NodeID:       worker.NodeID,
+		GranteeID:    granteeID,
+		Capabilities: capabilities,
+		GrantedBy:    account.AccountID,
+		GrantedAt:    now,
+		ExpiresAt:    now.Add(time.Duration(ttlSec) * time.Second),
+	}
+	if err := h.store.ReplaceWorkerSysGrant(grant); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to grant worker access"})
+		return
+	}
+
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionWorkerGrant,
+		TargetType: "worker",
+		TargetID:   worker.NodeID,
+		Details: map[string]any{
+			"grantee_id":         granteeID,
+			"capabilities":       capabilities,
+			"expires_at_unix_ms": grant.ExpiresAt.UnixMilli(),
+		},
+	})
+	c.JSON(http.StatusOK, buildWorkerGrantItem(grant, ownerID))
+}
+
+// DeleteWorkerGrant revokes an account's grant immediately; commands already
+// running finish.
+func (h *WorkerHandler) DeleteWorkerGrant(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	worker, ok := h.resolveGrantableWorker(c, account)
+	if !ok {
+		return
+	}
+	granteeID := strings.TrimSpace(c.Param("account_id"))
+	deleted, err := h.store.DeleteWorkerSysGrant(worker.NodeID, granteeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke worker grant"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "worker grant not found"})
+		return
+	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionWorkerGrantRevoke,
+		TargetType: "worker",
+		TargetID:   worker.NodeID,
+		Details:    map[string]any{"grantee_id": granteeID},
+	})
+	c.Status(http.StatusNoContent)
+}
+
+// ListSharedWorkers lists the worker-sys grants the current account holds.
+func (h *WorkerHandler) ListSharedWorkers(c *gin.Context) {
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return
+	}
+	grants, err := h.store.ListWorkerSysGrantsByGrantee(account.AccountID, h.nowFn())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list shared workers"})
+		return
+	}
+	items := make([]workerGrantItem, 0, len(grants))
+	for _, grant := range grants {
+		ownerID := strings.TrimSpace(h.store.LabelsByNodeID(grant.NodeID)[registry.LabelOwnerIDKey])
+		items = append(items, buildWorkerGrantItem(grant, ownerID))
+	}
+	c.JSON(http.StatusOK, workerGrantListResponse{Items: items, Total: len(items)})
+}
+
+// resolveGrantableWorker loads the worker-sys named in the path and checks
+// that the caller owns it, administers the owning organization, or is an
+// admin.
+func (h *WorkerHandler) resolveGrantableWorker(c *gin.Context, account SessionAccount) (registry.WorkerView, bool) {
+	nodeID := strings.TrimSpace(c.Param("node_id"))
+	if nodeID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "node_id is required"})
+		return registry.WorkerView{}, false
+	}
+	worker, found := h.store.GetByNodeID(nodeID, h.nowFn(), h.offlineTTL)
+	if !found || strings.TrimSpace(strings.ToLower(worker.Labels[registry.LabelWorkerTypeKey])) != registry.WorkerTypeSys {
+		c.JSON(http.StatusNotFound, gin.H{"error": "worker not found"})
+		return registry.WorkerView{}, false
+	}
+	if !account.IsAdmin && !h.canManageWorkerOwner(c.Request.Context(), account.AccountID, worker.Labels[registry.LabelOwnerIDKey]) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "worker not found"})
+		return registry.WorkerView{}, false
+	}
+	return worker, true
+}
+
+// resolveWorkerGrantCapabilities validates and de-duplicates the requested
+// capabilities. read_only is shorthand for readImage alone.
+func resolveWorkerGrantCapabilities(requested []string, readOnly bool) ([]string, error) {
+	capabilities := make([]string, 0, len(requested))
+	for _, raw := range requested {
+		declared, ok := registry.NormalizeWorkerSysGrantCapability(raw)
+		if !ok {
+			return nil, errors.New("capabilities must be computerUse, readImage, readFile, writeFile, listDir or screenshot")
+		}
+		if !slices.Contains(capabilities, declared) {
+			capabilities = append(capabilities, declared)
+		}
+	}
+	if readOnly {
+		if len(capabilities) > 1 || (len(capabilities) == 1 && capabilities[0] != registry.WorkerSysReadOnlyCapability) {
+			return nil, errors.New("read_only grants cover readImage only")
+		}
+		return []string{registry.WorkerSysReadOnlyCapability}, nil
+	}
+	if len(capabilities) == 0 {
+		return nil, errors.New("capabilities is required unless read_only is set")
+	}
+	slices.Sort(capabilities)
+	return capabilities, nil
+}
+
+func buildWorkerGrantItem(grant registry.WorkerSysGrant, ownerID string) workerGrantItem {
+	return workerGrantItem{
+		NodeID:       grant.NodeID,
+		OwnerID:      ownerID,
+		AccountID:    grant.GranteeID,
+		Capabilities: append([]string(nil), grant.Capabilities...),
+		ReadOnly:     grant.ReadOnly(),
+		GrantedBy:    grant.GrantedBy,
+		GrantedAt:    grant.GrantedAt,
+		ExpiresAt:    grant.ExpiresAt,
+	}
+}
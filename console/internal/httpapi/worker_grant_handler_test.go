@@ -0,0 +1,110 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
+	"github.com/onlyboxes/onlyboxes/console/internal/registry"
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/registrytest"
+)
+
+func TestWorkerGrantLifecycle(t *testing.T) {
+	store := registrytest.NewStore(t)
+	now := time.Unix(1_700_000_300, 0)
+	if err := store.Upsert(&registryv1.ConnectHello{
+		NodeId: "node-own-sys",
+		Labels: map[string]string{
+			registry.LabelOwnerIDKey:    "acc-member-1",
+			registry.LabelWorkerTypeKey: registry.WorkerTypeSys,
+		},
+	}, "session-own-sys", now); err != nil {
+		t.Fatalf("seed own sys worker: %v", err)
+	}
+
+	consoleAuth := newTestConsoleAuth(t)
+	seedTestAccount(t, consoleAuth.queries, "acc-member-1", "member-test", "member-password", false)
+	seedTestAccount(t, consoleAuth.queries, "acc-member-2", "member-two", "member-password", false)
+	seedTestAccount(t, store.Persistence().Queries, "acc-member-2", "member-two", "member-password", false)
+	handler := NewWorkerHandler(store, 15*time.Second, nil, nil, nil, "")
+	handler.nowFn = func() time.Time { return now }
+	router := mustNewRouter(t, handler, consoleAuth, newTestMCPAuth(t))
+	ownerCookie := loginSessionCookieFor(t, router, "member-test", "member-password")
+	granteeCookie := loginSessionCookieFor(t, router, "member-two", "member-password")
+
+	do := func(cookie *http.Cookie, method string, path string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addSessionCookie(req, cookie)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		return res
+	}
+
+	for _, tc := range []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/api/v1/workers/node-own-sys/grants/acc-member-2", `{"read_only":true,"capabilities":["computerUse"]}`, http.StatusBadRequest},
+		{"/api/v1/workers/node-own-sys/grants/acc-member-2", `{"capabilities":["terminalExec"]}`, http.StatusBadRequest},
+		{"/api/v1/workers/node-own-sys/grants/acc-member-2", `{}`, http.StatusBadRequest},
+		{"/api/v1/workers/node-own-sys/grants/acc-member-2", `{"read_only":true,"ttl_sec":10}`, http.StatusBadRequest},
+		{"/api/v1/workers/node-own-sys/grants/acc-member-1", `{"read_only":true}`, http.StatusBadRequest},
+		{"/api/v1/workers/node-own-sys/grants/acc-missing", `{"read_only":true}`, http.StatusNotFound},
+	} {
+		if res := do(ownerCookie, http.MethodPut, tc.path, tc.body); res.Code != tc.code {
+			t.Fatalf("PUT %s %s: expected %d, got %d body=%s", tc.path, tc.body, tc.code, res.Code, res.Body.String())
+		}
+	}
+
+	res := do(ownerCookie, http.MethodPut, "/api/v1/workers/node-own-sys/grants/acc-member-2", `{"read_only":true,"ttl_sec":3600}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 for grant, got %d body=%s", res.Code, res.Body.String())
+	}
+	granted := workerGrantItem{}
+	if err := json.Unmarshal(res.Body.Bytes(), &granted); err != nil {
+		t.Fatalf("decode grant: %v", err)
+	}
+	if !granted.ReadOnly || !reflect.DeepEqual(granted.Capabilities, []string{"readImage"}) || !granted.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected grant %#v", granted)
+	}
+
+	if res := do(granteeCookie, http.MethodGet, "/api/v1/workers/node-own-sys/grants", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected grantee to get 404 on owner grant list, got %d", res.Code)
+	}
+	res = do(granteeCookie, http.MethodGet, "/api/v1/workers/shared", "")
+	shared := workerGrantListResponse{}
+	if err := json.Unmarshal(res.Body.Bytes(), &shared); err != nil {
+		t.Fatalf("decode shared workers: %v", err)
+	}
+	if res.Code != http.StatusOK || shared.Total != 1 || shared.Items[0].NodeID != "node-own-sys" || shared.Items[0].OwnerID != "acc-member-1" {
+		t.Fatalf("unexpected shared workers %d %s", res.Code, res.Body.String())
+	}
+
+	res = do(ownerCookie, http.MethodPut, "/api/v1/workers/node-own-sys/grants/acc-member-2", `{"capabilities":["listDir","computeruse"]}`)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200 for grant update, got %d body=%s", res.Code, res.Body.String())
+	}
+	res = do(ownerCookie, http.MethodGet, "/api/v1/workers/node-own-sys/grants", "")
+	listed := workerGrantListResponse{}
+	if err := json.Unmarshal(res.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decode grants: %v", err)
+	}
+	if listed.Total != 1 || !reflect.DeepEqual(listed.Items[0].Capabilities, []string{"computerUse", "listDir"}) || listed.Items[0].ReadOnly {
+		t.Fatalf("expected replaced grant, got %s", res.Body.String())
+	}
+
+	if res := do(ownerCookie, http.MethodDelete, "/api/v1/workers/node-own-sys/grants/acc-member-2", ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for revoke, got %d body=%s", res.Code, res.Body.String())
+	}
+	if res := do(ownerCookie, http.MethodDelete, "/api/v1/workers/node-own-sys/grants/acc-member-2", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for repeated revoke, got %d", res.Code)
+	}
+}
@@ -1,16 +1,20 @@
 package httpapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/onlyboxes/onlyboxes/console/internal/grpcserver"
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence"
+	"github.com/onlyboxes/onlyboxes/console/internal/policy"
 	"github.com/onlyboxes/onlyboxes/console/internal/registry"
 )
 
@@ -24,6 +28,10 @@ const (
 
 var ErrMCPAuthRequired = errors.New("mcp auth is required")
 
+// ownedWorkerTypes are the worker types a non-admin account or organization
+// can own, see and delete.
+var ownedWorkerTypes = []string{registry.WorkerTypeSys, registry.WorkerTypePrivate}
+
 type WorkerHandler struct {
 	store           *registry.Store
 	offlineTTL      time.Duration
@@ -31,6 +39,10 @@ type WorkerHandler struct {
 	provisioning    WorkerProvisioning
 	inflightStats   InflightStatsProvider
 	consoleGRPCAddr string
+	policy          *policy.Engine
+	approvals       ApprovalService
+	usage           UsageService
+	scheduling      SchedulingService
 	nowFn           func() time.Time
 }
 
@@ -65,7 +77,8 @@ type workerStartupCommandResponse struct {
 }
 
 type createWorkerRequest struct {
-	Type string `json:"type"`
+	Type  string `json:"type"`
+	OrgID string `json:"org_id,omitempty"`
 }
 
 func NewWorkerHandler(
@@ -93,6 +106,11 @@ func NewRouter(workerHandler *WorkerHandler, consoleAuth *ConsoleAuth, mcpAuth *
 	}
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// Only the configured reverse proxies may set the client IP through
+	// X-Forwarded-For; login throttling, sessions and audit events key on it.
+	if err := router.SetTrustedProxies(consoleAuth.trustedProxyList()); err != nil {
+		return nil, err
+	}
 	router.Use(gin.Recovery())
 	router.Any("/mcp", mcpAuth.RequireToken(), gin.WrapH(NewMCPHandler(workerHandler.dispatcher)))
 
@@ -112,35 +130,97 @@ func NewRouter(workerHandler *WorkerHandler, consoleAuth *ConsoleAuth, mcpAuth *
 		api.GET("/workers/inflight", workerHandler.WorkerInflight)
 		api.POST("/workers", workerHandler.CreateWorker)
 		api.DELETE("/workers/:node_id", workerHandler.DeleteWorker)
+		execAPI.GET("/usage", workerHandler.GetUsage)
 		if err := registerEmbeddedWebRoutes(router); err != nil {
 			return nil, err
 		}
 		return router, nil
 	}
 
-	api.POST("/console/login", consoleAuth.Login)
-	api.POST("/console/logout", consoleAuth.Logout)
+	api.POST("/console/login", consoleAuth.RequireSameOrigin(), consoleAuth.Login)
+	api.POST("/console/login/2fa", consoleAuth.RequireSameOrigin(), consoleAuth.LoginSecondFactor)
+	api.POST("/console/logout", consoleAuth.RequireSameOrigin(), consoleAuth.Logout)
+	api.GET("/console/oidc", consoleAuth.OIDCConfig)
+	api.GET("/console/oidc/login", consoleAuth.OIDCLogin)
+	api.GET("/console/oidc/callback", consoleAuth.OIDCCallback)
 	api.GET("/console/session", consoleAuth.RequireAuth(), consoleAuth.Session)
 
 	dashboard := api.Group("/")
 	dashboard.Use(consoleAuth.RequireAuth())
 	dashboard.POST("/console/password", consoleAuth.ChangePassword)
-	dashboard.GET("/console/tokens", mcpAuth.ListTokens)
-	dashboard.POST("/console/tokens", mcpAuth.CreateToken)
-	dashboard.DELETE("/console/tokens/:token_id", mcpAuth.DeleteToken)
-	dashboard.GET("/console/tokens/:token_id/value", mcpAuth.GetTokenValue)
+	dashboard.GET("/console/sessions", consoleAuth.ListSessions)
+	dashboard.DELETE("/console/sessions/:session_id", consoleAuth.DeleteSession)
+	dashboard.GET("/console/2fa", consoleAuth.TwoFactorStatus)
+	dashboard.POST("/console/2fa/totp/enroll", consoleAuth.EnrollTOTP)
+	dashboard.POST("/console/2fa/totp/verify", consoleAuth.VerifyTOTPEnrollment)
+	dashboard.POST("/console/2fa/totp/disable", consoleAuth.DisableTOTP)
+	dashboard.POST("/console/2fa/recovery-codes", consoleAuth.RegenerateRecoveryCodes)
+	dashboard.GET("/console/management-tokens", mcpAuth.ListManagementTokens)
+	dashboard.POST("/console/management-tokens", mcpAuth.CreateManagementToken)
+	dashboard.DELETE("/console/management-tokens/:token_id", mcpAuth.DeleteManagementToken)
+	dashboard.GET("/console/management-tokens/:token_id/events", mcpAuth.ListManagementTokenEvents)
 	dashboard.POST("/console/register", consoleAuth.RequireAdmin(), consoleAuth.Register)
-	dashboard.GET("/workers", workerHandler.ListWorkers)
-	dashboard.GET("/workers/stats", workerHandler.WorkerStats)
-	dashboard.GET("/workers/inflight", workerHandler.WorkerInflight)
-	dashboard.POST("/workers", workerHandler.CreateWorker)
-	dashboard.DELETE("/workers/:node_id", workerHandler.DeleteWorker)
-	dashboard.GET("/workers/:node_id/startup-command", workerHandler.GetWorkerStartupCommand)
+
+	// Routes automation may reach with a management token instead of the
+	// session cookie.
+	manage := func(permission string) gin.HandlerFunc {
+		return mcpAuth.RequireManagementAccess(consoleAuth, permission)
+	}
+	api.GET("/console/tokens", manage(managementPermissionTokensRead), mcpAuth.ListTokens)
+	api.POST("/console/tokens", manage(managementPermissionTokensWrite), mcpAuth.CreateToken)
+	api.DELETE("/console/tokens/:token_id", manage(managementPermissionTokensWrite), mcpAuth.DeleteToken)
+	api.GET("/console/tokens/:token_id/value", manage(managementPermissionTokensRead), mcpAuth.GetTokenValue)
+	api.GET("/workers", manage(managementPermissionWorkersRead), workerHandler.ListWorkers)
+	api.GET("/workers/stats", manage(managementPermissionWorkersRead), workerHandler.WorkerStats)
+	api.GET("/workers/inflight", manage(managementPermissionWorkersRead), workerHandler.WorkerInflight)
+	api.POST("/workers", manage(managementPermissionWorkersWrite), workerHandler.CreateWorker)
+	api.DELETE("/workers/:node_id", manage(managementPermissionWorkersWrite), workerHandler.DeleteWorker)
+	api.GET("/workers/:node_id/startup-command", manage(managementPermissionWorkersRead), workerHandler.GetWorkerStartupCommand)
+	api.GET("/workers/shared", manage(managementPermissionWorkersRead), workerHandler.ListSharedWorkers)
+	api.GET("/workers/private-pool", manage(managementPermissionWorkersRead), workerHandler.GetPrivatePool)
+	api.PUT("/workers/private-pool", manage(managementPermissionWorkersWrite), workerHandler.PutPrivatePool)
+	api.GET("/workers/:node_id/grants", manage(managementPermissionWorkersRead), workerHandler.ListWorkerGrants)
+	api.PUT("/workers/:node_id/grants/:account_id", manage(managementPermissionWorkersWrite), workerHandler.PutWorkerGrant)
+	api.DELETE("/workers/:node_id/grants/:account_id", manage(managementPermissionWorkersWrite), workerHandler.DeleteWorkerGrant)
+	api.GET("/console/accounts", manage(managementPermissionAccountsRead), consoleAuth.RequireAdmin(), consoleAuth.ListAccounts)
+	api.DELETE("/console/accounts/:account_id", manage(managementPermissionAccountsWrite), consoleAuth.RequireAdmin(), consoleAuth.DeleteAccount)
+	api.DELETE("/console/accounts/:account_id/sessions", manage(managementPermissionAccountsWrite), consoleAuth.RequireAdmin(), consoleAuth.DeleteAccountSessions)
+	api.DELETE("/console/accounts/:account_id/2fa", manage(managementPermissionAccountsWrite), consoleAuth.RequireAdmin(), consoleAuth.ResetAccountTwoFactor)
+	api.GET("/quotas/:owner_id", manage(managementPermissionAccountsRead), consoleAuth.RequireAdmin(), workerHandler.GetQuota)
+	api.PUT("/quotas/:owner_id", manage(managementPermissionAccountsWrite), consoleAuth.RequireAdmin(), workerHandler.PutQuota)
+	api.GET("/usage", requireUsageAccess(consoleAuth, mcpAuth), workerHandler.GetUsage)
+	api.GET("/scheduling/stats", manage(managementPermissionAccountsRead), consoleAuth.RequireAdmin(), workerHandler.GetSchedulingStats)
+	api.GET("/scheduling/:owner_id", manage(managementPermissionAccountsRead), consoleAuth.RequireAdmin(), workerHandler.GetSchedulingSettings)
+	api.PUT("/scheduling/:owner_id", manage(managementPermissionAccountsWrite), consoleAuth.RequireAdmin(), workerHandler.PutSchedulingSettings)
+
+	dashboard.GET("/approvals", workerHandler.ListApprovals)
+	dashboard.GET("/approvals/:approval_id", workerHandler.GetApproval)
+	dashboard.POST("/approvals/:approval_id", workerHandler.DecideApproval)
+	dashboard.GET("/orgs", mcpAuth.ListOrganizations)
+	dashboard.POST("/orgs", mcpAuth.CreateOrganization)
+	dashboard.GET("/orgs/:org_id", mcpAuth.GetOrganization)
+	dashboard.DELETE("/orgs/:org_id", mcpAuth.DeleteOrganization)
+	dashboard.PUT("/orgs/:org_id/members/:account_id", mcpAuth.PutOrganizationMember)
+	dashboard.DELETE("/orgs/:org_id/members/:account_id", mcpAuth.DeleteOrganizationMember)
+	dashboard.GET("/orgs/:org_id/tokens", mcpAuth.ListOrganizationTokens)
+	dashboard.POST("/orgs/:org_id/tokens", mcpAuth.CreateOrganizationToken)
+	dashboard.DELETE("/orgs/:org_id/tokens/:token_id", mcpAuth.DeleteOrganizationToken)
+	dashboard.GET("/orgs/:org_id/tasks", workerHandler.ListOrganizationTasks)
 
 	adminDashboard := api.Group("/")
 	adminDashboard.Use(consoleAuth.RequireAuth(), consoleAuth.RequireAdmin())
-	adminDashboard.GET("/console/accounts", consoleAuth.ListAccounts)
-	adminDashboard.DELETE("/console/accounts/:account_id", consoleAuth.DeleteAccount)
+	adminDashboard.GET("/console/login-lockouts", consoleAuth.ListLoginLockouts)
+	adminDashboard.POST("/console/login-lockouts/unlock", consoleAuth.UnlockLogin)
+	adminDashboard.GET("/console/settings/security", consoleAuth.GetSecuritySettings)
+	adminDashboard.PUT("/console/settings/security", consoleAuth.UpdateSecuritySettings)
+	adminDashboard.GET("/audit", consoleAuth.ListAuditEvents)
+	adminDashboard.GET("/audit/export", consoleAuth.ExportAuditEvents)
+	adminDashboard.GET("/audit/verify", consoleAuth.VerifyAuditChain)
+	adminDashboard.GET("/policies", workerHandler.ListPolicies)
+	adminDashboard.POST("/policies", workerHandler.CreatePolicy)
+	adminDashboard.POST("/policies/evaluate", workerHandler.EvaluatePolicy)
+	adminDashboard.PUT("/policies/:policy_id", workerHandler.UpdatePolicy)
+	adminDashboard.DELETE("/policies/:policy_id", workerHandler.DeletePolicy)
 
 	if err := registerEmbeddedWebRoutes(router); err != nil {
 		return nil, err
@@ -150,9 +230,8 @@ func NewRouter(workerHandler *WorkerHandler, consoleAuth *ConsoleAuth, mcpAuth *
 }
 
 func (h *WorkerHandler) ListWorkers(c *gin.Context) {
-	ownerID, isAdmin, ok := resolveWorkerAccessScope(c)
+	ownerID, isAdmin, ok := h.resolveWorkerOwnerScope(c, c.Query("org_id"), organizationRoleMember)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 		return
 	}
 
@@ -188,7 +267,7 @@ func (h *WorkerHandler) ListWorkers(c *gin.Context) {
 			h.nowFn(),
 			h.offlineTTL,
 			ownerID,
-			registry.WorkerTypeSys,
+			ownedWorkerTypes...,
 		)
 	}
 	items := make([]workerItem, 0, len(workers))
@@ -215,11 +294,6 @@ func (h *WorkerHandler) ListWorkers(c *gin.Context) {
 }
 
 func (h *WorkerHandler) CreateWorker(c *gin.Context) {
-	ownerID, isAdmin, ok := resolveWorkerAccessScope(c)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
-		return
-	}
 	if h.provisioning == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "worker provisioning is unavailable"})
 		return
@@ -230,9 +304,13 @@ func (h *WorkerHandler) CreateWorker(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
+	ownerID, isAdmin, ok := h.resolveWorkerOwnerScope(c, req.OrgID, organizationRoleAdmin)
+	if !ok {
+		return
+	}
 	workerType := strings.TrimSpace(strings.ToLower(req.Type))
-	if workerType != registry.WorkerTypeNormal && workerType != registry.WorkerTypeSys {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of normal|worker-sys"})
+	if workerType != registry.WorkerTypeNormal && workerType != registry.WorkerTypeSys && workerType != registry.WorkerTypePrivate {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of normal|worker-sys|private"})
 		return
 	}
 	if !isAdmin && workerType == registry.WorkerTypeNormal {
@@ -247,13 +325,19 @@ func (h *WorkerHandler) CreateWorker(c *gin.Context) {
 			return
 		}
 		if errors.Is(err, grpcserver.ErrInvalidWorkerType) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of normal|worker-sys"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type must be one of normal|worker-sys|private"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create worker"})
 		return
 	}
 
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionWorkerCreate,
+		TargetType: "worker",
+		TargetID:   nodeID,
+		Details:    map[string]any{"type": workerType},
+	})
 	c.JSON(http.StatusCreated, workerStartupCommandResponse{
 		NodeID:  nodeID,
 		Type:    workerType,
@@ -283,8 +367,8 @@ func (h *WorkerHandler) DeleteWorker(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "worker not found"})
 			return
 		}
-		if strings.TrimSpace(worker.Labels[registry.LabelOwnerIDKey]) != ownerID ||
-			strings.TrimSpace(strings.ToLower(worker.Labels[registry.LabelWorkerTypeKey])) != registry.WorkerTypeSys {
+		if !h.canManageWorkerOwner(c.Request.Context(), ownerID, worker.Labels[registry.LabelOwnerIDKey]) ||
+			!slices.Contains(ownedWorkerTypes, strings.TrimSpace(strings.ToLower(worker.Labels[registry.LabelWorkerTypeKey]))) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "worker not found"})
 			return
 		}
@@ -293,6 +377,11 @@ func (h *WorkerHandler) DeleteWorker(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "worker not found"})
 		return
 	}
+	h.recordAudit(c, persistence.AuditEvent{
+		Action:     auditActionWorkerDelete,
+		TargetType: "worker",
+		TargetID:   nodeID,
+	})
 	c.Status(http.StatusNoContent)
 }
 
@@ -360,6 +449,50 @@ func resolveWorkerAccessScope(c *gin.Context) (string, bool, bool) {
 	return "system", true, true
 }
 
+// resolveWorkerOwnerScope picks whose workers a request acts on: the
+// caller's own, or an organization's when orgID is set and the caller holds
+// at least minRole in it. Organization scopes never get admin visibility.
+// It writes the error response itself.
+func (h *WorkerHandler) resolveWorkerOwnerScope(c *gin.Context, orgID string, minRole string) (string, bool, bool) {
+	trimmedOrgID := strings.TrimSpace(orgID)
+	if trimmedOrgID == "" {
+		ownerID, isAdmin, ok := resolveWorkerAccessScope(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return "", false, false
+		}
+		return ownerID, isAdmin, true
+	}
+	account, ok := requireSessionAccount(c)
+	if !ok {
+		return "", false, false
+	}
+	role, err := lookupOrganizationRole(c.Request.Context(), h.store.Persistence().Queries, trimmedOrgID, account.AccountID)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return "", false, false
+	}
+	if organizationRoleRank(role) < organizationRoleRank(minRole) {
+		writeOrganizationError(c, errOrganizationRoleTooLow)
+		return "", false, false
+	}
+	return trimmedOrgID, false, true
+}
+
+// canManageWorkerOwner reports whether accountID may manage workers owned by
+// workerOwnerID: its own, or its organization's as an org admin.
+func (h *WorkerHandler) canManageWorkerOwner(ctx context.Context, accountID string, workerOwnerID string) bool {
+	trimmedOwnerID := strings.TrimSpace(workerOwnerID)
+	if trimmedOwnerID == strings.TrimSpace(accountID) {
+		return true
+	}
+	if !registry.IsOrganizationOwnerID(trimmedOwnerID) {
+		return false
+	}
+	role, err := lookupOrganizationRole(ctx, h.store.Persistence().Queries, trimmedOwnerID, accountID)
+	return err == nil && organizationRoleRank(role) >= organizationRoleRank(organizationRoleAdmin)
+}
+
 func parseAddrHostPort(addr string) (string, string) {
 	if addr == "" {
 		return "", ""
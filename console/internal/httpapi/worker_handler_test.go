@@ -73,7 +73,7 @@ func TestListWorkersEmpty(t *testing.T) {
 	cookie := loginSessionCookie(t, router)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil)
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -110,7 +110,7 @@ func TestListWorkersPaginationAndFilter(t *testing.T) {
 
 	resPage := httptest.NewRecorder()
 	reqPage := httptest.NewRequest(http.MethodGet, "/api/v1/workers?page=2&page_size=1&status=all", nil)
-	reqPage.AddCookie(cookie)
+	addSessionCookie(reqPage, cookie)
 	router.ServeHTTP(resPage, reqPage)
 	if resPage.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", resPage.Code)
@@ -128,7 +128,7 @@ func TestListWorkersPaginationAndFilter(t *testing.T) {
 
 	resOffline := httptest.NewRecorder()
 	reqOffline := httptest.NewRequest(http.MethodGet, "/api/v1/workers?status=offline", nil)
-	reqOffline.AddCookie(cookie)
+	addSessionCookie(reqOffline, cookie)
 	router.ServeHTTP(resOffline, reqOffline)
 	if resOffline.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", resOffline.Code)
@@ -172,7 +172,7 @@ func TestCreateWorkerSuccess(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/workers", strings.NewReader(`{"type":"normal"}`))
 	req.Header.Set("Content-Type", "application/json")
 	req.Host = "console.local:8089"
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -226,7 +226,7 @@ func TestCreateWorkerRejectsMissingType(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/workers", strings.NewReader(`{}`))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -245,7 +245,7 @@ func TestCreateWorkerRejectsNormalTypeForNonAdmin(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/workers", strings.NewReader(`{"type":"normal"}`))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -264,7 +264,7 @@ func TestCreateWorkerMapsWorkerSysConflict(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/workers", strings.NewReader(`{"type":"worker-sys"}`))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -312,7 +312,7 @@ func TestListWorkersScopesToOwnWorkerSysForNonAdmin(t *testing.T) {
 	cookie := loginSessionCookieFor(t, router, "member-test", "member-password")
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/workers", nil)
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -367,7 +367,7 @@ func TestDeleteWorkerScopesToOwnWorkerSysForNonAdmin(t *testing.T) {
 	cookie := loginSessionCookieFor(t, router, "member-test", "member-password")
 
 	reqOwnSys := httptest.NewRequest(http.MethodDelete, "/api/v1/workers/node-own-sys", nil)
-	reqOwnSys.AddCookie(cookie)
+	addSessionCookie(reqOwnSys, cookie)
 	resOwnSys := httptest.NewRecorder()
 	router.ServeHTTP(resOwnSys, reqOwnSys)
 	if resOwnSys.Code != http.StatusNoContent {
@@ -375,7 +375,7 @@ func TestDeleteWorkerScopesToOwnWorkerSysForNonAdmin(t *testing.T) {
 	}
 
 	reqOwnNormal := httptest.NewRequest(http.MethodDelete, "/api/v1/workers/node-own-normal", nil)
-	reqOwnNormal.AddCookie(cookie)
+	addSessionCookie(reqOwnNormal, cookie)
 	resOwnNormal := httptest.NewRecorder()
 	router.ServeHTTP(resOwnNormal, reqOwnNormal)
 	if resOwnNormal.Code != http.StatusNotFound {
@@ -392,7 +392,7 @@ func TestDeleteWorkerSuccess(t *testing.T) {
 	cookie := loginSessionCookie(t, router)
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/v1/workers/node-delete-1", nil)
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -413,7 +413,7 @@ func TestDeleteWorkerNotFound(t *testing.T) {
 	cookie := loginSessionCookie(t, router)
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/v1/workers/node-missing", nil)
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -452,7 +452,7 @@ func TestGetWorkerStartupCommandReturnsGone(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/workers/node-copy-1/startup-command", nil)
 	req.Host = "console.local:8089"
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -494,7 +494,7 @@ func TestGetWorkerStartupCommandForMissingWorkerStillReturnsGone(t *testing.T) {
 	cookie := loginSessionCookie(t, router)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/workers/node-missing/startup-command", nil)
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -518,7 +518,7 @@ func TestListTrustedTokensSuccess(t *testing.T) {
 	cookie := loginSessionCookie(t, router)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/console/tokens", nil)
-	req.AddCookie(cookie)
+	addSessionCookie(req, cookie)
 	res := httptest.NewRecorder()
 	router.ServeHTTP(res, req)
 
@@ -582,7 +582,7 @@ func TestCreateTrustedTokenGetValueReturnsGone(t *testing.T) {
 
 	createReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/tokens", strings.NewReader(`{"name":"ci-prod"}`))
 	createReq.Header.Set("Content-Type", "application/json")
-	createReq.AddCookie(cookie)
+	addSessionCookie(createReq, cookie)
 	createRes := httptest.NewRecorder()
 	router.ServeHTTP(createRes, createReq)
 	if createRes.Code != http.StatusCreated {
@@ -595,7 +595,7 @@ func TestCreateTrustedTokenGetValueReturnsGone(t *testing.T) {
 	}
 
 	getReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/tokens/"+payload.ID+"/value", nil)
-	getReq.AddCookie(cookie)
+	addSessionCookie(getReq, cookie)
 	getRes := httptest.NewRecorder()
 	router.ServeHTTP(getRes, getReq)
 	if getRes.Code != http.StatusGone {
@@ -610,7 +610,7 @@ func TestDeleteTrustedTokenSuccess(t *testing.T) {
 
 	createReq := httptest.NewRequest(http.MethodPost, "/api/v1/console/tokens", strings.NewReader(`{"name":"ci-prod","token":"manual-token"}`))
 	createReq.Header.Set("Content-Type", "application/json")
-	createReq.AddCookie(cookie)
+	addSessionCookie(createReq, cookie)
 	createRes := httptest.NewRecorder()
 	router.ServeHTTP(createRes, createReq)
 	if createRes.Code != http.StatusCreated {
@@ -622,7 +622,7 @@ func TestDeleteTrustedTokenSuccess(t *testing.T) {
 	}
 
 	deleteReq := httptest.NewRequest(http.MethodDelete, "/api/v1/console/tokens/"+payload.ID, nil)
-	deleteReq.AddCookie(cookie)
+	addSessionCookie(deleteReq, cookie)
 	deleteRes := httptest.NewRecorder()
 	router.ServeHTTP(deleteRes, deleteReq)
 	if deleteRes.Code != http.StatusNoContent {
@@ -630,7 +630,7 @@ func TestDeleteTrustedTokenSuccess(t *testing.T) {
 	}
 
 	getReq := httptest.NewRequest(http.MethodGet, "/api/v1/console/tokens/"+payload.ID+"/value", nil)
-	getReq.AddCookie(cookie)
+	addSessionCookie(getReq, cookie)
 	getRes := httptest.NewRecorder()
 	router.ServeHTTP(getRes, getReq)
 	if getRes.Code != http.StatusGone {
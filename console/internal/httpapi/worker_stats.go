@@ -42,9 +42,8 @@ type workerInflightResponse struct {
 }
 
 func (h *WorkerHandler) WorkerStats(c *gin.Context) {
-	ownerID, isAdmin, ok := resolveWorkerAccessScope(c)
+	ownerID, isAdmin, ok := h.resolveWorkerOwnerScope(c, c.Query("org_id"), organizationRoleMember)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 		return
 	}
 
@@ -64,7 +63,7 @@ func (h *WorkerHandler) WorkerStats(c *gin.Context) {
 			h.offlineTTL,
 			time.Duration(staleAfterSec)*time.Second,
 			ownerID,
-			registry.WorkerTypeSys,
+			ownedWorkerTypes...,
 		)
 	}
 	c.JSON(http.StatusOK, workerStatsResponse{
@@ -78,9 +77,8 @@ func (h *WorkerHandler) WorkerStats(c *gin.Context) {
 }
 
 func (h *WorkerHandler) WorkerInflight(c *gin.Context) {
-	ownerID, isAdmin, ok := resolveWorkerAccessScope(c)
+	ownerID, isAdmin, ok := h.resolveWorkerOwnerScope(c, c.Query("org_id"), organizationRoleMember)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 		return
 	}
 
@@ -95,8 +93,10 @@ func (h *WorkerHandler) WorkerInflight(c *gin.Context) {
 	snapshots := h.inflightStats.InflightStats()
 	allowedNodeIDs := map[string]struct{}{}
 	if !isAdmin {
-		for _, nodeID := range h.store.ListNodeIDsByOwnerAndType(ownerID, registry.WorkerTypeSys) {
-			allowedNodeIDs[strings.TrimSpace(nodeID)] = struct{}{}
+		for _, workerType := range ownedWorkerTypes {
+			for _, nodeID := range h.store.ListNodeIDsByOwnerAndType(ownerID, workerType) {
+				allowedNodeIDs[strings.TrimSpace(nodeID)] = struct{}{}
+			}
 		}
 	}
 
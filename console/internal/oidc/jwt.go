@@ -0,0 +1,281 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+type parsedJWT struct {
+	header       jwtHeader
+	claims       map[string]any
+	signingInput string
+	signature    []byte
+}
+
+type publicKey struct {
+	algorithm string
+	key       crypto.PublicKey
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type signingAlgorithm struct {
+	hash    crypto.Hash
+	keyType string
+	curve   elliptic.Curve
+}
+
+var supportedAlgorithms = map[string]signingAlgorithm{
+	"RS256": {hash: crypto.SHA256, keyType: "RSA"},
+	"RS384": {hash: crypto.SHA384, keyType: "RSA"},
+	"RS512": {hash: crypto.SHA512, keyType: "RSA"},
+	"ES256": {hash: crypto.SHA256, keyType: "EC", curve: elliptic.P256()},
+	"ES384": {hash: crypto.SHA384, keyType: "EC", curve: elliptic.P384()},
+}
+
+func parseJWT(raw string) (parsedJWT, error) {
+	parts := strings.Split(strings.TrimSpace(raw), ".")
+	if len(parts) != 3 {
+		return parsedJWT{}, fmt.Errorf("%w: malformed jwt", ErrInvalidIDToken)
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return parsedJWT{}, fmt.Errorf("%w: malformed header", ErrInvalidIDToken)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return parsedJWT{}, fmt.Errorf("%w: malformed header", ErrInvalidIDToken)
+	}
+	if _, ok := supportedAlgorithms[header.Algorithm]; !ok {
+		return parsedJWT{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidIDToken, header.Algorithm)
+	}
+
+	claimBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return parsedJWT{}, fmt.Errorf("%w: malformed claims", ErrInvalidIDToken)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(claimBytes, &claims); err != nil || claims == nil {
+		return parsedJWT{}, fmt.Errorf("%w: malformed claims", ErrInvalidIDToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) == 0 {
+		return parsedJWT{}, fmt.Errorf("%w: malformed signature", ErrInvalidIDToken)
+	}
+
+	return parsedJWT{
+		header:       header,
+		claims:       claims,
+		signingInput: parts[0] + "." + parts[1],
+		signature:    signature,
+	}, nil
+}
+
+func (t parsedJWT) verify(key publicKey) error {
+	alg := supportedAlgorithms[t.header.Algorithm]
+	hasher := alg.hash.New()
+	hasher.Write([]byte(t.signingInput))
+	digest := hasher.Sum(nil)
+
+	switch pub := key.key.(type) {
+	case *rsa.PublicKey:
+		if alg.keyType != "RSA" {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(pub, alg.hash, digest, t.signature); err != nil {
+			return fmt.Errorf("%w: signature verification failed", ErrInvalidIDToken)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg.keyType != "EC" || pub.Curve != alg.curve {
+			break
+		}
+		size := (alg.curve.Params().BitSize + 7) / 8
+		if len(t.signature) != 2*size {
+			return fmt.Errorf("%w: signature verification failed", ErrInvalidIDToken)
+		}
+		r := new(big.Int).SetBytes(t.signature[:size])
+		s := new(big.Int).SetBytes(t.signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("%w: signature verification failed", ErrInvalidIDToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: key type does not match algorithm %q", ErrInvalidIDToken, t.header.Algorithm)
+}
+
+func (t parsedJWT) idToken() (IDToken, error) {
+	token := IDToken{Claims: t.claims}
+	token.Issuer, _ = t.claims["iss"].(string)
+	token.Subject, _ = t.claims["sub"].(string)
+	token.Nonce, _ = t.claims["nonce"].(string)
+
+	switch aud := t.claims["aud"].(type) {
+	case string:
+		token.Audience = []string{aud}
+	case []any:
+		for _, item := range aud {
+			value, ok := item.(string)
+			if !ok {
+				return IDToken{}, fmt.Errorf("%w: malformed aud claim", ErrInvalidIDToken)
+			}
+			token.Audience = append(token.Audience, value)
+		}
+	default:
+		return IDToken{}, fmt.Errorf("%w: missing aud claim", ErrInvalidIDToken)
+	}
+
+	var err error
+	if token.Expiry, err = numericDateClaim(t.claims, "exp"); err != nil {
+		return IDToken{}, err
+	}
+	if token.IssuedAt, err = numericDateClaim(t.claims, "iat"); err != nil {
+		return IDToken{}, err
+	}
+	return token, nil
+}
+
+func numericDateClaim(claims map[string]any, name string) (time.Time, error) {
+	raw, exists := claims[name]
+	if !exists {
+		return time.Time{}, nil
+	}
+	value, ok := raw.(float64)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: malformed %s claim", ErrInvalidIDToken, name)
+	}
+	return time.UnixMilli(int64(value * 1000)), nil
+}
+
+func (s jsonWebKeySet) publicKeys() (map[string]publicKey, error) {
+	keys := make(map[string]publicKey, len(s.Keys))
+	for i, jwk := range s.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Skip keys we cannot use instead of failing the whole set; providers
+			// commonly publish additional key types.
+			continue
+		}
+		mapKey := jwk.KeyID
+		if mapKey == "" {
+			mapKey = fmt.Sprintf("#%d", i)
+		}
+		keys[mapKey] = publicKey{algorithm: jwk.Algorithm, key: key}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("oidc jwks has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent out of range")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+func selectKey(keys map[string]publicKey, keyID string, algorithm string) (publicKey, bool) {
+	if keyID != "" {
+		key, ok := keys[keyID]
+		if ok && (key.algorithm == "" || key.algorithm == algorithm) {
+			return key, true
+		}
+		return publicKey{}, false
+	}
+	// Tokens without a kid are only accepted when the choice is unambiguous.
+	if len(keys) == 1 {
+		for _, key := range keys {
+			if key.algorithm == "" || key.algorithm == algorithm {
+				return key, true
+			}
+		}
+	}
+	return publicKey{}, false
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("malformed jwk integer")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+func randomURLSafe(byteSize int) (string, error) {
+	raw := make([]byte, byteSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
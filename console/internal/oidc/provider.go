@@ -0,0 +1,369 @@
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath           = "/.well-known/openid-configuration"
+	defaultHTTPTimeout      = 10 * time.Second
+	maxResponseBodyBytes    = 1 << 20
+	jwksMinRefreshPeriod    = 30 * time.Second
+	defaultClockSkew        = time.Minute
+	pkceVerifierByteSize    = 32
+	codeChallengeMethodS256 = "S256"
+)
+
+var (
+	ErrIssuerRequired      = errors.New("oidc issuer is required")
+	ErrClientIDRequired    = errors.New("oidc client id is required")
+	ErrRedirectURLRequired = errors.New("oidc redirect url is required")
+	ErrIssuerMismatch      = errors.New("oidc discovery issuer mismatch")
+	ErrInvalidIDToken      = errors.New("invalid id token")
+	ErrNonceMismatch       = errors.New("id token nonce mismatch")
+)
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	HTTPClient   *http.Client
+}
+
+type Provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	httpClient   *http.Client
+	nowFn        func() time.Time
+
+	mu            sync.Mutex
+	metadata      *providerMetadata
+	keys          map[string]publicKey
+	keysFetchedAt time.Time
+}
+
+type providerMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	JWKSURI                       string   `json:"jwks_uri"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// IDToken holds the verified standard claims together with the raw claim set,
+// so callers can map provider-specific claims such as usernames and groups.
+type IDToken struct {
+	Issuer   string
+	Subject  string
+	Audience []string
+	Nonce    string
+	Expiry   time.Time
+	IssuedAt time.Time
+	Claims   map[string]any
+}
+
+func NewProvider(cfg Config) (*Provider, error) {
+	issuer := strings.TrimRight(strings.TrimSpace(cfg.Issuer), "/")
+	if issuer == "" {
+		return nil, ErrIssuerRequired
+	}
+	if _, err := parseHTTPURL(issuer); err != nil {
+		return nil, fmt.Errorf("invalid oidc issuer: %w", err)
+	}
+	clientID := strings.TrimSpace(cfg.ClientID)
+	if clientID == "" {
+		return nil, ErrClientIDRequired
+	}
+	redirectURL := strings.TrimSpace(cfg.RedirectURL)
+	if redirectURL == "" {
+		return nil, ErrRedirectURLRequired
+	}
+	if _, err := parseHTTPURL(redirectURL); err != nil {
+		return nil, fmt.Errorf("invalid oidc redirect url: %w", err)
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range cfg.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || scope == "openid" {
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	return &Provider{
+		issuer:       issuer,
+		clientID:     clientID,
+		clientSecret: cfg.ClientSecret,
+		redirectURL:  redirectURL,
+		scopes:       scopes,
+		httpClient:   httpClient,
+		nowFn:        time.Now,
+	}, nil
+}
+
+func (p *Provider) Issuer() string {
+	return p.issuer
+}
+
+// AuthCodeURL builds the authorization endpoint URL for the authorization code
+// flow with a PKCE S256 challenge derived from codeVerifier.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	endpoint, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+
+	query := endpoint.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("scope", strings.Join(p.scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", PKCEChallenge(codeVerifier))
+	query.Set("code_challenge_method", codeChallengeMethodS256)
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified ID token.
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (IDToken, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return IDToken{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("code_verifier", codeVerifier)
+	if p.clientSecret == "" {
+		form.Set("client_id", p.clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return IDToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return IDToken{}, fmt.Errorf("oidc token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var payload tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBodyBytes)).Decode(&payload); err != nil {
+		return IDToken{}, fmt.Errorf("decode oidc token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if payload.Error != "" {
+			return IDToken{}, fmt.Errorf("oidc token request failed: %s", payload.Error)
+		}
+		return IDToken{}, fmt.Errorf("oidc token request failed with status %d", resp.StatusCode)
+	}
+	if strings.TrimSpace(payload.IDToken) == "" {
+		return IDToken{}, fmt.Errorf("%w: token response has no id_token", ErrInvalidIDToken)
+	}
+	return p.VerifyIDToken(ctx, payload.IDToken, nonce)
+}
+
+// VerifyIDToken checks the signature against the provider JWKS and validates
+// iss, aud, azp, exp, iat and nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (IDToken, error) {
+	parsed, err := parseJWT(rawIDToken)
+	if err != nil {
+		return IDToken{}, err
+	}
+	key, err := p.signingKey(ctx, parsed.header.KeyID, parsed.header.Algorithm)
+	if err != nil {
+		return IDToken{}, err
+	}
+	if err := parsed.verify(key); err != nil {
+		return IDToken{}, err
+	}
+
+	token, err := parsed.idToken()
+	if err != nil {
+		return IDToken{}, err
+	}
+	if strings.TrimRight(token.Issuer, "/") != p.issuer {
+		return IDToken{}, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, token.Issuer)
+	}
+	if token.Subject == "" {
+		return IDToken{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	if !containsString(token.Audience, p.clientID) {
+		return IDToken{}, fmt.Errorf("%w: audience does not include client id", ErrInvalidIDToken)
+	}
+	if azp, _ := token.Claims["azp"].(string); len(token.Audience) > 1 && azp != p.clientID {
+		return IDToken{}, fmt.Errorf("%w: unexpected authorized party", ErrInvalidIDToken)
+	}
+
+	now := p.nowFn()
+	if token.Expiry.IsZero() || !now.Before(token.Expiry.Add(defaultClockSkew)) {
+		return IDToken{}, fmt.Errorf("%w: token expired", ErrInvalidIDToken)
+	}
+	if !token.IssuedAt.IsZero() && token.IssuedAt.After(now.Add(defaultClockSkew)) {
+		return IDToken{}, fmt.Errorf("%w: token issued in the future", ErrInvalidIDToken)
+	}
+	if token.Nonce != nonce {
+		return IDToken{}, ErrNonceMismatch
+	}
+	return token, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (providerMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return *p.metadata, nil
+	}
+
+	var metadata providerMetadata
+	if err := p.getJSON(ctx, p.issuer+discoveryPath, &metadata); err != nil {
+		return providerMetadata{}, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimRight(metadata.Issuer, "/") != p.issuer {
+		return providerMetadata{}, fmt.Errorf("%w: got %q", ErrIssuerMismatch, metadata.Issuer)
+	}
+	for name, value := range map[string]string{
+		"authorization_endpoint": metadata.AuthorizationEndpoint,
+		"token_endpoint":         metadata.TokenEndpoint,
+		"jwks_uri":               metadata.JWKSURI,
+	} {
+		if _, err := parseHTTPURL(value); err != nil {
+			return providerMetadata{}, fmt.Errorf("oidc discovery: invalid %s: %w", name, err)
+		}
+	}
+	if len(metadata.CodeChallengeMethodsSupported) > 0 && !containsString(metadata.CodeChallengeMethodsSupported, codeChallengeMethodS256) {
+		return providerMetadata{}, errors.New("oidc discovery: provider does not support PKCE S256")
+	}
+
+	p.metadata = &metadata
+	return metadata, nil
+}
+
+func (p *Provider) signingKey(ctx context.Context, keyID string, algorithm string) (publicKey, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return publicKey{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := selectKey(p.keys, keyID, algorithm); ok {
+		return key, nil
+	}
+	// Unknown key ids usually mean the provider rotated its keys, so refetch the
+	// JWKS, but not more often than jwksMinRefreshPeriod.
+	if p.keys != nil && p.nowFn().Sub(p.keysFetchedAt) < jwksMinRefreshPeriod {
+		return publicKey{}, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, keyID)
+	}
+
+	var set jsonWebKeySet
+	if err := p.getJSON(ctx, metadata.JWKSURI, &set); err != nil {
+		return publicKey{}, fmt.Errorf("fetch oidc jwks: %w", err)
+	}
+	keys, err := set.publicKeys()
+	if err != nil {
+		return publicKey{}, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = p.nowFn()
+
+	if key, ok := selectKey(p.keys, keyID, algorithm); ok {
+		return key, nil
+	}
+	return publicKey{}, fmt.Errorf("%w: unknown signing key %q", ErrInvalidIDToken, keyID)
+}
+
+func (p *Provider) getJSON(ctx context.Context, target string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, target)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBodyBytes)).Decode(out)
+}
+
+// NewPKCEVerifier returns a random RFC 7636 code verifier.
+func NewPKCEVerifier() (string, error) {
+	return randomURLSafe(pkceVerifierByteSize)
+}
+
+func PKCEChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func parseHTTPURL(value string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return nil, fmt.Errorf("unsupported scheme %q", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return nil, errors.New("missing host")
+	}
+	return parsed, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/testutil/oidctest"
+)
+
+const (
+	testClientID    = "onlyboxes-console"
+	testRedirectURL = "https://console.example.test/api/v1/console/oidc/callback"
+)
+
+func newTestProvider(t *testing.T, idp *oidctest.Server) *Provider {
+	t.Helper()
+	provider, err := NewProvider(Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "profile", "groups"},
+	})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	return provider
+}
+
+func TestNewProviderValidatesConfig(t *testing.T) {
+	if _, err := NewProvider(Config{ClientID: "c", RedirectURL: testRedirectURL}); !errors.Is(err, ErrIssuerRequired) {
+		t.Fatalf("expected ErrIssuerRequired, got %v", err)
+	}
+	if _, err := NewProvider(Config{Issuer: "https://idp.example.test", RedirectURL: testRedirectURL}); !errors.Is(err, ErrClientIDRequired) {
+		t.Fatalf("expected ErrClientIDRequired, got %v", err)
+	}
+	if _, err := NewProvider(Config{Issuer: "https://idp.example.test", ClientID: "c"}); !errors.Is(err, ErrRedirectURLRequired) {
+		t.Fatalf("expected ErrRedirectURLRequired, got %v", err)
+	}
+	if _, err := NewProvider(Config{Issuer: "idp.example.test", ClientID: "c", RedirectURL: testRedirectURL}); err == nil {
+		t.Fatalf("expected invalid issuer to be rejected")
+	}
+}
+
+func TestProviderAuthorizationCodeFlow(t *testing.T) {
+	for _, clientSecret := range []string{"", "s3cret/+"} {
+		idp := oidctest.NewServer(t, testClientID, clientSecret)
+		provider := newTestProvider(t, idp)
+		ctx := context.Background()
+
+		verifier, err := NewPKCEVerifier()
+		if err != nil {
+			t.Fatalf("new verifier: %v", err)
+		}
+		authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+		if err != nil {
+			t.Fatalf("auth code url: %v", err)
+		}
+		parsedAuthURL, _ := url.Parse(authURL)
+		if scope := parsedAuthURL.Query().Get("scope"); scope != "openid profile groups" {
+			t.Fatalf("unexpected scope %q", scope)
+		}
+		if challenge := parsedAuthURL.Query().Get("code_challenge"); challenge != PKCEChallenge(verifier) {
+			t.Fatalf("unexpected code_challenge %q", challenge)
+		}
+
+		callback, _ := url.Parse(idp.Authorize(authURL, oidctest.Identity{
+			Subject: "user-1",
+			Claims:  map[string]any{"preferred_username": "alice", "groups": []string{"ops"}},
+		}))
+		if callback.Query().Get("state") != "state-1" {
+			t.Fatalf("unexpected callback state %q", callback.Query().Get("state"))
+		}
+		code := callback.Query().Get("code")
+
+		if _, err := provider.Exchange(ctx, code, "wrong-verifier", "nonce-1"); err == nil {
+			t.Fatalf("expected exchange with wrong verifier to fail")
+		}
+
+		callback, _ = url.Parse(idp.Authorize(authURL, oidctest.Identity{
+			Subject: "user-1",
+			Claims:  map[string]any{"preferred_username": "alice"},
+		}))
+		token, err := provider.Exchange(ctx, callback.Query().Get("code"), verifier, "nonce-1")
+		if err != nil {
+			t.Fatalf("exchange: %v", err)
+		}
+		if token.Subject != "user-1" || token.Claims["preferred_username"] != "alice" {
+			t.Fatalf("unexpected id token: %#v", token)
+		}
+	}
+}
+
+func TestProviderVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	idp := oidctest.NewServer(t, testClientID, "")
+	provider := newTestProvider(t, idp)
+	ctx := context.Background()
+	now := time.Now()
+
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"iss":   idp.Issuer(),
+			"sub":   "user-1",
+			"aud":   testClientID,
+			"iat":   now.Unix(),
+			"exp":   now.Add(5 * time.Minute).Unix(),
+			"nonce": "nonce-1",
+		}
+	}
+	if _, err := provider.VerifyIDToken(ctx, idp.SignIDToken(validClaims()), "nonce-1"); err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(map[string]any)
+		nonce  string
+		want   error
+	}{
+		{name: "wrong issuer", mutate: func(c map[string]any) { c["iss"] = "https://evil.example.test" }, want: ErrInvalidIDToken},
+		{name: "wrong audience", mutate: func(c map[string]any) { c["aud"] = "other-client" }, want: ErrInvalidIDToken},
+		{name: "foreign azp", mutate: func(c map[string]any) {
+			c["aud"] = []string{testClientID, "other-client"}
+			c["azp"] = "other-client"
+		}, want: ErrInvalidIDToken},
+		{name: "expired", mutate: func(c map[string]any) { c["exp"] = now.Add(-time.Hour).Unix() }, want: ErrInvalidIDToken},
+		{name: "missing exp", mutate: func(c map[string]any) { delete(c, "exp") }, want: ErrInvalidIDToken},
+		{name: "missing subject", mutate: func(c map[string]any) { delete(c, "sub") }, want: ErrInvalidIDToken},
+		{name: "nonce mismatch", mutate: func(map[string]any) {}, nonce: "nonce-2", want: ErrNonceMismatch},
+	}
+	for _, tc := range tests {
+		claims := validClaims()
+		tc.mutate(claims)
+		nonce := tc.nonce
+		if nonce == "" {
+			nonce = "nonce-1"
+		}
+		if _, err := provider.VerifyIDToken(ctx, idp.SignIDToken(claims), nonce); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	raw := idp.SignIDToken(validClaims())
+	parts := strings.Split(raw, ".")
+	forgedClaims := validClaims()
+	forgedClaims["sub"] = "admin"
+	forgedPayload, _ := json.Marshal(forgedClaims)
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString(forgedPayload) + "." + parts[2]
+	if _, err := provider.VerifyIDToken(ctx, tampered, "nonce-1"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected tampered token to be rejected, got %v", err)
+	}
+	unsigned := "eyJhbGciOiJub25lIn0." + parts[1] + "."
+	if _, err := provider.VerifyIDToken(ctx, unsigned, "nonce-1"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected alg=none token to be rejected, got %v", err)
+	}
+}
+
+func TestProviderRefetchesJWKSAfterKeyRotation(t *testing.T) {
+	idp := oidctest.NewServer(t, testClientID, "")
+	provider := newTestProvider(t, idp)
+	now := time.Now()
+	provider.nowFn = func() time.Time {
+		return now
+	}
+	ctx := context.Background()
+
+	claims := map[string]any{
+		"iss": idp.Issuer(),
+		"sub": "user-1",
+		"aud": testClientID,
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+	if _, err := provider.VerifyIDToken(ctx, idp.SignIDToken(claims), ""); err != nil {
+		t.Fatalf("verify before rotation: %v", err)
+	}
+
+	idp.RotateKey()
+	rotated := idp.SignIDToken(claims)
+	if _, err := provider.VerifyIDToken(ctx, rotated, ""); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("expected unknown key inside refresh period to be rejected, got %v", err)
+	}
+
+	now = now.Add(jwksMinRefreshPeriod)
+	if _, err := provider.VerifyIDToken(ctx, rotated, ""); err != nil {
+		t.Fatalf("expected rotated key to be fetched, got %v", err)
+	}
+}
+
+func TestProviderFailsWithoutDiscoveryDocument(t *testing.T) {
+	idp := oidctest.NewServer(t, testClientID, "")
+	provider, err := NewProvider(Config{
+		Issuer:      idp.Issuer() + "/tenant",
+		ClientID:    testClientID,
+		RedirectURL: testRedirectURL,
+	})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if _, err := provider.AuthCodeURL(context.Background(), "s", "n", "v"); err == nil {
+		t.Fatalf("expected discovery failure for unknown issuer path")
+	}
+}
@@ -0,0 +1,249 @@
+package persistence
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+	AuditOutcomeDenied  = "denied"
+
+	AuditActorAccount         = "account"
+	AuditActorAccessToken     = "access_token"
+	AuditActorManagementToken = "management_token"
+	AuditActorAnonymous       = "anonymous"
+	AuditActorSystem          = "system"
+
+	auditVerifyBatchSize = 500
+)
+
+// AuditEvent is one entry to append to the audit trail.
+type AuditEvent struct {
+	CreatedAt  time.Time
+	ActorType  string
+	ActorID    string
+	AccountID  string
+	Action     string
+	TargetType string
+	TargetID   string
+	Outcome    string
+	IPAddress  string
+	Details    map[string]any
+}
+
+// AuditFilter narrows audit queries; empty fields match everything.
+type AuditFilter struct {
+	AccountID  string
+	Action     string
+	TargetType string
+	TargetID   string
+	Outcome    string
+	Since      time.Time
+	Until      time.Time
+}
+
+// AuditChainVerification reports the result of walking the audit hash chain.
+type AuditChainVerification struct {
+	Valid         bool   `json:"valid"`
+	CheckedEvents int    `json:"checked_events"`
+	LastEventID   int64  `json:"last_event_id,omitempty"`
+	LastEventHash string `json:"last_event_hash,omitempty"`
+	BrokenEventID int64  `json:"broken_event_id,omitempty"`
+	BrokenReason  string `json:"broken_reason,omitempty"`
+	ExpectedHash  string `json:"expected_hash,omitempty"`
+	RecordedHash  string `json:"recorded_hash,omitempty"`
+}
+
+// AppendAuditEvent links event to the newest audit row and stores it. The
+// database keeps a single connection, so the read of the chain head and the
+// insert cannot interleave with another writer.
+func (d *DB) AppendAuditEvent(ctx context.Context, event AuditEvent) error {
+	if d == nil || d.SQL == nil {
+		return nil
+	}
+	action := strings.TrimSpace(event.Action)
+	if action == "" {
+		return errors.New("audit action is required")
+	}
+	createdAt := event.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	outcome := strings.TrimSpace(event.Outcome)
+	if outcome == "" {
+		outcome = AuditOutcomeSuccess
+	}
+	actorType := strings.TrimSpace(event.ActorType)
+	if actorType == "" {
+		actorType = AuditActorSystem
+	}
+	detailsJSON := "{}"
+	if len(event.Details) > 0 {
+		encoded, err := json.Marshal(event.Details)
+		if err != nil {
+			return fmt.Errorf("encode audit details: %w", err)
+		}
+		detailsJSON = string(encoded)
+	}
+
+	return d.WithTx(ctx, func(q *sqlc.Queries) error {
+		prevHash := ""
+		latest, err := q.GetLatestAuditEvent(ctx)
+		switch {
+		case err == nil:
+			prevHash = latest.EventHash
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+		record := sqlc.InsertAuditEventParams{
+			CreatedAtUnixMs: createdAt.UnixMilli(),
+			ActorType:       actorType,
+			ActorID:         strings.TrimSpace(event.ActorID),
+			AccountID:       strings.TrimSpace(event.AccountID),
+			Action:          action,
+			TargetType:      strings.TrimSpace(event.TargetType),
+			TargetID:        strings.TrimSpace(event.TargetID),
+			Outcome:         outcome,
+			IpAddress:       strings.TrimSpace(event.IPAddress),
+			DetailsJson:     detailsJSON,
+			PrevHash:        prevHash,
+		}
+		record.EventHash = AuditEventHash(sqlc.AuditEvent{
+			CreatedAtUnixMs: record.CreatedAtUnixMs,
+			ActorType:       record.ActorType,
+			ActorID:         record.ActorID,
+			AccountID:       record.AccountID,
+			Action:          record.Action,
+			TargetType:      record.TargetType,
+			TargetID:        record.TargetID,
+			Outcome:         record.Outcome,
+			IpAddress:       record.IpAddress,
+			DetailsJson:     record.DetailsJson,
+			PrevHash:        record.PrevHash,
+		})
+		return q.InsertAuditEvent(ctx, record)
+	})
+}
+
+// AuditEventHash is the SHA-256 over prev_hash and every stored field except
+// event_id, encoded as a JSON array so field boundaries are unambiguous. It
+// can be recomputed from an exported JSONL file without database access.
+func AuditEventHash(event sqlc.AuditEvent) string {
+	encoded, _ := json.Marshal([]string{
+		event.PrevHash,
+		strconv.FormatInt(event.CreatedAtUnixMs, 10),
+		event.ActorType,
+		event.ActorID,
+		event.AccountID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.Outcome,
+		event.IpAddress,
+		event.DetailsJson,
+	})
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// ListAuditEvents returns up to limit events older than beforeEventID, newest
+// first. A non-positive beforeEventID starts from the newest event.
+func (d *DB) ListAuditEvents(ctx context.Context, filter AuditFilter, beforeEventID int64, limit int) ([]sqlc.AuditEvent, error) {
+	if beforeEventID <= 0 {
+		beforeEventID = math.MaxInt64
+	}
+	since, until := filter.bounds()
+	return d.Queries.ListAuditEvents(ctx, sqlc.ListAuditEventsParams{
+		BeforeEventID: beforeEventID,
+		AccountID:     strings.TrimSpace(filter.AccountID),
+		Action:        strings.TrimSpace(filter.Action),
+		TargetType:    strings.TrimSpace(filter.TargetType),
+		TargetID:      strings.TrimSpace(filter.TargetID),
+		Outcome:       strings.TrimSpace(filter.Outcome),
+		SinceUnixMs:   since,
+		UntilUnixMs:   until,
+		Limit:         int64(limit),
+	})
+}
+
+// ListAuditEventsAfter returns up to limit events newer than afterEventID,
+// oldest first.
+func (d *DB) ListAuditEventsAfter(ctx context.Context, filter AuditFilter, afterEventID int64, limit int) ([]sqlc.AuditEvent, error) {
+	since, until := filter.bounds()
+	return d.Queries.ListAuditEventsAfter(ctx, sqlc.ListAuditEventsAfterParams{
+		AfterEventID: afterEventID,
+		AccountID:    strings.TrimSpace(filter.AccountID),
+		Action:       strings.TrimSpace(filter.Action),
+		TargetType:   strings.TrimSpace(filter.TargetType),
+		TargetID:     strings.TrimSpace(filter.TargetID),
+		Outcome:      strings.TrimSpace(filter.Outcome),
+		SinceUnixMs:  since,
+		UntilUnixMs:  until,
+		Limit:        int64(limit),
+	})
+}
+
+// VerifyAuditChain walks every audit event in order and reports the first
+// event whose hash or link to its predecessor does not match.
+func (d *DB) VerifyAuditChain(ctx context.Context) (AuditChainVerification, error) {
+	report := AuditChainVerification{Valid: true}
+	prevHash := ""
+	afterEventID := int64(0)
+	for {
+		events, err := d.ListAuditEventsAfter(ctx, AuditFilter{}, afterEventID, auditVerifyBatchSize)
+		if err != nil {
+			return AuditChainVerification{}, err
+		}
+		for _, event := range events {
+			report.CheckedEvents++
+			if event.PrevHash != prevHash {
+				report.markBroken(event, "previous hash does not match the preceding event", prevHash, event.PrevHash)
+				return report, nil
+			}
+			if expected := AuditEventHash(event); expected != event.EventHash {
+				report.markBroken(event, "event hash does not match event contents", expected, event.EventHash)
+				return report, nil
+			}
+			prevHash = event.EventHash
+			report.LastEventID = event.EventID
+			report.LastEventHash = event.EventHash
+		}
+		if len(events) < auditVerifyBatchSize {
+			return report, nil
+		}
+		afterEventID = events[len(events)-1].EventID
+	}
+}
+
+func (r *AuditChainVerification) markBroken(event sqlc.AuditEvent, reason string, expected string, recorded string) {
+	r.Valid = false
+	r.BrokenEventID = event.EventID
+	r.BrokenReason = reason
+	r.ExpectedHash = expected
+	r.RecordedHash = recorded
+}
+
+func (f AuditFilter) bounds() (int64, int64) {
+	since := int64(0)
+	if !f.Since.IsZero() {
+		since = f.Since.UnixMilli()
+	}
+	until := int64(math.MaxInt64)
+	if !f.Until.IsZero() {
+		until = f.Until.UnixMilli()
+	}
+	return since, until
+}
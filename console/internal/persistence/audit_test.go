@@ -0,0 +1,111 @@
+package persistence
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditChainAppendListAndVerify(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(ctx, Options{
+		Path:    filepath.Join(t.TempDir(), "console.db"),
+		HashKey: "test-hash-key",
+	})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	base := time.Unix(1_700_000_000, 0)
+	events := []AuditEvent{
+		{CreatedAt: base, ActorType: AuditActorAccount, ActorID: "acc-a", AccountID: "acc-a", Action: "auth.login"},
+		{CreatedAt: base.Add(time.Second), ActorType: AuditActorAccessToken, ActorID: "tok-1", AccountID: "acc-a", Action: "task.submit", TargetType: "task", TargetID: "task-1", Details: map[string]any{"capability": "echo"}},
+		{CreatedAt: base.Add(2 * time.Second), ActorType: AuditActorAccount, ActorID: "acc-b", AccountID: "acc-b", Action: "auth.login", Outcome: AuditOutcomeFailure},
+	}
+	for _, event := range events {
+		if err := db.AppendAuditEvent(ctx, event); err != nil {
+			t.Fatalf("append audit event: %v", err)
+		}
+	}
+	if err := db.AppendAuditEvent(ctx, AuditEvent{}); err == nil {
+		t.Fatalf("expected audit event without action to be rejected")
+	}
+
+	newest, err := db.ListAuditEvents(ctx, AuditFilter{}, 0, 10)
+	if err != nil {
+		t.Fatalf("list audit events: %v", err)
+	}
+	if len(newest) != 3 || newest[0].Action != "auth.login" || newest[0].Outcome != AuditOutcomeFailure {
+		t.Fatalf("unexpected newest-first listing %#v", newest)
+	}
+	if newest[1].PrevHash != newest[2].EventHash || newest[2].PrevHash != "" {
+		t.Fatalf("expected events to be chained, got %#v", newest)
+	}
+	if newest[1].DetailsJson != `{"capability":"echo"}` || newest[0].DetailsJson != "{}" {
+		t.Fatalf("unexpected details %q / %q", newest[1].DetailsJson, newest[0].DetailsJson)
+	}
+
+	filtered, err := db.ListAuditEvents(ctx, AuditFilter{AccountID: "acc-a", Action: "auth.login"}, 0, 10)
+	if err != nil || len(filtered) != 1 || filtered[0].ActorID != "acc-a" {
+		t.Fatalf("unexpected filtered listing %#v err=%v", filtered, err)
+	}
+	windowed, err := db.ListAuditEventsAfter(ctx, AuditFilter{Since: base.Add(time.Second), Until: base.Add(2 * time.Second)}, 0, 10)
+	if err != nil || len(windowed) != 1 || windowed[0].TargetID != "task-1" {
+		t.Fatalf("unexpected time-window listing %#v err=%v", windowed, err)
+	}
+	paged, err := db.ListAuditEvents(ctx, AuditFilter{}, newest[1].EventID, 10)
+	if err != nil || len(paged) != 1 || paged[0].EventID != newest[2].EventID {
+		t.Fatalf("unexpected paged listing %#v err=%v", paged, err)
+	}
+
+	report, err := db.VerifyAuditChain(ctx)
+	if err != nil {
+		t.Fatalf("verify audit chain: %v", err)
+	}
+	if !report.Valid || report.CheckedEvents != 3 || report.LastEventHash != newest[0].EventHash {
+		t.Fatalf("unexpected verification report %#v", report)
+	}
+
+	if _, err := db.SQL.ExecContext(ctx, `UPDATE audit_events SET outcome = 'success'`); err == nil || !strings.Contains(err.Error(), "append-only") {
+		t.Fatalf("expected update to be rejected, got %v", err)
+	}
+	if _, err := db.SQL.ExecContext(ctx, `DELETE FROM audit_events`); err == nil || !strings.Contains(err.Error(), "append-only") {
+		t.Fatalf("expected delete to be rejected, got %v", err)
+	}
+
+	// Someone with raw database access can drop the trigger, but the edit
+	// still breaks the chain.
+	if _, err := db.SQL.ExecContext(ctx, `DROP TRIGGER audit_events_no_update`); err != nil {
+		t.Fatalf("drop trigger: %v", err)
+	}
+	if _, err := db.SQL.ExecContext(ctx, `UPDATE audit_events SET target_id = 'task-2' WHERE event_id = ?`, newest[1].EventID); err != nil {
+		t.Fatalf("tamper audit event: %v", err)
+	}
+	report, err = db.VerifyAuditChain(ctx)
+	if err != nil {
+		t.Fatalf("verify tampered chain: %v", err)
+	}
+	if report.Valid || report.BrokenEventID != newest[1].EventID || report.CheckedEvents != 2 {
+		t.Fatalf("expected tampered event to be reported, got %#v", report)
+	}
+
+	if _, err := db.SQL.ExecContext(ctx, `UPDATE audit_events SET target_id = 'task-1' WHERE event_id = ?`, newest[1].EventID); err != nil {
+		t.Fatalf("restore audit event: %v", err)
+	}
+	if _, err := db.SQL.ExecContext(ctx, `DROP TRIGGER audit_events_no_delete`); err != nil {
+		t.Fatalf("drop trigger: %v", err)
+	}
+	if _, err := db.SQL.ExecContext(ctx, `DELETE FROM audit_events WHERE event_id = ?`, newest[1].EventID); err != nil {
+		t.Fatalf("delete audit event: %v", err)
+	}
+	report, err = db.VerifyAuditChain(ctx)
+	if err != nil {
+		t.Fatalf("verify truncated chain: %v", err)
+	}
+	if report.Valid || report.BrokenEventID != newest[0].EventID || !strings.Contains(report.BrokenReason, "previous hash") {
+		t.Fatalf("expected removed event to break the chain, got %#v", report)
+	}
+}
@@ -26,7 +26,12 @@ type Options struct {
 	Path             string
 	BusyTimeoutMS    int
 	HashKey          string
+	HashKeyID        string
+	RetiredHashKeys  []HashKey
 	TaskRetentionDay int
+	// SkipStartupRecovery leaves task and worker session state untouched, for
+	// maintenance commands that run next to a live console.
+	SkipStartupRecovery bool
 }
 
 type DB struct {
@@ -55,7 +60,7 @@ func Open(ctx context.Context, opts Options) (*DB, error) {
 		return nil, err
 	}
 
-	hasher, err := NewHasher(opts.HashKey)
+	hasher, err := NewKeyedHasher(HashKey{ID: opts.HashKeyID, Secret: opts.HashKey}, opts.RetiredHashKeys)
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +89,22 @@ func Open(ctx context.Context, opts Options) (*DB, error) {
 	}
 
 	queries := sqlc.New(db)
+	if !opts.SkipStartupRecovery {
+		if err := recoverOnStartup(ctx, queries, taskRetentionDays); err != nil {
+			return nil, err
+		}
+	}
+
+	cleanupOnErr = false
+	return &DB{
+		SQL:           db,
+		Queries:       queries,
+		Hasher:        hasher,
+		TaskRetention: time.Duration(taskRetentionDays) * 24 * time.Hour,
+	}, nil
+}
+
+func recoverOnStartup(ctx context.Context, queries *sqlc.Queries, taskRetentionDays int) error {
 	nowMS := time.Now().UnixMilli()
 	retentionMS := int64((time.Duration(taskRetentionDays) * 24 * time.Hour).Milliseconds())
 	expiresMS := nowMS + retentionMS
@@ -92,19 +113,15 @@ func Open(ctx context.Context, opts Options) (*DB, error) {
 		CompletedAtUnixMs: nowMS,
 		ExpiresAtUnixMs:   expiresMS,
 	}); err != nil {
-		return nil, fmt.Errorf("startup recovery tasks: %w", err)
+		return fmt.Errorf("startup recovery tasks: %w", err)
+	}
+	if _, err := queries.ExpirePendingTaskApprovalsOnStartup(ctx, nowMS); err != nil {
+		return fmt.Errorf("startup recovery approvals: %w", err)
 	}
 	if _, err := queries.ClearAllWorkerSessions(ctx); err != nil {
-		return nil, fmt.Errorf("startup recovery sessions: %w", err)
+		return fmt.Errorf("startup recovery sessions: %w", err)
 	}
-
-	cleanupOnErr = false
-	return &DB{
-		SQL:           db,
-		Queries:       queries,
-		Hasher:        hasher,
-		TaskRetention: time.Duration(taskRetentionDays) * 24 * time.Hour,
-	}, nil
+	return nil
 }
 
 func applyPragmas(ctx context.Context, db *sql.DB, busyTimeoutMS int) error {
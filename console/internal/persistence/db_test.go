@@ -184,3 +184,94 @@ func TestOpenCreatesParentDirectoryWhenMissing(t *testing.T) {
 		t.Fatalf("expected %q to be a directory", parentDir)
 	}
 }
+
+func TestHashKeyUsageWithoutStartupRecovery(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "console.db")
+	db, err := Open(ctx, Options{
+		Path:                path,
+		HashKey:             "new-secret",
+		HashKeyID:           "k2",
+		RetiredHashKeys:     []HashKey{{ID: "k1", Secret: "old-secret"}},
+		SkipStartupRecovery: true,
+	})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	nowMS := time.Now().UnixMilli()
+	for _, seed := range []struct {
+		nodeID    string
+		hashKeyID string
+	}{
+		{nodeID: "node-1", hashKeyID: "k1"},
+		{nodeID: "node-2", hashKeyID: "k1"},
+		{nodeID: "node-3", hashKeyID: "lost"},
+	} {
+		if err := db.Queries.UpsertWorkerNode(ctx, sqlc.UpsertWorkerNodeParams{
+			NodeID:             seed.nodeID,
+			SessionID:          "session-" + seed.nodeID,
+			Provisioned:        1,
+			NodeName:           seed.nodeID,
+			ExecutorKind:       "docker",
+			Version:            "v1",
+			RegisteredAtUnixMs: nowMS,
+			LastSeenAtUnixMs:   nowMS,
+		}); err != nil {
+			t.Fatalf("seed worker node: %v", err)
+		}
+		if _, err := db.Queries.InsertWorkerCredentialIfAbsent(ctx, sqlc.InsertWorkerCredentialIfAbsentParams{
+			NodeID:          seed.nodeID,
+			SecretHash:      "hash-" + seed.nodeID,
+			HashAlgo:        HashAlgorithmHMACSHA256,
+			CreatedAtUnixMs: nowMS,
+			UpdatedAtUnixMs: nowMS,
+			HashKeyID:       seed.hashKeyID,
+		}); err != nil {
+			t.Fatalf("seed worker credential: %v", err)
+		}
+	}
+
+	usage, err := db.HashKeyUsage(ctx)
+	if err != nil {
+		t.Fatalf("hash key usage: %v", err)
+	}
+	want := []HashKeyUsage{
+		{KeyID: "k2", Status: HashKeyStatusPrimary},
+		{KeyID: "k1", Status: HashKeyStatusRetired, WorkerCredentials: 2},
+		{KeyID: "lost", Status: HashKeyStatusUnknown, WorkerCredentials: 1},
+	}
+	if len(usage) != len(want) {
+		t.Fatalf("expected %d usage rows, got %#v", len(want), usage)
+	}
+	for i := range want {
+		if usage[i] != want[i] {
+			t.Fatalf("usage[%d]: expected %#v, got %#v", i, want[i], usage[i])
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("close db: %v", err)
+	}
+	reopened, err := Open(ctx, Options{
+		Path:                path,
+		HashKey:             "new-secret",
+		SkipStartupRecovery: true,
+	})
+	if err != nil {
+		t.Fatalf("reopen db: %v", err)
+	}
+	defer func() {
+		_ = reopened.Close()
+	}()
+	worker, err := reopened.Queries.GetWorkerNodeByID(ctx, "node-1")
+	if err != nil {
+		t.Fatalf("get worker: %v", err)
+	}
+	if worker.SessionID != "session-node-1" {
+		t.Fatalf("expected session kept without startup recovery, got %q", worker.SessionID)
+	}
+}
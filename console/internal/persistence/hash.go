@@ -5,36 +5,179 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"strings"
 )
 
 const HashAlgorithmHMACSHA256 = "hmac-sha256"
 
+// DefaultHashKeyID identifies CONSOLE_HASH_KEY when no key ID is configured.
+// Hashes written before key IDs existed carry this ID.
+const DefaultHashKeyID = "default"
+
+const maxHashKeyIDLength = 64
+
+// HashKey is one HMAC key. The ID is stored next to every hash so the key that
+// produced it can be found after the primary key changes.
+type HashKey struct {
+	ID     string
+	Secret string
+}
+
+type hashKey struct {
+	id     string
+	secret []byte
+}
+
+// Hasher computes keyed hashes for tokens and worker secrets. New hashes always
+// use the primary key; retired keys are only used to verify existing hashes
+// until they are re-keyed.
 type Hasher struct {
-	key []byte
+	keys []hashKey
 }
 
 func NewHasher(key string) (*Hasher, error) {
-	trimmed := strings.TrimSpace(key)
-	if trimmed == "" {
+	return NewKeyedHasher(HashKey{ID: DefaultHashKeyID, Secret: key}, nil)
+}
+
+func NewKeyedHasher(primary HashKey, retired []HashKey) (*Hasher, error) {
+	if strings.TrimSpace(primary.Secret) == "" {
 		return nil, errors.New("CONSOLE_HASH_KEY is required")
 	}
-	return &Hasher{key: []byte(trimmed)}, nil
+	if strings.TrimSpace(primary.ID) == "" {
+		primary.ID = DefaultHashKeyID
+	}
+
+	hasher := &Hasher{keys: make([]hashKey, 0, 1+len(retired))}
+	seen := make(map[string]struct{}, 1+len(retired))
+	for _, key := range append([]HashKey{primary}, retired...) {
+		id := strings.TrimSpace(key.ID)
+		secret := strings.TrimSpace(key.Secret)
+		if err := validateHashKeyID(id); err != nil {
+			return nil, err
+		}
+		if secret == "" {
+			return nil, fmt.Errorf("hash key %q has an empty secret", id)
+		}
+		if _, exists := seen[id]; exists {
+			return nil, fmt.Errorf("duplicate hash key id %q", id)
+		}
+		seen[id] = struct{}{}
+		hasher.keys = append(hasher.keys, hashKey{id: id, secret: []byte(secret)})
+	}
+	return hasher, nil
+}
+
+// ParseHashKeys parses a comma separated list of id:secret pairs, the format of
+// CONSOLE_HASH_KEYS_RETIRED.
+func ParseHashKeys(value string) ([]HashKey, error) {
+	var keys []HashKey
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("hash key %q must use the form id:secret", strings.TrimSpace(id))
+		}
+		keys = append(keys, HashKey{ID: strings.TrimSpace(id), Secret: strings.TrimSpace(secret)})
+	}
+	return keys, nil
+}
+
+func validateHashKeyID(id string) error {
+	if id == "" {
+		return errors.New("hash key id is required")
+	}
+	if len(id) > maxHashKeyIDLength {
+		return fmt.Errorf("hash key id %q is longer than %d characters", id, maxHashKeyIDLength)
+	}
+	if strings.ContainsAny(id, ":, \t\r\n") {
+		return fmt.Errorf("hash key id %q contains invalid characters", id)
+	}
+	return nil
 }
 
+// Hash hashes value with the primary key.
 func (h *Hasher) Hash(value string) string {
-	if h == nil {
+	if h == nil || len(h.keys) == 0 {
 		return ""
 	}
-	mac := hmac.New(sha256.New, h.key)
-	_, _ = mac.Write([]byte(value))
-	return hex.EncodeToString(mac.Sum(nil))
+	return h.keys[0].hash(value)
 }
 
-func (h *Hasher) Equal(hash string, plain string) bool {
+// PrimaryKeyID is the key ID to store alongside hashes returned by Hash.
+func (h *Hasher) PrimaryKeyID() string {
+	if h == nil || len(h.keys) == 0 {
+		return ""
+	}
+	return h.keys[0].id
+}
+
+// IsPrimary reports whether keyID is the current primary key.
+func (h *Hasher) IsPrimary(keyID string) bool {
+	return keyID != "" && keyID == h.PrimaryKeyID()
+}
+
+// RetiredKeyIDs lists the configured non-primary key IDs in configuration order.
+func (h *Hasher) RetiredKeyIDs() []string {
+	if h == nil || len(h.keys) < 2 {
+		return nil
+	}
+	ids := make([]string, 0, len(h.keys)-1)
+	for _, key := range h.keys[1:] {
+		ids = append(ids, key.id)
+	}
+	return ids
+}
+
+// Candidates returns the hash of value under every configured key, primary
+// first, keyed by key ID. Lookups by hash use it to find values stored with a
+// retired key.
+func (h *Hasher) Candidates(value string) []HashCandidate {
+	if h == nil {
+		return nil
+	}
+	candidates := make([]HashCandidate, 0, len(h.keys))
+	for _, key := range h.keys {
+		candidates = append(candidates, HashCandidate{KeyID: key.id, Hash: key.hash(value)})
+	}
+	return candidates
+}
+
+// HashCandidate is the hash of a value under one key.
+type HashCandidate struct {
+	KeyID string
+	Hash  string
+}
+
+// Match reports which configured key produced hash for plain. When keyID is
+// known only that key is tried; an empty keyID tries every key.
+func (h *Hasher) Match(keyID string, hash string, plain string) (string, bool) {
 	if h == nil {
-		return false
+		return "", false
+	}
+	trimmedHash := []byte(strings.TrimSpace(hash))
+	keyID = strings.TrimSpace(keyID)
+	for _, key := range h.keys {
+		if keyID != "" && key.id != keyID {
+			continue
+		}
+		if hmac.Equal(trimmedHash, []byte(key.hash(plain))) {
+			return key.id, true
+		}
 	}
-	expected := h.Hash(plain)
-	return hmac.Equal([]byte(strings.TrimSpace(hash)), []byte(expected))
+	return "", false
+}
+
+func (h *Hasher) Equal(hash string, plain string) bool {
+	_, ok := h.Match("", hash, plain)
+	return ok
+}
+
+func (k hashKey) hash(value string) string {
+	mac := hmac.New(sha256.New, k.secret)
+	_, _ = mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
 }
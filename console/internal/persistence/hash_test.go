@@ -39,3 +39,66 @@ func TestHasherHashAndEqual(t *testing.T) {
 		t.Fatalf("expected hash mismatch")
 	}
 }
+
+func TestKeyedHasherMatchesRetiredKeys(t *testing.T) {
+	retired, err := ParseHashKeys(" old:old-secret , older:older-secret ,")
+	if err != nil {
+		t.Fatalf("parse hash keys: %v", err)
+	}
+	if len(retired) != 2 || retired[0].ID != "old" || retired[1].Secret != "older-secret" {
+		t.Fatalf("unexpected parsed keys: %#v", retired)
+	}
+
+	hasher, err := NewKeyedHasher(HashKey{ID: "new", Secret: "new-secret"}, retired)
+	if err != nil {
+		t.Fatalf("new keyed hasher: %v", err)
+	}
+	oldHasher, err := NewKeyedHasher(HashKey{ID: "old", Secret: "old-secret"}, nil)
+	if err != nil {
+		t.Fatalf("new old hasher: %v", err)
+	}
+
+	if hasher.PrimaryKeyID() != "new" || !hasher.IsPrimary("new") || hasher.IsPrimary("old") {
+		t.Fatalf("unexpected primary key id %q", hasher.PrimaryKeyID())
+	}
+	if ids := hasher.RetiredKeyIDs(); len(ids) != 2 || ids[0] != "old" || ids[1] != "older" {
+		t.Fatalf("unexpected retired key ids %v", ids)
+	}
+
+	oldHash := oldHasher.Hash("worker-secret")
+	if keyID, ok := hasher.Match("", oldHash, "worker-secret"); !ok || keyID != "old" {
+		t.Fatalf("expected retired key match, got %q %v", keyID, ok)
+	}
+	if _, ok := hasher.Match("new", oldHash, "worker-secret"); ok {
+		t.Fatalf("expected match restricted to primary key to fail")
+	}
+	if keyID, ok := hasher.Match("", hasher.Hash("worker-secret"), "worker-secret"); !ok || keyID != "new" {
+		t.Fatalf("expected primary key match, got %q %v", keyID, ok)
+	}
+
+	candidates := hasher.Candidates("worker-secret")
+	if len(candidates) != 3 || candidates[0].KeyID != "new" || candidates[1].Hash != oldHash {
+		t.Fatalf("unexpected candidates %#v", candidates)
+	}
+}
+
+func TestKeyedHasherRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary HashKey
+		retired []HashKey
+	}{
+		{name: "duplicate id", primary: HashKey{ID: "k1", Secret: "a"}, retired: []HashKey{{ID: "k1", Secret: "b"}}},
+		{name: "empty retired secret", primary: HashKey{ID: "k1", Secret: "a"}, retired: []HashKey{{ID: "k0", Secret: " "}}},
+		{name: "invalid id", primary: HashKey{ID: "k 1", Secret: "a"}},
+		{name: "empty retired id", primary: HashKey{ID: "k1", Secret: "a"}, retired: []HashKey{{ID: "", Secret: "b"}}},
+	}
+	for _, tc := range tests {
+		if _, err := NewKeyedHasher(tc.primary, tc.retired); err == nil {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+	}
+	if _, err := ParseHashKeys("missing-separator"); err == nil {
+		t.Fatalf("expected error for key without id")
+	}
+}
@@ -0,0 +1,74 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+const (
+	HashKeyStatusPrimary = "primary"
+	HashKeyStatusRetired = "retired"
+	HashKeyStatusUnknown = "unknown"
+)
+
+// HashKeyUsage counts the stored hashes produced by one key ID.
+type HashKeyUsage struct {
+	KeyID             string
+	Status            string
+	TrustedTokens     int64
+	ManagementTokens  int64
+	WorkerCredentials int64
+}
+
+// HashKeyUsage reports how many tokens and worker credentials are still
+// stored under each key. Configured keys are always listed, primary first, so an
+// operator can see when a retired key is no longer referenced and can be
+// removed. Key IDs found in the database but missing from the configuration are
+// reported as unknown; those hashes can no longer be verified.
+func (d *DB) HashKeyUsage(ctx context.Context) ([]HashKeyUsage, error) {
+	tokenCounts, err := d.Queries.CountTrustedTokensByHashKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count trusted tokens by hash key: %w", err)
+	}
+	managementCounts, err := d.Queries.CountManagementTokensByHashKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count management tokens by hash key: %w", err)
+	}
+	credentialCounts, err := d.Queries.CountWorkerCredentialsByHashKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("count worker credentials by hash key: %w", err)
+	}
+
+	usage := make([]HashKeyUsage, 0, 1+len(d.Hasher.RetiredKeyIDs()))
+	index := make(map[string]int)
+	add := func(keyID string, status string) int {
+		if i, ok := index[keyID]; ok {
+			return i
+		}
+		index[keyID] = len(usage)
+		usage = append(usage, HashKeyUsage{KeyID: keyID, Status: status})
+		return len(usage) - 1
+	}
+	add(d.Hasher.PrimaryKeyID(), HashKeyStatusPrimary)
+	for _, keyID := range d.Hasher.RetiredKeyIDs() {
+		add(keyID, HashKeyStatusRetired)
+	}
+	configured := len(usage)
+
+	for _, row := range tokenCounts {
+		usage[add(row.HashKeyID, HashKeyStatusUnknown)].TrustedTokens += row.Total
+	}
+	for _, row := range managementCounts {
+		usage[add(row.HashKeyID, HashKeyStatusUnknown)].ManagementTokens += row.Total
+	}
+	for _, row := range credentialCounts {
+		usage[add(row.HashKeyID, HashKeyStatusUnknown)].WorkerCredentials += row.Total
+	}
+
+	unknown := usage[configured:]
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].KeyID < unknown[j].KeyID
+	})
+	return usage, nil
+}
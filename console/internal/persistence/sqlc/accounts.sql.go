@@ -247,6 +247,57 @@ func (q *Queries) ListAccountsPage(ctx context.Context, arg ListAccountsPagePara
 	return items, nil
 }
 
+const rehashAccountPassword = `-- name: RehashAccountPassword :execrows
+UPDATE accounts
+SET password_hash = ?,
+    hash_algo = ?,
+    updated_at_unix_ms = ?
+WHERE account_id = ? AND password_hash = ?
+`
+
+type RehashAccountPasswordParams struct {
+	PasswordHash    string `json:"password_hash"`
+	HashAlgo        string `json:"hash_algo"`
+	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+	AccountID       string `json:"account_id"`
+	PasswordHash_2  string `json:"password_hash_2"`
+}
+
+func (q *Queries) RehashAccountPassword(ctx context.Context, arg RehashAccountPasswordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rehashAccountPassword,
+		arg.PasswordHash,
+		arg.HashAlgo,
+		arg.UpdatedAtUnixMs,
+		arg.AccountID,
+		arg.PasswordHash_2,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateAccountIsAdminByID = `-- name: UpdateAccountIsAdminByID :execrows
+UPDATE accounts
+SET is_admin = ?,
+    updated_at_unix_ms = ?
+WHERE account_id = ?
+`
+
+type UpdateAccountIsAdminByIDParams struct {
+	IsAdmin         int64  `json:"is_admin"`
+	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+	AccountID       string `json:"account_id"`
+}
+
+func (q *Queries) UpdateAccountIsAdminByID(ctx context.Context, arg UpdateAccountIsAdminByIDParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateAccountIsAdminByID, arg.IsAdmin, arg.UpdatedAtUnixMs, arg.AccountID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAccountPasswordByID = `-- name: UpdateAccountPasswordByID :execrows
 UPDATE accounts
 SET password_hash = ?,
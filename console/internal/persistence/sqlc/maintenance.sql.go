@@ -9,6 +9,21 @@ import (
 	"context"
 )
 
+const expirePendingTaskApprovalsOnStartup = `-- name: ExpirePendingTaskApprovalsOnStartup :execrows
+UPDATE task_approvals
+SET status = 'expired',
+    decided_at_unix_ms = ?
+WHERE status = 'pending'
+`
+
+func (q *Queries) ExpirePendingTaskApprovalsOnStartup(ctx context.Context, decidedAtUnixMs int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expirePendingTaskApprovalsOnStartup, decidedAtUnixMs)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const markNonTerminalTasksFailedOnStartup = `-- name: MarkNonTerminalTasksFailedOnStartup :execrows
 UPDATE tasks
 SET status = 'failed',
@@ -17,7 +32,7 @@ SET status = 'failed',
     updated_at_unix_ms = ?,
     completed_at_unix_ms = ?,
     expires_at_unix_ms = ?
-WHERE status IN ('queued', 'dispatched', 'running')
+WHERE status IN ('pending_approval', 'queued', 'dispatched', 'running')
 `
 
 type MarkNonTerminalTasksFailedOnStartupParams struct {
@@ -15,6 +15,141 @@ type Account struct {
 	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
 }
 
+type AccountIdentity struct {
+	Issuer            string `json:"issuer"`
+	Subject           string `json:"subject"`
+	AccountID         string `json:"account_id"`
+	CreatedAtUnixMs   int64  `json:"created_at_unix_ms"`
+	LastLoginAtUnixMs int64  `json:"last_login_at_unix_ms"`
+}
+
+type AccountQuota struct {
+	OwnerID                    string `json:"owner_id"`
+	MaxConcurrentPerCapability int64  `json:"max_concurrent_per_capability"`
+	MaxTasksPerMinute          int64  `json:"max_tasks_per_minute"`
+	MaxExecSecondsPerDay       int64  `json:"max_exec_seconds_per_day"`
+	MaxTerminalSessions        int64  `json:"max_terminal_sessions"`
+	UpdatedAtUnixMs            int64  `json:"updated_at_unix_ms"`
+}
+
+type AccountTotp struct {
+	AccountID       string `json:"account_id"`
+	Secret          string `json:"secret"`
+	Enabled         int64  `json:"enabled"`
+	LastUsedStep    int64  `json:"last_used_step"`
+	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
+	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+}
+
+type AuditEvent struct {
+	EventID         int64  `json:"event_id"`
+	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
+	ActorType       string `json:"actor_type"`
+	ActorID         string `json:"actor_id"`
+	AccountID       string `json:"account_id"`
+	Action          string `json:"action"`
+	TargetType      string `json:"target_type"`
+	TargetID        string `json:"target_id"`
+	Outcome         string `json:"outcome"`
+	IpAddress       string `json:"ip_address"`
+	DetailsJson     string `json:"details_json"`
+	PrevHash        string `json:"prev_hash"`
+	EventHash       string `json:"event_hash"`
+}
+
+type CommandPolicy struct {
+	PolicyID         string `json:"policy_id"`
+	Name             string `json:"name"`
+	Description      string `json:"description"`
+	Action           string `json:"action"`
+	AccountID        string `json:"account_id"`
+	TokenID          string `json:"token_id"`
+	Capability       string `json:"capability"`
+	WorkerLabelsJson string `json:"worker_labels_json"`
+	MatchField       string `json:"match_field"`
+	Pattern          string `json:"pattern"`
+	Enabled          int64  `json:"enabled"`
+	CreatedBy        string `json:"created_by"`
+	CreatedAtUnixMs  int64  `json:"created_at_unix_ms"`
+	UpdatedAtUnixMs  int64  `json:"updated_at_unix_ms"`
+}
+
+type LoginChallenge struct {
+	ChallengeHash      string `json:"challenge_hash"`
+	AccountID          string `json:"account_id"`
+	Attempts           int64  `json:"attempts"`
+	CreatedAtUnixMs    int64  `json:"created_at_unix_ms"`
+	ExpiresAtUnixMs    int64  `json:"expires_at_unix_ms"`
+	RehashPasswordHash string `json:"rehash_password_hash"`
+	RehashHashAlgo     string `json:"rehash_hash_algo"`
+}
+
+type LoginLockout struct {
+	Scope             string `json:"scope"`
+	SubjectKey        string `json:"subject_key"`
+	FailureCount      int64  `json:"failure_count"`
+	LockedAtUnixMs    int64  `json:"locked_at_unix_ms"`
+	LockedUntilUnixMs int64  `json:"locked_until_unix_ms"`
+}
+
+type ManagementToken struct {
+	TokenID          string `json:"token_id"`
+	AccountID        string `json:"account_id"`
+	Name             string `json:"name"`
+	NameKey          string `json:"name_key"`
+	TokenHash        string `json:"token_hash"`
+	HashKeyID        string `json:"hash_key_id"`
+	TokenMasked      string `json:"token_masked"`
+	PermissionsJson  string `json:"permissions_json"`
+	CreatedAtUnixMs  int64  `json:"created_at_unix_ms"`
+	LastUsedAtUnixMs int64  `json:"last_used_at_unix_ms"`
+}
+
+type ManagementTokenEvent struct {
+	EventID         int64  `json:"event_id"`
+	TokenID         string `json:"token_id"`
+	AccountID       string `json:"account_id"`
+	Method          string `json:"method"`
+	Route           string `json:"route"`
+	Permission      string `json:"permission"`
+	StatusCode      int64  `json:"status_code"`
+	IpAddress       string `json:"ip_address"`
+	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
+}
+
+type OidcLoginState struct {
+	StateHash       string `json:"state_hash"`
+	Nonce           string `json:"nonce"`
+	CodeVerifier    string `json:"code_verifier"`
+	RedirectPath    string `json:"redirect_path"`
+	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
+	ExpiresAtUnixMs int64  `json:"expires_at_unix_ms"`
+}
+
+type Organization struct {
+	OrgID           string `json:"org_id"`
+	Name            string `json:"name"`
+	NameKey         string `json:"name_key"`
+	CreatedBy       string `json:"created_by"`
+	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
+	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+}
+
+type OrganizationMember struct {
+	OrgID           string `json:"org_id"`
+	AccountID       string `json:"account_id"`
+	Role            string `json:"role"`
+	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
+	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+}
+
+type SchedulingSetting struct {
+	OwnerID         string `json:"owner_id"`
+	Weight          int64  `json:"weight"`
+	PriorityClass   string `json:"priority_class"`
+	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+}
+
 type Task struct {
 	TaskID            string `json:"task_id"`
 	OwnerID           string `json:"owner_id"`
@@ -33,6 +168,24 @@ type Task struct {
 	ExpiresAtUnixMs   int64  `json:"expires_at_unix_ms"`
 }
 
+type TaskApproval struct {
+	ApprovalID      string `json:"approval_id"`
+	TaskID          string `json:"task_id"`
+	OwnerID         string `json:"owner_id"`
+	TokenID         string `json:"token_id"`
+	Capability      string `json:"capability"`
+	InputJson       string `json:"input_json"`
+	PolicyID        string `json:"policy_id"`
+	PolicyName      string `json:"policy_name"`
+	Status          string `json:"status"`
+	TaskTimeoutMs   int64  `json:"task_timeout_ms"`
+	DecidedBy       string `json:"decided_by"`
+	Comment         string `json:"comment"`
+	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
+	ExpiresAtUnixMs int64  `json:"expires_at_unix_ms"`
+	DecidedAtUnixMs int64  `json:"decided_at_unix_ms"`
+}
+
 type TrustedToken struct {
 	TokenID         string `json:"token_id"`
 	AccountID       string `json:"account_id"`
@@ -43,6 +196,7 @@ type TrustedToken struct {
 	Generated       int64  `json:"generated"`
 	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
 	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+	HashKeyID       string `json:"hash_key_id"`
 }
 
 type WorkerCapability struct {
@@ -57,6 +211,7 @@ type WorkerCredential struct {
 	HashAlgo        string `json:"hash_algo"`
 	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
 	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+	HashKeyID       string `json:"hash_key_id"`
 }
 
 type WorkerLabel struct {
@@ -75,3 +230,12 @@ type WorkerNode struct {
 	RegisteredAtUnixMs int64  `json:"registered_at_unix_ms"`
 	LastSeenAtUnixMs   int64  `json:"last_seen_at_unix_ms"`
 }
+
+type WorkerSysGrant struct {
+	NodeID          string `json:"node_id"`
+	GranteeID       string `json:"grantee_id"`
+	Capability      string `json:"capability"`
+	GrantedBy       string `json:"granted_by"`
+	GrantedAtUnixMs int64  `json:"granted_at_unix_ms"`
+	ExpiresAtUnixMs int64  `json:"expires_at_unix_ms"`
+}
@@ -178,6 +178,96 @@ func (q *Queries) InsertTask(ctx context.Context, arg InsertTaskParams) error {
 	return err
 }
 
+const listTasksByOwner = `-- name: ListTasksByOwner :many
+SELECT
+    task_id,
+    owner_id,
+    request_id,
+    capability,
+    input_json,
+    status,
+    command_id,
+    result_json,
+    error_code,
+    error_message,
+    created_at_unix_ms,
+    updated_at_unix_ms,
+    deadline_at_unix_ms,
+    completed_at_unix_ms,
+    expires_at_unix_ms
+FROM tasks
+WHERE owner_id = ?
+ORDER BY created_at_unix_ms DESC, task_id DESC
+LIMIT ?
+`
+
+type ListTasksByOwnerParams struct {
+	OwnerID string `json:"owner_id"`
+	Limit   int64  `json:"limit"`
+}
+
+func (q *Queries) ListTasksByOwner(ctx context.Context, arg ListTasksByOwnerParams) ([]Task, error) {
+	rows, err := q.db.QueryContext(ctx, listTasksByOwner, arg.OwnerID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Task
+	for rows.Next() {
+		var i Task
+		if err := rows.Scan(
+			&i.TaskID,
+			&i.OwnerID,
+			&i.RequestID,
+			&i.Capability,
+			&i.InputJson,
+			&i.Status,
+			&i.CommandID,
+			&i.ResultJson,
+			&i.ErrorCode,
+			&i.ErrorMessage,
+			&i.CreatedAtUnixMs,
+			&i.UpdatedAtUnixMs,
+			&i.DeadlineAtUnixMs,
+			&i.CompletedAtUnixMs,
+			&i.ExpiresAtUnixMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markTaskApproved = `-- name: MarkTaskApproved :execrows
+UPDATE tasks
+SET status = 'queued',
+    deadline_at_unix_ms = ?,
+    updated_at_unix_ms = ?
+WHERE task_id = ?
+  AND status = 'pending_approval'
+`
+
+type MarkTaskApprovedParams struct {
+	DeadlineAtUnixMs int64  `json:"deadline_at_unix_ms"`
+	UpdatedAtUnixMs  int64  `json:"updated_at_unix_ms"`
+	TaskID           string `json:"task_id"`
+}
+
+func (q *Queries) MarkTaskApproved(ctx context.Context, arg MarkTaskApprovedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markTaskApproved, arg.DeadlineAtUnixMs, arg.UpdatedAtUnixMs, arg.TaskID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const markTaskDispatched = `-- name: MarkTaskDispatched :execrows
 UPDATE tasks
 SET status = 'dispatched',
@@ -232,7 +322,7 @@ SET status = ?,
     completed_at_unix_ms = ?,
     expires_at_unix_ms = ?
 WHERE task_id = ?
-  AND status IN ('queued', 'dispatched', 'running')
+  AND status IN ('pending_approval', 'queued', 'dispatched', 'running')
 `
 
 type MarkTaskTerminalParams struct {
@@ -9,9 +9,47 @@ import (
 	"context"
 )
 
+const countTrustedTokensByHashKey = `-- name: CountTrustedTokensByHashKey :many
+SELECT
+    hash_key_id,
+    COUNT(*) AS total
+FROM trusted_tokens
+GROUP BY hash_key_id
+ORDER BY hash_key_id ASC
+`
+
+type CountTrustedTokensByHashKeyRow struct {
+	HashKeyID string `json:"hash_key_id"`
+	Total     int64  `json:"total"`
+}
+
+func (q *Queries) CountTrustedTokensByHashKey(ctx context.Context) ([]CountTrustedTokensByHashKeyRow, error) {
+	rows, err := q.db.QueryContext(ctx, countTrustedTokensByHashKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountTrustedTokensByHashKeyRow
+	for rows.Next() {
+		var i CountTrustedTokensByHashKeyRow
+		if err := rows.Scan(&i.HashKeyID, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteTrustedTokenByIDAndAccount = `-- name: DeleteTrustedTokenByIDAndAccount :execrows
 DELETE FROM trusted_tokens
 WHERE token_id = ? AND account_id = ?
+  AND token_id NOT IN (SELECT token_id FROM organization_tokens)
 `
 
 type DeleteTrustedTokenByIDAndAccountParams struct {
@@ -37,7 +75,8 @@ SELECT
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM trusted_tokens
 WHERE account_id = ? AND name_key = ?
 LIMIT 1
@@ -61,6 +100,7 @@ func (q *Queries) GetTrustedTokenByAccountAndNameKey(ctx context.Context, arg Ge
 		&i.Generated,
 		&i.CreatedAtUnixMs,
 		&i.UpdatedAtUnixMs,
+		&i.HashKeyID,
 	)
 	return i, err
 }
@@ -75,7 +115,8 @@ SELECT
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM trusted_tokens
 WHERE token_hash = ?
 LIMIT 1
@@ -94,6 +135,7 @@ func (q *Queries) GetTrustedTokenByHash(ctx context.Context, tokenHash string) (
 		&i.Generated,
 		&i.CreatedAtUnixMs,
 		&i.UpdatedAtUnixMs,
+		&i.HashKeyID,
 	)
 	return i, err
 }
@@ -108,7 +150,8 @@ SELECT
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM trusted_tokens
 WHERE token_id = ?
 LIMIT 1
@@ -127,6 +170,7 @@ func (q *Queries) GetTrustedTokenByID(ctx context.Context, tokenID string) (Trus
 		&i.Generated,
 		&i.CreatedAtUnixMs,
 		&i.UpdatedAtUnixMs,
+		&i.HashKeyID,
 	)
 	return i, err
 }
@@ -141,8 +185,9 @@ INSERT INTO trusted_tokens (
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    updated_at_unix_ms,
+    hash_key_id
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type InsertTrustedTokenParams struct {
@@ -155,6 +200,7 @@ type InsertTrustedTokenParams struct {
 	Generated       int64  `json:"generated"`
 	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
 	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+	HashKeyID       string `json:"hash_key_id"`
 }
 
 func (q *Queries) InsertTrustedToken(ctx context.Context, arg InsertTrustedTokenParams) error {
@@ -168,6 +214,7 @@ func (q *Queries) InsertTrustedToken(ctx context.Context, arg InsertTrustedToken
 		arg.Generated,
 		arg.CreatedAtUnixMs,
 		arg.UpdatedAtUnixMs,
+		arg.HashKeyID,
 	)
 	return err
 }
@@ -182,9 +229,11 @@ SELECT
     token_masked,
     generated,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM trusted_tokens
 WHERE account_id = ?
+  AND token_id NOT IN (SELECT token_id FROM organization_tokens)
 ORDER BY created_at_unix_ms ASC, token_id ASC
 `
 
@@ -207,6 +256,7 @@ func (q *Queries) ListTrustedTokensByAccount(ctx context.Context, accountID stri
 			&i.Generated,
 			&i.CreatedAtUnixMs,
 			&i.UpdatedAtUnixMs,
+			&i.HashKeyID,
 		); err != nil {
 			return nil, err
 		}
@@ -220,3 +270,30 @@ func (q *Queries) ListTrustedTokensByAccount(ctx context.Context, accountID stri
 	}
 	return items, nil
 }
+
+const updateTrustedTokenHash = `-- name: UpdateTrustedTokenHash :execrows
+UPDATE trusted_tokens
+SET token_hash = ?,
+    hash_key_id = ?
+WHERE token_id = ? AND token_hash = ?
+`
+
+type UpdateTrustedTokenHashParams struct {
+	TokenHash   string `json:"token_hash"`
+	HashKeyID   string `json:"hash_key_id"`
+	TokenID     string `json:"token_id"`
+	TokenHash_2 string `json:"token_hash_2"`
+}
+
+func (q *Queries) UpdateTrustedTokenHash(ctx context.Context, arg UpdateTrustedTokenHashParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateTrustedTokenHash,
+		arg.TokenHash,
+		arg.HashKeyID,
+		arg.TokenID,
+		arg.TokenHash_2,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -56,6 +56,43 @@ func (q *Queries) ClearWorkerSessionByNodeAndSession(ctx context.Context, arg Cl
 	return result.RowsAffected()
 }
 
+const countWorkerCredentialsByHashKey = `-- name: CountWorkerCredentialsByHashKey :many
+SELECT
+    hash_key_id,
+    COUNT(*) AS total
+FROM worker_credentials
+GROUP BY hash_key_id
+ORDER BY hash_key_id ASC
+`
+
+type CountWorkerCredentialsByHashKeyRow struct {
+	HashKeyID string `json:"hash_key_id"`
+	Total     int64  `json:"total"`
+}
+
+func (q *Queries) CountWorkerCredentialsByHashKey(ctx context.Context) ([]CountWorkerCredentialsByHashKeyRow, error) {
+	rows, err := q.db.QueryContext(ctx, countWorkerCredentialsByHashKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountWorkerCredentialsByHashKeyRow
+	for rows.Next() {
+		var i CountWorkerCredentialsByHashKeyRow
+		if err := rows.Scan(&i.HashKeyID, &i.Total); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const countWorkerNodesByOwnerAndType = `-- name: CountWorkerNodesByOwnerAndType :one
 SELECT COUNT(1)
 FROM worker_nodes wn
@@ -147,7 +184,8 @@ SELECT
     secret_hash,
     hash_algo,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM worker_credentials
 WHERE node_id = ?
 LIMIT 1
@@ -162,6 +200,7 @@ func (q *Queries) GetWorkerCredentialByNode(ctx context.Context, nodeID string)
 		&i.HashAlgo,
 		&i.CreatedAtUnixMs,
 		&i.UpdatedAtUnixMs,
+		&i.HashKeyID,
 	)
 	return i, err
 }
@@ -258,8 +297,9 @@ INSERT INTO worker_credentials (
     secret_hash,
     hash_algo,
     created_at_unix_ms,
-    updated_at_unix_ms
-) VALUES (?, ?, ?, ?, ?)
+    updated_at_unix_ms,
+    hash_key_id
+) VALUES (?, ?, ?, ?, ?, ?)
 ON CONFLICT(node_id) DO NOTHING
 `
 
@@ -269,6 +309,7 @@ type InsertWorkerCredentialIfAbsentParams struct {
 	HashAlgo        string `json:"hash_algo"`
 	CreatedAtUnixMs int64  `json:"created_at_unix_ms"`
 	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+	HashKeyID       string `json:"hash_key_id"`
 }
 
 func (q *Queries) InsertWorkerCredentialIfAbsent(ctx context.Context, arg InsertWorkerCredentialIfAbsentParams) (int64, error) {
@@ -278,6 +319,7 @@ func (q *Queries) InsertWorkerCredentialIfAbsent(ctx context.Context, arg Insert
 		arg.HashAlgo,
 		arg.CreatedAtUnixMs,
 		arg.UpdatedAtUnixMs,
+		arg.HashKeyID,
 	)
 	if err != nil {
 		return 0, err
@@ -336,6 +378,13 @@ JOIN worker_capabilities wc ON wc.node_id = wn.node_id
 WHERE LOWER(wc.capability_name) = ?
   AND wn.last_seen_at_unix_ms >= ?
   AND wn.session_id <> ''
+  AND NOT EXISTS (
+    SELECT 1
+    FROM worker_labels type_label
+    WHERE type_label.node_id = wn.node_id
+      AND type_label.label_key = 'obx.worker_type'
+      AND type_label.label_value = 'private'
+  )
 ORDER BY wn.node_id ASC
 `
 
@@ -496,7 +545,8 @@ SELECT
     secret_hash,
     hash_algo,
     created_at_unix_ms,
-    updated_at_unix_ms
+    updated_at_unix_ms,
+    hash_key_id
 FROM worker_credentials
 ORDER BY node_id ASC
 `
@@ -516,6 +566,7 @@ func (q *Queries) ListWorkerCredentials(ctx context.Context) ([]WorkerCredential
 			&i.HashAlgo,
 			&i.CreatedAtUnixMs,
 			&i.UpdatedAtUnixMs,
+			&i.HashKeyID,
 		); err != nil {
 			return nil, err
 		}
@@ -687,6 +738,36 @@ func (q *Queries) ListWorkerNodesOrdered(ctx context.Context) ([]WorkerNode, err
 	return items, nil
 }
 
+const updateWorkerCredentialHash = `-- name: UpdateWorkerCredentialHash :execrows
+UPDATE worker_credentials
+SET secret_hash = ?,
+    hash_key_id = ?,
+    updated_at_unix_ms = ?
+WHERE node_id = ? AND secret_hash = ?
+`
+
+type UpdateWorkerCredentialHashParams struct {
+	SecretHash      string `json:"secret_hash"`
+	HashKeyID       string `json:"hash_key_id"`
+	UpdatedAtUnixMs int64  `json:"updated_at_unix_ms"`
+	NodeID          string `json:"node_id"`
+	SecretHash_2    string `json:"secret_hash_2"`
+}
+
+func (q *Queries) UpdateWorkerCredentialHash(ctx context.Context, arg UpdateWorkerCredentialHashParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateWorkerCredentialHash,
+		arg.SecretHash,
+		arg.HashKeyID,
+		arg.UpdatedAtUnixMs,
+		arg.NodeID,
+		arg.SecretHash_2,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateWorkerHeartbeatBySession = `-- name: UpdateWorkerHeartbeatBySession :execrows
 UPDATE worker_nodes
 SET last_seen_at_unix_ms = ?
@@ -0,0 +1,253 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	ActionAllow           = "allow"
+	ActionDeny            = "deny"
+	ActionRequireApproval = "require_approval"
+
+	FieldCommand = "command"
+	FieldCode    = "code"
+	FieldPath    = "path"
+
+	maxNameLength    = 64
+	maxPatternLength = 1024
+)
+
+var (
+	ErrNameRequired       = errors.New("name is required")
+	ErrNameTooLong        = errors.New("name length must be <= 64")
+	ErrActionInvalid      = errors.New("action must be one of allow|deny|require_approval")
+	ErrMatchFieldInvalid  = errors.New("match_field must be one of command|code|path")
+	ErrPatternRequired    = errors.New("pattern is required when match_field is set")
+	ErrPatternWithoutType = errors.New("match_field is required when pattern is set")
+	ErrPatternTooLong     = errors.New("pattern length must be <= 1024")
+	ErrPatternInvalid     = errors.New("pattern is not a valid regular expression")
+	ErrPolicyNotFound     = errors.New("policy not found")
+)
+
+// Rule scopes an action to a set of submissions. Empty scope fields match
+// everything; a rule without match_field applies regardless of input.
+type Rule struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Action       string            `json:"action"`
+	AccountID    string            `json:"account_id"`
+	TokenID      string            `json:"token_id"`
+	Capability   string            `json:"capability"`
+	WorkerLabels map[string]string `json:"worker_labels"`
+	MatchField   string            `json:"match_field"`
+	Pattern      string            `json:"pattern"`
+	Enabled      bool              `json:"enabled"`
+	CreatedBy    string            `json:"created_by"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+
+	pattern *regexp.Regexp
+}
+
+// Request describes one task submission. Workers holds the labels of every
+// online worker that could receive the task; the worker is picked after
+// evaluation, so label-scoped rules are checked against each candidate.
+type Request struct {
+	AccountID  string
+	TokenID    string
+	Capability string
+	InputJSON  []byte
+	Workers    []map[string]string
+}
+
+// Decision is the outcome of evaluating a request. Policy is nil when no
+// rule matched and the default action applied.
+type Decision struct {
+	Action  string   `json:"action"`
+	Policy  *Rule    `json:"policy,omitempty"`
+	Matched []string `json:"matched_policy_ids"`
+}
+
+// Normalize trims and validates rule, compiling its pattern.
+func Normalize(rule Rule) (Rule, error) {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Description = strings.TrimSpace(rule.Description)
+	rule.Action = strings.TrimSpace(strings.ToLower(rule.Action))
+	rule.AccountID = strings.TrimSpace(rule.AccountID)
+	rule.TokenID = strings.TrimSpace(rule.TokenID)
+	rule.Capability = normalizeCapability(rule.Capability)
+	rule.MatchField = strings.TrimSpace(strings.ToLower(rule.MatchField))
+
+	if rule.Name == "" {
+		return Rule{}, ErrNameRequired
+	}
+	if len(rule.Name) > maxNameLength {
+		return Rule{}, ErrNameTooLong
+	}
+	if !ValidAction(rule.Action) {
+		return Rule{}, ErrActionInvalid
+	}
+	switch rule.MatchField {
+	case "":
+		if rule.Pattern != "" {
+			return Rule{}, ErrPatternWithoutType
+		}
+	case FieldCommand, FieldCode, FieldPath:
+		if rule.Pattern == "" {
+			return Rule{}, ErrPatternRequired
+		}
+	default:
+		return Rule{}, ErrMatchFieldInvalid
+	}
+	if len(rule.Pattern) > maxPatternLength {
+		return Rule{}, ErrPatternTooLong
+	}
+	if rule.Pattern != "" {
+		compiled, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return Rule{}, ErrPatternInvalid
+		}
+		rule.pattern = compiled
+	}
+
+	labels := make(map[string]string, len(rule.WorkerLabels))
+	for key, value := range rule.WorkerLabels {
+		trimmedKey := strings.TrimSpace(key)
+		if trimmedKey == "" {
+			continue
+		}
+		labels[trimmedKey] = strings.TrimSpace(value)
+	}
+	rule.WorkerLabels = labels
+	return rule, nil
+}
+
+func ValidAction(action string) bool {
+	switch action {
+	case ActionAllow, ActionDeny, ActionRequireApproval:
+		return true
+	default:
+		return false
+	}
+}
+
+// Evaluate applies rules to req. Deny beats require_approval, which beats
+// allow; defaultAction applies when nothing matches. With several candidate
+// workers, the most restrictive per-worker outcome wins.
+func Evaluate(rules []Rule, defaultAction string, req Request) Decision {
+	fields := inputFields(req.InputJSON)
+	workers := req.Workers
+	if len(workers) == 0 {
+		workers = []map[string]string{nil}
+	}
+
+	result := Decision{Matched: []string{}}
+	matched := make(map[string]struct{})
+	first := true
+	for _, labels := range workers {
+		decision := evaluateForWorker(rules, defaultAction, req, fields, labels)
+		for _, id := range decision.Matched {
+			if _, seen := matched[id]; !seen {
+				matched[id] = struct{}{}
+				result.Matched = append(result.Matched, id)
+			}
+		}
+		rank, current := actionRank(decision.Action), actionRank(result.Action)
+		if first || rank > current || (rank == current && result.Policy == nil) {
+			result.Action = decision.Action
+			result.Policy = decision.Policy
+			first = false
+		}
+	}
+	return result
+}
+
+func evaluateForWorker(rules []Rule, defaultAction string, req Request, fields map[string]string, labels map[string]string) Decision {
+	decision := Decision{Action: defaultAction}
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.matches(req, fields, labels) {
+			continue
+		}
+		decision.Matched = append(decision.Matched, rule.ID)
+		if decision.Policy == nil || actionRank(rule.Action) > actionRank(decision.Policy.Action) {
+			decision.Policy = rule
+		}
+	}
+	if decision.Policy != nil {
+		decision.Action = decision.Policy.Action
+	}
+	return decision
+}
+
+func (r *Rule) matches(req Request, fields map[string]string, labels map[string]string) bool {
+	if !r.Enabled {
+		return false
+	}
+	if r.AccountID != "" && r.AccountID != strings.TrimSpace(req.AccountID) {
+		return false
+	}
+	if r.TokenID != "" && r.TokenID != strings.TrimSpace(req.TokenID) {
+		return false
+	}
+	if r.Capability != "" && r.Capability != normalizeCapability(req.Capability) {
+		return false
+	}
+	for key, value := range r.WorkerLabels {
+		if labels == nil || labels[key] != value {
+			return false
+		}
+	}
+	if r.MatchField == "" {
+		return true
+	}
+	value, ok := fields[r.MatchField]
+	if !ok || r.pattern == nil {
+		return false
+	}
+	return r.pattern.MatchString(value)
+}
+
+// inputFields extracts the values rules can match from a task input.
+func inputFields(inputJSON []byte) map[string]string {
+	var decoded map[string]any
+	if err := json.Unmarshal(inputJSON, &decoded); err != nil {
+		return map[string]string{}
+	}
+	fields := make(map[string]string, 3)
+	for field, keys := range map[string][]string{
+		FieldCommand: {"command"},
+		FieldCode:    {"code"},
+		FieldPath:    {"file_path", "path"},
+	} {
+		for _, key := range keys {
+			if value, ok := decoded[key].(string); ok {
+				fields[field] = value
+				break
+			}
+		}
+	}
+	return fields
+}
+
+func actionRank(action string) int {
+	switch action {
+	case ActionDeny:
+		return 3
+	case ActionRequireApproval:
+		return 2
+	case ActionAllow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func normalizeCapability(capability string) string {
+	return strings.TrimSpace(strings.ToLower(capability))
+}
@@ -0,0 +1,256 @@
+package policy
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+const policyIDPrefix = "pol_"
+
+// Engine stores rules in SQLite and keeps a compiled copy in memory. Every
+// write goes through the engine, so the cache is refreshed in place.
+type Engine struct {
+	queries       *sqlc.Queries
+	defaultAction string
+	nowFn         func() time.Time
+
+	mu     sync.RWMutex
+	rules  []Rule
+	loaded bool
+}
+
+func NewEngine(queries *sqlc.Queries, defaultAction string) (*Engine, error) {
+	if queries == nil {
+		return nil, errors.New("policy queries are required")
+	}
+	defaultAction = strings.TrimSpace(strings.ToLower(defaultAction))
+	if defaultAction == "" {
+		defaultAction = ActionAllow
+	}
+	if !ValidAction(defaultAction) {
+		return nil, ErrActionInvalid
+	}
+	return &Engine{
+		queries:       queries,
+		defaultAction: defaultAction,
+		nowFn:         time.Now,
+	}, nil
+}
+
+func (e *Engine) DefaultAction() string {
+	if e == nil {
+		return ActionAllow
+	}
+	return e.defaultAction
+}
+
+// Evaluate applies the stored rules to req.
+func (e *Engine) Evaluate(ctx context.Context, req Request) (Decision, error) {
+	if e == nil {
+		return Decision{Action: ActionAllow, Matched: []string{}}, nil
+	}
+	rules, err := e.snapshot(ctx)
+	if err != nil {
+		return Decision{}, err
+	}
+	return Evaluate(rules, e.defaultAction, req), nil
+}
+
+func (e *Engine) List(ctx context.Context) ([]Rule, error) {
+	rules, err := e.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append([]Rule(nil), rules...), nil
+}
+
+func (e *Engine) Create(ctx context.Context, rule Rule) (Rule, error) {
+	normalized, err := Normalize(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+	id, err := generatePolicyID()
+	if err != nil {
+		return Rule{}, err
+	}
+	labelsJSON, err := json.Marshal(normalized.WorkerLabels)
+	if err != nil {
+		return Rule{}, err
+	}
+	now := e.nowFn()
+	normalized.ID = id
+	normalized.CreatedBy = strings.TrimSpace(normalized.CreatedBy)
+	normalized.CreatedAt = time.UnixMilli(now.UnixMilli())
+	normalized.UpdatedAt = normalized.CreatedAt
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.queries.InsertCommandPolicy(ctx, sqlc.InsertCommandPolicyParams{
+		PolicyID:         normalized.ID,
+		Name:             normalized.Name,
+		Description:      normalized.Description,
+		Action:           normalized.Action,
+		AccountID:        normalized.AccountID,
+		TokenID:          normalized.TokenID,
+		Capability:       normalized.Capability,
+		WorkerLabelsJson: string(labelsJSON),
+		MatchField:       normalized.MatchField,
+		Pattern:          normalized.Pattern,
+		Enabled:          boolToInt64(normalized.Enabled),
+		CreatedBy:        normalized.CreatedBy,
+		CreatedAtUnixMs:  now.UnixMilli(),
+		UpdatedAtUnixMs:  now.UnixMilli(),
+	}); err != nil {
+		return Rule{}, err
+	}
+	e.loaded = false
+	return normalized, nil
+}
+
+// Update replaces every editable field of the rule identified by rule.ID.
+func (e *Engine) Update(ctx context.Context, rule Rule) (Rule, error) {
+	id := strings.TrimSpace(rule.ID)
+	if id == "" {
+		return Rule{}, ErrPolicyNotFound
+	}
+	normalized, err := Normalize(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+	labelsJSON, err := json.Marshal(normalized.WorkerLabels)
+	if err != nil {
+		return Rule{}, err
+	}
+	now := e.nowFn()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rows, err := e.queries.UpdateCommandPolicy(ctx, sqlc.UpdateCommandPolicyParams{
+		Name:             normalized.Name,
+		Description:      normalized.Description,
+		Action:           normalized.Action,
+		AccountID:        normalized.AccountID,
+		TokenID:          normalized.TokenID,
+		Capability:       normalized.Capability,
+		WorkerLabelsJson: string(labelsJSON),
+		MatchField:       normalized.MatchField,
+		Pattern:          normalized.Pattern,
+		Enabled:          boolToInt64(normalized.Enabled),
+		UpdatedAtUnixMs:  now.UnixMilli(),
+		PolicyID:         id,
+	})
+	if err != nil {
+		return Rule{}, err
+	}
+	if rows == 0 {
+		return Rule{}, ErrPolicyNotFound
+	}
+	e.loaded = false
+	record, err := e.queries.GetCommandPolicyByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Rule{}, ErrPolicyNotFound
+		}
+		return Rule{}, err
+	}
+	return ruleFromRecord(record)
+}
+
+func (e *Engine) Delete(ctx context.Context, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	rows, err := e.queries.DeleteCommandPolicy(ctx, strings.TrimSpace(id))
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrPolicyNotFound
+	}
+	e.loaded = false
+	return nil
+}
+
+func (e *Engine) snapshot(ctx context.Context) ([]Rule, error) {
+	e.mu.RLock()
+	if e.loaded {
+		rules := e.rules
+		e.mu.RUnlock()
+		return rules, nil
+	}
+	e.mu.RUnlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.loaded {
+		return e.rules, nil
+	}
+	records, err := e.queries.ListCommandPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]Rule, 0, len(records))
+	for _, record := range records {
+		rule, err := ruleFromRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("load policy %s: %w", record.PolicyID, err)
+		}
+		rules = append(rules, rule)
+	}
+	e.rules = rules
+	e.loaded = true
+	return rules, nil
+}
+
+func ruleFromRecord(record sqlc.CommandPolicy) (Rule, error) {
+	labels := map[string]string{}
+	if strings.TrimSpace(record.WorkerLabelsJson) != "" {
+		if err := json.Unmarshal([]byte(record.WorkerLabelsJson), &labels); err != nil {
+			return Rule{}, err
+		}
+	}
+	rule, err := Normalize(Rule{
+		Name:         record.Name,
+		Description:  record.Description,
+		Action:       record.Action,
+		AccountID:    record.AccountID,
+		TokenID:      record.TokenID,
+		Capability:   record.Capability,
+		WorkerLabels: labels,
+		MatchField:   record.MatchField,
+		Pattern:      record.Pattern,
+	})
+	if err != nil {
+		return Rule{}, err
+	}
+	rule.ID = record.PolicyID
+	rule.Enabled = record.Enabled == 1
+	rule.CreatedBy = record.CreatedBy
+	rule.CreatedAt = time.UnixMilli(record.CreatedAtUnixMs)
+	rule.UpdatedAt = time.UnixMilli(record.UpdatedAtUnixMs)
+	return rule, nil
+}
+
+func generatePolicyID() (string, error) {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return policyIDPrefix + hex.EncodeToString(raw), nil
+}
+
+func boolToInt64(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -24,7 +24,7 @@ func (s *Store) GetCredentialHash(nodeID string) (string, bool) {
 	return secretHash, true
 }
 
-func (s *Store) PutCredentialHashIfAbsent(nodeID string, secretHash string, hashAlgo string, now time.Time) bool {
+func (s *Store) PutCredentialHashIfAbsent(nodeID string, secretHash string, hashAlgo string, hashKeyID string, now time.Time) bool {
 	trimmedNodeID := strings.TrimSpace(nodeID)
 	trimmedHash := strings.TrimSpace(secretHash)
 	trimmedHashAlgo := strings.TrimSpace(hashAlgo)
@@ -39,10 +39,31 @@ func (s *Store) PutCredentialHashIfAbsent(nodeID string, secretHash string, hash
 		HashAlgo:        trimmedHashAlgo,
 		CreatedAtUnixMs: nowMS,
 		UpdatedAtUnixMs: nowMS,
+		HashKeyID:       strings.TrimSpace(hashKeyID),
 	})
 	return err == nil && inserted == 1
 }
 
+// RekeyCredentialHash replaces a credential hash computed with a retired key.
+// The update only applies while the stored hash is still oldHash.
+func (s *Store) RekeyCredentialHash(nodeID string, oldHash string, newHash string, hashKeyID string, now time.Time) bool {
+	trimmedNodeID := strings.TrimSpace(nodeID)
+	trimmedOldHash := strings.TrimSpace(oldHash)
+	trimmedNewHash := strings.TrimSpace(newHash)
+	trimmedHashKeyID := strings.TrimSpace(hashKeyID)
+	if trimmedNodeID == "" || trimmedOldHash == "" || trimmedNewHash == "" || trimmedHashKeyID == "" || s == nil || s.queries == nil {
+		return false
+	}
+	updated, err := s.queries.UpdateWorkerCredentialHash(context.Background(), sqlc.UpdateWorkerCredentialHashParams{
+		SecretHash:      trimmedNewHash,
+		HashKeyID:       trimmedHashKeyID,
+		UpdatedAtUnixMs: now.UnixMilli(),
+		NodeID:          trimmedNodeID,
+		SecretHash_2:    trimmedOldHash,
+	})
+	return err == nil && updated == 1
+}
+
 func (s *Store) DeleteCredential(nodeID string) bool {
 	trimmedNodeID := strings.TrimSpace(nodeID)
 	if trimmedNodeID == "" || s == nil || s.queries == nil {
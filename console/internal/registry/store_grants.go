@@ -0,0 +1,224 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+// WorkerSysReadOnlyCapability is the only capability of a read-only grant.
+const WorkerSysReadOnlyCapability = "readImage"
+
+// workerSysGrantCapabilities maps the normalized name of every capability a
+// worker-sys grant may cover to its declared name.
+var workerSysGrantCapabilities = map[string]string{
+	"computeruse": "computerUse",
+	"readimage":   "readImage",
+	"readfile":    "readFile",
+	"writefile":   "writeFile",
+	"listdir":     "listDir",
+	"screenshot":  "screenshot",
+}
+
+// WorkerSysGrant lets one account use another account's worker-sys for the
+// listed capabilities until ExpiresAt.
+type WorkerSysGrant struct {
+	NodeID       string
+	GranteeID    string
+	Capabilities []string
+	GrantedBy    string
+	GrantedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// ReadOnly reports whether the grant covers readImage only.
+func (g WorkerSysGrant) ReadOnly() bool {
+	return len(g.Capabilities) == 1 && g.Capabilities[0] == WorkerSysReadOnlyCapability
+}
+
+// NormalizeWorkerSysGrantCapability returns the declared name of a grantable
+// worker-sys capability, matched case-insensitively.
+func NormalizeWorkerSysGrantCapability(capability string) (string, bool) {
+	declared, ok := workerSysGrantCapabilities[normalizeCapabilityName(capability)]
+	return declared, ok
+}
+
+// ReplaceWorkerSysGrant sets the capabilities and expiry granted to one
+// account on a worker-sys, replacing any earlier grant.
+func (s *Store) ReplaceWorkerSysGrant(grant WorkerSysGrant) error {
+	nodeID := strings.TrimSpace(grant.NodeID)
+	granteeID := strings.TrimSpace(grant.GranteeID)
+	if nodeID == "" || granteeID == "" {
+		return errors.New("node_id and grantee_id are required")
+	}
+	if len(grant.Capabilities) == 0 {
+		return errors.New("at least one capability is required")
+	}
+	if s == nil || s.db == nil || s.queries == nil {
+		return errors.New("registry store is unavailable")
+	}
+
+	ctx := context.Background()
+	return s.db.WithTx(ctx, func(q *sqlc.Queries) error {
+		if _, err := q.DeleteWorkerSysGrantsByNodeAndGrantee(ctx, sqlc.DeleteWorkerSysGrantsByNodeAndGranteeParams{
+			NodeID:    nodeID,
+			GranteeID: granteeID,
+		}); err != nil {
+			return err
+		}
+		for _, capability := range grant.Capabilities {
+			if err := q.InsertWorkerSysGrant(ctx, sqlc.InsertWorkerSysGrantParams{
+				NodeID:          nodeID,
+				GranteeID:       granteeID,
+				Capability:      normalizeCapabilityName(capability),
+				GrantedBy:       strings.TrimSpace(grant.GrantedBy),
+				GrantedAtUnixMs: grant.GrantedAt.UnixMilli(),
+				ExpiresAtUnixMs: grant.ExpiresAt.UnixMilli(),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteWorkerSysGrant revokes everything granted to one account on a
+// worker-sys and reports whether a grant existed.
+func (s *Store) DeleteWorkerSysGrant(nodeID string, granteeID string) (bool, error) {
+	if s == nil || s.queries == nil {
+		return false, errors.New("registry store is unavailable")
+	}
+	rows, err := s.queries.DeleteWorkerSysGrantsByNodeAndGrantee(context.Background(), sqlc.DeleteWorkerSysGrantsByNodeAndGranteeParams{
+		NodeID:    strings.TrimSpace(nodeID),
+		GranteeID: strings.TrimSpace(granteeID),
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ListWorkerSysGrantsByNode lists the unexpired grants on a worker-sys.
+func (s *Store) ListWorkerSysGrantsByNode(nodeID string, now time.Time) ([]WorkerSysGrant, error) {
+	if s == nil || s.queries == nil {
+		return nil, errors.New("registry store is unavailable")
+	}
+	rows, err := s.queries.ListWorkerSysGrantsByNode(context.Background(), sqlc.ListWorkerSysGrantsByNodeParams{
+		NodeID:          strings.TrimSpace(nodeID),
+		ExpiresAtUnixMs: now.UnixMilli(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groupWorkerSysGrants(rows), nil
+}
+
+// ListWorkerSysGrantsByGrantee lists the unexpired grants an account holds.
+func (s *Store) ListWorkerSysGrantsByGrantee(granteeID string, now time.Time) ([]WorkerSysGrant, error) {
+	if s == nil || s.queries == nil {
+		return nil, errors.New("registry store is unavailable")
+	}
+	rows, err := s.queries.ListWorkerSysGrantsByGrantee(context.Background(), sqlc.ListWorkerSysGrantsByGranteeParams{
+		GranteeID:       strings.TrimSpace(granteeID),
+		ExpiresAtUnixMs: now.UnixMilli(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groupWorkerSysGrants(rows), nil
+}
+
+// HasWorkerSysGrant reports whether granteeID holds an unexpired grant for
+// capability on nodeID.
+func (s *Store) HasWorkerSysGrant(nodeID string, granteeID string, capability string, now time.Time) bool {
+	trimmedNodeID := strings.TrimSpace(nodeID)
+	trimmedGranteeID := strings.TrimSpace(granteeID)
+	normalizedCapability := normalizeCapabilityName(capability)
+	if trimmedNodeID == "" || trimmedGranteeID == "" || normalizedCapability == "" || s == nil || s.queries == nil {
+		return false
+	}
+	count, err := s.queries.CountActiveWorkerSysGrant(context.Background(), sqlc.CountActiveWorkerSysGrantParams{
+		NodeID:          trimmedNodeID,
+		GranteeID:       trimmedGranteeID,
+		Capability:      normalizedCapability,
+		ExpiresAtUnixMs: now.UnixMilli(),
+	})
+	return err == nil && count > 0
+}
+
+// ListOnlineGrantedNodeIDsByCapability lists online worker-sys nodes that
+// granteeID may use for capability, ordered by node_id.
+func (s *Store) ListOnlineGrantedNodeIDsByCapability(
+	granteeID string,
+	capability string,
+	now time.Time,
+	offlineTTL time.Duration,
+) []string {
+	trimmedGranteeID := strings.TrimSpace(granteeID)
+	normalizedCapability := normalizeCapabilityName(capability)
+	if trimmedGranteeID == "" || normalizedCapability == "" || s == nil || s.queries == nil {
+		return []string{}
+	}
+
+	nodeIDs, err := s.queries.ListOnlineGrantedWorkerNodeIDsByCapability(
+		context.Background(),
+		sqlc.ListOnlineGrantedWorkerNodeIDsByCapabilityParams{
+			GranteeID:        trimmedGranteeID,
+			Capability:       normalizedCapability,
+			ExpiresAtUnixMs:  now.UnixMilli(),
+			LastSeenAtUnixMs: now.Add(-offlineTTL).UnixMilli(),
+		},
+	)
+	if err != nil {
+		return []string{}
+	}
+	return append([]string(nil), nodeIDs...)
+}
+
+// PruneExpiredWorkerSysGrants deletes expired grant rows. Reads already
+// ignore them; this only keeps the table small.
+func (s *Store) PruneExpiredWorkerSysGrants(now time.Time) int {
+	if s == nil || s.queries == nil {
+		return 0
+	}
+	rows, err := s.queries.DeleteExpiredWorkerSysGrants(context.Background(), now.UnixMilli())
+	if err != nil {
+		return 0
+	}
+	return int(rows)
+}
+
+// groupWorkerSysGrants folds per-capability rows, ordered by node and
+// grantee, into one grant per (node, grantee).
+func groupWorkerSysGrants(rows []sqlc.WorkerSysGrant) []WorkerSysGrant {
+	grants := make([]WorkerSysGrant, 0, len(rows))
+	index := make(map[[2]string]int, len(rows))
+	for _, row := range rows {
+		key := [2]string{row.NodeID, row.GranteeID}
+		position, ok := index[key]
+		if !ok {
+			position = len(grants)
+			index[key] = position
+			grants = append(grants, WorkerSysGrant{
+				NodeID:    row.NodeID,
+				GranteeID: row.GranteeID,
+				GrantedBy: row.GrantedBy,
+				GrantedAt: time.UnixMilli(row.GrantedAtUnixMs),
+				ExpiresAt: time.UnixMilli(row.ExpiresAtUnixMs),
+			})
+		}
+		declared, ok := workerSysGrantCapabilities[row.Capability]
+		if !ok {
+			declared = row.Capability
+		}
+		grants[position].Capabilities = append(grants[position].Capabilities, declared)
+	}
+	for i := range grants {
+		sort.Strings(grants[i].Capabilities)
+	}
+	return grants
+}
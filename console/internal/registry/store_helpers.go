@@ -13,8 +13,20 @@ const (
 
 	WorkerTypeNormal = "normal"
 	WorkerTypeSys    = "worker-sys"
+	// WorkerTypePrivate is a worker-docker that serves only its owner's
+	// tasks. It never joins the shared pool of normal workers.
+	WorkerTypePrivate = "private"
+
+	// OrganizationIDPrefix marks an owner_id that names an organization
+	// rather than an account.
+	OrganizationIDPrefix = "org_"
 )
 
+// IsOrganizationOwnerID reports whether ownerID names an organization.
+func IsOrganizationOwnerID(ownerID string) bool {
+	return strings.HasPrefix(strings.TrimSpace(ownerID), OrganizationIDPrefix)
+}
+
 func statusOf(lastSeenAt time.Time, now time.Time, offlineTTL time.Duration) WorkerStatus {
 	if now.Sub(lastSeenAt) <= offlineTTL {
 		return StatusOnline
@@ -0,0 +1,46 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+// PrivatePoolFallback reports whether tasks of ownerID may fall back to the
+// shared pool when its private workers are missing or busy. Owners that
+// never changed the setting fall back; a failed lookup does not.
+func (s *Store) PrivatePoolFallback(ownerID string) bool {
+	trimmedOwnerID := strings.TrimSpace(ownerID)
+	if trimmedOwnerID == "" || s == nil || s.queries == nil {
+		return false
+	}
+	fallback, err := s.queries.GetPrivatePoolFallback(context.Background(), trimmedOwnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true
+	}
+	return err == nil && fallback != 0
+}
+
+// SetPrivatePoolFallback stores the fallback preference of ownerID.
+func (s *Store) SetPrivatePoolFallback(ownerID string, fallback bool, now time.Time) error {
+	trimmedOwnerID := strings.TrimSpace(ownerID)
+	if trimmedOwnerID == "" {
+		return errors.New("owner_id is required")
+	}
+	if s == nil || s.queries == nil {
+		return errors.New("registry store is unavailable")
+	}
+	value := int64(0)
+	if fallback {
+		value = 1
+	}
+	return s.queries.UpsertPrivatePoolSetting(context.Background(), sqlc.UpsertPrivatePoolSettingParams{
+		OwnerID:          trimmedOwnerID,
+		FallbackToShared: value,
+		UpdatedAtUnixMs:  now.UnixMilli(),
+	})
+}
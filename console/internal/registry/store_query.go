@@ -10,7 +10,7 @@ import (
 )
 
 func (s *Store) List(status WorkerStatus, page int, pageSize int, now time.Time, offlineTTL time.Duration) ([]WorkerView, int) {
-	return s.ListScoped(status, page, pageSize, now, offlineTTL, "", "")
+	return s.ListScoped(status, page, pageSize, now, offlineTTL, "")
 }
 
 func (s *Store) ListScoped(
@@ -20,9 +20,9 @@ func (s *Store) ListScoped(
 	now time.Time,
 	offlineTTL time.Duration,
 	ownerID string,
-	workerType string,
+	workerTypes ...string,
 ) ([]WorkerView, int) {
-	filtered := s.listFilteredViews(status, now, offlineTTL, ownerID, workerType)
+	filtered := s.listFilteredViews(status, now, offlineTTL, ownerID, workerTypes)
 	total := len(filtered)
 	if page <= 0 {
 		page = 1
@@ -47,7 +47,7 @@ func (s *Store) listFilteredViews(
 	now time.Time,
 	offlineTTL time.Duration,
 	ownerID string,
-	workerType string,
+	workerTypes []string,
 ) []WorkerView {
 	if s == nil || s.queries == nil {
 		return []WorkerView{}
@@ -83,7 +83,12 @@ func (s *Store) listFilteredViews(
 
 	filtered := make([]WorkerView, 0, len(nodes))
 	normalizedOwnerID := strings.TrimSpace(ownerID)
-	normalizedWorkerType := normalizeWorkerType(workerType)
+	normalizedWorkerTypes := make(map[string]struct{}, len(workerTypes))
+	for _, workerType := range workerTypes {
+		if normalized := normalizeWorkerType(workerType); normalized != "" {
+			normalizedWorkerTypes[normalized] = struct{}{}
+		}
+	}
 	for _, node := range nodes {
 		worker := Worker{
 			NodeID:       node.NodeID,
@@ -105,7 +110,7 @@ func (s *Store) listFilteredViews(
 			if strings.TrimSpace(worker.Labels[LabelOwnerIDKey]) != normalizedOwnerID {
 				continue
 			}
-			if normalizedWorkerType != "" && resolveWorkerType(worker.Labels) != normalizedWorkerType {
+			if _, ok := normalizedWorkerTypes[resolveWorkerType(worker.Labels)]; len(normalizedWorkerTypes) > 0 && !ok {
 				continue
 			}
 		}
@@ -115,7 +120,7 @@ func (s *Store) listFilteredViews(
 }
 
 func (s *Store) Stats(now time.Time, offlineTTL time.Duration, staleAfter time.Duration) WorkerStats {
-	return s.StatsScoped(now, offlineTTL, staleAfter, "", "")
+	return s.StatsScoped(now, offlineTTL, staleAfter, "")
 }
 
 func (s *Store) GetByNodeID(nodeID string, now time.Time, offlineTTL time.Duration) (WorkerView, bool) {
@@ -193,9 +198,9 @@ func (s *Store) StatsScoped(
 	offlineTTL time.Duration,
 	staleAfter time.Duration,
 	ownerID string,
-	workerType string,
+	workerTypes ...string,
 ) WorkerStats {
-	workers := s.listFilteredViews(StatusAll, now, offlineTTL, ownerID, workerType)
+	workers := s.listFilteredViews(StatusAll, now, offlineTTL, ownerID, workerTypes)
 	stats := WorkerStats{}
 	for _, worker := range workers {
 		stats.Total++
@@ -295,6 +300,9 @@ func (s *Store) ListOnlineNodeIDsByOwnerTypeAndCapability(
 	return append([]string(nil), nodeIDs...)
 }
 
+// ListOnlineNodeIDsByCapability lists the online nodes of the shared pool
+// for capability. Private workers are left out; see
+// ListOnlineNodeIDsByOwnerTypeAndCapability.
 func (s *Store) ListOnlineNodeIDsByCapability(capability string, now time.Time, offlineTTL time.Duration) []string {
 	trimmed := normalizeCapabilityName(capability)
 	if trimmed == "" || s == nil || s.queries == nil {
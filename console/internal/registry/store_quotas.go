@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+// usageRetentionDays is how many past days of execution usage are kept.
+const usageRetentionDays = 31
+
+// Quota holds the execution limits of an owner scope. Zero means unlimited.
+type Quota struct {
+	MaxConcurrentPerCapability int
+	MaxTasksPerMinute          int
+	MaxExecSecondsPerDay       int
+	MaxTerminalSessions        int
+}
+
+// Quota returns the limits of ownerID. Owners without a row are unlimited.
+func (s *Store) Quota(ownerID string) (Quota, error) {
+	trimmedOwnerID := strings.TrimSpace(ownerID)
+	if trimmedOwnerID == "" || s == nil || s.queries == nil {
+		return Quota{}, nil
+	}
+	row, err := s.queries.GetAccountQuota(context.Background(), trimmedOwnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Quota{}, nil
+	}
+	if err != nil {
+		return Quota{}, err
+	}
+	return Quota{
+		MaxConcurrentPerCapability: int(row.MaxConcurrentPerCapability),
+		MaxTasksPerMinute:          int(row.MaxTasksPerMinute),
+		MaxExecSecondsPerDay:       int(row.MaxExecSecondsPerDay),
+		MaxTerminalSessions:        int(row.MaxTerminalSessions),
+	}, nil
+}
+
+// SetQuota replaces the limits of ownerID.
+func (s *Store) SetQuota(ownerID string, quota Quota, now time.Time) error {
+	trimmedOwnerID := strings.TrimSpace(ownerID)
+	if trimmedOwnerID == "" {
+		return errors.New("owner_id is required")
+	}
+	if s == nil || s.queries == nil {
+		return errors.New("registry store is unavailable")
+	}
+	return s.queries.UpsertAccountQuota(context.Background(), sqlc.UpsertAccountQuotaParams{
+		OwnerID:                    trimmedOwnerID,
+		MaxConcurrentPerCapability: int64(quota.MaxConcurrentPerCapability),
+		MaxTasksPerMinute:          int64(quota.MaxTasksPerMinute),
+		MaxExecSecondsPerDay:       int64(quota.MaxExecSecondsPerDay),
+		MaxTerminalSessions:        int64(quota.MaxTerminalSessions),
+		UpdatedAtUnixMs:            now.UnixMilli(),
+	})
+}
+
+// AddExecUsage adds command execution time to ownerID's total for the UTC
+// day of now, rounded up to whole milliseconds.
+func (s *Store) AddExecUsage(ownerID string, elapsed time.Duration, now time.Time) error {
+	trimmedOwnerID := strings.TrimSpace(ownerID)
+	if trimmedOwnerID == "" || elapsed <= 0 || s == nil || s.queries == nil {
+		return nil
+	}
+	return s.queries.AddAccountUsageExecMs(context.Background(), sqlc.AddAccountUsageExecMsParams{
+		OwnerID:  trimmedOwnerID,
+		UsageDay: usageDay(now),
+		ExecMs:   int64((elapsed + time.Millisecond - 1) / time.Millisecond),
+	})
+}
+
+// ExecUsage returns ownerID's command execution time for the UTC day of now.
+func (s *Store) ExecUsage(ownerID string, now time.Time) (time.Duration, error) {
+	trimmedOwnerID := strings.TrimSpace(ownerID)
+	if trimmedOwnerID == "" || s == nil || s.queries == nil {
+		return 0, nil
+	}
+	execMs, err := s.queries.GetAccountUsageExecMs(context.Background(), sqlc.GetAccountUsageExecMsParams{
+		OwnerID:  trimmedOwnerID,
+		UsageDay: usageDay(now),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(execMs) * time.Millisecond, nil
+}
+
+// PruneExecUsage deletes daily usage rows older than the retention window.
+func (s *Store) PruneExecUsage(now time.Time) int {
+	if s == nil || s.queries == nil {
+		return 0
+	}
+	rows, err := s.queries.DeleteAccountUsageBefore(context.Background(), usageDay(now)-usageRetentionDays)
+	if err != nil {
+		return 0
+	}
+	return int(rows)
+}
+
+// UsageDayStart returns the start of the UTC day that holds now; daily
+// execution budgets reset at that boundary.
+func UsageDayStart(now time.Time) time.Time {
+	return time.UnixMilli(usageDay(now) * int64(24*time.Hour/time.Millisecond)).UTC()
+}
+
+func usageDay(now time.Time) int64 {
+	return now.UnixMilli() / int64(24*time.Hour/time.Millisecond)
+}
@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+const (
+	PriorityClassInteractive = "interactive"
+	PriorityClassBatch       = "batch"
+
+	DefaultSchedulingWeight = 1
+	MaxSchedulingWeight     = 100
+)
+
+// SchedulingSettings control how an owner's tasks share worker capacity
+// with other owners while they wait for a slot. A batch owner queues every
+// task as batch; an interactive owner queues async tasks as batch only.
+type SchedulingSettings struct {
+	Weight        int
+	PriorityClass string
+}
+
+// DefaultSchedulingSettings applies to owners without a row.
+func DefaultSchedulingSettings() SchedulingSettings {
+	return SchedulingSettings{Weight: DefaultSchedulingWeight, PriorityClass: PriorityClassInteractive}
+}
+
+// SchedulingSettings returns the settings of ownerID.
+func (s *Store) SchedulingSettings(ownerID string) (SchedulingSettings, error) {
+	trimmedOwnerID := strings.TrimSpace(ownerID)
+	if trimmedOwnerID == "" || s == nil || s.queries == nil {
+		return DefaultSchedulingSettings(), nil
+	}
+	row, err := s.queries.GetSchedulingSetting(context.Background(), trimmedOwnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultSchedulingSettings(), nil
+	}
+	if err != nil {
+		return DefaultSchedulingSettings(), err
+	}
+	return SchedulingSettings{Weight: int(row.Weight), PriorityClass: row.PriorityClass}, nil
+}
+
+// SetSchedulingSettings replaces the settings of ownerID.
+func (s *Store) SetSchedulingSettings(ownerID string, settings SchedulingSettings, now time.Time) error {
+	trimmedOwnerID := strings.TrimSpace(ownerID)
+	if trimmedOwnerID == "" {
+		return errors.New("owner_id is required")
+	}
+	if settings.Weight < 1 || settings.Weight > MaxSchedulingWeight {
+		return errors.New("weight is out of range")
+	}
+	if settings.PriorityClass != PriorityClassInteractive && settings.PriorityClass != PriorityClassBatch {
+		return errors.New("priority_class must be interactive or batch")
+	}
+	if s == nil || s.queries == nil {
+		return errors.New("registry store is unavailable")
+	}
+	return s.queries.UpsertSchedulingSetting(context.Background(), sqlc.UpsertSchedulingSettingParams{
+		OwnerID:         trimmedOwnerID,
+		Weight:          int64(settings.Weight),
+		PriorityClass:   settings.PriorityClass,
+		UpdatedAtUnixMs: now.UnixMilli(),
+	})
+}
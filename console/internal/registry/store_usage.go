@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/console/internal/persistence/sqlc"
+)
+
+// usageRecordRetention is how long per-command usage records are kept for
+// reports.
+const usageRecordRetention = 90 * 24 * time.Hour
+
+const (
+	UsageStatusSucceeded = "succeeded"
+	UsageStatusFailed    = "failed"
+)
+
+// UsageRecord is what one dispatched command cost its owner scope.
+// CPUSeconds and PeakMemoryBytes are zero when the worker did not measure
+// them.
+type UsageRecord struct {
+	CommandID       string
+	OwnerID         string
+	Capability      string
+	NodeID          string
+	Status          string
+	QueueWait       time.Duration
+	Exec            time.Duration
+	OutputBytes     int64
+	CPUSeconds      float64
+	PeakMemoryBytes int64
+	CompletedAt     time.Time
+}
+
+// UsageTotals sums the usage records of one owner, capability and worker
+// node. PeakMemoryBytes is the largest single peak.
+type UsageTotals struct {
+	OwnerID         string
+	Capability      string
+	NodeID          string
+	Tasks           int64
+	FailedTasks     int64
+	QueueWait       time.Duration
+	Exec            time.Duration
+	OutputBytes     int64
+	CPUSeconds      float64
+	PeakMemoryBytes int64
+}
+
+// RecordUsage stores the usage of one command.
+func (s *Store) RecordUsage(record UsageRecord) error {
+	if strings.TrimSpace(record.CommandID) == "" || strings.TrimSpace(record.OwnerID) == "" {
+		return errors.New("command_id and owner_id are required")
+	}
+	if s == nil || s.queries == nil {
+		return nil
+	}
+	return s.queries.InsertTaskUsage(context.Background(), sqlc.InsertTaskUsageParams{
+		CommandID:         record.CommandID,
+		OwnerID:           strings.TrimSpace(record.OwnerID),
+		Capability:        record.Capability,
+		NodeID:            record.NodeID,
+		Status:            record.Status,
+		QueueWaitMs:       max(record.QueueWait.Milliseconds(), 0),
+		ExecMs:            max(int64((record.Exec+time.Millisecond-1)/time.Millisecond), 0),
+		OutputBytes:       max(record.OutputBytes, 0),
+		CpuSeconds:        max(record.CPUSeconds, 0),
+		PeakMemoryBytes:   max(record.PeakMemoryBytes, 0),
+		CompletedAtUnixMs: record.CompletedAt.UnixMilli(),
+	})
+}
+
+// UsageTotals sums usage records completed in [from, to) per owner,
+// capability and worker node. An empty ownerID covers every owner.
+func (s *Store) UsageTotals(ownerID string, from time.Time, to time.Time) ([]UsageTotals, error) {
+	if s == nil || s.queries == nil {
+		return nil, nil
+	}
+	rows, err := s.queries.SummarizeTaskUsage(context.Background(), sqlc.SummarizeTaskUsageParams{
+		OwnerID:    strings.TrimSpace(ownerID),
+		FromUnixMs: from.UnixMilli(),
+		ToUnixMs:   to.UnixMilli(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	totals := make([]UsageTotals, 0, len(rows))
+	for _, row := range rows {
+		totals = append(totals, UsageTotals{
+			OwnerID:         row.OwnerID,
+			Capability:      row.Capability,
+			NodeID:          row.NodeID,
+			Tasks:           row.Tasks,
+			FailedTasks:     row.FailedTasks,
+			QueueWait:       time.Duration(row.QueueWaitMs) * time.Millisecond,
+			Exec:            time.Duration(row.ExecMs) * time.Millisecond,
+			OutputBytes:     row.OutputBytes,
+			CPUSeconds:      row.CpuSeconds,
+			PeakMemoryBytes: row.PeakMemoryBytes,
+		})
+	}
+	return totals, nil
+}
+
+// PruneUsageRecords deletes usage records older than the retention window.
+func (s *Store) PruneUsageRecords(now time.Time) int {
+	if s == nil || s.queries == nil {
+		return 0
+	}
+	rows, err := s.queries.DeleteTaskUsageBefore(context.Background(), now.Add(-usageRecordRetention).UnixMilli())
+	if err != nil {
+		return 0
+	}
+	return int(rows)
+}
@@ -0,0 +1,284 @@
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Identity is the end user the stub IdP signs in when Authorize is called.
+type Identity struct {
+	Subject string
+	Claims  map[string]any
+}
+
+type authorization struct {
+	identity      Identity
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+// Server is a minimal OpenID provider for tests. It serves discovery, JWKS and
+// the token endpoint; the interactive authorization step is simulated by
+// Authorize.
+type Server struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	t testing.TB
+
+	mu     sync.Mutex
+	key    *rsa.PrivateKey
+	keyID  string
+	codes  map[string]authorization
+	tokens int
+}
+
+func NewServer(t testing.TB, clientID string, clientSecret string) *Server {
+	t.Helper()
+
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		t:            t,
+		codes:        make(map[string]authorization),
+	}
+	s.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.handleDiscovery)
+	mux.HandleFunc("/jwks", s.handleJWKS)
+	mux.HandleFunc("/token", s.handleToken)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// RotateKey replaces the signing key, as a provider does during key rollover.
+func (s *Server) RotateKey() {
+	s.t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		s.t.Fatalf("generate oidc test key: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+	s.keyID = randomString(s.t, 8)
+}
+
+// TokenRequests reports how many successful token exchanges were served.
+func (s *Server) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens
+}
+
+// Authorize validates an authorization request URL produced by the relying
+// party and returns the redirect URL the browser would follow back to it.
+func (s *Server) Authorize(authURL string, identity Identity) string {
+	s.t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		s.t.Fatalf("parse authorization url: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("response_type") != "code" {
+		s.t.Fatalf("expected response_type=code, got %q", query.Get("response_type"))
+	}
+	if query.Get("client_id") != s.ClientID {
+		s.t.Fatalf("expected client_id=%q, got %q", s.ClientID, query.Get("client_id"))
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		s.t.Fatalf("expected PKCE S256 challenge, got %q", parsed.RawQuery)
+	}
+	if query.Get("state") == "" || query.Get("nonce") == "" {
+		s.t.Fatalf("expected state and nonce, got %q", parsed.RawQuery)
+	}
+
+	code := randomString(s.t, 16)
+	s.mu.Lock()
+	s.codes[code] = authorization{
+		identity:      identity,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+	}
+	s.mu.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		s.t.Fatalf("parse redirect_uri: %v", err)
+	}
+	redirectQuery := redirect.Query()
+	redirectQuery.Set("code", code)
+	redirectQuery.Set("state", query.Get("state"))
+	redirect.RawQuery = redirectQuery.Encode()
+	return redirect.String()
+}
+
+// SignIDToken signs arbitrary claims with the current key.
+func (s *Server) SignIDToken(claims map[string]any) string {
+	s.t.Helper()
+
+	s.mu.Lock()
+	key := s.key
+	keyID := s.keyID
+	s.mu.Unlock()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	if err != nil {
+		s.t.Fatalf("marshal jwt header: %v", err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		s.t.Fatalf("marshal jwt claims: %v", err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		s.t.Fatalf("sign jwt: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (s *Server) handleDiscovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) handleJWKS(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	key := s.key.PublicKey
+	keyID := s.keyID
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if !s.authenticateClient(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	s.mu.Lock()
+	grant, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+	if !ok || grant.redirectURI != r.PostForm.Get("redirect_uri") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	verifierSum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(verifierSum[:]) != grant.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   s.URL,
+		"sub":   grant.identity.Subject,
+		"aud":   s.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": grant.nonce,
+	}
+	for name, value := range grant.identity.Claims {
+		claims[name] = value
+	}
+
+	s.mu.Lock()
+	s.tokens++
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(s.t, 16),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     s.SignIDToken(claims),
+	})
+}
+
+func (s *Server) authenticateClient(r *http.Request) bool {
+	if s.ClientSecret == "" {
+		return r.PostForm.Get("client_id") == s.ClientID
+	}
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	clientID, err := url.QueryUnescape(username)
+	if err != nil {
+		return false
+	}
+	clientSecret, err := url.QueryUnescape(password)
+	if err != nil {
+		return false
+	}
+	return clientID == s.ClientID && clientSecret == s.ClientSecret
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func randomString(t testing.TB, byteSize int) string {
+	raw := make([]byte, byteSize)
+	if _, err := rand.Read(raw); err != nil {
+		t.Fatalf("generate random value: %v", err)
+	}
+	return hex.EncodeToString(raw)
+}
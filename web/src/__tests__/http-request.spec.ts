@@ -0,0 +1,33 @@
+import { afterEach, beforeEach, describe, expect, it, vi } from 'vitest'
+
+import { request } from '../services/http'
+import { jsonResponse } from './testkit'
+
+describe('request', () => {
+  beforeEach(() => {
+    vi.restoreAllMocks()
+    document.cookie = 'onlyboxes_console_csrf=csrf-value; path=/'
+  })
+
+  afterEach(() => {
+    vi.unstubAllGlobals()
+    document.cookie = 'onlyboxes_console_csrf=; path=/; expires=Thu, 01 Jan 1970 00:00:00 GMT'
+  })
+
+  it('sends the csrf cookie value on state-changing requests only', async () => {
+    const sentHeaders: Headers[] = []
+    const fetchMock = vi.fn(async (_input: RequestInfo | URL, init?: RequestInit) => {
+      sentHeaders.push(new Headers(init?.headers))
+      return jsonResponse({})
+    })
+    vi.stubGlobal('fetch', fetchMock as unknown as typeof fetch)
+
+    await request('/api/v1/console/tokens', { method: 'POST' })
+    await request('/api/v1/console/tokens/tok-1', { method: 'delete' })
+    await request('/api/v1/console/tokens')
+
+    expect(sentHeaders[0]?.get('X-CSRF-Token')).toBe('csrf-value')
+    expect(sentHeaders[1]?.get('X-CSRF-Token')).toBe('csrf-value')
+    expect(sentHeaders[2]?.has('X-CSRF-Token')).toBe(false)
+  })
+})
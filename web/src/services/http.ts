@@ -21,11 +21,35 @@ export async function parseAPIError(response: Response): Promise<string> {
   return `API ${response.status}: ${response.statusText}`
 }
 
+const csrfCookieName = 'onlyboxes_console_csrf'
+const csrfHeaderName = 'X-CSRF-Token'
+const safeMethods = new Set(['GET', 'HEAD', 'OPTIONS', 'TRACE'])
+
+function readCSRFToken(): string {
+  if (typeof document === 'undefined') {
+    return ''
+  }
+  for (const part of document.cookie.split(';')) {
+    const [name, ...value] = part.trim().split('=')
+    if (name === csrfCookieName) {
+      return decodeURIComponent(value.join('='))
+    }
+  }
+  return ''
+}
+
 export async function request(url: string, init: RequestInit = {}): Promise<Response> {
   const headers = new Headers(init.headers ?? {})
   if (!headers.has('Accept')) {
     headers.set('Accept', 'application/json')
   }
+  const method = (init.method ?? 'GET').toUpperCase()
+  if (!safeMethods.has(method) && !headers.has(csrfHeaderName)) {
+    const csrfToken = readCSRFToken()
+    if (csrfToken !== '') {
+      headers.set(csrfHeaderName, csrfToken)
+    }
+  }
 
   const response = await fetch(url, {
     ...init,
@@ -102,6 +102,7 @@ type pythonExecRunResult struct {
 	Output   string
 	Stderr   string
 	ExitCode int
+	Usage    commandUsage
 }
 
 func buildPythonExecCommandResult(baseCtx context.Context, commandID string, dispatch *registryv1.CommandDispatch) *registryv1.ConnectRequest {
@@ -151,6 +152,8 @@ func buildPythonExecCommandResult(baseCtx context.Context, commandID string, dis
 				CommandId:       commandID,
 				PayloadJson:     resultPayload,
 				CompletedUnixMs: time.Now().UnixMilli(),
+				CpuSeconds:      execResult.Usage.CPUSeconds,
+				PeakMemoryBytes: execResult.Usage.PeakMemoryBytes,
 			},
 		},
 	}
@@ -208,6 +211,8 @@ func buildTerminalExecCommandResult(baseCtx context.Context, commandID string, d
 				CommandId:       commandID,
 				PayloadJson:     resultPayload,
 				CompletedUnixMs: time.Now().UnixMilli(),
+				CpuSeconds:      execResult.Usage.CPUSeconds,
+				PeakMemoryBytes: execResult.Usage.PeakMemoryBytes,
 			},
 		},
 	}
@@ -241,6 +246,10 @@ func buildTerminalResourceCommandResult(baseCtx context.Context, commandID strin
 		SessionID: decoded.SessionID,
 		FilePath:  decoded.FilePath,
 		Action:    decoded.Action,
+		MaxWidth:  decoded.MaxWidth,
+		MaxHeight: decoded.MaxHeight,
+		MaxBytes:  decoded.MaxBytes,
+		Format:    decoded.Format,
 	})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
@@ -59,7 +59,9 @@ func runPythonExecInDockerWithImage(ctx context.Context, dockerImage string, cod
 
 	defer cleanupPythonExecContainer(containerName)
 
+	stopUsage := watchContainerUsage(containerName)
 	startResult := runDockerCommand(ctx, pythonExecDockerStartArgs(containerName)...)
+	usage := stopUsage()
 	if startResult.Err != nil {
 		if errors.Is(startResult.Err, context.DeadlineExceeded) || errors.Is(startResult.Err, context.Canceled) {
 			return pythonExecRunResult{}, startResult.Err
@@ -72,6 +74,7 @@ func runPythonExecInDockerWithImage(ctx context.Context, dockerImage string, cod
 			Output:   startResult.Stdout,
 			Stderr:   startResult.Stderr,
 			ExitCode: 0,
+			Usage:    usage,
 		}, nil
 	}
 
@@ -91,6 +94,7 @@ func runPythonExecInDockerWithImage(ctx context.Context, dockerImage string, cod
 		Output:   startResult.Stdout,
 		Stderr:   startResult.Stderr,
 		ExitCode: state.ExitCode,
+		Usage:    usage,
 	}, nil
 }
 
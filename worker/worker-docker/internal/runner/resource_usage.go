@@ -0,0 +1,238 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	containerUsageResolveTimeout = 2 * time.Second
+	// containerUsageSampleInterval is how often a one-shot container is
+	// sampled while it runs; its cgroup is gone once it exits.
+	containerUsageSampleInterval = 100 * time.Millisecond
+)
+
+// hostCgroupRoot and hostProcRoot are where the worker reads container
+// cgroups on the host. Reading them from outside the container keeps the
+// figures out of reach of the code being metered.
+var (
+	hostCgroupRoot = "/sys/fs/cgroup"
+	hostProcRoot   = "/proc"
+)
+
+var resolveContainerCgroupFn = resolveContainerCgroup
+
+// commandUsage is what a command cost its container. Zero values mean the
+// worker could not measure them.
+type commandUsage struct {
+	CPUSeconds      float64
+	PeakMemoryBytes int64
+}
+
+// containerUsageSample is a cumulative reading of a container's cgroup.
+type containerUsageSample struct {
+	CPUUsec   int64
+	PeakBytes int64
+}
+
+// containerCgroup holds the host paths of a container's CPU and peak memory
+// counters: cpu.stat and memory.peak on cgroup v2, cpuacct.usage and
+// memory.max_usage_in_bytes on cgroup v1.
+type containerCgroup struct {
+	cpuPath  string
+	cpuV1    bool
+	peakPath string
+}
+
+// resolveContainerCgroup finds the cgroup of a running container through
+// the host /proc entry of its init process.
+func resolveContainerCgroup(ctx context.Context, containerName string) (containerCgroup, error) {
+	result := runDockerCommand(ctx, "inspect", "--format", "{{.State.Pid}}", containerName)
+	if result.Err != nil {
+		return containerCgroup{}, result.Err
+	}
+	if result.ExitCode != 0 {
+		return containerCgroup{}, errors.New(dockerCommandFailureMessage("exit code", result.ExitCode, result.Stderr))
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(result.Stdout))
+	if err != nil {
+		return containerCgroup{}, fmt.Errorf("unexpected docker inspect output: %q", strings.TrimSpace(result.Stdout))
+	}
+	if pid <= 0 {
+		return containerCgroup{}, errors.New("container is not running")
+	}
+
+	content, err := os.ReadFile(filepath.Join(hostProcRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return containerCgroup{}, err
+	}
+	cgroup, ok := parseProcCgroup(string(content))
+	if !ok {
+		return containerCgroup{}, errors.New("container cgroup not found")
+	}
+	return cgroup, nil
+}
+
+// parseProcCgroup maps a /proc/<pid>/cgroup listing to counter paths under
+// hostCgroupRoot, preferring the v1 controllers on hybrid hosts.
+func parseProcCgroup(content string) (containerCgroup, bool) {
+	unified := ""
+	cpuacct := ""
+	memory := ""
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		hierarchy, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		controllers, path, ok := strings.Cut(rest, ":")
+		if !ok {
+			continue
+		}
+		if hierarchy == "0" && controllers == "" {
+			unified = path
+			continue
+		}
+		for _, controller := range strings.Split(controllers, ",") {
+			switch controller {
+			case "cpuacct":
+				cpuacct = path
+			case "memory":
+				memory = path
+			}
+		}
+	}
+
+	if cpuacct != "" && memory != "" {
+		return containerCgroup{
+			cpuPath:  filepath.Join(hostCgroupRoot, "cpuacct", cpuacct, "cpuacct.usage"),
+			cpuV1:    true,
+			peakPath: filepath.Join(hostCgroupRoot, "memory", memory, "memory.max_usage_in_bytes"),
+		}, true
+	}
+	if unified != "" {
+		return containerCgroup{
+			cpuPath:  filepath.Join(hostCgroupRoot, unified, "cpu.stat"),
+			peakPath: filepath.Join(hostCgroupRoot, unified, "memory.peak"),
+		}, true
+	}
+	return containerCgroup{}, false
+}
+
+// read samples the counters. A counter that cannot be read is left at 0;
+// it fails only when neither can be read.
+func (c containerCgroup) read() (containerUsageSample, error) {
+	sample := containerUsageSample{}
+	cpuErr := c.readCPU(&sample)
+	peakErr := c.readPeak(&sample)
+	if cpuErr != nil && peakErr != nil {
+		return containerUsageSample{}, cpuErr
+	}
+	return sample, nil
+}
+
+func (c containerCgroup) readCPU(sample *containerUsageSample) error {
+	content, err := os.ReadFile(c.cpuPath)
+	if err != nil {
+		return err
+	}
+	if c.cpuV1 {
+		nanoseconds, err := parseCgroupCounter(string(content))
+		if err != nil {
+			return err
+		}
+		sample.CPUUsec = nanoseconds / 1000
+		return nil
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if ok && key == "usage_usec" {
+			usec, err := parseCgroupCounter(value)
+			if err != nil {
+				return err
+			}
+			sample.CPUUsec = usec
+			return nil
+		}
+	}
+	return errors.New("cpu.stat has no usage_usec")
+}
+
+func (c containerCgroup) readPeak(sample *containerUsageSample) error {
+	content, err := os.ReadFile(c.peakPath)
+	if err != nil {
+		return err
+	}
+	peak, err := parseCgroupCounter(string(content))
+	if err != nil {
+		return err
+	}
+	sample.PeakBytes = peak
+	return nil
+}
+
+func parseCgroupCounter(raw string) (int64, error) {
+	value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid cgroup counter %q", strings.TrimSpace(raw))
+	}
+	return value, nil
+}
+
+// watchContainerUsage samples a one-shot container from the host until the
+// returned stop function is called, which reports the last sample. Work
+// done after the last sample before the container exited is not counted.
+func watchContainerUsage(containerName string) func() commandUsage {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	last := containerUsageSample{}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(containerUsageSampleInterval)
+		defer ticker.Stop()
+		var cgroup *containerCgroup
+		for {
+			if cgroup == nil {
+				if resolved, err := resolveContainerCgroupFn(ctx, containerName); err == nil {
+					cgroup = &resolved
+				}
+			}
+			if cgroup != nil {
+				if sample, err := cgroup.read(); err == nil {
+					last.CPUUsec = max(last.CPUUsec, sample.CPUUsec)
+					last.PeakBytes = max(last.PeakBytes, sample.PeakBytes)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() commandUsage {
+		cancel()
+		<-done
+		return usageSince(containerUsageSample{}, last)
+	}
+}
+
+// usageSince turns two cumulative samples of the same container into what
+// happened in between. Peak memory is the container's peak so far.
+func usageSince(previous containerUsageSample, current containerUsageSample) commandUsage {
+	cpuUsec := current.CPUUsec - previous.CPUUsec
+	if cpuUsec < 0 {
+		cpuUsec = 0
+	}
+	return commandUsage{
+		CPUSeconds:      float64(cpuUsec) / 1e6,
+		PeakMemoryBytes: current.PeakBytes,
+	}
+}
@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// stubContainerCgroup replaces the docker lookup of container cgroups. A nil
+// cgroup makes every lookup fail as if the container were not running.
+func stubContainerCgroup(t *testing.T, cgroup *containerCgroup) {
+	t.Helper()
+	original := resolveContainerCgroupFn
+	t.Cleanup(func() {
+		resolveContainerCgroupFn = original
+	})
+	resolveContainerCgroupFn = func(context.Context, string) (containerCgroup, error) {
+		if cgroup == nil {
+			return containerCgroup{}, errors.New("container is not running")
+		}
+		return *cgroup, nil
+	}
+}
+
+func writeCgroupFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s failed: %v", path, err)
+	}
+}
+
+func TestParseProcCgroupResolvesHostCounterPaths(t *testing.T) {
+	originalRoot := hostCgroupRoot
+	t.Cleanup(func() {
+		hostCgroupRoot = originalRoot
+	})
+	hostCgroupRoot = "/host/cgroup"
+
+	cgroup, ok := parseProcCgroup("0::/system.slice/docker-abc.scope\n")
+	if !ok {
+		t.Fatalf("expected cgroup v2 entry to resolve")
+	}
+	if cgroup.cpuV1 || cgroup.cpuPath != "/host/cgroup/system.slice/docker-abc.scope/cpu.stat" ||
+		cgroup.peakPath != "/host/cgroup/system.slice/docker-abc.scope/memory.peak" {
+		t.Fatalf("unexpected cgroup v2 paths %#v", cgroup)
+	}
+
+	cgroup, ok = parseProcCgroup("12:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n0::/docker/abc\n")
+	if !ok {
+		t.Fatalf("expected cgroup v1 entries to resolve")
+	}
+	if !cgroup.cpuV1 || cgroup.cpuPath != "/host/cgroup/cpuacct/docker/abc/cpuacct.usage" ||
+		cgroup.peakPath != "/host/cgroup/memory/docker/abc/memory.max_usage_in_bytes" {
+		t.Fatalf("unexpected cgroup v1 paths %#v", cgroup)
+	}
+
+	if _, ok := parseProcCgroup("garbage\n"); ok {
+		t.Fatalf("expected listing without cgroup entries to be rejected")
+	}
+}
+
+func TestContainerCgroupReadsV1Counters(t *testing.T) {
+	dir := t.TempDir()
+	cgroup := containerCgroup{
+		cpuPath:  filepath.Join(dir, "cpuacct.usage"),
+		cpuV1:    true,
+		peakPath: filepath.Join(dir, "memory.max_usage_in_bytes"),
+	}
+	writeCgroupFile(t, cgroup.cpuPath, "1500000000\n")
+	writeCgroupFile(t, cgroup.peakPath, "2048\n")
+
+	sample, err := cgroup.read()
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if sample.CPUUsec != 1500000 || sample.PeakBytes != 2048 {
+		t.Fatalf("unexpected usage sample %#v", sample)
+	}
+
+	if err := os.Remove(cgroup.peakPath); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	sample, err = cgroup.read()
+	if err != nil || sample.CPUUsec != 1500000 || sample.PeakBytes != 0 {
+		t.Fatalf("expected missing peak to read as 0, got %#v err=%v", sample, err)
+	}
+
+	if _, err := (containerCgroup{cpuPath: filepath.Join(dir, "missing"), peakPath: filepath.Join(dir, "missing")}).read(); err == nil {
+		t.Fatalf("expected error when no counter can be read")
+	}
+}
+
+func TestWatchContainerUsageReportsLastSample(t *testing.T) {
+	dir := t.TempDir()
+	cgroup := containerCgroup{
+		cpuPath:  filepath.Join(dir, "cpu.stat"),
+		peakPath: filepath.Join(dir, "memory.peak"),
+	}
+	writeCgroupFile(t, cgroup.cpuPath, "usage_usec 250000\nuser_usec 200000\n")
+	writeCgroupFile(t, cgroup.peakPath, "8192\n")
+	stubContainerCgroup(t, &cgroup)
+
+	usage := watchContainerUsage("container-1")()
+	if usage.CPUSeconds != 0.25 || usage.PeakMemoryBytes != 8192 {
+		t.Fatalf("unexpected usage %#v", usage)
+	}
+}
+
+func TestTerminalSessionManagerChargesCPUDeltaPerCommand(t *testing.T) {
+	originalRunDockerCommand := runDockerCommand
+	t.Cleanup(func() {
+		runDockerCommand = originalRunDockerCommand
+	})
+	runDockerCommand = func(_ context.Context, args ...string) dockerCommandResult {
+		return dockerCommandResult{}
+	}
+
+	dir := t.TempDir()
+	cgroup := containerCgroup{
+		cpuPath:  filepath.Join(dir, "cpu.stat"),
+		peakPath: filepath.Join(dir, "memory.peak"),
+	}
+	stubContainerCgroup(t, &cgroup)
+
+	manager := newTerminalSessionManager(terminalSessionManagerConfig{
+		LeaseMinSec:      60,
+		LeaseMaxSec:      1800,
+		LeaseDefaultSec:  60,
+		OutputLimitBytes: 1024,
+	})
+	defer manager.Close()
+
+	writeCgroupFile(t, cgroup.cpuPath, "usage_usec 2000000\n")
+	writeCgroupFile(t, cgroup.peakPath, "1024\n")
+	first, err := manager.Execute(context.Background(), terminalExecRequest{Command: "make"})
+	if err != nil {
+		t.Fatalf("first execute failed: %v", err)
+	}
+	if first.Usage.CPUSeconds != 2 || first.Usage.PeakMemoryBytes != 1024 {
+		t.Fatalf("unexpected first usage %#v", first.Usage)
+	}
+
+	writeCgroupFile(t, cgroup.cpuPath, "usage_usec 2500000\n")
+	writeCgroupFile(t, cgroup.peakPath, "4096\n")
+	second, err := manager.Execute(context.Background(), terminalExecRequest{Command: "make test", SessionID: first.SessionID})
+	if err != nil {
+		t.Fatalf("second execute failed: %v", err)
+	}
+	if second.Usage.CPUSeconds != 0.5 || second.Usage.PeakMemoryBytes != 4096 {
+		t.Fatalf("expected only the added CPU time to be charged, got %#v", second.Usage)
+	}
+}
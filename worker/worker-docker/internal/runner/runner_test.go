@@ -436,6 +436,7 @@ func TestRunPythonExecInDockerWithImageUsesConfiguredImage(t *testing.T) {
 		runDockerCommand = originalRunDockerCommand
 		pythonExecContainerNameFn = originalContainerNameFn
 	})
+	stubContainerCgroup(t, nil)
 
 	pythonExecContainerNameFn = func() (string, error) {
 		return "container-custom-image", nil
@@ -470,6 +471,7 @@ func TestRunPythonExecInDockerReturnsNonZeroExitAsResult(t *testing.T) {
 		runDockerCommand = originalRunDockerCommand
 		pythonExecContainerNameFn = originalContainerNameFn
 	})
+	stubContainerCgroup(t, nil)
 
 	pythonExecContainerNameFn = func() (string, error) {
 		return "container-1", nil
@@ -526,6 +528,7 @@ func TestRunPythonExecInDockerTimeoutTriggersForceRemove(t *testing.T) {
 		runDockerCommand = originalRunDockerCommand
 		pythonExecContainerNameFn = originalContainerNameFn
 	})
+	stubContainerCgroup(t, nil)
 
 	pythonExecContainerNameFn = func() (string, error) {
 		return "container-timeout", nil
@@ -569,6 +572,7 @@ func TestRunPythonExecInDockerCreateFailureReturnsErrorWithoutCleanup(t *testing
 		runDockerCommand = originalRunDockerCommand
 		pythonExecContainerNameFn = originalContainerNameFn
 	})
+	stubContainerCgroup(t, nil)
 
 	pythonExecContainerNameFn = func() (string, error) {
 		return "container-create-fail", nil
@@ -603,6 +607,7 @@ func TestRunPythonExecInDockerStartFailureReturnsExecutionError(t *testing.T) {
 		runDockerCommand = originalRunDockerCommand
 		pythonExecContainerNameFn = originalContainerNameFn
 	})
+	stubContainerCgroup(t, nil)
 
 	pythonExecContainerNameFn = func() (string, error) {
 		return "container-start-fail", nil
@@ -652,6 +657,7 @@ func TestRunPythonExecInDockerCleanupFailureDoesNotOverrideDeadline(t *testing.T
 		runDockerCommand = originalRunDockerCommand
 		pythonExecContainerNameFn = originalContainerNameFn
 	})
+	stubContainerCgroup(t, nil)
 
 	pythonExecContainerNameFn = func() (string, error) {
 		return "container-cleanup-fail", nil
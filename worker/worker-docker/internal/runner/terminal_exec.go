@@ -55,6 +55,8 @@ type terminalExecRunResult struct {
 	StdoutTruncated    bool   `json:"stdout_truncated"`
 	StderrTruncated    bool   `json:"stderr_truncated"`
 	LeaseExpiresUnixMS int64  `json:"lease_expires_unix_ms"`
+
+	Usage commandUsage `json:"-"`
 }
 
 type terminalExecError struct {
@@ -88,6 +90,10 @@ type terminalSession struct {
 	containerName  string
 	leaseExpiresAt time.Time
 	busy           bool
+	// usage is the last cgroup sample, so each command is charged only the
+	// CPU time it added to the long-lived container.
+	usage  containerUsageSample
+	cgroup *containerCgroup
 }
 
 type terminalSessionManagerConfig struct {
@@ -309,6 +315,7 @@ func (m *terminalSessionManager) Execute(ctx context.Context, req terminalExecRe
 		return terminalExecRunResult{}, newTerminalExecError(terminalExecCodeSessionNotFound, terminalExecNoSessionMessage)
 	}
 
+	usage := m.sampleSessionUsage(session)
 	stdout, stdoutTruncated := truncateByBytes(execResult.Stdout, m.outputLimitBytes)
 	stderr, stderrTruncated := truncateByBytes(execResult.Stderr, m.outputLimitBytes)
 	leaseExpiresAt, ok := m.markSessionIdle(session.sessionID)
@@ -325,9 +332,41 @@ func (m *terminalSessionManager) Execute(ctx context.Context, req terminalExecRe
 		StdoutTruncated:    stdoutTruncated,
 		StderrTruncated:    stderrTruncated,
 		LeaseExpiresUnixMS: leaseExpiresAt.UnixMilli(),
+		Usage:              usage,
 	}, nil
 }
 
+// sampleSessionUsage charges the command that just finished with the CPU
+// time the session container used since the previous sample. It must be
+// called while the session is still marked busy.
+func (m *terminalSessionManager) sampleSessionUsage(session *terminalSession) commandUsage {
+	m.mu.Lock()
+	cgroup := session.cgroup
+	m.mu.Unlock()
+	if cgroup == nil {
+		resolveCtx, cancel := context.WithTimeout(context.Background(), containerUsageResolveTimeout)
+		resolved, err := resolveContainerCgroupFn(resolveCtx, session.containerName)
+		cancel()
+		if err != nil {
+			logging.Warnf("terminalExec usage sample failed: container=%s err=%v", session.containerName, err)
+			return commandUsage{}
+		}
+		cgroup = &resolved
+	}
+	sample, err := cgroup.read()
+	if err != nil {
+		logging.Warnf("terminalExec usage sample failed: container=%s err=%v", session.containerName, err)
+		return commandUsage{}
+	}
+
+	m.mu.Lock()
+	previous := session.usage
+	session.usage = sample
+	session.cgroup = cgroup
+	m.mu.Unlock()
+	return usageSince(previous, sample)
+}
+
 func (m *terminalSessionManager) resolveLeaseDuration(leaseTTLSec *int) (time.Duration, error) {
 	leaseSec := m.leaseDefaultSec
 	if leaseTTLSec != nil {
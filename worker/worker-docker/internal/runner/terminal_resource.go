@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/onlyboxes/onlyboxes/api/imagefit"
 )
 
 const (
@@ -18,26 +20,42 @@ const (
 	terminalResourceCodeFileNotFound   = "file_not_found"
 	terminalResourceCodePathIsDir      = "path_is_directory"
 	terminalResourceCodeFileTooLarge   = "file_too_large"
+	terminalResourceCodeImageTooLarge  = "image_too_large"
+	terminalResourceCodeUnsupported    = "unsupported_image"
 )
 
 type terminalResourcePayload struct {
 	SessionID string `json:"session_id"`
 	FilePath  string `json:"file_path"`
 	Action    string `json:"action,omitempty"`
+	MaxWidth  int    `json:"max_width,omitempty"`
+	MaxHeight int    `json:"max_height,omitempty"`
+	MaxBytes  int    `json:"max_bytes,omitempty"`
+	Format    string `json:"format,omitempty"`
 }
 
+// terminalResourceRequest carries optional image limits that apply only to
+// image files on the read action; zero values leave the file untouched.
 type terminalResourceRequest struct {
 	SessionID string
 	FilePath  string
 	Action    string
+	MaxWidth  int
+	MaxHeight int
+	MaxBytes  int
+	Format    string
 }
 
 type terminalResourceRunResult struct {
-	SessionID string `json:"session_id"`
-	FilePath  string `json:"file_path"`
-	MIMEType  string `json:"mime_type"`
-	SizeBytes int64  `json:"size_bytes"`
-	Blob      []byte `json:"blob,omitempty"`
+	SessionID      string `json:"session_id"`
+	FilePath       string `json:"file_path"`
+	MIMEType       string `json:"mime_type"`
+	SizeBytes      int64  `json:"size_bytes"`
+	Width          int    `json:"width,omitempty"`
+	Height         int    `json:"height,omitempty"`
+	OriginalWidth  int    `json:"original_width,omitempty"`
+	OriginalHeight int    `json:"original_height,omitempty"`
+	Blob           []byte `json:"blob,omitempty"`
 }
 
 type terminalResourceProbeResult struct {
@@ -122,6 +140,13 @@ func (m *terminalSessionManager) ResolveResource(ctx context.Context, req termin
 	if action == "" {
 		return terminalResourceRunResult{}, newTerminalExecError(terminalExecCodeInvalidPayload, "action must be validate or read")
 	}
+	format, ok := imagefit.NormalizeFormat(req.Format)
+	if !ok {
+		return terminalResourceRunResult{}, newTerminalExecError(terminalExecCodeInvalidPayload, "format must be png, jpeg or webp")
+	}
+	if req.MaxWidth < 0 || req.MaxHeight < 0 || req.MaxBytes < 0 {
+		return terminalResourceRunResult{}, newTerminalExecError(terminalExecCodeInvalidPayload, "max_width, max_height and max_bytes must not be negative")
+	}
 
 	m.mu.Lock()
 	session, ok := m.sessions[sessionID]
@@ -195,6 +220,33 @@ func (m *terminalSessionManager) ResolveResource(ctx context.Context, req termin
 		return terminalResourceRunResult{}, fmt.Errorf("decode resource blob: %w", err)
 	}
 	result.Blob = decoded
+	if !strings.HasPrefix(mimeType, "image/") {
+		return result, nil
+	}
+
+	fitted, err := imagefit.Fit(decoded, imagefit.Options{
+		MaxWidth:  req.MaxWidth,
+		MaxHeight: req.MaxHeight,
+		MaxBytes:  req.MaxBytes,
+		Format:    format,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, imagefit.ErrTooLarge):
+			return terminalResourceRunResult{}, newTerminalExecError(terminalResourceCodeImageTooLarge, "image does not fit the requested limits")
+		case errors.Is(err, imagefit.ErrUndecodable):
+			return terminalResourceRunResult{}, newTerminalExecError(terminalResourceCodeUnsupported, "image format cannot be converted")
+		default:
+			return terminalResourceRunResult{}, fmt.Errorf("convert image failed: %w", err)
+		}
+	}
+	if fitted.Reencoded {
+		result.MIMEType = fitted.MIMEType
+	}
+	result.Blob = fitted.Blob
+	result.SizeBytes = int64(len(fitted.Blob))
+	result.Width, result.Height = fitted.Width, fitted.Height
+	result.OriginalWidth, result.OriginalHeight = fitted.OriginalWidth, fitted.OriginalHeight
 	return result, nil
 }
 
@@ -1,9 +1,15 @@
 package runner
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
 	"reflect"
 	"testing"
 	"time"
@@ -161,6 +167,72 @@ func TestTerminalSessionManagerResolveResourceValidateAndRead(t *testing.T) {
 	}
 }
 
+func TestTerminalSessionManagerResolveResourceScalesImageOnRead(t *testing.T) {
+	source := image.NewRGBA(image.Rect(0, 0, 300, 150))
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, source); err != nil {
+		t.Fatalf("encode test png failed: %v", err)
+	}
+
+	originalRunDockerCommand := runDockerCommand
+	t.Cleanup(func() {
+		runDockerCommand = originalRunDockerCommand
+	})
+	runDockerCommand = func(_ context.Context, args ...string) dockerCommandResult {
+		if args[0] != "exec" {
+			return dockerCommandResult{ExitCode: 0}
+		}
+		return dockerCommandResult{
+			Stdout:   fmt.Sprintf(`{"mime_type":"image/png","size_bytes":%d,"blob":%q}`, encoded.Len(), base64.StdEncoding.EncodeToString(encoded.Bytes())),
+			ExitCode: 0,
+		}
+	}
+
+	manager := newTerminalSessionManager(terminalSessionManagerConfig{
+		LeaseMinSec:      60,
+		LeaseMaxSec:      1800,
+		LeaseDefaultSec:  60,
+		OutputLimitBytes: 1 << 20,
+	})
+	defer manager.Close()
+
+	manager.mu.Lock()
+	manager.sessions["sess-1"] = &terminalSession{
+		sessionID:      "sess-1",
+		containerName:  "container-1",
+		leaseExpiresAt: time.Now().Add(time.Minute),
+	}
+	manager.mu.Unlock()
+
+	result, err := manager.ResolveResource(context.Background(), terminalResourceRequest{
+		SessionID: "sess-1",
+		FilePath:  "/tmp/shot.png",
+		Action:    terminalResourceActionRead,
+		MaxWidth:  60,
+		Format:    "jpeg",
+	})
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if result.MIMEType != "image/jpeg" || result.Width != 60 || result.Height != 30 || result.OriginalWidth != 300 || result.OriginalHeight != 150 {
+		t.Fatalf("unexpected read result mime=%s size=%dx%d original=%dx%d", result.MIMEType, result.Width, result.Height, result.OriginalWidth, result.OriginalHeight)
+	}
+	if _, err := jpeg.DecodeConfig(bytes.NewReader(result.Blob)); err != nil {
+		t.Fatalf("expected jpeg blob: %v", err)
+	}
+
+	_, err = manager.ResolveResource(context.Background(), terminalResourceRequest{
+		SessionID: "sess-1",
+		FilePath:  "/tmp/shot.png",
+		Action:    terminalResourceActionRead,
+		Format:    "tiff",
+	})
+	var execErr *terminalExecError
+	if !errors.As(err, &execErr) || execErr.Code() != terminalExecCodeInvalidPayload {
+		t.Fatalf("expected invalid_payload for unknown format, got %v", err)
+	}
+}
+
 func TestTerminalSessionManagerResolveResourceDomainErrors(t *testing.T) {
 	originalRunDockerCommand := runDockerCommand
 	t.Cleanup(func() {
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/config"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/journal"
+)
+
+const journalUsage = `usage: worker-sys journal <show|verify> [flags]
+
+  show    print the most recent entries
+  verify  check MACs, the chain across all kept files and the head
+
+flags:`
+
+var journalCommandTextEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// runJournalCommand inspects the local execution journal. It only reads the
+// files, so it is safe to run next to a live worker.
+func runJournalCommand(cfg config.Config, args []string, out io.Writer, errOut io.Writer) int {
+	flags := flag.NewFlagSet("journal", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	path := flags.String("path", cfg.JournalPath, "journal file (default WORKER_JOURNAL_PATH)")
+	maxFiles := flags.Int("max-files", cfg.JournalMaxFiles, "rotated files kept (default WORKER_JOURNAL_MAX_FILES)")
+	limit := flags.Int("n", 20, "show: number of most recent entries, 0 for all")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, journalUsage)
+		flags.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		flags.Usage()
+		return 2
+	}
+	action := args[0]
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if strings.TrimSpace(*path) == "" {
+		_, _ = fmt.Fprintln(errOut, "journal path is required: set WORKER_JOURNAL_PATH or pass -path")
+		return 2
+	}
+
+	switch action {
+	case "show":
+		return showJournal(*path, *maxFiles, *limit, out, errOut)
+	case "verify":
+		if cfg.JournalKey == "" {
+			_, _ = fmt.Fprintln(errOut, "journal key is required: set WORKER_JOURNAL_KEY or WORKER_SECRET")
+			return 2
+		}
+		return verifyJournal(*path, *maxFiles, []byte(cfg.JournalKey), out, errOut)
+	default:
+		flags.Usage()
+		return 2
+	}
+}
+
+func showJournal(path string, maxFiles int, limit int, out io.Writer, errOut io.Writer) int {
+	entries := make([]journal.Entry, 0, max(limit, 0))
+	err := journal.ReadEntries(path, maxFiles, func(_ string, _ int, entry journal.Entry) error {
+		if limit > 0 && len(entries) == limit {
+			entries = append(entries[:0], entries[1:]...)
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(errOut, "read journal failed: %v\n", err)
+		return 1
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "SEQ\tTIME\tCAPABILITY\tCOMMAND ID\tEXIT\tERROR\tDURATION MS\tCOMMAND")
+	for _, entry := range entries {
+		exitCode := "-"
+		if entry.ExitCode != nil {
+			exitCode = strconv.Itoa(*entry.ExitCode)
+		}
+		errorCode := entry.ErrorCode
+		if errorCode == "" {
+			errorCode = "-"
+		}
+		_, _ = fmt.Fprintf(
+			writer,
+			"%d\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			entry.Seq,
+			entry.Time,
+			entry.Capability,
+			entry.CommandID,
+			exitCode,
+			errorCode,
+			entry.DurationMS,
+			journalCommandTextEscaper.Replace(entry.Command),
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		_, _ = fmt.Fprintf(errOut, "write journal entries failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func verifyJournal(path string, maxFiles int, key []byte, out io.Writer, errOut io.Writer) int {
+	result, err := journal.Verify(path, maxFiles, key)
+	if err != nil {
+		var verifyErr *journal.VerifyError
+		if errors.As(err, &verifyErr) {
+			_, _ = fmt.Fprintf(out, "journal is NOT intact: %v\n", verifyErr)
+			return 1
+		}
+		_, _ = fmt.Fprintf(errOut, "read journal failed: %v\n", err)
+		return 1
+	}
+	if result.Entries == 0 {
+		_, _ = fmt.Fprintln(out, "journal is empty")
+		return 0
+	}
+	_, _ = fmt.Fprintf(out, "journal is intact: %d entries in %d files, seq %d..%d\n", result.Entries, result.Files, result.FirstSeq, result.LastSeq)
+	if result.RotatedOut {
+		_, _ = fmt.Fprintf(out, "entries before seq %d were removed by rotation and are not covered\n", result.FirstSeq)
+	}
+	return 0
+}
@@ -3,17 +3,40 @@ package main
 import (
 	"context"
 	"errors"
+	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/config"
 	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/logging"
 	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/runner"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/sandbox"
 )
 
 func main() {
-	cfg := config.Load()
+	if sandbox.IsHelper() {
+		sandbox.RunHelper()
+	}
+
+	if err := runner.HideWorkerEnv(); err != nil {
+		logging.Fatalf("hide worker environment: %v", err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		logging.Fatalf("load config: %v", err)
+	}
 	logging.Configure(cfg.LogLevel, cfg.LogFormat, cfg.LogAddSource)
+	runner.UnsetWorkerEnv()
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "journal":
+			os.Exit(runJournalCommand(cfg, os.Args[2:], os.Stdout, os.Stderr))
+		default:
+			logging.Fatalf("unknown command: %s", os.Args[1])
+		}
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
@@ -9,6 +9,8 @@ require (
 )
 
 require (
+	github.com/HugoSmits86/nativewebp v1.2.0 // indirect
+	golang.org/x/image v0.34.0 // indirect
 	golang.org/x/net v0.48.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -11,19 +12,62 @@ import (
 )
 
 const (
-	defaultConsoleTarget             = "127.0.0.1:50051"
-	defaultHeartbeatIntervalSec      = 5
-	defaultHeartbeatJitterPct        = 20
-	defaultExecutorKind              = "sys"
-	defaultComputerUseOutputMaxByte  = 1024 * 1024
-	computerUseWhitelistModePrefix   = "prefix"
-	computerUseWhitelistModeExact    = "exact"
-	computerUseWhitelistModeAllowAll = "allow_all"
-	defaultLogLevel                  = "info"
-	defaultLogFormat                 = "json"
-	defaultLogAddSource              = false
+	defaultConsoleTarget              = "127.0.0.1:50051"
+	defaultHeartbeatIntervalSec       = 5
+	defaultHeartbeatJitterPct         = 20
+	defaultExecutorKind               = "sys"
+	defaultComputerUseOutputMaxByte   = 1024 * 1024
+	computerUseWhitelistModePrefix    = "prefix"
+	computerUseWhitelistModeExact     = "exact"
+	computerUseWhitelistModeAllowAll  = "allow_all"
+	computerUseWhitelistModeParsed    = "parsed"
+	computerUseExecModeShell          = "shell"
+	computerUseExecModeDirect         = "direct"
+	defaultComputerUseMaxInflight     = 1
+	maxComputerUseMaxInflight         = 8
+	defaultComputerUseSessionLeaseMin = 60
+	defaultComputerUseSessionLeaseMax = 1800
+	defaultComputerUseSessionLeaseTTL = 300
+	defaultFileReadMaxBytes           = 1024 * 1024
+	defaultFileWriteMaxBytes          = 1024 * 1024
+	defaultListDirMaxEntries          = 1000
+	defaultScreenshotTimeoutSec       = 10
+	defaultScreenshotMaxBytes         = 5 * 1024 * 1024
+	defaultJournalMaxBytes            = 10 * 1024 * 1024
+	defaultJournalMaxFiles            = 5
+	defaultLogLevel                   = "info"
+	defaultLogFormat                  = "json"
+	defaultLogAddSource               = false
 )
 
+// ComputerUseCommandRule is one entry of WORKER_COMPUTER_USE_COMMAND_RULES,
+// used by the parsed whitelist mode.
+type ComputerUseCommandRule struct {
+	Action  string   `json:"action"`
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	AnyArg  string   `json:"any_arg,omitempty"`
+}
+
+// ComputerUseSandboxConfig restricts how computerUse commands are executed.
+// Zero values leave the corresponding restriction disabled.
+type ComputerUseSandboxConfig struct {
+	RunAsUser         string
+	WorkDir           string
+	RlimitCPUSec      uint64
+	RlimitASBytes     uint64
+	RlimitFSizeBytes  uint64
+	RlimitNoFile      uint64
+	RlimitNProc       uint64
+	CgroupParent      string
+	CgroupMemoryBytes int64
+	CgroupPidsMax     int64
+	CgroupCPUPercent  int
+	PrivateTmp        bool
+	NoNetwork         bool
+	ReadOnlyPaths     []string
+}
+
 type Config struct {
 	ConsoleGRPCTarget          string
 	ConsoleTLS                 bool
@@ -39,20 +83,73 @@ type Config struct {
 	ComputerUseOutputLimitByte int
 	ComputerUseWhitelistMode   string
 	ComputerUseWhitelist       []string
-	ReadImageAllowedPaths      []string
-	LogLevel                   string
-	LogFormat                  string
-	LogAddSource               bool
+	ComputerUseCommandRules    []ComputerUseCommandRule
+	ComputerUseAllowedSyntax   []string
+	ComputerUseExecMode        string
+	ComputerUseSandbox         ComputerUseSandboxConfig
+	ComputerUseEnvPassthrough  []string
+	ComputerUseEnvOverrides    map[string]string
+	// ComputerUseMaxSessions is 0 when persistent sessions are disabled.
+	ComputerUseMaxSessions            int
+	ComputerUseMaxInflight            int
+	ComputerUseSessionLeaseMinSec     int
+	ComputerUseSessionLeaseMaxSec     int
+	ComputerUseSessionLeaseDefaultSec int
+	// ReadImageAllowedPaths gates readImage only. FileReadAllowedPaths gates
+	// readFile and listDir; FileWriteAllowedPaths gates writeFile. Each list
+	// denies everything when empty and none of them widens another.
+	ReadImageAllowedPaths []string
+	FileReadAllowedPaths  []string
+	FileWriteAllowedPaths []string
+	FileReadMaxBytes      int
+	FileWriteMaxBytes     int
+	ListDirMaxEntries     int
+	// ScreenshotCommand is the capture argv; the screenshot capability is
+	// declared only when it is set. A "{file}" argument is replaced with a
+	// temporary output path, otherwise the image is read from stdout.
+	ScreenshotCommand    []string
+	ScreenshotTimeoutSec int
+	// ScreenshotMaxWidth and ScreenshotMaxHeight are 0 when captures are
+	// not scaled.
+	ScreenshotMaxWidth  int
+	ScreenshotMaxHeight int
+	ScreenshotMaxBytes  int
+	// JournalPath is empty when the local execution journal is disabled.
+	// JournalMaxBytes is the size at which the file rotates and
+	// JournalMaxFiles how many rotated files are kept.
+	// JournalKey signs the journal chain; it defaults to WorkerSecret.
+	JournalPath     string
+	JournalKey      string
+	JournalMaxBytes int
+	JournalMaxFiles int
+	LogLevel        string
+	LogFormat       string
+	LogAddSource    bool
 }
 
-func Load() Config {
+// Load reads the worker configuration from the environment. Most values fall
+// back to defaults when malformed; values that widen what commands may run,
+// such as command rules, are rejected instead.
+func Load() (Config, error) {
 	heartbeatSec := parsePositiveIntEnv("WORKER_HEARTBEAT_INTERVAL_SEC", defaultHeartbeatIntervalSec)
 	heartbeatJitter := parsePercentEnv("WORKER_HEARTBEAT_JITTER_PCT", defaultHeartbeatJitterPct)
 	callTimeoutSec := parsePositiveIntEnv("WORKER_CALL_TIMEOUT_SEC", defaultCallTimeoutSec(heartbeatSec))
 	outputLimit := parsePositiveIntEnv("WORKER_COMPUTER_USE_OUTPUT_LIMIT_BYTES", defaultComputerUseOutputMaxByte)
 	whitelistMode := parseComputerUseWhitelistMode(os.Getenv("WORKER_COMPUTER_USE_COMMAND_WHITELIST_MODE"))
 	whitelist := parseComputerUseWhitelist(os.Getenv("WORKER_COMPUTER_USE_COMMAND_WHITELIST"))
-	readImageAllowedPaths := parseReadImageAllowedPaths(os.Getenv("WORKER_READ_IMAGE_ALLOWED_PATHS"))
+	sessionLeaseMinSec := parsePositiveIntEnv("WORKER_COMPUTER_USE_SESSION_LEASE_MIN_SEC", defaultComputerUseSessionLeaseMin)
+	sessionLeaseMaxSec := parsePositiveIntEnv("WORKER_COMPUTER_USE_SESSION_LEASE_MAX_SEC", defaultComputerUseSessionLeaseMax)
+	if sessionLeaseMaxSec < sessionLeaseMinSec {
+		sessionLeaseMaxSec = sessionLeaseMinSec
+	}
+	sessionLeaseDefaultSec := parsePositiveIntEnv("WORKER_COMPUTER_USE_SESSION_LEASE_DEFAULT_SEC", defaultComputerUseSessionLeaseTTL)
+	sessionLeaseDefaultSec = min(max(sessionLeaseDefaultSec, sessionLeaseMinSec), sessionLeaseMaxSec)
+	maxInflight := min(parsePositiveIntEnv("WORKER_COMPUTER_USE_MAX_INFLIGHT", defaultComputerUseMaxInflight), maxComputerUseMaxInflight)
+	readImageAllowedPaths := parsePathList(os.Getenv("WORKER_READ_IMAGE_ALLOWED_PATHS"))
+	commandRules, err := parseComputerUseCommandRules(os.Getenv("WORKER_COMPUTER_USE_COMMAND_RULES"))
+	if err != nil {
+		return Config{}, err
+	}
 
 	defaultVersion := strings.TrimSpace(buildinfo.Version)
 	if defaultVersion == "" {
@@ -60,25 +157,50 @@ func Load() Config {
 	}
 
 	return Config{
-		ConsoleGRPCTarget:          getEnv("WORKER_CONSOLE_GRPC_TARGET", defaultConsoleTarget),
-		ConsoleTLS:                 os.Getenv("WORKER_CONSOLE_INSECURE") != "true",
-		WorkerID:                   strings.TrimSpace(os.Getenv("WORKER_ID")),
-		WorkerSecret:               strings.TrimSpace(os.Getenv("WORKER_SECRET")),
-		HeartbeatInterval:          time.Duration(heartbeatSec) * time.Second,
-		HeartbeatJitter:            heartbeatJitter,
-		CallTimeout:                time.Duration(callTimeoutSec) * time.Second,
-		NodeName:                   os.Getenv("WORKER_NODE_NAME"),
-		ExecutorKind:               defaultExecutorKind,
-		Version:                    getEnv("WORKER_VERSION", defaultVersion),
-		Labels:                     parseLabels(os.Getenv("WORKER_LABELS")),
-		ComputerUseOutputLimitByte: outputLimit,
-		ComputerUseWhitelistMode:   whitelistMode,
-		ComputerUseWhitelist:       whitelist,
-		ReadImageAllowedPaths:      readImageAllowedPaths,
-		LogLevel:                   parseLogLevelEnv("WORKER_LOG_LEVEL", defaultLogLevel),
-		LogFormat:                  parseLogFormatEnv("WORKER_LOG_FORMAT", defaultLogFormat),
-		LogAddSource:               parseBoolEnv("WORKER_LOG_ADD_SOURCE", defaultLogAddSource),
-	}
+		ConsoleGRPCTarget:                 getEnv("WORKER_CONSOLE_GRPC_TARGET", defaultConsoleTarget),
+		ConsoleTLS:                        os.Getenv("WORKER_CONSOLE_INSECURE") != "true",
+		WorkerID:                          strings.TrimSpace(os.Getenv("WORKER_ID")),
+		WorkerSecret:                      strings.TrimSpace(os.Getenv("WORKER_SECRET")),
+		HeartbeatInterval:                 time.Duration(heartbeatSec) * time.Second,
+		HeartbeatJitter:                   heartbeatJitter,
+		CallTimeout:                       time.Duration(callTimeoutSec) * time.Second,
+		NodeName:                          os.Getenv("WORKER_NODE_NAME"),
+		ExecutorKind:                      defaultExecutorKind,
+		Version:                           getEnv("WORKER_VERSION", defaultVersion),
+		Labels:                            parseLabels(os.Getenv("WORKER_LABELS")),
+		ComputerUseOutputLimitByte:        outputLimit,
+		ComputerUseWhitelistMode:          whitelistMode,
+		ComputerUseWhitelist:              whitelist,
+		ComputerUseCommandRules:           commandRules,
+		ComputerUseAllowedSyntax:          parseComputerUseAllowedSyntax(os.Getenv("WORKER_COMPUTER_USE_ALLOWED_SHELL_SYNTAX")),
+		ComputerUseExecMode:               parseComputerUseExecMode(os.Getenv("WORKER_COMPUTER_USE_EXEC_MODE")),
+		ComputerUseSandbox:                loadComputerUseSandbox(),
+		ComputerUseEnvPassthrough:         parseEnvNameList(os.Getenv("WORKER_COMPUTER_USE_ENV_PASSTHROUGH")),
+		ComputerUseEnvOverrides:           parseEnvOverrides(os.Getenv("WORKER_COMPUTER_USE_ENV_OVERRIDES")),
+		ComputerUseMaxSessions:            parsePositiveIntEnv("WORKER_COMPUTER_USE_MAX_SESSIONS", 0),
+		ComputerUseMaxInflight:            maxInflight,
+		ComputerUseSessionLeaseMinSec:     sessionLeaseMinSec,
+		ComputerUseSessionLeaseMaxSec:     sessionLeaseMaxSec,
+		ComputerUseSessionLeaseDefaultSec: sessionLeaseDefaultSec,
+		ReadImageAllowedPaths:             readImageAllowedPaths,
+		FileReadAllowedPaths:              parsePathList(os.Getenv("WORKER_FILE_READ_ALLOWED_PATHS")),
+		FileWriteAllowedPaths:             parsePathList(os.Getenv("WORKER_FILE_WRITE_ALLOWED_PATHS")),
+		FileReadMaxBytes:                  parsePositiveIntEnv("WORKER_FILE_READ_MAX_BYTES", defaultFileReadMaxBytes),
+		FileWriteMaxBytes:                 parsePositiveIntEnv("WORKER_FILE_WRITE_MAX_BYTES", defaultFileWriteMaxBytes),
+		ListDirMaxEntries:                 parsePositiveIntEnv("WORKER_LIST_DIR_MAX_ENTRIES", defaultListDirMaxEntries),
+		ScreenshotCommand:                 parseCommandArgv(os.Getenv("WORKER_SCREENSHOT_COMMAND")),
+		ScreenshotTimeoutSec:              parsePositiveIntEnv("WORKER_SCREENSHOT_TIMEOUT_SEC", defaultScreenshotTimeoutSec),
+		ScreenshotMaxWidth:                parsePositiveIntEnv("WORKER_SCREENSHOT_MAX_WIDTH", 0),
+		ScreenshotMaxHeight:               parsePositiveIntEnv("WORKER_SCREENSHOT_MAX_HEIGHT", 0),
+		ScreenshotMaxBytes:                parsePositiveIntEnv("WORKER_SCREENSHOT_MAX_BYTES", defaultScreenshotMaxBytes),
+		JournalPath:                       strings.TrimSpace(os.Getenv("WORKER_JOURNAL_PATH")),
+		JournalKey:                        getEnv("WORKER_JOURNAL_KEY", strings.TrimSpace(os.Getenv("WORKER_SECRET"))),
+		JournalMaxBytes:                   parsePositiveIntEnv("WORKER_JOURNAL_MAX_BYTES", defaultJournalMaxBytes),
+		JournalMaxFiles:                   parsePositiveIntEnv("WORKER_JOURNAL_MAX_FILES", defaultJournalMaxFiles),
+		LogLevel:                          parseLogLevelEnv("WORKER_LOG_LEVEL", defaultLogLevel),
+		LogFormat:                         parseLogFormatEnv("WORKER_LOG_FORMAT", defaultLogFormat),
+		LogAddSource:                      parseBoolEnv("WORKER_LOG_ADD_SOURCE", defaultLogAddSource),
+	}, nil
 }
 
 func getEnv(key string, defaultValue string) string {
@@ -187,7 +309,7 @@ func parseLabels(raw string) map[string]string {
 func parseComputerUseWhitelistMode(raw string) string {
 	mode := strings.ToLower(strings.TrimSpace(raw))
 	switch mode {
-	case computerUseWhitelistModePrefix, computerUseWhitelistModeExact, computerUseWhitelistModeAllowAll:
+	case computerUseWhitelistModePrefix, computerUseWhitelistModeExact, computerUseWhitelistModeAllowAll, computerUseWhitelistModeParsed:
 		return mode
 	default:
 		return computerUseWhitelistModeExact
@@ -221,7 +343,123 @@ func parseComputerUseWhitelist(raw string) []string {
 	return result
 }
 
-func parseReadImageAllowedPaths(raw string) []string {
+func parseComputerUseExecMode(raw string) string {
+	mode := strings.ToLower(strings.TrimSpace(raw))
+	switch mode {
+	case computerUseExecModeShell, computerUseExecModeDirect:
+		return mode
+	default:
+		return computerUseExecModeShell
+	}
+}
+
+func parseComputerUseCommandRules(raw string) ([]ComputerUseCommandRule, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []ComputerUseCommandRule{}, nil
+	}
+	decoded := []ComputerUseCommandRule{}
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return nil, fmt.Errorf("invalid WORKER_COMPUTER_USE_COMMAND_RULES: %w", err)
+	}
+	return decoded, nil
+}
+
+func parseComputerUseAllowedSyntax(raw string) []string {
+	result := []string{}
+	seen := map[string]struct{}{}
+	for _, part := range strings.Split(raw, ",") {
+		value := strings.ToLower(strings.TrimSpace(part))
+		switch value {
+		case "pipe", "list", "background", "redirect", "substitution", "expansion", "assignment", "glob":
+		default:
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	return result
+}
+
+// parseEnvNameList parses a comma separated list of variable names; a
+// trailing "*" is kept as a prefix wildcard.
+func parseEnvNameList(raw string) []string {
+	result := []string{}
+	seen := map[string]struct{}{}
+	for _, part := range strings.Split(raw, ",") {
+		value := strings.TrimSpace(part)
+		if value == "" || strings.ContainsAny(value, "= \t") {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		result = append(result, value)
+	}
+	return result
+}
+
+// parseEnvOverrides parses a JSON object of variable values. Invalid JSON
+// yields no overrides.
+func parseEnvOverrides(raw string) map[string]string {
+	if strings.TrimSpace(raw) == "" {
+		return map[string]string{}
+	}
+	decoded := map[string]string{}
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return map[string]string{}
+	}
+	result := make(map[string]string, len(decoded))
+	for name, value := range decoded {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, "= \t") {
+			continue
+		}
+		result[name] = value
+	}
+	return result
+}
+
+func loadComputerUseSandbox() ComputerUseSandboxConfig {
+	return ComputerUseSandboxConfig{
+		RunAsUser:         strings.TrimSpace(os.Getenv("WORKER_COMPUTER_USE_RUN_AS_USER")),
+		WorkDir:           strings.TrimSpace(os.Getenv("WORKER_COMPUTER_USE_WORKDIR")),
+		RlimitCPUSec:      uint64(parsePositiveIntEnv("WORKER_COMPUTER_USE_RLIMIT_CPU_SEC", 0)),
+		RlimitASBytes:     uint64(parsePositiveIntEnv("WORKER_COMPUTER_USE_RLIMIT_AS_BYTES", 0)),
+		RlimitFSizeBytes:  uint64(parsePositiveIntEnv("WORKER_COMPUTER_USE_RLIMIT_FSIZE_BYTES", 0)),
+		RlimitNoFile:      uint64(parsePositiveIntEnv("WORKER_COMPUTER_USE_RLIMIT_NOFILE", 0)),
+		RlimitNProc:       uint64(parsePositiveIntEnv("WORKER_COMPUTER_USE_RLIMIT_NPROC", 0)),
+		CgroupParent:      strings.TrimSpace(os.Getenv("WORKER_COMPUTER_USE_CGROUP_PARENT")),
+		CgroupMemoryBytes: int64(parsePositiveIntEnv("WORKER_COMPUTER_USE_CGROUP_MEMORY_MAX_BYTES", 0)),
+		CgroupPidsMax:     int64(parsePositiveIntEnv("WORKER_COMPUTER_USE_CGROUP_PIDS_MAX", 0)),
+		CgroupCPUPercent:  parsePositiveIntEnv("WORKER_COMPUTER_USE_CGROUP_CPU_PCT", 0),
+		PrivateTmp:        parseBoolEnv("WORKER_COMPUTER_USE_PRIVATE_TMP", false),
+		NoNetwork:         parseBoolEnv("WORKER_COMPUTER_USE_NO_NETWORK", false),
+		ReadOnlyPaths:     parsePathList(os.Getenv("WORKER_COMPUTER_USE_READONLY_PATHS")),
+	}
+}
+
+// parseCommandArgv parses a JSON string array used as argv. Invalid JSON or
+// an empty program yields no command.
+func parseCommandArgv(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return []string{}
+	}
+	decoded := []string{}
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		return []string{}
+	}
+	if len(decoded) == 0 || strings.TrimSpace(decoded[0]) == "" {
+		return []string{}
+	}
+	decoded[0] = strings.TrimSpace(decoded[0])
+	return decoded
+}
+
+func parsePathList(raw string) []string {
 	if strings.TrimSpace(raw) == "" {
 		return []string{}
 	}
@@ -2,16 +2,26 @@ package config
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/buildinfo"
 )
 
+func mustLoad(t *testing.T) Config {
+	t.Helper()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	return cfg
+}
+
 func TestLoadUsesBuildVersionByDefault(t *testing.T) {
 	t.Setenv("WORKER_VERSION", "")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.Version != buildinfo.Version {
 		t.Fatalf("expected default worker version %q, got %q", buildinfo.Version, cfg.Version)
 	}
@@ -20,7 +30,7 @@ func TestLoadUsesBuildVersionByDefault(t *testing.T) {
 func TestLoadSupportsCustomVersion(t *testing.T) {
 	t.Setenv("WORKER_VERSION", "v1.2.3-custom")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.Version != "v1.2.3-custom" {
 		t.Fatalf("expected custom worker version, got %q", cfg.Version)
 	}
@@ -29,7 +39,7 @@ func TestLoadSupportsCustomVersion(t *testing.T) {
 func TestLoadUsesComputerUseOutputLimitEnv(t *testing.T) {
 	t.Setenv("WORKER_COMPUTER_USE_OUTPUT_LIMIT_BYTES", "2048")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.ComputerUseOutputLimitByte != 2048 {
 		t.Fatalf("expected output limit 2048, got %d", cfg.ComputerUseOutputLimitByte)
 	}
@@ -38,7 +48,7 @@ func TestLoadUsesComputerUseOutputLimitEnv(t *testing.T) {
 func TestLoadUsesDefaultComputerUseWhitelistMode(t *testing.T) {
 	t.Setenv("WORKER_COMPUTER_USE_COMMAND_WHITELIST_MODE", "")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.ComputerUseWhitelistMode != "exact" {
 		t.Fatalf("expected default whitelist mode exact, got %q", cfg.ComputerUseWhitelistMode)
 	}
@@ -47,7 +57,7 @@ func TestLoadUsesDefaultComputerUseWhitelistMode(t *testing.T) {
 func TestLoadNormalizesComputerUseWhitelistMode(t *testing.T) {
 	t.Setenv("WORKER_COMPUTER_USE_COMMAND_WHITELIST_MODE", "  PREFIX ")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.ComputerUseWhitelistMode != "prefix" {
 		t.Fatalf("expected whitelist mode prefix, got %q", cfg.ComputerUseWhitelistMode)
 	}
@@ -56,7 +66,7 @@ func TestLoadNormalizesComputerUseWhitelistMode(t *testing.T) {
 func TestLoadFallsBackComputerUseWhitelistModeOnInvalidValue(t *testing.T) {
 	t.Setenv("WORKER_COMPUTER_USE_COMMAND_WHITELIST_MODE", "bad_mode")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.ComputerUseWhitelistMode != "exact" {
 		t.Fatalf("expected fallback whitelist mode exact, got %q", cfg.ComputerUseWhitelistMode)
 	}
@@ -65,7 +75,7 @@ func TestLoadFallsBackComputerUseWhitelistModeOnInvalidValue(t *testing.T) {
 func TestLoadParsesComputerUseWhitelist(t *testing.T) {
 	t.Setenv("WORKER_COMPUTER_USE_COMMAND_WHITELIST", `[" echo ","time","","echo"]`)
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	want := []string{"echo", "time"}
 	if !reflect.DeepEqual(cfg.ComputerUseWhitelist, want) {
 		t.Fatalf("unexpected whitelist: want=%v got=%v", want, cfg.ComputerUseWhitelist)
@@ -75,16 +85,58 @@ func TestLoadParsesComputerUseWhitelist(t *testing.T) {
 func TestLoadUsesEmptyComputerUseWhitelistWhenInvalid(t *testing.T) {
 	t.Setenv("WORKER_COMPUTER_USE_COMMAND_WHITELIST", `{"not":"array"}`)
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if len(cfg.ComputerUseWhitelist) != 0 {
 		t.Fatalf("expected empty whitelist, got %v", cfg.ComputerUseWhitelist)
 	}
 }
 
+func TestLoadParsesComputerUseCommandPolicy(t *testing.T) {
+	t.Setenv("WORKER_COMPUTER_USE_COMMAND_WHITELIST_MODE", "parsed")
+	t.Setenv("WORKER_COMPUTER_USE_COMMAND_RULES", `[{"action":"deny","command":"rm","any_arg":"-rf"},{"command":"git","args":["status","..."]}]`)
+	t.Setenv("WORKER_COMPUTER_USE_ALLOWED_SHELL_SYNTAX", " Pipe,list,bogus,pipe,GLOB ")
+	t.Setenv("WORKER_COMPUTER_USE_EXEC_MODE", "DIRECT")
+
+	cfg := mustLoad(t)
+	if cfg.ComputerUseWhitelistMode != "parsed" {
+		t.Fatalf("expected whitelist mode parsed, got %q", cfg.ComputerUseWhitelistMode)
+	}
+	expectedRules := []ComputerUseCommandRule{
+		{Action: "deny", Command: "rm", AnyArg: "-rf"},
+		{Command: "git", Args: []string{"status", "..."}},
+	}
+	if !reflect.DeepEqual(cfg.ComputerUseCommandRules, expectedRules) {
+		t.Fatalf("unexpected command rules: %#v", cfg.ComputerUseCommandRules)
+	}
+	if !reflect.DeepEqual(cfg.ComputerUseAllowedSyntax, []string{"pipe", "list", "glob"}) {
+		t.Fatalf("unexpected allowed syntax: %#v", cfg.ComputerUseAllowedSyntax)
+	}
+	if cfg.ComputerUseExecMode != "direct" {
+		t.Fatalf("expected exec mode direct, got %q", cfg.ComputerUseExecMode)
+	}
+}
+
+func TestLoadRejectsInvalidComputerUseCommandRules(t *testing.T) {
+	t.Setenv("WORKER_COMPUTER_USE_COMMAND_RULES", `{"command":"ls"}`)
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "WORKER_COMPUTER_USE_COMMAND_RULES") {
+		t.Fatalf("expected invalid command rules to be rejected, got %v", err)
+	}
+}
+
+func TestLoadFallsBackComputerUseExecModeOnInvalidValue(t *testing.T) {
+	t.Setenv("WORKER_COMPUTER_USE_EXEC_MODE", "exec")
+
+	cfg := mustLoad(t)
+	if cfg.ComputerUseExecMode != "shell" {
+		t.Fatalf("expected exec mode shell, got %q", cfg.ComputerUseExecMode)
+	}
+}
+
 func TestLoadUsesEmptyReadImageAllowedPathsByDefault(t *testing.T) {
 	t.Setenv("WORKER_READ_IMAGE_ALLOWED_PATHS", "")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if len(cfg.ReadImageAllowedPaths) != 0 {
 		t.Fatalf("expected empty readImage allowed paths, got %v", cfg.ReadImageAllowedPaths)
 	}
@@ -93,7 +145,7 @@ func TestLoadUsesEmptyReadImageAllowedPathsByDefault(t *testing.T) {
 func TestLoadParsesReadImageAllowedPaths(t *testing.T) {
 	t.Setenv("WORKER_READ_IMAGE_ALLOWED_PATHS", `[" /data/images ","/tmp/a.png","","/tmp/a.png"]`)
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	want := []string{"/data/images", "/tmp/a.png"}
 	if !reflect.DeepEqual(cfg.ReadImageAllowedPaths, want) {
 		t.Fatalf("unexpected readImage allowed paths: want=%v got=%v", want, cfg.ReadImageAllowedPaths)
@@ -103,17 +155,86 @@ func TestLoadParsesReadImageAllowedPaths(t *testing.T) {
 func TestLoadUsesEmptyReadImageAllowedPathsWhenInvalid(t *testing.T) {
 	t.Setenv("WORKER_READ_IMAGE_ALLOWED_PATHS", `{"not":"array"}`)
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if len(cfg.ReadImageAllowedPaths) != 0 {
 		t.Fatalf("expected empty readImage allowed paths, got %v", cfg.ReadImageAllowedPaths)
 	}
 }
 
+func TestLoadParsesFileAccessConfig(t *testing.T) {
+	t.Setenv("WORKER_FILE_READ_ALLOWED_PATHS", `["/srv/data/"]`)
+	t.Setenv("WORKER_FILE_WRITE_ALLOWED_PATHS", `["/srv/data/out"]`)
+	t.Setenv("WORKER_FILE_READ_MAX_BYTES", "4096")
+	t.Setenv("WORKER_FILE_WRITE_MAX_BYTES", "-1")
+	t.Setenv("WORKER_LIST_DIR_MAX_ENTRIES", "50")
+
+	cfg := mustLoad(t)
+	if !reflect.DeepEqual(cfg.FileReadAllowedPaths, []string{"/srv/data/"}) {
+		t.Fatalf("unexpected read allowed paths %v", cfg.FileReadAllowedPaths)
+	}
+	if !reflect.DeepEqual(cfg.FileWriteAllowedPaths, []string{"/srv/data/out"}) {
+		t.Fatalf("unexpected write allowed paths %v", cfg.FileWriteAllowedPaths)
+	}
+	if cfg.FileReadMaxBytes != 4096 || cfg.FileWriteMaxBytes != defaultFileWriteMaxBytes || cfg.ListDirMaxEntries != 50 {
+		t.Fatalf("unexpected file limits read=%d write=%d entries=%d", cfg.FileReadMaxBytes, cfg.FileWriteMaxBytes, cfg.ListDirMaxEntries)
+	}
+}
+
+func TestLoadParsesScreenshotConfig(t *testing.T) {
+	t.Setenv("WORKER_SCREENSHOT_COMMAND", `[" grim ","-t","png","{file}"]`)
+	t.Setenv("WORKER_SCREENSHOT_MAX_WIDTH", "1280")
+	t.Setenv("WORKER_SCREENSHOT_MAX_HEIGHT", "")
+
+	cfg := mustLoad(t)
+	if !reflect.DeepEqual(cfg.ScreenshotCommand, []string{"grim", "-t", "png", "{file}"}) {
+		t.Fatalf("unexpected screenshot command %v", cfg.ScreenshotCommand)
+	}
+	if cfg.ScreenshotMaxWidth != 1280 || cfg.ScreenshotMaxHeight != 0 {
+		t.Fatalf("unexpected screenshot bounds %dx%d", cfg.ScreenshotMaxWidth, cfg.ScreenshotMaxHeight)
+	}
+	if cfg.ScreenshotTimeoutSec != defaultScreenshotTimeoutSec || cfg.ScreenshotMaxBytes != defaultScreenshotMaxBytes {
+		t.Fatalf("unexpected screenshot defaults timeout=%d max_bytes=%d", cfg.ScreenshotTimeoutSec, cfg.ScreenshotMaxBytes)
+	}
+
+	t.Setenv("WORKER_SCREENSHOT_COMMAND", `["", "x"]`)
+	if cfg := mustLoad(t); len(cfg.ScreenshotCommand) != 0 {
+		t.Fatalf("expected blank program to disable screenshot, got %v", cfg.ScreenshotCommand)
+	}
+}
+
+func TestLoadParsesJournalConfig(t *testing.T) {
+	t.Setenv("WORKER_JOURNAL_PATH", "")
+	if cfg := mustLoad(t); cfg.JournalPath != "" || cfg.JournalMaxBytes != defaultJournalMaxBytes || cfg.JournalMaxFiles != defaultJournalMaxFiles {
+		t.Fatalf("unexpected journal defaults path=%q max_bytes=%d max_files=%d", cfg.JournalPath, cfg.JournalMaxBytes, cfg.JournalMaxFiles)
+	}
+
+	t.Setenv("WORKER_JOURNAL_PATH", " /var/lib/onlyboxes/journal.jsonl ")
+	t.Setenv("WORKER_JOURNAL_MAX_BYTES", "4096")
+	t.Setenv("WORKER_JOURNAL_MAX_FILES", "0")
+	cfg := mustLoad(t)
+	if cfg.JournalPath != "/var/lib/onlyboxes/journal.jsonl" || cfg.JournalMaxBytes != 4096 || cfg.JournalMaxFiles != defaultJournalMaxFiles {
+		t.Fatalf("unexpected journal config path=%q max_bytes=%d max_files=%d", cfg.JournalPath, cfg.JournalMaxBytes, cfg.JournalMaxFiles)
+	}
+}
+
+func TestLoadDefaultsJournalKeyToWorkerSecret(t *testing.T) {
+	t.Setenv("WORKER_SECRET", " worker-secret ")
+	t.Setenv("WORKER_JOURNAL_KEY", "")
+	if cfg := mustLoad(t); cfg.JournalKey != "worker-secret" {
+		t.Fatalf("expected journal key to default to WORKER_SECRET, got %q", cfg.JournalKey)
+	}
+
+	t.Setenv("WORKER_JOURNAL_KEY", "journal-key")
+	if cfg := mustLoad(t); cfg.JournalKey != "journal-key" {
+		t.Fatalf("expected WORKER_JOURNAL_KEY, got %q", cfg.JournalKey)
+	}
+}
+
 func TestLoadUsesDynamicCallTimeoutDefault(t *testing.T) {
 	t.Setenv("WORKER_HEARTBEAT_INTERVAL_SEC", "5")
 	t.Setenv("WORKER_CALL_TIMEOUT_SEC", "")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.CallTimeout != 13*time.Second {
 		t.Fatalf("expected dynamic default call timeout 13s, got %s", cfg.CallTimeout)
 	}
@@ -123,7 +244,7 @@ func TestLoadUsesDynamicCallTimeoutDefaultFromHeartbeat(t *testing.T) {
 	t.Setenv("WORKER_HEARTBEAT_INTERVAL_SEC", "7")
 	t.Setenv("WORKER_CALL_TIMEOUT_SEC", "")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.CallTimeout != 18*time.Second {
 		t.Fatalf("expected dynamic default call timeout 18s, got %s", cfg.CallTimeout)
 	}
@@ -133,7 +254,7 @@ func TestLoadKeepsExplicitCallTimeout(t *testing.T) {
 	t.Setenv("WORKER_HEARTBEAT_INTERVAL_SEC", "5")
 	t.Setenv("WORKER_CALL_TIMEOUT_SEC", "9")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.CallTimeout != 9*time.Second {
 		t.Fatalf("expected explicit call timeout 9s, got %s", cfg.CallTimeout)
 	}
@@ -144,7 +265,7 @@ func TestLoadUsesDefaultLogConfig(t *testing.T) {
 	t.Setenv("WORKER_LOG_FORMAT", "")
 	t.Setenv("WORKER_LOG_ADD_SOURCE", "")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.LogLevel != defaultLogLevel {
 		t.Fatalf("expected default log level %q, got %q", defaultLogLevel, cfg.LogLevel)
 	}
@@ -161,7 +282,7 @@ func TestLoadSupportsCustomLogConfig(t *testing.T) {
 	t.Setenv("WORKER_LOG_FORMAT", "text")
 	t.Setenv("WORKER_LOG_ADD_SOURCE", "true")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.LogLevel != "debug" {
 		t.Fatalf("expected custom log level debug, got %q", cfg.LogLevel)
 	}
@@ -178,7 +299,7 @@ func TestLoadFallsBackInvalidLogConfig(t *testing.T) {
 	t.Setenv("WORKER_LOG_FORMAT", "yaml")
 	t.Setenv("WORKER_LOG_ADD_SOURCE", "invalid")
 
-	cfg := Load()
+	cfg := mustLoad(t)
 	if cfg.LogLevel != defaultLogLevel {
 		t.Fatalf("expected fallback log level %q, got %q", defaultLogLevel, cfg.LogLevel)
 	}
@@ -212,3 +333,72 @@ func TestParseLabelsMixedEntries(t *testing.T) {
 		t.Fatalf("unexpected labels: want=%v got=%v", want, labels)
 	}
 }
+
+func TestLoadParsesComputerUseSandbox(t *testing.T) {
+	t.Setenv("WORKER_COMPUTER_USE_RUN_AS_USER", " nobody ")
+	t.Setenv("WORKER_COMPUTER_USE_WORKDIR", "/srv/work")
+	t.Setenv("WORKER_COMPUTER_USE_RLIMIT_CPU_SEC", "30")
+	t.Setenv("WORKER_COMPUTER_USE_RLIMIT_NOFILE", "-1")
+	t.Setenv("WORKER_COMPUTER_USE_CGROUP_PARENT", "/sys/fs/cgroup/onlyboxes")
+	t.Setenv("WORKER_COMPUTER_USE_CGROUP_MEMORY_MAX_BYTES", "268435456")
+	t.Setenv("WORKER_COMPUTER_USE_CGROUP_CPU_PCT", "50")
+	t.Setenv("WORKER_COMPUTER_USE_PRIVATE_TMP", "true")
+	t.Setenv("WORKER_COMPUTER_USE_NO_NETWORK", "bad")
+	t.Setenv("WORKER_COMPUTER_USE_READONLY_PATHS", `["/etc","","/etc","/usr"]`)
+
+	cfg := mustLoad(t)
+	want := ComputerUseSandboxConfig{
+		RunAsUser:         "nobody",
+		WorkDir:           "/srv/work",
+		RlimitCPUSec:      30,
+		CgroupParent:      "/sys/fs/cgroup/onlyboxes",
+		CgroupMemoryBytes: 268435456,
+		CgroupCPUPercent:  50,
+		PrivateTmp:        true,
+		ReadOnlyPaths:     []string{"/etc", "/usr"},
+	}
+	if !reflect.DeepEqual(cfg.ComputerUseSandbox, want) {
+		t.Fatalf("unexpected sandbox config: want=%#v got=%#v", want, cfg.ComputerUseSandbox)
+	}
+}
+
+func TestLoadParsesComputerUseEnvPolicy(t *testing.T) {
+	t.Setenv("WORKER_COMPUTER_USE_ENV_PASSTHROUGH", " TERM, LC_*,,TERM,BAD NAME,A=B ")
+	t.Setenv("WORKER_COMPUTER_USE_ENV_OVERRIDES", `{"LANG":"C.UTF-8"," EDITOR ":"vi","":"x"}`)
+
+	cfg := mustLoad(t)
+	if want := []string{"TERM", "LC_*"}; !reflect.DeepEqual(cfg.ComputerUseEnvPassthrough, want) {
+		t.Fatalf("unexpected passthrough: want=%v got=%v", want, cfg.ComputerUseEnvPassthrough)
+	}
+	if want := map[string]string{"LANG": "C.UTF-8", "EDITOR": "vi"}; !reflect.DeepEqual(cfg.ComputerUseEnvOverrides, want) {
+		t.Fatalf("unexpected overrides: want=%v got=%v", want, cfg.ComputerUseEnvOverrides)
+	}
+
+	t.Setenv("WORKER_COMPUTER_USE_ENV_OVERRIDES", `["not","object"]`)
+	if cfg := mustLoad(t); len(cfg.ComputerUseEnvOverrides) != 0 {
+		t.Fatalf("expected invalid overrides to be ignored, got %v", cfg.ComputerUseEnvOverrides)
+	}
+}
+
+func TestLoadParsesComputerUseSessions(t *testing.T) {
+	t.Setenv("WORKER_COMPUTER_USE_MAX_SESSIONS", "4")
+	t.Setenv("WORKER_COMPUTER_USE_MAX_INFLIGHT", "99")
+	t.Setenv("WORKER_COMPUTER_USE_SESSION_LEASE_MIN_SEC", "120")
+	t.Setenv("WORKER_COMPUTER_USE_SESSION_LEASE_MAX_SEC", "60")
+	t.Setenv("WORKER_COMPUTER_USE_SESSION_LEASE_DEFAULT_SEC", "30")
+
+	cfg := mustLoad(t)
+	if cfg.ComputerUseMaxSessions != 4 || cfg.ComputerUseMaxInflight != 8 {
+		t.Fatalf("unexpected session limits: sessions=%d inflight=%d", cfg.ComputerUseMaxSessions, cfg.ComputerUseMaxInflight)
+	}
+	if cfg.ComputerUseSessionLeaseMinSec != 120 || cfg.ComputerUseSessionLeaseMaxSec != 120 || cfg.ComputerUseSessionLeaseDefaultSec != 120 {
+		t.Fatalf("unexpected lease bounds: %d/%d/%d", cfg.ComputerUseSessionLeaseMinSec, cfg.ComputerUseSessionLeaseMaxSec, cfg.ComputerUseSessionLeaseDefaultSec)
+	}
+}
+
+func TestLoadDisablesComputerUseSessionsByDefault(t *testing.T) {
+	cfg := mustLoad(t)
+	if cfg.ComputerUseMaxSessions != 0 || cfg.ComputerUseMaxInflight != 1 || cfg.ComputerUseSessionLeaseDefaultSec != 300 {
+		t.Fatalf("unexpected defaults: sessions=%d inflight=%d lease=%d", cfg.ComputerUseMaxSessions, cfg.ComputerUseMaxInflight, cfg.ComputerUseSessionLeaseDefaultSec)
+	}
+}
@@ -0,0 +1,460 @@
+// Package journal keeps an append-only, hash-chained JSONL record of the
+// commands a worker-sys host executed on behalf of the console.
+//
+// Every entry carries an HMAC-SHA256 over its content and the MAC of the
+// previous entry, so editing, reordering or removing a line breaks the chain
+// and is reported by Verify. The key is kept outside the journal, so a
+// process that can write the file cannot recompute the chain. The newest
+// sequence number and MAC are also recorded in <path>.head, which exposes
+// lines cut from the end. The journal rotates by size; rotated files are
+// named <path>.1 (newest) through <path>.<MaxFiles> (oldest) and the chain
+// continues across them.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxBytes = 10 * 1024 * 1024
+	defaultMaxFiles = 5
+	// maxLineBytes bounds a single entry when reading the journal back.
+	maxLineBytes = 1024 * 1024
+)
+
+// Entry is one executed command. Output is never stored, only its SHA-256
+// and size as returned to the console (after truncation).
+type Entry struct {
+	Seq        uint64 `json:"seq"`
+	Time       string `json:"time"`
+	CommandID  string `json:"command_id"`
+	Capability string `json:"capability"`
+	// Command is the human-readable command text, e.g. the shell command or
+	// "read <path>". writeFile content is never included.
+	Command         string `json:"command,omitempty"`
+	SessionID       string `json:"session_id,omitempty"`
+	ExitCode        *int   `json:"exit_code,omitempty"`
+	ErrorCode       string `json:"error_code,omitempty"`
+	DurationMS      int64  `json:"duration_ms"`
+	StdoutSHA256    string `json:"stdout_sha256,omitempty"`
+	StdoutBytes     int    `json:"stdout_bytes,omitempty"`
+	StdoutTruncated bool   `json:"stdout_truncated,omitempty"`
+	StderrSHA256    string `json:"stderr_sha256,omitempty"`
+	StderrBytes     int    `json:"stderr_bytes,omitempty"`
+	StderrTruncated bool   `json:"stderr_truncated,omitempty"`
+	// ResultSHA256 covers the whole result payload of capabilities without
+	// stdout/stderr, such as readFile or screenshot.
+	ResultSHA256 string `json:"result_sha256,omitempty"`
+	ResultBytes  int    `json:"result_bytes,omitempty"`
+	PrevHash     string `json:"prev_hash"`
+	Hash         string `json:"hash"`
+}
+
+// SHA256Hex returns the hex SHA-256 of data, the form used for output
+// hashes in Entry.
+func SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// entryMAC is the HMAC-SHA256 of the entry encoded with an empty Hash. The
+// encoding includes PrevHash, which links the entry to its predecessor.
+func entryMAC(key []byte, entry Entry) (string, error) {
+	entry.Hash = ""
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	return macHex(key, encoded), nil
+}
+
+func macHex(key []byte, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validMAC compares in constant time; want comes from the journal file.
+func validMAC(got string, want string) bool {
+	return hmac.Equal([]byte(got), []byte(want))
+}
+
+type Options struct {
+	Path string
+	// MaxBytes is the size at which the current file is rotated.
+	MaxBytes int64
+	// MaxFiles is how many rotated files are kept; older ones are deleted.
+	MaxFiles int
+	// Key is the HMAC key of the chain. It must not be stored next to the
+	// journal, or whoever can edit the file can also re-sign it.
+	Key []byte
+}
+
+// Journal appends entries to Options.Path. It is safe for concurrent use.
+type Journal struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	maxFiles int
+	key      []byte
+	file     *os.File
+	size     int64
+	seq      uint64
+	lastHash string
+	now      func() time.Time
+}
+
+// Open opens or creates the journal and resumes the chain from its last
+// entry. It fails when that entry cannot be read or authenticated, or when
+// entries recorded in the head are missing, since appending after a damaged
+// tail would hide the damage.
+func Open(opts Options) (*Journal, error) {
+	if opts.Path == "" {
+		return nil, errors.New("journal path is required")
+	}
+	if len(opts.Key) == 0 {
+		return nil, errors.New("journal key is required")
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = defaultMaxBytes
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = defaultMaxFiles
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0o700); err != nil {
+		return nil, fmt.Errorf("create journal directory: %w", err)
+	}
+
+	j := &Journal{
+		path:     opts.Path,
+		maxBytes: opts.MaxBytes,
+		maxFiles: opts.MaxFiles,
+		key:      opts.Key,
+		now:      time.Now,
+	}
+	last, found, err := lastEntry(j.path, j.maxFiles)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		mac, err := entryMAC(j.key, last)
+		if err != nil {
+			return nil, fmt.Errorf("encode journal entry: %w", err)
+		}
+		if !validMAC(mac, last.Hash) {
+			return nil, fmt.Errorf("journal entry %d does not match the journal key", last.Seq)
+		}
+		j.seq = last.Seq
+		j.lastHash = last.Hash
+	}
+	recorded, headFound, err := readHead(j.path, j.key)
+	if err != nil {
+		return nil, err
+	}
+	if reason := checkHead(recorded, headFound, j.seq, j.lastHash); reason != "" {
+		return nil, errors.New("journal " + reason)
+	}
+	if err := j.openCurrent(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Path returns the current journal file.
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Append fills in Seq, Time, PrevHash and Hash and writes the entry as one
+// line, rotating first when the line would exceed MaxBytes.
+func (j *Journal) Append(entry Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return errors.New("journal is closed")
+	}
+
+	entry.Seq = j.seq + 1
+	if entry.Time == "" {
+		entry.Time = j.now().UTC().Format(time.RFC3339Nano)
+	}
+	entry.PrevHash = j.lastHash
+	hash, err := entryMAC(j.key, entry)
+	if err != nil {
+		return fmt.Errorf("encode journal entry: %w", err)
+	}
+	entry.Hash = hash
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode journal entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	if j.size > 0 && j.size+int64(len(line)) > j.maxBytes {
+		if err := j.rotate(); err != nil {
+			return err
+		}
+	}
+	written, err := j.file.Write(line)
+	j.size += int64(written)
+	if err != nil {
+		return fmt.Errorf("write journal entry: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("sync journal: %w", err)
+	}
+	j.seq = entry.Seq
+	j.lastHash = entry.Hash
+	if err := writeHead(j.path, j.key, entry.Seq, entry.Hash); err != nil {
+		return fmt.Errorf("write journal head: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+func (j *Journal) openCurrent() error {
+	file, err := os.OpenFile(j.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat journal: %w", err)
+	}
+	j.file = file
+	j.size = info.Size()
+	return nil
+}
+
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return fmt.Errorf("close journal for rotation: %w", err)
+	}
+	j.file = nil
+	if err := os.Remove(rotatedPath(j.path, j.maxFiles)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove oldest journal: %w", err)
+	}
+	for i := j.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedPath(j.path, i), rotatedPath(j.path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("rotate journal: %w", err)
+		}
+	}
+	if err := os.Rename(j.path, rotatedPath(j.path, 1)); err != nil {
+		return fmt.Errorf("rotate journal: %w", err)
+	}
+	return j.openCurrent()
+}
+
+// head records the newest entry outside the rotated files. It is written
+// after the entry, so a crash in between leaves it one entry behind, which
+// is accepted; a head ahead of the entries means lines were cut.
+type head struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+	MAC  string `json:"mac"`
+}
+
+func headPath(path string) string {
+	return path + ".head"
+}
+
+func headMAC(key []byte, seq uint64, hash string) string {
+	return macHex(key, []byte("head\n"+strconv.FormatUint(seq, 10)+"\n"+hash))
+}
+
+// writeHead replaces the head file through a temporary file and rename, so
+// it is never seen half written.
+func writeHead(path string, key []byte, seq uint64, hash string) error {
+	payload, err := json.Marshal(head{Seq: seq, Hash: hash, MAC: headMAC(key, seq, hash)})
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(headPath(path))+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tempPath := temp.Name()
+	_, err = temp.Write(append(payload, '\n'))
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, headPath(path))
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+	}
+	return err
+}
+
+// readHead returns the recorded head; found is false when there is no head
+// file yet.
+func readHead(path string, key []byte) (head, bool, error) {
+	payload, err := os.ReadFile(headPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return head{}, false, nil
+	}
+	if err != nil {
+		return head{}, false, fmt.Errorf("read journal head: %w", err)
+	}
+	var recorded head
+	if err := json.Unmarshal(payload, &recorded); err != nil {
+		return head{}, false, &VerifyError{File: headPath(path), Reason: "malformed head: " + err.Error()}
+	}
+	if !validMAC(headMAC(key, recorded.Seq, recorded.Hash), recorded.MAC) {
+		return head{}, false, &VerifyError{File: headPath(path), Reason: "head does not match the journal key"}
+	}
+	return recorded, true, nil
+}
+
+// checkHead compares the newest entry with the head and returns why they
+// disagree, or "" when they are consistent.
+func checkHead(recorded head, found bool, lastSeq uint64, lastHash string) string {
+	switch {
+	case !found && lastSeq > 0:
+		return "head file is missing"
+	case !found:
+		return ""
+	case lastSeq < recorded.Seq:
+		return fmt.Sprintf("is missing entries up to seq %d recorded in its head", recorded.Seq)
+	case lastSeq == recorded.Seq && lastHash != recorded.Hash:
+		return fmt.Sprintf("entry %d does not match its head", lastSeq)
+	default:
+		return ""
+	}
+}
+
+func rotatedPath(path string, index int) string {
+	return path + "." + strconv.Itoa(index)
+}
+
+// Files returns the existing journal files, oldest first.
+func Files(path string, maxFiles int) []string {
+	if maxFiles <= 0 {
+		maxFiles = defaultMaxFiles
+	}
+	files := make([]string, 0, maxFiles+1)
+	for i := maxFiles; i >= 1; i-- {
+		if _, err := os.Stat(rotatedPath(path, i)); err == nil {
+			files = append(files, rotatedPath(path, i))
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files
+}
+
+// lastEntry returns the newest entry across the journal files.
+func lastEntry(path string, maxFiles int) (Entry, bool, error) {
+	files := Files(path, maxFiles)
+	for i := len(files) - 1; i >= 0; i-- {
+		var last Entry
+		found := false
+		err := readEntries(files[i], func(_ int, entry Entry) error {
+			last, found = entry, true
+			return nil
+		})
+		if err != nil {
+			return Entry{}, false, err
+		}
+		if found {
+			return last, true, nil
+		}
+	}
+	return Entry{}, false, nil
+}
+
+// ReadEntries calls fn for every entry of every journal file, oldest first.
+func ReadEntries(path string, maxFiles int, fn func(file string, line int, entry Entry) error) error {
+	for _, file := range Files(path, maxFiles) {
+		err := readEntries(file, func(line int, entry Entry) error {
+			return fn(file, line, entry)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readEntries(file string, fn func(line int, entry Entry) error) error {
+	handle, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("open journal: %w", err)
+	}
+	defer handle.Close()
+
+	reader := bufio.NewReaderSize(handle, 64*1024)
+	for line := 1; ; line++ {
+		raw, err := readLine(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		var entry Entry
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&entry); err != nil {
+			return fmt.Errorf("%s:%d: malformed entry: %w", file, line, err)
+		}
+		if err := fn(line, entry); err != nil {
+			return err
+		}
+	}
+}
+
+// readLine returns the next line without its newline. A final line without
+// a newline is a torn write and reported as such.
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxLineBytes {
+			return nil, errors.New("entry exceeds maximum line size")
+		}
+		switch {
+		case err == nil:
+			return line[:len(line)-1], nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF) && len(line) > 0:
+			return nil, errors.New("incomplete last entry")
+		default:
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package journal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testJournalKey = []byte("journal-test-key")
+
+func openTestJournal(t *testing.T, path string, maxBytes int64) *Journal {
+	t.Helper()
+	j, err := Open(Options{Path: path, MaxBytes: maxBytes, MaxFiles: 3, Key: testJournalKey})
+	if err != nil {
+		t.Fatalf("open journal failed: %v", err)
+	}
+	t.Cleanup(func() { _ = j.Close() })
+	return j
+}
+
+func appendTestEntries(t *testing.T, j *Journal, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		exitCode := i
+		if err := j.Append(Entry{
+			CommandID:    "cmd",
+			Capability:   "computeruse",
+			Command:      "echo hello",
+			ExitCode:     &exitCode,
+			StdoutSHA256: SHA256Hex([]byte("hello\n")),
+			StdoutBytes:  6,
+		}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+}
+
+func TestJournalChainsEntriesAndResumesAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTestJournal(t, path, 0)
+	appendTestEntries(t, j, 3)
+	if err := j.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	reopened := openTestJournal(t, path, 0)
+	appendTestEntries(t, reopened, 2)
+
+	result, err := Verify(path, 3, testJournalKey)
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if result.Entries != 5 || result.FirstSeq != 1 || result.LastSeq != 5 || result.RotatedOut {
+		t.Fatalf("unexpected verify result %#v", result)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat journal failed: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected journal mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestJournalRotatesAndKeepsChainAcrossFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTestJournal(t, path, 600)
+	appendTestEntries(t, j, 20)
+
+	files := Files(path, 3)
+	if len(files) != 4 || files[len(files)-1] != path {
+		t.Fatalf("expected current plus 3 rotated files, got %v", files)
+	}
+	result, err := Verify(path, 3, testJournalKey)
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if !result.RotatedOut || result.LastSeq != 20 || result.FirstSeq == 1 {
+		t.Fatalf("expected chain verified from a rotated-out anchor, got %#v", result)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	for name, tamper := range map[string]func([]byte) []byte{
+		"edited field": func(content []byte) []byte {
+			return bytes.Replace(content, []byte(`"echo hello"`), []byte(`"echo hallo"`), 1)
+		},
+		"removed first line": func(content []byte) []byte {
+			_, rest, _ := bytes.Cut(content, []byte("\n"))
+			return rest
+		},
+		"removed line": func(content []byte) []byte {
+			lines := bytes.SplitAfter(content, []byte("\n"))
+			return bytes.Join(append(lines[:1:1], lines[2:]...), nil)
+		},
+		"swapped lines": func(content []byte) []byte {
+			lines := bytes.SplitAfter(content, []byte("\n"))
+			lines[0], lines[1] = lines[1], lines[0]
+			return bytes.Join(lines, nil)
+		},
+		"removed last line": func(content []byte) []byte {
+			lines := bytes.SplitAfter(content, []byte("\n"))
+			return bytes.Join(lines[:len(lines)-2], nil)
+		},
+		"emptied file": func([]byte) []byte {
+			return nil
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "journal.jsonl")
+			j := openTestJournal(t, path, 0)
+			appendTestEntries(t, j, 3)
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read journal failed: %v", err)
+			}
+			if err := os.WriteFile(path, tamper(content), 0o600); err != nil {
+				t.Fatalf("write journal failed: %v", err)
+			}
+
+			_, err = Verify(path, 3, testJournalKey)
+			var verifyErr *VerifyError
+			if !errors.As(err, &verifyErr) {
+				t.Fatalf("expected VerifyError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsTornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTestJournal(t, path, 0)
+	appendTestEntries(t, j, 1)
+	if err := j.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("open journal failed: %v", err)
+	}
+	_, _ = file.WriteString(`{"seq":2,`)
+	_ = file.Close()
+
+	if _, err := Open(Options{Path: path, Key: testJournalKey}); err == nil {
+		t.Fatalf("expected open to fail on a torn last entry")
+	}
+}
+
+func TestVerifyRequiresTheJournalKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTestJournal(t, path, 0)
+	appendTestEntries(t, j, 2)
+
+	_, err := Verify(path, 3, []byte("other-key"))
+	var verifyErr *VerifyError
+	if !errors.As(err, &verifyErr) || verifyErr.Seq != 1 {
+		t.Fatalf("expected first entry to fail under another key, got %v", err)
+	}
+	if _, err := Open(Options{Path: path, Key: []byte("other-key")}); err == nil {
+		t.Fatalf("expected open to fail under another key")
+	}
+}
+
+func TestJournalHeadDetectsTailTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTestJournal(t, path, 0)
+	appendTestEntries(t, j, 3)
+	if err := j.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read journal failed: %v", err)
+	}
+	lines := bytes.SplitAfter(content, []byte("\n"))
+	if err := os.WriteFile(path, bytes.Join(lines[:2], nil), 0o600); err != nil {
+		t.Fatalf("write journal failed: %v", err)
+	}
+
+	_, err = Verify(path, 3, testJournalKey)
+	var verifyErr *VerifyError
+	if !errors.As(err, &verifyErr) || verifyErr.File != headPath(path) {
+		t.Fatalf("expected head mismatch, got %v", err)
+	}
+	if _, err := Open(Options{Path: path, Key: testJournalKey}); err == nil {
+		t.Fatalf("expected open to fail when entries recorded in the head are missing")
+	}
+
+	if err := os.Remove(headPath(path)); err != nil {
+		t.Fatalf("remove head failed: %v", err)
+	}
+	if _, err := Verify(path, 3, testJournalKey); !errors.As(err, &verifyErr) {
+		t.Fatalf("expected a missing head to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// VerifyResult summarizes a journal whose chain is intact.
+type VerifyResult struct {
+	Files    int
+	Entries  int
+	FirstSeq uint64
+	LastSeq  uint64
+	// RotatedOut is true when the oldest kept entry links to an entry that
+	// was deleted by rotation; the chain is verified from that point on.
+	RotatedOut bool
+}
+
+// VerifyError locates the first entry that breaks the chain. Line is 0 for
+// problems with the head file.
+type VerifyError struct {
+	File   string
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Reason)
+	}
+	return fmt.Sprintf("%s:%d: seq %d: %s", e.File, e.Line, e.Seq, e.Reason)
+}
+
+// Verify checks every kept entry: its MAC under key, its link to the
+// previous entry and that sequence numbers have no gaps, then that the
+// newest entry matches the head. It returns a *VerifyError for the first
+// broken entry, or another error when a file cannot be read.
+func Verify(path string, maxFiles int, key []byte) (VerifyResult, error) {
+	if len(key) == 0 {
+		return VerifyResult{}, errors.New("journal key is required")
+	}
+	if maxFiles <= 0 {
+		maxFiles = defaultMaxFiles
+	}
+	result := VerifyResult{Files: len(Files(path, maxFiles))}
+	var prev *Entry
+	err := ReadEntries(path, maxFiles, func(file string, line int, entry Entry) error {
+		fail := func(reason string) error {
+			return &VerifyError{File: file, Line: line, Seq: entry.Seq, Reason: reason}
+		}
+		hash, err := entryMAC(key, entry)
+		if err != nil {
+			return fail(err.Error())
+		}
+		if !validMAC(hash, entry.Hash) {
+			return fail("hash does not match entry content or journal key")
+		}
+		if prev == nil {
+			result.FirstSeq = entry.Seq
+			result.RotatedOut = entry.PrevHash != ""
+			if entry.PrevHash == "" && entry.Seq != 1 {
+				return fail("chain starts without a predecessor after seq 1")
+			}
+		} else {
+			if entry.PrevHash != prev.Hash {
+				return fail("prev_hash does not match the previous entry")
+			}
+			if entry.Seq != prev.Seq+1 {
+				return fail(fmt.Sprintf("seq does not follow %d", prev.Seq))
+			}
+		}
+		result.Entries++
+		result.LastSeq = entry.Seq
+		prev = &entry
+		return nil
+	})
+	if err != nil {
+		var verifyErr *VerifyError
+		if errors.As(err, &verifyErr) {
+			return result, verifyErr
+		}
+		return result, err
+	}
+	// Rotation deletes entries only once every rotated slot is taken, so a
+	// missing start with free slots means lines were removed.
+	if result.RotatedOut && result.Files < maxFiles+1 {
+		files := Files(path, maxFiles)
+		return result, &VerifyError{File: files[0], Line: 1, Seq: result.FirstSeq, Reason: "earlier entries are missing but the journal was never rotated that far"}
+	}
+	recorded, found, err := readHead(path, key)
+	if err != nil {
+		return result, err
+	}
+	var lastHash string
+	if prev != nil {
+		lastHash = prev.Hash
+	}
+	if reason := checkHead(recorded, found, result.LastSeq, lastHash); reason != "" {
+		return result, &VerifyError{File: headPath(path), Reason: "journal " + reason}
+	}
+	return result, nil
+}
@@ -13,6 +13,10 @@ import (
 
 var runComputerUse = runComputerUseUnavailable
 var runReadImage = runReadImageUnavailable
+var runReadFile = runReadFileUnavailable
+var runWriteFile = runWriteFileUnavailable
+var runListDir = runListDirUnavailable
+var runScreenshot = runScreenshotUnavailable
 
 func buildCommandResult(dispatch *registryv1.CommandDispatch) *registryv1.ConnectRequest {
 	return buildCommandResultWithContext(context.Background(), dispatch)
@@ -44,6 +48,14 @@ func buildCommandResultWithContext(baseCtx context.Context, dispatch *registryv1
 		return buildComputerUseCommandResult(baseCtx, commandID, dispatch)
 	case readImageCapabilityName:
 		return buildReadImageCommandResult(baseCtx, commandID, dispatch)
+	case readFileCapabilityName:
+		return buildReadFileCommandResult(baseCtx, commandID, dispatch)
+	case writeFileCapabilityName:
+		return buildWriteFileCommandResult(baseCtx, commandID, dispatch)
+	case listDirCapabilityName:
+		return buildListDirCommandResult(baseCtx, commandID, dispatch)
+	case screenshotCapabilityName:
+		return buildScreenshotCommandResult(baseCtx, commandID, dispatch)
 	default:
 		return commandErrorResult(commandID, "unsupported_capability", fmt.Sprintf("capability %q is not supported", dispatch.GetCapability()))
 	}
@@ -73,7 +85,12 @@ func buildComputerUseCommandResult(baseCtx context.Context, commandID string, di
 	}
 	defer cancel()
 
-	execResult, err := runComputerUse(commandCtx, computerUseRequest{Command: decoded.Command})
+	execResult, err := runComputerUse(commandCtx, computerUseRequest{
+		Command:         decoded.Command,
+		SessionID:       decoded.SessionID,
+		CreateIfMissing: decoded.CreateIfMissing,
+		LeaseTTLSec:     decoded.LeaseTTLSec,
+	})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 			return commandErrorResult(commandID, "deadline_exceeded", "command deadline exceeded")
@@ -96,6 +113,8 @@ func buildComputerUseCommandResult(baseCtx context.Context, commandID string, di
 				CommandId:       commandID,
 				PayloadJson:     resultPayload,
 				CompletedUnixMs: time.Now().UnixMilli(),
+				CpuSeconds:      execResult.Usage.CPUSeconds,
+				PeakMemoryBytes: execResult.Usage.PeakMemoryBytes,
 			},
 		},
 	}
@@ -129,6 +148,10 @@ func buildReadImageCommandResult(baseCtx context.Context, commandID string, disp
 		SessionID: decoded.SessionID,
 		FilePath:  decoded.FilePath,
 		Action:    decoded.Action,
+		MaxWidth:  decoded.MaxWidth,
+		MaxHeight: decoded.MaxHeight,
+		MaxBytes:  decoded.MaxBytes,
+		Format:    decoded.Format,
 	})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
@@ -157,6 +180,147 @@ func buildReadImageCommandResult(baseCtx context.Context, commandID string, disp
 	}
 }
 
+func buildReadFileCommandResult(baseCtx context.Context, commandID string, dispatch *registryv1.CommandDispatch) *registryv1.ConnectRequest {
+	decoded := readFilePayload{}
+	if err := json.Unmarshal(dispatch.GetPayloadJson(), &decoded); err != nil {
+		return commandErrorResult(commandID, fileAccessCodeInvalidPayload, "payload_json is not valid readFile payload")
+	}
+	if strings.TrimSpace(decoded.SessionID) == "" || strings.TrimSpace(decoded.FilePath) == "" {
+		return commandErrorResult(commandID, fileAccessCodeInvalidPayload, "readFile session_id and file_path are required")
+	}
+	return buildFileAccessCommandResult(baseCtx, commandID, dispatch, readFileCapabilityDeclared, func(ctx context.Context) (any, error) {
+		return runReadFile(ctx, readFileRequest{
+			SessionID: decoded.SessionID,
+			FilePath:  decoded.FilePath,
+			Offset:    decoded.Offset,
+			Limit:     decoded.Limit,
+		})
+	})
+}
+
+func buildWriteFileCommandResult(baseCtx context.Context, commandID string, dispatch *registryv1.CommandDispatch) *registryv1.ConnectRequest {
+	decoded := writeFilePayload{}
+	if err := json.Unmarshal(dispatch.GetPayloadJson(), &decoded); err != nil {
+		return commandErrorResult(commandID, fileAccessCodeInvalidPayload, "payload_json is not valid writeFile payload")
+	}
+	if strings.TrimSpace(decoded.SessionID) == "" || strings.TrimSpace(decoded.FilePath) == "" {
+		return commandErrorResult(commandID, fileAccessCodeInvalidPayload, "writeFile session_id and file_path are required")
+	}
+	return buildFileAccessCommandResult(baseCtx, commandID, dispatch, writeFileCapabilityDeclared, func(ctx context.Context) (any, error) {
+		return runWriteFile(ctx, writeFileRequest{
+			SessionID: decoded.SessionID,
+			FilePath:  decoded.FilePath,
+			Content:   decoded.Content,
+			Encoding:  decoded.Encoding,
+			Append:    decoded.Append,
+		})
+	})
+}
+
+func buildListDirCommandResult(baseCtx context.Context, commandID string, dispatch *registryv1.CommandDispatch) *registryv1.ConnectRequest {
+	decoded := listDirPayload{}
+	if err := json.Unmarshal(dispatch.GetPayloadJson(), &decoded); err != nil {
+		return commandErrorResult(commandID, fileAccessCodeInvalidPayload, "payload_json is not valid listDir payload")
+	}
+	if strings.TrimSpace(decoded.SessionID) == "" || strings.TrimSpace(decoded.DirPath) == "" {
+		return commandErrorResult(commandID, fileAccessCodeInvalidPayload, "listDir session_id and dir_path are required")
+	}
+	return buildFileAccessCommandResult(baseCtx, commandID, dispatch, listDirCapabilityDeclared, func(ctx context.Context) (any, error) {
+		return runListDir(ctx, listDirRequest{
+			SessionID: decoded.SessionID,
+			DirPath:   decoded.DirPath,
+		})
+	})
+}
+
+// buildFileAccessCommandResult runs one decoded readFile, writeFile or
+// listDir request under the dispatch deadline and encodes its result.
+func buildFileAccessCommandResult(
+	baseCtx context.Context,
+	commandID string,
+	dispatch *registryv1.CommandDispatch,
+	capability string,
+	run func(context.Context) (any, error),
+) *registryv1.ConnectRequest {
+	commandCtx := baseCtx
+	if commandCtx == nil {
+		commandCtx = context.Background()
+	}
+	cancel := func() {}
+	if deadlineUnixMS := dispatch.GetDeadlineUnixMs(); deadlineUnixMS > 0 {
+		commandCtx, cancel = context.WithDeadline(commandCtx, time.UnixMilli(deadlineUnixMS))
+	}
+	defer cancel()
+
+	execResult, err := run(commandCtx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return commandErrorResult(commandID, "deadline_exceeded", "command deadline exceeded")
+		}
+		var fileAccessErr *fileAccessError
+		if errors.As(err, &fileAccessErr) {
+			return commandErrorResult(commandID, fileAccessErr.Code(), fileAccessErr.Error())
+		}
+		return commandErrorResult(commandID, "execution_failed", fmt.Sprintf("%s execution failed: %v", capability, err))
+	}
+
+	resultPayload, err := json.Marshal(execResult)
+	if err != nil {
+		return commandErrorResult(commandID, "encode_failed", fmt.Sprintf("failed to encode %s payload", capability))
+	}
+
+	return &registryv1.ConnectRequest{
+		Payload: &registryv1.ConnectRequest_CommandResult{
+			CommandResult: &registryv1.CommandResult{
+				CommandId:       commandID,
+				PayloadJson:     resultPayload,
+				CompletedUnixMs: time.Now().UnixMilli(),
+			},
+		},
+	}
+}
+
+// buildScreenshotCommandResult ignores the payload: a capture takes no
+// parameters, scaling and size limits are worker configuration.
+func buildScreenshotCommandResult(baseCtx context.Context, commandID string, dispatch *registryv1.CommandDispatch) *registryv1.ConnectRequest {
+	commandCtx := baseCtx
+	if commandCtx == nil {
+		commandCtx = context.Background()
+	}
+	cancel := func() {}
+	if deadlineUnixMS := dispatch.GetDeadlineUnixMs(); deadlineUnixMS > 0 {
+		commandCtx, cancel = context.WithDeadline(commandCtx, time.UnixMilli(deadlineUnixMS))
+	}
+	defer cancel()
+
+	execResult, err := runScreenshot(commandCtx, screenshotRequest{})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return commandErrorResult(commandID, "deadline_exceeded", "command deadline exceeded")
+		}
+		var screenshotErr *screenshotError
+		if errors.As(err, &screenshotErr) {
+			return commandErrorResult(commandID, screenshotErr.Code(), screenshotErr.Error())
+		}
+		return commandErrorResult(commandID, "execution_failed", fmt.Sprintf("screenshot execution failed: %v", err))
+	}
+
+	resultPayload, err := json.Marshal(execResult)
+	if err != nil {
+		return commandErrorResult(commandID, "encode_failed", "failed to encode screenshot payload")
+	}
+
+	return &registryv1.ConnectRequest{
+		Payload: &registryv1.ConnectRequest_CommandResult{
+			CommandResult: &registryv1.CommandResult{
+				CommandId:       commandID,
+				PayloadJson:     resultPayload,
+				CompletedUnixMs: time.Now().UnixMilli(),
+			},
+		},
+	}
+}
+
 func commandErrorResult(commandID string, code string, message string) *registryv1.ConnectRequest {
 	return &registryv1.ConnectRequest{
 		Payload: &registryv1.ConnectRequest_CommandResult{
@@ -179,3 +343,19 @@ func runComputerUseUnavailable(context.Context, computerUseRequest) (computerUse
 func runReadImageUnavailable(context.Context, readImageRequest) (readImageRunResult, error) {
 	return readImageRunResult{}, newReadImageError("execution_failed", readImageNotReadyMessage)
 }
+
+func runReadFileUnavailable(context.Context, readFileRequest) (readFileRunResult, error) {
+	return readFileRunResult{}, newFileAccessError("execution_failed", fileAccessNotReadyMessage)
+}
+
+func runWriteFileUnavailable(context.Context, writeFileRequest) (writeFileRunResult, error) {
+	return writeFileRunResult{}, newFileAccessError("execution_failed", fileAccessNotReadyMessage)
+}
+
+func runListDirUnavailable(context.Context, listDirRequest) (listDirRunResult, error) {
+	return listDirRunResult{}, newFileAccessError("execution_failed", fileAccessNotReadyMessage)
+}
+
+func runScreenshotUnavailable(context.Context, screenshotRequest) (screenshotRunResult, error) {
+	return screenshotRunResult{}, newScreenshotError("execution_failed", screenshotNotReadyMessage)
+}
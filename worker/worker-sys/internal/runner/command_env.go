@@ -0,0 +1,209 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// workerEnvPrefix marks the worker's own configuration, including
+// WORKER_SECRET. Such variables never reach a command, whatever the
+// passthrough or override lists say.
+const workerEnvPrefix = "WORKER_"
+
+// workerEnvFDEnv names the descriptor the re-executed worker reads its
+// WORKER_ variables from; see HideWorkerEnv.
+const workerEnvFDEnv = "ONLYBOXES_WORKER_ENV_FD"
+
+// defaultCommandEnv is passed through from the worker environment even when
+// no passthrough list is configured.
+var defaultCommandEnv = []string{"PATH", "HOME", "LANG"}
+
+// commandEnvPolicy builds the environment for computerUse commands from an
+// allowlist instead of inheriting the worker environment.
+type commandEnvPolicy struct {
+	passthrough []string
+	overrides   map[string]string
+}
+
+func newCommandEnvPolicy(passthrough []string, overrides map[string]string) commandEnvPolicy {
+	policy := commandEnvPolicy{
+		passthrough: append(append([]string{}, defaultCommandEnv...), passthrough...),
+		overrides:   make(map[string]string, len(overrides)),
+	}
+	for name, value := range overrides {
+		policy.overrides[name] = value
+	}
+	return policy
+}
+
+// blocked returns the configured names that are dropped by the WORKER_
+// block, for startup warnings.
+func (p commandEnvPolicy) blocked() []string {
+	names := []string{}
+	for _, name := range p.passthrough {
+		if isWorkerEnvName(name) {
+			names = append(names, name)
+		}
+	}
+	for name := range p.overrides {
+		if isWorkerEnvName(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// build returns the command environment: allowed entries of environ, then
+// overrides replacing or adding values.
+func (p commandEnvPolicy) build(environ []string) []string {
+	values := map[string]string{}
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" || isWorkerEnvName(name) || !p.allows(name) {
+			continue
+		}
+		values[name] = value
+	}
+	for name, value := range p.overrides {
+		if name == "" || isWorkerEnvName(name) {
+			continue
+		}
+		values[name] = value
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, name+"="+values[name])
+	}
+	return env
+}
+
+// allows matches name against passthrough entries; an entry ending in "*"
+// matches by prefix, e.g. "LC_*".
+func (p commandEnvPolicy) allows(name string) bool {
+	for _, entry := range p.passthrough {
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+			continue
+		}
+		if name == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// HideWorkerEnv keeps the WORKER_ variables out of /proc/<pid>/environ,
+// where a command could read them through /proc/$PPID/environ: that file
+// shows the environment the process was started with, whatever is unset
+// later. On first start the worker re-executes itself without them and
+// passes them over a pipe; the re-executed worker restores them into its
+// own environment for config.Load. PR_SET_DUMPABLE would also hide the file,
+// but it stops a non-root worker from setting up user namespaces for the
+// sandbox.
+func HideWorkerEnv() error {
+	if raw, ok := os.LookupEnv(workerEnvFDEnv); ok {
+		_ = os.Unsetenv(workerEnvFDEnv)
+		return restoreWorkerEnv(raw)
+	}
+	kept := []string{}
+	hidden := []string{}
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if isWorkerEnvName(name) {
+			hidden = append(hidden, entry)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if len(hidden) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(hidden)
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolve worker executable: %w", err)
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// The variables are far smaller than a pipe buffer, so they can be
+	// written before the exec.
+	_, err = writer.Write(payload)
+	closeErr := writer.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	// os.Pipe sets close-on-exec; the re-executed worker needs the reader.
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, reader.Fd(), syscall.F_SETFD, 0); errno != 0 {
+		return fmt.Errorf("keep worker env pipe open: %w", errno)
+	}
+	kept = append(kept, workerEnvFDEnv+"="+strconv.Itoa(int(reader.Fd())))
+	if err := syscall.Exec(executable, os.Args, kept); err != nil {
+		return fmt.Errorf("re-exec worker: %w", err)
+	}
+	return nil
+}
+
+func restoreWorkerEnv(raw string) error {
+	fd, err := strconv.Atoi(raw)
+	if err != nil || fd < 0 {
+		return fmt.Errorf("invalid %s %q", workerEnvFDEnv, raw)
+	}
+	file := os.NewFile(uintptr(fd), "worker-env")
+	payload, err := io.ReadAll(file)
+	_ = file.Close()
+	if err != nil {
+		return fmt.Errorf("read worker env: %w", err)
+	}
+	entries := []string{}
+	if err := json.Unmarshal(payload, &entries); err != nil {
+		return fmt.Errorf("decode worker env: %w", err)
+	}
+	for _, entry := range entries {
+		name, value, _ := strings.Cut(entry, "=")
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("restore %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// UnsetWorkerEnv removes the WORKER_ variables once the config has been
+// loaded, so no child process can inherit them.
+func UnsetWorkerEnv() {
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		if isWorkerEnvName(name) {
+			_ = os.Unsetenv(name)
+		}
+	}
+}
+
+// isWorkerEnvName ignores case so "worker_secret" cannot slip through on
+// platforms or shells that treat names case-insensitively.
+func isWorkerEnvName(name string) bool {
+	return strings.HasPrefix(strings.ToUpper(name), workerEnvPrefix)
+}
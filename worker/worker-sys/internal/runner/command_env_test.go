@@ -0,0 +1,118 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandEnvPolicyBuildsFromAllowlist(t *testing.T) {
+	policy := newCommandEnvPolicy([]string{"LC_*", "TERM", "WORKER_SECRET", "worker_id"}, map[string]string{
+		"LANG":          "C.UTF-8",
+		"EDITOR":        "vi",
+		"WORKER_SECRET": "override",
+	})
+	got := policy.build([]string{
+		"PATH=/usr/bin",
+		"HOME=/home/worker",
+		"LANG=en_US.UTF-8",
+		"LC_ALL=C",
+		"TERM=xterm",
+		"SSH_AUTH_SOCK=/tmp/agent",
+		"WORKER_SECRET=top-secret",
+		"worker_id=worker-1",
+		"WORKER_CONSOLE_GRPC_TARGET=127.0.0.1:50051",
+		"malformed",
+	})
+	want := []string{"EDITOR=vi", "HOME=/home/worker", "LANG=C.UTF-8", "LC_ALL=C", "PATH=/usr/bin", "TERM=xterm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected env: want=%v got=%v", want, got)
+	}
+	if blocked := policy.blocked(); !reflect.DeepEqual(blocked, []string{"WORKER_SECRET", "WORKER_SECRET", "worker_id"}) {
+		t.Fatalf("unexpected blocked entries %v", blocked)
+	}
+}
+
+func TestComputerUseExecutorNeverPassesWorkerSecret(t *testing.T) {
+	t.Setenv("WORKER_SECRET", "top-secret-value")
+	t.Setenv("WORKER_ID", "worker-id-value")
+	t.Setenv("ONLYBOXES_UNLISTED", "unlisted-value")
+	t.Setenv("ONLYBOXES_LISTED", "listed-value")
+
+	executor := newComputerUseExecutor(computerUseExecutorConfig{
+		OutputLimitBytes: 1 << 20,
+		WhitelistMode:    computerUseWhitelistModeAllowAll,
+		EnvPassthrough:   []string{"ONLYBOXES_LISTED", "WORKER_*", "*"},
+		EnvOverrides:     map[string]string{"WORKER_SECRET": "override-value", "ONLYBOXES_OVERRIDE": "override-value"},
+	})
+	for _, execMode := range []string{"", computerUseExecModeDirect} {
+		executor.execMode = execMode
+		result, err := executor.Execute(context.Background(), computerUseRequest{Command: "env"})
+		if err != nil {
+			t.Fatalf("exec mode %q: run env: %v", execMode, err)
+		}
+		for _, secret := range []string{"top-secret-value", "worker-id-value", "WORKER_"} {
+			if strings.Contains(result.Stdout, secret) {
+				t.Fatalf("exec mode %q: child environment leaked %q:\n%s", execMode, secret, result.Stdout)
+			}
+		}
+		for _, expected := range []string{"ONLYBOXES_LISTED=listed-value", "ONLYBOXES_OVERRIDE=override-value", "PATH="} {
+			if !strings.Contains(result.Stdout, expected) {
+				t.Fatalf("exec mode %q: expected %q in child environment:\n%s", execMode, expected, result.Stdout)
+			}
+		}
+	}
+}
+
+// environProbeEnv makes the test binary act as a worker whose command tries
+// to read the worker's initial environment through /proc.
+const environProbeEnv = "ONLYBOXES_TEST_ENVIRON_PROBE"
+
+func TestComputerUseCommandCannotReadWorkerEnviron(t *testing.T) {
+	if os.Getenv(environProbeEnv) == "1" {
+		runEnvironProbe(t)
+		return
+	}
+	// The secret has to be in the environment the process starts with:
+	// /proc/<pid>/environ shows that block, not later Setenv calls.
+	probe := exec.Command(os.Args[0], "-test.run=^TestComputerUseCommandCannotReadWorkerEnviron$")
+	probe.Env = append(os.Environ(), environProbeEnv+"=1", "WORKER_SECRET=environ-secret-value")
+	output, err := probe.CombinedOutput()
+	if err != nil {
+		t.Fatalf("run probe: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "environ-probe-done") {
+		t.Fatalf("probe command did not run:\n%s", output)
+	}
+	if strings.Contains(string(output), "environ-secret-value") {
+		t.Fatalf("command read the worker environ:\n%s", output)
+	}
+}
+
+func runEnvironProbe(t *testing.T) {
+	if err := HideWorkerEnv(); err != nil {
+		t.Fatalf("hide worker env: %v", err)
+	}
+	if value := os.Getenv("WORKER_SECRET"); value != "environ-secret-value" {
+		t.Fatalf("expected WORKER_SECRET to be restored, got %q", value)
+	}
+	UnsetWorkerEnv()
+	if value, ok := os.LookupEnv("WORKER_SECRET"); ok {
+		t.Fatalf("expected WORKER_SECRET to be unset, got %q", value)
+	}
+	executor := newComputerUseExecutor(computerUseExecutorConfig{
+		OutputLimitBytes: 1 << 20,
+		WhitelistMode:    computerUseWhitelistModeAllowAll,
+	})
+	result, err := executor.Execute(context.Background(), computerUseRequest{
+		Command: "tr '\\0' '\\n' < /proc/$PPID/environ; echo environ-probe-done",
+	})
+	if err != nil {
+		t.Fatalf("run probe command: %v", err)
+	}
+	fmt.Println(result.Stdout, result.Stderr)
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/journal"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/logging"
+)
+
+var appendJournal = appendJournalDisabled
+
+func appendJournalDisabled(journal.Entry) error {
+	return nil
+}
+
+// recordCommandJournal appends the executed dispatch to the local journal.
+// A journal failure is logged and never fails the command.
+func recordCommandJournal(dispatch *registryv1.CommandDispatch, resultReq *registryv1.ConnectRequest, duration time.Duration) {
+	entry := journalEntryForCommand(dispatch, resultReq, duration)
+	if err := appendJournal(entry); err != nil {
+		logging.Warnf("append execution journal failed: command_id=%s err=%v", entry.CommandID, err)
+	}
+}
+
+func journalEntryForCommand(dispatch *registryv1.CommandDispatch, resultReq *registryv1.ConnectRequest, duration time.Duration) journal.Entry {
+	capability := strings.TrimSpace(strings.ToLower(dispatch.GetCapability()))
+	entry := journal.Entry{
+		CommandID:  strings.TrimSpace(dispatch.GetCommandId()),
+		Capability: capability,
+		Command:    commandDispatchTextForLog(capability, dispatch.GetPayloadJson()),
+		DurationMS: duration.Milliseconds(),
+	}
+	if capability == computerUseCapabilityName {
+		decoded := computerUsePayload{}
+		if err := json.Unmarshal(dispatch.GetPayloadJson(), &decoded); err == nil {
+			entry.SessionID = strings.TrimSpace(decoded.SessionID)
+		}
+	}
+
+	result := resultReq.GetCommandResult()
+	if result == nil {
+		return entry
+	}
+	if commandErr := result.GetError(); commandErr != nil {
+		entry.ErrorCode = commandErr.GetCode()
+		return entry
+	}
+
+	payload := result.GetPayloadJson()
+	if capability == computerUseCapabilityName {
+		decoded := computerUseRunResult{}
+		if err := json.Unmarshal(payload, &decoded); err == nil {
+			exitCode := decoded.ExitCode
+			entry.ExitCode = &exitCode
+			entry.StdoutSHA256, entry.StdoutBytes = journal.SHA256Hex([]byte(decoded.Stdout)), len(decoded.Stdout)
+			entry.StderrSHA256, entry.StderrBytes = journal.SHA256Hex([]byte(decoded.Stderr)), len(decoded.Stderr)
+			entry.StdoutTruncated, entry.StderrTruncated = decoded.StdoutTruncated, decoded.StderrTruncated
+			if decoded.SessionID != "" {
+				entry.SessionID = decoded.SessionID
+			}
+			return entry
+		}
+	}
+	entry.ResultSHA256, entry.ResultBytes = journal.SHA256Hex(payload), len(payload)
+	return entry
+}
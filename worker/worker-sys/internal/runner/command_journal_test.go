@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/journal"
+)
+
+func TestJournalEntryForComputerUseHashesOutput(t *testing.T) {
+	resultJSON, _ := json.Marshal(computerUseRunResult{
+		Stdout:          "hello\n",
+		Stderr:          "",
+		ExitCode:        3,
+		StdoutTruncated: true,
+		SessionID:       "sess-1",
+	})
+	entry := journalEntryForCommand(
+		&registryv1.CommandDispatch{
+			CommandId:   "cmd-1",
+			Capability:  computerUseCapabilityDeclared,
+			PayloadJson: []byte(`{"command":"echo hello","create_if_missing":true}`),
+		},
+		&registryv1.ConnectRequest{
+			Payload: &registryv1.ConnectRequest_CommandResult{
+				CommandResult: &registryv1.CommandResult{CommandId: "cmd-1", PayloadJson: resultJSON},
+			},
+		},
+		1500*time.Millisecond,
+	)
+
+	if entry.CommandID != "cmd-1" || entry.Capability != computerUseCapabilityName || entry.Command != "echo hello" || entry.DurationMS != 1500 {
+		t.Fatalf("unexpected entry identity %#v", entry)
+	}
+	if entry.ExitCode == nil || *entry.ExitCode != 3 || entry.SessionID != "sess-1" {
+		t.Fatalf("unexpected exit code or session %#v", entry)
+	}
+	if entry.StdoutSHA256 != journal.SHA256Hex([]byte("hello\n")) || entry.StdoutBytes != 6 || !entry.StdoutTruncated {
+		t.Fatalf("unexpected stdout digest %#v", entry)
+	}
+	if entry.StderrSHA256 != journal.SHA256Hex(nil) || entry.ResultSHA256 != "" {
+		t.Fatalf("unexpected stderr or result digest %#v", entry)
+	}
+}
+
+func TestJournalEntryForWriteFileOmitsContent(t *testing.T) {
+	entry := journalEntryForCommand(
+		&registryv1.CommandDispatch{
+			CommandId:   "cmd-2",
+			Capability:  writeFileCapabilityDeclared,
+			PayloadJson: []byte(`{"session_id":"computerUse","file_path":"/srv/a.txt","content":"secret"}`),
+		},
+		commandErrorResult("cmd-2", fileAccessCodePathNotAllowed, "file path is not allowed"),
+		time.Millisecond,
+	)
+	if entry.Command != "write /srv/a.txt (6 chars)" || entry.ErrorCode != fileAccessCodePathNotAllowed || entry.ExitCode != nil {
+		t.Fatalf("unexpected writeFile entry %#v", entry)
+	}
+}
+
+func TestHandleCommandDispatchAppendsJournalEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	commandJournal, err := journal.Open(journal.Options{Path: path, Key: []byte("journal-key")})
+	if err != nil {
+		t.Fatalf("open journal failed: %v", err)
+	}
+	defer commandJournal.Close()
+	originalAppendJournal := appendJournal
+	appendJournal = commandJournal.Append
+	t.Cleanup(func() {
+		appendJournal = originalAppendJournal
+	})
+
+	commandExecSlots := make(chan struct{}, commandExecSlotCapacity)
+	commandExecSlots <- struct{}{}
+	outbound := make(chan *registryv1.ConnectRequest, 1)
+	errCh := make(chan error, 1)
+	ok := handleCommandDispatch(
+		context.Background(),
+		outbound,
+		errCh,
+		commandExecSlots,
+		&registryv1.CommandDispatch{
+			CommandId:   "cmd-journal",
+			Capability:  listDirCapabilityDeclared,
+			PayloadJson: []byte(`{"session_id":"computerUse","dir_path":"/srv"}`),
+		},
+		func(context.Context, *registryv1.CommandDispatch) *registryv1.ConnectRequest {
+			return &registryv1.ConnectRequest{
+				Payload: &registryv1.ConnectRequest_CommandResult{
+					CommandResult: &registryv1.CommandResult{CommandId: "cmd-journal", PayloadJson: []byte(`{"entries":[]}`)},
+				},
+			}
+		},
+	)
+	if !ok {
+		t.Fatalf("expected dispatch handling to continue")
+	}
+	select {
+	case <-outbound:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for command result")
+	}
+
+	entries := make([]journal.Entry, 0, 1)
+	if err := journal.ReadEntries(path, 0, func(_ string, _ int, entry journal.Entry) error {
+		entries = append(entries, entry)
+		return nil
+	}); err != nil {
+		t.Fatalf("read journal failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].CommandID != "cmd-journal" || entries[0].Command != "list /srv" || entries[0].ResultBytes != len(`{"entries":[]}`) {
+		t.Fatalf("unexpected journal entries %#v", entries)
+	}
+}
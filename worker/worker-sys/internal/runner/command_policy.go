@@ -0,0 +1,206 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/config"
+)
+
+const (
+	commandRuleActionAllow = "allow"
+	commandRuleActionDeny  = "deny"
+	commandRuleRestArgs    = "..."
+	commandRuleRegexPrefix = "re:"
+)
+
+// argPattern matches one argv word: literally, as a glob (path.Match, so
+// "*" does not cross "/"), or as a fully anchored regular expression when
+// written with the "re:" prefix.
+type argPattern struct {
+	literal string
+	glob    string
+	re      *regexp.Regexp
+}
+
+func compileArgPattern(raw string) (argPattern, error) {
+	if expr, ok := strings.CutPrefix(raw, commandRuleRegexPrefix); ok {
+		re, err := regexp.Compile(`^(?:` + expr + `)$`)
+		if err != nil {
+			return argPattern{}, fmt.Errorf("invalid regular expression %q", expr)
+		}
+		return argPattern{re: re}, nil
+	}
+	if _, err := path.Match(raw, ""); err != nil {
+		return argPattern{}, fmt.Errorf("invalid glob %q", raw)
+	}
+	return argPattern{glob: raw}, nil
+}
+
+func (p argPattern) match(value string) bool {
+	switch {
+	case p.re != nil:
+		return p.re.MatchString(value)
+	case p.glob != "":
+		ok, _ := path.Match(p.glob, value)
+		return ok
+	default:
+		return p.literal == value
+	}
+}
+
+type commandRule struct {
+	deny    bool
+	command argPattern
+	// args is nil when any arguments match; restArgs lets a trailing "..."
+	// match zero or more remaining arguments.
+	args     []argPattern
+	restArgs bool
+	anyArg   *argPattern
+}
+
+func (r commandRule) matches(argv []string) bool {
+	if len(argv) == 0 {
+		return false
+	}
+	// Allow rules see argv[0] as written so "ls" does not admit "./ls";
+	// deny rules also match its base name so "/bin/rm" is still "rm".
+	if !r.command.match(argv[0]) && !(r.deny && r.command.match(path.Base(argv[0]))) {
+		return false
+	}
+	args := argv[1:]
+	if r.args != nil {
+		if len(args) < len(r.args) || (!r.restArgs && len(args) != len(r.args)) {
+			return false
+		}
+		for i, pattern := range r.args {
+			if !pattern.match(args[i]) {
+				return false
+			}
+		}
+	}
+	if r.anyArg != nil {
+		for _, arg := range args {
+			if r.anyArg.match(arg) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// commandPolicy is the parsed whitelist mode: every simple command in the
+// line must match an allow rule and no deny rule, and shell syntax beyond
+// plain words is rejected unless listed in allowedSyntax.
+type commandPolicy struct {
+	rules         []commandRule
+	allowedSyntax map[string]struct{}
+}
+
+func newCommandPolicy(whitelist []string, rules []config.ComputerUseCommandRule, allowedSyntax []string) (*commandPolicy, error) {
+	policy := &commandPolicy{allowedSyntax: make(map[string]struct{}, len(allowedSyntax))}
+	for _, feature := range allowedSyntax {
+		policy.allowedSyntax[feature] = struct{}{}
+	}
+
+	// Whitelist entries are literal argv prefixes: "git status" admits
+	// "git status --short" but not "git push".
+	for _, entry := range whitelist {
+		parsed, err := parseShellCommand(entry)
+		if err != nil || len(parsed.commands) != 1 || len(parsed.features) > 0 {
+			return nil, fmt.Errorf("whitelist entry %q must be a single plain command", entry)
+		}
+		argv := parsed.commands[0]
+		rule := commandRule{command: argPattern{literal: argv[0]}, args: []argPattern{}, restArgs: true}
+		for _, arg := range argv[1:] {
+			rule.args = append(rule.args, argPattern{literal: arg})
+		}
+		policy.rules = append(policy.rules, rule)
+	}
+
+	for i, raw := range rules {
+		rule, err := compileCommandRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("command rule %d: %w", i, err)
+		}
+		policy.rules = append(policy.rules, rule)
+	}
+	return policy, nil
+}
+
+func compileCommandRule(raw config.ComputerUseCommandRule) (commandRule, error) {
+	rule := commandRule{}
+	switch strings.ToLower(strings.TrimSpace(raw.Action)) {
+	case "", commandRuleActionAllow:
+	case commandRuleActionDeny:
+		rule.deny = true
+	default:
+		return commandRule{}, fmt.Errorf("action must be one of allow|deny")
+	}
+	if strings.TrimSpace(raw.Command) == "" {
+		return commandRule{}, errors.New("command is required")
+	}
+	command, err := compileArgPattern(strings.TrimSpace(raw.Command))
+	if err != nil {
+		return commandRule{}, err
+	}
+	rule.command = command
+
+	if raw.Args != nil {
+		rule.args = make([]argPattern, 0, len(raw.Args))
+		for i, arg := range raw.Args {
+			if arg == commandRuleRestArgs {
+				if i != len(raw.Args)-1 {
+					return commandRule{}, errors.New(`"..." must be the last args entry`)
+				}
+				rule.restArgs = true
+				break
+			}
+			pattern, err := compileArgPattern(arg)
+			if err != nil {
+				return commandRule{}, err
+			}
+			rule.args = append(rule.args, pattern)
+		}
+	}
+	if raw.AnyArg != "" {
+		pattern, err := compileArgPattern(raw.AnyArg)
+		if err != nil {
+			return commandRule{}, err
+		}
+		rule.anyArg = &pattern
+	}
+	return rule, nil
+}
+
+// check returns a command_not_allowed error naming the first violation.
+func (p *commandPolicy) check(parsed shellParseResult) error {
+	for _, feature := range parsed.features {
+		if _, ok := p.allowedSyntax[feature]; !ok {
+			return newComputerUseError(computerUseCodeCommandNotAllowed, "command is blocked by whitelist policy: shell syntax "+feature+" is not allowed")
+		}
+	}
+	if len(parsed.commands) == 0 {
+		return newComputerUseError(computerUseCodeCommandNotAllowed, "command is blocked by whitelist policy: no command to run")
+	}
+	for _, argv := range parsed.commands {
+		allowed := false
+		for _, rule := range p.rules {
+			if !rule.matches(argv) {
+				continue
+			}
+			if rule.deny {
+				return newComputerUseError(computerUseCodeCommandNotAllowed, fmt.Sprintf("command is blocked by whitelist policy: %q is denied", argv[0]))
+			}
+			allowed = true
+		}
+		if !allowed {
+			return newComputerUseError(computerUseCodeCommandNotAllowed, fmt.Sprintf("command is blocked by whitelist policy: %q does not match any allow rule", argv[0]))
+		}
+	}
+	return nil
+}
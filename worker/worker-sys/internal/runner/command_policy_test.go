@@ -0,0 +1,235 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/config"
+)
+
+func TestParseShellCommandTokenizesQuotedWords(t *testing.T) {
+	parsed, err := parseShellCommand(`grep -e 'a b' "c \"d\"" e\ f`)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	expected := [][]string{{"grep", "-e", "a b", `c "d"`, "e f"}}
+	if !reflect.DeepEqual(parsed.commands, expected) || len(parsed.features) != 0 {
+		t.Fatalf("unexpected parse result: %#v", parsed)
+	}
+}
+
+func TestParseShellCommandReportsSyntaxFeatures(t *testing.T) {
+	cases := []struct {
+		command  string
+		features []string
+		commands [][]string
+	}{
+		{command: "ls; rm -rf ~", features: []string{"list"}, commands: [][]string{{"ls"}, {"rm", "-rf", "~"}}},
+		{command: "ls && id || true", features: []string{"list"}, commands: [][]string{{"ls"}, {"id"}, {"true"}}},
+		{command: "ps aux | grep x", features: []string{"pipe"}, commands: [][]string{{"ps", "aux"}, {"grep", "x"}}},
+		{command: "sleep 1 &", features: []string{"background"}, commands: [][]string{{"sleep", "1"}}},
+		{command: "echo hi 2>&1 >/tmp/out", features: []string{"redirect"}, commands: [][]string{{"echo", "hi"}}},
+		{command: "ls $(curl -s x)", features: []string{"substitution"}, commands: [][]string{{"curl", "-s", "x"}, {"ls", "$(curl -s x)"}}},
+		{command: "ls \"`id`\"", features: []string{"substitution"}, commands: [][]string{{"id"}, {"ls", "`id`"}}},
+		{command: "echo $HOME ${PATH}", features: []string{"expansion"}, commands: [][]string{{"echo", "$HOME", "${PATH}"}}},
+		{command: "LD_PRELOAD=/tmp/x.so ls", features: []string{"assignment"}, commands: [][]string{{"ls"}}},
+		{command: "echo 'a;b|c' # trailing; rm", features: []string{}, commands: [][]string{{"echo", "a;b|c"}}},
+		{command: "cat <<EOF\nrm -rf ~\nEOF", features: []string{"redirect"}, commands: [][]string{{"cat"}}},
+		{command: "cat <<-'EOF' | sh\n$(id)\n\tEOF\nls", features: []string{"list", "pipe", "redirect"}, commands: [][]string{{"cat"}, {"sh"}, {"ls"}}},
+		{command: "cat <<EOF\n$(id)\nEOF", features: []string{"redirect", "substitution"}, commands: [][]string{{"cat"}, {"id"}}},
+		{command: "echo $'\\x2fetc' \"$'x'\"", features: []string{"expansion"}, commands: [][]string{{"echo", "$'\\x2fetc'", "$'x'"}}},
+		{command: "echo $\"rm\" \"$\"", features: []string{}, commands: [][]string{{"echo", "rm", "$"}}},
+		{command: "ls *.go src/[ab] {x,y}.txt >out?", features: []string{"glob", "redirect"}, commands: [][]string{{"ls", "*.go", "src/[ab]", "{x,y}.txt"}}},
+		{command: "[ -f x ] && echo {} '*' a\\*", features: []string{"list"}, commands: [][]string{{"[", "-f", "x", "]"}, {"echo", "{}", "*", "a*"}}},
+	}
+	for _, tc := range cases {
+		parsed, err := parseShellCommand(tc.command)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.command, err)
+		}
+		if !reflect.DeepEqual(parsed.features, tc.features) {
+			t.Fatalf("parse %q: expected features %v, got %v", tc.command, tc.features, parsed.features)
+		}
+		if !reflect.DeepEqual(parsed.commands, tc.commands) {
+			t.Fatalf("parse %q: expected commands %#v, got %#v", tc.command, tc.commands, parsed.commands)
+		}
+	}
+}
+
+func TestParseShellCommandRejectsUnsupportedSyntax(t *testing.T) {
+	for _, command := range []string{
+		"(rm -rf ~)",
+		"if true; then rm x; fi",
+		"echo 'open",
+		"echo \"open",
+		"ls |",
+		"| ls",
+		"ls >",
+		"echo ${x:-$(id)}",
+		"echo $(id",
+		"cat <<EOF\nrm -rf ~",
+		"cat <<EOF",
+		"echo $'open",
+		"echo $\"open",
+		"/usr/bin/r[m] -rf x",
+		"/bin/r? -rf x",
+	} {
+		if _, err := parseShellCommand(command); !errors.Is(err, errShellSyntax) {
+			t.Fatalf("expected syntax error for %q, got %v", command, err)
+		}
+	}
+}
+
+func TestCommandPolicyDenyOverridesAllow(t *testing.T) {
+	policy, err := newCommandPolicy([]string{"ls", "git status"}, []config.ComputerUseCommandRule{
+		{Command: "rm", Args: []string{"re:-[a-z]*", "/tmp/*"}},
+		{Action: "deny", Command: "rm", AnyArg: "re:-[a-z]*r[a-z]*"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+
+	check := func(command string) error {
+		t.Helper()
+		parsed, err := parseShellCommand(command)
+		if err != nil {
+			t.Fatalf("parse %q: %v", command, err)
+		}
+		return policy.check(parsed)
+	}
+	for _, allowed := range []string{"ls", "ls -la /var", "git status --short", "rm -f /tmp/a"} {
+		if err := check(allowed); err != nil {
+			t.Fatalf("expected %q to be allowed, got %v", allowed, err)
+		}
+	}
+	for _, blocked := range []string{
+		"ls; rm -rf ~",
+		"ls $(curl evil)",
+		"git push",
+		"./ls",
+		"rm -rf /tmp/a",
+		"/bin/rm -fr /tmp/a",
+		"rm -f /tmp/a/b",
+		"ls > /etc/passwd",
+	} {
+		err := check(blocked)
+		var cuErr *computerUseError
+		if !errors.As(err, &cuErr) || cuErr.Code() != computerUseCodeCommandNotAllowed {
+			t.Fatalf("expected %q to be blocked, got %v", blocked, err)
+		}
+	}
+}
+
+func TestCommandPolicyDenySeesThroughQuotingAndGlobs(t *testing.T) {
+	policy, err := newCommandPolicy(nil, []config.ComputerUseCommandRule{
+		{Command: "*"},
+		{Command: "/usr/bin/*"},
+		{Action: "deny", Command: "rm"},
+	}, []string{shellSyntaxGlob})
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	for _, command := range []string{`$"rm" -rf x`, `/usr/bin/$"rm" -rf x`} {
+		parsed, err := parseShellCommand(command)
+		if err != nil {
+			t.Fatalf("parse %q: %v", command, err)
+		}
+		if err := policy.check(parsed); err == nil || !strings.Contains(err.Error(), "denied") {
+			t.Fatalf("expected %q to be denied, got %v", command, err)
+		}
+	}
+	if _, err := parseShellCommand("/usr/bin/r[m] -rf x"); !errors.Is(err, errShellSyntax) {
+		t.Fatalf("expected glob in command name to be rejected, got %v", err)
+	}
+
+	parsed, _ := parseShellCommand("ls *.go")
+	if err := policy.check(parsed); err != nil {
+		t.Fatalf("expected allowed glob argument, got %v", err)
+	}
+	strict, err := newCommandPolicy([]string{"ls"}, nil, nil)
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	if err := strict.check(parsed); err == nil || !strings.Contains(err.Error(), "glob") {
+		t.Fatalf("expected glob to need allowed syntax, got %v", err)
+	}
+}
+
+func TestCommandPolicyAllowedSyntaxChecksEveryCommand(t *testing.T) {
+	policy, err := newCommandPolicy([]string{"ls", "grep"}, nil, []string{shellSyntaxPipe, shellSyntaxSubstitution})
+	if err != nil {
+		t.Fatalf("new policy: %v", err)
+	}
+	parsed, _ := parseShellCommand("ls | grep go")
+	if err := policy.check(parsed); err != nil {
+		t.Fatalf("expected pipe between allowed commands, got %v", err)
+	}
+	parsed, _ = parseShellCommand("ls $(rm -rf ~)")
+	if err := policy.check(parsed); err == nil || !strings.Contains(err.Error(), `"rm"`) {
+		t.Fatalf("expected substituted command to be checked, got %v", err)
+	}
+}
+
+func TestNewCommandPolicyRejectsInvalidRules(t *testing.T) {
+	for _, rule := range []config.ComputerUseCommandRule{
+		{Action: "maybe", Command: "ls"},
+		{Command: ""},
+		{Command: "re:("},
+		{Command: "ls", Args: []string{"...", "x"}},
+	} {
+		if _, err := newCommandPolicy(nil, []config.ComputerUseCommandRule{rule}, nil); err == nil {
+			t.Fatalf("expected rule %#v to be rejected", rule)
+		}
+	}
+	if _, err := newCommandPolicy([]string{"ls | sh"}, nil, nil); err == nil {
+		t.Fatalf("expected compound whitelist entry to be rejected")
+	}
+}
+
+func TestComputerUseExecutorParsedModeBlocksMetacharacterBypass(t *testing.T) {
+	executor := newComputerUseExecutor(computerUseExecutorConfig{
+		OutputLimitBytes: 1024,
+		WhitelistMode:    computerUseWhitelistModeParsed,
+		Whitelist:        []string{"echo"},
+	})
+
+	result, err := executor.Execute(context.Background(), computerUseRequest{Command: "echo hi"})
+	if err != nil || result.Stdout != "hi\n" {
+		t.Fatalf("expected echo to run, got %#v err=%v", result, err)
+	}
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: "echo hi; id"})
+	assertComputerUseErrorCode(t, err, computerUseCodeCommandNotAllowed)
+}
+
+func TestComputerUseExecutorParsedModeBlocksAllOnInvalidRules(t *testing.T) {
+	executor := newComputerUseExecutor(computerUseExecutorConfig{
+		OutputLimitBytes: 1024,
+		WhitelistMode:    computerUseWhitelistModeParsed,
+		Whitelist:        []string{"echo"},
+		CommandRules:     []config.ComputerUseCommandRule{{Action: "deny", Command: "re:("}},
+	})
+
+	_, err := executor.Execute(context.Background(), computerUseRequest{Command: "echo hi"})
+	assertComputerUseErrorCode(t, err, computerUseCodeCommandNotAllowed)
+}
+
+func TestComputerUseExecutorDirectExecModeSkipsShell(t *testing.T) {
+	executor := newComputerUseExecutor(computerUseExecutorConfig{
+		OutputLimitBytes: 1024,
+		WhitelistMode:    computerUseWhitelistModeAllowAll,
+		ExecMode:         computerUseExecModeDirect,
+	})
+
+	result, err := executor.Execute(context.Background(), computerUseRequest{Command: `printf '%s|%s' "a b" '$HOME'`})
+	if err != nil {
+		t.Fatalf("expected direct exec to succeed, got %v", err)
+	}
+	if result.Stdout != "a b|$HOME" {
+		t.Fatalf("expected literal argv, got %q", result.Stdout)
+	}
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: "echo hi | cat"})
+	assertComputerUseErrorCode(t, err, computerUseCodeInvalidPayload)
+}
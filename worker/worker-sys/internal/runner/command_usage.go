@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/sandbox"
+)
+
+// commandUsage is what a command cost. Zero values mean the worker could
+// not measure them.
+type commandUsage struct {
+	CPUSeconds      float64
+	PeakMemoryBytes int64
+}
+
+// processUsage reads the rusage of an exited command: user plus system CPU
+// time and the largest resident set among the command and the children it
+// waited for.
+func processUsage(state *os.ProcessState) commandUsage {
+	if state == nil {
+		return commandUsage{}
+	}
+	rusage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || rusage == nil {
+		return commandUsage{}
+	}
+	// ru_maxrss is in kilobytes on Linux and in bytes on macOS.
+	peak := int64(rusage.Maxrss)
+	if runtime.GOOS != "darwin" {
+		peak *= 1024
+	}
+	cpu := state.UserTime() + state.SystemTime()
+	return commandUsage{
+		CPUSeconds:      cpu.Seconds(),
+		PeakMemoryBytes: peak,
+	}
+}
+
+// cgroupUsageSince turns two readings of a session cgroup into what the
+// command between them cost. Peak memory is the session's peak so far.
+func cgroupUsageSince(previous sandbox.CgroupUsage, current sandbox.CgroupUsage) commandUsage {
+	cpuUsec := max(current.CPUUsec-previous.CPUUsec, 0)
+	return commandUsage{
+		CPUSeconds:      float64(cpuUsec) / 1e6,
+		PeakMemoryBytes: current.PeakMemoryBytes,
+	}
+}
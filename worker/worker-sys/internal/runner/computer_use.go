@@ -5,8 +5,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/config"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/sandbox"
 )
 
 const (
@@ -16,14 +20,28 @@ const (
 	computerUseWhitelistModePrefix   = "prefix"
 	computerUseWhitelistModeExact    = "exact"
 	computerUseWhitelistModeAllowAll = "allow_all"
+	computerUseWhitelistModeParsed   = "parsed"
+	computerUseExecModeDirect        = "direct"
 )
 
 type computerUsePayload struct {
-	Command string `json:"command"`
+	Command         string `json:"command"`
+	SessionID       string `json:"session_id,omitempty"`
+	CreateIfMissing bool   `json:"create_if_missing,omitempty"`
+	LeaseTTLSec     *int   `json:"lease_ttl_sec,omitempty"`
 }
 
+// computerUseRequest runs statelessly unless SessionID or CreateIfMissing
+// selects a persistent session.
 type computerUseRequest struct {
-	Command string
+	Command         string
+	SessionID       string
+	CreateIfMissing bool
+	LeaseTTLSec     *int
+}
+
+func (r computerUseRequest) usesSession() bool {
+	return strings.TrimSpace(r.SessionID) != "" || r.CreateIfMissing
 }
 
 type computerUseRunResult struct {
@@ -32,6 +50,13 @@ type computerUseRunResult struct {
 	ExitCode        int    `json:"exit_code"`
 	StdoutTruncated bool   `json:"stdout_truncated"`
 	StderrTruncated bool   `json:"stderr_truncated"`
+	// Session fields are only set for session requests.
+	SessionID          string `json:"session_id,omitempty"`
+	Created            bool   `json:"created,omitempty"`
+	SessionClosed      bool   `json:"session_closed,omitempty"`
+	LeaseExpiresUnixMS int64  `json:"lease_expires_unix_ms,omitempty"`
+
+	Usage commandUsage `json:"-"`
 }
 
 type computerUseError struct {
@@ -64,12 +89,31 @@ type computerUseExecutorConfig struct {
 	OutputLimitBytes int
 	WhitelistMode    string
 	Whitelist        []string
+	CommandRules     []config.ComputerUseCommandRule
+	AllowedSyntax    []string
+	ExecMode         string
+	Sandbox          *sandbox.Sandbox
+	EnvPassthrough   []string
+	EnvOverrides     map[string]string
+	// MaxSessions enables persistent sessions when positive.
+	MaxSessions     int
+	LeaseMinSec     int
+	LeaseMaxSec     int
+	LeaseDefaultSec int
 }
 
 type computerUseExecutor struct {
 	outputLimitBytes int
 	whitelistMode    string
 	whitelist        []string
+	execMode         string
+	sandbox          *sandbox.Sandbox
+	env              commandEnvPolicy
+	sessions         *computerUseSessionManager
+	// policy backs the parsed whitelist mode. policyErr is set when the
+	// configured rules are invalid; every command is then blocked.
+	policy    *commandPolicy
+	policyErr error
 }
 
 func newComputerUseExecutor(cfg computerUseExecutorConfig) *computerUseExecutor {
@@ -78,11 +122,37 @@ func newComputerUseExecutor(cfg computerUseExecutorConfig) *computerUseExecutor
 		outputLimit = 1024 * 1024
 	}
 
-	return &computerUseExecutor{
+	executor := &computerUseExecutor{
 		outputLimitBytes: outputLimit,
 		whitelistMode:    cfg.WhitelistMode,
 		whitelist:        cfg.Whitelist,
+		execMode:         cfg.ExecMode,
+		sandbox:          cfg.Sandbox,
+		env:              newCommandEnvPolicy(cfg.EnvPassthrough, cfg.EnvOverrides),
+	}
+	if cfg.WhitelistMode == computerUseWhitelistModeParsed {
+		executor.policy, executor.policyErr = newCommandPolicy(cfg.Whitelist, cfg.CommandRules, cfg.AllowedSyntax)
+	}
+	if cfg.MaxSessions > 0 {
+		executor.sessions = newComputerUseSessionManager(computerUseSessionManagerConfig{
+			MaxSessions:     cfg.MaxSessions,
+			LeaseMinSec:     cfg.LeaseMinSec,
+			LeaseMaxSec:     cfg.LeaseMaxSec,
+			LeaseDefaultSec: cfg.LeaseDefaultSec,
+			StartShell: func() (*computerUseShell, error) {
+				return startComputerUseShell(executor.sandbox, executor.env.build(os.Environ()))
+			},
+		})
+	}
+	return executor
+}
+
+// Close ends all persistent sessions.
+func (e *computerUseExecutor) Close() {
+	if e == nil {
+		return
 	}
+	e.sessions.Close()
 }
 
 func (e *computerUseExecutor) Execute(ctx context.Context, req computerUseRequest) (computerUseRunResult, error) {
@@ -94,17 +164,33 @@ func (e *computerUseExecutor) Execute(ctx context.Context, req computerUseReques
 	if command == "" {
 		return computerUseRunResult{}, newComputerUseError(computerUseCodeInvalidPayload, "command is required")
 	}
-	if !e.isCommandAllowed(command) {
-		return computerUseRunResult{}, newComputerUseError(computerUseCodeCommandNotAllowed, "command is blocked by whitelist policy")
+	if err := e.checkCommand(command); err != nil {
+		return computerUseRunResult{}, err
+	}
+	if req.usesSession() {
+		return e.executeInSession(ctx, req)
 	}
 
-	execCmd := exec.CommandContext(ctx, "/bin/sh", "-lc", command)
+	argv := []string{"/bin/sh", "-lc", command}
+	if e.execMode == computerUseExecModeDirect {
+		directArgv, err := directExecArgv(command)
+		if err != nil {
+			return computerUseRunResult{}, err
+		}
+		argv = directArgv
+	}
+	execCmd, cleanup, err := e.sandbox.Command(ctx, argv)
+	if err != nil {
+		return computerUseRunResult{}, fmt.Errorf("prepare command sandbox: %w", err)
+	}
+	defer cleanup()
+	execCmd.Env = e.env.build(os.Environ())
 	var stdoutBuf bytes.Buffer
 	var stderrBuf bytes.Buffer
 	execCmd.Stdout = &stdoutBuf
 	execCmd.Stderr = &stderrBuf
 
-	err := execCmd.Run()
+	err = execCmd.Run()
 	exitCode := 0
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
@@ -114,6 +200,9 @@ func (e *computerUseExecutor) Execute(ctx context.Context, req computerUseReques
 		if errors.As(err, &exitErr) {
 			exitCode = exitErr.ExitCode()
 		} else {
+			if e.execMode == computerUseExecModeDirect {
+				return computerUseRunResult{}, fmt.Errorf("command execution failed: %w", err)
+			}
 			return computerUseRunResult{}, fmt.Errorf("shell execution failed: %w", err)
 		}
 	} else if execCmd.ProcessState != nil {
@@ -129,9 +218,33 @@ func (e *computerUseExecutor) Execute(ctx context.Context, req computerUseReques
 		ExitCode:        exitCode,
 		StdoutTruncated: stdoutTruncated,
 		StderrTruncated: stderrTruncated,
+		Usage:           processUsage(execCmd.ProcessState),
 	}, nil
 }
 
+func (e *computerUseExecutor) executeInSession(ctx context.Context, req computerUseRequest) (computerUseRunResult, error) {
+	if e.sessions == nil {
+		return computerUseRunResult{}, newComputerUseError(computerUseCodeInvalidPayload, "computerUse sessions are disabled on this worker")
+	}
+	if e.execMode == computerUseExecModeDirect {
+		return computerUseRunResult{}, newComputerUseError(computerUseCodeInvalidPayload, "computerUse sessions are not supported in direct exec mode")
+	}
+	// A syntax error inside eval would terminate the session shell, so
+	// reject it up front without touching the session.
+	check := exec.CommandContext(ctx, "/bin/sh", "-n", "-c", req.Command)
+	check.Env = e.env.build(os.Environ())
+	var checkStderr bytes.Buffer
+	check.Stderr = &checkStderr
+	if err := check.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return computerUseRunResult{}, fmt.Errorf("shell syntax check failed: %w", err)
+		}
+		return computerUseRunResult{}, newComputerUseError(computerUseCodeInvalidPayload, "command has a shell syntax error: "+strings.TrimSpace(checkStderr.String()))
+	}
+	return e.sessions.Execute(ctx, req, e.outputLimitBytes)
+}
+
 func truncateByBytes(value string, maxBytes int) (string, bool) {
 	if maxBytes <= 0 {
 		return value, false
@@ -142,6 +255,39 @@ func truncateByBytes(value string, maxBytes int) (string, bool) {
 	return value[:maxBytes], true
 }
 
+func (e *computerUseExecutor) checkCommand(command string) error {
+	if e.whitelistMode != computerUseWhitelistModeParsed {
+		if !e.isCommandAllowed(command) {
+			return newComputerUseError(computerUseCodeCommandNotAllowed, "command is blocked by whitelist policy")
+		}
+		return nil
+	}
+	if e.policyErr != nil || e.policy == nil {
+		return newComputerUseError(computerUseCodeCommandNotAllowed, "command is blocked by whitelist policy: command rules are invalid")
+	}
+	parsed, err := parseShellCommand(command)
+	if err != nil {
+		return newComputerUseError(computerUseCodeCommandNotAllowed, "command is blocked by whitelist policy: "+err.Error())
+	}
+	return e.policy.check(parsed)
+}
+
+// directExecArgv splits command into argv for running without a shell. Any
+// syntax a shell would interpret is rejected instead of passed through.
+func directExecArgv(command string) ([]string, error) {
+	parsed, err := parseShellCommand(command)
+	if err != nil {
+		return nil, newComputerUseError(computerUseCodeInvalidPayload, err.Error())
+	}
+	if len(parsed.features) > 0 {
+		return nil, newComputerUseError(computerUseCodeInvalidPayload, "shell syntax "+strings.Join(parsed.features, ", ")+" is not supported in direct exec mode")
+	}
+	if len(parsed.commands) != 1 {
+		return nil, newComputerUseError(computerUseCodeInvalidPayload, "command is required")
+	}
+	return parsed.commands[0], nil
+}
+
 func (e *computerUseExecutor) isCommandAllowed(command string) bool {
 	if e == nil {
 		return false
@@ -0,0 +1,510 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/logging"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/sandbox"
+)
+
+const (
+	computerUseCodeSessionNotFound  = "session_not_found"
+	computerUseCodeSessionBusy      = "session_busy"
+	computerUseCodeSessionLimit     = "session_limit_reached"
+	computerUseNoSessionMessage     = "session not found"
+	computerUseSessionBusyMessage   = "session is busy"
+	computerUseSessionJanitorPeriod = 5 * time.Second
+	computerUseSessionReadBuffer    = 64 * 1024
+)
+
+// computerUseShell is a long-lived /bin/sh reading commands from stdin.
+// Each command is followed by a random sentinel on stdout (carrying the
+// exit status) and on stderr, which delimits its output.
+type computerUseShell struct {
+	cmd     *exec.Cmd
+	cleanup func()
+	stdin   io.WriteCloser
+	stdout  *os.File
+	stderr  *os.File
+	outR    *bufio.Reader
+	errR    *bufio.Reader
+	closed  sync.Once
+
+	// cgroupDir is the shell's cgroup, "" without cgroup limits. usage is
+	// its last reading, so each command is charged only the CPU time it
+	// added.
+	cgroupDir string
+	usage     sandbox.CgroupUsage
+}
+
+func startComputerUseShell(box *sandbox.Sandbox, env []string) (*computerUseShell, error) {
+	// The session outlives any single request, so it is not tied to a
+	// request context; destroy kills it instead.
+	cmd, cgroupDir, cleanup, err := box.CommandWithCgroup(context.Background(), []string{"/bin/sh", "-l", "-s"})
+	if err != nil {
+		return nil, err
+	}
+	cmd.Env = env
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// A process group lets destroy reach background jobs started in the
+	// session as well.
+	cmd.SysProcAttr.Setpgid = true
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		_ = stdoutR.Close()
+		_ = stdoutW.Close()
+		cleanup()
+		return nil, err
+	}
+	cmd.Stdout = stdoutW
+	cmd.Stderr = stderrW
+	startErr := cmd.Start()
+	_ = stdoutW.Close()
+	_ = stderrW.Close()
+	if startErr != nil {
+		_ = stdoutR.Close()
+		_ = stderrR.Close()
+		cleanup()
+		return nil, fmt.Errorf("start session shell: %w", startErr)
+	}
+
+	return &computerUseShell{
+		cmd:       cmd,
+		cleanup:   cleanup,
+		cgroupDir: cgroupDir,
+		stdin:     stdin,
+		stdout:    stdoutR,
+		stderr:    stderrR,
+		outR:      bufio.NewReaderSize(stdoutR, computerUseSessionReadBuffer),
+		errR:      bufio.NewReaderSize(stderrR, computerUseSessionReadBuffer),
+	}, nil
+}
+
+// run executes command in the shell. alive is false when the shell exited
+// (for example after "exit"), in which case the session must be dropped.
+func (s *computerUseShell) run(ctx context.Context, command string, outputLimit int) (computerUseRunResult, bool, error) {
+	token, err := newSessionSentinel()
+	if err != nil {
+		return computerUseRunResult{}, false, err
+	}
+	// eval keeps quoting intact and the leading newline in each printf puts
+	// the sentinel on its own line even when output lacks a trailing one.
+	script := "eval " + shellSingleQuote(command) + " </dev/null\n" +
+		"printf '\\n%s %s\\n' '" + token + "' \"$?\"\n" +
+		"printf '\\n%s\\n' '" + token + "' >&2\n"
+
+	type streamResult struct {
+		output   sessionOutput
+		exitCode int
+		sentinel bool
+	}
+	stdoutCh := make(chan streamResult, 1)
+	stderrCh := make(chan streamResult, 1)
+	go func() {
+		output, line, err := readUntilSentinel(s.outR, token, outputLimit)
+		result := streamResult{output: output}
+		if err == nil {
+			code, convErr := strconv.Atoi(strings.TrimPrefix(line, token+" "))
+			result.exitCode, result.sentinel = code, convErr == nil
+		}
+		stdoutCh <- result
+	}()
+	go func() {
+		output, _, err := readUntilSentinel(s.errR, token, outputLimit)
+		stderrCh <- streamResult{output: output, sentinel: err == nil}
+	}()
+
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		s.destroy()
+		<-stdoutCh
+		<-stderrCh
+		return computerUseRunResult{}, false, fmt.Errorf("write to session shell: %w", err)
+	}
+
+	var stdoutResult, stderrResult streamResult
+	for received := 0; received < 2; {
+		select {
+		case <-ctx.Done():
+			s.destroy()
+			<-stdoutCh
+			<-stderrCh
+			return computerUseRunResult{}, false, ctx.Err()
+		case stdoutResult = <-stdoutCh:
+			received++
+		case stderrResult = <-stderrCh:
+			received++
+		}
+	}
+
+	alive := stdoutResult.sentinel && stderrResult.sentinel
+	exitCode := stdoutResult.exitCode
+	usage := s.sampleUsage()
+	if !alive {
+		exitCode = s.destroy()
+	}
+	stdout, stdoutTruncated := stdoutResult.output.value(outputLimit)
+	stderr, stderrTruncated := stderrResult.output.value(outputLimit)
+	return computerUseRunResult{
+		Stdout:          stdout,
+		Stderr:          stderr,
+		ExitCode:        exitCode,
+		StdoutTruncated: stdoutTruncated,
+		StderrTruncated: stderrTruncated,
+		Usage:           usage,
+	}, alive, nil
+}
+
+// sampleUsage reads the shell's cgroup and charges the command that just
+// finished with the CPU time added since the previous reading. Without a
+// cgroup the usage is unknown and reported as zero.
+func (s *computerUseShell) sampleUsage() commandUsage {
+	if s.cgroupDir == "" {
+		return commandUsage{}
+	}
+	current, err := sandbox.ReadCgroupUsage(s.cgroupDir)
+	if err != nil {
+		logging.Warnf("computerUse session usage sample failed: err=%v", err)
+		return commandUsage{}
+	}
+	previous := s.usage
+	s.usage = current
+	return cgroupUsageSince(previous, current)
+}
+
+// destroy kills the shell's process group and releases its resources. It
+// returns the shell's exit code when it had already exited on its own.
+func (s *computerUseShell) destroy() int {
+	exitCode := -1
+	s.closed.Do(func() {
+		if s.cmd.Process != nil {
+			_ = syscall.Kill(-s.cmd.Process.Pid, syscall.SIGKILL)
+		}
+		_ = s.stdin.Close()
+		_ = s.stdout.Close()
+		_ = s.stderr.Close()
+		_ = s.cmd.Wait()
+		if s.cmd.ProcessState != nil {
+			exitCode = s.cmd.ProcessState.ExitCode()
+		}
+		s.cleanup()
+	})
+	return exitCode
+}
+
+// sessionOutput keeps at most limit+1 bytes of a stream; the extra byte is
+// the newline printed before the sentinel.
+type sessionOutput struct {
+	buf      bytes.Buffer
+	total    int
+	sentinel bool
+}
+
+func (o *sessionOutput) write(chunk []byte, limit int) {
+	if room := limit + 1 - o.buf.Len(); room > 0 {
+		o.buf.Write(chunk[:min(room, len(chunk))])
+	}
+	o.total += len(chunk)
+}
+
+func (o *sessionOutput) value(limit int) (string, bool) {
+	size := o.total
+	if o.sentinel && size > 0 {
+		size--
+	}
+	if size > limit {
+		return string(o.buf.Bytes()[:limit]), true
+	}
+	return string(o.buf.Bytes()[:size]), false
+}
+
+// readUntilSentinel consumes r up to and including the first line that
+// starts with token, returning the output before it and the line itself
+// without its newline.
+func readUntilSentinel(r *bufio.Reader, token string, limit int) (sessionOutput, string, error) {
+	output := sessionOutput{}
+	atLineStart := true
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			output.write(chunk, limit)
+			atLineStart = false
+			continue
+		}
+		if err != nil {
+			output.write(chunk, limit)
+			return output, "", err
+		}
+		line := strings.TrimSuffix(string(chunk), "\n")
+		if atLineStart && (line == token || strings.HasPrefix(line, token+" ")) {
+			output.sentinel = true
+			return output, line, nil
+		}
+		output.write(chunk, limit)
+		atLineStart = true
+	}
+}
+
+func newSessionSentinel() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "__onlyboxes_" + hex.EncodeToString(raw), nil
+}
+
+func shellSingleQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+type computerUseSession struct {
+	sessionID      string
+	shell          *computerUseShell
+	leaseExpiresAt time.Time
+	busy           bool
+}
+
+type computerUseSessionManagerConfig struct {
+	MaxSessions     int
+	LeaseMinSec     int
+	LeaseMaxSec     int
+	LeaseDefaultSec int
+	StartShell      func() (*computerUseShell, error)
+}
+
+// computerUseSessionManager owns the persistent shells behind
+// session_id, with the same lease rules as terminalExec sessions.
+type computerUseSessionManager struct {
+	mu       sync.Mutex
+	sessions map[string]*computerUseSession
+
+	maxSessions     int
+	leaseMinSec     int
+	leaseMaxSec     int
+	leaseDefaultSec int
+	startShell      func() (*computerUseShell, error)
+
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+}
+
+func newComputerUseSessionManager(cfg computerUseSessionManagerConfig) *computerUseSessionManager {
+	leaseMinSec := cfg.LeaseMinSec
+	if leaseMinSec <= 0 {
+		leaseMinSec = 60
+	}
+	leaseMaxSec := max(cfg.LeaseMaxSec, leaseMinSec)
+	leaseDefaultSec := cfg.LeaseDefaultSec
+	if leaseDefaultSec <= 0 {
+		leaseDefaultSec = leaseMinSec
+	}
+	leaseDefaultSec = min(max(leaseDefaultSec, leaseMinSec), leaseMaxSec)
+
+	manager := &computerUseSessionManager{
+		sessions:        make(map[string]*computerUseSession),
+		maxSessions:     cfg.MaxSessions,
+		leaseMinSec:     leaseMinSec,
+		leaseMaxSec:     leaseMaxSec,
+		leaseDefaultSec: leaseDefaultSec,
+		startShell:      cfg.StartShell,
+		stopCh:          make(chan struct{}),
+		doneCh:          make(chan struct{}),
+	}
+	go manager.janitorLoop()
+	return manager
+}
+
+func (m *computerUseSessionManager) Close() {
+	if m == nil {
+		return
+	}
+	m.closeOnce.Do(func() {
+		close(m.stopCh)
+		<-m.doneCh
+
+		m.mu.Lock()
+		sessions := m.sessions
+		m.sessions = make(map[string]*computerUseSession)
+		m.mu.Unlock()
+		for _, session := range sessions {
+			session.shell.destroy()
+		}
+	})
+}
+
+func (m *computerUseSessionManager) Execute(ctx context.Context, req computerUseRequest, outputLimit int) (computerUseRunResult, error) {
+	leaseDuration, err := m.resolveLeaseDuration(req.LeaseTTLSec)
+	if err != nil {
+		return computerUseRunResult{}, err
+	}
+	session, created, err := m.acquire(strings.TrimSpace(req.SessionID), req.CreateIfMissing, time.Now().Add(leaseDuration))
+	if err != nil {
+		return computerUseRunResult{}, err
+	}
+
+	result, alive, err := session.shell.run(ctx, req.Command, outputLimit)
+	if err != nil || !alive {
+		m.drop(session.sessionID)
+		if err != nil {
+			return computerUseRunResult{}, err
+		}
+	}
+	leaseExpiresAt := m.release(session)
+
+	result.SessionID = session.sessionID
+	result.Created = created
+	result.SessionClosed = !alive
+	if alive {
+		result.LeaseExpiresUnixMS = leaseExpiresAt.UnixMilli()
+	}
+	return result, nil
+}
+
+func (m *computerUseSessionManager) resolveLeaseDuration(leaseTTLSec *int) (time.Duration, error) {
+	leaseSec := m.leaseDefaultSec
+	if leaseTTLSec != nil {
+		leaseSec = *leaseTTLSec
+	}
+	if leaseSec < m.leaseMinSec || leaseSec > m.leaseMaxSec {
+		return 0, newComputerUseError(
+			computerUseCodeInvalidPayload,
+			fmt.Sprintf("lease_ttl_sec must be between %d and %d", m.leaseMinSec, m.leaseMaxSec),
+		)
+	}
+	return time.Duration(leaseSec) * time.Second, nil
+}
+
+// acquire marks an existing session busy, or starts a new shell when the
+// session is missing and createIfMissing is set. An empty sessionID with
+// createIfMissing always creates a session with a generated ID.
+func (m *computerUseSessionManager) acquire(sessionID string, createIfMissing bool, leaseTarget time.Time) (*computerUseSession, bool, error) {
+	m.mu.Lock()
+	if existing, ok := m.sessions[sessionID]; ok && sessionID != "" {
+		defer m.mu.Unlock()
+		if existing.busy {
+			return nil, false, newComputerUseError(computerUseCodeSessionBusy, computerUseSessionBusyMessage)
+		}
+		existing.busy = true
+		if existing.leaseExpiresAt.Before(leaseTarget) {
+			existing.leaseExpiresAt = leaseTarget
+		}
+		return existing, false, nil
+	}
+	if !createIfMissing {
+		m.mu.Unlock()
+		return nil, false, newComputerUseError(computerUseCodeSessionNotFound, computerUseNoSessionMessage)
+	}
+	if len(m.sessions) >= m.maxSessions {
+		m.mu.Unlock()
+		return nil, false, newComputerUseError(computerUseCodeSessionLimit, fmt.Sprintf("session limit %d reached", m.maxSessions))
+	}
+	if sessionID == "" {
+		generated, err := newSessionID()
+		if err != nil {
+			m.mu.Unlock()
+			return nil, false, err
+		}
+		sessionID = generated
+	}
+	// Reserve the slot before starting the shell outside the lock.
+	session := &computerUseSession{sessionID: sessionID, leaseExpiresAt: leaseTarget, busy: true}
+	m.sessions[sessionID] = session
+	m.mu.Unlock()
+
+	shell, err := m.startShell()
+	if err != nil {
+		m.drop(sessionID)
+		return nil, false, err
+	}
+	m.mu.Lock()
+	session.shell = shell
+	m.mu.Unlock()
+	return session, true, nil
+}
+
+func (m *computerUseSessionManager) release(session *computerUseSession) time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session.busy = false
+	return session.leaseExpiresAt
+}
+
+func (m *computerUseSessionManager) drop(sessionID string) {
+	m.mu.Lock()
+	session, ok := m.sessions[sessionID]
+	if ok {
+		delete(m.sessions, sessionID)
+	}
+	m.mu.Unlock()
+	if ok && session.shell != nil {
+		session.shell.destroy()
+	}
+}
+
+func (m *computerUseSessionManager) janitorLoop() {
+	ticker := time.NewTicker(computerUseSessionJanitorPeriod)
+	defer ticker.Stop()
+	defer close(m.doneCh)
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.cleanupExpiredSessions(time.Now())
+		}
+	}
+}
+
+func (m *computerUseSessionManager) cleanupExpiredSessions(now time.Time) {
+	expired := []*computerUseSession{}
+	m.mu.Lock()
+	for sessionID, session := range m.sessions {
+		if session.busy || session.leaseExpiresAt.After(now) {
+			continue
+		}
+		expired = append(expired, session)
+		delete(m.sessions, sessionID)
+	}
+	m.mu.Unlock()
+
+	for _, session := range expired {
+		logging.Infof("computerUse session expired: session_id=%s", session.sessionID)
+		session.shell.destroy()
+	}
+}
+
+func newSessionID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,187 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSessionTestExecutor(t *testing.T, maxSessions int) *computerUseExecutor {
+	t.Helper()
+	executor := newComputerUseExecutor(computerUseExecutorConfig{
+		OutputLimitBytes: 1024,
+		WhitelistMode:    computerUseWhitelistModeAllowAll,
+		MaxSessions:      maxSessions,
+		LeaseMinSec:      1,
+		LeaseMaxSec:      600,
+		LeaseDefaultSec:  60,
+	})
+	t.Cleanup(executor.Close)
+	return executor
+}
+
+func TestComputerUseSessionKeepsCwdAndEnvironment(t *testing.T) {
+	executor := newSessionTestExecutor(t, 2)
+	dir := t.TempDir()
+
+	first, err := executor.Execute(context.Background(), computerUseRequest{
+		Command:         "cd " + dir + " && export ONLYBOXES_VENV=active && printf partial",
+		CreateIfMissing: true,
+	})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if first.SessionID == "" || !first.Created || first.Stdout != "partial" || first.LeaseExpiresUnixMS <= time.Now().UnixMilli() {
+		t.Fatalf("unexpected first result %#v", first)
+	}
+
+	second, err := executor.Execute(context.Background(), computerUseRequest{
+		Command:   `pwd; echo "$ONLYBOXES_VENV"; echo 'it''s' >&2; false`,
+		SessionID: first.SessionID,
+	})
+	if err != nil {
+		t.Fatalf("reuse session: %v", err)
+	}
+	if second.Created || second.Stdout != dir+"\nactive\n" || second.Stderr != "its\n" || second.ExitCode != 1 {
+		t.Fatalf("unexpected second result %#v", second)
+	}
+}
+
+func TestComputerUseSessionErrors(t *testing.T) {
+	executor := newSessionTestExecutor(t, 1)
+
+	_, err := executor.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: "missing"})
+	assertComputerUseErrorCode(t, err, computerUseCodeSessionNotFound)
+
+	created, err := executor.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: "named", CreateIfMissing: true})
+	if err != nil || created.SessionID != "named" {
+		t.Fatalf("expected named session, got %#v err=%v", created, err)
+	}
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: "true", CreateIfMissing: true})
+	assertComputerUseErrorCode(t, err, computerUseCodeSessionLimit)
+
+	// A syntax error is rejected before it can kill the session shell.
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: `echo "open`, SessionID: "named"})
+	assertComputerUseErrorCode(t, err, computerUseCodeInvalidPayload)
+	tooLong := 601
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: "named", LeaseTTLSec: &tooLong})
+	assertComputerUseErrorCode(t, err, computerUseCodeInvalidPayload)
+
+	executor.sessions.mu.Lock()
+	executor.sessions.sessions["named"].busy = true
+	executor.sessions.mu.Unlock()
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: "named"})
+	assertComputerUseErrorCode(t, err, computerUseCodeSessionBusy)
+}
+
+func TestComputerUseSessionExitClosesSession(t *testing.T) {
+	executor := newSessionTestExecutor(t, 1)
+
+	result, err := executor.Execute(context.Background(), computerUseRequest{Command: "echo bye; exit 3", SessionID: "s", CreateIfMissing: true})
+	if err != nil {
+		t.Fatalf("run exit: %v", err)
+	}
+	if !result.SessionClosed || result.ExitCode != 3 || result.Stdout != "bye\n" || result.LeaseExpiresUnixMS != 0 {
+		t.Fatalf("unexpected exit result %#v", result)
+	}
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: "s"})
+	assertComputerUseErrorCode(t, err, computerUseCodeSessionNotFound)
+}
+
+func TestComputerUseSessionTimeoutDestroysSession(t *testing.T) {
+	executor := newSessionTestExecutor(t, 1)
+	if _, err := executor.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: "s", CreateIfMissing: true}); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := executor.Execute(ctx, computerUseRequest{Command: "sleep 30 & sleep 30", SessionID: "s"})
+	if err == nil || time.Since(started) > 5*time.Second {
+		t.Fatalf("expected prompt timeout, got err=%v after %s", err, time.Since(started))
+	}
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: "s"})
+	assertComputerUseErrorCode(t, err, computerUseCodeSessionNotFound)
+}
+
+func TestComputerUseSessionOutputIsTruncated(t *testing.T) {
+	executor := newSessionTestExecutor(t, 1)
+	executor.outputLimitBytes = 4
+
+	result, err := executor.Execute(context.Background(), computerUseRequest{Command: "printf 0123456789; printf abcd >&2", CreateIfMissing: true})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Stdout != "0123" || !result.StdoutTruncated || result.Stderr != "abcd" || result.StderrTruncated {
+		t.Fatalf("unexpected truncation %#v", result)
+	}
+}
+
+func TestComputerUseSessionExpiresAfterLease(t *testing.T) {
+	executor := newSessionTestExecutor(t, 1)
+	lease := 1
+	result, err := executor.Execute(context.Background(), computerUseRequest{Command: "true", CreateIfMissing: true, LeaseTTLSec: &lease})
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	executor.sessions.cleanupExpiredSessions(time.Now().Add(2 * time.Second))
+	_, err = executor.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: result.SessionID})
+	assertComputerUseErrorCode(t, err, computerUseCodeSessionNotFound)
+}
+
+func TestComputerUseSessionsRequireConfiguration(t *testing.T) {
+	executor := newSessionTestExecutor(t, 0)
+	_, err := executor.Execute(context.Background(), computerUseRequest{Command: "true", CreateIfMissing: true})
+	if err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Fatalf("expected sessions to be disabled, got %v", err)
+	}
+}
+
+func TestComputerUseSessionChargesCgroupCPUDelta(t *testing.T) {
+	cgroupDir := t.TempDir()
+	writeCgroup := func(usageUsec string, peak string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(cgroupDir, "cpu.stat"), []byte("usage_usec "+usageUsec+"\nuser_usec 0\n"), 0o644); err != nil {
+			t.Fatalf("write cpu.stat: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(cgroupDir, "memory.peak"), []byte(peak+"\n"), 0o644); err != nil {
+			t.Fatalf("write memory.peak: %v", err)
+		}
+	}
+	manager := newComputerUseSessionManager(computerUseSessionManagerConfig{
+		MaxSessions:     1,
+		LeaseMinSec:     1,
+		LeaseMaxSec:     600,
+		LeaseDefaultSec: 60,
+		StartShell: func() (*computerUseShell, error) {
+			shell, err := startComputerUseShell(nil, os.Environ())
+			if err == nil {
+				shell.cgroupDir = cgroupDir
+			}
+			return shell, err
+		},
+	})
+	t.Cleanup(manager.Close)
+
+	writeCgroup("1500000", "4096")
+	first, err := manager.Execute(context.Background(), computerUseRequest{Command: "true", CreateIfMissing: true}, 1024)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if first.Usage.CPUSeconds != 1.5 || first.Usage.PeakMemoryBytes != 4096 {
+		t.Fatalf("unexpected first usage %#v", first.Usage)
+	}
+
+	writeCgroup("1750000", "8192")
+	second, err := manager.Execute(context.Background(), computerUseRequest{Command: "true", SessionID: first.SessionID}, 1024)
+	if err != nil {
+		t.Fatalf("reuse session: %v", err)
+	}
+	if second.Usage.CPUSeconds != 0.25 || second.Usage.PeakMemoryBytes != 8192 {
+		t.Fatalf("expected only the added CPU time to be charged, got %#v", second.Usage)
+	}
+}
@@ -131,3 +131,23 @@ func assertComputerUseErrorCode(t *testing.T, err error, wantCode string) {
 		t.Fatalf("expected error code %q, got %q", wantCode, cuErr.Code())
 	}
 }
+
+func TestComputerUseReportsProcessUsage(t *testing.T) {
+	executor := newComputerUseExecutor(computerUseExecutorConfig{
+		OutputLimitBytes: 1024,
+		WhitelistMode:    computerUseWhitelistModeAllowAll,
+	})
+
+	result, err := executor.Execute(context.Background(), computerUseRequest{
+		Command: `i=0; while [ "$i" -lt 200000 ]; do i=$((i+1)); done; exit 3`,
+	})
+	if err != nil {
+		t.Fatalf("execute failed: %v", err)
+	}
+	if result.ExitCode != 3 {
+		t.Fatalf("expected exit code 3, got %d", result.ExitCode)
+	}
+	if result.Usage.CPUSeconds <= 0 || result.Usage.PeakMemoryBytes < 1024*1024 {
+		t.Fatalf("expected rusage of the exited command, got %#v", result.Usage)
+	}
+}
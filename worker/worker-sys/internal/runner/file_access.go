@@ -0,0 +1,520 @@
+package runner
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+)
+
+const (
+	fileAccessNotReadyMessage       = "file access executor is unavailable"
+	fileAccessCodeInvalidPayload    = "invalid_payload"
+	fileAccessCodeSessionNotFound   = "session_not_found"
+	fileAccessCodeFileNotFound      = "file_not_found"
+	fileAccessCodePathIsDirectory   = "path_is_directory"
+	fileAccessCodePathNotDirectory  = "path_not_directory"
+	fileAccessCodePathNotAllowed    = "path_not_allowed"
+	fileAccessCodeFileTooLarge      = "file_too_large"
+	fileAccessEncodingUTF8          = "utf-8"
+	fileAccessEncodingBase64        = "base64"
+	fileAccessDefaultFileMode       = 0o644
+	fileAccessTempFilePatternSuffix = ".tmp-*"
+	listDirEntryTypeFile            = "file"
+	listDirEntryTypeDir             = "dir"
+	listDirEntryTypeSymlink         = "symlink"
+	listDirEntryTypeOther           = "other"
+)
+
+type readFilePayload struct {
+	SessionID string `json:"session_id"`
+	FilePath  string `json:"file_path"`
+	Offset    int64  `json:"offset,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+type readFileRequest struct {
+	SessionID string
+	FilePath  string
+	Offset    int64
+	Limit     int
+}
+
+type readFileRunResult struct {
+	SessionID string `json:"session_id"`
+	FilePath  string `json:"file_path"`
+	SizeBytes int64  `json:"size_bytes"`
+	Offset    int64  `json:"offset"`
+	Content   string `json:"content"`
+	Encoding  string `json:"encoding"`
+	Truncated bool   `json:"truncated"`
+}
+
+type writeFilePayload struct {
+	SessionID string `json:"session_id"`
+	FilePath  string `json:"file_path"`
+	Content   string `json:"content"`
+	Encoding  string `json:"encoding,omitempty"`
+	Append    bool   `json:"append,omitempty"`
+}
+
+type writeFileRequest struct {
+	SessionID string
+	FilePath  string
+	Content   string
+	Encoding  string
+	Append    bool
+}
+
+type writeFileRunResult struct {
+	SessionID    string `json:"session_id"`
+	FilePath     string `json:"file_path"`
+	BytesWritten int64  `json:"bytes_written"`
+	SizeBytes    int64  `json:"size_bytes"`
+	Created      bool   `json:"created"`
+}
+
+type listDirPayload struct {
+	SessionID string `json:"session_id"`
+	DirPath   string `json:"dir_path"`
+}
+
+type listDirRequest struct {
+	SessionID string
+	DirPath   string
+}
+
+type listDirEntry struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	SizeBytes     int64  `json:"size_bytes"`
+	ModTimeUnixMS int64  `json:"mod_time_unix_ms"`
+}
+
+type listDirRunResult struct {
+	SessionID string         `json:"session_id"`
+	DirPath   string         `json:"dir_path"`
+	Entries   []listDirEntry `json:"entries"`
+	Truncated bool           `json:"truncated"`
+}
+
+type fileAccessError struct {
+	code    string
+	message string
+}
+
+func (e *fileAccessError) Error() string {
+	if e == nil {
+		return "file access failed"
+	}
+	return e.message
+}
+
+func (e *fileAccessError) Code() string {
+	if e == nil {
+		return ""
+	}
+	return e.code
+}
+
+func newFileAccessError(code string, message string) *fileAccessError {
+	return &fileAccessError{
+		code:    strings.TrimSpace(code),
+		message: strings.TrimSpace(message),
+	}
+}
+
+type fileAccessExecutorConfig struct {
+	ReadAllowedPaths  []string
+	WriteAllowedPaths []string
+	ReadMaxBytes      int
+	WriteMaxBytes     int
+	ListDirMaxEntries int
+}
+
+// fileAccessExecutor serves readFile, writeFile and listDir. Reads and
+// directory listings are gated by the read rules, writes by the separate
+// write rules; both deny everything when empty. The operations run in the
+// worker process with its OS identity, not the sandbox run-as user, so the
+// rules are the only boundary: file permissions are checked against the
+// worker account.
+type fileAccessExecutor struct {
+	readRules         []allowedPathRule
+	writeRules        []allowedPathRule
+	readMaxBytes      int
+	writeMaxBytes     int
+	listDirMaxEntries int
+}
+
+func newFileAccessExecutor(cfg fileAccessExecutorConfig) *fileAccessExecutor {
+	return &fileAccessExecutor{
+		readRules:         compileAllowedPathRules(cfg.ReadAllowedPaths),
+		writeRules:        compileAllowedPathRules(cfg.WriteAllowedPaths),
+		readMaxBytes:      max(cfg.ReadMaxBytes, 1),
+		writeMaxBytes:     max(cfg.WriteMaxBytes, 1),
+		listDirMaxEntries: max(cfg.ListDirMaxEntries, 1),
+	}
+}
+
+func (e *fileAccessExecutor) ReadFile(ctx context.Context, req readFileRequest) (readFileRunResult, error) {
+	if e == nil {
+		return readFileRunResult{}, newFileAccessError("execution_failed", fileAccessNotReadyMessage)
+	}
+	if err := ctx.Err(); err != nil {
+		return readFileRunResult{}, err
+	}
+	if err := validateFileAccessSession(req.SessionID); err != nil {
+		return readFileRunResult{}, err
+	}
+	if strings.TrimSpace(req.FilePath) == "" {
+		return readFileRunResult{}, newFileAccessError(fileAccessCodeInvalidPayload, "session_id and file_path are required")
+	}
+	if req.Offset < 0 || req.Limit < 0 {
+		return readFileRunResult{}, newFileAccessError(fileAccessCodeInvalidPayload, "offset and limit must not be negative")
+	}
+
+	normalizedPath, err := normalizeRequestPath(req.FilePath)
+	if err != nil || !pathRulesAllowLexically(e.readRules, normalizedPath) {
+		return readFileRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+	}
+
+	// O_NONBLOCK keeps a FIFO at the path from blocking the open until a
+	// writer shows up; it is rejected as a non-regular file below.
+	file, err := os.OpenFile(normalizedPath, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return readFileRunResult{}, newFileAccessError(fileAccessCodeFileNotFound, "file not found")
+		}
+		return readFileRunResult{}, fmt.Errorf("open file failed: %w", err)
+	}
+	defer file.Close()
+
+	openedInfo, err := file.Stat()
+	if err != nil {
+		return readFileRunResult{}, fmt.Errorf("stat file failed: %w", err)
+	}
+	if openedInfo.IsDir() {
+		return readFileRunResult{}, newFileAccessError(fileAccessCodePathIsDirectory, "path is directory")
+	}
+	if !openedInfo.Mode().IsRegular() {
+		return readFileRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+	}
+	bound, err := pathRulesBindOpenedFile(e.readRules, normalizedPath, openedInfo)
+	if err != nil {
+		return readFileRunResult{}, fmt.Errorf("validate file binding failed: %w", err)
+	}
+	if !bound {
+		return readFileRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+	}
+
+	limit := e.readMaxBytes
+	if req.Limit > 0 && req.Limit < limit {
+		limit = req.Limit
+	}
+	content, err := io.ReadAll(io.NewSectionReader(file, req.Offset, int64(limit)))
+	if err != nil {
+		return readFileRunResult{}, fmt.Errorf("read file failed: %w", err)
+	}
+
+	result := readFileRunResult{
+		SessionID: computerUseSessionID,
+		FilePath:  normalizedPath,
+		SizeBytes: openedInfo.Size(),
+		Offset:    req.Offset,
+		Encoding:  fileAccessEncodingUTF8,
+		Truncated: req.Offset+int64(len(content)) < openedInfo.Size(),
+	}
+	if utf8.Valid(content) {
+		result.Content = string(content)
+	} else {
+		result.Content = base64.StdEncoding.EncodeToString(content)
+		result.Encoding = fileAccessEncodingBase64
+	}
+	return result, nil
+}
+
+func (e *fileAccessExecutor) WriteFile(ctx context.Context, req writeFileRequest) (writeFileRunResult, error) {
+	if e == nil {
+		return writeFileRunResult{}, newFileAccessError("execution_failed", fileAccessNotReadyMessage)
+	}
+	if err := ctx.Err(); err != nil {
+		return writeFileRunResult{}, err
+	}
+	if err := validateFileAccessSession(req.SessionID); err != nil {
+		return writeFileRunResult{}, err
+	}
+	if strings.TrimSpace(req.FilePath) == "" {
+		return writeFileRunResult{}, newFileAccessError(fileAccessCodeInvalidPayload, "session_id and file_path are required")
+	}
+	content, err := decodeWriteFileContent(req.Content, req.Encoding)
+	if err != nil {
+		return writeFileRunResult{}, err
+	}
+	if len(content) > e.writeMaxBytes {
+		return writeFileRunResult{}, newFileAccessError(fileAccessCodeFileTooLarge, fmt.Sprintf("content exceeds %d bytes", e.writeMaxBytes))
+	}
+
+	normalizedPath, err := normalizeRequestPath(req.FilePath)
+	if err != nil || !pathRulesAllowLexically(e.writeRules, normalizedPath) {
+		return writeFileRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+	}
+
+	// Resolve the parent only: the final component is never followed, so a
+	// symlink planted at the target cannot redirect the write.
+	resolvedParent, err := evalRealPath(filepath.Dir(normalizedPath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return writeFileRunResult{}, newFileAccessError(fileAccessCodeFileNotFound, "parent directory not found")
+		}
+		return writeFileRunResult{}, fmt.Errorf("resolve parent directory failed: %w", err)
+	}
+	targetPath := filepath.Join(resolvedParent, filepath.Base(normalizedPath))
+	allowed, err := resolvedPathRulesAllow(e.writeRules, targetPath)
+	if err != nil {
+		return writeFileRunResult{}, fmt.Errorf("validate write path failed: %w", err)
+	}
+	if !allowed {
+		return writeFileRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+	}
+
+	existingInfo, err := os.Lstat(targetPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		existingInfo = nil
+	case err != nil:
+		return writeFileRunResult{}, fmt.Errorf("stat file failed: %w", err)
+	case existingInfo.IsDir():
+		return writeFileRunResult{}, newFileAccessError(fileAccessCodePathIsDirectory, "path is directory")
+	case !existingInfo.Mode().IsRegular():
+		return writeFileRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+	}
+
+	if req.Append && existingInfo != nil {
+		err = appendFileAccessContent(targetPath, existingInfo, content, int64(e.writeMaxBytes))
+	} else {
+		err = replaceFileAccessContent(targetPath, existingInfo, content)
+	}
+	if err != nil {
+		var fileAccessErr *fileAccessError
+		if errors.As(err, &fileAccessErr) {
+			return writeFileRunResult{}, fileAccessErr
+		}
+		return writeFileRunResult{}, fmt.Errorf("write file failed: %w", err)
+	}
+
+	writtenInfo, err := os.Lstat(targetPath)
+	if err != nil {
+		return writeFileRunResult{}, fmt.Errorf("stat written file failed: %w", err)
+	}
+	return writeFileRunResult{
+		SessionID:    computerUseSessionID,
+		FilePath:     normalizedPath,
+		BytesWritten: int64(len(content)),
+		SizeBytes:    writtenInfo.Size(),
+		Created:      existingInfo == nil,
+	}, nil
+}
+
+// replaceFileAccessContent writes content to a temporary file next to
+// targetPath and renames it into place. rename replaces a symlink rather
+// than following it, and readers never observe a partial file.
+func replaceFileAccessContent(targetPath string, existingInfo os.FileInfo, content []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(targetPath), "."+filepath.Base(targetPath)+fileAccessTempFilePatternSuffix)
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = os.Remove(tempPath)
+		}
+	}()
+
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+	mode := os.FileMode(fileAccessDefaultFileMode)
+	if existingInfo != nil {
+		mode = existingInfo.Mode().Perm()
+	}
+	if err := tempFile.Chmod(mode); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, targetPath); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// appendFileAccessContent opens an existing regular file without O_CREATE
+// and checks it is still the file inspected before, so a concurrent swap to
+// a symlink or FIFO is rejected before anything is written. maxBytes caps
+// the size of the file after the append, like a replace.
+func appendFileAccessContent(targetPath string, existingInfo os.FileInfo, content []byte, maxBytes int64) error {
+	file, err := os.OpenFile(targetPath, os.O_WRONLY|os.O_APPEND|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return newFileAccessError(fileAccessCodeFileNotFound, "file not found")
+		}
+		return err
+	}
+	defer file.Close()
+
+	openedInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	currentInfo, err := os.Lstat(targetPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+		}
+		return err
+	}
+	if !currentInfo.Mode().IsRegular() || !os.SameFile(existingInfo, openedInfo) || !os.SameFile(openedInfo, currentInfo) {
+		return newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+	}
+	if openedInfo.Size()+int64(len(content)) > maxBytes {
+		return newFileAccessError(fileAccessCodeFileTooLarge, fmt.Sprintf("file would exceed %d bytes", maxBytes))
+	}
+
+	if _, err := file.Write(content); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (e *fileAccessExecutor) ListDir(ctx context.Context, req listDirRequest) (listDirRunResult, error) {
+	if e == nil {
+		return listDirRunResult{}, newFileAccessError("execution_failed", fileAccessNotReadyMessage)
+	}
+	if err := ctx.Err(); err != nil {
+		return listDirRunResult{}, err
+	}
+	if err := validateFileAccessSession(req.SessionID); err != nil {
+		return listDirRunResult{}, err
+	}
+	if strings.TrimSpace(req.DirPath) == "" {
+		return listDirRunResult{}, newFileAccessError(fileAccessCodeInvalidPayload, "session_id and dir_path are required")
+	}
+
+	normalizedPath, err := normalizeRequestPath(req.DirPath)
+	if err != nil || !pathRulesAllowLexically(e.readRules, normalizedPath) {
+		return listDirRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "directory path is not allowed")
+	}
+
+	// List the resolved directory so the listing is of the exact directory
+	// that passed the real path check.
+	resolvedPath, err := evalRealPath(normalizedPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return listDirRunResult{}, newFileAccessError(fileAccessCodeFileNotFound, "directory not found")
+		}
+		return listDirRunResult{}, fmt.Errorf("resolve directory failed: %w", err)
+	}
+	allowed, err := resolvedPathRulesAllow(e.readRules, resolvedPath)
+	if err != nil {
+		return listDirRunResult{}, fmt.Errorf("validate directory path failed: %w", err)
+	}
+	if !allowed {
+		return listDirRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "directory path is not allowed")
+	}
+
+	dir, err := os.Open(resolvedPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return listDirRunResult{}, newFileAccessError(fileAccessCodeFileNotFound, "directory not found")
+		}
+		return listDirRunResult{}, fmt.Errorf("open directory failed: %w", err)
+	}
+	defer dir.Close()
+
+	info, err := dir.Stat()
+	if err != nil {
+		return listDirRunResult{}, fmt.Errorf("stat directory failed: %w", err)
+	}
+	if !info.IsDir() {
+		return listDirRunResult{}, newFileAccessError(fileAccessCodePathNotDirectory, "path is not a directory")
+	}
+
+	dirEntries, err := dir.ReadDir(e.listDirMaxEntries + 1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return listDirRunResult{}, fmt.Errorf("read directory failed: %w", err)
+	}
+	truncated := len(dirEntries) > e.listDirMaxEntries
+	if truncated {
+		dirEntries = dirEntries[:e.listDirMaxEntries]
+	}
+
+	entries := make([]listDirEntry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		entry := listDirEntry{
+			Name: dirEntry.Name(),
+			Type: listDirEntryType(dirEntry.Type()),
+		}
+		if entryInfo, err := dirEntry.Info(); err == nil {
+			entry.SizeBytes = entryInfo.Size()
+			entry.ModTimeUnixMS = entryInfo.ModTime().UnixMilli()
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return listDirRunResult{
+		SessionID: computerUseSessionID,
+		DirPath:   normalizedPath,
+		Entries:   entries,
+		Truncated: truncated,
+	}, nil
+}
+
+func validateFileAccessSession(sessionID string) error {
+	if strings.TrimSpace(sessionID) != computerUseSessionID {
+		return newFileAccessError(fileAccessCodeSessionNotFound, "session not found")
+	}
+	return nil
+}
+
+func decodeWriteFileContent(content string, encoding string) ([]byte, error) {
+	switch strings.TrimSpace(strings.ToLower(encoding)) {
+	case "", fileAccessEncodingUTF8:
+		return []byte(content), nil
+	case fileAccessEncodingBase64:
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, newFileAccessError(fileAccessCodeInvalidPayload, "content is not valid base64")
+		}
+		return decoded, nil
+	default:
+		return nil, newFileAccessError(fileAccessCodeInvalidPayload, "encoding must be utf-8 or base64")
+	}
+}
+
+func listDirEntryType(mode os.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return listDirEntryTypeDir
+	case mode&os.ModeSymlink != 0:
+		return listDirEntryTypeSymlink
+	case mode.IsRegular():
+		return listDirEntryTypeFile
+	default:
+		return listDirEntryTypeOther
+	}
+}
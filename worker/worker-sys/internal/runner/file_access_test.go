@@ -0,0 +1,418 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	registryv1 "github.com/onlyboxes/onlyboxes/api/gen/go/registry/v1"
+)
+
+func newTestFileAccessExecutor(readPaths []string, writePaths []string) *fileAccessExecutor {
+	return newFileAccessExecutor(fileAccessExecutorConfig{
+		ReadAllowedPaths:  readPaths,
+		WriteAllowedPaths: writePaths,
+		ReadMaxBytes:      1024,
+		WriteMaxBytes:     16,
+		ListDirMaxEntries: 2,
+	})
+}
+
+func TestFileAccessReadFileWithOffsetAndLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(filePath, []byte("hello world"), 0o600); err != nil {
+		t.Fatalf("write test file failed: %v", err)
+	}
+
+	executor := newTestFileAccessExecutor([]string{tmpDir}, nil)
+	result, err := executor.ReadFile(context.Background(), readFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filePath,
+		Offset:    6,
+		Limit:     3,
+	})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if result.Content != "wor" || result.Encoding != fileAccessEncodingUTF8 {
+		t.Fatalf("unexpected content %q encoding %q", result.Content, result.Encoding)
+	}
+	if !result.Truncated || result.SizeBytes != 11 || result.Offset != 6 {
+		t.Fatalf("unexpected read result: %#v", result)
+	}
+}
+
+func TestFileAccessReadFileEncodesBinaryAsBase64(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "blob.bin")
+	if err := os.WriteFile(filePath, []byte{0xff, 0xfe, 0x00}, 0o600); err != nil {
+		t.Fatalf("write test file failed: %v", err)
+	}
+
+	executor := newTestFileAccessExecutor([]string{filePath}, nil)
+	result, err := executor.ReadFile(context.Background(), readFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filePath,
+	})
+	if err != nil {
+		t.Fatalf("readFile failed: %v", err)
+	}
+	if result.Encoding != fileAccessEncodingBase64 || result.Content != "//4A" || result.Truncated {
+		t.Fatalf("unexpected read result: %#v", result)
+	}
+}
+
+func TestFileAccessReadFileRejectsEscapes(t *testing.T) {
+	tmpDir := t.TempDir()
+	allowedDir := filepath.Join(tmpDir, "allowed")
+	if err := os.MkdirAll(allowedDir, 0o755); err != nil {
+		t.Fatalf("create allowed dir failed: %v", err)
+	}
+	outsidePath := filepath.Join(tmpDir, "secret.txt")
+	if err := os.WriteFile(outsidePath, []byte("secret"), 0o600); err != nil {
+		t.Fatalf("write outside file failed: %v", err)
+	}
+	linkPath := filepath.Join(allowedDir, "link.txt")
+	if err := os.Symlink(outsidePath, linkPath); err != nil {
+		t.Skipf("symlink not available: %v", err)
+	}
+
+	executor := newTestFileAccessExecutor([]string{allowedDir}, nil)
+	for _, filePath := range []string{
+		allowedDir + "/../secret.txt",
+		linkPath,
+	} {
+		_, err := executor.ReadFile(context.Background(), readFileRequest{
+			SessionID: computerUseSessionID,
+			FilePath:  filePath,
+		})
+		assertFileAccessErrorCode(t, err, fileAccessCodePathNotAllowed)
+	}
+}
+
+func TestFileAccessReadFileRequiresComputerUseSession(t *testing.T) {
+	executor := newTestFileAccessExecutor([]string{t.TempDir()}, nil)
+	_, err := executor.ReadFile(context.Background(), readFileRequest{
+		SessionID: "other",
+		FilePath:  "/tmp/a.txt",
+	})
+	assertFileAccessErrorCode(t, err, fileAccessCodeSessionNotFound)
+}
+
+func TestFileAccessWriteFileCreatesAndAppends(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "out.txt")
+
+	executor := newTestFileAccessExecutor(nil, []string{tmpDir})
+	created, err := executor.WriteFile(context.Background(), writeFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filePath,
+		Content:   "aGVsbG8=",
+		Encoding:  fileAccessEncodingBase64,
+	})
+	if err != nil {
+		t.Fatalf("writeFile failed: %v", err)
+	}
+	if !created.Created || created.BytesWritten != 5 || created.SizeBytes != 5 {
+		t.Fatalf("unexpected create result: %#v", created)
+	}
+
+	appended, err := executor.WriteFile(context.Background(), writeFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filePath,
+		Content:   " world",
+		Append:    true,
+	})
+	if err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if appended.Created || appended.SizeBytes != 11 {
+		t.Fatalf("unexpected append result: %#v", appended)
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("read written file failed: %v", err)
+	}
+	if string(content) != "hello world" {
+		t.Fatalf("unexpected file content %q", string(content))
+	}
+}
+
+func TestFileAccessWriteFileUsesSeparateRoots(t *testing.T) {
+	tmpDir := t.TempDir()
+	readOnlyDir := filepath.Join(tmpDir, "ro")
+	if err := os.MkdirAll(readOnlyDir, 0o755); err != nil {
+		t.Fatalf("create read dir failed: %v", err)
+	}
+
+	executor := newTestFileAccessExecutor([]string{readOnlyDir}, nil)
+	_, err := executor.WriteFile(context.Background(), writeFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filepath.Join(readOnlyDir, "out.txt"),
+		Content:   "data",
+	})
+	assertFileAccessErrorCode(t, err, fileAccessCodePathNotAllowed)
+}
+
+func TestFileAccessWriteFileRejectsSymlinkTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	allowedDir := filepath.Join(tmpDir, "allowed")
+	if err := os.MkdirAll(allowedDir, 0o755); err != nil {
+		t.Fatalf("create allowed dir failed: %v", err)
+	}
+	outsidePath := filepath.Join(tmpDir, "outside.txt")
+	if err := os.WriteFile(outsidePath, []byte("keep"), 0o600); err != nil {
+		t.Fatalf("write outside file failed: %v", err)
+	}
+	linkPath := filepath.Join(allowedDir, "link.txt")
+	if err := os.Symlink(outsidePath, linkPath); err != nil {
+		t.Skipf("symlink not available: %v", err)
+	}
+	linkDir := filepath.Join(allowedDir, "linkdir")
+	if err := os.Symlink(tmpDir, linkDir); err != nil {
+		t.Skipf("symlink not available: %v", err)
+	}
+
+	executor := newTestFileAccessExecutor(nil, []string{allowedDir})
+	for _, filePath := range []string{linkPath, filepath.Join(linkDir, "outside.txt")} {
+		_, err := executor.WriteFile(context.Background(), writeFileRequest{
+			SessionID: computerUseSessionID,
+			FilePath:  filePath,
+			Content:   "overwrite",
+		})
+		assertFileAccessErrorCode(t, err, fileAccessCodePathNotAllowed)
+	}
+	content, err := os.ReadFile(outsidePath)
+	if err != nil {
+		t.Fatalf("read outside file failed: %v", err)
+	}
+	if string(content) != "keep" {
+		t.Fatalf("outside file was modified: %q", string(content))
+	}
+}
+
+func TestFileAccessWriteFileEnforcesSizeLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	executor := newTestFileAccessExecutor(nil, []string{tmpDir})
+	_, err := executor.WriteFile(context.Background(), writeFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filepath.Join(tmpDir, "big.txt"),
+		Content:   "0123456789abcdefg",
+	})
+	assertFileAccessErrorCode(t, err, fileAccessCodeFileTooLarge)
+}
+
+func TestFileAccessWriteFileCapsAppendedSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "log.txt")
+	if err := os.WriteFile(filePath, []byte("0123456789"), 0o600); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	executor := newTestFileAccessExecutor(nil, []string{tmpDir})
+	_, err := executor.WriteFile(context.Background(), writeFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filePath,
+		Content:   "abcdefg",
+		Append:    true,
+	})
+	assertFileAccessErrorCode(t, err, fileAccessCodeFileTooLarge)
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("read file failed: %v", err)
+	}
+	if string(content) != "0123456789" {
+		t.Fatalf("file was modified: %q", string(content))
+	}
+}
+
+func TestFileAccessRejectsFIFOWithoutBlocking(t *testing.T) {
+	tmpDir := t.TempDir()
+	fifoPath := filepath.Join(tmpDir, "pipe")
+	if err := syscall.Mkfifo(fifoPath, 0o600); err != nil {
+		t.Skipf("mkfifo not available: %v", err)
+	}
+
+	executor := newTestFileAccessExecutor([]string{tmpDir}, []string{tmpDir})
+	errs := make(chan error, 2)
+	go func() {
+		_, err := executor.ReadFile(context.Background(), readFileRequest{
+			SessionID: computerUseSessionID,
+			FilePath:  fifoPath,
+		})
+		errs <- err
+		_, err = executor.WriteFile(context.Background(), writeFileRequest{
+			SessionID: computerUseSessionID,
+			FilePath:  fifoPath,
+			Content:   "data",
+			Append:    true,
+		})
+		errs <- err
+	}()
+	for range 2 {
+		select {
+		case err := <-errs:
+			assertFileAccessErrorCode(t, err, fileAccessCodePathNotAllowed)
+		case <-time.After(5 * time.Second):
+			t.Fatal("file access blocked on a FIFO")
+		}
+	}
+}
+
+// File access does not switch to WORKER_COMPUTER_USE_RUN_AS_USER: a file
+// only the worker account can read is readable once the rules allow it, and
+// the rules alone decide what is reachable.
+func TestFileAccessRunsAsWorkerIdentity(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("needs a root worker to compare against a less privileged account")
+	}
+	tmpDir := t.TempDir()
+	allowedDir := filepath.Join(tmpDir, "allowed")
+	if err := os.MkdirAll(allowedDir, 0o755); err != nil {
+		t.Fatalf("create allowed dir failed: %v", err)
+	}
+	for _, path := range []string{filepath.Join(allowedDir, "private.txt"), filepath.Join(tmpDir, "outside.txt")} {
+		if err := os.WriteFile(path, []byte("private"), 0o600); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+	}
+
+	executor := newTestFileAccessExecutor([]string{allowedDir}, nil)
+	result, err := executor.ReadFile(context.Background(), readFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filepath.Join(allowedDir, "private.txt"),
+	})
+	if err != nil || result.Content != "private" {
+		t.Fatalf("expected the worker account to read the allowed file, got %#v, %v", result, err)
+	}
+	_, err = executor.ReadFile(context.Background(), readFileRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  filepath.Join(tmpDir, "outside.txt"),
+	})
+	assertFileAccessErrorCode(t, err, fileAccessCodePathNotAllowed)
+}
+
+func TestFileAccessListDirSortsAndTruncates(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"c.txt", "a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(name), 0o600); err != nil {
+			t.Fatalf("write %s failed: %v", name, err)
+		}
+	}
+
+	executor := newTestFileAccessExecutor([]string{tmpDir}, nil)
+	result, err := executor.ListDir(context.Background(), listDirRequest{
+		SessionID: computerUseSessionID,
+		DirPath:   tmpDir,
+	})
+	if err != nil {
+		t.Fatalf("listDir failed: %v", err)
+	}
+	if !result.Truncated || len(result.Entries) != 2 {
+		t.Fatalf("unexpected listDir result: %#v", result)
+	}
+	if result.Entries[0].Name > result.Entries[1].Name || result.Entries[0].Type != listDirEntryTypeFile {
+		t.Fatalf("unexpected entries: %#v", result.Entries)
+	}
+}
+
+func TestFileAccessListDirRejectsFileAndOutsidePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	allowedDir := filepath.Join(tmpDir, "allowed")
+	if err := os.MkdirAll(allowedDir, 0o755); err != nil {
+		t.Fatalf("create allowed dir failed: %v", err)
+	}
+	filePath := filepath.Join(allowedDir, "a.txt")
+	if err := os.WriteFile(filePath, []byte("a"), 0o600); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	executor := newTestFileAccessExecutor([]string{allowedDir}, nil)
+	_, err := executor.ListDir(context.Background(), listDirRequest{
+		SessionID: computerUseSessionID,
+		DirPath:   filePath,
+	})
+	assertFileAccessErrorCode(t, err, fileAccessCodePathNotDirectory)
+
+	_, err = executor.ListDir(context.Background(), listDirRequest{
+		SessionID: computerUseSessionID,
+		DirPath:   tmpDir,
+	})
+	assertFileAccessErrorCode(t, err, fileAccessCodePathNotAllowed)
+}
+
+func TestBuildCommandResultWriteFileMapsDomainError(t *testing.T) {
+	originalRunWriteFile := runWriteFile
+	t.Cleanup(func() {
+		runWriteFile = originalRunWriteFile
+	})
+
+	runWriteFile = func(_ context.Context, req writeFileRequest) (writeFileRunResult, error) {
+		if req.FilePath != "/tmp/a.txt" || req.Content != "hi" || !req.Append {
+			t.Fatalf("unexpected writeFile request: %#v", req)
+		}
+		return writeFileRunResult{}, newFileAccessError(fileAccessCodePathNotAllowed, "file path is not allowed")
+	}
+
+	req := buildCommandResult(&registryv1.CommandDispatch{
+		CommandId:   "cmd-write-file",
+		Capability:  writeFileCapabilityDeclared,
+		PayloadJson: []byte(`{"session_id":"computerUse","file_path":"/tmp/a.txt","content":"hi","append":true}`),
+	})
+	result := req.GetCommandResult()
+	if result == nil || result.GetError() == nil {
+		t.Fatalf("expected command error, got %#v", req)
+	}
+	if result.GetError().GetCode() != fileAccessCodePathNotAllowed {
+		t.Fatalf("expected error code %q, got %q", fileAccessCodePathNotAllowed, result.GetError().GetCode())
+	}
+}
+
+func TestBuildCommandResultListDirSuccess(t *testing.T) {
+	originalRunListDir := runListDir
+	t.Cleanup(func() {
+		runListDir = originalRunListDir
+	})
+
+	runListDir = func(_ context.Context, req listDirRequest) (listDirRunResult, error) {
+		return listDirRunResult{
+			SessionID: computerUseSessionID,
+			DirPath:   req.DirPath,
+			Entries:   []listDirEntry{{Name: "a.txt", Type: listDirEntryTypeFile, SizeBytes: 1}},
+		}, nil
+	}
+
+	req := buildCommandResult(&registryv1.CommandDispatch{
+		CommandId:   "cmd-list-dir",
+		Capability:  listDirCapabilityDeclared,
+		PayloadJson: []byte(`{"session_id":"computerUse","dir_path":"/tmp"}`),
+	})
+	result := req.GetCommandResult()
+	if result == nil || result.GetError() != nil {
+		t.Fatalf("expected success, got %#v", req)
+	}
+	decoded := listDirRunResult{}
+	if err := json.Unmarshal(result.GetPayloadJson(), &decoded); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if decoded.DirPath != "/tmp" || len(decoded.Entries) != 1 || decoded.Entries[0].Name != "a.txt" {
+		t.Fatalf("unexpected listDir payload: %#v", decoded)
+	}
+}
+
+func assertFileAccessErrorCode(t *testing.T, err error, wantCode string) {
+	t.Helper()
+	var fileAccessErr *fileAccessError
+	if !errors.As(err, &fileAccessErr) {
+		t.Fatalf("expected fileAccessError %q, got %v", wantCode, err)
+	}
+	if fileAccessErr.Code() != wantCode {
+		t.Fatalf("expected error code %q, got %q (%v)", wantCode, fileAccessErr.Code(), err)
+	}
+}
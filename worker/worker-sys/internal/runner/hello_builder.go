@@ -28,13 +28,31 @@ func buildHello(cfg config.Config) (*registryv1.ConnectHello, error) {
 		Capabilities: []*registryv1.CapabilityDeclaration{
 			{
 				Name:        computerUseCapabilityDeclared,
-				MaxInflight: computerUseCapabilityMaxInflight,
+				MaxInflight: int32(computerUseMaxInflight(cfg)),
 			},
 			{
 				Name:        readImageCapabilityDeclared,
 				MaxInflight: readImageCapabilityMaxInflight,
 			},
+			{
+				Name:        readFileCapabilityDeclared,
+				MaxInflight: fileAccessCapabilityMaxInflight,
+			},
+			{
+				Name:        writeFileCapabilityDeclared,
+				MaxInflight: fileAccessCapabilityMaxInflight,
+			},
+			{
+				Name:        listDirCapabilityDeclared,
+				MaxInflight: fileAccessCapabilityMaxInflight,
+			},
 		},
 	}
+	if screenshotConfigured(cfg) {
+		hello.Capabilities = append(hello.Capabilities, &registryv1.CapabilityDeclaration{
+			Name:        screenshotCapabilityDeclared,
+			MaxInflight: screenshotCapabilityMaxInflight,
+		})
+	}
 	return hello, nil
 }
@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// allowedPathRule is one entry of an allowed paths list: a single file, or
+// a directory and everything below it. readImage, readFile, writeFile and
+// listDir each check requests against their own list of these.
+type allowedPathRule struct {
+	path  string
+	isDir bool
+}
+
+func normalizeRequestPath(rawPath string) (string, error) {
+	pathValue := strings.TrimSpace(rawPath)
+	if pathValue == "" {
+		return "", errors.New("path is required")
+	}
+	cleaned := filepath.Clean(pathValue)
+	absPath, err := filepath.Abs(cleaned)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Clean(absPath), nil
+}
+
+func compileAllowedPathRules(allowedPaths []string) []allowedPathRule {
+	if len(allowedPaths) == 0 {
+		return []allowedPathRule{}
+	}
+	rules := make([]allowedPathRule, 0, len(allowedPaths))
+	seen := make(map[string]struct{}, len(allowedPaths))
+	for _, entry := range allowedPaths {
+		rule, ok := compileAllowedPathRule(entry)
+		if !ok {
+			continue
+		}
+		key := rule.path + "|file"
+		if rule.isDir {
+			key = rule.path + "|dir"
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func compileAllowedPathRule(rawPath string) (allowedPathRule, bool) {
+	pathValue := strings.TrimSpace(rawPath)
+	if pathValue == "" {
+		return allowedPathRule{}, false
+	}
+	normalizedPath, err := normalizeRequestPath(pathValue)
+	if err != nil {
+		return allowedPathRule{}, false
+	}
+	isDir := hasTrailingPathSeparator(pathValue)
+	if info, err := os.Stat(normalizedPath); err == nil {
+		isDir = info.IsDir()
+	}
+	return allowedPathRule{path: normalizedPath, isDir: isDir}, true
+}
+
+func hasTrailingPathSeparator(pathValue string) bool {
+	return strings.HasSuffix(pathValue, "/") || strings.HasSuffix(pathValue, "\\")
+}
+
+// pathRulesAllowLexically checks the cleaned path against the rules without
+// touching the filesystem.
+func pathRulesAllowLexically(rules []allowedPathRule, pathValue string) bool {
+	for _, rule := range rules {
+		if pathRuleMatches(rule, pathValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathRulesAllowReally resolves symlinks on both the path and the rules, so
+// a link inside an allowed directory cannot point outside of it.
+func pathRulesAllowReally(rules []allowedPathRule, pathValue string) (bool, error) {
+	if len(rules) == 0 {
+		return false, nil
+	}
+
+	resolvedPath, err := evalRealPath(pathValue)
+	if err != nil {
+		return false, err
+	}
+	return resolvedPathRulesAllow(rules, resolvedPath)
+}
+
+func resolvedPathRulesAllow(rules []allowedPathRule, resolvedPath string) (bool, error) {
+	for _, rule := range rules {
+		resolvedRulePath, err := evalRealPath(rule.path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return false, err
+		}
+		if rule.isDir {
+			if pathWithin(resolvedRulePath, resolvedPath) {
+				return true, nil
+			}
+			continue
+		}
+		if pathsEqual(resolvedRulePath, resolvedPath) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// pathRulesBindOpenedFile reports whether pathValue is still allowed after
+// symlink resolution and still names the already opened file, so a path
+// swapped between the lexical check and open is rejected.
+func pathRulesBindOpenedFile(rules []allowedPathRule, pathValue string, openedInfo os.FileInfo) (bool, error) {
+	if openedInfo == nil {
+		return false, errors.New("opened file info is required")
+	}
+
+	reallyAllowed, err := pathRulesAllowReally(rules, pathValue)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !reallyAllowed {
+		return false, nil
+	}
+
+	currentInfo, err := os.Stat(pathValue)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if currentInfo.IsDir() != openedInfo.IsDir() {
+		return false, nil
+	}
+	return os.SameFile(openedInfo, currentInfo), nil
+}
+
+func evalRealPath(pathValue string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(pathValue)
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(resolved)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Clean(absPath), nil
+}
+
+func pathRuleMatches(rule allowedPathRule, pathValue string) bool {
+	if rule.isDir {
+		return pathWithin(rule.path, pathValue)
+	}
+	return pathsEqual(rule.path, pathValue)
+}
+
+func pathWithin(basePath string, targetPath string) bool {
+	rel, err := filepath.Rel(basePath, targetPath)
+	if err != nil {
+		return false
+	}
+	cleanRel := filepath.Clean(rel)
+	if cleanRel == "." {
+		return true
+	}
+	if cleanRel == ".." || strings.HasPrefix(cleanRel, ".."+string(os.PathSeparator)) {
+		return false
+	}
+	return !filepath.IsAbs(cleanRel)
+}
+
+func pathsEqual(left string, right string) bool {
+	rel, err := filepath.Rel(left, right)
+	if err != nil {
+		return false
+	}
+	return filepath.Clean(rel) == "."
+}
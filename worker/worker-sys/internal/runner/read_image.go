@@ -10,8 +10,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/onlyboxes/onlyboxes/api/imagefit"
 )
 
+// computerUseSessionID is the only session_id readImage, screenshot and the
+// file capabilities accept; they act on the worker host as a whole.
+const computerUseSessionID = "computerUse"
+
 const (
 	readImageNotReadyMessage      = "readImage executor is unavailable"
 	readImageCodeInvalidPayload   = "invalid_payload"
@@ -19,7 +25,8 @@ const (
 	readImageCodeFileNotFound     = "file_not_found"
 	readImageCodePathIsDirectory  = "path_is_directory"
 	readImageCodePathNotAllowed   = "path_not_allowed"
-	readImageSessionComputerUse   = "computerUse"
+	readImageCodeImageTooLarge    = "image_too_large"
+	readImageCodeUnsupported      = "unsupported_image"
 	readImageActionValidate       = "validate"
 	readImageActionRead           = "read"
 	readImageDetectSniffByteLimit = 512
@@ -29,20 +36,34 @@ type readImagePayload struct {
 	SessionID string `json:"session_id"`
 	FilePath  string `json:"file_path"`
 	Action    string `json:"action,omitempty"`
+	MaxWidth  int    `json:"max_width,omitempty"`
+	MaxHeight int    `json:"max_height,omitempty"`
+	MaxBytes  int    `json:"max_bytes,omitempty"`
+	Format    string `json:"format,omitempty"`
 }
 
+// readImageRequest carries optional output limits that apply only to the
+// read action; zero values leave the file untouched.
 type readImageRequest struct {
 	SessionID string
 	FilePath  string
 	Action    string
+	MaxWidth  int
+	MaxHeight int
+	MaxBytes  int
+	Format    string
 }
 
 type readImageRunResult struct {
-	SessionID string `json:"session_id"`
-	FilePath  string `json:"file_path"`
-	MIMEType  string `json:"mime_type"`
-	SizeBytes int64  `json:"size_bytes"`
-	Blob      []byte `json:"blob,omitempty"`
+	SessionID      string `json:"session_id"`
+	FilePath       string `json:"file_path"`
+	MIMEType       string `json:"mime_type"`
+	SizeBytes      int64  `json:"size_bytes"`
+	Width          int    `json:"width,omitempty"`
+	Height         int    `json:"height,omitempty"`
+	OriginalWidth  int    `json:"original_width,omitempty"`
+	OriginalHeight int    `json:"original_height,omitempty"`
+	Blob           []byte `json:"blob,omitempty"`
 }
 
 type readImageError struct {
@@ -50,11 +71,6 @@ type readImageError struct {
 	message string
 }
 
-type readImagePathRule struct {
-	path  string
-	isDir bool
-}
-
 func (e *readImageError) Error() string {
 	if e == nil {
 		return "readImage execution failed"
@@ -77,11 +93,11 @@ func newReadImageError(code string, message string) *readImageError {
 }
 
 type readImageExecutor struct {
-	pathRules []readImagePathRule
+	pathRules []allowedPathRule
 }
 
 func newReadImageExecutor(allowedPaths []string) *readImageExecutor {
-	return &readImageExecutor{pathRules: compileReadImagePathRules(allowedPaths)}
+	return &readImageExecutor{pathRules: compileAllowedPathRules(allowedPaths)}
 }
 
 func (e *readImageExecutor) Execute(ctx context.Context, req readImageRequest) (readImageRunResult, error) {
@@ -93,7 +109,7 @@ func (e *readImageExecutor) Execute(ctx context.Context, req readImageRequest) (
 	}
 
 	sessionID := strings.TrimSpace(req.SessionID)
-	if sessionID != readImageSessionComputerUse {
+	if sessionID != computerUseSessionID {
 		return readImageRunResult{}, newReadImageError(readImageCodeSessionNotFound, "session not found")
 	}
 	filePath := strings.TrimSpace(req.FilePath)
@@ -104,8 +120,15 @@ func (e *readImageExecutor) Execute(ctx context.Context, req readImageRequest) (
 	if action == "" {
 		return readImageRunResult{}, newReadImageError(readImageCodeInvalidPayload, "action must be validate or read")
 	}
+	format, ok := imagefit.NormalizeFormat(req.Format)
+	if !ok {
+		return readImageRunResult{}, newReadImageError(readImageCodeInvalidPayload, "format must be png, jpeg or webp")
+	}
+	if req.MaxWidth < 0 || req.MaxHeight < 0 || req.MaxBytes < 0 {
+		return readImageRunResult{}, newReadImageError(readImageCodeInvalidPayload, "max_width, max_height and max_bytes must not be negative")
+	}
 
-	normalizedPath, err := normalizeReadImagePath(filePath)
+	normalizedPath, err := normalizeRequestPath(filePath)
 	if err != nil {
 		return readImageRunResult{}, newReadImageError(readImageCodePathNotAllowed, "file path is not allowed")
 	}
@@ -137,7 +160,7 @@ func (e *readImageExecutor) Execute(ctx context.Context, req readImageRequest) (
 	}
 
 	result := readImageRunResult{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  normalizedPath,
 		MIMEType:  mimeType,
 		SizeBytes: openedInfo.Size(),
@@ -150,8 +173,35 @@ func (e *readImageExecutor) Execute(ctx context.Context, req readImageRequest) (
 	if err != nil {
 		return readImageRunResult{}, fmt.Errorf("read file failed: %w", err)
 	}
-	result.Blob = blob
-	result.SizeBytes = int64(len(blob))
+	if !strings.HasPrefix(mimeType, "image/") {
+		result.Blob = blob
+		result.SizeBytes = int64(len(blob))
+		return result, nil
+	}
+
+	fitted, err := imagefit.Fit(blob, imagefit.Options{
+		MaxWidth:  req.MaxWidth,
+		MaxHeight: req.MaxHeight,
+		MaxBytes:  req.MaxBytes,
+		Format:    format,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, imagefit.ErrTooLarge):
+			return readImageRunResult{}, newReadImageError(readImageCodeImageTooLarge, "image does not fit the requested limits")
+		case errors.Is(err, imagefit.ErrUndecodable):
+			return readImageRunResult{}, newReadImageError(readImageCodeUnsupported, "image format cannot be converted")
+		default:
+			return readImageRunResult{}, fmt.Errorf("convert image failed: %w", err)
+		}
+	}
+	if fitted.Reencoded {
+		result.MIMEType = fitted.MIMEType
+	}
+	result.Blob = fitted.Blob
+	result.SizeBytes = int64(len(fitted.Blob))
+	result.Width, result.Height = fitted.Width, fitted.Height
+	result.OriginalWidth, result.OriginalHeight = fitted.OriginalWidth, fitted.OriginalHeight
 	return result, nil
 }
 
@@ -189,181 +239,27 @@ func normalizeReadImageAction(action string) string {
 	}
 }
 
-func normalizeReadImagePath(rawPath string) (string, error) {
-	pathValue := strings.TrimSpace(rawPath)
-	if pathValue == "" {
-		return "", errors.New("path is required")
-	}
-	cleaned := filepath.Clean(pathValue)
-	absPath, err := filepath.Abs(cleaned)
-	if err != nil {
-		return "", err
-	}
-	return filepath.Clean(absPath), nil
-}
-
-func compileReadImagePathRules(allowedPaths []string) []readImagePathRule {
-	if len(allowedPaths) == 0 {
-		return []readImagePathRule{}
-	}
-	rules := make([]readImagePathRule, 0, len(allowedPaths))
-	seen := make(map[string]struct{}, len(allowedPaths))
-	for _, entry := range allowedPaths {
-		rule, ok := compileReadImagePathRule(entry)
-		if !ok {
-			continue
-		}
-		key := rule.path + "|file"
-		if rule.isDir {
-			key = rule.path + "|dir"
-		}
-		if _, exists := seen[key]; exists {
-			continue
-		}
-		seen[key] = struct{}{}
-		rules = append(rules, rule)
-	}
-	return rules
-}
-
-func compileReadImagePathRule(rawPath string) (readImagePathRule, bool) {
-	pathValue := strings.TrimSpace(rawPath)
-	if pathValue == "" {
-		return readImagePathRule{}, false
-	}
-	normalizedPath, err := normalizeReadImagePath(pathValue)
-	if err != nil {
-		return readImagePathRule{}, false
-	}
-	isDir := hasTrailingPathSeparator(pathValue)
-	if info, err := os.Stat(normalizedPath); err == nil {
-		isDir = info.IsDir()
-	}
-	return readImagePathRule{path: normalizedPath, isDir: isDir}, true
-}
-
-func hasTrailingPathSeparator(pathValue string) bool {
-	return strings.HasSuffix(pathValue, "/") || strings.HasSuffix(pathValue, "\\")
-}
-
 func (e *readImageExecutor) isPathLexicallyAllowed(pathValue string) bool {
-	if e == nil || len(e.pathRules) == 0 {
+	if e == nil {
 		return false
 	}
-	for _, rule := range e.pathRules {
-		if readImagePathRuleMatches(rule, pathValue) {
-			return true
-		}
-	}
-	return false
-}
-
-func (e *readImageExecutor) isPathReallyAllowed(pathValue string) (bool, error) {
-	if e == nil || len(e.pathRules) == 0 {
-		return false, nil
-	}
-
-	resolvedPath, err := evalReadImageRealPath(pathValue)
-	if err != nil {
-		return false, err
-	}
-
-	for _, rule := range e.pathRules {
-		resolvedRulePath, err := evalReadImageRealPath(rule.path)
-		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				continue
-			}
-			return false, err
-		}
-		if rule.isDir {
-			if readImagePathWithin(resolvedRulePath, resolvedPath) {
-				return true, nil
-			}
-			continue
-		}
-		if readImagePathsEqual(resolvedRulePath, resolvedPath) {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return pathRulesAllowLexically(e.pathRules, pathValue)
 }
 
 func (e *readImageExecutor) ensureReadImagePathBinding(pathValue string, openedInfo os.FileInfo) error {
-	if openedInfo == nil {
-		return errors.New("opened file info is required")
-	}
-
-	reallyAllowed, err := e.isPathReallyAllowed(pathValue)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return newReadImageError(readImageCodePathNotAllowed, "file path is not allowed")
-		}
-		return err
-	}
-	if !reallyAllowed {
+	if e == nil {
 		return newReadImageError(readImageCodePathNotAllowed, "file path is not allowed")
 	}
-
-	currentInfo, err := os.Stat(pathValue)
+	bound, err := pathRulesBindOpenedFile(e.pathRules, pathValue, openedInfo)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return newReadImageError(readImageCodePathNotAllowed, "file path is not allowed")
-		}
 		return err
 	}
-	if currentInfo.IsDir() {
-		return newReadImageError(readImageCodePathNotAllowed, "file path is not allowed")
-	}
-	if !os.SameFile(openedInfo, currentInfo) {
+	if !bound {
 		return newReadImageError(readImageCodePathNotAllowed, "file path is not allowed")
 	}
 	return nil
 }
 
-func evalReadImageRealPath(pathValue string) (string, error) {
-	resolved, err := filepath.EvalSymlinks(pathValue)
-	if err != nil {
-		return "", err
-	}
-	absPath, err := filepath.Abs(resolved)
-	if err != nil {
-		return "", err
-	}
-	return filepath.Clean(absPath), nil
-}
-
-func readImagePathRuleMatches(rule readImagePathRule, pathValue string) bool {
-	if rule.isDir {
-		return readImagePathWithin(rule.path, pathValue)
-	}
-	return readImagePathsEqual(rule.path, pathValue)
-}
-
-func readImagePathWithin(basePath string, targetPath string) bool {
-	rel, err := filepath.Rel(basePath, targetPath)
-	if err != nil {
-		return false
-	}
-	cleanRel := filepath.Clean(rel)
-	if cleanRel == "." {
-		return true
-	}
-	if cleanRel == ".." || strings.HasPrefix(cleanRel, ".."+string(os.PathSeparator)) {
-		return false
-	}
-	return !filepath.IsAbs(cleanRel)
-}
-
-func readImagePathsEqual(left string, right string) bool {
-	rel, err := filepath.Rel(left, right)
-	if err != nil {
-		return false
-	}
-	return filepath.Clean(rel) == "."
-}
-
 func detectReadImageMIMEFromFile(filePath string, file *os.File) (string, error) {
 	ext := strings.ToLower(strings.TrimSpace(filepath.Ext(filePath)))
 	if ext != "" {
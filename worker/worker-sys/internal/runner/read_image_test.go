@@ -1,9 +1,11 @@
 package runner
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"image/jpeg"
 	"io"
 	"os"
 	"path/filepath"
@@ -26,15 +28,15 @@ func TestReadImageExecutorValidateAndReadSuccessWithDirectoryAllowRule(t *testin
 
 	executor := newReadImageExecutor([]string{allowedDir})
 	validateResult, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  imagePath,
 		Action:    readImageActionValidate,
 	})
 	if err != nil {
 		t.Fatalf("validate failed: %v", err)
 	}
-	if validateResult.SessionID != readImageSessionComputerUse {
-		t.Fatalf("expected session_id=%q, got %q", readImageSessionComputerUse, validateResult.SessionID)
+	if validateResult.SessionID != computerUseSessionID {
+		t.Fatalf("expected session_id=%q, got %q", computerUseSessionID, validateResult.SessionID)
 	}
 	if validateResult.FilePath != filepath.Clean(imagePath) {
 		t.Fatalf("expected file_path=%q, got %q", filepath.Clean(imagePath), validateResult.FilePath)
@@ -50,7 +52,7 @@ func TestReadImageExecutorValidateAndReadSuccessWithDirectoryAllowRule(t *testin
 	}
 
 	readResult, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  imagePath,
 		Action:    readImageActionRead,
 	})
@@ -75,7 +77,7 @@ func TestReadImageExecutorValidateAndReadSuccessWithFileAllowRule(t *testing.T)
 
 	executor := newReadImageExecutor([]string{imagePath})
 	validateResult, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  imagePath,
 		Action:    readImageActionValidate,
 	})
@@ -87,7 +89,7 @@ func TestReadImageExecutorValidateAndReadSuccessWithFileAllowRule(t *testing.T)
 	}
 
 	readResult, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  imagePath,
 		Action:    readImageActionRead,
 	})
@@ -118,7 +120,7 @@ func TestReadImageExecutorRejectsWhenAllowlistEmpty(t *testing.T) {
 
 	executor := newReadImageExecutor(nil)
 	_, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  imagePath,
 		Action:    readImageActionValidate,
 	})
@@ -142,7 +144,7 @@ func TestReadImageExecutorRejectsPathOutsideAllowlist(t *testing.T) {
 
 	executor := newReadImageExecutor([]string{allowedDir})
 	_, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  blockedFile,
 		Action:    readImageActionValidate,
 	})
@@ -163,7 +165,7 @@ func TestReadImageExecutorRejectsTraversalOutsideAllowlist(t *testing.T) {
 	traversalPath := filepath.Join(allowedDir, "..", "outside.png")
 	executor := newReadImageExecutor([]string{allowedDir})
 	_, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  traversalPath,
 		Action:    readImageActionValidate,
 	})
@@ -187,7 +189,7 @@ func TestReadImageExecutorRejectsSymlinkEscape(t *testing.T) {
 
 	executor := newReadImageExecutor([]string{allowedDir})
 	_, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  linkPath,
 		Action:    readImageActionRead,
 	})
@@ -203,7 +205,7 @@ func TestReadImageExecutorRejectsDirectoryPath(t *testing.T) {
 
 	executor := newReadImageExecutor([]string{allowedDir})
 	_, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  allowedDir,
 		Action:    readImageActionValidate,
 	})
@@ -216,7 +218,7 @@ func TestReadImageExecutorMissingFileReturnsFileNotFound(t *testing.T) {
 
 	executor := newReadImageExecutor([]string{missingPath})
 	_, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  missingPath,
 		Action:    readImageActionRead,
 	})
@@ -298,7 +300,7 @@ func TestReadImageExecutorMIMEExtensionPriority(t *testing.T) {
 
 	executor := newReadImageExecutor([]string{filePath})
 	result, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  filePath,
 		Action:    readImageActionValidate,
 	})
@@ -320,7 +322,7 @@ func TestReadImageExecutorMIMESniffFallback(t *testing.T) {
 
 	executor := newReadImageExecutor([]string{filePath})
 	result, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  filePath,
 		Action:    readImageActionValidate,
 	})
@@ -376,7 +378,7 @@ func TestReadImageExecutorMIMEFallbackToOctetStream(t *testing.T) {
 
 	executor := newReadImageExecutor([]string{filePath})
 	result, err := executor.Execute(context.Background(), readImageRequest{
-		SessionID: readImageSessionComputerUse,
+		SessionID: computerUseSessionID,
 		FilePath:  filePath,
 		Action:    readImageActionValidate,
 	})
@@ -388,6 +390,96 @@ func TestReadImageExecutorMIMEFallbackToOctetStream(t *testing.T) {
 	}
 }
 
+func TestReadImageExecutorScalesAndConvertsOnRead(t *testing.T) {
+	source := writeTestPNG(t, 400, 200)
+	executor := newReadImageExecutor([]string{filepath.Dir(source)})
+
+	result, err := executor.Execute(context.Background(), readImageRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  source,
+		Action:    readImageActionRead,
+		MaxWidth:  100,
+		MaxHeight: 100,
+		Format:    "jpg",
+	})
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if result.MIMEType != "image/jpeg" || result.Width != 100 || result.Height != 50 {
+		t.Fatalf("unexpected result mime=%s size=%dx%d", result.MIMEType, result.Width, result.Height)
+	}
+	if result.OriginalWidth != 400 || result.OriginalHeight != 200 || result.SizeBytes != int64(len(result.Blob)) {
+		t.Fatalf("unexpected result %#v", result)
+	}
+	if _, err := jpeg.DecodeConfig(bytes.NewReader(result.Blob)); err != nil {
+		t.Fatalf("expected jpeg blob: %v", err)
+	}
+}
+
+func TestReadImageExecutorReportsDimensionsOfUnchangedImage(t *testing.T) {
+	source := writeTestPNG(t, 40, 30)
+	executor := newReadImageExecutor([]string{filepath.Dir(source)})
+
+	result, err := executor.Execute(context.Background(), readImageRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  source,
+		Action:    readImageActionRead,
+		MaxWidth:  100,
+		Format:    "png",
+	})
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	original, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatalf("read source failed: %v", err)
+	}
+	if !bytes.Equal(result.Blob, original) || result.Width != 40 || result.OriginalHeight != 30 {
+		t.Fatalf("expected unchanged image with dimensions, got %#v", result)
+	}
+}
+
+func TestReadImageExecutorRejectsInvalidConversionOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	imagePath := filepath.Join(tmpDir, "sample.png")
+	if err := os.WriteFile(imagePath, []byte("not-a-png"), 0o600); err != nil {
+		t.Fatalf("write test image failed: %v", err)
+	}
+	executor := newReadImageExecutor([]string{tmpDir})
+
+	_, err := executor.Execute(context.Background(), readImageRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  imagePath,
+		Action:    readImageActionRead,
+		Format:    "tiff",
+	})
+	assertReadImageErrorCode(t, err, readImageCodeInvalidPayload)
+
+	_, err = executor.Execute(context.Background(), readImageRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  imagePath,
+		Action:    readImageActionRead,
+		MaxBytes:  -1,
+	})
+	assertReadImageErrorCode(t, err, readImageCodeInvalidPayload)
+
+	_, err = executor.Execute(context.Background(), readImageRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  imagePath,
+		Action:    readImageActionRead,
+		Format:    "jpeg",
+	})
+	assertReadImageErrorCode(t, err, readImageCodeUnsupported)
+
+	_, err = executor.Execute(context.Background(), readImageRequest{
+		SessionID: computerUseSessionID,
+		FilePath:  imagePath,
+		Action:    readImageActionRead,
+		MaxBytes:  4,
+	})
+	assertReadImageErrorCode(t, err, readImageCodeImageTooLarge)
+}
+
 func TestBuildCommandResultReadImageMapsDomainError(t *testing.T) {
 	originalRunReadImage := runReadImage
 	t.Cleanup(func() {
@@ -422,11 +514,11 @@ func TestBuildCommandResultReadImageSuccess(t *testing.T) {
 	})
 
 	runReadImage = func(_ context.Context, req readImageRequest) (readImageRunResult, error) {
-		if req.SessionID != readImageSessionComputerUse || req.FilePath != "/tmp/a.png" || req.Action != readImageActionRead {
+		if req.SessionID != computerUseSessionID || req.FilePath != "/tmp/a.png" || req.Action != readImageActionRead || req.MaxWidth != 640 || req.Format != "webp" {
 			t.Fatalf("unexpected readImage request: %#v", req)
 		}
 		return readImageRunResult{
-			SessionID: readImageSessionComputerUse,
+			SessionID: computerUseSessionID,
 			FilePath:  "/tmp/a.png",
 			MIMEType:  "image/png",
 			SizeBytes: 3,
@@ -437,7 +529,7 @@ func TestBuildCommandResultReadImageSuccess(t *testing.T) {
 	req := buildCommandResult(&registryv1.CommandDispatch{
 		CommandId:   "cmd-read-image-ok",
 		Capability:  readImageCapabilityDeclared,
-		PayloadJson: []byte(`{"session_id":"computerUse","file_path":"/tmp/a.png","action":"read"}`),
+		PayloadJson: []byte(`{"session_id":"computerUse","file_path":"/tmp/a.png","action":"read","max_width":640,"format":"webp"}`),
 	})
 	result := req.GetCommandResult()
 	if result == nil {
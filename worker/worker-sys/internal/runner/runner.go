@@ -3,25 +3,37 @@ package runner
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/config"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/journal"
 	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/logging"
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/sandbox"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 const (
-	minHeartbeatInterval             = 1 * time.Second
-	initialReconnectDelay            = 1 * time.Second
-	maxReconnectDelay                = 15 * time.Second
-	computerUseCapabilityName        = "computeruse"
-	computerUseCapabilityDeclared    = "computerUse"
-	readImageCapabilityName          = "readimage"
-	readImageCapabilityDeclared      = "readImage"
-	computerUseCapabilityMaxInflight = 1
-	readImageCapabilityMaxInflight   = 1
+	minHeartbeatInterval            = 1 * time.Second
+	initialReconnectDelay           = 1 * time.Second
+	maxReconnectDelay               = 15 * time.Second
+	computerUseCapabilityName       = "computeruse"
+	computerUseCapabilityDeclared   = "computerUse"
+	readImageCapabilityName         = "readimage"
+	readImageCapabilityDeclared     = "readImage"
+	readImageCapabilityMaxInflight  = 1
+	readFileCapabilityName          = "readfile"
+	readFileCapabilityDeclared      = "readFile"
+	writeFileCapabilityName         = "writefile"
+	writeFileCapabilityDeclared     = "writeFile"
+	listDirCapabilityName           = "listdir"
+	listDirCapabilityDeclared       = "listDir"
+	fileAccessCapabilityMaxInflight = 1
+	screenshotCapabilityName        = "screenshot"
+	screenshotCapabilityDeclared    = "screenshot"
+	screenshotCapabilityMaxInflight = 1
 )
 
 var waitReconnect = waitReconnectDelay
@@ -35,28 +47,153 @@ func Run(ctx context.Context, cfg config.Config) error {
 		return errors.New("WORKER_SECRET is required")
 	}
 
+	commandSandbox, err := sandbox.New(sandbox.Options{
+		RunAsUser: cfg.ComputerUseSandbox.RunAsUser,
+		WorkDir:   cfg.ComputerUseSandbox.WorkDir,
+		Limits: sandbox.Limits{
+			CPUSeconds:        cfg.ComputerUseSandbox.RlimitCPUSec,
+			AddressSpaceBytes: cfg.ComputerUseSandbox.RlimitASBytes,
+			FileSizeBytes:     cfg.ComputerUseSandbox.RlimitFSizeBytes,
+			OpenFiles:         cfg.ComputerUseSandbox.RlimitNoFile,
+			Processes:         cfg.ComputerUseSandbox.RlimitNProc,
+		},
+		Cgroup: sandbox.Cgroup{
+			Parent:      cfg.ComputerUseSandbox.CgroupParent,
+			MemoryBytes: cfg.ComputerUseSandbox.CgroupMemoryBytes,
+			PidsMax:     cfg.ComputerUseSandbox.CgroupPidsMax,
+			CPUPercent:  cfg.ComputerUseSandbox.CgroupCPUPercent,
+		},
+		PrivateTmp:    cfg.ComputerUseSandbox.PrivateTmp,
+		NoNetwork:     cfg.ComputerUseSandbox.NoNetwork,
+		ReadOnlyPaths: cfg.ComputerUseSandbox.ReadOnlyPaths,
+	})
+	if err != nil {
+		return fmt.Errorf("computerUse sandbox: %w", err)
+	}
+
+	if cfg.JournalPath != "" {
+		commandJournal, err := journal.Open(journal.Options{
+			Path:     cfg.JournalPath,
+			MaxBytes: int64(cfg.JournalMaxBytes),
+			MaxFiles: cfg.JournalMaxFiles,
+			Key:      []byte(cfg.JournalKey),
+		})
+		if err != nil {
+			return fmt.Errorf("execution journal: %w", err)
+		}
+		defer commandJournal.Close()
+		originalAppendJournal := appendJournal
+		appendJournal = commandJournal.Append
+		defer func() {
+			appendJournal = originalAppendJournal
+		}()
+	}
+
 	executor := newComputerUseExecutor(computerUseExecutorConfig{
 		OutputLimitBytes: cfg.ComputerUseOutputLimitByte,
 		WhitelistMode:    cfg.ComputerUseWhitelistMode,
 		Whitelist:        cfg.ComputerUseWhitelist,
+		CommandRules:     cfg.ComputerUseCommandRules,
+		AllowedSyntax:    cfg.ComputerUseAllowedSyntax,
+		ExecMode:         cfg.ComputerUseExecMode,
+		Sandbox:          commandSandbox,
+		EnvPassthrough:   cfg.ComputerUseEnvPassthrough,
+		EnvOverrides:     cfg.ComputerUseEnvOverrides,
+		MaxSessions:      cfg.ComputerUseMaxSessions,
+		LeaseMinSec:      cfg.ComputerUseSessionLeaseMinSec,
+		LeaseMaxSec:      cfg.ComputerUseSessionLeaseMaxSec,
+		LeaseDefaultSec:  cfg.ComputerUseSessionLeaseDefaultSec,
 	})
+	defer executor.Close()
 	originalRunComputerUse := runComputerUse
 	runComputerUse = executor.Execute
 	originalRunReadImage := runReadImage
 	runReadImage = newReadImageExecutor(cfg.ReadImageAllowedPaths).Execute
+	fileAccess := newFileAccessExecutor(fileAccessExecutorConfig{
+		ReadAllowedPaths:  cfg.FileReadAllowedPaths,
+		WriteAllowedPaths: cfg.FileWriteAllowedPaths,
+		ReadMaxBytes:      cfg.FileReadMaxBytes,
+		WriteMaxBytes:     cfg.FileWriteMaxBytes,
+		ListDirMaxEntries: cfg.ListDirMaxEntries,
+	})
+	originalRunReadFile, originalRunWriteFile, originalRunListDir := runReadFile, runWriteFile, runListDir
+	runReadFile, runWriteFile, runListDir = fileAccess.ReadFile, fileAccess.WriteFile, fileAccess.ListDir
+	originalRunScreenshot := runScreenshot
+	if screenshotConfigured(cfg) {
+		runScreenshot = newScreenshotExecutor(screenshotExecutorConfig{
+			Command:   cfg.ScreenshotCommand,
+			Timeout:   time.Duration(cfg.ScreenshotTimeoutSec) * time.Second,
+			MaxWidth:  cfg.ScreenshotMaxWidth,
+			MaxHeight: cfg.ScreenshotMaxHeight,
+			MaxBytes:  cfg.ScreenshotMaxBytes,
+			Env: newCommandEnvPolicy(
+				append(append([]string{}, cfg.ComputerUseEnvPassthrough...), screenshotEnvPassthrough...),
+				cfg.ComputerUseEnvOverrides,
+			),
+		}).Execute
+	}
 	defer func() {
 		runComputerUse = originalRunComputerUse
 		runReadImage = originalRunReadImage
+		runReadFile, runWriteFile, runListDir = originalRunReadFile, originalRunWriteFile, originalRunListDir
+		runScreenshot = originalRunScreenshot
 	}()
 	logging.Infof(
-		"computerUse whitelist configured: mode=%s count=%d",
+		"computerUse whitelist configured: mode=%s count=%d rules=%d allowed_syntax=%v exec_mode=%s",
 		cfg.ComputerUseWhitelistMode,
 		len(cfg.ComputerUseWhitelist),
+		len(cfg.ComputerUseCommandRules),
+		cfg.ComputerUseAllowedSyntax,
+		cfg.ComputerUseExecMode,
+	)
+	logging.Infof("computerUse sandbox configured: %s", commandSandbox.Summary())
+	logging.Infof(
+		"computerUse environment configured: passthrough=%v overrides=%d",
+		executor.env.passthrough,
+		len(executor.env.overrides),
+	)
+	logging.Infof(
+		"computerUse sessions configured: max_sessions=%d max_inflight=%d lease_default_sec=%d",
+		cfg.ComputerUseMaxSessions,
+		computerUseMaxInflight(cfg),
+		cfg.ComputerUseSessionLeaseDefaultSec,
 	)
+	if blocked := executor.env.blocked(); len(blocked) > 0 {
+		logging.Warnf("computerUse environment entries are ignored, WORKER_* variables never reach commands: %v", blocked)
+	}
+	if executor.policyErr != nil {
+		logging.Warnf("computerUse command rules are invalid, all commands will be blocked: %v", executor.policyErr)
+	}
 	logging.Infof(
 		"readImage allowed paths configured: count=%d",
 		len(cfg.ReadImageAllowedPaths),
 	)
+	logging.Infof(
+		"file access allowed paths configured: read_count=%d write_count=%d read_max_bytes=%d write_max_bytes=%d list_dir_max_entries=%d",
+		len(cfg.FileReadAllowedPaths),
+		len(cfg.FileWriteAllowedPaths),
+		cfg.FileReadMaxBytes,
+		cfg.FileWriteMaxBytes,
+		cfg.ListDirMaxEntries,
+	)
+	if screenshotConfigured(cfg) {
+		logging.Infof(
+			"screenshot configured: program=%s max_width=%d max_height=%d max_bytes=%d timeout_sec=%d",
+			cfg.ScreenshotCommand[0],
+			cfg.ScreenshotMaxWidth,
+			cfg.ScreenshotMaxHeight,
+			cfg.ScreenshotMaxBytes,
+			cfg.ScreenshotTimeoutSec,
+		)
+	}
+	if cfg.JournalPath != "" {
+		logging.Infof(
+			"execution journal configured: path=%s max_bytes=%d max_files=%d",
+			cfg.JournalPath,
+			cfg.JournalMaxBytes,
+			cfg.JournalMaxFiles,
+		)
+	}
 
 	reconnectDelay := initialReconnectDelay
 	for {
@@ -112,3 +249,15 @@ func waitReconnectDelay(ctx context.Context, delay time.Duration) error {
 		return nil
 	}
 }
+
+// computerUseMaxInflight is the concurrency declared to the console; below
+// one it falls back to the original single-flight behaviour.
+func computerUseMaxInflight(cfg config.Config) int {
+	return max(cfg.ComputerUseMaxInflight, 1)
+}
+
+// screenshotConfigured reports whether the screenshot capability is served
+// and declared in hello.
+func screenshotConfigured(cfg config.Config) bool {
+	return len(cfg.ScreenshotCommand) > 0
+}
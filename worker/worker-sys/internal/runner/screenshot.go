@@ -0,0 +1,201 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/onlyboxes/onlyboxes/api/imagefit"
+)
+
+const (
+	screenshotNotReadyMessage       = "screenshot capture is not configured"
+	screenshotCodeCaptureFailed     = "capture_failed"
+	screenshotCodeImageTooLarge     = "image_too_large"
+	screenshotOutputFilePlaceholder = "{file}"
+	screenshotOutputFileName        = "screenshot.png"
+	screenshotStderrLimitBytes      = 2048
+)
+
+// screenshotEnvPassthrough lets capture tools reach the display server on
+// top of the computerUse environment allowlist.
+var screenshotEnvPassthrough = []string{"DISPLAY", "WAYLAND_DISPLAY", "XAUTHORITY", "XDG_RUNTIME_DIR"}
+
+type screenshotRequest struct{}
+
+type screenshotRunResult struct {
+	SessionID      string `json:"session_id"`
+	MIMEType       string `json:"mime_type"`
+	SizeBytes      int64  `json:"size_bytes"`
+	Width          int    `json:"width,omitempty"`
+	Height         int    `json:"height,omitempty"`
+	OriginalWidth  int    `json:"original_width,omitempty"`
+	OriginalHeight int    `json:"original_height,omitempty"`
+	Blob           []byte `json:"blob,omitempty"`
+}
+
+type screenshotError struct {
+	code    string
+	message string
+}
+
+func (e *screenshotError) Error() string {
+	if e == nil {
+		return "screenshot failed"
+	}
+	return e.message
+}
+
+func (e *screenshotError) Code() string {
+	if e == nil {
+		return ""
+	}
+	return e.code
+}
+
+func newScreenshotError(code string, message string) *screenshotError {
+	return &screenshotError{
+		code:    strings.TrimSpace(code),
+		message: strings.TrimSpace(message),
+	}
+}
+
+type screenshotExecutorConfig struct {
+	Command   []string
+	Timeout   time.Duration
+	MaxWidth  int
+	MaxHeight int
+	MaxBytes  int
+	Env       commandEnvPolicy
+}
+
+// screenshotExecutor runs the configured capture command directly, without
+// a shell or the computerUse sandbox, and returns the image in the readImage
+// result shape.
+type screenshotExecutor struct {
+	command   []string
+	timeout   time.Duration
+	maxWidth  int
+	maxHeight int
+	maxBytes  int
+	env       commandEnvPolicy
+}
+
+func newScreenshotExecutor(cfg screenshotExecutorConfig) *screenshotExecutor {
+	return &screenshotExecutor{
+		command:   append([]string(nil), cfg.Command...),
+		timeout:   cfg.Timeout,
+		maxWidth:  cfg.MaxWidth,
+		maxHeight: cfg.MaxHeight,
+		maxBytes:  cfg.MaxBytes,
+		env:       cfg.Env,
+	}
+}
+
+func (e *screenshotExecutor) Execute(ctx context.Context, _ screenshotRequest) (screenshotRunResult, error) {
+	if e == nil || len(e.command) == 0 {
+		return screenshotRunResult{}, newScreenshotError("execution_failed", screenshotNotReadyMessage)
+	}
+	if err := ctx.Err(); err != nil {
+		return screenshotRunResult{}, err
+	}
+
+	captured, err := e.capture(ctx)
+	if err != nil {
+		return screenshotRunResult{}, err
+	}
+	if len(captured) == 0 {
+		return screenshotRunResult{}, newScreenshotError(screenshotCodeCaptureFailed, "capture command produced no image")
+	}
+
+	fitted, err := imagefit.Fit(captured, imagefit.Options{MaxWidth: e.maxWidth, MaxHeight: e.maxHeight, MaxBytes: e.maxBytes})
+	if err != nil {
+		if errors.Is(err, imagefit.ErrTooLarge) {
+			return screenshotRunResult{}, newScreenshotError(screenshotCodeImageTooLarge, fmt.Sprintf("screenshot does not fit in %d bytes", e.maxBytes))
+		}
+		return screenshotRunResult{}, newScreenshotError(screenshotCodeCaptureFailed, fmt.Sprintf("decode screenshot failed: %v", err))
+	}
+	if !strings.HasPrefix(fitted.MIMEType, "image/") {
+		return screenshotRunResult{}, newScreenshotError(screenshotCodeCaptureFailed, fmt.Sprintf("capture output is %s, not an image", fitted.MIMEType))
+	}
+
+	return screenshotRunResult{
+		SessionID:      computerUseSessionID,
+		MIMEType:       fitted.MIMEType,
+		SizeBytes:      int64(len(fitted.Blob)),
+		Width:          fitted.Width,
+		Height:         fitted.Height,
+		OriginalWidth:  fitted.OriginalWidth,
+		OriginalHeight: fitted.OriginalHeight,
+		Blob:           fitted.Blob,
+	}, nil
+}
+
+// capture runs the command and returns the image it wrote to the "{file}"
+// argument, or to stdout when the command has no such argument.
+func (e *screenshotExecutor) capture(ctx context.Context) ([]byte, error) {
+	captureCtx := ctx
+	cancel := func() {}
+	if e.timeout > 0 {
+		captureCtx, cancel = context.WithTimeout(ctx, e.timeout)
+	}
+	defer cancel()
+
+	argv := append([]string(nil), e.command...)
+	outputPath := ""
+	for i, arg := range argv {
+		if !strings.Contains(arg, screenshotOutputFilePlaceholder) {
+			continue
+		}
+		if outputPath == "" {
+			tempDir, err := os.MkdirTemp("", "onlyboxes-screenshot-")
+			if err != nil {
+				return nil, fmt.Errorf("create screenshot directory failed: %w", err)
+			}
+			defer os.RemoveAll(tempDir)
+			outputPath = filepath.Join(tempDir, screenshotOutputFileName)
+		}
+		argv[i] = strings.ReplaceAll(arg, screenshotOutputFilePlaceholder, outputPath)
+	}
+
+	cmd := exec.CommandContext(captureCtx, argv[0], argv[1:]...)
+	cmd.Env = e.env.build(os.Environ())
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	if outputPath == "" {
+		cmd.Stdout = &stdout
+	}
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if errors.Is(captureCtx.Err(), context.DeadlineExceeded) {
+			return nil, newScreenshotError(screenshotCodeCaptureFailed, fmt.Sprintf("capture command timed out after %s", e.timeout))
+		}
+		detail, _ := truncateByBytes(strings.TrimSpace(stderr.String()), screenshotStderrLimitBytes)
+		if detail == "" {
+			detail = err.Error()
+		}
+		return nil, newScreenshotError(screenshotCodeCaptureFailed, "capture command failed: "+detail)
+	}
+
+	if outputPath == "" {
+		return stdout.Bytes(), nil
+	}
+	captured, err := os.ReadFile(outputPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, newScreenshotError(screenshotCodeCaptureFailed, "capture command did not write "+screenshotOutputFilePlaceholder)
+		}
+		return nil, fmt.Errorf("read screenshot failed: %w", err)
+	}
+	return captured, nil
+}
@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/config"
+)
+
+func writeTestPNG(t *testing.T, width int, height int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode test png failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "capture.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("write test png failed: %v", err)
+	}
+	return path
+}
+
+func TestScreenshotExecutorReadsStdoutAndScales(t *testing.T) {
+	source := writeTestPNG(t, 200, 100)
+	executor := newScreenshotExecutor(screenshotExecutorConfig{
+		Command:  []string{"cat", source},
+		MaxWidth: 50,
+		Env:      newCommandEnvPolicy(nil, nil),
+	})
+
+	result, err := executor.Execute(context.Background(), screenshotRequest{})
+	if err != nil {
+		t.Fatalf("screenshot failed: %v", err)
+	}
+	if result.MIMEType != "image/png" || result.Width != 50 || result.Height != 25 {
+		t.Fatalf("unexpected screenshot result mime=%s size=%dx%d", result.MIMEType, result.Width, result.Height)
+	}
+	if result.OriginalWidth != 200 || result.OriginalHeight != 100 {
+		t.Fatalf("unexpected original size %dx%d", result.OriginalWidth, result.OriginalHeight)
+	}
+	decoded, err := png.DecodeConfig(bytes.NewReader(result.Blob))
+	if err != nil || decoded.Width != 50 {
+		t.Fatalf("unexpected blob config %#v err=%v", decoded, err)
+	}
+}
+
+func TestScreenshotExecutorReadsOutputFilePlaceholder(t *testing.T) {
+	source := writeTestPNG(t, 20, 10)
+	executor := newScreenshotExecutor(screenshotExecutorConfig{
+		Command: []string{"cp", source, "{file}"},
+		Env:     newCommandEnvPolicy(nil, nil),
+	})
+
+	result, err := executor.Execute(context.Background(), screenshotRequest{})
+	if err != nil {
+		t.Fatalf("screenshot failed: %v", err)
+	}
+	original, err := os.ReadFile(source)
+	if err != nil {
+		t.Fatalf("read source failed: %v", err)
+	}
+	if !bytes.Equal(result.Blob, original) || result.Width != 20 || result.SessionID != computerUseSessionID {
+		t.Fatalf("expected unchanged capture, got %#v", result)
+	}
+}
+
+func TestScreenshotExecutorShrinksToMaxBytes(t *testing.T) {
+	source := writeTestPNG(t, 256, 256)
+	executor := newScreenshotExecutor(screenshotExecutorConfig{
+		Command:  []string{"cat", source},
+		MaxBytes: 4096,
+		Env:      newCommandEnvPolicy(nil, nil),
+	})
+
+	result, err := executor.Execute(context.Background(), screenshotRequest{})
+	if err != nil {
+		t.Fatalf("screenshot failed: %v", err)
+	}
+	if len(result.Blob) > 4096 || result.SizeBytes != int64(len(result.Blob)) {
+		t.Fatalf("expected blob within 4096 bytes, got %d", len(result.Blob))
+	}
+}
+
+func TestScreenshotExecutorReportsCaptureFailures(t *testing.T) {
+	for _, command := range [][]string{
+		{"sh", "-c", "echo no display >&2; exit 1"},
+		{"echo", "not an image"},
+		{"true", "{file}"},
+	} {
+		executor := newScreenshotExecutor(screenshotExecutorConfig{
+			Command: command,
+			Env:     newCommandEnvPolicy(nil, nil),
+		})
+		_, err := executor.Execute(context.Background(), screenshotRequest{})
+		var screenshotErr *screenshotError
+		if !errors.As(err, &screenshotErr) || screenshotErr.Code() != screenshotCodeCaptureFailed {
+			t.Fatalf("command %v: expected capture_failed, got %v", command, err)
+		}
+	}
+}
+
+func TestBuildHelloDeclaresScreenshotOnlyWhenConfigured(t *testing.T) {
+	hasScreenshot := func(cfg config.Config) bool {
+		hello, err := buildHello(cfg)
+		if err != nil {
+			t.Fatalf("build hello failed: %v", err)
+		}
+		for _, capability := range hello.GetCapabilities() {
+			if capability.GetName() == screenshotCapabilityDeclared {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasScreenshot(config.Config{WorkerID: "worker-1"}) {
+		t.Fatalf("expected no screenshot capability without a capture command")
+	}
+	if !hasScreenshot(config.Config{WorkerID: "worker-1", ScreenshotCommand: []string{"grim", "-"}}) {
+		t.Fatalf("expected screenshot capability with a capture command")
+	}
+}
@@ -73,8 +73,7 @@ func runSession(ctx context.Context, cfg config.Config) error {
 	outbound := make(chan *registryv1.ConnectRequest, 64)
 	heartbeatAckCh := make(chan *registryv1.HeartbeatAck, 16)
 	sessionErrCh := make(chan error, 4)
-	commandExecSlots := make(chan struct{}, commandExecSlotCapacity)
-	commandExecSlots <- struct{}{}
+	commandExecSlots := newCommandExecSlots(computerUseMaxInflight(cfg))
 
 	go senderLoop(sessionCtx, stream, outbound, sessionErrCh)
 	go receiverLoop(sessionCtx, stream, outbound, heartbeatAckCh, sessionErrCh, commandExecSlots)
@@ -194,6 +193,27 @@ func commandDispatchTextForLog(capability string, payload []byte) string {
 			return filePath
 		}
 		return action + " " + filePath
+	case readFileCapabilityName:
+		decoded := readFilePayload{}
+		if err := json.Unmarshal(payload, &decoded); err != nil || strings.TrimSpace(decoded.FilePath) == "" {
+			return rawPayload
+		}
+		return "read " + strings.TrimSpace(decoded.FilePath)
+	case writeFileCapabilityName:
+		// Never log the content itself; it may carry secrets.
+		decoded := writeFilePayload{}
+		if err := json.Unmarshal(payload, &decoded); err != nil || strings.TrimSpace(decoded.FilePath) == "" {
+			return "write"
+		}
+		return fmt.Sprintf("write %s (%d chars)", strings.TrimSpace(decoded.FilePath), len(decoded.Content))
+	case listDirCapabilityName:
+		decoded := listDirPayload{}
+		if err := json.Unmarshal(payload, &decoded); err != nil || strings.TrimSpace(decoded.DirPath) == "" {
+			return rawPayload
+		}
+		return "list " + strings.TrimSpace(decoded.DirPath)
+	case screenshotCapabilityName:
+		return "capture"
 	default:
 		return rawPayload
 	}
@@ -229,7 +249,9 @@ func handleCommandDispatch(
 
 	go func(dispatch *registryv1.CommandDispatch) {
 		defer releaseCommandSlot(commandExecSlots)
+		started := time.Now()
 		resultReq := executeFn(ctx, dispatch)
+		recordCommandJournal(dispatch, resultReq, time.Since(started))
 		if sendErr := enqueueRequest(ctx, outbound, resultReq); sendErr != nil {
 			if errors.Is(sendErr, context.Canceled) || errors.Is(sendErr, context.DeadlineExceeded) {
 				return
@@ -322,6 +344,17 @@ func tryEnqueueRequest(ctx context.Context, outbound chan<- *registryv1.ConnectR
 	}
 }
 
+// newCommandExecSlots returns a full slot pool. Commands of every
+// capability share it, matching the computerUse max_inflight.
+func newCommandExecSlots(capacity int) chan struct{} {
+	capacity = max(capacity, commandExecSlotCapacity)
+	slots := make(chan struct{}, capacity)
+	for i := 0; i < capacity; i++ {
+		slots <- struct{}{}
+	}
+	return slots
+}
+
 func tryAcquireCommandSlot(commandExecSlots chan struct{}) bool {
 	if commandExecSlots == nil {
 		return false
@@ -0,0 +1,662 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Shell syntax features the parsed whitelist mode can allow explicitly.
+const (
+	shellSyntaxPipe         = "pipe"
+	shellSyntaxList         = "list"
+	shellSyntaxBackground   = "background"
+	shellSyntaxRedirect     = "redirect"
+	shellSyntaxSubstitution = "substitution"
+	shellSyntaxExpansion    = "expansion"
+	shellSyntaxAssignment   = "assignment"
+	shellSyntaxGlob         = "glob"
+
+	maxShellParseDepth = 8
+)
+
+var errShellSyntax = errors.New("invalid shell syntax")
+
+var shellReservedWords = map[string]struct{}{
+	"if": {}, "then": {}, "else": {}, "elif": {}, "fi": {},
+	"do": {}, "done": {}, "case": {}, "esac": {}, "while": {},
+	"until": {}, "for": {}, "in": {}, "function": {}, "select": {},
+	"!": {}, "{": {}, "}": {}, "[[": {}, "]]": {},
+}
+
+// shellParseResult lists every simple command a shell line would run,
+// including those inside command substitutions, and the syntax features it
+// uses. Words are recorded as written, before expansion.
+type shellParseResult struct {
+	commands [][]string
+	features []string
+}
+
+func (r shellParseResult) uses(feature string) bool {
+	for _, used := range r.features {
+		if used == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// parseShellCommand tokenizes command following POSIX sh quoting rules. It is
+// deliberately strict: subshells, compound commands and anything it cannot
+// classify are errors rather than guesses.
+func parseShellCommand(command string) (shellParseResult, error) {
+	parser := newShellParser([]rune(command), 0, map[string]struct{}{})
+	if err := parser.parse(); err != nil {
+		return shellParseResult{}, err
+	}
+	features := make([]string, 0, len(parser.features))
+	for feature := range parser.features {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	return shellParseResult{commands: parser.commands, features: features}, nil
+}
+
+type shellParser struct {
+	src      []rune
+	pos      int
+	depth    int
+	features map[string]struct{}
+	commands [][]string
+
+	current    []string
+	word       strings.Builder
+	inWord     bool
+	wordQuoted bool
+	// wordGlob marks an unquoted * or ? in the current word; wordBracket
+	// and wordBrace are the offsets of its first unquoted [ and {, or -1.
+	wordGlob        bool
+	wordBracket     int
+	wordBrace       int
+	pendingRedirect bool
+	needCommand     bool
+
+	// pendingHeredoc marks that the redirect target being read is a
+	// here-document delimiter; heredocs queue the bodies to skip at the next
+	// newline.
+	pendingHeredoc bool
+	stripTabs      bool
+	heredocs       []shellHeredoc
+}
+
+type shellHeredoc struct {
+	delimiter string
+	quoted    bool
+	stripTabs bool
+}
+
+func newShellParser(src []rune, depth int, features map[string]struct{}) *shellParser {
+	return &shellParser{src: src, depth: depth, features: features, wordBracket: -1, wordBrace: -1}
+}
+
+func syntaxError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errShellSyntax, fmt.Sprintf(format, args...))
+}
+
+func (p *shellParser) peek(offset int) rune {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *shellParser) parse() error {
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch {
+		case r == ' ' || r == '\t':
+			if err := p.endWord(); err != nil {
+				return err
+			}
+			p.pos++
+		case r == '\n':
+			if err := p.endWord(); err != nil {
+				return err
+			}
+			if len(p.heredocs) == 0 {
+				if err := p.endCommand(shellSyntaxList, false); err != nil {
+					return err
+				}
+				p.pos++
+				continue
+			}
+			// The newline after a here-document redirect starts its body; the
+			// command is only part of a list if another line follows the body.
+			if err := p.endCommand("", false); err != nil {
+				return err
+			}
+			delete(p.features, "")
+			p.pos++
+			if err := p.heredocBodies(); err != nil {
+				return err
+			}
+			if p.pos < len(p.src) {
+				p.features[shellSyntaxList] = struct{}{}
+			}
+		case r == ';':
+			if err := p.endCommand(shellSyntaxList, false); err != nil {
+				return err
+			}
+			p.pos++
+		case r == '|':
+			if p.peek(1) == '|' {
+				if err := p.endCommand(shellSyntaxList, true); err != nil {
+					return err
+				}
+				p.pos += 2
+				continue
+			}
+			if err := p.endCommand(shellSyntaxPipe, true); err != nil {
+				return err
+			}
+			p.pos++
+		case r == '&':
+			if p.peek(1) == '&' {
+				if err := p.endCommand(shellSyntaxList, true); err != nil {
+					return err
+				}
+				p.pos += 2
+				continue
+			}
+			if err := p.endCommand(shellSyntaxBackground, false); err != nil {
+				return err
+			}
+			p.pos++
+		case r == '<' || r == '>':
+			if err := p.redirect(); err != nil {
+				return err
+			}
+		case r == '(' || r == ')':
+			return syntaxError("subshells are not supported")
+		case r == '#' && !p.inWord:
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case r == '\'':
+			if err := p.singleQuoted(); err != nil {
+				return err
+			}
+		case r == '"':
+			if err := p.doubleQuoted(); err != nil {
+				return err
+			}
+		case r == '\\':
+			next := p.peek(1)
+			if next == 0 {
+				return syntaxError("trailing backslash")
+			}
+			p.pos += 2
+			if next == '\n' {
+				continue
+			}
+			p.inWord, p.wordQuoted = true, true
+			p.word.WriteRune(next)
+		case r == '$':
+			if err := p.dollar(); err != nil {
+				return err
+			}
+		case r == '`':
+			if err := p.backtick(); err != nil {
+				return err
+			}
+		default:
+			switch {
+			case r == '*' || r == '?':
+				p.wordGlob = true
+			case r == '[' && p.wordBracket < 0:
+				p.wordBracket = p.word.Len()
+			case r == '{' && p.wordBrace < 0:
+				p.wordBrace = p.word.Len()
+			}
+			p.inWord = true
+			p.word.WriteRune(r)
+			p.pos++
+		}
+	}
+	if err := p.endWord(); err != nil {
+		return err
+	}
+	if p.pendingRedirect {
+		return syntaxError("redirection without target")
+	}
+	if len(p.heredocs) > 0 {
+		return syntaxError("unterminated here-document")
+	}
+	if len(p.current) > 0 {
+		p.commands = append(p.commands, p.current)
+		p.current = nil
+	} else if p.needCommand {
+		return syntaxError("operator without following command")
+	}
+	return nil
+}
+
+func (p *shellParser) endWord() error {
+	if !p.inWord {
+		return nil
+	}
+	word := p.word.String()
+	quoted := p.wordQuoted
+	glob := p.wordExpandsPathnames(word)
+	p.word.Reset()
+	p.inWord, p.wordQuoted = false, false
+	p.wordGlob, p.wordBracket, p.wordBrace = false, -1, -1
+
+	if p.pendingRedirect {
+		p.pendingRedirect = false
+		if p.pendingHeredoc {
+			p.pendingHeredoc = false
+			p.heredocs = append(p.heredocs, shellHeredoc{delimiter: word, quoted: quoted, stripTabs: p.stripTabs})
+		} else if glob {
+			p.features[shellSyntaxGlob] = struct{}{}
+		}
+		return nil
+	}
+	if len(p.current) == 0 && !quoted {
+		if isShellAssignment(word) {
+			p.features[shellSyntaxAssignment] = struct{}{}
+			return nil
+		}
+		if _, reserved := shellReservedWords[word]; reserved {
+			return syntaxError("compound commands are not supported")
+		}
+	}
+	if glob {
+		// Rules match the command name as written, which says nothing
+		// about the file a pattern such as /usr/bin/r[m] picks.
+		if len(p.current) == 0 {
+			return syntaxError("pathname expansion in a command name is not supported")
+		}
+		p.features[shellSyntaxGlob] = struct{}{}
+	}
+	p.current = append(p.current, word)
+	return nil
+}
+
+// wordExpandsPathnames reports whether the shell would expand the word just
+// read into other words: an unquoted *, ?, a bracket expression or a brace
+// expansion such as {a,b} or {1..3}.
+func (p *shellParser) wordExpandsPathnames(word string) bool {
+	if p.wordGlob {
+		return true
+	}
+	if p.wordBracket >= 0 && strings.Contains(word[p.wordBracket+1:], "]") {
+		return true
+	}
+	if p.wordBrace >= 0 {
+		inner, _, closed := strings.Cut(word[p.wordBrace+1:], "}")
+		if closed && (strings.Contains(inner, ",") || strings.Contains(inner, "..")) {
+			return true
+		}
+	}
+	return false
+}
+
+// endCommand closes the current simple command at an operator. needNext
+// marks operators (|, &&, ||) that must be followed by another command.
+func (p *shellParser) endCommand(feature string, needNext bool) error {
+	if err := p.endWord(); err != nil {
+		return err
+	}
+	if p.pendingRedirect {
+		return syntaxError("redirection without target")
+	}
+	if len(p.current) == 0 {
+		return syntaxError("operator without preceding command")
+	}
+	p.commands = append(p.commands, p.current)
+	p.current = nil
+	p.features[feature] = struct{}{}
+	p.needCommand = needNext
+	return nil
+}
+
+func (p *shellParser) redirect() error {
+	// A bare number right before the operator is a file descriptor.
+	if p.inWord && !p.wordQuoted && isAllDigits(p.word.String()) {
+		p.word.Reset()
+		p.inWord = false
+	}
+	if err := p.endWord(); err != nil {
+		return err
+	}
+	if p.pendingRedirect {
+		return syntaxError("redirection without target")
+	}
+	op := p.src[p.pos]
+	p.pos++
+	switch next := p.peek(0); {
+	case op == '>' && (next == '>' || next == '|' || next == '&'):
+		p.pos++
+	case op == '<' && (next == '&' || next == '>'):
+		p.pos++
+	case op == '<' && next == '<':
+		p.pos++
+		p.pendingHeredoc, p.stripTabs = true, false
+		if p.peek(0) == '-' {
+			p.pendingHeredoc, p.stripTabs = true, true
+			p.pos++
+		}
+	}
+	p.pendingRedirect = true
+	p.features[shellSyntaxRedirect] = struct{}{}
+	return nil
+}
+
+// heredocBodies consumes the here-document bodies queued on the line that
+// just ended, so their text is not read as commands. Bodies of unquoted
+// delimiters still expand, so their substitutions are parsed like those in a
+// double-quoted word.
+func (p *shellParser) heredocBodies() error {
+	for _, heredoc := range p.heredocs {
+		start := p.pos
+		terminated := false
+		for p.pos < len(p.src) {
+			lineEnd := p.pos
+			for lineEnd < len(p.src) && p.src[lineEnd] != '\n' {
+				lineEnd++
+			}
+			line := string(p.src[p.pos:lineEnd])
+			bodyEnd := p.pos
+			p.pos = min(lineEnd+1, len(p.src))
+			if heredoc.stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == heredoc.delimiter {
+				if !heredoc.quoted {
+					if err := p.heredocExpansions(p.src[start:bodyEnd]); err != nil {
+						return err
+					}
+				}
+				terminated = true
+				break
+			}
+		}
+		if !terminated {
+			return syntaxError("unterminated here-document")
+		}
+	}
+	p.heredocs = nil
+	return nil
+}
+
+func (p *shellParser) heredocExpansions(body []rune) error {
+	sub := newShellParser(body, p.depth, p.features)
+	for sub.pos < len(sub.src) {
+		var err error
+		switch sub.src[sub.pos] {
+		case '\\':
+			sub.pos += 2
+		case '$':
+			if next := sub.peek(1); next == '\'' || next == '"' {
+				sub.pos++
+				continue
+			}
+			err = sub.dollar()
+		case '`':
+			err = sub.backtick()
+		default:
+			sub.pos++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	p.commands = append(p.commands, sub.commands...)
+	return nil
+}
+
+func (p *shellParser) singleQuoted() error {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '\'' {
+		p.word.WriteRune(p.src[p.pos])
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		p.pos = start
+		return syntaxError("unterminated single quote")
+	}
+	p.pos++
+	p.inWord, p.wordQuoted = true, true
+	return nil
+}
+
+func (p *shellParser) doubleQuoted() error {
+	p.pos++
+	p.inWord, p.wordQuoted = true, true
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		switch r {
+		case '"':
+			p.pos++
+			return nil
+		case '\\':
+			next := p.peek(1)
+			switch next {
+			case '$', '`', '"', '\\':
+				p.word.WriteRune(next)
+				p.pos += 2
+			case '\n':
+				p.pos += 2
+			default:
+				p.word.WriteRune(r)
+				p.pos++
+			}
+		case '$':
+			if next := p.peek(1); next == '\'' || next == '"' {
+				// ANSI-C and locale quoting do not apply inside double
+				// quotes.
+				p.word.WriteRune(r)
+				p.pos++
+				continue
+			}
+			if err := p.dollar(); err != nil {
+				return err
+			}
+		case '`':
+			if err := p.backtick(); err != nil {
+				return err
+			}
+		default:
+			p.word.WriteRune(r)
+			p.pos++
+		}
+	}
+	return syntaxError("unterminated double quote")
+}
+
+func (p *shellParser) dollar() error {
+	start := p.pos
+	next := p.peek(1)
+	switch {
+	case next == '(' && p.peek(2) == '(':
+		end, err := p.scanClosing(p.pos+3, '(', ')')
+		if err != nil {
+			return err
+		}
+		if end+1 >= len(p.src) || p.src[end+1] != ')' {
+			return syntaxError("unterminated arithmetic expansion")
+		}
+		if err := rejectNestedCommands(p.src[p.pos+3 : end]); err != nil {
+			return err
+		}
+		p.pos = end + 2
+		p.features[shellSyntaxExpansion] = struct{}{}
+	case next == '(':
+		end, err := p.scanClosing(p.pos+2, '(', ')')
+		if err != nil {
+			return err
+		}
+		if err := p.nested(string(p.src[p.pos+2 : end])); err != nil {
+			return err
+		}
+		p.pos = end + 1
+		p.features[shellSyntaxSubstitution] = struct{}{}
+	case next == '{':
+		end, err := p.scanClosing(p.pos+2, '{', '}')
+		if err != nil {
+			return err
+		}
+		if err := rejectNestedCommands(p.src[p.pos+2 : end]); err != nil {
+			return err
+		}
+		p.pos = end + 1
+		p.features[shellSyntaxExpansion] = struct{}{}
+	case next == '\'':
+		// $'...' decodes escapes such as \x2f, so the word is not the
+		// literal text a rule would match.
+		end := p.pos + 2
+		for end < len(p.src) && p.src[end] != '\'' {
+			if p.src[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(p.src) {
+			return syntaxError("unterminated ANSI-C quote")
+		}
+		p.pos = end + 1
+		p.wordQuoted = true
+		p.features[shellSyntaxExpansion] = struct{}{}
+	case next == '"':
+		// $"..." is a locale-translated string, read like "...".
+		p.pos++
+		return p.doubleQuoted()
+	case isShellNameStart(next):
+		p.pos++
+		for p.pos < len(p.src) && isShellNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+		p.features[shellSyntaxExpansion] = struct{}{}
+	case next != 0 && strings.ContainsRune("0123456789@*#?$!-", next):
+		p.pos += 2
+		p.features[shellSyntaxExpansion] = struct{}{}
+	default:
+		p.pos++
+	}
+	p.inWord = true
+	p.word.WriteString(string(p.src[start:p.pos]))
+	return nil
+}
+
+func (p *shellParser) backtick() error {
+	start := p.pos
+	var inner strings.Builder
+	p.pos++
+	for p.pos < len(p.src) {
+		r := p.src[p.pos]
+		if r == '`' {
+			p.pos++
+			if err := p.nested(inner.String()); err != nil {
+				return err
+			}
+			p.features[shellSyntaxSubstitution] = struct{}{}
+			p.inWord = true
+			p.word.WriteString(string(p.src[start:p.pos]))
+			return nil
+		}
+		if r == '\\' && strings.ContainsRune("`$\\", p.peek(1)) {
+			inner.WriteRune(p.peek(1))
+			p.pos += 2
+			continue
+		}
+		inner.WriteRune(r)
+		p.pos++
+	}
+	return syntaxError("unterminated command substitution")
+}
+
+// nested parses the body of a command substitution; its commands run too.
+func (p *shellParser) nested(body string) error {
+	if p.depth+1 > maxShellParseDepth {
+		return syntaxError("command substitution nested too deeply")
+	}
+	sub := newShellParser([]rune(body), p.depth+1, p.features)
+	if err := sub.parse(); err != nil {
+		return err
+	}
+	p.commands = append(p.commands, sub.commands...)
+	return nil
+}
+
+// scanClosing returns the index of the close rune matching an already
+// consumed open rune, skipping quoted text.
+func (p *shellParser) scanClosing(from int, open rune, close rune) (int, error) {
+	depth := 1
+	for i := from; i < len(p.src); i++ {
+		switch r := p.src[i]; r {
+		case '\\':
+			i++
+		case '\'':
+			for i++; i < len(p.src) && p.src[i] != '\''; i++ {
+			}
+		case '"':
+			for i++; i < len(p.src) && p.src[i] != '"'; i++ {
+				if p.src[i] == '\\' {
+					i++
+				}
+			}
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, syntaxError("unterminated %c", open)
+}
+
+func rejectNestedCommands(body []rune) error {
+	text := string(body)
+	if strings.Contains(text, "$(") || strings.ContainsRune(text, '`') {
+		return syntaxError("command substitution inside parameter expansion is not supported")
+	}
+	return nil
+}
+
+func isShellAssignment(word string) bool {
+	name, _, found := strings.Cut(word, "=")
+	if !found || name == "" || !isShellNameStart(rune(name[0])) {
+		return false
+	}
+	for _, r := range name {
+		if !isShellNameChar(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isShellNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isShellNameChar(r rune) bool {
+	return isShellNameStart(r) || (r >= '0' && r <= '9')
+}
+
+func isAllDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
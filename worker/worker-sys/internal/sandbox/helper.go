@@ -0,0 +1,113 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// IsHelper reports whether the current process was started by Command as
+// the sandbox helper. main must call RunHelper before anything else when
+// it returns true.
+func IsHelper() bool {
+	return len(os.Args) > 0 && filepath.Base(os.Args[0]) == helperArg0
+}
+
+// RunHelper applies the spec received on fd 3 to the current process and
+// replaces it with the target command. It never returns; setup failures
+// exit with HelperExitCode.
+func RunHelper() {
+	if err := runHelper(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s%v\n", helperErrorPrefix, err)
+		os.Exit(HelperExitCode)
+	}
+	os.Exit(0)
+}
+
+func runHelper() error {
+	specFile := os.NewFile(helperSpecFD, "sandbox-spec")
+	if specFile == nil {
+		return fmt.Errorf("missing spec")
+	}
+	payload, err := io.ReadAll(specFile)
+	_ = specFile.Close()
+	if err != nil {
+		return fmt.Errorf("read spec: %w", err)
+	}
+	spec := helperSpec{}
+	if err := json.Unmarshal(payload, &spec); err != nil {
+		return fmt.Errorf("decode spec: %w", err)
+	}
+
+	// A probe only checks that the namespaces could be created; mount
+	// errors are configuration problems and must fail the real command.
+	if spec.Probe {
+		return nil
+	}
+	// Mounts need the helper's original privileges, so they come first.
+	if err := setupIsolation(spec.Isolation); err != nil {
+		return err
+	}
+	if len(spec.Argv) == 0 {
+		return fmt.Errorf("command is required")
+	}
+	if err := applyLimits(spec.Limits); err != nil {
+		return err
+	}
+	if spec.GID != unchangedCredValue {
+		if err := syscall.Setgroups([]int{spec.GID}); err != nil {
+			return fmt.Errorf("setgroups: %w", err)
+		}
+		if err := syscall.Setgid(spec.GID); err != nil {
+			return fmt.Errorf("setgid %d: %w", spec.GID, err)
+		}
+	}
+	if spec.UID != unchangedCredValue {
+		if err := syscall.Setuid(spec.UID); err != nil {
+			return fmt.Errorf("setuid %d: %w", spec.UID, err)
+		}
+	}
+	if spec.Dir != "" {
+		if err := os.Chdir(spec.Dir); err != nil {
+			return fmt.Errorf("chdir: %w", err)
+		}
+	}
+
+	// Resolve after dropping privileges so PATH lookup sees what the
+	// restricted user can execute.
+	path, err := exec.LookPath(spec.Argv[0])
+	if err != nil {
+		return err
+	}
+	if err := syscall.Exec(path, spec.Argv, os.Environ()); err != nil {
+		return fmt.Errorf("exec %s: %w", spec.Argv[0], err)
+	}
+	return nil
+}
+
+func applyLimits(limits Limits) error {
+	for _, limit := range []struct {
+		name     string
+		resource int
+		value    uint64
+	}{
+		{name: "cpu", resource: syscall.RLIMIT_CPU, value: limits.CPUSeconds},
+		{name: "as", resource: syscall.RLIMIT_AS, value: limits.AddressSpaceBytes},
+		{name: "fsize", resource: syscall.RLIMIT_FSIZE, value: limits.FileSizeBytes},
+		{name: "nofile", resource: syscall.RLIMIT_NOFILE, value: limits.OpenFiles},
+		{name: "nproc", resource: rlimitNProc, value: limits.Processes},
+	} {
+		if limit.value == 0 {
+			continue
+		}
+		rlimit := syscall.Rlimit{Cur: limit.value, Max: limit.value}
+		if err := syscall.Setrlimit(limit.resource, &rlimit); err != nil {
+			return fmt.Errorf("setrlimit %s: %w", limit.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+// Package sandbox runs computerUse commands under a restricted identity with
+// resource limits and, on Linux, optional cgroup v2 and namespace isolation.
+//
+// Restrictions that must be applied inside the child before the target
+// program starts (mounts, rlimits, dropping privileges) are handled by
+// re-executing the worker binary as a small helper; see RunHelper.
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/onlyboxes/onlyboxes/worker/worker-sys/internal/logging"
+)
+
+// HelperExitCode is the exit status reported when the helper fails to set
+// up the sandbox before the target command starts.
+const HelperExitCode = 125
+
+const (
+	helperArg0         = "onlyboxes-sandbox-exec"
+	helperSpecFD       = 3
+	helperErrorPrefix  = "onlyboxes sandbox: "
+	unchangedCredValue = -1
+)
+
+// Limits are setrlimit values applied to the command. Zero means unchanged.
+type Limits struct {
+	CPUSeconds        uint64
+	AddressSpaceBytes uint64
+	FileSizeBytes     uint64
+	OpenFiles         uint64
+	Processes         uint64
+}
+
+// Cgroup describes a per-command cgroup v2 created under Parent. Zero
+// limits are left at the kernel default.
+type Cgroup struct {
+	Parent      string
+	MemoryBytes int64
+	PidsMax     int64
+	CPUPercent  int
+}
+
+// CgroupUsage is a cumulative reading of a command cgroup. PeakMemoryBytes
+// is 0 on kernels without memory.peak (before Linux 5.19).
+type CgroupUsage struct {
+	CPUUsec         int64
+	PeakMemoryBytes int64
+}
+
+type Options struct {
+	// RunAsUser is a user name, "uid" or "uid:gid". Empty keeps the worker
+	// identity.
+	RunAsUser     string
+	WorkDir       string
+	Limits        Limits
+	Cgroup        Cgroup
+	PrivateTmp    bool
+	NoNetwork     bool
+	ReadOnlyPaths []string
+}
+
+// Sandbox builds restricted commands. A nil *Sandbox runs commands
+// unrestricted.
+type Sandbox struct {
+	uid        int
+	gid        int
+	workDir    string
+	limits     Limits
+	cgroup     Cgroup
+	useCgroup  bool
+	isolation  isolation
+	useHelper  bool
+	helperPath string
+}
+
+// isolation is the namespace part of Options after availability checks.
+type isolation struct {
+	PrivateTmp    bool     `json:"private_tmp,omitempty"`
+	NoNetwork     bool     `json:"no_network,omitempty"`
+	ReadOnlyPaths []string `json:"readonly_paths,omitempty"`
+}
+
+func (i isolation) enabled() bool {
+	return i.PrivateTmp || i.NoNetwork || len(i.ReadOnlyPaths) > 0
+}
+
+// helperSpec is sent to the helper on fd 3.
+type helperSpec struct {
+	Argv      []string  `json:"argv"`
+	UID       int       `json:"uid"`
+	GID       int       `json:"gid"`
+	Dir       string    `json:"dir,omitempty"`
+	Limits    Limits    `json:"limits"`
+	Isolation isolation `json:"isolation"`
+	Probe     bool      `json:"probe,omitempty"`
+}
+
+// New validates opts and checks which restrictions the host supports.
+// Namespace isolation and cgroups degrade to disabled with a warning when
+// unavailable; an unusable identity or working directory is an error.
+func New(opts Options) (*Sandbox, error) {
+	s := &Sandbox{
+		uid:     unchangedCredValue,
+		gid:     unchangedCredValue,
+		workDir: strings.TrimSpace(opts.WorkDir),
+		limits:  opts.Limits,
+		cgroup:  opts.Cgroup,
+		isolation: isolation{
+			PrivateTmp:    opts.PrivateTmp,
+			NoNetwork:     opts.NoNetwork,
+			ReadOnlyPaths: opts.ReadOnlyPaths,
+		},
+	}
+
+	if raw := strings.TrimSpace(opts.RunAsUser); raw != "" {
+		uid, gid, err := resolveUser(raw)
+		if err != nil {
+			return nil, err
+		}
+		if uid != os.Geteuid() && os.Geteuid() != 0 {
+			return nil, fmt.Errorf("running commands as %q requires the worker to run as root", raw)
+		}
+		s.uid, s.gid = uid, gid
+	}
+	if s.workDir != "" {
+		info, err := os.Stat(s.workDir)
+		if err != nil {
+			return nil, fmt.Errorf("working directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("working directory %q is not a directory", s.workDir)
+		}
+	}
+
+	s.useHelper = s.uid != unchangedCredValue || s.limits != (Limits{}) || s.isolation.enabled()
+	if s.useHelper {
+		helperPath, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("locate worker binary for sandbox helper: %w", err)
+		}
+		s.helperPath = helperPath
+	}
+
+	if s.isolation.enabled() {
+		if err := s.probeIsolation(); err != nil {
+			logging.Warnf("computerUse namespace isolation is unavailable, running without it: %v", err)
+			s.isolation = isolation{}
+			s.useHelper = s.uid != unchangedCredValue || s.limits != (Limits{})
+		}
+	}
+	if strings.TrimSpace(s.cgroup.Parent) != "" {
+		if err := probeCgroup(s.cgroup); err != nil {
+			logging.Warnf("computerUse cgroup limits are unavailable, running without them: %v", err)
+		} else {
+			s.useCgroup = true
+		}
+	}
+	return s, nil
+}
+
+// Summary describes the active restrictions for startup logs.
+func (s *Sandbox) Summary() string {
+	if s == nil {
+		return "none"
+	}
+	parts := []string{}
+	if s.uid != unchangedCredValue {
+		parts = append(parts, fmt.Sprintf("uid=%d gid=%d", s.uid, s.gid))
+	}
+	if s.workDir != "" {
+		parts = append(parts, "workdir="+s.workDir)
+	}
+	if s.limits != (Limits{}) {
+		parts = append(parts, "rlimits")
+	}
+	if s.useCgroup {
+		parts = append(parts, "cgroup="+s.cgroup.Parent)
+	}
+	if s.isolation.PrivateTmp {
+		parts = append(parts, "private_tmp")
+	}
+	if s.isolation.NoNetwork {
+		parts = append(parts, "no_network")
+	}
+	if len(s.isolation.ReadOnlyPaths) > 0 {
+		parts = append(parts, fmt.Sprintf("readonly_paths=%d", len(s.isolation.ReadOnlyPaths)))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Command returns a command that runs argv inside the sandbox. The caller
+// must call cleanup once the command has finished (or failed to start).
+func (s *Sandbox) Command(ctx context.Context, argv []string) (*exec.Cmd, func(), error) {
+	cmd, _, cleanup, err := s.CommandWithCgroup(ctx, argv)
+	return cmd, cleanup, err
+}
+
+// CommandWithCgroup is Command that also returns the directory of the
+// command's cgroup, or "" when cgroups are not in use. The directory is
+// removed by cleanup.
+func (s *Sandbox) CommandWithCgroup(ctx context.Context, argv []string) (*exec.Cmd, string, func(), error) {
+	if len(argv) == 0 {
+		return nil, "", nil, errors.New("command is required")
+	}
+	if s == nil || (!s.useHelper && !s.useCgroup) {
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		if s != nil {
+			cmd.Dir = s.workDir
+		}
+		return cmd, "", func() {}, nil
+	}
+
+	var cmd *exec.Cmd
+	var cleanups []func()
+	cleanup := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+	if s.useHelper {
+		helperCmd, closeSpec, err := s.helperCommand(ctx, helperSpec{
+			Argv:      argv,
+			UID:       s.uid,
+			GID:       s.gid,
+			Dir:       s.workDir,
+			Limits:    s.limits,
+			Isolation: s.isolation,
+		})
+		if err != nil {
+			return nil, "", nil, err
+		}
+		cmd = helperCmd
+		cleanups = append(cleanups, closeSpec)
+	} else {
+		cmd = exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Dir = s.workDir
+	}
+	cgroupDir := ""
+	if s.useCgroup {
+		dir, removeCgroup, err := attachCgroup(cmd, s.cgroup)
+		if err != nil {
+			cleanup()
+			return nil, "", nil, fmt.Errorf("create command cgroup: %w", err)
+		}
+		cgroupDir = dir
+		cleanups = append(cleanups, removeCgroup)
+	}
+	return cmd, cgroupDir, cleanup, nil
+}
+
+func (s *Sandbox) helperCommand(ctx context.Context, spec helperSpec) (*exec.Cmd, func(), error) {
+	payload, err := json.Marshal(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	// The spec is far smaller than a pipe buffer, so it can be written
+	// before the helper starts.
+	_, err = writer.Write(payload)
+	closeErr := writer.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = reader.Close()
+		return nil, nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, s.helperPath)
+	cmd.Args = []string{helperArg0}
+	cmd.ExtraFiles = []*os.File{reader}
+	if err := configureIsolation(cmd, spec.Isolation); err != nil {
+		_ = reader.Close()
+		return nil, nil, err
+	}
+	return cmd, func() { _ = reader.Close() }, nil
+}
+
+func (s *Sandbox) probeIsolation() error {
+	cmd, closeSpec, err := s.helperCommand(context.Background(), helperSpec{
+		UID:       unchangedCredValue,
+		GID:       unchangedCredValue,
+		Isolation: s.isolation,
+		Probe:     true,
+	})
+	if err != nil {
+		return err
+	}
+	defer closeSpec()
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
+
+func resolveUser(raw string) (int, int, error) {
+	name, group, hasGroup := strings.Cut(raw, ":")
+	uid, err := strconv.Atoi(name)
+	gid := unchangedCredValue
+	if err != nil {
+		account, lookupErr := user.Lookup(name)
+		if lookupErr != nil {
+			return 0, 0, fmt.Errorf("resolve run-as user %q: %w", name, lookupErr)
+		}
+		uid, _ = strconv.Atoi(account.Uid)
+		gid, _ = strconv.Atoi(account.Gid)
+	} else if account, lookupErr := user.LookupId(name); lookupErr == nil {
+		gid, _ = strconv.Atoi(account.Gid)
+	}
+	if hasGroup {
+		parsed, err := strconv.Atoi(group)
+		if err != nil {
+			account, lookupErr := user.LookupGroup(group)
+			if lookupErr != nil {
+				return 0, 0, fmt.Errorf("resolve run-as group %q: %w", group, lookupErr)
+			}
+			parsed, _ = strconv.Atoi(account.Gid)
+		}
+		gid = parsed
+	}
+	if uid < 0 {
+		return 0, 0, fmt.Errorf("invalid run-as user %q", raw)
+	}
+	if gid < 0 {
+		// A bare numeric uid without a passwd entry runs with the same gid.
+		gid = uid
+	}
+	return uid, gid, nil
+}
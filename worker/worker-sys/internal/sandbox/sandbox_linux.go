@@ -0,0 +1,281 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	rlimitNProc          = 6
+	cgroupCPUPeriodUsec  = 100000
+	cgroupRemoveAttempts = 20
+	cgroupRemoveBackoff  = 10 * time.Millisecond
+)
+
+// configureIsolation requests the namespaces the helper will be started in.
+// A non-root worker additionally gets a user namespace that maps only its
+// own uid/gid, which is enough to set up private mounts.
+func configureIsolation(cmd *exec.Cmd, spec isolation) error {
+	if !spec.enabled() {
+		return nil
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	var flags uintptr
+	if spec.PrivateTmp || len(spec.ReadOnlyPaths) > 0 {
+		flags |= syscall.CLONE_NEWNS
+	}
+	if spec.NoNetwork {
+		flags |= syscall.CLONE_NEWNET
+	}
+	if os.Geteuid() != 0 {
+		flags |= syscall.CLONE_NEWUSER
+		cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Geteuid(), HostID: os.Geteuid(), Size: 1}}
+		cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getegid(), HostID: os.Getegid(), Size: 1}}
+	}
+	cmd.SysProcAttr.Cloneflags = flags
+	return nil
+}
+
+// setupIsolation runs inside the helper, already in its own namespaces.
+// The private /tmp is mounted first, so read-only paths below /tmp are
+// hidden by it rather than exposed.
+func setupIsolation(spec isolation) error {
+	if !spec.PrivateTmp && len(spec.ReadOnlyPaths) == 0 {
+		return nil
+	}
+	// Keep the mounts below from propagating back to the host.
+	if err := syscall.Mount("none", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if spec.PrivateTmp {
+		if err := syscall.Mount("tmpfs", "/tmp", "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=1777"); err != nil {
+			return fmt.Errorf("mount private /tmp: %w", err)
+		}
+	}
+	for _, path := range spec.ReadOnlyPaths {
+		if err := syscall.Mount(path, path, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return fmt.Errorf("bind %s: %w", path, err)
+		}
+		if err := remountReadOnly(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mountFlagsByOption maps the per-mount options shown in mountinfo to the
+// flags a bind remount must repeat. Inside a user namespace the kernel locks
+// them, and a remount that drops one fails with EPERM.
+var mountFlagsByOption = map[string]uintptr{
+	"nosuid":     syscall.MS_NOSUID,
+	"nodev":      syscall.MS_NODEV,
+	"noexec":     syscall.MS_NOEXEC,
+	"noatime":    syscall.MS_NOATIME,
+	"nodiratime": syscall.MS_NODIRATIME,
+	"relatime":   syscall.MS_RELATIME,
+}
+
+type mountPoint struct {
+	path  string
+	flags uintptr
+}
+
+// remountReadOnly makes the recursive bind at path read-only. MS_RDONLY on a
+// bind remount only applies to the one mount, so every submount copied by
+// MS_REC is remounted as well.
+func remountReadOnly(path string) error {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return fmt.Errorf("read mountinfo: %w", err)
+	}
+	defer file.Close()
+	root := path
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		root = resolved
+	}
+	mounts, err := mountsBelow(file, root)
+	if err != nil {
+		return fmt.Errorf("read mountinfo: %w", err)
+	}
+	if len(mounts) == 0 {
+		return fmt.Errorf("remount %s read-only: bind mount not found", path)
+	}
+	for _, mount := range mounts {
+		flags := syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY | mount.flags
+		if err := syscall.Mount("none", mount.path, "", flags, ""); err != nil {
+			return fmt.Errorf("remount %s read-only: %w", mount.path, err)
+		}
+	}
+	return nil
+}
+
+// mountsBelow returns the mount points at or below root from a mountinfo
+// listing, parents first.
+func mountsBelow(r io.Reader, root string) ([]mountPoint, error) {
+	mounts := []mountPoint{}
+	seen := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// id parent major:minor root mount-point options ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		path := unescapeMountPath(fields[4])
+		if path != root && !strings.HasPrefix(path, strings.TrimSuffix(root, "/")+"/") {
+			continue
+		}
+		var flags uintptr
+		for _, option := range strings.Split(fields[5], ",") {
+			flags |= mountFlagsByOption[option]
+		}
+		// A later entry for the same path is stacked on top, and remounting
+		// the path reaches only that one.
+		if index, ok := seen[path]; ok {
+			mounts[index].flags = flags
+			continue
+		}
+		seen[path] = len(mounts)
+		mounts = append(mounts, mountPoint{path: path, flags: flags})
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for a space) mountinfo
+// uses in paths.
+func unescapeMountPath(raw string) string {
+	if !strings.Contains(raw, "\\") {
+		return raw
+	}
+	var out strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+3 < len(raw) {
+			if value, err := strconv.ParseUint(raw[i+1:i+4], 8, 8); err == nil {
+				out.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		out.WriteByte(raw[i])
+	}
+	return out.String()
+}
+
+// probeCgroup checks that parent is a writable cgroup v2 directory with the
+// controllers needed for the configured limits.
+func probeCgroup(cgroup Cgroup) error {
+	if _, err := os.Stat(filepath.Join(cgroup.Parent, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("%s is not a cgroup v2 directory: %w", cgroup.Parent, err)
+	}
+	dir, err := createCgroup(cgroup, "probe-")
+	if err != nil {
+		return err
+	}
+	return removeCgroup(dir)
+}
+
+func attachCgroup(cmd *exec.Cmd, cgroup Cgroup) (string, func(), error) {
+	dir, err := createCgroup(cgroup, "cmd-")
+	if err != nil {
+		return "", nil, err
+	}
+	handle, err := os.Open(dir)
+	if err != nil {
+		_ = removeCgroup(dir)
+		return "", nil, err
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(handle.Fd())
+	return dir, func() {
+		_ = handle.Close()
+		// Background processes left behind by the command would keep the
+		// cgroup busy; cgroup.kill (Linux 5.14+) ends them.
+		_ = os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0)
+		_ = removeCgroup(dir)
+	}, nil
+}
+
+// ReadCgroupUsage reads the CPU time and peak memory of a command cgroup
+// from its cpu.stat and memory.peak.
+func ReadCgroupUsage(dir string) (CgroupUsage, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return CgroupUsage{}, err
+	}
+	usage := CgroupUsage{}
+	found := false
+	for _, line := range strings.Split(string(content), "\n") {
+		if value, ok := strings.CutPrefix(line, "usage_usec "); ok {
+			usage.CPUUsec, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return CgroupUsage{}, fmt.Errorf("parse cpu.stat: %w", err)
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		return CgroupUsage{}, fmt.Errorf("cpu.stat in %s has no usage_usec", dir)
+	}
+	if peak, err := os.ReadFile(filepath.Join(dir, "memory.peak")); err == nil {
+		usage.PeakMemoryBytes, _ = strconv.ParseInt(strings.TrimSpace(string(peak)), 10, 64)
+	}
+	return usage, nil
+}
+
+func createCgroup(cgroup Cgroup, prefix string) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cgroup.Parent, prefix+hex.EncodeToString(suffix))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", err
+	}
+	limits := map[string]string{}
+	if cgroup.MemoryBytes > 0 {
+		limits["memory.max"] = strconv.FormatInt(cgroup.MemoryBytes, 10)
+	}
+	if cgroup.PidsMax > 0 {
+		limits["pids.max"] = strconv.FormatInt(cgroup.PidsMax, 10)
+	}
+	if cgroup.CPUPercent > 0 {
+		quota := cgroup.CPUPercent * cgroupCPUPeriodUsec / 100
+		limits["cpu.max"] = fmt.Sprintf("%d %d", quota, cgroupCPUPeriodUsec)
+	}
+	for name, value := range limits {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0); err != nil {
+			_ = removeCgroup(dir)
+			return "", fmt.Errorf("set %s: %w", name, err)
+		}
+	}
+	return dir, nil
+}
+
+func removeCgroup(dir string) error {
+	var err error
+	for attempt := 0; attempt < cgroupRemoveAttempts; attempt++ {
+		if err = os.Remove(dir); err == nil || os.IsNotExist(err) {
+			return nil
+		}
+		time.Sleep(cgroupRemoveBackoff)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestMountsBelowListsSubmounts(t *testing.T) {
+	mountinfo := strings.Join([]string{
+		"22 1 0:21 / / rw,relatime - ext4 /dev/root rw",
+		"30 22 0:25 / /srv rw,nosuid - ext4 /dev/sdb rw",
+		"31 30 0:26 / /srv/data rw,nosuid,nodev,noexec - tmpfs tmpfs rw",
+		"32 30 0:27 / /srv/my\\040dir rw - tmpfs tmpfs rw",
+		"33 22 0:28 / /srvx rw - tmpfs tmpfs rw",
+		"34 30 0:25 / /srv ro,noatime - ext4 /dev/sdb rw",
+	}, "\n")
+
+	mounts, err := mountsBelow(strings.NewReader(mountinfo), "/srv")
+	if err != nil {
+		t.Fatalf("parse mountinfo: %v", err)
+	}
+	expected := []mountPoint{
+		{path: "/srv", flags: syscall.MS_NOATIME},
+		{path: "/srv/data", flags: syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC},
+		{path: "/srv/my dir"},
+	}
+	if !reflect.DeepEqual(mounts, expected) {
+		t.Fatalf("unexpected mounts %#v", mounts)
+	}
+}
+
+func TestCommandMountsReadOnlySubmounts(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("mounting a submount requires root")
+	}
+	readOnly := t.TempDir()
+	submount := filepath.Join(readOnly, "sub")
+	if err := os.Mkdir(submount, 0o755); err != nil {
+		t.Fatalf("create submount dir: %v", err)
+	}
+	if err := syscall.Mount("tmpfs", submount, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		t.Skipf("mount tmpfs: %v", err)
+	}
+	t.Cleanup(func() { _ = syscall.Unmount(submount, syscall.MNT_DETACH) })
+	s := newIsolatedSandbox(t, Options{ReadOnlyPaths: []string{readOnly}})
+
+	output, code := runSandboxed(t, s, "/bin/sh", "-c", "touch "+submount+"/x 2>/dev/null || echo readonly")
+	if code != 0 || output != "readonly" {
+		t.Fatalf("expected submount to be read-only, got %q (exit %d)", output, code)
+	}
+	if err := os.WriteFile(filepath.Join(submount, "x"), nil, 0o600); err != nil {
+		t.Fatalf("expected host submount to stay writable: %v", err)
+	}
+}
+
+func TestReadCgroupUsage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cpu.stat"), []byte("usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n"), 0o644); err != nil {
+		t.Fatalf("write cpu.stat: %v", err)
+	}
+
+	usage, err := ReadCgroupUsage(dir)
+	if err != nil {
+		t.Fatalf("read usage: %v", err)
+	}
+	if usage.CPUUsec != 2500000 || usage.PeakMemoryBytes != 0 {
+		t.Fatalf("expected missing memory.peak to read as 0, got %#v", usage)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "memory.peak"), []byte("1048576\n"), 0o644); err != nil {
+		t.Fatalf("write memory.peak: %v", err)
+	}
+	usage, err = ReadCgroupUsage(dir)
+	if err != nil || usage.PeakMemoryBytes != 1048576 {
+		t.Fatalf("unexpected usage %#v err=%v", usage, err)
+	}
+
+	if _, err := ReadCgroupUsage(t.TempDir()); err == nil {
+		t.Fatalf("expected error without cpu.stat")
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// rlimitNProc is RLIMIT_NPROC on the BSD-derived platforms worker-sys
+// ships for; the syscall package does not export it there.
+const rlimitNProc = 7
+
+var errUnsupportedPlatform = errors.New("not supported on this platform")
+
+func configureIsolation(_ *exec.Cmd, spec isolation) error {
+	if spec.enabled() {
+		return errUnsupportedPlatform
+	}
+	return nil
+}
+
+func setupIsolation(spec isolation) error {
+	if spec.enabled() {
+		return errUnsupportedPlatform
+	}
+	return nil
+}
+
+func probeCgroup(Cgroup) error {
+	return errUnsupportedPlatform
+}
+
+func attachCgroup(*exec.Cmd, Cgroup) (string, func(), error) {
+	return "", nil, errUnsupportedPlatform
+}
+
+func ReadCgroupUsage(string) (CgroupUsage, error) {
+	return CgroupUsage{}, errUnsupportedPlatform
+}
@@ -0,0 +1,188 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestMain lets the test binary act as the sandbox helper, the same way the
+// worker binary does in production.
+func TestMain(m *testing.M) {
+	if IsHelper() {
+		RunHelper()
+	}
+	os.Exit(m.Run())
+}
+
+func runSandboxed(t *testing.T, s *Sandbox, argv ...string) (string, int) {
+	t.Helper()
+	cmd, cleanup, err := s.Command(context.Background(), argv)
+	if err != nil {
+		t.Fatalf("build command: %v", err)
+	}
+	defer cleanup()
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("run %v: %v", argv, err)
+	}
+	return strings.TrimSpace(string(output)), cmd.ProcessState.ExitCode()
+}
+
+func TestCommandWithoutRestrictionsRunsDirectly(t *testing.T) {
+	s, err := New(Options{})
+	if err != nil {
+		t.Fatalf("new sandbox: %v", err)
+	}
+	cmd, cleanup, err := s.Command(context.Background(), []string{"/bin/sh", "-c", "echo hi"})
+	if err != nil {
+		t.Fatalf("build command: %v", err)
+	}
+	defer cleanup()
+	if cmd.Args[0] == helperArg0 {
+		t.Fatalf("expected no helper without restrictions")
+	}
+	if s.Summary() != "none" {
+		t.Fatalf("unexpected summary %q", s.Summary())
+	}
+}
+
+func TestCommandAppliesWorkDirAndLimits(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(Options{WorkDir: dir, Limits: Limits{OpenFiles: 64}})
+	if err != nil {
+		t.Fatalf("new sandbox: %v", err)
+	}
+	output, code := runSandboxed(t, s, "/bin/sh", "-c", "pwd; ulimit -n")
+	resolved, _ := filepath.EvalSymlinks(dir)
+	if code != 0 || output != resolved+"\n64" {
+		t.Fatalf("unexpected output %q (exit %d)", output, code)
+	}
+}
+
+func TestCommandReportsHelperFailures(t *testing.T) {
+	s, err := New(Options{Limits: Limits{OpenFiles: 64}})
+	if err != nil {
+		t.Fatalf("new sandbox: %v", err)
+	}
+	output, code := runSandboxed(t, s, "definitely-not-a-command")
+	if code != HelperExitCode || !strings.HasPrefix(output, helperErrorPrefix) {
+		t.Fatalf("expected helper failure, got %q (exit %d)", output, code)
+	}
+}
+
+func TestNewRejectsUnusableOptions(t *testing.T) {
+	if _, err := New(Options{WorkDir: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Fatalf("expected missing working directory to be rejected")
+	}
+	if _, err := New(Options{RunAsUser: "no-such-user-onlyboxes"}); err == nil {
+		t.Fatalf("expected unknown user to be rejected")
+	}
+	if os.Geteuid() != 0 {
+		if _, err := New(Options{RunAsUser: "0"}); err == nil {
+			t.Fatalf("expected switching user without root to be rejected")
+		}
+	}
+}
+
+func TestCommandRunsAsConfiguredUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching user requires root")
+	}
+	s, err := New(Options{RunAsUser: "65534:65534"})
+	if err != nil {
+		t.Fatalf("new sandbox: %v", err)
+	}
+	output, code := runSandboxed(t, s, "/bin/sh", "-c", "id -u; id -g; id -G")
+	if code != 0 || output != "65534\n65534\n65534" {
+		t.Fatalf("unexpected identity %q (exit %d)", output, code)
+	}
+}
+
+func newIsolatedSandbox(t *testing.T, opts Options) *Sandbox {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("namespace isolation is linux only")
+	}
+	s, err := New(opts)
+	if err != nil {
+		t.Fatalf("new sandbox: %v", err)
+	}
+	if !s.isolation.enabled() {
+		t.Skip("namespaces are unavailable on this host")
+	}
+	return s
+}
+
+func TestCommandIsolatesTmpAndNetwork(t *testing.T) {
+	s := newIsolatedSandbox(t, Options{PrivateTmp: true, NoNetwork: true})
+
+	marker := "onlyboxes-sandbox-marker"
+	output, code := runSandboxed(t, s, "/bin/sh", "-c", "touch /tmp/"+marker+" && ls /tmp; grep -c : /proc/net/dev")
+	// Only the marker is visible in the private /tmp and only loopback
+	// exists in the new network namespace.
+	if code != 0 || output != marker+"\n1" {
+		t.Fatalf("unexpected isolated output %q (exit %d)", output, code)
+	}
+	if _, err := os.Stat(filepath.Join("/tmp", marker)); !os.IsNotExist(err) {
+		t.Fatalf("expected marker to stay inside the private /tmp, stat err=%v", err)
+	}
+}
+
+func TestCommandMountsReadOnlyPaths(t *testing.T) {
+	readOnly := t.TempDir()
+	s := newIsolatedSandbox(t, Options{ReadOnlyPaths: []string{readOnly}})
+
+	output, code := runSandboxed(t, s, "/bin/sh", "-c", "touch "+readOnly+"/x 2>/dev/null || echo readonly")
+	if code != 0 || output != "readonly" {
+		t.Fatalf("expected read-only bind mount, got %q (exit %d)", output, code)
+	}
+	if err := os.WriteFile(filepath.Join(readOnly, "x"), nil, 0o600); err != nil {
+		t.Fatalf("expected host path to stay writable: %v", err)
+	}
+
+	missing := newIsolatedSandbox(t, Options{ReadOnlyPaths: []string{filepath.Join(readOnly, "missing")}})
+	if output, code := runSandboxed(t, missing, "true"); code != HelperExitCode {
+		t.Fatalf("expected missing read-only path to fail the command, got %q (exit %d)", output, code)
+	}
+}
+
+func TestNewDisablesUnavailableCgroup(t *testing.T) {
+	s, err := New(Options{Cgroup: Cgroup{Parent: t.TempDir(), PidsMax: 8}})
+	if err != nil {
+		t.Fatalf("new sandbox: %v", err)
+	}
+	if s.useCgroup {
+		t.Fatalf("expected a plain directory not to be used as a cgroup")
+	}
+	if output, code := runSandboxed(t, s, "/bin/sh", "-c", "echo ok"); code != 0 || output != "ok" {
+		t.Fatalf("expected command to run without cgroup, got %q (exit %d)", output, code)
+	}
+}
+
+func TestHelperPassesOnlyCommandEnv(t *testing.T) {
+	t.Setenv("WORKER_SECRET", "top-secret-value")
+	s, err := New(Options{Limits: Limits{OpenFiles: 64}})
+	if err != nil {
+		t.Fatalf("new sandbox: %v", err)
+	}
+	cmd, cleanup, err := s.Command(context.Background(), []string{"/usr/bin/env"})
+	if err != nil {
+		t.Fatalf("build command: %v", err)
+	}
+	defer cleanup()
+	cmd.Env = []string{"PATH=/usr/bin:/bin", "ONLYBOXES_TEST=1"}
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("run env: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "PATH=/usr/bin:/bin\nONLYBOXES_TEST=1" {
+		t.Fatalf("expected only the command env, got %q", got)
+	}
+}